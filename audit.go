@@ -0,0 +1,61 @@
+package jsonpath
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Auditor defines the interface for audit trails of the normalized paths
+// of the nodes selected by a [Path], but not the nodes themselves.
+// Configure one with [WithAuditor]. See [spec.Auditor] for details.
+type Auditor = spec.Auditor
+
+// WithAuditor configures a [Parser] to return [Path] values that report
+// the normalized paths of the nodes selected by each call to Select and
+// its variants to auditor, for compliance audit trails of the fields read
+// from sensitive documents. Paths call auditor from every goroutine that
+// selects with them, so it must be safe for concurrent use. Use
+// [AuditLogger] to log the paths.
+//
+// Paths configured with an Auditor must compute the normalized paths of
+// the nodes they select, so [Path.Select] and [Path.TrySelect] become as
+// costly as [Path.SelectLocated], which is cheap enough for most
+// production use. See [spec.Auditor] for details.
+func WithAuditor(auditor Auditor) Option {
+	return func(p *Parser) { p.opts.Auditor = auditor }
+}
+
+// AuditLogger is an [Auditor] that logs the query and normalized paths of
+// each selection to a [slog.Logger]. It formats the paths only if the
+// logger is enabled for its level, so it costs little when disabled. It's
+// safe for concurrent use.
+type AuditLogger struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// NewAuditLogger creates an [AuditLogger] that logs to logger at level.
+func NewAuditLogger(logger *slog.Logger, level slog.Level) *AuditLogger {
+	return &AuditLogger{logger: logger, level: level}
+}
+
+// Audit logs the message "jsonpath: audit" with the attributes "query",
+// the string representation of query, and "paths", the string
+// representations of paths. Defined by [Auditor].
+func (l *AuditLogger) Audit(query *spec.PathQuery, paths []spec.NormalizedPath) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, l.level) {
+		return
+	}
+	strs := make([]string, len(paths))
+	for i, p := range paths {
+		strs[i] = p.String()
+	}
+	l.logger.LogAttrs(
+		ctx, l.level, "jsonpath: audit",
+		slog.String("query", query.String()),
+		slog.Any("paths", strs),
+	)
+}
@@ -0,0 +1,60 @@
+package jsonpath
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogger(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))
+	input := map[string]any{
+		"users": []any{
+			map[string]any{"name": "Alice", "ssn": "123"},
+			map[string]any{"name": "Bob"},
+		},
+	}
+
+	// Should log the paths selected but not the values.
+	path := NewParser(WithAuditor(NewAuditLogger(logger, slog.LevelInfo))).
+		MustParse(`$.users[?@.ssn].name`)
+	a.Equal(NodeList{"Alice"}, path.Select(input))
+	a.Equal(
+		`level=INFO msg="jsonpath: audit" query="$[\"users\"][?@[\"ssn\"]][\"name\"]" paths=[$['users'][0]['name']]`+"\n",
+		buf.String(),
+	)
+
+	// Should log once per selection.
+	buf.Reset()
+	nodes, err := path.TrySelectLocated(input)
+	r.NoError(err)
+	a.Len(nodes, 1)
+	a.Equal(NodeList{"Alice", "Bob"}, NewParser(WithAuditor(NewAuditLogger(logger, slog.LevelInfo))).
+		MustParse(`$..name`).Select(input))
+	a.Equal(
+		`level=INFO msg="jsonpath: audit" query="$[\"users\"][?@[\"ssn\"]][\"name\"]" paths=[$['users'][0]['name']]`+"\n"+
+			`level=INFO msg="jsonpath: audit" query="$..[\"name\"]" paths="[$['users'][0]['name'] $['users'][1]['name']]"`+"\n",
+		buf.String(),
+	)
+
+	// Should not log below the logger's level.
+	buf.Reset()
+	path = NewParser(WithAuditor(NewAuditLogger(logger, slog.LevelDebug))).MustParse(`$.users[*].name`)
+	a.Equal(NodeList{"Alice", "Bob"}, path.Select(input))
+	a.Empty(buf.String())
+}
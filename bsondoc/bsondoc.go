@@ -0,0 +1,127 @@
+// Package bsondoc executes RFC 9535 JSONPath queries against MongoDB
+// documents decoded into [bson.D], [bson.M], and [bson.A] values, preserving
+// the member order of [bson.D] documents for wildcard and descendant
+// segments.
+package bsondoc
+
+import (
+	"iter"
+
+	"github.com/theory/jsonpath"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	_ jsonpath.Object = Document{}
+	_ jsonpath.Object = Map{}
+	_ jsonpath.Array  = Array{}
+)
+
+// Value returns val for use in a JSONPath query. It converts [bson.D] values
+// to [Document], [bson.M] values to [Map], and [bson.A] values to [Array].
+// Returns any other value, including [bson.ObjectID] and other BSON scalar
+// types, unchanged.
+func Value(val any) any {
+	switch val := val.(type) {
+	case bson.D:
+		return Document{val}
+	case bson.M:
+		return Map{val}
+	case bson.A:
+		return Array{val}
+	default:
+		return val
+	}
+}
+
+// Document wraps a [bson.D] to implement [jsonpath.Object]. Queries return
+// Document values for selected documents; use the embedded D to access the
+// original value.
+type Document struct {
+	bson.D
+}
+
+// Get returns the value of the first element with the key name. Defined by
+// [jsonpath.Object].
+func (d Document) Get(name string) (any, bool) {
+	for _, e := range d.D {
+		if e.Key == name {
+			return Value(e.Value), true
+		}
+	}
+	return nil, false
+}
+
+// Len returns the number of elements in d. Defined by [jsonpath.Object].
+func (d Document) Len() int { return len(d.D) }
+
+// Iterate returns an iterator over the keys and values of the elements in
+// d, in document order. Defined by [jsonpath.Object].
+func (d Document) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, e := range d.D {
+			if !yield(e.Key, Value(e.Value)) {
+				return
+			}
+		}
+	}
+}
+
+// Map wraps a [bson.M] to implement [jsonpath.Object]. Queries return Map
+// values for selected maps; use the embedded M to access the original value.
+type Map struct {
+	bson.M
+}
+
+// Get returns the value for the key name. Defined by [jsonpath.Object].
+func (m Map) Get(name string) (any, bool) {
+	if v, ok := m.M[name]; ok {
+		return Value(v), true
+	}
+	return nil, false
+}
+
+// Len returns the number of keys in m. Defined by [jsonpath.Object].
+func (m Map) Len() int { return len(m.M) }
+
+// Iterate returns an iterator over the keys and values of m. Like map
+// iteration, the order is not specified. Defined by [jsonpath.Object].
+func (m Map) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for k, v := range m.M {
+			if !yield(k, Value(v)) {
+				return
+			}
+		}
+	}
+}
+
+// Array wraps a [bson.A] to implement [jsonpath.Array]. Queries return Array
+// values for selected arrays; use the embedded A to access the original
+// value.
+type Array struct {
+	bson.A
+}
+
+// Get returns the value at index. Defined by [jsonpath.Array].
+func (a Array) Get(index int) (any, bool) {
+	if index < 0 || index >= len(a.A) {
+		return nil, false
+	}
+	return Value(a.A[index]), true
+}
+
+// Len returns the number of values in a. Defined by [jsonpath.Array].
+func (a Array) Len() int { return len(a.A) }
+
+// Iterate returns an iterator over the indexes and values of a. Defined by
+// [jsonpath.Array].
+func (a Array) Iterate() iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for i, v := range a.A {
+			if !yield(i, Value(v)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package bsondoc_test
+
+import (
+	"fmt"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/bsondoc"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Select values from an ordered BSON document in document order.
+func ExampleValue() {
+	doc := bson.D{
+		{Key: "name", Value: "widget"},
+		{Key: "sizes", Value: bson.A{"S", "M", "L"}},
+		{Key: "color", Value: "blue"},
+	}
+
+	path := jsonpath.MustParse(`$.*`)
+	for p := range path.SelectLocated(bsondoc.Value(doc)).Paths() {
+		fmt.Println(p)
+	}
+	// Output:
+	// $['name']
+	// $['sizes']
+	// $['color']
+}
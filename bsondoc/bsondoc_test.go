@@ -0,0 +1,136 @@
+package bsondoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	id := bson.NewObjectID()
+	raw, err := bson.Marshal(bson.D{
+		{Key: "_id", Value: id},
+		{Key: "z", Value: "last"},
+		{Key: "a", Value: "first"},
+		{Key: "items", Value: bson.A{
+			bson.D{{Key: "sku", Value: "x"}, {Key: "qty", Value: int32(2)}},
+			bson.D{{Key: "sku", Value: "y"}, {Key: "qty", Value: int64(7)}},
+		}},
+		{Key: "meta", Value: bson.M{"tag": "m"}},
+	})
+	require.NoError(t, err)
+	var doc bson.D
+	require.NoError(t, bson.Unmarshal(raw, &doc))
+
+	for _, tc := range []struct {
+		test    string
+		path    string
+		exp     []any
+		ordered bool
+	}{
+		{
+			test:    "wildcard_order",
+			path:    `$[?@ == 'last' || @ == 'first']`,
+			exp:     []any{"last", "first"},
+			ordered: true,
+		},
+		{
+			test: "object_id",
+			path: `$._id`,
+			exp:  []any{id},
+		},
+		{
+			test: "index",
+			path: `$.items[1].sku`,
+			exp:  []any{"y"},
+		},
+		{
+			test: "filter_ints",
+			path: `$.items[?@.qty > 5].sku`,
+			exp:  []any{"y"},
+		},
+		{
+			test:    "descendant_order",
+			path:    `$..sku`,
+			exp:     []any{"x", "y"},
+			ordered: true,
+		},
+		{
+			test: "nested_document",
+			path: `$.meta.tag`,
+			exp:  []any{"m"},
+		},
+		{
+			test: "missing",
+			path: `$.nope`,
+			exp:  []any{},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			res := []any(jsonpath.MustParse(tc.path).Select(Value(doc)))
+			if tc.ordered {
+				assert.Equal(t, tc.exp, res)
+			} else {
+				assert.ElementsMatch(t, tc.exp, res)
+			}
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal(Document{bson.D{}}, Value(bson.D{}))
+	a.Equal(Map{bson.M{}}, Value(bson.M{}))
+	a.Equal(Array{bson.A{}}, Value(bson.A{}))
+	a.Equal(42, Value(42))
+
+	m := Map{bson.M{"a": bson.A{1}, "b": 2}}
+	a.Equal(2, m.Len())
+	v, ok := m.Get("a")
+	a.True(ok)
+	a.Equal(Array{bson.A{1}}, v)
+	_, ok = m.Get("c")
+	a.False(ok)
+
+	arr := Array{bson.A{1, bson.D{}}}
+	a.Equal(2, arr.Len())
+	v, ok = arr.Get(1)
+	a.True(ok)
+	a.Equal(Document{bson.D{}}, v)
+	_, ok = arr.Get(2)
+	a.False(ok)
+	_, ok = arr.Get(-1)
+	a.False(ok)
+
+	d := Document{bson.D{{Key: "a", Value: 1}, {Key: "a", Value: 2}}}
+	a.Equal(2, d.Len())
+	v, ok = d.Get("a")
+	a.True(ok)
+	a.Equal(1, v)
+	_, ok = d.Get("b")
+	a.False(ok)
+
+	// Stop iteration early.
+	n := 0
+	for range d.Iterate() {
+		n++
+		break
+	}
+	for range m.Iterate() {
+		n++
+		break
+	}
+	for range arr.Iterate() {
+		n++
+		break
+	}
+	a.Equal(3, n)
+}
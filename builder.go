@@ -0,0 +1,126 @@
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// errEmptySegment is returned by [Builder.Build] for segments without
+// selectors.
+var errEmptySegment = errors.New("jsonpath: segment without selectors")
+
+// Builder assembles a [Path] from segments and selectors, without the
+// escaping mistakes of generating query strings by concatenation. Each
+// method appends a segment to the path and returns the Builder, so that
+// calls chain:
+//
+//	path, err := jsonpath.NewBuilder().
+//		Child("store").
+//		Descendant().Wildcard().
+//		Filter(spec.And(spec.Existence(spec.Query(false, spec.Child(spec.Name("isbn")))))).
+//		Build()
+//
+// Methods append child segments unless preceded by [Builder.Descendant].
+// Use [Builder.Select] for segments with selectors of different kinds.
+type Builder struct {
+	parser     *Parser
+	segments   []*spec.Segment
+	descendant bool
+}
+
+// NewBuilder creates a new [Builder] for a path that selects the root node.
+// Pass [Option] values to configure the [Parser] with which
+// [Builder.Build] validates the path, and the evaluation of the path.
+func NewBuilder(opt ...Option) *Builder {
+	return &Builder{parser: NewParser(opt...)}
+}
+
+// Descendant makes the next segment a descendant segment, so that
+// Descendant().Child("x") appends ..x rather than .x.
+func (b *Builder) Descendant() *Builder {
+	b.descendant = true
+	return b
+}
+
+// Child appends a segment that selects the object members with the names
+// in name, as in ["a","b"].
+func (b *Builder) Child(name ...string) *Builder {
+	sels := make([]spec.Selector, len(name))
+	for i, n := range name {
+		sels[i] = spec.Name(n)
+	}
+	return b.Select(sels...)
+}
+
+// Index appends a segment that selects the array elements at the indexes
+// in index, as in [0,-1].
+func (b *Builder) Index(index ...int) *Builder {
+	sels := make([]spec.Selector, len(index))
+	for i, idx := range index {
+		sels[i] = spec.Index(idx)
+	}
+	return b.Select(sels...)
+}
+
+// Wildcard appends a segment that selects all object member values and
+// array elements, as in [*].
+func (b *Builder) Wildcard() *Builder {
+	return b.Select(spec.Wildcard())
+}
+
+// Filter appends a segment with a filter selector that selects the object
+// member values and array elements for which expr is true. The expressions
+// in expr are alternatives, as in [?a || b]. Use the filter expression
+// constructors in package spec, such as [spec.And], [spec.Comparison], and
+// [spec.Existence], to create them.
+func (b *Builder) Filter(expr ...spec.LogicalAnd) *Builder {
+	return b.Select(spec.Filter(expr...))
+}
+
+// Select appends a segment with the selectors in sel, which may be of any
+// kind, as in ["a",0,1:3].
+func (b *Builder) Select(sel ...spec.Selector) *Builder {
+	if b.descendant {
+		b.segments = append(b.segments, spec.Descendant(sel...))
+		b.descendant = false
+	} else {
+		b.segments = append(b.segments, spec.Child(sel...))
+	}
+	return b
+}
+
+// Build validates the assembled path and returns it. It validates the path
+// by parsing its string representation with the [Parser] configured by the
+// options passed to [NewBuilder], so that it returns the same [Path] as
+// [Parser.Parse] would, and the same errors for invalid paths, such as
+// indexes out of range or calls to function extensions not found in the
+// registry. Also returns an error for segments without selectors, including
+// a [Builder.Descendant] not followed by a segment.
+func (b *Builder) Build() (*Path, error) {
+	q := spec.Query(true, b.segments...)
+	if b.descendant {
+		return nil, fmt.Errorf("%w after %v", errEmptySegment, q)
+	}
+	for i, seg := range b.segments {
+		if len(seg.Selectors()) == 0 {
+			return nil, fmt.Errorf("%w after %v", errEmptySegment, spec.Query(true, b.segments[:i]...))
+		}
+	}
+
+	path, err := b.parser.Parse(q.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w in %v", err, q)
+	}
+	return path, nil
+}
+
+// MustBuild is like [Builder.Build] but panics on error.
+func (b *Builder) MustBuild() *Path {
+	path, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
@@ -0,0 +1,138 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+
+	isbn := spec.And(spec.Existence(spec.Query(false, spec.Child(spec.Name("isbn")))))
+
+	for _, tc := range []struct {
+		test  string
+		build *Builder
+		exp   string
+	}{
+		{
+			test:  "root",
+			build: NewBuilder(),
+			exp:   `$`,
+		},
+		{
+			test:  "child",
+			build: NewBuilder().Child("store"),
+			exp:   `$["store"]`,
+		},
+		{
+			test:  "names",
+			build: NewBuilder().Child("a", "b"),
+			exp:   `$["a","b"]`,
+		},
+		{
+			test:  "escapes",
+			build: NewBuilder().Child(`it's "quoted"`, "a\nb"),
+			exp:   `$["it's \"quoted\"","a\nb"]`,
+		},
+		{
+			test:  "index",
+			build: NewBuilder().Index(0, -1),
+			exp:   `$[0,-1]`,
+		},
+		{
+			test:  "descendant_wildcard",
+			build: NewBuilder().Child("store").Descendant().Wildcard(),
+			exp:   `$["store"]..[*]`,
+		},
+		{
+			test:  "descendant_once",
+			build: NewBuilder().Descendant().Child("book").Index(2),
+			exp:   `$..["book"][2]`,
+		},
+		{
+			test:  "filter",
+			build: NewBuilder().Child("store").Descendant().Wildcard().Filter(isbn),
+			exp:   `$["store"]..[*][?@["isbn"]]`,
+		},
+		{
+			test:  "select",
+			build: NewBuilder().Select(spec.Name("a"), spec.Index(0), spec.Slice(1, 3)),
+			exp:   `$["a",0,1:3]`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			path, err := tc.build.Build()
+			require.NoError(t, err)
+			a.Equal(tc.exp, path.String())
+			a.Equal(MustParse(tc.exp), path)
+		})
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	t.Parallel()
+
+	first := spec.Extension(
+		"first",
+		spec.FuncValue,
+		func([]spec.FuncExprArg) error { return nil },
+		func([]spec.PathValue) spec.PathValue { return nil },
+	)
+	firstA := spec.And(spec.Comparison(
+		spec.Function(first, spec.Query(false, spec.Child(spec.Wildcard()))),
+		spec.EqualTo,
+		spec.Literal("a"),
+	))
+
+	for _, tc := range []struct {
+		test  string
+		build *Builder
+		err   string
+	}{
+		{
+			test:  "no_names",
+			build: NewBuilder().Child("a").Child(),
+			err:   `jsonpath: segment without selectors after $["a"]`,
+		},
+		{
+			test:  "trailing_descendant",
+			build: NewBuilder().Child("a").Descendant(),
+			err:   `jsonpath: segment without selectors after $["a"]`,
+		},
+		{
+			test:  "index_range",
+			build: NewBuilder().Index(1 << 60),
+			err:   `jsonpath: cannot parse "1152921504606846976", value out of range at position 3 in $[1152921504606846976]`,
+		},
+		{
+			test:  "unknown_function",
+			build: NewBuilder().Filter(firstA),
+			err:   `jsonpath: unknown function first() at position 4 in $[?first(@[*]) == "a"]`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			path, err := tc.build.Build()
+			a.EqualError(err, tc.err)
+			a.Nil(path)
+			a.PanicsWithError(tc.err, func() { tc.build.MustBuild() })
+		})
+	}
+
+	// Should find functions in the registry.
+	reg := registry.New()
+	require.NoError(t, reg.Register("first", spec.FuncValue, first.Validate, first.Evaluate))
+	path, err := NewBuilder(WithRegistry(reg)).Filter(firstA).Build()
+	require.NoError(t, err)
+	assert.Equal(t, `$[?first(@[*]) == "a"]`, path.String())
+}
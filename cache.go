@@ -0,0 +1,305 @@
+package jsonpath
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// defaultCacheShards is the default number of shards in a [PathCache].
+const defaultCacheShards = 16
+
+// EvictionReason describes why a [PathCache] evicted a path.
+type EvictionReason uint8
+
+const (
+	// EvictedCapacity indicates that a path was evicted as the least
+	// recently used path in a full [PathCache].
+	EvictedCapacity EvictionReason = iota
+
+	// EvictedExpired indicates that a path was evicted because it was
+	// requested after its time to live elapsed.
+	EvictedExpired
+
+	// EvictedRemoved indicates that a path was removed by
+	// [PathCache.Remove] or [PathCache.Purge].
+	EvictedRemoved
+)
+
+// String returns a string representation of r.
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictedCapacity:
+		return "capacity"
+	case EvictedExpired:
+		return "expired"
+	case EvictedRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// CacheStats contains the counters of a [PathCache], as returned by
+// [PathCache.Stats].
+type CacheStats struct {
+	// Hits counts the calls to [PathCache.Get] that found a cached path.
+	Hits uint64
+	// Misses counts the calls to [PathCache.Get] that parsed a query,
+	// including queries that failed to parse.
+	Misses uint64
+	// Evictions counts the paths evicted to make room for other paths.
+	Evictions uint64
+	// Expirations counts the paths evicted because their time to live
+	// elapsed.
+	Expirations uint64
+	// Len is the number of paths in the cache.
+	Len int
+}
+
+// CacheOption defines a [PathCache] option.
+type CacheOption func(*PathCache)
+
+// WithCacheTTL configures a [PathCache] to evict paths requested more
+// than ttl after they were parsed. A ttl less than or equal to zero, the
+// default, disables expiration.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(pc *PathCache) { pc.ttl = ttl }
+}
+
+// WithCacheShards configures the number of shards in a [PathCache], each
+// with its own lock and least recently used list, to reduce contention
+// between goroutines. Defaults to 16. The cache uses fewer shards if its
+// capacity is smaller.
+func WithCacheShards(shards int) CacheOption {
+	return func(pc *PathCache) { pc.shards = make([]cacheShard, max(shards, 1)) }
+}
+
+// WithEvictionFunc configures a [PathCache] to call fn for each path it
+// evicts or removes, with the query, the path, and the reason. The cache
+// calls fn after releasing its locks, so fn may use the cache.
+func WithEvictionFunc(fn func(query string, path *Path, reason EvictionReason)) CacheOption {
+	return func(pc *PathCache) { pc.onEvict = fn }
+}
+
+// PathCache is a sharded, least recently used cache of the paths parsed
+// by a [Parser], for applications that repeatedly parse queries from
+// requests or configuration. It's safe for concurrent use by multiple
+// goroutines. Create one with [NewPathCache] or [Parser.Cache].
+//
+// Use [PathCache.Stats] to monitor the cache. For example, to export its
+// counters to Prometheus:
+//
+//	prometheus.MustRegister(prometheus.NewCounterFunc(
+//		prometheus.CounterOpts{Name: "jsonpath_cache_hits_total"},
+//		func() float64 { return float64(cache.Stats().Hits) },
+//	))
+type PathCache struct {
+	parser  *Parser
+	ttl     time.Duration
+	onEvict func(query string, path *Path, reason EvictionReason)
+	now     func() time.Time
+	seed    maphash.Seed
+	shards  []cacheShard
+}
+
+// cacheShard is a shard of a [PathCache].
+type cacheShard struct {
+	mu          sync.Mutex
+	capacity    int
+	entries     map[string]*list.Element
+	lru         list.List
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+}
+
+// cacheEntry is an entry in the lru list of a [cacheShard].
+type cacheEntry struct {
+	query   string
+	path    *Path
+	expires time.Time
+}
+
+// evicted records a path evicted from a [cacheShard], to be passed to the
+// eviction function once the shard is unlocked.
+type evicted struct {
+	entry  *cacheEntry
+	reason EvictionReason
+}
+
+// NewPathCache returns a [PathCache] that holds up to capacity paths
+// parsed with the RFC 9535 functions and default options. See
+// [Parser.Cache] for details.
+func NewPathCache(capacity int, opts ...CacheOption) *PathCache {
+	return NewParser().Cache(capacity, opts...)
+}
+
+// Cache returns a [PathCache] that holds up to capacity paths parsed by c.
+// When full, it evicts the least recently used path in the shard for the
+// query being added. If capacity is less than 1, the cache is unbounded and
+// evicts paths only when they expire.
+func (c *Parser) Cache(capacity int, opts ...CacheOption) *PathCache {
+	pc := &PathCache{
+		parser: c,
+		now:    time.Now,
+		seed:   maphash.MakeSeed(),
+		shards: make([]cacheShard, defaultCacheShards),
+	}
+	for _, opt := range opts {
+		opt(pc)
+	}
+
+	// Distribute the capacity across the shards.
+	if capacity > 0 && capacity < len(pc.shards) {
+		pc.shards = pc.shards[:capacity]
+	}
+	for i := range pc.shards {
+		s := &pc.shards[i]
+		s.entries = map[string]*list.Element{}
+		if capacity > 0 {
+			s.capacity = capacity / len(pc.shards)
+			if i < capacity%len(pc.shards) {
+				s.capacity++
+			}
+		}
+	}
+	return pc
+}
+
+// Get returns the [Path] for query, parsing and caching it if it's not
+// already cached or if it has expired. Returns an [ErrPathParse] error if
+// query is invalid; the cache does not cache errors.
+func (pc *PathCache) Get(query string) (*Path, error) {
+	s := pc.shard(query)
+	var gone []evicted
+	defer func() { pc.notify(gone) }()
+
+	s.mu.Lock()
+	if elem, ok := s.entries[query]; ok {
+		entry, _ := elem.Value.(*cacheEntry)
+		if pc.ttl <= 0 || pc.now().Before(entry.expires) {
+			s.lru.MoveToFront(elem)
+			s.hits++
+			s.mu.Unlock()
+			return entry.path, nil
+		}
+		s.remove(elem)
+		s.expirations++
+		gone = append(gone, evicted{entry, EvictedExpired})
+	}
+	s.misses++
+	s.mu.Unlock()
+
+	path, err := pc.parser.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[query]; ok {
+		// Another goroutine cached it first.
+		entry, _ := elem.Value.(*cacheEntry)
+		return entry.path, nil
+	}
+	entry := &cacheEntry{query: query, path: path}
+	if pc.ttl > 0 {
+		entry.expires = pc.now().Add(pc.ttl)
+	}
+	s.entries[query] = s.lru.PushFront(entry)
+	for s.capacity > 0 && s.lru.Len() > s.capacity {
+		gone = append(gone, evicted{s.remove(s.lru.Back()), EvictedCapacity})
+		s.evictions++
+	}
+	return path, nil
+}
+
+// Remove removes the path for query from the cache. Returns true if it was
+// cached.
+func (pc *PathCache) Remove(query string) bool {
+	s := pc.shard(query)
+	s.mu.Lock()
+	elem, ok := s.entries[query]
+	if !ok {
+		s.mu.Unlock()
+		return false
+	}
+	entry := s.remove(elem)
+	s.mu.Unlock()
+	pc.notify([]evicted{{entry, EvictedRemoved}})
+	return true
+}
+
+// Purge removes all paths from the cache. It does not reset the counters
+// returned by [PathCache.Stats].
+func (pc *PathCache) Purge() {
+	var gone []evicted
+	for i := range pc.shards {
+		s := &pc.shards[i]
+		s.mu.Lock()
+		for s.lru.Len() > 0 {
+			gone = append(gone, evicted{s.remove(s.lru.Back()), EvictedRemoved})
+		}
+		s.mu.Unlock()
+	}
+	pc.notify(gone)
+}
+
+// Len returns the number of paths in the cache, including expired paths
+// not yet evicted.
+func (pc *PathCache) Len() int {
+	n := 0
+	for i := range pc.shards {
+		s := &pc.shards[i]
+		s.mu.Lock()
+		n += s.lru.Len()
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Stats returns the counters and length of the cache. The counters
+// increase monotonically for the life of the cache, suitable for export as
+// Prometheus counters.
+func (pc *PathCache) Stats() CacheStats {
+	var stats CacheStats
+	for i := range pc.shards {
+		s := &pc.shards[i]
+		s.mu.Lock()
+		stats.Hits += s.hits
+		stats.Misses += s.misses
+		stats.Evictions += s.evictions
+		stats.Expirations += s.expirations
+		stats.Len += s.lru.Len()
+		s.mu.Unlock()
+	}
+	return stats
+}
+
+// shard returns the shard for query.
+func (pc *PathCache) shard(query string) *cacheShard {
+	if len(pc.shards) == 1 {
+		return &pc.shards[0]
+	}
+	return &pc.shards[maphash.String(pc.seed, query)%uint64(len(pc.shards))]
+}
+
+// notify passes each of gone to the eviction function, if any.
+func (pc *PathCache) notify(gone []evicted) {
+	if pc.onEvict == nil {
+		return
+	}
+	for _, e := range gone {
+		pc.onEvict(e.entry.query, e.entry.path, e.reason)
+	}
+}
+
+// remove removes elem from s and returns its entry. s must be locked.
+func (s *cacheShard) remove(elem *list.Element) *cacheEntry {
+	entry, _ := s.lru.Remove(elem).(*cacheEntry)
+	delete(s.entries, entry.query)
+	return entry
+}
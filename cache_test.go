@@ -0,0 +1,208 @@
+package jsonpath
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvictionReason(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		reason EvictionReason
+		str    string
+	}{
+		{EvictedCapacity, "capacity"},
+		{EvictedExpired, "expired"},
+		{EvictedRemoved, "removed"},
+		{EvictionReason(99), "unknown"},
+	} {
+		t.Run(tc.str, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.str, tc.reason.String())
+		})
+	}
+}
+
+func TestPathCacheLRU(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	type eviction struct {
+		query  string
+		reason EvictionReason
+	}
+	var evictions []eviction
+	cache := NewPathCache(2, WithCacheShards(1), WithEvictionFunc(
+		func(query string, path *Path, reason EvictionReason) {
+			a.Equal(query, path.String())
+			evictions = append(evictions, eviction{query, reason})
+		},
+	))
+	a.Len(cache.shards, 1)
+
+	// Should parse and cache the path.
+	path, err := cache.Get(`$["a"]`)
+	r.NoError(err)
+	a.Equal(`$["a"]`, path.String())
+	again, err := cache.Get(`$["a"]`)
+	r.NoError(err)
+	a.Same(path, again)
+	a.Equal(CacheStats{Hits: 1, Misses: 1, Len: 1}, cache.Stats())
+
+	// Should not cache errors.
+	_, err = cache.Get(`$[`)
+	r.ErrorIs(err, ErrPathParse)
+	a.Equal(CacheStats{Hits: 1, Misses: 2, Len: 1}, cache.Stats())
+
+	// Should evict the least recently used path.
+	_, err = cache.Get(`$["b"]`)
+	r.NoError(err)
+	_, err = cache.Get(`$["a"]`)
+	r.NoError(err)
+	_, err = cache.Get(`$["c"]`)
+	r.NoError(err)
+	a.Equal([]eviction{{`$["b"]`, EvictedCapacity}}, evictions)
+	a.Equal(CacheStats{Hits: 2, Misses: 4, Evictions: 1, Len: 2}, cache.Stats())
+	a.Equal(2, cache.Len())
+
+	// Should remove paths.
+	a.True(cache.Remove(`$["a"]`))
+	a.False(cache.Remove(`$["a"]`))
+	a.Equal(eviction{`$["a"]`, EvictedRemoved}, evictions[1])
+	a.Equal(1, cache.Len())
+
+	// Should purge paths but not counters.
+	cache.Purge()
+	a.Equal(eviction{`$["c"]`, EvictedRemoved}, evictions[2])
+	a.Equal(CacheStats{Hits: 2, Misses: 4, Evictions: 1}, cache.Stats())
+}
+
+func TestPathCacheTTL(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	var reasons []EvictionReason
+	cache := NewPathCache(10, WithCacheTTL(time.Minute), WithEvictionFunc(
+		func(_ string, _ *Path, reason EvictionReason) { reasons = append(reasons, reason) },
+	))
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	path, err := cache.Get(`$.a`)
+	r.NoError(err)
+	now = now.Add(time.Minute - time.Second)
+	again, err := cache.Get(`$.a`)
+	r.NoError(err)
+	a.Same(path, again)
+
+	// Should reparse once expired.
+	now = now.Add(time.Second)
+	again, err = cache.Get(`$.a`)
+	r.NoError(err)
+	a.NotSame(path, again)
+	a.Equal([]EvictionReason{EvictedExpired}, reasons)
+	a.Equal(CacheStats{Hits: 1, Misses: 2, Expirations: 1, Len: 1}, cache.Stats())
+}
+
+func TestPathCacheShards(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		capacity   int
+		opts       []CacheOption
+		capacities []int
+	}{
+		{
+			name:       "default",
+			capacity:   36,
+			capacities: []int{3, 3, 3, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+		},
+		{
+			name:       "small",
+			capacity:   3,
+			capacities: []int{1, 1, 1},
+		},
+		{
+			name:       "unbounded",
+			capacity:   0,
+			opts:       []CacheOption{WithCacheShards(2)},
+			capacities: []int{0, 0},
+		},
+		{
+			name:       "min_shards",
+			capacity:   5,
+			opts:       []CacheOption{WithCacheShards(-1)},
+			capacities: []int{5},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cache := NewPathCache(tc.capacity, tc.opts...)
+			capacities := make([]int, len(cache.shards))
+			for i := range cache.shards {
+				capacities[i] = cache.shards[i].capacity
+			}
+			assert.Equal(t, tc.capacities, capacities)
+		})
+	}
+
+	// Should never exceed capacity.
+	a := assert.New(t)
+	cache := NewPathCache(20)
+	for i := range 100 {
+		_, err := cache.Get(fmt.Sprintf("$[%d]", i))
+		require.NoError(t, err)
+	}
+	a.LessOrEqual(cache.Len(), 20)
+	stats := cache.Stats()
+	a.Equal(uint64(100), stats.Misses)
+	a.Equal(uint64(100-stats.Len), stats.Evictions)
+}
+
+func TestPathCacheParser(t *testing.T) {
+	t.Parallel()
+
+	cache := NewParser(WithLenientNumbers()).Cache(1)
+	path, err := cache.Get(`$[01]`)
+	require.NoError(t, err)
+	assert.Equal(t, `$[1]`, path.String())
+}
+
+func TestPathCacheConcurrency(t *testing.T) {
+	t.Parallel()
+
+	// Run with -race to detect data races.
+	cache := NewPathCache(4, WithEvictionFunc(func(string, *Path, EvictionReason) {}))
+	queries := []string{`$.a`, `$.b`, `$.c`, `$.d`, `$.e`, `$.f`}
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				query := queries[(i+j)%len(queries)]
+				path, err := cache.Get(query)
+				if assert.NoError(t, err) {
+					assert.Equal(t, NodeList{query}, path.Select(map[string]any{query[2:]: query}))
+				}
+				if j%10 == 0 {
+					cache.Remove(query)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(800), stats.Hits+stats.Misses)
+	assert.LessOrEqual(t, stats.Len, 4)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const completionUsage = "jsonpath completion bash|zsh|fish"
+
+// commandFlags maps the name of each command to its flags, with "" for the
+// default command, for completion.
+var commandFlags = map[string][]string{
+	"":           {"-e", "-indent", "-interval", "-l", "-ndjson", "-output", "-watch"},
+	"set":        {"-dry-run", "-in-place"},
+	"delete":     {"-dry-run", "-in-place"},
+	"repl":       {"-color"},
+	"fmt":        {"-bracket", "-compact", "-double", "-l"},
+	"completion": nil,
+	"man":        nil,
+}
+
+// valueFlags lists the flags that take a value as a separate argument.
+var valueFlags = []string{"-e", "-interval", "-output"}
+
+// completionScripts maps the name of each supported shell to its
+// completion script.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for jsonpath. Load with:
+#   source <(jsonpath completion bash)
+_jsonpath() {
+	local cur=${COMP_WORDS[COMP_CWORD]}
+	local IFS=$'\n'
+	COMPREPLY=($(jsonpath __complete $((COMP_CWORD - 1)) "${COMP_WORDS[@]:1}" 2>/dev/null))
+	if [[ ${#COMPREPLY[@]} -eq 0 ]]; then
+		COMPREPLY=($(compgen -f -- "$cur"))
+	elif [[ $cur == *'$'* ]]; then
+		compopt -o nospace
+	fi
+}
+complete -o filenames -F _jsonpath jsonpath
+`,
+	"zsh": `#compdef jsonpath
+# zsh completion for jsonpath. Load with:
+#   source <(jsonpath completion zsh)
+_jsonpath() {
+	local -a candidates
+	candidates=("${(@f)$(jsonpath __complete $((CURRENT - 2)) "${(@)words[2,-1]}" 2>/dev/null)}")
+	if [[ -n ${candidates[1]} ]]; then
+		if [[ ${words[CURRENT]} == *'$'* ]]; then
+			compadd -Q -S '' -- "${candidates[@]}"
+		else
+			compadd -Q -- "${candidates[@]}"
+		fi
+	else
+		_files
+	fi
+}
+if [[ $funcstack[1] == _jsonpath ]]; then
+	_jsonpath "$@"
+else
+	compdef _jsonpath jsonpath
+fi
+`,
+	"fish": `# fish completion for jsonpath. Load with:
+#   jsonpath completion fish | source
+function __jsonpath_complete
+	set -l before (commandline -opc)
+	set -l cur (commandline -ct)
+	set -l all (commandline -o)
+	set -l next (math (count $before) + 1)
+	if test -n "$cur"
+		set next (math $next + 1)
+	end
+	set -l after
+	if test $next -le (count $all)
+		set after $all[$next..-1]
+	end
+	set -e before[1]
+	set -l res (jsonpath __complete (count $before) $before "$cur" $after 2>/dev/null)
+	if test (count $res) -eq 0
+		__fish_complete_path $cur
+	else
+		printf '%s\n' $res
+	end
+end
+complete -c jsonpath -f -a '(__jsonpath_complete)'
+`,
+}
+
+// runCompletion parses args for the completion command and writes the
+// completion script for the shell they name to stdout.
+func runCompletion(args []string, stdout io.Writer) error {
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		return fmt.Errorf("%w: %v", errUsage, completionUsage)
+	}
+	_, err := io.WriteString(stdout, completionScripts[args[0]])
+	return err //nolint:wrapcheck
+}
+
+// runComplete parses args for the hidden __complete command, called by the
+// completion scripts: the index of the word being completed followed by
+// the arguments to jsonpath. Writes the completions of the word to stdout,
+// one per line. The scripts complete file names when it writes nothing.
+func runComplete(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: jsonpath __complete index [arg...]", errUsage)
+	}
+	idx, err := strconv.Atoi(args[0])
+	if err != nil || idx < 0 || idx >= len(args) {
+		return fmt.Errorf("%w: invalid index %q", errUsage, args[0])
+	}
+	args = args[1:]
+	if idx == len(args) {
+		args = append(args, "")
+	}
+
+	for _, c := range completeArgs(args, idx) {
+		if _, err := fmt.Fprintln(stdout, c); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+	return nil
+}
+
+// completeArgs returns the completions of args[idx], one of args to
+// jsonpath: command names, flag names, output formats, and member names in
+// queries, completed from the first JSON document in the files named by
+// args. Returns nil to complete file names.
+func completeArgs(args []string, idx int) []string {
+	cmd := ""
+	if idx > 0 {
+		if _, ok := commandFlags[args[0]]; ok {
+			cmd, args, idx = args[0], args[1:], idx-1
+		}
+	}
+	cur := args[idx]
+
+	switch {
+	case cmd == "" && idx == 0 && !strings.HasPrefix(cur, "-") && !isQuery(cur):
+		return matching(commandNames(), cur)
+	case cmd == "completion":
+		if idx == 0 {
+			return matching([]string{"bash", "fish", "zsh"}, cur)
+		}
+		return nil
+	case strings.HasPrefix(cur, "-"):
+		return matching(commandFlags[cmd], cur)
+	case cmd == "" && idx > 0 && args[idx-1] == "-output":
+		names := make([]string, len(formats))
+		for i, f := range formats {
+			names[i] = string(f)
+		}
+		return matching(names, cur)
+	case isQuery(cur):
+		return completeQuery(cmd, args, idx)
+	default:
+		return nil
+	}
+}
+
+// completeQuery returns the completions of args[idx], a query argument to
+// the command cmd, with the member names in the first JSON document in the
+// files named by args. Retains a leading quotation mark in the query.
+func completeQuery(cmd string, args []string, idx int) []string {
+	// Parse flags as the flag package does, up to the first non-flag.
+	query := false
+	var positional []int
+	for i := 0; i < len(args); i++ {
+		switch {
+		case len(positional) > 0 || args[i] == "-" || !strings.HasPrefix(args[i], "-"):
+			positional = append(positional, i)
+		case args[i] == "--":
+			for i++; i < len(args); i++ {
+				positional = append(positional, i)
+			}
+		case cmd == "" && slices.Contains(valueFlags, args[i]):
+			query = query || args[i] == "-e"
+			i++
+		case cmd == "" && strings.HasPrefix(args[i], "-e="):
+			query = true
+		}
+	}
+
+	// Skip the query and, for set, its value.
+	var files []int
+	switch {
+	case cmd == "repl" || (cmd == "" && query):
+		files = positional
+	case cmd == "set" && len(positional) > 1:
+		files = positional[2:]
+	case len(positional) > 0:
+		files = positional[1:]
+	}
+
+	var doc any
+	found := false
+	for _, i := range files {
+		if i != idx {
+			if doc, found = firstDocument(args[i]); found {
+				break
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	cur, quote := args[idx], ""
+	if cur[0] == '\'' || cur[0] == '"' {
+		quote, cur = cur[:1], cur[1:]
+	}
+	res := completions(doc, cur)
+	for i, c := range res {
+		res[i] = quote + c
+	}
+	return res
+}
+
+// firstDocument returns the first JSON document in the file named name.
+// Returns false if name is "-" or if the file cannot be read or does not
+// start with a JSON document.
+func firstDocument(name string) (any, bool) {
+	if name == "-" {
+		return nil, false
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, false
+	}
+	return doc, true
+}
+
+// isQuery returns true if s, optionally preceded by a quotation mark, is
+// the start of a JSONPath query.
+func isQuery(s string) bool {
+	return strings.HasPrefix(strings.TrimLeft(s, `'"`), "$")
+}
+
+// commandNames returns the sorted names of the commands other than the
+// default command.
+func commandNames() []string {
+	var names []string
+	for name := range commandFlags {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// matching returns the strings in candidates that start with prefix.
+func matching(candidates []string, prefix string) []string {
+	var res []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCompletion(t *testing.T) {
+	t.Parallel()
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			require.NoError(t, run([]string{"completion", shell}, nil, &out))
+			assert.Contains(t, out.String(), "jsonpath __complete")
+		})
+	}
+
+	for _, tc := range []struct {
+		test string
+		args []string
+	}{
+		{"no_shell", []string{"completion"}},
+		{"unknown_shell", []string{"completion", "tcsh"}},
+		{"extra_args", []string{"completion", "bash", "zsh"}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			err := run(tc.args, nil, &bytes.Buffer{})
+			require.ErrorIs(t, err, errUsage)
+			require.EqualError(t, err, "usage: "+completionUsage)
+		})
+	}
+}
+
+func TestRunComplete(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "doc.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"store": {"book": [], "bicycle": {}}, "size": 1}`+"\n{}"), 0o600))
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`{"store": `), 0o600))
+
+	// Each args starts with the index of the word to complete.
+	for _, tc := range []struct {
+		test string
+		args []string
+		exp  []string
+		err  string
+	}{
+		{
+			test: "nothing",
+			args: []string{"0"},
+			exp:  []string{"completion", "delete", "fmt", "man", "repl", "set"},
+		},
+		{
+			test: "command",
+			args: []string{"0", "re"},
+			exp:  []string{"repl"},
+		},
+		{
+			test: "flags",
+			args: []string{"0", "-i", "$.a"},
+			exp:  []string{"-indent", "-interval"},
+		},
+		{
+			test: "command_flags",
+			args: []string{"1", "set", "-"},
+			exp:  []string{"-dry-run", "-in-place"},
+		},
+		{
+			test: "output_formats",
+			args: []string{"1", "-output", "j"},
+			exp:  []string{"json", "jsonl"},
+		},
+		{
+			test: "shells",
+			args: []string{"1", "completion"},
+			exp:  []string{"bash", "fish", "zsh"},
+		},
+		{
+			test: "file_after_shell",
+			args: []string{"2", "completion", "bash", ""},
+		},
+		{
+			test: "file",
+			args: []string{"1", "$.a", "d"},
+		},
+		{
+			test: "query_without_file",
+			args: []string{"0", "$.s"},
+		},
+		{
+			test: "query",
+			args: []string{"0", "$.s", file},
+			exp:  []string{"$.size", "$.store"},
+		},
+		{
+			test: "query_after_file",
+			args: []string{"1", file, "$.s"},
+		},
+		{
+			test: "query_flag",
+			args: []string{"1", "-e", "$.s", file},
+			exp:  []string{"$.size", "$.store"},
+		},
+		{
+			test: "query_flags",
+			args: []string{"5", "-e=$.x", "-l", "-e", "$.foo", "-e", "$.store.b", "-output", "json", file},
+			exp:  []string{"$.store.bicycle", "$.store.book"},
+		},
+		{
+			test: "quoted_query",
+			args: []string{"0", "'$.st", file},
+			exp:  []string{"'$.store"},
+		},
+		{
+			test: "double_dash",
+			args: []string{"1", "--", "$.st", file},
+			exp:  []string{"$.store"},
+		},
+		{
+			test: "delete",
+			args: []string{"2", "delete", "-dry-run", "$.store.bo", file},
+			exp:  []string{"$.store.book"},
+		},
+		{
+			test: "set",
+			args: []string{"1", "set", "$.store.bi", "{}", file},
+			exp:  []string{"$.store.bicycle"},
+		},
+		{
+			test: "set_value_not_file",
+			args: []string{"1", "set", "$.store.bi", file},
+		},
+		{
+			test: "repl",
+			args: []string{"2", "repl", file, "$.s"},
+			exp:  []string{"$.size", "$.store"},
+		},
+		{
+			test: "skip_bad_files",
+			args: []string{"0", "$.si", "-", bad, "nonesuch.json", file},
+			exp:  []string{"$.size"},
+		},
+		{
+			test: "no_document",
+			args: []string{"0", "$.si", bad},
+		},
+		{
+			test: "no_index",
+			err:  "usage: jsonpath __complete index [arg...]",
+		},
+		{
+			test: "invalid_index",
+			args: []string{"x"},
+			err:  `usage: invalid index "x"`,
+		},
+		{
+			test: "index_out_of_range",
+			args: []string{"2", "$"},
+			err:  `usage: invalid index "2"`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			err := run(append([]string{"__complete"}, tc.args...), nil, &out)
+			if tc.err != "" {
+				require.ErrorIs(t, err, errUsage)
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			var exp string
+			if len(tc.exp) > 0 {
+				exp = strings.Join(tc.exp, "\n") + "\n"
+			}
+			assert.Equal(t, exp, out.String())
+		})
+	}
+}
+
+func TestCommandFlags(t *testing.T) {
+	t.Parallel()
+
+	// Make sure each command defines the flags it completes.
+	for cmd, flags := range commandFlags {
+		for _, flag := range flags {
+			args := []string{flag}
+			if flag == "-e" {
+				args = append(args, "$")
+			} else if flag == "-output" {
+				args = append(args, "json")
+			} else if flag == "-interval" {
+				args = append(args, "1s")
+			}
+			if cmd != "" {
+				args = append([]string{cmd}, args...)
+			}
+			err := run(args, strings.NewReader(""), &bytes.Buffer{})
+			if err != nil {
+				assert.NotContains(t, err.Error(), "not defined", "%v %v", cmd, flag)
+			}
+		}
+	}
+}
+
+func TestRunMan(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, run([]string{"man"}, nil, &out))
+	page := out.String()
+	assert.True(t, strings.HasPrefix(page, ".TH JSONPATH 1\n"))
+	for _, section := range []string{"NAME", "SYNOPSIS", "DESCRIPTION", "OPTIONS", "COMMANDS", "EXAMPLES", "EXIT STATUS", "SEE ALSO"} {
+		assert.Contains(t, page, "\n.SH "+section+"\n")
+	}
+	for _, flags := range commandFlags {
+		for _, flag := range flags {
+			assert.Contains(t, page, strings.ReplaceAll(flag, "-", `\-`))
+		}
+	}
+
+	err := run([]string{"man", "x"}, nil, &bytes.Buffer{})
+	require.ErrorIs(t, err, errUsage)
+	require.EqualError(t, err, "usage: "+manUsage)
+}
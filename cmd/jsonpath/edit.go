@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+)
+
+// editUsage describes the arguments of the edit commands.
+var editUsage = map[string]string{
+	"set":    "jsonpath set [-in-place | -dry-run] path value [file...]",
+	"delete": "jsonpath delete [-in-place | -dry-run] path [file...]",
+}
+
+// runEdit parses args for the edit command cmd, either "set" or "delete",
+// applies the edit to the JSON documents in the files they name, or in
+// stdin, and writes the results to the files, to stdout, or, for a dry run,
+// writes the changes to stdout.
+func runEdit(cmd string, args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("jsonpath "+cmd, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	inPlace := flags.Bool("in-place", false, "update files in place")
+	dryRun := flags.Bool("dry-run", false, "print changes without applying them")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	nargs := 1
+	if cmd == "set" {
+		nargs = 2
+	}
+	if flags.NArg() < nargs {
+		return fmt.Errorf("%w: %v", errUsage, editUsage[cmd])
+	}
+	files := flags.Args()[nargs:]
+	switch {
+	case *inPlace && *dryRun:
+		return fmt.Errorf("%w: -in-place and -dry-run are mutually exclusive", errUsage)
+	case *inPlace && (len(files) == 0 || slices.Contains(files, "-")):
+		return fmt.Errorf("%w: -in-place cannot update stdin", errUsage)
+	}
+
+	path, err := jsonpath.Parse(flags.Arg(0))
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	var value any = jsonpath.Nothing
+	if cmd == "set" {
+		if value, err = decodeValue([]byte(flags.Arg(1))); err != nil {
+			return fmt.Errorf("invalid value %q: %w", flags.Arg(1), err)
+		}
+	}
+
+	ed := &editor{path: path, value: value, inPlace: *inPlace, dryRun: *dryRun, stdout: stdout}
+	if len(files) == 0 {
+		return ed.edit("stdin", stdin)
+	}
+	for _, name := range files {
+		if err := ed.editFile(name, stdin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeValue decodes data, which must contain a single JSON value,
+// preserving the precision of numbers.
+func decodeValue(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var val any
+	if err := dec.Decode(&val); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if dec.More() {
+		return nil, errLine
+	}
+	return val, nil
+}
+
+// editor sets or deletes the nodes that a path selects from JSON
+// documents.
+type editor struct {
+	path    *jsonpath.Path
+	value   any
+	inPlace bool
+	dryRun  bool
+	stdout  io.Writer
+}
+
+// editFile edits the JSON documents in the file named name, or in stdin if
+// name is "-".
+func (e *editor) editFile(name string, stdin io.Reader) error {
+	if name == "-" {
+		return e.edit("stdin", stdin)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	defer f.Close()
+	return e.edit(name, f)
+}
+
+// edit edits each JSON document read from r, which reads the input named
+// name, and writes the results to the file named name, to stdout, or, for
+// a dry run, writes the changes to stdout.
+func (e *editor) edit(name string, r io.Reader) error {
+	var docs []any
+	var changes []jsonpath.Change
+	dec := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		before, _ := decodeValue(raw)
+		doc, err := e.apply(raw)
+		if err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		docs = append(docs, doc)
+		changes = append(changes, jsonpath.Diff(before, doc)...)
+	}
+
+	switch {
+	case e.dryRun:
+		return printChanges(e.stdout, name, changes)
+	case e.inPlace:
+		if len(changes) == 0 {
+			return nil
+		}
+		return writeFile(name, docs)
+	default:
+		return writeDocs(e.stdout, docs)
+	}
+}
+
+// apply decodes the JSON document in raw and sets the nodes that e's path
+// selects from it to e's value, or deletes them if the value is
+// [jsonpath.Nothing], and returns the edited document. If e sets a value
+// and its path is a singular query that selects no node, apply adds the
+// value at the location it identifies, if the parent of that location
+// exists.
+func (e *editor) apply(raw json.RawMessage) (any, error) {
+	doc, err := decodeValue(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	w := jsonpath.NewWatcher(e.path, doc)
+	nodes := w.Results()
+	if len(nodes) == 0 && e.value != jsonpath.Nothing {
+		if prefix, rest := e.path.SingularPrefix(); len(rest.Query().Segments()) == 0 {
+			nodes = append(nodes, &spec.LocatedNode{Path: prefix})
+		}
+	}
+
+	// Edit the nodes in reverse order, so that deleting an array element
+	// does not shift the indexes of the nodes that remain to be edited, and
+	// edits to descendants precede edits to their ancestors.
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if _, err := w.Update(nodes[i].Path, e.value); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+	}
+	return w.Document(), nil
+}
+
+// printChanges writes changes to the documents in the input named name to
+// w, one line for the old value and one for the new value of each, if
+// present, prefixed by - and +, respectively.
+func printChanges(w io.Writer, name string, changes []jsonpath.Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "--- %v\n", name); err != nil {
+		return err //nolint:wrapcheck
+	}
+	for _, c := range changes {
+		for _, line := range []struct {
+			op  string
+			val any
+		}{{"-", c.Old}, {"+", c.New}} {
+			if line.val == jsonpath.Nothing {
+				continue
+			}
+			data, err := marshal(line.val, "")
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "%v%v\t%s\n", line.op, c.Path, bytes.TrimSpace(data)); err != nil {
+				return err //nolint:wrapcheck
+			}
+		}
+	}
+	return nil
+}
+
+// writeDocs writes docs to w as indented JSON.
+func writeDocs(w io.Writer, docs []any) error {
+	for _, doc := range docs {
+		data, err := marshal(doc, "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+	return nil
+}
+
+// writeFile replaces the contents of the file named name with docs as
+// indented JSON, preserving its permissions. It writes to a temporary file
+// and renames it, so that the file is never partially written.
+func writeFile(name string, docs []any) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	defer os.Remove(tmp.Name())
+
+	err = writeDocs(tmp, docs)
+	if err == nil {
+		err = tmp.Chmod(info.Mode().Perm())
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	return os.Rename(tmp.Name(), name) //nolint:wrapcheck
+}
+
+// marshal marshals val to JSON followed by a newline, indented by indent
+// if it's not empty, without escaping HTML characters.
+func marshal(val any, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(val); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunEdit(t *testing.T) {
+	t.Parallel()
+
+	const src = `{"a": {"b": 1}, "s": [{"secret": 1}, {"x": {"secret": [2]}}], "n": 1.50}`
+
+	for _, tc := range []struct {
+		test  string
+		args  []string
+		stdin string
+		exp   string
+		file  string
+		err   string
+	}{
+		{
+			test:  "set_stdin",
+			args:  []string{"set", "$.a.b", "42"},
+			stdin: `{"a": {"b": 1}} {"a": {"b": 2, "c": 3}}`,
+			exp:   "{\n  \"a\": {\n    \"b\": 42\n  }\n}\n{\n  \"a\": {\n    \"b\": 42,\n    \"c\": 3\n  }\n}\n",
+		},
+		{
+			test:  "set_many",
+			args:  []string{"set", "$.a[*]", `{"x": "<y>"}`},
+			stdin: `{"a": [1, 2]}`,
+			exp:   "{\n  \"a\": [\n    {\n      \"x\": \"<y>\"\n    },\n    {\n      \"x\": \"<y>\"\n    }\n  ]\n}\n",
+		},
+		{
+			test:  "set_new_member",
+			args:  []string{"set", "$.a.c", `"new"`},
+			stdin: `{"a": {}}`,
+			exp:   "{\n  \"a\": {\n    \"c\": \"new\"\n  }\n}\n",
+		},
+		{
+			test:  "set_new_element",
+			args:  []string{"set", "$[1]", "true"},
+			stdin: `[0]`,
+			exp:   "[\n  0,\n  true\n]\n",
+		},
+		{
+			test:  "set_root",
+			args:  []string{"set", "$", "[]"},
+			stdin: `{"a": 1}`,
+			exp:   "[]\n",
+		},
+		{
+			test:  "set_no_match",
+			args:  []string{"set", "$.a[?@ > 5]", "0"},
+			stdin: `{"a": [1, 2]}`,
+			exp:   "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n",
+		},
+		{
+			test:  "delete_stdin",
+			args:  []string{"delete", "$..secret", "-"},
+			stdin: src,
+			exp:   "{\n  \"a\": {\n    \"b\": 1\n  },\n  \"n\": 1.50,\n  \"s\": [\n    {},\n    {\n      \"x\": {}\n    }\n  ]\n}\n",
+		},
+		{
+			test:  "delete_elements",
+			args:  []string{"delete", "$[0,2]"},
+			stdin: `[1, 2, 3, 4]`,
+			exp:   "[\n  2,\n  4\n]\n",
+		},
+		{
+			test:  "delete_nested",
+			args:  []string{"delete", "$..[?@.x]"},
+			stdin: `[{"x": {"x": 1}}, {"y": 1}]`,
+			exp:   "[\n  {\n    \"y\": 1\n  }\n]\n",
+		},
+		{
+			test: "dry_run",
+			args: []string{"delete", "-dry-run", "$..secret", "FILE"},
+			exp:  "--- FILE\n-$['s'][0]['secret']\t1\n-$['s'][1]['x']['secret']\t[2]\n",
+			file: src,
+		},
+		{
+			test: "dry_run_set",
+			args: []string{"set", "-dry-run", "$.a.*", `"<x>"`, "FILE"},
+			exp:  "--- FILE\n-$['a']['b']\t1\n+$['a']['b']\t\"<x>\"\n",
+			file: src,
+		},
+		{
+			test: "dry_run_no_change",
+			args: []string{"set", "-dry-run", "$.a.b", "1", "FILE"},
+			file: src,
+		},
+		{
+			test: "in_place",
+			args: []string{"set", "-in-place", "$.n", "2", "FILE"},
+			file: "{\n  \"a\": {\n    \"b\": 1\n  },\n  \"n\": 2,\n  \"s\": [\n    {\n      \"secret\": 1\n    },\n    {\n      \"x\": {\n        \"secret\": [\n          2\n        ]\n      }\n    }\n  ]\n}\n",
+		},
+		{
+			test: "in_place_no_change",
+			args: []string{"delete", "-in-place", "$.nope", "FILE"},
+			file: src,
+		},
+		{
+			test: "usage",
+			args: []string{"set", "$.a"},
+			err:  "usage: jsonpath set [-in-place | -dry-run] path value [file...]",
+		},
+		{
+			test: "delete_usage",
+			args: []string{"delete"},
+			err:  "usage: jsonpath delete [-in-place | -dry-run] path [file...]",
+		},
+		{
+			test: "bad_flag",
+			args: []string{"delete", "-nope", "$"},
+			err:  "usage: flag provided but not defined: -nope",
+		},
+		{
+			test: "in_place_dry_run",
+			args: []string{"delete", "-in-place", "-dry-run", "$.a", "FILE"},
+			err:  "usage: -in-place and -dry-run are mutually exclusive",
+			file: src,
+		},
+		{
+			test: "in_place_stdin",
+			args: []string{"delete", "-in-place", "$.a"},
+			err:  "usage: -in-place cannot update stdin",
+		},
+		{
+			test: "bad_path",
+			args: []string{"delete", "$.a["},
+			err:  "jsonpath: unexpected eof at position 5",
+		},
+		{
+			test: "bad_value",
+			args: []string{"set", "$.a", "nope"},
+			err:  `invalid value "nope": invalid character 'o' in literal null (expecting 'u')`,
+		},
+		{
+			test: "two_values",
+			args: []string{"set", "$.a", "1 2"},
+			err:  `invalid value "1 2": multiple JSON values on one line`,
+		},
+		{
+			test:  "bad_json",
+			args:  []string{"delete", "$.a"},
+			stdin: `{"a": `,
+			err:   "stdin: unexpected EOF",
+		},
+		{
+			test:  "delete_root",
+			args:  []string{"delete", "$"},
+			stdin: `{}`,
+			err:   "stdin: jsonpath: cannot apply update to $: cannot remove the root value",
+		},
+		{
+			test:  "set_missing_parent",
+			args:  []string{"set", "$.a.b", "1"},
+			stdin: `{}`,
+			err:   "stdin: jsonpath: cannot apply update to $['a']['b']: $['a'] does not exist",
+		},
+		{
+			test: "no_file",
+			args: []string{"delete", "$.a", "FILE.nope"},
+			err:  "open FILE.nope: no such file or directory",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			dir := t.TempDir()
+			file := filepath.Join(dir, "doc.json")
+			require.NoError(t, os.WriteFile(file, []byte(src), 0o640))
+			args := make([]string, len(tc.args))
+			for i, arg := range tc.args {
+				args[i] = strings.ReplaceAll(arg, "FILE", file)
+			}
+
+			var stdout bytes.Buffer
+			err := run(args, strings.NewReader(tc.stdin), &stdout)
+			if tc.err != "" {
+				a.EqualError(err, strings.ReplaceAll(tc.err, "FILE", file))
+			} else {
+				a.NoError(err)
+			}
+			a.Equal(strings.ReplaceAll(tc.exp, "FILE", file), stdout.String())
+
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			if tc.file == "" {
+				a.Equal(src, string(data))
+			} else {
+				a.Equal(tc.file, string(data))
+			}
+			info, err := os.Stat(file)
+			require.NoError(t, err)
+			a.Equal(os.FileMode(0o640), info.Mode().Perm())
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			a.Len(entries, 1)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/theory/jsonpath"
+)
+
+// runFmt parses args for the fmt command, formats the queries they contain,
+// or the queries on the lines of stdin, and writes the results to stdout.
+func runFmt(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("jsonpath fmt", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	list := flags.Bool("l", false, "list queries whose formatting differs")
+	bracket := flags.Bool("bracket", false, "use bracket notation")
+	double := flags.Bool("double", false, "use double quotation marks")
+	compact := flags.Bool("compact", false, "omit spaces around operators")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	f := &queryFormatter{
+		w:    stdout,
+		list: *list,
+		opts: jsonpath.FormatOpts{Shorthand: !*bracket, SingleQuotes: !*double, Compact: *compact},
+	}
+	if flags.NArg() > 0 {
+		for _, query := range flags.Args() {
+			if err := f.format(query); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(stdin)
+	for num := 1; scanner.Scan(); num++ {
+		if err := f.format(scanner.Text()); err != nil {
+			return fmt.Errorf("stdin:%d: %w", num, err)
+		}
+	}
+	return scanner.Err() //nolint:wrapcheck
+}
+
+// queryFormatter formats queries as configured by opts and writes them to
+// w, or, if list is true, writes only those whose formatting differs.
+type queryFormatter struct {
+	w    io.Writer
+	list bool
+	opts jsonpath.FormatOpts
+}
+
+// format formats query and writes the result to f's output, or, if f lists
+// queries, writes query if its formatting differs. Passes blank lines
+// through unchanged.
+func (f *queryFormatter) format(query string) error {
+	var res string
+	if strings.TrimSpace(query) != "" {
+		path, err := jsonpath.Parse(query)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		res = path.Format(f.opts)
+	}
+
+	switch {
+	case !f.list:
+		_, err := fmt.Fprintln(f.w, res)
+		return err //nolint:wrapcheck
+	case res != query:
+		_, err := fmt.Fprintln(f.w, query)
+		return err //nolint:wrapcheck
+	default:
+		return nil
+	}
+}
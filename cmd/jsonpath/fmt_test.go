@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+)
+
+func TestRunFmt(t *testing.T) {
+	t.Parallel()
+
+	const query = `$["a"][?@.b<1&&@["c"]=="d"]`
+
+	for _, tc := range []struct {
+		test  string
+		args  []string
+		stdin string
+		exp   string
+		err   string
+	}{
+		{
+			test: "args",
+			args: []string{query, `$..*[ 0:2 ]`},
+			exp:  "$.a[?@.b < 1 && @.c == 'd']\n$..*[:2]\n",
+		},
+		{
+			test:  "stdin",
+			stdin: query + "\n\n$['x y']\n",
+			exp:   "$.a[?@.b < 1 && @.c == 'd']\n\n$['x y']\n",
+		},
+		{
+			test: "bracket",
+			args: []string{"-bracket", query},
+			exp:  "$['a'][?@['b'] < 1 && @['c'] == 'd']\n",
+		},
+		{
+			test: "double",
+			args: []string{"-double", query},
+			exp:  "$.a[?@.b < 1 && @.c == \"d\"]\n",
+		},
+		{
+			test: "compact",
+			args: []string{"-compact", query},
+			exp:  "$.a[?@.b<1&&@.c=='d']\n",
+		},
+		{
+			test: "all_styles",
+			args: []string{"-bracket", "-double", "-compact", query},
+			exp:  "$[\"a\"][?@[\"b\"]<1&&@[\"c\"]==\"d\"]\n",
+		},
+		{
+			test:  "list",
+			args:  []string{"-l"},
+			stdin: "$.a\n$[\"a\"]\n\n$.b[?@ == 'x']\n$.b[?@=='x']\n",
+			exp:   "$[\"a\"]\n$.b[?@=='x']\n",
+		},
+		{
+			test: "list_args",
+			args: []string{"-l", "-compact", "$.a[?@==1]", "$.a[?@ == 1]"},
+			exp:  "$.a[?@ == 1]\n",
+		},
+		{
+			test: "bad_flag",
+			args: []string{"-nope"},
+			err:  "usage: flag provided but not defined: -nope",
+		},
+		{
+			test: "bad_arg",
+			args: []string{"$.a", "$.a["},
+			exp:  "$.a\n",
+			err:  "jsonpath: unexpected eof at position 5",
+		},
+		{
+			test:  "bad_line",
+			stdin: "$.a\n$.a[\n",
+			exp:   "$.a\n",
+			err:   "stdin:2: jsonpath: unexpected eof at position 5",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			var stdout bytes.Buffer
+			err := run(append([]string{"fmt"}, tc.args...), strings.NewReader(tc.stdin), &stdout)
+			if tc.err != "" {
+				a.EqualError(err, tc.err)
+			} else {
+				a.NoError(err)
+			}
+			a.Equal(tc.exp, stdout.String())
+		})
+	}
+}
+
+func TestRunFmtCanonical(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// The default style should be the canonical style of jsonpath.Format.
+	for _, query := range []string{
+		`$["a"]..b[?@.c<1 || length(@["d e"])>=2]`,
+		`$[*, 1, -1, ::2]`,
+	} {
+		exp, err := jsonpath.Format(query)
+		a.NoError(err)
+		var stdout bytes.Buffer
+		a.NoError(run([]string{"fmt", query}, strings.NewReader(""), &stdout))
+		a.Equal(exp+"\n", stdout.String())
+	}
+}
+
+func TestRunFmtSemantics(t *testing.T) {
+	t.Parallel()
+
+	doc := map[string]any{
+		"list":   []any{0, 1, 2, 3, 4, 5, 6},
+		"a b":    map[string]any{"c": 1},
+		"1a":     "digit",
+		"$":      "dollar",
+		"\u0001": "control",
+		"'\"":    "quotes",
+		"café":   "unicode",
+		"items": []any{
+			map[string]any{"n": 1, "s": "x", "tags": []any{"a"}},
+			map[string]any{"n": 2.5, "s": "y\tz", "ok": true},
+			map[string]any{"n": -0.0, "s": nil},
+		},
+	}
+	parser := jsonpath.NewParser(jsonpath.WithSortedMembers())
+
+	// Formatting should never change what a query selects.
+	for _, query := range []string{
+		`$.list[0::-1]`,
+		`$.list[0:-3:-2]`,
+		`$.list[::-1]`,
+		`$.list[5:0:-2]`,
+		`$.list[-1::-3]`,
+		`$.list[:2:-1]`,
+		`$.list[ 1 : 5 : 2 ]`,
+		`$.list[0, -1, 1:3]`,
+		`$["a b"].c`,
+		`$['1a']`,
+		`$["$"]`,
+		`$["\u0001"]`,
+		`$["'\""]`,
+		`$["café"]`,
+		`$..*`,
+		`$..[0]`,
+		`$.items[?@.n > 1 || @.s == "y\tz"].s`,
+		`$.items[?!(@.ok == true) && (@.n == 1 || @.n == -0)]`,
+		`$.items[?@.n == 1.0 || @.n == 25e-1]`,
+		`$.items[?@.s == null]`,
+		`$.items[?length(@.tags) == 1 && match(@.s, "[x-z]")]`,
+		`$.items[?search(@.s, '\t') && !match(@.s, "x")]`,
+		`$.items[?count(@.*) >= 3 && value(@..n) != 2.5]`,
+		`$.items[?@.n > $.items[0].n]`,
+	} {
+		orig, err := parser.Parse(query)
+		require.NoError(t, err, query)
+		exp := orig.Select(doc)
+		for _, args := range [][]string{
+			{},
+			{"-bracket"},
+			{"-double"},
+			{"-compact"},
+			{"-bracket", "-double", "-compact"},
+		} {
+			var stdout bytes.Buffer
+			require.NoError(t, run(append(append([]string{"fmt"}, args...), query), strings.NewReader(""), &stdout))
+			formatted := strings.TrimSuffix(stdout.String(), "\n")
+			path, err := parser.Parse(formatted)
+			require.NoError(t, err, formatted)
+			assert.Equal(t, exp, path.Select(doc), "%v %v: %v", query, args, formatted)
+		}
+	}
+}
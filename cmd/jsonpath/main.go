@@ -0,0 +1,384 @@
+// Command jsonpath selects values from JSON documents with RFC 9535
+// JSONPath queries. It reads JSON from the named files, or from standard
+// input if there are none or a file is named "-", applies each query to
+// each document, and prints the selected nodes as JSON, one per line. Each
+// input may contain any number of JSON documents, such as newline-delimited
+// JSON.
+//
+// Usage:
+//
+//	jsonpath [-output format] [-l] [-indent | -ndjson] [-watch [-interval duration]] [-e path]... [path] [file...]
+//
+// The first argument is the query unless the -e flag appears, which may
+// appear more than once to apply several queries to each document, in
+// order. Numbers retain their original precision. The -output flag selects
+// one of these output formats:
+//
+//   - values: Each node as JSON on its own line, the default
+//   - paths: The normalized path of each node on its own line
+//   - both: The normalized path of each node, a tab, and the node as JSON
+//     on its own line; the -l flag is shorthand for -output both
+//   - json: The nodes each query selects from each document as a JSON array
+//   - jsonl: Each node as a JSON object with "path" and "node" members on
+//     its own line
+//   - tsv: The normalized path of each node, a tab, and the node on its own
+//     line, with strings as raw text and other values as JSON, and with
+//     backslashes, tabs, newlines, and carriage returns escaped as \\, \t,
+//     \n, and \r
+//   - raw: Each node on its own line, strings as raw text and other values
+//     as JSON, for use in shell scripts
+//
+// The -indent flag prints JSON indented, and supports the values, json, and
+// raw formats.
+//
+// The -ndjson flag reads [JSON Lines] input one line at a time, skipping
+// blank lines, and prints one line per query for each input line: the node
+// a singular query such as $.level selects, or null if it selects none, and
+// an array of the nodes that other queries select. With -output raw, it
+// prints strings selected by singular queries as raw text, and with -output
+// both, it prints an object that maps the normalized path of each node to
+// its value. It supports no other formats. Use it to filter log streams in
+// constant memory while preserving the correspondence between input and
+// output lines.
+//
+// The -watch flag prints the nodes selected from the JSON document in a
+// single file or HTTP or HTTPS URL, and then reads the file or URL again at
+// the interval set by the -interval flag, one second by default, until
+// interrupted. Requests for URLs time out after 30 seconds, and responses
+// larger than 64 MiB are errors. When its contents change, it prints the
+// changes to the selected nodes as -dry-run does for the set and delete
+// commands, described below, and reports errors, such as invalid JSON in a
+// partially written file, to standard error without exiting. Use it to monitor
+// configuration files and rendered manifests.
+//
+// The set and delete commands edit JSON documents:
+//
+//	jsonpath set [-in-place | -dry-run] path value [file...]
+//	jsonpath delete [-in-place | -dry-run] path [file...]
+//
+// The set command replaces each node that path selects with value, which
+// must be JSON, such as 42 or '"hello"'. If path is a singular query
+// without negative indexes, such as $.a.b, that selects no node, set adds
+// value as a new member or element at the location it identifies, provided
+// that its parent exists. The delete command removes each node that path
+// selects from its parent object or array. Both write the edited documents
+// to standard output as indented JSON, with object members sorted by name.
+// The -in-place flag instead writes them back to the files they were read
+// from, leaving unchanged files untouched, and the -dry-run flag writes a
+// line for the old value of each changed node, prefixed with "-", and for
+// its new value, prefixed with "+", after a header that names the file.
+//
+// The repl command loads a JSON document and evaluates queries against it
+// interactively:
+//
+//	jsonpath repl [-color] file
+//
+// It reads a line at a time, evaluating each query when it's entered, and
+// prints the nodes it selects with their normalized paths. For invalid
+// queries, it prints the query with carets beneath the text at which
+// parsing failed and a description of the error. End a query with a tab
+// character before entering it, or enter ":complete" and the query, to list
+// the completions of its last member name shorthand from the names of the
+// members in the document, as in $.store.bo. It reads input from the
+// terminal in its usual line mode, so it neither completes nor evaluates
+// queries as they're typed, only once Enter is pressed, and relies on the
+// terminal for line editing. The -color flag, the default when standard
+// output is a terminal and the NO_COLOR environment variable is not set,
+// colorizes the output. Enter ":help" for help and ":quit" to exit.
+//
+// The fmt command formats queries in a consistent style:
+//
+//	jsonpath fmt [-l] [-bracket] [-double] [-compact] [query...]
+//
+// It formats each query argument, or if there are none, each line of
+// standard input, and prints the results, one per line. By default, it
+// formats queries in the canonical style of [jsonpath.Format], as in
+// $.store.book[?@.price < 10].title. The -bracket flag formats all segments
+// in bracket notation, -double quotes names and strings with double
+// quotation marks, and -compact omits the spaces around operators. The -l
+// flag instead prints only the queries whose formatting differs, so that
+// scripts can check that stored queries are formatted.
+//
+// The completion command prints a completion script for bash, zsh, or fish:
+//
+//	jsonpath completion bash|zsh|fish
+//
+// Load it with source <(jsonpath completion bash) or the equivalent for
+// other shells. The script completes command names, flags, and output
+// formats, and, when the command line names a JSON file, the member names
+// of the first document in the file for queries that start with $, as in
+// $.store.bo. The man command prints a manual page in roff format:
+//
+//	jsonpath man > /usr/local/share/man/man1/jsonpath.1
+//
+// [JSON Lines]: https://jsonlines.org
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/theory/jsonpath"
+)
+
+var (
+	// errUsage errors are returned by run for invalid arguments.
+	errUsage = errors.New("usage")
+
+	// errLine errors are returned by run for -ndjson input lines that
+	// contain more than one JSON value.
+	errLine = errors.New("multiple JSON values on one line")
+)
+
+const usage = "jsonpath [-output format] [-l] [-indent | -ndjson] [-watch [-interval duration]] [-e path]... [path] [file...]"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "jsonpath: %v\n", err)
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// run parses args, applies the queries they describe to the JSON documents
+// in the files they name, or in stdin, and writes the selected nodes to
+// stdout. Passes args for the set and delete commands to runEdit, for the
+// repl command to runREPL, for the fmt command to runFmt, for the
+// completion command to runCompletion, for the man command to runMan, and
+// for the hidden __complete command, used by completion scripts, to
+// runComplete.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) > 0 {
+		switch {
+		case editUsage[args[0]] != "":
+			return runEdit(args[0], args[1:], stdin, stdout)
+		case args[0] == "repl":
+			return runREPL(args[1:], stdin, stdout)
+		case args[0] == "fmt":
+			return runFmt(args[1:], stdin, stdout)
+		case args[0] == "completion":
+			return runCompletion(args[1:], stdout)
+		case args[0] == "man":
+			return runMan(args[1:], stdout)
+		case args[0] == "__complete":
+			return runComplete(args[1:], stdout)
+		}
+	}
+
+	var queries []string
+	flags := flag.NewFlagSet("jsonpath", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	located := flags.Bool("l", false, "same as -output both")
+	indent := flags.Bool("indent", false, "indent JSON output")
+	ndjson := flags.Bool("ndjson", false, "read and write JSON Lines")
+	watch := flags.Bool("watch", false, "print changes to the results as the input changes")
+	interval := flags.Duration("interval", defaultInterval, "how often -watch checks for changes")
+	output := formatValues
+	flags.Func("output", "output format", func(s string) error {
+		var err error
+		output, err = parseFormat(s)
+		return err
+	})
+	flags.Func("e", "JSONPath query", func(q string) error {
+		queries = append(queries, q)
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if *located {
+		if output != formatValues && output != formatBoth {
+			return fmt.Errorf("%w: -l and -output %v are mutually exclusive", errUsage, output)
+		}
+		output = formatBoth
+	}
+	switch {
+	case *indent && *ndjson:
+		return fmt.Errorf("%w: -indent and -ndjson are mutually exclusive", errUsage)
+	case *indent && !output.indents():
+		return fmt.Errorf("%w: -indent and -output %v are mutually exclusive", errUsage, output)
+	case *ndjson && output != formatValues && output != formatRaw && output != formatBoth:
+		return fmt.Errorf("%w: -ndjson and -output %v are mutually exclusive", errUsage, output)
+	case *ndjson && *watch:
+		return fmt.Errorf("%w: -ndjson and -watch are mutually exclusive", errUsage)
+	case *interval <= 0:
+		return fmt.Errorf("%w: -interval must be positive", errUsage)
+	}
+
+	files := flags.Args()
+	if len(queries) == 0 {
+		if len(files) == 0 {
+			return fmt.Errorf("%w: %v", errUsage, usage)
+		}
+		queries, files = files[:1], files[1:]
+	}
+
+	paths := make([]*jsonpath.Path, len(queries))
+	for i, q := range queries {
+		p, err := jsonpath.Parse(q)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		paths[i] = p
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetEscapeHTML(false)
+	if *indent {
+		enc.SetIndent("", "  ")
+	}
+	out := &printer{w: stdout, enc: enc, format: output, ndjson: *ndjson}
+
+	if *watch {
+		return runWatch(files, paths, out, *interval)
+	}
+
+	if len(files) == 0 {
+		return out.process("stdin", stdin, paths)
+	}
+	for _, name := range files {
+		if err := processFile(name, stdin, paths, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processFile applies paths to the JSON documents in the file named name,
+// or in stdin if name is "-", and prints the selected nodes with out.
+func processFile(name string, stdin io.Reader, paths []*jsonpath.Path, out *printer) error {
+	if name == "-" {
+		return out.process("stdin", stdin, paths)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	defer f.Close()
+	return out.process(name, f, paths)
+}
+
+// process applies paths to each JSON document read from r, which reads the
+// input named name, and prints the nodes they select.
+func (p *printer) process(name string, r io.Reader, paths []*jsonpath.Path) error {
+	if p.ndjson {
+		return p.processLines(name, r, paths)
+	}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	for {
+		var doc any
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		if err := p.printDoc(name, doc, paths); err != nil {
+			return err
+		}
+	}
+}
+
+// printDoc applies paths to doc, read from the input named name, and prints
+// the nodes they select.
+func (p *printer) printDoc(name string, doc any, paths []*jsonpath.Path) error {
+	for _, path := range paths {
+		nodes, err := path.TrySelectLocated(doc)
+		if err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		if p.format == formatJSON {
+			if err := p.enc.Encode(values(nodes)); err != nil {
+				return err //nolint:wrapcheck
+			}
+			continue
+		}
+		for _, node := range nodes {
+			if err := p.print(node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// processLines applies paths to the JSON document on each line read from r,
+// which reads the input named name, and prints one line for each path with
+// the nodes it selects.
+func (p *printer) processLines(name string, r io.Reader, paths []*jsonpath.Path) error {
+	buf := bufio.NewReader(r)
+	for num := 1; ; num++ {
+		line, err := buf.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if err := p.printLine(line, paths); err != nil {
+				return fmt.Errorf("%v:%d: %w", name, num, err)
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("%v: %w", name, err)
+		}
+	}
+}
+
+// printLine applies paths to the JSON document in line and prints a line
+// for each with the nodes it selects.
+func (p *printer) printLine(line []byte, paths []*jsonpath.Path) error {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if dec.More() {
+		return errLine
+	}
+
+	for _, path := range paths {
+		nodes, err := path.TrySelectLocated(doc)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		var res any
+		switch {
+		case p.format == formatBoth:
+			obj := make(map[string]any, len(nodes))
+			for _, node := range nodes {
+				obj[node.Path.String()] = node.Node
+			}
+			res = obj
+		case path.Query().Singular() != nil:
+			if len(nodes) > 0 {
+				res = nodes[0].Node
+			}
+		default:
+			res = values(nodes)
+		}
+		if err := p.printValue(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// values returns the values of nodes.
+func values(nodes jsonpath.LocatedNodeList) []any {
+	vals := make([]any, len(nodes))
+	for i, node := range nodes {
+		vals[i] = node.Node
+	}
+	return vals
+}
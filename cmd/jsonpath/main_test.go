@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "doc.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"a": [1, {"b": "x"}], "c": 1.50}`), 0o600))
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`{"a": `), 0o600))
+
+	for _, tc := range []struct {
+		test  string
+		args  []string
+		stdin string
+		exp   string
+		err   string
+	}{
+		{
+			test:  "stdin",
+			args:  []string{"$.a[*]"},
+			stdin: `{"a": [1, "<b>", null]}`,
+			exp:   "1\n\"<b>\"\nnull\n",
+		},
+		{
+			test:  "stream",
+			args:  []string{"$.a"},
+			stdin: "{\"a\": 1}\n{\"b\": 2}\n{\"a\": 3}\n",
+			exp:   "1\n3\n",
+		},
+		{
+			test: "file",
+			args: []string{"$..b", file},
+			exp:  "\"x\"\n",
+		},
+		{
+			test:  "file_and_stdin",
+			args:  []string{"$.c", file, "-"},
+			stdin: `{"c": 2}`,
+			exp:   "1.50\n2\n",
+		},
+		{
+			test: "located",
+			args: []string{"-l", "$.a[*]", file},
+			exp:  "$['a'][0]\t1\n$['a'][1]\t{\"b\":\"x\"}\n",
+		},
+		{
+			test: "indent",
+			args: []string{"-indent", "$.a[1]", file},
+			exp:  "{\n  \"b\": \"x\"\n}\n",
+		},
+		{
+			test: "multiple_queries",
+			args: []string{"-e", "$.c", "-e", "$.a[0]", file},
+			exp:  "1.50\n1\n",
+		},
+		{
+			test:  "no_match",
+			args:  []string{"$.nope"},
+			stdin: `{"a": 1}`,
+			exp:   "",
+		},
+		{
+			test:  "ndjson",
+			args:  []string{"-ndjson", "-e", "$.level", "-e", "$.tags[*]"},
+			stdin: "{\"level\": \"info\", \"tags\": [\"a\", \"b\"]}\n\n  \n{\"tags\": []}\n{\"level\": 1.0}",
+			exp:   "\"info\"\n[\"a\",\"b\"]\nnull\n[]\n1.0\n[]\n",
+		},
+		{
+			test:  "ndjson_located",
+			args:  []string{"--ndjson", "-l", "$..b"},
+			stdin: "{\"a\": {\"b\": 1}, \"b\": 2}\n{}\n",
+			exp:   "{\"$['a']['b']\":1,\"$['b']\":2}\n{}\n",
+		},
+		{
+			test: "ndjson_file",
+			args: []string{"-ndjson", "$.c", file},
+			exp:  "1.50\n",
+		},
+		{
+			test:  "ndjson_bad_line",
+			args:  []string{"-ndjson", "$.a"},
+			stdin: "{\"a\": 1}\n{\"a\": \n",
+			exp:   "1\n",
+			err:   "stdin:2: unexpected EOF",
+		},
+		{
+			test:  "ndjson_multiple_values",
+			args:  []string{"-ndjson", "$.a"},
+			stdin: "{\"a\": 1} {\"a\": 2}\n",
+			err:   "stdin:1: multiple JSON values on one line",
+		},
+		{
+			test: "ndjson_indent",
+			args: []string{"-ndjson", "-indent", "$"},
+			err:  "usage: -indent and -ndjson are mutually exclusive",
+		},
+		{
+			test:  "output_values",
+			args:  []string{"-output", "values", "$.a[*]"},
+			stdin: `{"a": ["x", 1]}`,
+			exp:   "\"x\"\n1\n",
+		},
+		{
+			test:  "output_paths",
+			args:  []string{"--output=paths", "$..b"},
+			stdin: `{"a": [{"b": 1}, {"b": 2}]}`,
+			exp:   "$['a'][0]['b']\n$['a'][1]['b']\n",
+		},
+		{
+			test:  "output_both",
+			args:  []string{"-output", "both", "$.a[*]"},
+			stdin: `{"a": ["x", 1]}`,
+			exp:   "$['a'][0]\t\"x\"\n$['a'][1]\t1\n",
+		},
+		{
+			test:  "output_both_located",
+			args:  []string{"-output", "both", "-l", "$.a[0]"},
+			stdin: `{"a": ["x", 1]}`,
+			exp:   "$['a'][0]\t\"x\"\n",
+		},
+		{
+			test:  "output_json",
+			args:  []string{"-output", "json", "-e", "$.a[*]", "-e", "$.nope"},
+			stdin: `{"a": ["x", 1]} {"a": []}`,
+			exp:   "[\"x\",1]\n[]\n[]\n[]\n",
+		},
+		{
+			test:  "output_json_indent",
+			args:  []string{"-output", "json", "-indent", "$.a[*]"},
+			stdin: `{"a": ["x", 1]}`,
+			exp:   "[\n  \"x\",\n  1\n]\n",
+		},
+		{
+			test:  "output_jsonl",
+			args:  []string{"-output", "jsonl", "$.a[*]"},
+			stdin: `{"a": ["x", 1]}`,
+			exp:   "{\"node\":\"x\",\"path\":\"$['a'][0]\"}\n{\"node\":1,\"path\":\"$['a'][1]\"}\n",
+		},
+		{
+			test:  "output_tsv",
+			args:  []string{"-output", "tsv", "$.a[*]"},
+			stdin: `{"a": ["x\ty\nz\\", [1, "<2>"]]}`,
+			exp:   "$['a'][0]\tx\\ty\\nz\\\\\n$['a'][1]\t[1,\"<2>\"]\n",
+		},
+		{
+			test:  "output_tsv_path",
+			args:  []string{"-output", "tsv", `$["a\tb"]`},
+			stdin: `{"a\tb": "x"}`,
+			exp:   "$['a\\\\tb']\tx\n",
+		},
+		{
+			test:  "output_raw",
+			args:  []string{"-output", "raw", "$.a[*]"},
+			stdin: `{"a": ["x y", "\"q\"", 1, null, {"b": true}]}`,
+			exp:   "x y\n\"q\"\n1\nnull\n{\"b\":true}\n",
+		},
+		{
+			test:  "output_raw_ndjson",
+			args:  []string{"-output", "raw", "-ndjson", "-e", "$.msg", "-e", "$.tags[*]"},
+			stdin: "{\"msg\": \"hi there\", \"tags\": [\"a\"]}\n{\"msg\": 1}\n",
+			exp:   "hi there\n[\"a\"]\n1\n[]\n",
+		},
+		{
+			test: "output_unknown",
+			args: []string{"-output", "yaml", "$"},
+			err:  `usage: invalid value "yaml" for flag -output: unknown format "yaml"; expected one of values, paths, both, json, jsonl, tsv, raw`,
+		},
+		{
+			test: "output_located",
+			args: []string{"-output", "paths", "-l", "$"},
+			err:  "usage: -l and -output paths are mutually exclusive",
+		},
+		{
+			test: "output_indent",
+			args: []string{"-output", "tsv", "-indent", "$"},
+			err:  "usage: -indent and -output tsv are mutually exclusive",
+		},
+		{
+			test: "output_ndjson",
+			args: []string{"-output", "json", "-ndjson", "$"},
+			err:  "usage: -ndjson and -output json are mutually exclusive",
+		},
+		{
+			test: "bad_flag",
+			args: []string{"-nope"},
+			err:  "usage: flag provided but not defined: -nope",
+		},
+		{
+			test: "no_args",
+			args: []string{},
+			err:  "usage: jsonpath [-output format] [-l] [-indent | -ndjson] [-watch [-interval duration]] [-e path]... [path] [file...]",
+		},
+		{
+			test: "bad_path",
+			args: []string{"$.a["},
+			err:  "jsonpath: unexpected eof at position 5",
+		},
+		{
+			test: "no_file",
+			args: []string{"$", filepath.Join(dir, "nope.json")},
+			err:  "open " + filepath.Join(dir, "nope.json") + ": no such file or directory",
+		},
+		{
+			test: "bad_json",
+			args: []string{"$", bad},
+			err:  bad + ": unexpected EOF",
+		},
+		{
+			test:  "bad_stdin",
+			args:  []string{"$"},
+			stdin: `{"a": 1} [`,
+			exp:   "{\"a\":1}\n",
+			err:   "stdin: unexpected EOF",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			var stdout bytes.Buffer
+			err := run(tc.args, strings.NewReader(tc.stdin), &stdout)
+			if tc.err != "" {
+				a.EqualError(err, tc.err)
+			} else {
+				a.NoError(err)
+			}
+			a.Equal(tc.exp, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+const manUsage = "jsonpath man"
+
+// manPage is the jsonpath manual page, in roff format for man(1).
+const manPage = `.TH JSONPATH 1
+.SH NAME
+jsonpath \- select values from JSON documents with RFC 9535 JSONPath queries
+.SH SYNOPSIS
+.B jsonpath
+[\fB\-output\fR \fIformat\fR] [\fB\-l\fR] [\fB\-indent\fR | \fB\-ndjson\fR] [\fB\-watch\fR [\fB\-interval\fR \fIduration\fR]] [\fB\-e\fR \fIpath\fR]... [\fIpath\fR] [\fIfile\fR...]
+.br
+.B jsonpath set
+[\fB\-in\-place\fR | \fB\-dry\-run\fR] \fIpath\fR \fIvalue\fR [\fIfile\fR...]
+.br
+.B jsonpath delete
+[\fB\-in\-place\fR | \fB\-dry\-run\fR] \fIpath\fR [\fIfile\fR...]
+.br
+.B jsonpath repl
+[\fB\-color\fR] \fIfile\fR
+.br
+.B jsonpath fmt
+[\fB\-l\fR] [\fB\-bracket\fR] [\fB\-double\fR] [\fB\-compact\fR] [\fIquery\fR...]
+.br
+.B jsonpath completion
+\fBbash\fR | \fBzsh\fR | \fBfish\fR
+.br
+.B jsonpath man
+.SH DESCRIPTION
+.B jsonpath
+reads JSON from the named files, or from standard input if there are none
+or a file is named \-, applies each query to each document, and prints the
+selected nodes as JSON, one per line. Each input may contain any number of
+JSON documents, such as newline\-delimited JSON. Numbers retain their
+original precision.
+.PP
+The first argument is the query unless the \fB\-e\fR option appears.
+.SH OPTIONS
+.TP
+.BI \-e " path"
+Apply \fIpath\fR to each document. May appear more than once to apply
+several queries to each document, in order.
+.TP
+.BI \-output " format"
+Print nodes in \fIformat\fR: \fBvalues\fR, each node as JSON, the default;
+\fBpaths\fR, the normalized path of each node; \fBboth\fR, the normalized
+path of each node, a tab, and the node as JSON; \fBjson\fR, the nodes each
+query selects from each document as a JSON array; \fBjsonl\fR, each node as
+a JSON object with path and node members; \fBtsv\fR, the normalized path
+of each node, a tab, and the node, with strings as raw text; or \fBraw\fR,
+each node with strings as raw text.
+.TP
+.B \-l
+Same as \fB\-output both\fR.
+.TP
+.B \-indent
+Print JSON indented. Supports the values, json, and raw formats.
+.TP
+.B \-ndjson
+Read JSON Lines input one line at a time and print one line per query for
+each input line: the node a singular query selects, or null if it selects
+none, and an array of the nodes that other queries select.
+.TP
+.B \-watch
+Print the nodes selected from a single file or HTTP or HTTPS URL, then read
+it again at each interval and print the changes to the selected nodes
+until interrupted. Requests for URLs time out after 30 seconds, and
+responses larger than 64 MiB are errors.
+.TP
+.BI \-interval " duration"
+How often \fB\-watch\fR reads its input, one second by default.
+.SH COMMANDS
+.TP
+.B set
+Replace each node that \fIpath\fR selects with \fIvalue\fR, which must be
+JSON, or add it at the location a singular \fIpath\fR identifies. Writes
+the edited documents to standard output as indented JSON. The
+\fB\-in\-place\fR option instead writes them back to their files, and
+\fB\-dry\-run\fR prints the changes.
+.TP
+.B delete
+Remove each node that \fIpath\fR selects from its parent. Supports the
+same options as \fBset\fR.
+.TP
+.B repl
+Load the JSON document in \fIfile\fR and evaluate queries entered
+interactively, one line at a time. Queries are evaluated and completed
+only when Enter is pressed, not as they're typed. End a query with a tab
+before pressing Enter, or enter :complete and the query, to list the
+completions of its last member name. Enter :help for help. The
+\fB\-color\fR option colorizes the output.
+.TP
+.B fmt
+Format each \fIquery\fR, or each line of standard input, in a consistent
+style. The \fB\-bracket\fR option formats all segments in bracket
+notation, \fB\-double\fR quotes with double quotation marks,
+\fB\-compact\fR omits the spaces around operators, and \fB\-l\fR prints
+only the queries whose formatting differs.
+.TP
+.B completion
+Print a completion script for \fBbash\fR, \fBzsh\fR, or \fBfish\fR. The
+script completes commands, options, and output formats, and member names
+in queries from the first JSON document named on the command line.
+.TP
+.B man
+Print this manual page.
+.SH EXAMPLES
+Print the titles of the books in store.json:
+.PP
+.RS
+jsonpath '$.store.book[*].title' store.json
+.RE
+.PP
+Print the prices of cheap books with their normalized paths:
+.PP
+.RS
+jsonpath \-l '$..book[?@.price < 10].price' store.json
+.RE
+.PP
+Enable completion in bash:
+.PP
+.RS
+source <(jsonpath completion bash)
+.RE
+.PP
+Install the manual page:
+.PP
+.RS
+jsonpath man > /usr/local/share/man/man1/jsonpath.1
+.RE
+.SH EXIT STATUS
+0 on success, 1 on failure, and 2 for invalid arguments.
+.SH SEE ALSO
+RFC 9535, https://www.rfc-editor.org/rfc/rfc9535.html
+`
+
+// runMan parses args for the man command and writes the manual page to
+// stdout.
+func runMan(args []string, stdout io.Writer) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: %v", errUsage, manUsage)
+	}
+	_, err := io.WriteString(stdout, manPage)
+	return err //nolint:wrapcheck
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// format identifies an output format for selected nodes.
+type format string
+
+const (
+	// formatValues prints each node as JSON on its own line.
+	formatValues format = "values"
+	// formatPaths prints the normalized path of each node on its own line.
+	formatPaths format = "paths"
+	// formatBoth prints the normalized path of each node, a tab, and the
+	// node as JSON on its own line.
+	formatBoth format = "both"
+	// formatJSON prints the nodes that each query selects from each
+	// document as a JSON array.
+	formatJSON format = "json"
+	// formatJSONL prints each node as a JSON object with "path" and "node"
+	// members on its own line.
+	formatJSONL format = "jsonl"
+	// formatTSV prints the normalized path of each node, a tab, and the
+	// node as raw text, escaped for tab-separated values, on its own line.
+	formatTSV format = "tsv"
+	// formatRaw prints each node on its own line, strings as raw text and
+	// other values as JSON.
+	formatRaw format = "raw"
+)
+
+// formats lists the supported output formats.
+var formats = []format{
+	formatValues, formatPaths, formatBoth, formatJSON, formatJSONL, formatTSV, formatRaw,
+}
+
+// parseFormat parses s into a format.
+func parseFormat(s string) (format, error) {
+	if f := format(s); slices.Contains(formats, f) {
+		return f, nil
+	}
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown format %q; expected one of %v", s, strings.Join(names, ", "))
+}
+
+// indents returns true if f supports indented JSON output.
+func (f format) indents() bool {
+	return f == formatValues || f == formatJSON || f == formatRaw
+}
+
+// printer prints the nodes that paths select from JSON documents.
+type printer struct {
+	w      io.Writer
+	enc    *json.Encoder
+	format format
+	ndjson bool
+}
+
+// print prints node in p's format.
+func (p *printer) print(node *spec.LocatedNode) error {
+	var err error
+	switch p.format {
+	case formatPaths:
+		_, err = fmt.Fprintln(p.w, node.Path)
+	case formatBoth:
+		if _, err = fmt.Fprintf(p.w, "%v\t", node.Path); err == nil {
+			err = p.enc.Encode(node.Node)
+		}
+	case formatJSONL:
+		err = p.enc.Encode(node)
+	case formatTSV:
+		var text string
+		if text, err = p.raw(node.Node); err == nil {
+			_, err = fmt.Fprintf(p.w, "%v\t%v\n", escapeTSV(node.Path.String()), escapeTSV(text))
+		}
+	default:
+		err = p.printValue(node.Node)
+	}
+	return err
+}
+
+// printValue prints val as JSON, or as raw text if p's format is
+// formatRaw and val is a string.
+func (p *printer) printValue(val any) error {
+	if s, ok := val.(string); ok && p.format == formatRaw {
+		_, err := fmt.Fprintln(p.w, s)
+		return err //nolint:wrapcheck
+	}
+	return p.enc.Encode(val) //nolint:wrapcheck
+}
+
+// raw returns val as raw text if it's a string and as compact JSON
+// otherwise.
+func (p *printer) raw(val any) (string, error) {
+	if s, ok := val.(string); ok {
+		return s, nil
+	}
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(val); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// tsvEscaper escapes the characters that delimit tab-separated values.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// escapeTSV escapes backslashes, tabs, newlines, and carriage returns in s
+// so that it occupies a single field of tab-separated values.
+func escapeTSV(s string) string {
+	return tsvEscaper.Replace(s)
+}
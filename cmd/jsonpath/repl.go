@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+)
+
+const replUsage = "jsonpath repl [-color] file"
+
+// replHelp describes the commands the REPL supports.
+const replHelp = `Enter a JSONPath query to select nodes from the document, such as
+$.store.book[?@.price < 10].title. Queries are read a line at a time, so
+evaluation and completion happen only when you press Enter: end a query
+with a tab before pressing Enter to list the member names that complete
+its last name selector. Commands:
+
+  :complete query  List the member names that complete query
+  :help            Show this help
+  :quit            Exit; so does end of input
+`
+
+// ANSI escape sequences for colorized output.
+const (
+	ansiRed   = "\x1b[31m"
+	ansiCyan  = "\x1b[36m"
+	ansiDim   = "\x1b[2m"
+	ansiReset = "\x1b[0m"
+)
+
+// runREPL parses args for the repl command, loads the JSON document in the
+// file they name, and evaluates queries read from stdin against it,
+// writing the results to stdout.
+func runREPL(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("jsonpath repl", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	color := flags.Bool("color", isTerminal(stdout), "colorize output")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%w: %v", errUsage, replUsage)
+	}
+
+	name := flags.Arg(0)
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	doc, err := decodeValue(data)
+	if err != nil {
+		return fmt.Errorf("%v: %w", name, err)
+	}
+
+	r := &repl{doc: doc, w: stdout, color: *color}
+	return r.run(stdin)
+}
+
+// isTerminal returns true if w is a terminal and the NO_COLOR environment
+// variable is not set.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// repl evaluates JSONPath queries against a document. It reads whole lines
+// rather than keystrokes, leaving the terminal in line mode, so that it
+// needs no platform-specific terminal handling; it therefore evaluates and
+// completes queries only once they're entered, never as they're typed.
+type repl struct {
+	doc   any
+	w     io.Writer
+	color bool
+	err   error
+}
+
+// run reads lines from r and evaluates them until it reads :quit or
+// reaches the end of r.
+func (r *repl) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for r.printf("jsonpath> "); scanner.Scan(); r.printf("jsonpath> ") {
+		line := scanner.Text()
+		if strings.HasSuffix(line, "\t") {
+			r.complete(strings.TrimRight(line, "\t"))
+			continue
+		}
+
+		line = strings.TrimSpace(line)
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch cmd {
+		case "":
+		case ":quit", ":q":
+			return r.err
+		case ":help":
+			r.printf("%s", replHelp)
+		case ":complete":
+			r.complete(strings.TrimSpace(arg))
+		default:
+			r.eval(line)
+		}
+		if r.err != nil {
+			return r.err
+		}
+	}
+	r.printf("\n")
+	if err := scanner.Err(); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return r.err
+}
+
+// eval evaluates query and prints the nodes it selects, each with its
+// normalized path, followed by their count.
+func (r *repl) eval(query string) {
+	path, err := jsonpath.Parse(query)
+	if err != nil {
+		r.printParseError(query, err)
+		return
+	}
+	nodes, err := path.TrySelectLocated(r.doc)
+	if err != nil {
+		r.printf("%v\n", r.paint(ansiRed, err.Error()))
+		return
+	}
+	for _, node := range nodes {
+		data, err := marshal(node.Node, "")
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.printf("%v\t%s", r.paint(ansiCyan, node.Path.String()), data)
+	}
+	noun := "nodes"
+	if len(nodes) == 1 {
+		noun = "node"
+	}
+	r.printf("%v\n", r.paint(ansiDim, fmt.Sprintf("(%d %v)", len(nodes), noun)))
+}
+
+// printParseError prints err, the error returned for parsing query, with
+// query, the text at which the error occurred highlighted, and carets
+// beneath it.
+func (r *repl) printParseError(query string, err error) {
+	var pe *jsonpath.ParseError
+	if !errors.As(err, &pe) {
+		r.printf("%v\n", r.paint(ansiRed, err.Error()))
+		return
+	}
+
+	start, end := min(pe.Start, len(query)), min(pe.End, len(query))
+	r.printf("  %v%v%v\n", query[:start], r.paint(ansiRed, query[start:end]), query[end:])
+	width := max(utf8.RuneCountInString(query[start:end]), 1)
+	r.printf(
+		"  %v%v\n",
+		strings.Repeat(" ", utf8.RuneCountInString(query[:start])),
+		r.paint(ansiRed, strings.Repeat("^", width)),
+	)
+	r.printf("%v\n", r.paint(ansiRed, err.Error()))
+	if pe.Hint != "" {
+		r.printf("hint: %v\n", pe.Hint)
+	}
+}
+
+// complete prints the completions of the last member name in query, one
+// per line.
+func (r *repl) complete(query string) {
+	for _, c := range completions(r.doc, query) {
+		r.printf("%v\n", c)
+	}
+}
+
+// completions returns the queries that complete the trailing member name
+// shorthand in query, such as $.store.bo or $..ti, with the names of the
+// members of the objects from which the rest of query selects in doc,
+// sorted and without duplicates. Returns nil if query does not end with
+// member name shorthand or if the rest of query is invalid.
+func completions(doc any, query string) []string {
+	dot := lastDot(query)
+	if dot < 0 {
+		return nil
+	}
+	partial := query[dot+1:]
+	if partial != "" && !strings.HasPrefix(memberSegment(partial), ".") {
+		return nil
+	}
+
+	base := query[:dot]
+	descendant := strings.HasSuffix(base, ".")
+	path, err := jsonpath.Parse(strings.TrimSuffix(base, "."))
+	if err != nil {
+		return nil
+	}
+	nodes := path.Select(doc)
+	if descendant {
+		nodes = append(nodes, path.Append(spec.Descendant(spec.Wildcard())).Select(doc)...)
+	}
+	return complete(query[:dot+1], partial, nodes)
+}
+
+// complete returns prefix followed by each member name in the objects in
+// nodes that starts with partial, using bracket notation for names that
+// cannot use shorthand, sorted and without duplicates.
+func complete(prefix, partial string, nodes []any) []string {
+	var names []string
+	for _, node := range nodes {
+		obj, ok := spec.AsObject(node)
+		if !ok {
+			continue
+		}
+		for name := range obj.Iterate() {
+			if strings.HasPrefix(name, partial) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	// Bracketed names follow the descendant segment's double dot, but
+	// replace the child segment's single dot.
+	bracketPrefix := prefix
+	if !strings.HasSuffix(prefix, "..") {
+		bracketPrefix = strings.TrimSuffix(prefix, ".")
+	}
+	res := make([]string, len(names))
+	for i, name := range names {
+		if seg := memberSegment(name); strings.HasPrefix(seg, ".") {
+			res[i] = prefix + seg[1:]
+		} else {
+			res[i] = bracketPrefix + seg
+		}
+	}
+	return res
+}
+
+// lastDot returns the index of the last dot in query outside of brackets
+// and quoted strings, or -1 if there is none.
+func lastDot(query string) int {
+	dot, depth := -1, 0
+	var quote rune
+	escaped := false
+	for i, c := range query {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			switch c {
+			case '\\':
+				escaped = true
+			case quote:
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case c == '.' && depth == 0:
+			dot = i
+		}
+	}
+	return dot
+}
+
+// memberSegment returns the child segment that selects the member named
+// name, formatted by [spec.PathQuery.Format] in shorthand notation, such as
+// .name, if name is valid member name shorthand, and otherwise in bracket
+// notation, such as ['a b'].
+func memberSegment(name string) string {
+	q := spec.Query(true, spec.Child(spec.Name(name)))
+	return strings.TrimPrefix(q.Format(spec.FormatOpts{Shorthand: true, SingleQuotes: true}), "$")
+}
+
+// paint wraps s in the ANSI color escape sequence code if r colorizes its
+// output.
+func (r *repl) paint(code, s string) string {
+	if !r.color || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// printf writes to r's output, recording the first error.
+func (r *repl) printf(format string, args ...any) {
+	if r.err == nil {
+		_, r.err = fmt.Fprintf(r.w, format, args...)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunREPL(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "doc.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"a": [{"b": 1, "x y": 2}, {"b": "<c>"}], "ab": true}`), 0o600))
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`{"a": `), 0o600))
+
+	for _, tc := range []struct {
+		test  string
+		args  []string
+		stdin string
+		exp   string
+		err   string
+	}{
+		{
+			test:  "eval",
+			args:  []string{file},
+			stdin: "$.a[*].b\n\n$.ab\n$.nope\n",
+			exp: "jsonpath> $['a'][0]['b']\t1\n$['a'][1]['b']\t\"<c>\"\n(2 nodes)\n" +
+				"jsonpath> jsonpath> $['ab']\ttrue\n(1 node)\n" +
+				"jsonpath> (0 nodes)\njsonpath> \n",
+		},
+		{
+			test:  "quit",
+			args:  []string{file},
+			stdin: "$.ab\n:quit\n$.a\n",
+			exp:   "jsonpath> $['ab']\ttrue\n(1 node)\njsonpath> ",
+		},
+		{
+			test:  "help",
+			args:  []string{file},
+			stdin: ":help\n:q\n",
+			exp:   "jsonpath> " + replHelp + "jsonpath> ",
+		},
+		{
+			test:  "complete",
+			args:  []string{file},
+			stdin: "$.a\t\n:complete $.a[0].\n:complete $..b\n",
+			exp: "jsonpath> $.a\n$.ab\n" +
+				"jsonpath> $.a[0].b\n$.a[0]['x y']\n" +
+				"jsonpath> $..b\njsonpath> \n",
+		},
+		{
+			test:  "parse_error",
+			args:  []string{file},
+			stdin: "$.a[?@.b < ]\n$.a[?nope()]\n",
+			exp: "jsonpath>   $.a[?@.b < ]\n" +
+				"             ^\n" +
+				"jsonpath: unexpected ']' at position 12\n" +
+				"jsonpath>   $.a[?nope()]\n" +
+				"       ^^^^\n" +
+				"jsonpath: unknown function nope() at position 6\n" +
+				"hint: register function extensions with a registry.Registry\n" +
+				"jsonpath> \n",
+		},
+		{
+			test:  "color",
+			args:  []string{"-color", file},
+			stdin: "$.ab\n$.a[\n",
+			exp: "jsonpath> \x1b[36m$['ab']\x1b[0m\ttrue\n\x1b[2m(1 node)\x1b[0m\n" +
+				"jsonpath>   $.a[\n      \x1b[31m^\x1b[0m\n\x1b[31mjsonpath: unexpected eof at position 5\x1b[0m\n" +
+				"jsonpath> \n",
+		},
+		{
+			test: "usage",
+			args: []string{},
+			err:  "usage: jsonpath repl [-color] file",
+		},
+		{
+			test: "bad_flag",
+			args: []string{"-nope", file},
+			err:  "usage: flag provided but not defined: -nope",
+		},
+		{
+			test: "no_file",
+			args: []string{file + ".nope"},
+			err:  "open " + file + ".nope: no such file or directory",
+		},
+		{
+			test: "bad_json",
+			args: []string{bad},
+			err:  bad + ": unexpected EOF",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			var stdout bytes.Buffer
+			err := run(append([]string{"repl"}, tc.args...), strings.NewReader(tc.stdin), &stdout)
+			if tc.err != "" {
+				a.EqualError(err, tc.err)
+			} else {
+				a.NoError(err)
+			}
+			a.Equal(tc.exp, stdout.String())
+		})
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	t.Parallel()
+
+	doc := map[string]any{
+		"store": map[string]any{
+			"book":    []any{map[string]any{"title": "A", "the end": 1}},
+			"bicycle": map[string]any{"color": "red"},
+		},
+		"books": 1,
+	}
+
+	for _, tc := range []struct {
+		test  string
+		query string
+		exp   []string
+	}{
+		{"root", "$.", []string{"$.books", "$.store"}},
+		{"partial", "$.bo", []string{"$.books"}},
+		{"nested", "$.store.b", []string{"$.store.bicycle", "$.store.book"}},
+		{"elements", "$.store.book[*].t", []string{"$.store.book[*]['the end']", "$.store.book[*].title"}},
+		{"descendants", "$..t", []string{"$..['the end']", "$..title"}},
+		{"descendants_of", "$.store..c", []string{"$.store..color"}},
+		{"brackets", `$["store"].bi`, []string{`$["store"].bicycle`}},
+		{"filter", "$.store.book[?@.title != 'a.b'].t", []string{"$.store.book[?@.title != 'a.b']['the end']", "$.store.book[?@.title != 'a.b'].title"}},
+		{"no_dot", "$", nil},
+		{"not_shorthand", "$.store.1", nil},
+		{"invalid_base", "$.store[.b", nil},
+		{"invalid_descendant", "$...b", nil},
+		{"no_match", "$.nope.", nil},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			got := completions(doc, tc.query)
+			if tc.exp == nil {
+				assert.Empty(t, got)
+			} else {
+				assert.Equal(t, tc.exp, got)
+			}
+		})
+	}
+}
+
+func TestMemberSegment(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, name := range []string{"a", "_", "a1", "A_b", "été", "日本"} {
+		a.Equal("."+name, memberSegment(name))
+	}
+	for _, name := range []string{"", "1", "a b", "a-b", "a.b", "$"} {
+		a.Equal("['"+name+"']", memberSegment(name))
+	}
+	a.Equal(`['it\'s']`, memberSegment("it's"))
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/theory/jsonpath"
+)
+
+// defaultInterval is the default interval at which -watch reads its input.
+const defaultInterval = time.Second
+
+const (
+	// fetchTimeout limits the time -watch waits for a response from a URL,
+	// so that a stalled server cannot hang it.
+	fetchTimeout = 30 * time.Second
+
+	// maxFetchSize limits the size of the responses -watch reads from a
+	// URL, so that a large response cannot exhaust memory.
+	maxFetchSize = 64 << 20
+)
+
+// errTooLarge errors are returned for responses larger than maxFetchSize.
+var errTooLarge = errors.New("response too large")
+
+// runWatch prints the nodes that paths select from the file or URL named
+// by the single item in files, then prints the changes to them whenever
+// its contents change, checking at interval until interrupted.
+func runWatch(files []string, paths []*jsonpath.Path, out *printer, interval time.Duration) error {
+	if len(files) != 1 || files[0] == "-" {
+		return fmt.Errorf("%w: -watch requires a single file or URL", errUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	w := &watcher{name: files[0], load: loader(files[0]), paths: paths, out: out, stderr: os.Stderr}
+	return w.loop(ctx, ticker.C)
+}
+
+// loader returns a function that reads the contents of the file or, if
+// name starts with http:// or https://, the URL named name.
+func loader(name string) func(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(name, "http://") && !strings.HasPrefix(name, "https://") {
+		return func(context.Context) ([]byte, error) {
+			return os.ReadFile(name) //nolint:wrapcheck
+		}
+	}
+	client := &http.Client{Timeout: fetchTimeout}
+	return func(ctx context.Context) ([]byte, error) {
+		return fetch(ctx, client, name, maxFetchSize)
+	}
+}
+
+// fetch uses client to read the contents of the URL named name. Returns an
+// [errTooLarge] error if they exceed limit bytes.
+func fetch(ctx context.Context, client *http.Client, name string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v: %v", name, res.Status) //nolint:err113
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, limit+1))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%v: %w: more than %d bytes", name, errTooLarge, limit)
+	}
+	return data, nil
+}
+
+// watcher prints the changes to the nodes that paths select from an input
+// as its contents change.
+type watcher struct {
+	name   string
+	load   func(ctx context.Context) ([]byte, error)
+	paths  []*jsonpath.Path
+	out    *printer
+	stderr io.Writer
+	data   []byte
+	doc    any
+}
+
+// loop prints the nodes that w's paths select from its input, then reloads
+// the input for each tick received from ticks and prints the changes to the
+// selected nodes if its contents changed, until ctx is done. Returns an
+// error if the initial load fails, and reports subsequent errors to w's
+// stderr.
+func (w *watcher) loop(ctx context.Context, ticks <-chan time.Time) error {
+	data, err := w.load(ctx)
+	if err != nil {
+		return err
+	}
+	doc, err := decodeValue(data)
+	if err != nil {
+		return fmt.Errorf("%v: %w", w.name, err)
+	}
+	if err := w.out.printDoc(w.name, doc, w.paths); err != nil {
+		return err
+	}
+	w.data, w.doc = data, doc
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticks:
+			if err := w.reload(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				fmt.Fprintf(w.stderr, "jsonpath: %v\n", err)
+			}
+		}
+	}
+}
+
+// reload reloads w's input and, if its contents changed, prints the
+// changes to the nodes that w's paths select.
+func (w *watcher) reload(ctx context.Context) error {
+	data, err := w.load(ctx)
+	if err != nil || bytes.Equal(data, w.data) {
+		return err
+	}
+	// Record invalid contents, too, to report them only once, but compare
+	// the next valid contents to the last valid document.
+	w.data = data
+	doc, err := decodeValue(data)
+	if err != nil {
+		return fmt.Errorf("%v: %w", w.name, err)
+	}
+
+	var changes []jsonpath.Change
+	for _, path := range w.paths {
+		changes = append(changes, path.Diff(w.doc, doc)...)
+	}
+	w.doc = doc
+	return printChanges(w.out.w, w.name, changes)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+)
+
+func TestWatcher(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "config.json")
+	// Write atomically, because the loop may be reading the file.
+	write := func(src string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(file+".tmp", []byte(src), 0o600))
+		require.NoError(t, os.Rename(file+".tmp", file))
+	}
+	write(`{"spec": {"replicas": 2, "image": "app:1"}, "status": {"ready": 1}}`)
+
+	var stdout, stderr bytes.Buffer
+	out := &printer{w: &stdout, enc: json.NewEncoder(&stdout), format: formatBoth}
+	w := &watcher{
+		name: file,
+		load: loader(file),
+		paths: []*jsonpath.Path{
+			jsonpath.MustParse(`$.spec.replicas`),
+			jsonpath.MustParse(`$.spec.image`),
+			jsonpath.MustParse(`$.spec.tag`),
+		},
+		out:    out,
+		stderr: &stderr,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := make(chan time.Time)
+	done := make(chan error)
+	go func() { done <- w.loop(ctx, ticks) }()
+
+	// Send a second tick to wait for the loop to process the first.
+	// Each change is processed once, by either tick.
+	tick := func() {
+		ticks <- time.Now()
+		ticks <- time.Now()
+	}
+
+	// No change.
+	tick()
+
+	// Change outside the selected nodes.
+	write(`{"spec": {"replicas": 2, "image": "app:1"}, "status": {"ready": 2}}`)
+	tick()
+
+	// Change a selected node.
+	write(`{"spec": {"replicas": 3, "image": "app:1"}, "status": {"ready": 2}}`)
+	tick()
+
+	// Invalid JSON.
+	write(`{"spec": {`)
+	tick()
+
+	// Add and remove nodes.
+	write(`{"spec": {"replicas": 3, "tag": "v2"}}`)
+	tick()
+
+	cancel()
+	a.NoError(<-done)
+
+	a.Equal(strings.Join([]string{
+		"$['spec']['replicas']\t2",
+		"$['spec']['image']\t\"app:1\"",
+		"--- " + file,
+		"-$['spec']['replicas']\t2",
+		"+$['spec']['replicas']\t3",
+		"--- " + file,
+		"-$['spec']['image']\t\"app:1\"",
+		"+$['spec']['tag']\t\"v2\"",
+		"",
+	}, "\n"), stdout.String())
+	a.Equal("jsonpath: "+file+": unexpected EOF\n", stderr.String())
+}
+
+func TestWatcherErrors(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	dir := t.TempDir()
+
+	var stdout bytes.Buffer
+	out := &printer{w: &stdout, enc: json.NewEncoder(&stdout), format: formatValues}
+	paths := []*jsonpath.Path{jsonpath.MustParse(`$`)}
+
+	nope := filepath.Join(dir, "nope.json")
+	w := &watcher{name: nope, load: loader(nope), paths: paths, out: out}
+	a.EqualError(w.loop(context.Background(), nil), "open "+nope+": no such file or directory")
+
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`[`), 0o600))
+	w = &watcher{name: bad, load: loader(bad), paths: paths, out: out}
+	a.EqualError(w.loop(context.Background(), nil), bad+": unexpected EOF")
+	a.Empty(stdout.String())
+}
+
+func TestLoader(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/doc.json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"a": 1}`))
+	}))
+	defer srv.Close()
+
+	data, err := loader(srv.URL + "/doc.json")(context.Background())
+	a.NoError(err)
+	a.JSONEq(`{"a": 1}`, string(data))
+
+	_, err = loader(srv.URL + "/nope.json")(context.Background())
+	a.EqualError(err, srv.URL+"/nope.json: 404 Not Found")
+
+	_, err = loader("http://[::1")(context.Background())
+	a.Error(err)
+
+	// Should limit the size of the response.
+	client := &http.Client{Timeout: time.Second}
+	data, err = fetch(context.Background(), client, srv.URL+"/doc.json", 8)
+	a.NoError(err)
+	a.JSONEq(`{"a": 1}`, string(data))
+	_, err = fetch(context.Background(), client, srv.URL+"/doc.json", 7)
+	a.ErrorIs(err, errTooLarge)
+	a.EqualError(err, srv.URL+"/doc.json: response too large: more than 7 bytes")
+
+	// Should time out.
+	stall := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-stall
+	}))
+	defer slow.Close()
+	defer close(stall)
+	_, err = fetch(context.Background(), &http.Client{Timeout: 10 * time.Millisecond}, slow.URL, 8)
+	a.ErrorContains(err, "Client.Timeout exceeded")
+}
+
+func TestRunWatchUsage(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		args []string
+		err  string
+	}{
+		{"no_file", []string{"-watch", "$"}, "usage: -watch requires a single file or URL"},
+		{"stdin", []string{"-watch", "$", "-"}, "usage: -watch requires a single file or URL"},
+		{"two_files", []string{"-watch", "$", "a.json", "b.json"}, "usage: -watch requires a single file or URL"},
+		{"ndjson", []string{"-watch", "-ndjson", "$", "a.json"}, "usage: -ndjson and -watch are mutually exclusive"},
+		{"interval", []string{"-watch", "-interval", "0s", "$", "a.json"}, "usage: -interval must be positive"},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			var stdout bytes.Buffer
+			err := run(tc.args, strings.NewReader(""), &stdout)
+			require.EqualError(t, err, tc.err)
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
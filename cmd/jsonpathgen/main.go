@@ -0,0 +1,68 @@
+// Command jsonpathgen generates Go functions that implement JSONPath
+// queries, for use with go:generate. Each argument takes the form
+// Name=path, where Name is the name of the function to generate and path
+// is the JSONPath query it implements. See the codegen package for details.
+//
+// Usage:
+//
+//	jsonpathgen [-pkg name] [-o file] Name=path...
+//
+// The -pkg flag defaults to the value of the $GOPACKAGE environment
+// variable set by go generate, and -o defaults to standard output.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/theory/jsonpath/codegen"
+)
+
+// errUsage errors are returned by run for invalid arguments.
+var errUsage = errors.New("usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "jsonpathgen: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+// run parses args, generates the functions they describe, and writes the
+// result to the file named by the -o flag, or to stdout.
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("jsonpathgen", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	pkg := flags.String("pkg", os.Getenv("GOPACKAGE"), "package name")
+	out := flags.String("o", "", "output file")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("%w: jsonpathgen [-pkg name] [-o file] Name=path...", errUsage)
+	}
+
+	funcs := make([]codegen.Func, flags.NArg())
+	for i, arg := range flags.Args() {
+		name, path, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("%w: argument %q is not of the form Name=path", errUsage, arg)
+		}
+		funcs[i] = codegen.Func{Name: name, Path: path}
+	}
+
+	src, err := codegen.Generate(*pkg, funcs...)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if *out == "" {
+		_, err = stdout.Write(src)
+		return err //nolint:wrapcheck
+	}
+	return os.WriteFile(*out, src, 0o600) //nolint:wrapcheck
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/codegen"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	exp, err := codegen.Generate("x", codegen.Func{Name: "A", Path: "$.a"}, codegen.Func{Name: "B", Path: "$..b"})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		test string
+		args []string
+		err  string
+	}{
+		{
+			test: "stdout",
+			args: []string{"-pkg", "x", "A=$.a", "B=$..b"},
+		},
+		{
+			test: "file",
+			args: []string{"-pkg", "x", "-o", "OUT", "A=$.a", "B=$..b"},
+		},
+		{
+			test: "bad_flag",
+			args: []string{"-nope"},
+			err:  "usage: flag provided but not defined: -nope",
+		},
+		{
+			test: "no_args",
+			args: []string{"-pkg", "x"},
+			err:  "usage: jsonpathgen [-pkg name] [-o file] Name=path...",
+		},
+		{
+			test: "bad_arg",
+			args: []string{"-pkg", "x", "$.a"},
+			err:  `usage: argument "$.a" is not of the form Name=path`,
+		},
+		{
+			test: "bad_path",
+			args: []string{"-pkg", "x", "A=$.a["},
+			err:  "codegen: A: jsonpath: unexpected eof at position 5",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			out := filepath.Join(t.TempDir(), "paths.go")
+			for i, arg := range tc.args {
+				if arg == "OUT" {
+					tc.args[i] = out
+				}
+			}
+
+			var stdout bytes.Buffer
+			err := run(tc.args, &stdout)
+			if tc.err != "" {
+				a.EqualError(err, tc.err)
+				a.Empty(stdout.String())
+				return
+			}
+
+			a.NoError(err)
+			if stdout.Len() > 0 {
+				a.Equal(string(exp), stdout.String())
+				a.NoFileExists(out)
+			} else {
+				src, err := os.ReadFile(out)
+				a.NoError(err)
+				a.Equal(string(exp), string(src))
+			}
+		})
+	}
+}
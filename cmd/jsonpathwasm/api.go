@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/theory/jsonpath"
+)
+
+var (
+	// errArgs errors are returned by exported functions for invalid
+	// arguments.
+	errArgs = errors.New("invalid arguments")
+
+	// errJSON errors are returned by select for invalid JSON documents.
+	errJSON = errors.New("invalid JSON")
+)
+
+// funcs maps the names of the functions exported to JavaScript to their
+// implementations. Each takes the string arguments passed from JavaScript
+// and returns a value that marshals to the JSON object it returns.
+//
+//nolint:gochecknoglobals
+var funcs = map[string]func(args []string) (any, error){
+	"parse":  parse,
+	"select": selectNodes,
+	"lint":   lint,
+}
+
+// result is the value returned by exported functions that fail.
+type result struct {
+	Error any `json:"error"`
+}
+
+// callError describes the failure of an exported function other than a
+// parse error.
+type callError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// options contains the options for select.
+type options struct {
+	MaxNodes int `json:"maxNodes"`
+	MaxDepth int `json:"maxDepth"`
+}
+
+// call calls the function named name with args and returns the JSON
+// encoding of its result, or of a result describing its failure.
+func call(name string, args []string) []byte {
+	fn, ok := funcs[name]
+	if !ok {
+		return errorJSON(fmt.Errorf("%w: unknown function %v", errArgs, name))
+	}
+	res, err := fn(args)
+	if err != nil {
+		return errorJSON(err)
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return errorJSON(err)
+	}
+	return data
+}
+
+// errorJSON returns the JSON encoding of a result describing err.
+func errorJSON(err error) []byte {
+	var res result
+	var pe *jsonpath.ParseError
+	switch {
+	case errors.As(err, &pe):
+		res.Error = pe
+	case errors.Is(err, errArgs):
+		res.Error = callError{"invalid-arguments", err.Error()}
+	case errors.Is(err, jsonpath.ErrBudgetExceeded):
+		res.Error = callError{"budget-exceeded", err.Error()}
+	case errors.Is(err, errJSON):
+		res.Error = callError{"invalid-json", err.Error()}
+	default:
+		res.Error = callError{"error", err.Error()}
+	}
+	//nolint:errchkjson
+	data, _ := json.Marshal(res)
+	return data
+}
+
+// parse parses args[0] and returns its canonical format.
+func parse(args []string) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: parse(query) takes 1 argument", errArgs)
+	}
+	query, err := jsonpath.Format(args[0])
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return map[string]string{"query": query}, nil
+}
+
+// selectNodes selects nodes with the query in args[0] from the JSON
+// document in args[1], limited by the options in the optional JSON object
+// in args[2].
+func selectNodes(args []string) (any, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, fmt.Errorf("%w: select(query, json, options) takes 2 or 3 arguments", errArgs)
+	}
+
+	var opts options
+	if len(args) == 3 && args[2] != "" {
+		if err := json.Unmarshal([]byte(args[2]), &opts); err != nil {
+			return nil, fmt.Errorf("%w: options: %w", errArgs, err)
+		}
+	}
+
+	path, err := jsonpath.NewParser(
+		jsonpath.WithMaxNodes(opts.MaxNodes),
+		jsonpath.WithMaxDepth(opts.MaxDepth),
+	).Parse(args[0])
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	dec := json.NewDecoder(strings.NewReader(args[1]))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", errJSON, err)
+	}
+
+	nodes, err := path.TrySelectLocated(doc)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if nodes == nil {
+		nodes = jsonpath.LocatedNodeList{}
+	}
+	return map[string]any{"nodes": nodes}, nil
+}
+
+// lint lints args[0] and returns its diagnostics.
+func lint(args []string) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: lint(query) takes 1 argument", errArgs)
+	}
+	diags := jsonpath.Lint(args[0])
+	if diags == nil {
+		diags = []jsonpath.Diagnostic{}
+	}
+	return map[string]any{"diagnostics": diags}, nil
+}
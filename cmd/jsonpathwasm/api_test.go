@@ -0,0 +1,118 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCall(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		fn   string
+		args []string
+		exp  string
+	}{
+		{
+			test: "parse",
+			fn:   "parse",
+			args: []string{`$["a"][ 0 ]`},
+			exp:  `{"query": "$.a[0]"}`,
+		},
+		{
+			test: "parse_error",
+			fn:   "parse",
+			args: []string{`$.a[`},
+			exp:  `{"error": {"code": "syntax", "message": "unexpected eof", "start": 4, "end": 4}}`,
+		},
+		{
+			test: "parse_args",
+			fn:   "parse",
+			exp:  `{"error": {"code": "invalid-arguments", "message": "invalid arguments: parse(query) takes 1 argument"}}`,
+		},
+		{
+			test: "select",
+			fn:   "select",
+			args: []string{`$..x`, `{"a": {"x": 1.10}, "x": [2]}`},
+			exp:  `{"nodes": [{"path": "$['x']", "node": [2]}, {"path": "$['a']['x']", "node": 1.10}]}`,
+		},
+		{
+			test: "select_none",
+			fn:   "select",
+			args: []string{`$.y`, `{"x": 1}`, ""},
+			exp:  `{"nodes": []}`,
+		},
+		{
+			test: "select_options",
+			fn:   "select",
+			args: []string{`$..*`, `[[[1]]]`, `{"maxNodes": 1}`},
+			exp:  `{"error": {"code": "budget-exceeded", "message": "evaluation budget exceeded: visited more than 1 nodes"}}`,
+		},
+		{
+			test: "select_max_depth",
+			fn:   "select",
+			args: []string{`$..*`, `[[[1]]]`, `{"maxDepth": 1}`},
+			exp:  `{"nodes": [{"path": "$[0]", "node": [[1]]}, {"path": "$[0][0]", "node": [1]}]}`,
+		},
+		{
+			test: "select_parse_error",
+			fn:   "select",
+			args: []string{`$[`, `{}`},
+			exp:  `{"error": {"code": "syntax", "message": "unexpected eof", "start": 2, "end": 2}}`,
+		},
+		{
+			test: "select_invalid_json",
+			fn:   "select",
+			args: []string{`$`, `{`},
+			exp:  `{"error": {"code": "invalid-json", "message": "invalid JSON: unexpected EOF"}}`,
+		},
+		{
+			test: "select_invalid_options",
+			fn:   "select",
+			args: []string{`$`, `{}`, `[]`},
+			exp:  `{"error": {"code": "invalid-arguments", "message": "invalid arguments: options: json: cannot unmarshal array into Go value of type main.options"}}`,
+		},
+		{
+			test: "select_args",
+			fn:   "select",
+			args: []string{`$`},
+			exp:  `{"error": {"code": "invalid-arguments", "message": "invalid arguments: select(query, json, options) takes 2 or 3 arguments"}}`,
+		},
+		{
+			test: "lint",
+			fn:   "lint",
+			args: []string{`$[1:1]`},
+			exp: `{"diagnostics": [{
+				"severity": "warning",
+				"code": "unreachable",
+				"message": "selector 1:1 in segment 1 of $[1:1] selects nothing",
+				"start": 0,
+				"end": 0
+			}]}`,
+		},
+		{
+			test: "lint_valid",
+			fn:   "lint",
+			args: []string{`$.a`},
+			exp:  `{"diagnostics": []}`,
+		},
+		{
+			test: "lint_args",
+			fn:   "lint",
+			args: []string{`$.a`, `$.b`},
+			exp:  `{"error": {"code": "invalid-arguments", "message": "invalid arguments: lint(query) takes 1 argument"}}`,
+		},
+		{
+			test: "unknown",
+			fn:   "nope",
+			exp:  `{"error": {"code": "invalid-arguments", "message": "invalid arguments: unknown function nope"}}`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.JSONEq(t, tc.exp, string(call(tc.fn, tc.args)))
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Command jsonpathwasm exports JSONPath parsing, selection, and linting to
+// JavaScript when compiled to WebAssembly, so that web applications can
+// validate and preview queries with the same engine as Go services. Build
+// it with:
+//
+//	GOOS=js GOARCH=wasm go build -o jsonpath.wasm ./cmd/jsonpathwasm
+//
+// Load jsonpath.wasm with the wasm_exec.js support file distributed with
+// Go, in $(go env GOROOT)/lib/wasm. Once running, it defines a global
+// jsonpath object with these functions, each of which returns a plain
+// JavaScript object:
+//
+//   - parse(query): Parses query and returns {query}, where query is the
+//     query in the canonical format of [jsonpath.Format]
+//   - select(query, json, options): Selects nodes from json, a string
+//     containing a JSON document, and returns {nodes}, where nodes is an
+//     array of {path, node} objects with the normalized path of each node.
+//     The optional options object supports the maxNodes and maxDepth
+//     properties, which configure [jsonpath.WithMaxNodes] and
+//     [jsonpath.WithMaxDepth] to limit the work done by untrusted queries.
+//     Numbers in nodes retain their original precision only to the extent
+//     JavaScript numbers do.
+//   - lint(query): Lints query and returns {diagnostics}, an array of the
+//     [jsonpath.Diagnostic] values returned by [jsonpath.Lint]
+//
+// On failure, each function instead returns {error}, where error is an
+// object with code and message properties. For parse errors, it also has
+// the start, end, and hint properties of [jsonpath.ParseError]. Other codes
+// are invalid-arguments, invalid-json, budget-exceeded, and error, for
+// unexpected failures.
+//
+// Other builds of the command exit with an error.
+package main
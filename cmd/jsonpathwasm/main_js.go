@@ -0,0 +1,30 @@
+//go:build js && wasm
+
+package main
+
+import "syscall/js"
+
+func main() {
+	parseJSON := js.Global().Get("JSON").Get("parse")
+	obj := js.Global().Get("Object").New()
+	for name := range funcs {
+		obj.Set(name, js.FuncOf(func(_ js.Value, args []js.Value) any {
+			strs := make([]string, len(args))
+			for i, arg := range args {
+				switch arg.Type() {
+				case js.TypeString:
+					strs[i] = arg.String()
+				case js.TypeUndefined, js.TypeNull:
+				default:
+					// Pass objects, such as options, as JSON.
+					strs[i] = js.Global().Get("JSON").Call("stringify", arg).String()
+				}
+			}
+			return parseJSON.Invoke(string(call(name, strs)))
+		}))
+	}
+	js.Global().Set("jsonpath", obj)
+
+	// Keep running to serve calls from JavaScript.
+	select {}
+}
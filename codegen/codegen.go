@@ -0,0 +1,310 @@
+// Package codegen generates Go source code that implements JSONPath queries
+// as specialized functions. Each function traverses its input with type
+// switches and loops generated for the segments and selectors of its query,
+// rather than interpreting a [spec.PathQuery], so that hot paths can select
+// values with no interpretive overhead.
+//
+// Generated functions select from the values produced by [encoding/json]:
+// map[string]any, []any, and scalars. Unlike [jsonpath.Path], they do not
+// select from structs, other maps and slices, or [spec.Object] and
+// [spec.Array] values. Filter selectors select values with the
+// [spec.FilterSelector] parsed from their filter expressions, and so
+// support the function extensions in [registry.New].
+//
+// Use the jsonpathgen command with go:generate to generate functions:
+//
+//	//go:generate go run github.com/theory/jsonpath/cmd/jsonpathgen -o paths.go Authors=$.store.book[*].author
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+)
+
+// ErrGenerate errors are returned by [Generate] for invalid package or
+// function names and path parse errors.
+var ErrGenerate = errors.New("codegen")
+
+// Func describes a function for [Generate] to generate.
+type Func struct {
+	// Name is the name of the function, which must be a valid Go identifier.
+	Name string
+	// Path is the JSONPath query that the function implements.
+	Path string
+}
+
+// Generate generates the source code for a Go file in package pkg that
+// defines a function for each of funcs. Each function has the signature
+// func(input any) []any and selects the same values as [jsonpath.Path.Select]
+// for its path, in the same order, from input decoded by [encoding/json].
+// Generates code for each path as rewritten by [jsonpath.Path.Optimize].
+// Returns an [ErrGenerate] error if pkg or a function name is not a valid Go
+// identifier, two functions have the same name, or a path fails to parse.
+func Generate(pkg string, funcs ...Func) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("%w: invalid package name %q", ErrGenerate, pkg)
+	}
+
+	g := &generator{counts: map[string]int{}}
+	seen := make(map[string]bool, len(funcs))
+	for _, f := range funcs {
+		if !token.IsIdentifier(f.Name) {
+			return nil, fmt.Errorf("%w: invalid function name %q", ErrGenerate, f.Name)
+		}
+		if seen[f.Name] {
+			return nil, fmt.Errorf("%w: duplicate function name %q", ErrGenerate, f.Name)
+		}
+		seen[f.Name] = true
+
+		path, err := jsonpath.Parse(f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v: %w", ErrGenerate, f.Name, err)
+		}
+		g.function(f.Name, path.Optimize().Query())
+	}
+
+	return g.source(pkg)
+}
+
+// generator accumulates the functions and package variables of a generated
+// file.
+type generator struct {
+	funcs bytes.Buffer
+	vars  bytes.Buffer
+	// counts holds the number of variables of each kind declared for each
+	// function.
+	counts map[string]int
+	// usesMath, usesSpec, and usesJSONPath record whether the generated code
+	// refers to the math, spec, and jsonpath packages.
+	usesMath     bool
+	usesSpec     bool
+	usesJSONPath bool
+}
+
+// source returns the formatted source code of the file for package pkg.
+func (g *generator) source(pkg string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by jsonpathgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %v\n\n", pkg)
+	if g.usesSpec {
+		buf.WriteString("import (\n")
+		if g.usesMath {
+			buf.WriteString("\t\"math\"\n\n")
+		}
+		if g.usesJSONPath {
+			buf.WriteString("\t\"github.com/theory/jsonpath\"\n")
+		}
+		buf.WriteString("\t\"github.com/theory/jsonpath/spec\"\n)\n\n")
+	}
+	if g.vars.Len() > 0 {
+		buf.WriteString("var (\n")
+		buf.Write(g.vars.Bytes())
+		buf.WriteString(")\n\n")
+	}
+	buf.Write(g.funcs.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		// Should not happen.
+		return nil, fmt.Errorf("%w: %w", ErrGenerate, err)
+	}
+	return src, nil
+}
+
+// function generates a function named name that selects with q.
+func (g *generator) function(name string, q *spec.PathQuery) {
+	w := &g.funcs
+	fmt.Fprintf(w, "// %v selects %v from input.\n", name, q.Format(spec.FormatOpts{}))
+	fmt.Fprintf(w, "func %v(input any) []any {\n", name)
+	if q.Singular() != nil {
+		g.lookups(q.Segments())
+	} else {
+		g.segments(name, q.Segments())
+	}
+	w.WriteString("}\n\n")
+}
+
+// lookups generates the body of a function that selects a single value by
+// looking up the selector of each of segs in turn.
+func (g *generator) lookups(segs []*spec.Segment) {
+	w := &g.funcs
+	w.WriteString("v := input\n")
+	for i, seg := range segs {
+		switch sel := seg.Selectors()[0].(type) {
+		case spec.Name:
+			fmt.Fprintf(w, "m%v, ok := v.(map[string]any)\n", i)
+			w.WriteString("if !ok {\nreturn []any{}\n}\n")
+			fmt.Fprintf(w, "if v, ok = m%v[%v]; !ok {\nreturn []any{}\n}\n", i, strconv.Quote(string(sel)))
+		case spec.Index:
+			fmt.Fprintf(w, "a%v, ok := v.([]any)\n", i)
+			if sel >= 0 {
+				fmt.Fprintf(w, "if !ok || len(a%v) <= %v {\nreturn []any{}\n}\n", i, sel)
+				fmt.Fprintf(w, "v = a%v[%v]\n", i, sel)
+			} else {
+				fmt.Fprintf(w, "if !ok || len(a%v) < %v {\nreturn []any{}\n}\n", i, -sel)
+				fmt.Fprintf(w, "v = a%v[len(a%v)-%v]\n", i, i, -sel)
+			}
+		}
+	}
+	w.WriteString("return []any{v}\n")
+}
+
+// segments generates the body of a function named name that selects with
+// each of segs in turn.
+func (g *generator) segments(name string, segs []*spec.Segment) {
+	w := &g.funcs
+	w.WriteString("nodes := []any{input}\n")
+	if len(segs) > 0 {
+		w.WriteString("var next []any\n")
+	}
+	for i, seg := range segs {
+		fmt.Fprintf(w, "\n// %v\n", formatSegment(seg))
+		w.WriteString("next = make([]any, 0, len(nodes))\n")
+		if seg.IsDescendant() {
+			fmt.Fprintf(w, "var descend%v func(v any)\n", i)
+			fmt.Fprintf(w, "descend%v = func(v any) {\n", i)
+			g.selectors(name, seg.Selectors())
+			w.WriteString("switch v := v.(type) {\n")
+			w.WriteString("case []any:\nfor _, x := range v {\n")
+			fmt.Fprintf(w, "descend%v(x)\n}\n", i)
+			w.WriteString("case map[string]any:\nfor _, x := range v {\n")
+			fmt.Fprintf(w, "descend%v(x)\n}\n", i)
+			w.WriteString("}\n}\n")
+			fmt.Fprintf(w, "for _, v := range nodes {\ndescend%v(v)\n}\n", i)
+		} else {
+			w.WriteString("for _, v := range nodes {\n")
+			g.selectors(name, seg.Selectors())
+			w.WriteString("}\n")
+		}
+		w.WriteString("nodes = next\n")
+	}
+	w.WriteString("return nodes\n")
+}
+
+// selectors generates code that appends the values each of sels selects
+// from v to next, for a function named name.
+func (g *generator) selectors(name string, sels []spec.Selector) {
+	w := &g.funcs
+	if len(sels) == 1 {
+		if s, ok := sels[0].(spec.SliceSelector); ok && s.Step() == 0 {
+			// Selects nothing.
+			w.WriteString("_ = v\n")
+			return
+		}
+	}
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case spec.Name:
+			w.WriteString("if m, ok := v.(map[string]any); ok {\n")
+			fmt.Fprintf(w, "if x, ok := m[%v]; ok {\n", strconv.Quote(string(sel)))
+			w.WriteString("next = append(next, x)\n}\n}\n")
+		case spec.Index:
+			if sel >= 0 {
+				fmt.Fprintf(w, "if a, ok := v.([]any); ok && len(a) > %v {\n", sel)
+				fmt.Fprintf(w, "next = append(next, a[%v])\n}\n", sel)
+			} else {
+				fmt.Fprintf(w, "if a, ok := v.([]any); ok && len(a) >= %v {\n", -sel)
+				fmt.Fprintf(w, "next = append(next, a[len(a)-%v])\n}\n", -sel)
+			}
+		case spec.WildcardSelector:
+			w.WriteString("switch v := v.(type) {\n")
+			w.WriteString("case []any:\nnext = append(next, v...)\n")
+			w.WriteString("case map[string]any:\nfor _, x := range v {\n")
+			w.WriteString("next = append(next, x)\n}\n}\n")
+		case spec.SliceSelector:
+			g.slice(name, sel)
+		case *spec.FilterSelector:
+			g.filter(name, sel)
+		}
+	}
+}
+
+// slice generates code that appends the values sel selects from v to next,
+// for a function named name. Generates nothing for a slice with a step of
+// zero, which selects nothing.
+func (g *generator) slice(name string, sel spec.SliceSelector) {
+	if sel.Step() == 0 {
+		return
+	}
+	g.usesSpec = true
+	v := g.variable(name, "Slice", fmt.Sprintf(
+		"spec.Slice(%v, %v, %v)", g.intLiteral(sel.Start()), g.intLiteral(sel.End()), sel.Step(),
+	))
+
+	w := &g.funcs
+	w.WriteString("if a, ok := v.([]any); ok {\n")
+	fmt.Fprintf(w, "lo, hi := %v.Bounds(len(a))\n", v)
+	switch step := sel.Step(); {
+	case step == 1:
+		w.WriteString("for i := lo; i < hi; i++ {\n")
+	case step > 0:
+		fmt.Fprintf(w, "for i := lo; i < hi; i += %v {\n", step)
+	case step == -1:
+		w.WriteString("for i := hi; lo < i; i-- {\n")
+	default:
+		fmt.Fprintf(w, "for i := hi; lo < i; i -= %v {\n", -step)
+	}
+	w.WriteString("next = append(next, a[i])\n}\n}\n")
+}
+
+// filter generates code that appends the values sel selects from v to
+// next, for a function named name.
+func (g *generator) filter(name string, sel *spec.FilterSelector) {
+	g.usesSpec = true
+	g.usesJSONPath = true
+	// Format escapes string literals exactly as RFC 9535 requires, so that
+	// the query always parses, unlike the output of String.
+	v := g.variable(name, "Filter", fmt.Sprintf(
+		"jsonpath.MustParse(%v).Query().Segments()[0].Selectors()[0].(*spec.FilterSelector)",
+		strconv.Quote(spec.Query(true, spec.Child(sel)).Format(spec.FormatOpts{})),
+	))
+
+	w := &g.funcs
+	w.WriteString("switch v := v.(type) {\n")
+	for _, typ := range []string{"[]any", "map[string]any"} {
+		fmt.Fprintf(w, "case %v:\nfor _, x := range v {\n", typ)
+		fmt.Fprintf(w, "if %v.Eval(x, input) {\n", v)
+		w.WriteString("next = append(next, x)\n}\n}\n")
+	}
+	w.WriteString("}\n")
+}
+
+// formatSegment formats seg as [spec.PathQuery.Format] would format it in a
+// query, escaping its names and string literals as RFC 9535 requires.
+func formatSegment(seg *spec.Segment) string {
+	return strings.TrimPrefix(spec.Query(true, seg).Format(spec.FormatOpts{}), "$")
+}
+
+// variable declares a package variable initialized to expr for a function
+// named name and returns its name, which is unique to the function.
+func (g *generator) variable(name, kind, expr string) string {
+	v := fmt.Sprintf("jsonpath%v%v%v", name, kind, g.counts[name+"."+kind])
+	g.counts[name+"."+kind]++
+	fmt.Fprintf(&g.vars, "\t%v = %v\n", v, expr)
+	return v
+}
+
+// intLiteral returns i as a Go integer literal, using math.MaxInt and
+// math.MinInt for the extremes so that the source compiles on 32-bit
+// platforms.
+func (g *generator) intLiteral(i int) string {
+	switch i {
+	case math.MaxInt:
+		g.usesMath = true
+		return "math.MaxInt"
+	case math.MinInt:
+		g.usesMath = true
+		return "math.MinInt"
+	default:
+		return strconv.Itoa(i)
+	}
+}
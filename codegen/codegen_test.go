@@ -0,0 +1,146 @@
+package codegen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/codegen/internal/example"
+)
+
+// exampleFuncs lists the functions generated in internal/example, and must
+// match the go:generate directive in its doc.go.
+var exampleFuncs = []Func{
+	{"Authors", `$.store.book[*].author`},
+	{"Cheap", `$..book[?@.price < 10].title`},
+	{"LastISBN", `$.store.book[-1].isbn`},
+	{"All", `$..*`},
+	{"Books", `$.store.book[1:3,::-2,0,-1]`},
+	{"Root", `$`},
+	{"Reversed", `$..[::-1]`},
+	{"Empty", `$.store[:0:0]`},
+	{"Control", `$..book[?@.title != "\u0001\t"].title`},
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test  string
+		pkg   string
+		funcs []Func
+		err   string
+	}{
+		{
+			test:  "bad_package",
+			pkg:   "no-good",
+			funcs: exampleFuncs,
+			err:   `codegen: invalid package name "no-good"`,
+		},
+		{
+			test:  "bad_name",
+			pkg:   "x",
+			funcs: []Func{{"1x", "$"}},
+			err:   `codegen: invalid function name "1x"`,
+		},
+		{
+			test:  "duplicate_name",
+			pkg:   "x",
+			funcs: []Func{{"X", "$"}, {"X", "$.a"}},
+			err:   `codegen: duplicate function name "X"`,
+		},
+		{
+			test:  "parse_error",
+			pkg:   "x",
+			funcs: []Func{{"X", "$.a["}},
+			err:   `codegen: X: jsonpath: unexpected eof at position 5`,
+		},
+		{
+			test: "no_imports",
+			pkg:  "x",
+			funcs: []Func{
+				{"X", "$.a[*]..b"},
+				{"Y", "$[0]"},
+			},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			src, err := Generate(tc.pkg, tc.funcs...)
+			if tc.err != "" {
+				a.Nil(src)
+				a.ErrorIs(err, ErrGenerate)
+				a.EqualError(err, tc.err)
+				return
+			}
+			a.NoError(err)
+			a.Contains(string(src), "package x\n")
+			a.NotContains(string(src), "import")
+		})
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	// The generated file should be up to date.
+	src, err := Generate("example", exampleFuncs...)
+	r.NoError(err)
+	file, err := os.ReadFile(filepath.Join("internal", "example", "paths.go"))
+	r.NoError(err)
+	r.Equal(string(file), string(src))
+
+	var input any
+	r.NoError(json.Unmarshal([]byte(`{
+		"store": {
+			"book": [
+				{"author": "Nigel Rees", "price": 8.95, "isbn": "0-553-21311-3", "title": "Sayings"},
+				{"author": "Evelyn Waugh", "price": 12.99, "title": "Sword"},
+				{"author": "Herman Melville", "price": 8.99, "title": "Moby Dick"},
+				{"author": "J. R. R. Tolkien", "price": 22.99, "isbn": "0-395-19395-8", "title": "Rings"}
+			],
+			"bicycle": {"color": "red", "price": 399}
+		},
+		"tags": [[1, 2, 3], ["a", "b"]]
+	}`), &input))
+
+	// Selections from objects in more than one object have no set order.
+	for i, tc := range []struct {
+		fn      func(any) []any
+		ordered bool
+	}{
+		{example.Authors, true},
+		{example.Cheap, true},
+		{example.LastISBN, true},
+		{example.All, false},
+		{example.Books, true},
+		{example.Root, true},
+		{example.Reversed, false},
+		{example.Empty, true},
+		{example.Control, true},
+	} {
+		f := exampleFuncs[i]
+		t.Run(f.Name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			path := jsonpath.MustParse(f.Path)
+			for _, v := range []any{input, nil, "x", []any{}, map[string]any{}} {
+				exp := []any(path.Select(v))
+				got := tc.fn(v)
+				a.NotNil(got)
+				if tc.ordered {
+					a.Equal(exp, got)
+				} else {
+					a.ElementsMatch(exp, got)
+				}
+			}
+		})
+	}
+}
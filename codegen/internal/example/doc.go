@@ -0,0 +1,5 @@
+// Package example contains functions generated by the jsonpathgen command,
+// for testing the codegen package.
+package example
+
+//go:generate go run ../../../cmd/jsonpathgen -o paths.go Authors=$.store.book[*].author "Cheap=$..book[?@.price < 10].title" LastISBN=$.store.book[-1].isbn All=$..* "Books=$.store.book[1:3,::-2,0,-1]" Root=$ Reversed=$..[::-1] Empty=$.store[:0:0] "Control=$..book[?@.title != \"\\u0001\\t\"].title"
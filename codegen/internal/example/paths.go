@@ -0,0 +1,382 @@
+// Code generated by jsonpathgen. DO NOT EDIT.
+
+package example
+
+import (
+	"math"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+)
+
+var (
+	jsonpathCheapFilter0   = jsonpath.MustParse("$[?@[\"price\"] < 10]").Query().Segments()[0].Selectors()[0].(*spec.FilterSelector)
+	jsonpathBooksSlice0    = spec.Slice(1, 3, 1)
+	jsonpathBooksSlice1    = spec.Slice(math.MaxInt, math.MinInt, -2)
+	jsonpathReversedSlice0 = spec.Slice(math.MaxInt, math.MinInt, -1)
+	jsonpathControlFilter0 = jsonpath.MustParse("$[?@[\"title\"] != \"\\u0001\\t\"]").Query().Segments()[0].Selectors()[0].(*spec.FilterSelector)
+)
+
+// Authors selects $["store"]["book"][*]["author"] from input.
+func Authors(input any) []any {
+	nodes := []any{input}
+	var next []any
+
+	// ["store"]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		if m, ok := v.(map[string]any); ok {
+			if x, ok := m["store"]; ok {
+				next = append(next, x)
+			}
+		}
+	}
+	nodes = next
+
+	// ["book"]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		if m, ok := v.(map[string]any); ok {
+			if x, ok := m["book"]; ok {
+				next = append(next, x)
+			}
+		}
+	}
+	nodes = next
+
+	// [*]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		switch v := v.(type) {
+		case []any:
+			next = append(next, v...)
+		case map[string]any:
+			for _, x := range v {
+				next = append(next, x)
+			}
+		}
+	}
+	nodes = next
+
+	// ["author"]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		if m, ok := v.(map[string]any); ok {
+			if x, ok := m["author"]; ok {
+				next = append(next, x)
+			}
+		}
+	}
+	nodes = next
+	return nodes
+}
+
+// Cheap selects $..["book"][?@["price"] < 10]["title"] from input.
+func Cheap(input any) []any {
+	nodes := []any{input}
+	var next []any
+
+	// ..["book"]
+	next = make([]any, 0, len(nodes))
+	var descend0 func(v any)
+	descend0 = func(v any) {
+		if m, ok := v.(map[string]any); ok {
+			if x, ok := m["book"]; ok {
+				next = append(next, x)
+			}
+		}
+		switch v := v.(type) {
+		case []any:
+			for _, x := range v {
+				descend0(x)
+			}
+		case map[string]any:
+			for _, x := range v {
+				descend0(x)
+			}
+		}
+	}
+	for _, v := range nodes {
+		descend0(v)
+	}
+	nodes = next
+
+	// [?@["price"] < 10]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		switch v := v.(type) {
+		case []any:
+			for _, x := range v {
+				if jsonpathCheapFilter0.Eval(x, input) {
+					next = append(next, x)
+				}
+			}
+		case map[string]any:
+			for _, x := range v {
+				if jsonpathCheapFilter0.Eval(x, input) {
+					next = append(next, x)
+				}
+			}
+		}
+	}
+	nodes = next
+
+	// ["title"]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		if m, ok := v.(map[string]any); ok {
+			if x, ok := m["title"]; ok {
+				next = append(next, x)
+			}
+		}
+	}
+	nodes = next
+	return nodes
+}
+
+// LastISBN selects $["store"]["book"][-1]["isbn"] from input.
+func LastISBN(input any) []any {
+	v := input
+	m0, ok := v.(map[string]any)
+	if !ok {
+		return []any{}
+	}
+	if v, ok = m0["store"]; !ok {
+		return []any{}
+	}
+	m1, ok := v.(map[string]any)
+	if !ok {
+		return []any{}
+	}
+	if v, ok = m1["book"]; !ok {
+		return []any{}
+	}
+	a2, ok := v.([]any)
+	if !ok || len(a2) < 1 {
+		return []any{}
+	}
+	v = a2[len(a2)-1]
+	m3, ok := v.(map[string]any)
+	if !ok {
+		return []any{}
+	}
+	if v, ok = m3["isbn"]; !ok {
+		return []any{}
+	}
+	return []any{v}
+}
+
+// All selects $..[*] from input.
+func All(input any) []any {
+	nodes := []any{input}
+	var next []any
+
+	// ..[*]
+	next = make([]any, 0, len(nodes))
+	var descend0 func(v any)
+	descend0 = func(v any) {
+		switch v := v.(type) {
+		case []any:
+			next = append(next, v...)
+		case map[string]any:
+			for _, x := range v {
+				next = append(next, x)
+			}
+		}
+		switch v := v.(type) {
+		case []any:
+			for _, x := range v {
+				descend0(x)
+			}
+		case map[string]any:
+			for _, x := range v {
+				descend0(x)
+			}
+		}
+	}
+	for _, v := range nodes {
+		descend0(v)
+	}
+	nodes = next
+	return nodes
+}
+
+// Books selects $["store"]["book"][1:3,::-2,0,-1] from input.
+func Books(input any) []any {
+	nodes := []any{input}
+	var next []any
+
+	// ["store"]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		if m, ok := v.(map[string]any); ok {
+			if x, ok := m["store"]; ok {
+				next = append(next, x)
+			}
+		}
+	}
+	nodes = next
+
+	// ["book"]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		if m, ok := v.(map[string]any); ok {
+			if x, ok := m["book"]; ok {
+				next = append(next, x)
+			}
+		}
+	}
+	nodes = next
+
+	// [1:3,::-2,0,-1]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		if a, ok := v.([]any); ok {
+			lo, hi := jsonpathBooksSlice0.Bounds(len(a))
+			for i := lo; i < hi; i++ {
+				next = append(next, a[i])
+			}
+		}
+		if a, ok := v.([]any); ok {
+			lo, hi := jsonpathBooksSlice1.Bounds(len(a))
+			for i := hi; lo < i; i -= 2 {
+				next = append(next, a[i])
+			}
+		}
+		if a, ok := v.([]any); ok && len(a) > 0 {
+			next = append(next, a[0])
+		}
+		if a, ok := v.([]any); ok && len(a) >= 1 {
+			next = append(next, a[len(a)-1])
+		}
+	}
+	nodes = next
+	return nodes
+}
+
+// Root selects $ from input.
+func Root(input any) []any {
+	v := input
+	return []any{v}
+}
+
+// Reversed selects $..[::-1] from input.
+func Reversed(input any) []any {
+	nodes := []any{input}
+	var next []any
+
+	// ..[::-1]
+	next = make([]any, 0, len(nodes))
+	var descend0 func(v any)
+	descend0 = func(v any) {
+		if a, ok := v.([]any); ok {
+			lo, hi := jsonpathReversedSlice0.Bounds(len(a))
+			for i := hi; lo < i; i-- {
+				next = append(next, a[i])
+			}
+		}
+		switch v := v.(type) {
+		case []any:
+			for _, x := range v {
+				descend0(x)
+			}
+		case map[string]any:
+			for _, x := range v {
+				descend0(x)
+			}
+		}
+	}
+	for _, v := range nodes {
+		descend0(v)
+	}
+	nodes = next
+	return nodes
+}
+
+// Empty selects $["store"][:0:0] from input.
+func Empty(input any) []any {
+	nodes := []any{input}
+	var next []any
+
+	// ["store"]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		if m, ok := v.(map[string]any); ok {
+			if x, ok := m["store"]; ok {
+				next = append(next, x)
+			}
+		}
+	}
+	nodes = next
+
+	// [:0:0]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		_ = v
+	}
+	nodes = next
+	return nodes
+}
+
+// Control selects $..["book"][?@["title"] != "\u0001\t"]["title"] from input.
+func Control(input any) []any {
+	nodes := []any{input}
+	var next []any
+
+	// ..["book"]
+	next = make([]any, 0, len(nodes))
+	var descend0 func(v any)
+	descend0 = func(v any) {
+		if m, ok := v.(map[string]any); ok {
+			if x, ok := m["book"]; ok {
+				next = append(next, x)
+			}
+		}
+		switch v := v.(type) {
+		case []any:
+			for _, x := range v {
+				descend0(x)
+			}
+		case map[string]any:
+			for _, x := range v {
+				descend0(x)
+			}
+		}
+	}
+	for _, v := range nodes {
+		descend0(v)
+	}
+	nodes = next
+
+	// [?@["title"] != "\u0001\t"]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		switch v := v.(type) {
+		case []any:
+			for _, x := range v {
+				if jsonpathControlFilter0.Eval(x, input) {
+					next = append(next, x)
+				}
+			}
+		case map[string]any:
+			for _, x := range v {
+				if jsonpathControlFilter0.Eval(x, input) {
+					next = append(next, x)
+				}
+			}
+		}
+	}
+	nodes = next
+
+	// ["title"]
+	next = make([]any, 0, len(nodes))
+	for _, v := range nodes {
+		if m, ok := v.(map[string]any); ok {
+			if x, ok := m["title"]; ok {
+				next = append(next, x)
+			}
+		}
+	}
+	nodes = next
+	return nodes
+}
@@ -0,0 +1,43 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+
+	input := []any{
+		map[string]any{"name": []byte("x"), "tags": []any{"a", "b"}},
+		map[string]any{"name": "y", "kids": []any{map[string]any{"name": "z"}}},
+	}
+
+	for _, tc := range []struct {
+		test string
+		path string
+		opt  []Option
+		exp  NodeList
+	}{
+		{"root", `$`, nil, NodeList{input}},
+		{"singular", `$[1].kids[0].name`, nil, NodeList{"z"}},
+		{"missing", `$[2].name`, nil, NodeList{}},
+		{"wildcard", `$[0].tags[*]`, nil, NodeList{"a", "b"}},
+		{"descendant", `$..kids..name`, nil, NodeList{"z"}},
+		{"filter", `$[?@.name == 'x'].tags[1]`, nil, NodeList{}},
+		{"bytes", `$[?@.name == 'x'].tags[1]`, []Option{WithBytesAsStrings()}, NodeList{"b"}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := NewParser(tc.opt...).MustParse(tc.path)
+			c := p.Compile()
+			a.Same(p, c.Path())
+			a.Equal(p.String(), c.String())
+			a.Equal(tc.exp, c.Select(input))
+			a.Equal(p.Select(input), c.Select(input))
+		})
+	}
+}
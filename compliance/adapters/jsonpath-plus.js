@@ -0,0 +1,23 @@
+// Adapter for comparing the jsonpath-plus JavaScript library with
+// theory/jsonpath using compliance.Diff. Install jsonpath-plus with npm and
+// run with node:
+//
+//	compliance.NewCommand("jsonpath-plus", exec.Command("node", "adapters/jsonpath-plus.js"))
+//
+// Reads one request per line from standard input and writes one response
+// per line to standard output, as described by compliance.Command.
+"use strict";
+
+const readline = require("node:readline");
+const { JSONPath } = require("jsonpath-plus");
+
+readline.createInterface({ input: process.stdin }).on("line", (line) => {
+  const req = JSON.parse(line);
+  let res;
+  try {
+    res = { result: JSONPath({ path: req.selector, json: req.document, wrap: true }) ?? [] };
+  } catch (e) {
+    res = { error: String(e?.message ?? e) };
+  }
+  process.stdout.write(JSON.stringify(res) + "\n");
+});
@@ -0,0 +1,251 @@
+// Package compliance runs the [JSONPath Compliance Test Suite] against
+// theory/jsonpath. Load the suite's cts.json file with [LoadFile], as
+// checked out by the jsonpath-compliance-test-suite submodule of this
+// repository, or download the latest version with [Fetch]. Then use
+// [Suite.Run] to collect a [Result] for every case, or [Test] to run each
+// case as a subtest:
+//
+//	func TestCompliance(t *testing.T) {
+//		t.Parallel()
+//		suite, err := compliance.LoadFile("cts.json")
+//		require.NoError(t, err)
+//		compliance.Test(t, jsonpath.NewParser(), suite)
+//	}
+//
+// Pass a [jsonpath.Parser] configured with a custom [registry.Registry] to
+// test an implementation of function extensions.
+//
+// Use [NewReport] to generate a machine-readable [Report] of the behavior of
+// a parser, including the function extensions it supports, its results for
+// the documented behavior [Probes], and a summary of its results for a
+// suite. Compare reports to compare the behavior of implementations.
+//
+// Use [Diff] to run a corpus of selectors and documents through this and
+// other implementations and report each [Divergence] between them. Compare
+// other implementations, such as jsonpath-plus, Jayway JsonPath, or
+// libjsonpath, by wrapping them in adapter processes run by [Command].
+//
+// [JSONPath Compliance Test Suite]: https://github.com/jsonpath-standard/jsonpath-compliance-test-suite
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/theory/jsonpath"
+)
+
+// URL is the location of the latest version of the suite's cts.json file.
+const URL = "https://raw.githubusercontent.com/jsonpath-standard/jsonpath-compliance-test-suite/main/cts.json"
+
+// ErrFailure errors are returned by [Case.Run] for cases that fail.
+var ErrFailure = errors.New("compliance")
+
+// ErrLoad errors are returned by [Load], [LoadFile], and [Fetch] for suites
+// that fail to load.
+var ErrLoad = errors.New("compliance load")
+
+// Suite represents the JSONPath Compliance Test Suite.
+type Suite struct {
+	// Description describes the suite.
+	Description string `json:"description"`
+	// Tests contains the test cases in the suite.
+	Tests []*Case `json:"tests"`
+}
+
+// Case represents a single case in the JSONPath Compliance Test Suite.
+// A case either expects Selector to fail to parse, when InvalidSelector is
+// true, or expects it to select Result or one of Results from Document.
+// Cases with Results select nodes in a nondeterministic order.
+//
+//nolint:tagliatelle
+type Case struct {
+	// Name describes the case.
+	Name string `json:"name"`
+	// Selector is the JSONPath query to test.
+	Selector string `json:"selector"`
+	// Document is the JSON value from which to select.
+	Document any `json:"document"`
+	// Result contains the values the query selects from Document.
+	Result []any `json:"result"`
+	// Results contains the possible lists of values the query selects from
+	// Document.
+	Results [][]any `json:"results"`
+	// ResultPaths contains the normalized paths to the values in Result.
+	ResultPaths []string `json:"result_paths"`
+	// ResultsPaths contains the normalized paths to the values in each of
+	// Results.
+	ResultsPaths [][]string `json:"results_paths"`
+	// InvalidSelector indicates that Selector must fail to parse.
+	InvalidSelector bool `json:"invalid_selector"`
+	// Tags categorize the case.
+	Tags []string `json:"tags"`
+}
+
+// Result records the result of running a [Case].
+type Result struct {
+	// Case is the case that was run.
+	Case *Case
+	// Err describes why Case failed. Nil if Case passed.
+	Err error
+}
+
+// Passed returns true if the case passed.
+func (r Result) Passed() bool { return r.Err == nil }
+
+// Load loads a [Suite] from the JSON in r.
+func Load(r io.Reader) (*Suite, error) {
+	var suite Suite
+	if err := json.NewDecoder(r).Decode(&suite); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLoad, err)
+	}
+	return &suite, nil
+}
+
+// LoadFile loads a [Suite] from the JSON file name, such as the cts.json
+// file in the suite's repository.
+func LoadFile(name string) (*Suite, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLoad, err)
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Fetch downloads a [Suite] from url, usually [URL].
+func Fetch(ctx context.Context, url string) (*Suite, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLoad, err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLoad, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: GET %v: %v", ErrLoad, url, res.Status)
+	}
+	return Load(res.Body)
+}
+
+// Run runs every case in s with p and returns a [Result] for each, in the
+// same order as s.Tests. Uses [jsonpath.NewParser] if p is nil.
+func (s *Suite) Run(p *jsonpath.Parser) []Result {
+	if p == nil {
+		p = jsonpath.NewParser()
+	}
+	res := make([]Result, len(s.Tests))
+	for i, c := range s.Tests {
+		res[i] = Result{Case: c, Err: c.Run(p)}
+	}
+	return res
+}
+
+// Test runs every case in s with p as a subtest of t, failing each subtest
+// whose case fails. Uses [jsonpath.NewParser] if p is nil.
+func Test(t *testing.T, p *jsonpath.Parser, s *Suite) {
+	t.Helper()
+	if p == nil {
+		p = jsonpath.NewParser()
+	}
+	for i, c := range s.Tests {
+		t.Run(fmt.Sprintf("test_%03d", i), func(t *testing.T) {
+			t.Parallel()
+			if err := c.Run(p); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Run runs c with p. Returns nil if c passes and an [ErrFailure] error
+// describing the failure if it does not, including when p panics.
+func (c *Case) Run(p *jsonpath.Parser) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = c.fail("panic: %v", r)
+		}
+	}()
+
+	path, err := p.Parse(c.Selector)
+	if c.InvalidSelector {
+		switch {
+		case err == nil:
+			return c.fail("parsed invalid selector")
+		case !errors.Is(err, jsonpath.ErrPathParse):
+			return c.fail("unexpected error: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return c.fail("%w", err)
+	}
+
+	if res := path.Select(c.Document); !c.expect(res) {
+		return c.fail("selected %v", []any(res))
+	}
+
+	// Assemble and test located nodes.
+	nodes := []any{}
+	paths := []string{}
+	for l := range path.SelectLocated(c.Document).All() {
+		nodes = append(nodes, l.Node)
+		paths = append(paths, l.Path.String())
+	}
+	if !c.expect(nodes) {
+		return c.fail("located %v", nodes)
+	}
+
+	switch {
+	case c.ResultPaths != nil:
+		if !slices.Equal(c.ResultPaths, paths) {
+			return c.fail("located paths %v", paths)
+		}
+	case c.ResultsPaths != nil:
+		if !slices.ContainsFunc(c.ResultsPaths, func(exp []string) bool {
+			return slices.Equal(exp, paths)
+		}) {
+			return c.fail("located paths %v", paths)
+		}
+	}
+
+	return nil
+}
+
+// expect returns true if nodes equals c.Result or one of c.Results.
+func (c *Case) expect(nodes []any) bool {
+	switch {
+	case c.Result != nil:
+		return equal(c.Result, nodes)
+	case c.Results != nil:
+		return slices.ContainsFunc(c.Results, func(exp []any) bool {
+			return equal(exp, nodes)
+		})
+	default:
+		return true
+	}
+}
+
+// fail returns an [ErrFailure] error for c with a message formatted from
+// format and args.
+func (c *Case) fail(format string, args ...any) error {
+	//nolint:err113
+	return fmt.Errorf("%w: %v: `%v`: %w", ErrFailure, c.Name, c.Selector, fmt.Errorf(format, args...))
+}
+
+// equal returns true if exp and got contain equal values.
+func equal(exp, got []any) bool {
+	return slices.EqualFunc(exp, got, func(x, y any) bool {
+		return reflect.DeepEqual(x, y)
+	})
+}
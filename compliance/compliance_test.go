@@ -0,0 +1,182 @@
+package compliance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestCompliance(t *testing.T) {
+	t.Parallel()
+
+	// Run the full suite from the submodule.
+	suite, err := LoadFile(filepath.Join("..", "jsonpath-compliance-test-suite", "cts.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skip("jsonpath-compliance-test-suite submodule not checked out")
+	}
+	require.NoError(t, err)
+	Test(t, nil, suite)
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	suite, err := LoadFile(filepath.Join("testdata", "cts.json"))
+	r.NoError(err)
+	a.Equal("Sample of the JSONPath Compliance Test Suite", suite.Description)
+	r.Len(suite.Tests, 5)
+	a.Equal(&Case{
+		Name:        "basic, root",
+		Selector:    "$",
+		Document:    []any{"first", "second"},
+		Result:      []any{[]any{"first", "second"}},
+		ResultPaths: []string{"$"},
+	}, suite.Tests[0])
+	a.Equal(&Case{
+		Name:            "basic, no leading whitespace",
+		Selector:        " $",
+		InvalidSelector: true,
+		Tags:            []string{"whitespace"},
+	}, suite.Tests[1])
+
+	// Test errors.
+	suite, err = LoadFile(filepath.Join("testdata", "nonesuch.json"))
+	r.ErrorIs(err, ErrLoad)
+	r.ErrorIs(err, os.ErrNotExist)
+	a.Nil(suite)
+
+	suite, err = Load(strings.NewReader(`{"tests": {}}`))
+	r.ErrorIs(err, ErrLoad)
+	r.EqualError(err, "compliance load: json: cannot unmarshal object into Go struct field Suite.tests of type []*compliance.Case")
+	a.Nil(suite)
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cts.json" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join("testdata", "cts.json"))
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, tc := range []struct {
+		test string
+		url  string
+		err  string
+	}{
+		{
+			test: "ok",
+			url:  srv.URL + "/cts.json",
+		},
+		{
+			test: "not_found",
+			url:  srv.URL + "/nonesuch.json",
+			err:  "compliance load: GET " + srv.URL + "/nonesuch.json: 404 Not Found",
+		},
+		{
+			test: "bad_url",
+			url:  "\x00",
+			err:  `compliance load: parse "\x00": net/url: invalid control character in URL`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			suite, err := Fetch(context.Background(), tc.url)
+			if tc.err != "" {
+				r.ErrorIs(err, ErrLoad)
+				r.EqualError(err, tc.err)
+				a.Nil(suite)
+				return
+			}
+			r.NoError(err)
+			a.Len(suite.Tests, 5)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	suite, err := LoadFile(filepath.Join("testdata", "cts.json"))
+	r.NoError(err)
+
+	// All sample cases should pass.
+	for _, res := range suite.Run(nil) {
+		a.True(res.Passed(), res.Case.Name)
+		a.NoError(res.Err, res.Case.Name)
+	}
+	Test(t, jsonpath.NewParser(), suite)
+
+	// Cases should fail with descriptive errors.
+	doc := map[string]any{"a": []any{"x", "y"}}
+	suite = &Suite{Tests: []*Case{
+		{Name: "valid", Selector: "$.a[0]", Document: doc, Result: []any{"x"}},
+		{Name: "invalid", Selector: "$", InvalidSelector: true},
+		{Name: "parse", Selector: "$[", Document: doc, Result: []any{}},
+		{Name: "result", Selector: "$.a[0]", Document: doc, Result: []any{"y"}},
+		{Name: "results", Selector: "$.a[*]", Document: doc, Results: [][]any{{"y", "x"}}},
+		{
+			Name: "paths", Selector: "$.a[0]", Document: doc,
+			Result: []any{"x"}, ResultPaths: []string{"$['a'][1]"},
+		},
+		{
+			Name: "results_paths", Selector: "$.a[*]", Document: doc,
+			Results:      [][]any{{"x", "y"}},
+			ResultsPaths: [][]string{{"$['a'][1]", "$['a'][0]"}},
+		},
+		{Name: "panic", Selector: "$.a[?boom()]", Document: doc, Result: []any{}},
+	}}
+
+	// Register a function that panics.
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"boom",
+		spec.FuncLogical,
+		func([]spec.FuncExprArg) error { return nil },
+		func([]spec.PathValue) spec.PathValue { panic("boom") },
+	))
+	res := suite.Run(jsonpath.NewParser(jsonpath.WithRegistry(reg)))
+	r.Len(res, len(suite.Tests))
+	for i, exp := range []string{
+		"",
+		"compliance: invalid: `$`: parsed invalid selector",
+		"compliance: parse: `$[`: jsonpath: unexpected eof at position 3",
+		"compliance: result: `$.a[0]`: selected [x]",
+		"compliance: results: `$.a[*]`: selected [x y]",
+		"compliance: paths: `$.a[0]`: located paths [$['a'][0]]",
+		"compliance: results_paths: `$.a[*]`: located paths [$['a'][0] $['a'][1]]",
+		"compliance: panic: `$.a[?boom()]`: panic: boom",
+	} {
+		a.Same(suite.Tests[i], res[i].Case)
+		if exp == "" {
+			a.True(res[i].Passed(), suite.Tests[i].Name)
+			a.NoError(res[i].Err, suite.Tests[i].Name)
+			continue
+		}
+		a.False(res[i].Passed(), suite.Tests[i].Name)
+		a.ErrorIs(res[i].Err, ErrFailure, suite.Tests[i].Name)
+		a.EqualError(res[i].Err, exp, suite.Tests[i].Name)
+	}
+}
@@ -0,0 +1,321 @@
+package compliance
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os/exec"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/theory/jsonpath"
+)
+
+// ErrAdapter errors are returned by [Command.Select] and [Diff] when an
+// adapter process fails or violates the adapter protocol.
+var ErrAdapter = errors.New("compliance adapter")
+
+// Engine is a JSONPath implementation compared by [Diff]. Use [Native] for
+// theory/jsonpath and [NewCommand] for other implementations.
+type Engine interface {
+	// Name identifies the engine in each [Divergence].
+	Name() string
+
+	// Select applies selector to document and returns the values it
+	// selects. Returns an error if selector is invalid or its evaluation
+	// fails. Returns an [ErrAdapter] error if the engine itself fails, which
+	// aborts [Diff].
+	Select(selector string, document any) ([]any, error)
+}
+
+// native is the [Engine] returned by [Native].
+type native struct {
+	p *jsonpath.Parser
+}
+
+// Native returns an [Engine] named by [Module] that parses selectors with
+// p. Uses [jsonpath.NewParser] if p is nil.
+func Native(p *jsonpath.Parser) Engine {
+	if p == nil {
+		p = jsonpath.NewParser()
+	}
+	return native{p}
+}
+
+// Name returns [Module].
+func (native) Name() string { return Module }
+
+// Select parses selector and selects values from document.
+func (e native) Select(selector string, document any) ([]any, error) {
+	path, err := e.p.Parse(selector)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	nodes, err := path.TrySelect(document)
+	//nolint:wrapcheck
+	return nodes, err
+}
+
+// Command is an [Engine] that delegates to an adapter process, such as a
+// script that wraps a JavaScript, Java, or Python JSONPath library. It
+// starts the process on the first call to Select, and for each call writes
+// a JSON object with selector and document members to the process's
+// standard input on a single line, then reads a JSON object on a single
+// line from its standard output: either a result member containing an
+// array of the selected values, or an error member containing a string
+// describing why the selector is invalid or evaluation failed:
+//
+//	{"selector": "$.a", "document": {"a": 1}}
+//	{"result": [1]}
+//	{"selector": "$[", "document": {}}
+//	{"error": "unexpected end of input"}
+//
+// The adapters directory of this package contains an adapter for the
+// jsonpath-plus JavaScript library. Command is safe for concurrent use, but
+// sends one request at a time. Call Close to stop the process.
+type Command struct {
+	name string
+	cmd  *exec.Cmd
+
+	mu  sync.Mutex
+	in  io.WriteCloser
+	out *bufio.Scanner
+	enc *json.Encoder
+	err error
+}
+
+// NewCommand creates a [Command] named name that runs cmd, which must not
+// yet have been started or configured with standard input or output.
+func NewCommand(name string, cmd *exec.Cmd) *Command {
+	return &Command{name: name, cmd: cmd}
+}
+
+// Name returns the name of c.
+func (c *Command) Name() string { return c.name }
+
+// request is a request sent to an adapter process.
+type request struct {
+	Selector string `json:"selector"`
+	Document any    `json:"document"`
+}
+
+// response is a response read from an adapter process.
+type response struct {
+	Result []any   `json:"result"`
+	Error  *string `json:"error"`
+}
+
+// adapterError is the error returned by [Command.Select] for selectors that
+// the adapter reports as invalid or as failing to evaluate.
+type adapterError string
+
+func (e adapterError) Error() string { return string(e) }
+
+// Select sends selector and document to the adapter process, starting it if
+// necessary, and returns its result. Returns an [ErrAdapter] error if the
+// process fails to start, exits, or responds with anything other than a
+// valid response, after which all calls return the same error.
+func (c *Command) Select(selector string, document any) ([]any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.in == nil {
+		if err := c.start(); err != nil {
+			c.err = err
+			return nil, err
+		}
+	}
+
+	if err := c.enc.Encode(request{selector, document}); err != nil {
+		c.err = fmt.Errorf("%w: %v: %w", ErrAdapter, c.name, err)
+		return nil, c.err
+	}
+	if !c.out.Scan() {
+		err := c.out.Err()
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		c.err = fmt.Errorf("%w: %v: %w", ErrAdapter, c.name, err)
+		return nil, c.err
+	}
+
+	var res response
+	if err := json.Unmarshal(c.out.Bytes(), &res); err != nil {
+		c.err = fmt.Errorf("%w: %v: invalid response: %w", ErrAdapter, c.name, err)
+		return nil, c.err
+	}
+	switch {
+	case res.Error != nil:
+		return nil, adapterError(*res.Error)
+	case res.Result == nil:
+		c.err = fmt.Errorf("%w: %v: response has neither result nor error", ErrAdapter, c.name)
+		return nil, c.err
+	default:
+		return res.Result, nil
+	}
+}
+
+// start starts the adapter process.
+func (c *Command) start() error {
+	in, err := c.cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("%w: %v: %w", ErrAdapter, c.name, err)
+	}
+	out, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("%w: %v: %w", ErrAdapter, c.name, err)
+	}
+	if err := c.cmd.Start(); err != nil {
+		return fmt.Errorf("%w: %v: %w", ErrAdapter, c.name, err)
+	}
+
+	c.in = in
+	c.enc = json.NewEncoder(in)
+	c.enc.SetEscapeHTML(false)
+	c.out = bufio.NewScanner(out)
+	c.out.Buffer(nil, 64<<20)
+	return nil
+}
+
+// Close closes the standard input of the adapter process, if it has
+// started, and waits for it to exit. Subsequent calls to Select return an
+// [ErrAdapter] error.
+func (c *Command) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.in == nil {
+		if c.err == nil {
+			c.err = fmt.Errorf("%w: %v: closed", ErrAdapter, c.name)
+		}
+		return nil
+	}
+
+	c.in.Close()
+	c.in = nil
+	c.err = fmt.Errorf("%w: %v: closed", ErrAdapter, c.name)
+	if err := c.cmd.Wait(); err != nil {
+		return fmt.Errorf("%w: %v: %w", ErrAdapter, c.name, err)
+	}
+	return nil
+}
+
+// Outcome records the outcome of applying a selector to a document with an
+// [Engine].
+type Outcome struct {
+	// Result contains the values the selector selected. Nil if Error is
+	// not empty.
+	Result []any `json:"result"`
+	// Error describes why the selector is invalid or failed to evaluate.
+	Error string `json:"error,omitempty"`
+}
+
+// Divergence describes a [Case] for which the outcomes of engines compared
+// by [Diff] differ.
+type Divergence struct {
+	// Case is the case on which the engines diverge.
+	Case *Case `json:"case"`
+	// Outcomes maps the name of each engine to its outcome.
+	Outcomes map[string]Outcome `json:"outcomes"`
+}
+
+// Diff runs every case in s with each of engines, usually [Native] and one
+// or more [Command] engines, and returns a [Divergence] for each case on
+// which their outcomes differ, in the same order as s.Tests. It ignores the
+// expected results of the cases, so s may contain any corpus of selectors
+// and documents. Outcomes agree when all engines report an error,
+// regardless of message, or when they select equal values. It compares
+// values as JSON, so that numbers of different types compare equal, and
+// compares them in order only for cases with a Result, which select values
+// in a deterministic order; otherwise it ignores their order. Returns an
+// [ErrAdapter] error if an engine fails.
+func Diff(s *Suite, engines ...Engine) ([]Divergence, error) {
+	var divs []Divergence
+	for _, c := range s.Tests {
+		outcomes := make(map[string]Outcome, len(engines))
+		for _, e := range engines {
+			out, err := outcome(e, c)
+			if err != nil {
+				return nil, err
+			}
+			outcomes[e.Name()] = out
+		}
+
+		ordered := c.Result != nil
+		outs := slices.Collect(maps.Values(outcomes))
+		for i := 1; i < len(outs); i++ {
+			if !agree(outs[0], outs[i], ordered) {
+				divs = append(divs, Divergence{Case: c, Outcomes: outcomes})
+				break
+			}
+		}
+	}
+	return divs, nil
+}
+
+// outcome applies c to e and returns the outcome, with values normalized
+// by their JSON encoding. Returns an error only for [ErrAdapter] errors.
+func outcome(e Engine, c *Case) (out Outcome, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			out, err = Outcome{Error: fmt.Sprintf("panic: %v", r)}, nil
+		}
+	}()
+
+	res, err := e.Select(c.Selector, c.Document)
+	switch {
+	case errors.Is(err, ErrAdapter):
+		return Outcome{}, err
+	case err != nil:
+		return Outcome{Error: err.Error()}, nil
+	}
+
+	if res == nil {
+		res = []any{}
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return Outcome{Error: err.Error()}, nil
+	}
+	out.Result = []any{}
+	if err := json.Unmarshal(data, &out.Result); err != nil {
+		return Outcome{Error: err.Error()}, nil
+	}
+	return out, nil
+}
+
+// agree returns true if a and b both failed or both selected the same
+// values, in the same order if ordered is true.
+func agree(a, b Outcome, ordered bool) bool {
+	if a.Error != "" || b.Error != "" {
+		return a.Error != "" && b.Error != ""
+	}
+	if ordered {
+		return equal(a.Result, b.Result)
+	}
+	if len(a.Result) != len(b.Result) {
+		return false
+	}
+
+	// Match each value in a with an unmatched equal value in b.
+	matched := make([]bool, len(b.Result))
+	for _, x := range a.Result {
+		found := false
+		for i, y := range b.Result {
+			if !matched[i] && reflect.DeepEqual(x, y) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,322 @@
+package compliance
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+)
+
+// adapterEnv names the environment variable that runs the test binary as an
+// adapter process in TestAdapterProcess, and selects its behavior.
+const adapterEnv = "COMPLIANCE_TEST_ADAPTER"
+
+// adapter returns a Command that runs the test binary as an adapter process
+// with behavior mode.
+func adapter(name, mode string) *Command {
+	//nolint:gosec
+	cmd := exec.Command(os.Args[0], "-test.run=^TestAdapterProcess$")
+	cmd.Env = append(os.Environ(), adapterEnv+"="+mode)
+	return NewCommand(name, cmd)
+}
+
+// TestAdapterProcess implements the adapter protocol with theory/jsonpath
+// when run by adapter. Its behavior depends on the mode:
+//
+//   - native: Responds with the values selected by theory/jsonpath
+//   - reverse: Responds with the selected values in reverse order
+//   - lenient: Responds with an empty result for invalid selectors
+//   - garbage: Responds with invalid JSON
+//   - empty: Responds with an empty object
+//   - exit: Exits without responding
+//
+//nolint:paralleltest
+func TestAdapterProcess(t *testing.T) {
+	mode := os.Getenv(adapterEnv)
+	if mode == "" {
+		t.Skip("not running as an adapter")
+	}
+
+	in := bufio.NewScanner(os.Stdin)
+	enc := json.NewEncoder(os.Stdout)
+	p := jsonpath.NewParser()
+	for in.Scan() {
+		var req request
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			os.Exit(3)
+		}
+		res := map[string]any{}
+		switch mode {
+		case "garbage":
+			fmt.Println("nope")
+			continue
+		case "empty":
+		case "exit":
+			os.Exit(1)
+		default:
+			path, err := p.Parse(req.Selector)
+			switch {
+			case err == nil:
+				nodes := []any(path.Select(req.Document))
+				if mode == "reverse" {
+					slices.Reverse(nodes)
+				}
+				res["result"] = nodes
+			case mode == "lenient":
+				res["result"] = []any{}
+			default:
+				res["error"] = err.Error()
+			}
+		}
+		if err := enc.Encode(res); err != nil {
+			os.Exit(4)
+		}
+	}
+	os.Exit(0)
+}
+
+func TestNative(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	e := Native(nil)
+	a.Equal(Module, e.Name())
+	res, err := e.Select("$.a", map[string]any{"a": 1})
+	require.NoError(t, err)
+	a.Equal([]any{1}, res)
+
+	_, err = e.Select("$[", nil)
+	require.ErrorIs(t, err, jsonpath.ErrPathParse)
+
+	e = Native(jsonpath.NewParser(jsonpath.WithMaxNodes(1)))
+	_, err = e.Select("$..*", []any{[]any{1}})
+	require.ErrorIs(t, err, jsonpath.ErrBudgetExceeded)
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	c := adapter("test", "native")
+	a.Equal("test", c.Name())
+	res, err := c.Select("$.a[*]", map[string]any{"a": []any{1, "x"}})
+	require.NoError(t, err)
+	a.Equal([]any{float64(1), "x"}, res)
+
+	_, err = c.Select("$[", nil)
+	require.EqualError(t, err, "jsonpath: unexpected eof at position 3")
+	require.NotErrorIs(t, err, ErrAdapter)
+
+	require.NoError(t, c.Close())
+	_, err = c.Select("$", nil)
+	require.EqualError(t, err, "compliance adapter: test: closed")
+	require.NoError(t, c.Close())
+
+	// Should fail once closed without starting.
+	c = adapter("unstarted", "native")
+	require.NoError(t, c.Close())
+	_, err = c.Select("$", nil)
+	require.EqualError(t, err, "compliance adapter: unstarted: closed")
+}
+
+func TestCommandErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		cmd  *Command
+		err  string
+	}{
+		{
+			test: "garbage",
+			cmd:  adapter("garbage", "garbage"),
+			err:  "compliance adapter: garbage: invalid response: invalid character 'o' in literal null (expecting 'u')",
+		},
+		{
+			test: "empty",
+			cmd:  adapter("empty", "empty"),
+			err:  "compliance adapter: empty: response has neither result nor error",
+		},
+		{
+			test: "exit",
+			cmd:  adapter("exit", "exit"),
+			err:  "compliance adapter: exit: unexpected EOF",
+		},
+		{
+			test: "not_found",
+			cmd:  NewCommand("missing", exec.Command(filepath.Join(t.TempDir(), "nonesuch"))),
+			err:  "compliance adapter: missing: fork/exec",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			_, err := tc.cmd.Select("$", nil)
+			require.ErrorIs(t, err, ErrAdapter)
+			a.ErrorContains(err, tc.err)
+
+			// Should return the same error again.
+			_, again := tc.cmd.Select("$", nil)
+			a.Equal(err, again)
+			tc.cmd.Close()
+		})
+	}
+
+	// Should report unencodable documents.
+	c := adapter("test", "native")
+	_, err := c.Select("$", func() {})
+	require.ErrorIs(t, err, ErrAdapter)
+	require.EqualError(t, err, "compliance adapter: test: json: unsupported type: func()")
+	c.Close()
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	suite := &Suite{Tests: []*Case{
+		{Name: "ordered", Selector: "$[*]", Document: []any{1, 2}, Result: []any{1, 2}},
+		{Name: "unordered", Selector: "$.*", Document: map[string]any{"a": 1, "b": 1, "c": 2}},
+		{Name: "invalid", Selector: "$[", InvalidSelector: true},
+		{Name: "none", Selector: "$.x", Document: map[string]any{}},
+		{Name: "numbers", Selector: "$", Document: []int{1}},
+	}}
+
+	// Should find no divergence between the same implementations.
+	native := adapter("native", "native")
+	t.Cleanup(func() { native.Close() })
+	divs, err := Diff(suite, Native(nil), native)
+	require.NoError(t, err)
+	a.Nil(divs)
+
+	// Should find divergent order only for ordered cases.
+	reverse := adapter("reverse", "reverse")
+	t.Cleanup(func() { reverse.Close() })
+	divs, err = Diff(suite, Native(nil), reverse)
+	require.NoError(t, err)
+	require.Len(t, divs, 1)
+	a.Equal(Divergence{
+		Case: suite.Tests[0],
+		Outcomes: map[string]Outcome{
+			Module:    {Result: []any{float64(1), float64(2)}},
+			"reverse": {Result: []any{float64(2), float64(1)}},
+		},
+	}, divs[0])
+
+	// Should find divergent validity.
+	lenient := adapter("lenient", "lenient")
+	t.Cleanup(func() { lenient.Close() })
+	divs, err = Diff(suite, Native(nil), native, lenient)
+	require.NoError(t, err)
+	require.Len(t, divs, 1)
+	a.Equal(suite.Tests[2], divs[0].Case)
+	a.Equal(Outcome{Error: "jsonpath: unexpected eof at position 3"}, divs[0].Outcomes[Module])
+	a.Equal(Outcome{Error: "jsonpath: unexpected eof at position 3"}, divs[0].Outcomes["native"])
+	a.Equal(Outcome{Result: []any{}}, divs[0].Outcomes["lenient"])
+
+	// Should marshal to JSON.
+	data, err := json.Marshal(divs[0])
+	require.NoError(t, err)
+	a.JSONEq(`{
+		"case": {
+			"name": "invalid",
+			"selector": "$[",
+			"document": null,
+			"result": null,
+			"results": null,
+			"result_paths": null,
+			"results_paths": null,
+			"invalid_selector": true,
+			"tags": null
+		},
+		"outcomes": {
+			"github.com/theory/jsonpath": {"result": null, "error": "jsonpath: unexpected eof at position 3"},
+			"native": {"result": null, "error": "jsonpath: unexpected eof at position 3"},
+			"lenient": {"result": []}
+		}
+	}`, string(data))
+
+	// Should abort on adapter errors.
+	divs, err = Diff(suite, Native(nil), adapter("exit", "exit"))
+	require.ErrorIs(t, err, ErrAdapter)
+	a.Nil(divs)
+}
+
+func TestDiffPanic(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	suite := &Suite{Tests: []*Case{{Name: "panic", Selector: "$", Document: 1}}}
+	divs, err := Diff(suite, Native(nil), panicEngine{})
+	require.NoError(t, err)
+	require.Len(t, divs, 1)
+	a.Equal(Outcome{Error: "panic: oops"}, divs[0].Outcomes["panic"])
+
+	// Should report unencodable results as errors.
+	divs, err = Diff(suite, Native(nil), badEngine{})
+	require.NoError(t, err)
+	require.Len(t, divs, 1)
+	a.Equal(Outcome{Error: "json: unsupported type: func()"}, divs[0].Outcomes["bad"])
+
+	// Should agree with itself.
+	divs, err = Diff(suite, Native(nil))
+	require.NoError(t, err)
+	a.Nil(divs)
+}
+
+type panicEngine struct{}
+
+func (panicEngine) Name() string { return "panic" }
+
+func (panicEngine) Select(string, any) ([]any, error) { panic("oops") }
+
+type badEngine struct{}
+
+func (badEngine) Name() string { return "bad" }
+
+func (badEngine) Select(string, any) ([]any, error) { return []any{func() {}}, nil }
+
+func TestAgree(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test    string
+		a       Outcome
+		b       Outcome
+		ordered bool
+		exp     bool
+	}{
+		{"errors", Outcome{Error: "x"}, Outcome{Error: "y"}, false, true},
+		{"error_result", Outcome{Error: "x"}, Outcome{Result: []any{}}, false, false},
+		{"result_error", Outcome{Result: []any{}}, Outcome{Error: "x"}, false, false},
+		{"empty", Outcome{Result: []any{}}, Outcome{Result: []any{}}, true, true},
+		{"ordered", Outcome{Result: []any{1.0, 2.0}}, Outcome{Result: []any{1.0, 2.0}}, true, true},
+		{"misordered", Outcome{Result: []any{1.0, 2.0}}, Outcome{Result: []any{2.0, 1.0}}, true, false},
+		{"unordered", Outcome{Result: []any{1.0, 2.0}}, Outcome{Result: []any{2.0, 1.0}}, false, true},
+		{"lengths", Outcome{Result: []any{1.0}}, Outcome{Result: []any{1.0, 1.0}}, false, false},
+		{"duplicates", Outcome{Result: []any{1.0, 1.0, 2.0}}, Outcome{Result: []any{1.0, 2.0, 2.0}}, false, false},
+		{"deep", Outcome{Result: []any{[]any{"a"}, 1.0}}, Outcome{Result: []any{1.0, []any{"a"}}}, false, true},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, agree(tc.a, tc.b, tc.ordered))
+		})
+	}
+}
+
+func TestAdapterError(t *testing.T) {
+	t.Parallel()
+	err := error(adapterError("bad selector"))
+	assert.EqualError(t, err, "bad selector")
+	assert.False(t, errors.Is(err, ErrAdapter))
+}
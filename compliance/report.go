@@ -0,0 +1,336 @@
+package compliance
+
+import (
+	"encoding/json"
+	"runtime/debug"
+
+	"github.com/theory/jsonpath"
+)
+
+// Module is the path of the module that implements the parser and queries
+// described by a [Report].
+const Module = "github.com/theory/jsonpath"
+
+// Probe describes a query that probes a documented behavior of JSONPath
+// implementations, such as the order in which they select nodes or the
+// queries they consider invalid.
+type Probe struct {
+	// Name identifies the probe.
+	Name string `json:"name"`
+	// Description describes the behavior the probe tests.
+	Description string `json:"description"`
+	// Selector is the JSONPath query to run.
+	Selector string `json:"selector"`
+	// Document is the JSON value from which to select.
+	Document any `json:"document"`
+}
+
+// ProbeResult records the behavior of an implementation for a [Probe].
+type ProbeResult struct {
+	Probe
+	// Valid is true if the implementation parsed Selector.
+	Valid bool `json:"valid"`
+	// Error contains the parse error for an invalid Selector.
+	Error string `json:"error,omitempty"`
+	// Result contains the values Selector selected from Document, in the
+	// order selected. Nil for an invalid Selector.
+	Result []any `json:"result"`
+	// Paths contains the normalized paths to the values in Result.
+	Paths []string `json:"paths"`
+}
+
+// Summary summarizes the results of running a [Suite].
+type Summary struct {
+	// Total is the number of cases in the suite.
+	Total int `json:"total"`
+	// Passed is the number of cases that passed.
+	Passed int `json:"passed"`
+	// Failed lists the names of the cases that failed.
+	Failed []string `json:"failed"`
+}
+
+// Report is a machine-readable description of the capabilities and behavior
+// of a [jsonpath.Parser] and the paths it parses. Marshal it with
+// [encoding/json] to compare it with the reports of other implementations.
+type Report struct {
+	// Module is the path of the module that implements the parser.
+	Module string `json:"module"`
+	// Version is the version of Module, if known.
+	Version string `json:"version,omitempty"`
+	// Functions lists the names of the function extensions registered with
+	// the parser.
+	Functions []string `json:"functions"`
+	// Probes contains the result of each of [Probes].
+	Probes []ProbeResult `json:"probes"`
+	// Suite summarizes the results of running a [Suite], if any.
+	Suite *Summary `json:"suite,omitempty"`
+}
+
+// NewReport runs [Probes] and the cases in s with p and returns a [Report]
+// of the results. Uses [jsonpath.NewParser] if p is nil. Omits the suite
+// summary if s is nil.
+func NewReport(p *jsonpath.Parser, s *Suite) *Report {
+	if p == nil {
+		p = jsonpath.NewParser()
+	}
+
+	probes := Probes()
+	report := &Report{
+		Module:    Module,
+		Version:   version(),
+		Functions: p.Registry().Names(),
+		Probes:    make([]ProbeResult, len(probes)),
+	}
+	for i, probe := range probes {
+		report.Probes[i] = probe.Run(p)
+	}
+
+	if s != nil {
+		report.Suite = &Summary{Total: len(s.Tests), Failed: []string{}}
+		for _, res := range s.Run(p) {
+			if res.Passed() {
+				report.Suite.Passed++
+			} else {
+				report.Suite.Failed = append(report.Suite.Failed, res.Case.Name)
+			}
+		}
+	}
+
+	return report
+}
+
+// Run runs pr with p and returns the result.
+func (pr Probe) Run(p *jsonpath.Parser) ProbeResult {
+	res := ProbeResult{Probe: pr}
+	path, err := p.Parse(pr.Selector)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Valid = true
+	res.Result = []any{}
+	res.Paths = []string{}
+	for l := range path.SelectLocated(pr.Document).All() {
+		res.Result = append(res.Result, l.Node)
+		res.Paths = append(res.Paths, l.Path.String())
+	}
+	return res
+}
+
+// version returns the version of [Module] recorded in the build info of the
+// running binary, or an empty string if it's not available.
+func version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if info.Main.Path == Module {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == Module {
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+// Probes returns the behavior probes run by [NewReport]. Each probes a
+// behavior that RFC 9535 defines but implementations often get wrong, or
+// that it leaves to implementations, on documents for which the results do
+// not depend on the order of object members.
+func Probes() []Probe {
+	return []Probe{
+		{
+			Name:        "array_order",
+			Description: "Selects array elements in index order",
+			Selector:    `$[*]`,
+			Document:    doc(`[3, 1, 2]`),
+		},
+		{
+			Name:        "descendant_order",
+			Description: "Selects descendants with parents before children and array elements in index order",
+			Selector:    `$..*`,
+			Document:    doc(`[[1, [2]], 3]`),
+		},
+		{
+			Name:        "duplicate_indexes",
+			Description: "Selects a node once for each selector that selects it",
+			Selector:    `$[0, 0, -3]`,
+			Document:    doc(`["a", "b", "c"]`),
+		},
+		{
+			Name:        "duplicate_wildcard",
+			Description: "Retains nodes selected by both an index and a wildcard",
+			Selector:    `$[0, *]`,
+			Document:    doc(`["a", "b"]`),
+		},
+		{
+			Name:        "negative_index",
+			Description: "Selects from the end of an array with a negative index",
+			Selector:    `$[-1]`,
+			Document:    doc(`[1, 2, 3]`),
+		},
+		{
+			Name:        "index_out_of_range",
+			Description: "Selects nothing with an index beyond the end of an array",
+			Selector:    `$[5]`,
+			Document:    doc(`[1, 2, 3]`),
+		},
+		{
+			Name:        "reverse_slice",
+			Description: "Selects elements in reverse order with a negative slice step",
+			Selector:    `$[::-1]`,
+			Document:    doc(`[1, 2, 3]`),
+		},
+		{
+			Name:        "zero_step_slice",
+			Description: "Selects nothing with a slice step of zero",
+			Selector:    `$[::0]`,
+			Document:    doc(`[1, 2, 3]`),
+		},
+		{
+			Name:        "slice_bounds",
+			Description: "Clamps slice bounds beyond the ends of an array",
+			Selector:    `$[-100:100]`,
+			Document:    doc(`[1, 2, 3]`),
+		},
+		{
+			Name:        "filter_object",
+			Description: "Filters the member values of an object",
+			Selector:    `$[?@ > 1]`,
+			Document:    doc(`{"a": 1, "b": 2}`),
+		},
+		{
+			Name:        "missing_equals_missing",
+			Description: "Compares two missing values as equal",
+			Selector:    `$[?@.a == @.b]`,
+			Document:    doc(`[{"c": 1}, {"a": 1}]`),
+		},
+		{
+			Name:        "null_not_missing",
+			Description: "Distinguishes a null value from a missing value",
+			Selector:    `$[?@.a == null]`,
+			Document:    doc(`[{"a": null}, {}]`),
+		},
+		{
+			Name:        "mixed_type_ordering",
+			Description: "Orders only values of the same type",
+			Selector:    `$[?@ < 2]`,
+			Document:    doc(`[1, "1", true, null]`),
+		},
+		{
+			Name:        "int_float_equality",
+			Description: "Compares integers and floats by value",
+			Selector:    `$[?@ == 1]`,
+			Document:    doc(`[1.0, 1.5]`),
+		},
+		{
+			Name:        "exponent_literal",
+			Description: "Parses number literals with exponents",
+			Selector:    `$[?@ == 1e2]`,
+			Document:    doc(`[100, 1]`),
+		},
+		{
+			Name:        "unicode_escape",
+			Description: "Decodes Unicode escapes in names",
+			Selector:    `$['\u0041']`,
+			Document:    doc(`{"A": 1}`),
+		},
+		{
+			Name:        "keyword_shorthand",
+			Description: "Selects a member named by a keyword in shorthand notation",
+			Selector:    `$.true`,
+			Document:    doc(`{"true": 1}`),
+		},
+		{
+			Name:        "normalized_path_escapes",
+			Description: "Escapes apostrophes and control characters in normalized paths",
+			Selector:    `$.*`,
+			Document:    doc(`{"a'b\u001f": 1}`),
+		},
+		{
+			Name:        "length_code_points",
+			Description: "Counts Unicode scalar values with length()",
+			Selector:    `$[?length(@) == 1]`,
+			Document:    doc(`["\u00e9", "e\u0301", "\ud83d\ude00", "ab"]`),
+		},
+		{
+			Name:        "count_nodes",
+			Description: "Counts array elements and object members with count()",
+			Selector:    `$[?count(@.*) == 2]`,
+			Document:    doc(`[[1, 2], [1], {"a": 1, "b": 2}]`),
+		},
+		{
+			Name:        "value_singular",
+			Description: "Returns a value from value() only for a single node",
+			Selector:    `$[?value(@..x) == 1]`,
+			Document:    doc(`[{"x": 1}, {"y": {"x": 1}}, {"x": 1, "y": {"x": 1}}]`),
+		},
+		{
+			Name:        "match_anchored",
+			Description: "Matches the entire string with match()",
+			Selector:    `$[?match(@, 'a')]`,
+			Document:    doc(`["a", "ba"]`),
+		},
+		{
+			Name:        "search_unanchored",
+			Description: "Matches a substring with search()",
+			Selector:    `$[?search(@, 'a')]`,
+			Document:    doc(`["a", "ba", "b"]`),
+		},
+		{
+			Name:        "regex_dot",
+			Description: "Matches any character but line breaks with . in I-Regexp",
+			Selector:    `$[?match(@, 'a.b')]`,
+			Document:    doc(`["a\nb", "a\rb", "axb"]`),
+		},
+		{
+			Name:        "non_singular_comparison",
+			Description: "Rejects comparison of a non-singular query",
+			Selector:    `$[?@.* == 1]`,
+			Document:    doc(`[[1]]`),
+		},
+		{
+			Name:        "unknown_function",
+			Description: "Rejects an unregistered function",
+			Selector:    `$[?nonesuch(@)]`,
+			Document:    doc(`[1]`),
+		},
+		{
+			Name:        "leading_blank_space",
+			Description: "Rejects blank space before the root identifier",
+			Selector:    ` $`,
+			Document:    doc(`[1]`),
+		},
+		{
+			Name:        "trailing_blank_space",
+			Description: "Rejects blank space after the query",
+			Selector:    `$ `,
+			Document:    doc(`[1]`),
+		},
+		{
+			Name:        "leading_zero_index",
+			Description: "Rejects an index with a leading zero",
+			Selector:    `$[01]`,
+			Document:    doc(`[1, 2]`),
+		},
+		{
+			Name:        "negative_zero_index",
+			Description: "Rejects an index of -0",
+			Selector:    `$[-0]`,
+			Document:    doc(`[1, 2]`),
+		},
+	}
+}
+
+// doc decodes src, which must be valid JSON, just as [Load] decodes
+// documents.
+func doc(src string) any {
+	var val any
+	if err := json.Unmarshal([]byte(src), &val); err != nil {
+		panic(err)
+	}
+	return val
+}
@@ -0,0 +1,162 @@
+package compliance
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	report := NewReport(nil, nil)
+	a.Equal(Module, report.Module)
+	a.Equal([]string{"count", "length", "match", "search", "value"}, report.Functions)
+	a.Nil(report.Suite)
+
+	probes := Probes()
+	r.Len(report.Probes, len(probes))
+	seen := map[string]bool{}
+	for i, res := range report.Probes {
+		a.Equal(probes[i], res.Probe)
+		a.False(seen[res.Name], "duplicate probe %v", res.Name)
+		seen[res.Name] = true
+		a.NotEmpty(res.Description, res.Name)
+		if res.Valid {
+			a.Empty(res.Error, res.Name)
+			a.Len(res.Paths, len(res.Result), res.Name)
+		} else {
+			a.NotEmpty(res.Error, res.Name)
+			a.Nil(res.Result, res.Name)
+			a.Nil(res.Paths, res.Name)
+		}
+	}
+
+	// Check the results of each probe against RFC 9535.
+	for name, exp := range map[string]struct {
+		result []any
+		paths  []string
+	}{
+		"array_order": {
+			[]any{3.0, 1.0, 2.0},
+			[]string{"$[0]", "$[1]", "$[2]"},
+		},
+		"descendant_order": {
+			[]any{[]any{1.0, []any{2.0}}, 3.0, 1.0, []any{2.0}, 2.0},
+			[]string{"$[0]", "$[1]", "$[0][0]", "$[0][1]", "$[0][1][0]"},
+		},
+		"duplicate_indexes":      {[]any{"a", "a", "a"}, []string{"$[0]", "$[0]", "$[0]"}},
+		"duplicate_wildcard":     {[]any{"a", "a", "b"}, []string{"$[0]", "$[0]", "$[1]"}},
+		"negative_index":         {[]any{3.0}, []string{"$[2]"}},
+		"index_out_of_range":     {[]any{}, []string{}},
+		"reverse_slice":          {[]any{3.0, 2.0, 1.0}, []string{"$[2]", "$[1]", "$[0]"}},
+		"zero_step_slice":        {[]any{}, []string{}},
+		"slice_bounds":           {[]any{1.0, 2.0, 3.0}, []string{"$[0]", "$[1]", "$[2]"}},
+		"filter_object":          {[]any{2.0}, []string{"$['b']"}},
+		"missing_equals_missing": {[]any{map[string]any{"c": 1.0}}, []string{"$[0]"}},
+		"null_not_missing":       {[]any{map[string]any{"a": nil}}, []string{"$[0]"}},
+		"mixed_type_ordering":    {[]any{1.0}, []string{"$[0]"}},
+		"int_float_equality":     {[]any{1.0}, []string{"$[0]"}},
+		"exponent_literal":       {[]any{100.0}, []string{"$[0]"}},
+		"unicode_escape":         {[]any{1.0}, []string{"$['A']"}},
+		"keyword_shorthand":      {[]any{1.0}, []string{"$['true']"}},
+		"normalized_path_escapes": {
+			[]any{1.0},
+			[]string{`$['a\'b\u001f']`},
+		},
+		"length_code_points": {[]any{"é", "😀"}, []string{"$[0]", "$[2]"}},
+		"count_nodes": {
+			[]any{[]any{1.0, 2.0}, map[string]any{"a": 1.0, "b": 2.0}},
+			[]string{"$[0]", "$[2]"},
+		},
+		"value_singular": {
+			[]any{map[string]any{"x": 1.0}, map[string]any{"y": map[string]any{"x": 1.0}}},
+			[]string{"$[0]", "$[1]"},
+		},
+		"match_anchored":          {[]any{"a"}, []string{"$[0]"}},
+		"search_unanchored":       {[]any{"a", "ba"}, []string{"$[0]", "$[1]"}},
+		"regex_dot":               {[]any{"axb"}, []string{"$[2]"}},
+		"non_singular_comparison": {},
+		"unknown_function":        {},
+		"leading_blank_space":     {},
+		"trailing_blank_space":    {},
+		"leading_zero_index":      {},
+		"negative_zero_index":     {},
+	} {
+		var res *ProbeResult
+		for i := range report.Probes {
+			if report.Probes[i].Name == name {
+				res = &report.Probes[i]
+			}
+		}
+		r.NotNil(res, name)
+		a.Equal(exp.result != nil, res.Valid, name)
+		a.Equal(exp.result, res.Result, name)
+		a.Equal(exp.paths, res.Paths, name)
+		delete(seen, name)
+	}
+	a.Empty(seen, "untested probes")
+
+	// Should marshal to JSON.
+	data, err := json.Marshal(report)
+	r.NoError(err)
+	var got map[string]any
+	r.NoError(json.Unmarshal(data, &got))
+	a.Equal(Module, got["module"])
+	a.NotContains(got, "suite")
+	a.Len(got["probes"], len(probes))
+}
+
+func TestReportSuite(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	suite, err := LoadFile(filepath.Join("testdata", "cts.json"))
+	r.NoError(err)
+	suite.Tests = append(suite.Tests, &Case{Name: "fail", Selector: "$", InvalidSelector: true})
+
+	// Register a function.
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"first",
+		spec.FuncValue,
+		func([]spec.FuncExprArg) error { return nil },
+		func([]spec.PathValue) spec.PathValue { return nil },
+	))
+
+	report := NewReport(jsonpath.NewParser(jsonpath.WithRegistry(reg)), suite)
+	a.Equal([]string{"count", "first", "length", "match", "search", "value"}, report.Functions)
+	a.Equal(&Summary{Total: 6, Passed: 5, Failed: []string{"fail"}}, report.Suite)
+
+	data, err := json.Marshal(report.Suite)
+	r.NoError(err)
+	a.JSONEq(`{"total": 6, "passed": 5, "failed": ["fail"]}`, string(data))
+}
+
+func TestProbeRun(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	probe := Probe{Name: "x", Selector: "$.a", Document: map[string]any{"a": nil}}
+	a.Equal(ProbeResult{
+		Probe:  probe,
+		Valid:  true,
+		Result: []any{nil},
+		Paths:  []string{"$['a']"},
+	}, probe.Run(jsonpath.NewParser()))
+
+	probe.Selector = "$["
+	a.Equal(ProbeResult{
+		Probe: probe,
+		Error: "jsonpath: unexpected eof at position 3",
+	}, probe.Run(jsonpath.NewParser()))
+}
@@ -0,0 +1,65 @@
+package jsonpath
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Change describes a difference between two JSON values at a normalized
+// path, as returned by [Diff] and [Path.Diff]. See [spec.Change] for
+// details.
+type Change = spec.Change
+
+// Diff compares a and b, two JSON documents, and returns the changes that
+// transform a into b, each identifying the added, removed, or replaced
+// value by its normalized path, in the same format as [Path.SelectLocated].
+// Changes that add a value have an Old value of [Nothing], and those that
+// remove a value have a New value of [Nothing]. Returns nil if a and b are
+// equal. See [spec.Diff] for details.
+func Diff(a, b any) []Change {
+	return spec.Diff(a, b, spec.Normalized())
+}
+
+// Diff compares the nodes that p selects from a and b, two JSON documents,
+// and returns the changes that transform them, as [Diff] does for entire
+// documents. Use it to scope a diff to the parts of the documents that p
+// selects, such as $.spec or $.items[*].status. Nodes that p selects from
+// only one of the documents are added or removed, while nodes that p
+// selects inside other nodes it selects, as $..* does, are compared only
+// once. Returns nil if p selects the same values from a and b.
+func (p *Path) Diff(a, b any) []Change {
+	type pair struct {
+		path          spec.NormalizedPath
+		before, after any
+	}
+	pairs := map[string]*pair{}
+	for _, node := range p.SelectLocated(a) {
+		pairs[node.Path.String()] = &pair{path: node.Path, before: node.Node, after: Nothing}
+	}
+	for _, node := range p.SelectLocated(b) {
+		if pr, ok := pairs[node.Path.String()]; ok {
+			pr.after = node.Node
+		} else {
+			pairs[node.Path.String()] = &pair{path: node.Path, before: Nothing, after: node.Node}
+		}
+	}
+
+	sorted := slices.SortedFunc(maps.Values(pairs), func(x, y *pair) int {
+		return x.path.Compare(y.path)
+	})
+
+	var changes []Change
+	var prev spec.NormalizedPath
+	for i, pr := range sorted {
+		// Skip nodes inside the previous node compared; sorting places them
+		// immediately after it.
+		if i > 0 && len(pr.path) > len(prev) && slices.Equal(pr.path[:len(prev)], prev) {
+			continue
+		}
+		prev = pr.path
+		changes = append(changes, spec.Diff(pr.before, pr.after, pr.path)...)
+	}
+	return changes
+}
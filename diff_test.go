@@ -0,0 +1,106 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	before := map[string]any{"a": 1, "b": []any{"x"}}
+	after := map[string]any{"a": 1, "b": []any{"y", "z"}}
+	a.Nil(Diff(before, before))
+	a.Equal([]Change{
+		{Path: spec.Normalized(spec.Name("b"), spec.Index(0)), Old: "x", New: "y"},
+		{Path: spec.Normalized(spec.Name("b"), spec.Index(1)), Old: Nothing, New: "z"},
+	}, Diff(before, after))
+}
+
+func TestPathDiff(t *testing.T) {
+	t.Parallel()
+
+	before := map[string]any{
+		"meta":  map[string]any{"rev": 1},
+		"items": []any{map[string]any{"id": 1, "qty": 2}, map[string]any{"id": 2, "qty": 1}},
+	}
+	after := map[string]any{
+		"meta":  map[string]any{"rev": 2},
+		"items": []any{map[string]any{"id": 1, "qty": 3}, map[string]any{"id": 2, "qty": 1, "x": true}, map[string]any{"id": 3}},
+	}
+	np := func(n ...any) spec.NormalizedPath {
+		np := spec.Normalized()
+		for _, sel := range n {
+			switch sel := sel.(type) {
+			case string:
+				np = append(np, spec.Name(sel))
+			case int:
+				np = append(np, spec.Index(sel))
+			}
+		}
+		return np
+	}
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  []Change
+	}{
+		{
+			test: "root",
+			path: `$`,
+			exp:  Diff(before, after),
+		},
+		{
+			test: "scoped",
+			path: `$.items`,
+			exp: []Change{
+				{Path: np("items", 0, "qty"), Old: 2, New: 3},
+				{Path: np("items", 1, "x"), Old: Nothing, New: true},
+				{Path: np("items", 2), Old: Nothing, New: map[string]any{"id": 3}},
+			},
+		},
+		{
+			test: "fields",
+			path: `$.items[*].qty`,
+			exp: []Change{
+				{Path: np("items", 0, "qty"), Old: 2, New: 3},
+			},
+		},
+		{
+			test: "added_node",
+			path: `$.items[*].id`,
+			exp: []Change{
+				{Path: np("items", 2, "id"), Old: Nothing, New: 3},
+			},
+		},
+		{
+			test: "removed_node",
+			path: `$[?@.rev == 1]`,
+			exp: []Change{
+				{Path: np("meta"), Old: map[string]any{"rev": 1}, New: Nothing},
+			},
+		},
+		{
+			test: "nested_nodes",
+			path: `$..*`,
+			exp:  Diff(before, after),
+		},
+		{
+			test: "nothing",
+			path: `$.nope`,
+		},
+		{
+			test: "unchanged",
+			path: `$.items[1].id`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, MustParse(tc.path).Diff(before, after))
+		})
+	}
+}
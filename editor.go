@@ -0,0 +1,251 @@
+package jsonpath
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/theory/jsonpath/parser"
+	"github.com/theory/jsonpath/spec"
+)
+
+// Hover describes the segment of a query string at an offset, as returned
+// by [Parser.Hover]. Its fields marshal to JSON for editors and language
+// servers that describe the segment under the cursor.
+type Hover struct {
+	// Segment is the segment at the offset.
+	Segment *spec.Segment `json:"-"`
+	// Start and End are the zero-based byte offsets of the start and end of
+	// the text of Segment in the query string.
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Text is the canonical string representation of Segment.
+	Text string `json:"text"`
+	// Description describes the nodes Segment selects, as in
+	// `selects member "a" of each node`.
+	Description string `json:"description"`
+}
+
+// Completion describes a completion of a query string, as returned by
+// [Parser.Complete]. Apply it by replacing the text between Start and End,
+// zero-based byte offsets into the query string, with Text.
+type Completion struct {
+	// Kind identifies the kind of completion: member or function.
+	Kind string `json:"kind"`
+	// Text replaces the text between Start and End.
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Hover parses query and returns a [Hover] describing its segment at
+// offset, a zero-based byte offset into query, such as the position of
+// the cursor in an editor. At the boundary between two segments, it
+// describes the segment that starts at offset. Returns nil if query fails
+// to parse, for which [Parser.Lint] returns diagnostics, or if offset falls
+// outside its segments, as for the $ that starts every query.
+func (c *Parser) Hover(query string, offset int) *Hover {
+	q, spans, err := parser.ParseSpans(c.Registry(), query, c.mode)
+	if err != nil {
+		return nil
+	}
+
+	segs := q.Segments()
+	for i := len(spans) - 1; i >= 0; i-- {
+		if spans[i].Contains(offset) {
+			seg := q.Describe().Children[i]
+			return &Hover{
+				Segment:     segs[i],
+				Start:       spans[i].Start,
+				End:         spans[i].End,
+				Text:        seg.Text,
+				Description: describeSegment(seg),
+			}
+		}
+	}
+	return nil
+}
+
+// describeSegment returns a description of the nodes selected by seg, the
+// [ASTNode] of a segment returned by [spec.PathQuery.Describe].
+func describeSegment(seg *ASTNode) string {
+	descs := make([]string, len(seg.Children))
+	for i, sel := range seg.Children {
+		descs[i] = describeSelector(sel)
+	}
+
+	var list string
+	switch len(descs) {
+	case 1:
+		list = descs[0]
+	case 2:
+		list = descs[0] + " and " + descs[1]
+	default:
+		list = strings.Join(descs[:len(descs)-1], ", ") + ", and " + descs[len(descs)-1]
+	}
+
+	if seg.Kind == "descendant-segment" {
+		return "selects " + list + " of each node and its descendants"
+	}
+	return "selects " + list + " of each node"
+}
+
+// describeSelector returns a description of the values selected by sel, the
+// [ASTNode] of a selector returned by [spec.PathQuery.Describe].
+func describeSelector(sel *ASTNode) string {
+	switch sel.Kind {
+	case "name":
+		return "member " + sel.Text
+	case "index":
+		return "element " + sel.Text
+	case "slice":
+		return "the elements in slice " + sel.Text
+	case "wildcard":
+		return "all members and elements"
+	case "filter":
+		return "the members and elements that match filter " + sel.Text
+	default:
+		return "the values selected by " + sel.Text
+	}
+}
+
+// Complete returns the completions of query at offset, a zero-based byte
+// offset into query, such as the position of the cursor in an editor. It
+// completes:
+//
+//   - Member name shorthand outside brackets, such as $.store.bo and
+//     $..ti, with the names of the members that schema defines for the
+//     values selected by the rest of the query, in bracket notation for
+//     names that cannot use shorthand
+//   - Function names inside brackets, such as the len in $[?len, with the
+//     names of the functions in c's registry followed by an opening
+//     parenthesis
+//
+// Returns completions in sorted order, or nil if there are none. Pass a
+// nil schema to complete function names only.
+func (c *Parser) Complete(query string, offset int, schema *Schema) []Completion {
+	offset = max(0, min(offset, len(query)))
+	prefix := query[:offset]
+	dot, depth, quoted := scanPrefix(prefix)
+	if quoted {
+		return nil
+	}
+
+	// Find the partial name that ends at offset.
+	start := offset
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(prefix[:start])
+		if !isNameChar(r) {
+			break
+		}
+		start -= size
+	}
+	partial := prefix[start:]
+
+	switch {
+	case depth == 0 && dot >= 0 && dot == start-1:
+		return c.completeMember(prefix[:dot], partial, schema)
+	case depth > 0 && partial != "" && start > 0 && !strings.ContainsRune(".@$", rune(prefix[start-1])):
+		return c.completeFunction(partial, start)
+	default:
+		return nil
+	}
+}
+
+// completeMember returns the completions of partial, the member name
+// shorthand that follows base and a dot, with the names of the members that
+// schema defines for the values base selects.
+func (c *Parser) completeMember(base, partial string, schema *Schema) []Completion {
+	if schema == nil {
+		return nil
+	}
+	descendant := strings.HasSuffix(base, ".")
+	q, err := parser.ParseMode(c.Registry(), strings.TrimSuffix(base, "."), c.mode)
+	if err != nil {
+		return nil
+	}
+
+	names := q.MemberNames(schema)
+	if descendant {
+		desc := New(q).Append(spec.Descendant(spec.Wildcard()))
+		names = append(names, desc.q.MemberNames(schema)...)
+		slices.Sort(names)
+		names = slices.Compact(names)
+	}
+
+	var res []Completion
+	for _, name := range names {
+		if !strings.HasPrefix(name, partial) {
+			continue
+		}
+		// Shorthand replaces the dot and the partial name; bracket notation
+		// follows the double dot of a descendant segment.
+		text := spec.Query(true, spec.Child(spec.Name(name))).Format(canonicalFormat)[1:]
+		if descendant && !strings.HasPrefix(text, ".") {
+			text = "." + text
+		}
+		res = append(res, Completion{
+			Kind:  "member",
+			Text:  text,
+			Start: len(base),
+			End:   len(base) + 1 + len(partial),
+		})
+	}
+	return res
+}
+
+// completeFunction returns the completions of partial, a partial function
+// name at offset start, with the names of the functions in c's registry.
+func (c *Parser) completeFunction(partial string, start int) []Completion {
+	var res []Completion
+	for _, name := range c.Registry().Names() {
+		if strings.HasPrefix(name, partial) {
+			res = append(res, Completion{
+				Kind:  "function",
+				Text:  name + "(",
+				Start: start,
+				End:   start + len(partial),
+			})
+		}
+	}
+	return res
+}
+
+// scanPrefix scans prefix, the start of a query string, and returns the
+// index of its last dot outside of brackets, parentheses, and quoted
+// strings, or -1 if there is none, the depth of brackets and parentheses
+// at its end, and whether it ends within a quoted string.
+func scanPrefix(prefix string) (int, int, bool) {
+	dot, depth := -1, 0
+	var quote rune
+	escaped := false
+	for i, r := range prefix {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			switch r {
+			case '\\':
+				escaped = true
+			case quote:
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '[' || r == '(':
+			depth++
+		case r == ']' || r == ')':
+			depth--
+		case r == '.' && depth == 0:
+			dot = i
+		}
+	}
+	return dot, depth, quote != 0
+}
+
+// isNameChar returns true if r may appear in member name shorthand or a
+// function name.
+func isNameChar(r rune) bool {
+	return r == '_' || r >= utf8.RuneSelf || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
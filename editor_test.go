@@ -0,0 +1,265 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const editorSchema = `{
+	"type": "object",
+	"properties": {
+		"store": {
+			"type": "object",
+			"properties": {
+				"book": {"type": "array", "items": {"$ref": "#/$defs/book"}},
+				"bicycle": {"type": "object", "properties": {"color": {}, "price": {}}}
+			}
+		}
+	},
+	"$defs": {
+		"book": {
+			"type": "object",
+			"properties": {"title": {}, "price": {}, "sale price": {}}
+		}
+	}
+}`
+
+func TestHover(t *testing.T) {
+	t.Parallel()
+	p := NewParser()
+
+	for _, tc := range []struct {
+		test   string
+		query  string
+		offset int
+		exp    *Hover
+	}{
+		{
+			test:   "root",
+			query:  "$.a",
+			offset: 0,
+		},
+		{
+			test:   "name",
+			query:  "$.a",
+			offset: 2,
+			exp:    &Hover{Start: 1, End: 3, Text: `["a"]`, Description: `selects member "a" of each node`},
+		},
+		{
+			test:   "boundary",
+			query:  "$.a[0]",
+			offset: 3,
+			exp:    &Hover{Start: 3, End: 6, Text: `[0]`, Description: `selects element 0 of each node`},
+		},
+		{
+			test:   "end",
+			query:  "$.a[0]",
+			offset: 6,
+			exp:    &Hover{Start: 3, End: 6, Text: `[0]`, Description: `selects element 0 of each node`},
+		},
+		{
+			test:   "descendant",
+			query:  "$..*",
+			offset: 2,
+			exp:    &Hover{Start: 1, End: 4, Text: `..[*]`, Description: `selects all members and elements of each node and its descendants`},
+		},
+		{
+			test:   "union",
+			query:  `$["a", 1]`,
+			offset: 4,
+			exp:    &Hover{Start: 1, End: 9, Text: `["a",1]`, Description: `selects member "a" and element 1 of each node`},
+		},
+		{
+			test:   "list",
+			query:  `$[1:, *, ?@.x]`,
+			offset: 1,
+			exp: &Hover{
+				Start: 1, End: 14, Text: `[1:,*,?@["x"]]`,
+				Description: `selects the elements in slice 1:, all members and elements, and the members and elements that match filter ?@["x"] of each node`,
+			},
+		},
+		{
+			test:   "filter_query",
+			query:  `$[?@.x == $.y]`,
+			offset: 11,
+			exp: &Hover{
+				Start: 1, End: 14, Text: `[?@["x"] == $["y"]]`,
+				Description: `selects the members and elements that match filter ?@["x"] == $["y"] of each node`,
+			},
+		},
+		{
+			test:   "past_end",
+			query:  "$.a",
+			offset: 4,
+		},
+		{
+			test:   "parse_error",
+			query:  "$.a[",
+			offset: 2,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			hover := p.Hover(tc.query, tc.offset)
+			if tc.exp == nil {
+				a.Nil(hover)
+				return
+			}
+			require.NotNil(t, hover)
+			a.Equal(hover.Text, hover.Segment.String())
+			hover.Segment = nil
+			a.Equal(tc.exp, hover)
+		})
+	}
+
+	// Should marshal to JSON without the segment.
+	data, err := json.Marshal(p.Hover("$.a", 1))
+	require.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{"start": 1, "end": 3, "text": "[\"a\"]", "description": "selects member \"a\" of each node"}`,
+		string(data),
+	)
+}
+
+func TestComplete(t *testing.T) {
+	t.Parallel()
+	p := NewParser()
+	schema, err := ParseSchema([]byte(editorSchema))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		test   string
+		query  string
+		offset int
+		schema *Schema
+		exp    []Completion
+	}{
+		{
+			test:   "root",
+			query:  "$.",
+			offset: 2,
+			schema: schema,
+			exp:    []Completion{{"member", ".store", 1, 2}},
+		},
+		{
+			test:   "partial",
+			query:  "$.store.b",
+			offset: 9,
+			schema: schema,
+			exp: []Completion{
+				{"member", ".bicycle", 7, 9},
+				{"member", ".book", 7, 9},
+			},
+		},
+		{
+			test:   "cursor",
+			query:  "$.store.bo.title",
+			offset: 10,
+			schema: schema,
+			exp:    []Completion{{"member", ".book", 7, 10}},
+		},
+		{
+			test:   "brackets",
+			query:  "$.store.book[0].",
+			offset: 16,
+			schema: schema,
+			exp: []Completion{
+				{"member", ".price", 15, 16},
+				{"member", `['sale price']`, 15, 16},
+				{"member", ".title", 15, 16},
+			},
+		},
+		{
+			test:   "descendant",
+			query:  "$..",
+			offset: 3,
+			schema: schema,
+			exp: []Completion{
+				{"member", ".bicycle", 2, 3},
+				{"member", ".book", 2, 3},
+				{"member", ".color", 2, 3},
+				{"member", ".price", 2, 3},
+				{"member", `.['sale price']`, 2, 3},
+				{"member", ".store", 2, 3},
+				{"member", ".title", 2, 3},
+			},
+		},
+		{
+			test:   "descendant_partial",
+			query:  "$.store..t",
+			offset: 10,
+			schema: schema,
+			exp:    []Completion{{"member", ".title", 8, 10}},
+		},
+		{
+			test:   "unknown",
+			query:  "$.nope.",
+			offset: 7,
+			schema: schema,
+		},
+		{
+			test:   "no_schema",
+			query:  "$.",
+			offset: 2,
+		},
+		{
+			test:   "invalid_base",
+			query:  "$[.",
+			offset: 3,
+			schema: schema,
+		},
+		{
+			test:   "function",
+			query:  "$[?le",
+			offset: 5,
+			exp:    []Completion{{"function", "length(", 3, 5}},
+		},
+		{
+			test:   "functions",
+			query:  "$[?count(@.*) > 1 && !m",
+			offset: 23,
+			exp:    []Completion{{"function", "match(", 22, 23}},
+		},
+		{
+			test:   "filter_member",
+			query:  "$[?@.le",
+			offset: 7,
+			schema: schema,
+		},
+		{
+			test:   "quoted",
+			query:  "$[?@.a == 'le",
+			offset: 13,
+		},
+		{
+			test:   "escaped_quote",
+			query:  `$['a\'s'][?s`,
+			offset: 12,
+			exp:    []Completion{{"function", "search(", 11, 12}},
+		},
+		{
+			test:   "offset_range",
+			query:  "$.",
+			offset: 10,
+			schema: schema,
+			exp:    []Completion{{"member", ".store", 1, 2}},
+		},
+		{
+			test:   "negative_offset",
+			query:  "$.",
+			offset: -1,
+			schema: schema,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, p.Complete(tc.query, tc.offset, tc.schema))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package jsonpath
+
+import (
+	"sync"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Evaluator selects nodes with [Path] values using scratch buffers that it
+// reuses from one selection to the next, so that services that evaluate
+// many queries need not allocate memory for each. Once its buffers have
+// grown to fit the paths and documents it evaluates, it selects nodes
+// without allocating memory for paths that select without filter
+// expressions from []any and map[string]any values. Because it reuses its
+// buffers, the [NodeList] values it returns remain valid only until the
+// next call to one of its methods. See [spec.Scratch] for details.
+//
+// An Evaluator is not safe for concurrent use. Use an [EvaluatorPool] to
+// share Evaluators between goroutines, such as those of HTTP handlers.
+type Evaluator struct {
+	s spec.Scratch
+}
+
+// NewEvaluator creates a new [Evaluator].
+func NewEvaluator() *Evaluator {
+	return &Evaluator{}
+}
+
+// Select returns the nodes that path selects from input, as
+// [Path.Select] does. The returned [NodeList] remains valid only until the
+// next call to a method of e; use [slices.Clone] to retain it.
+func (e *Evaluator) Select(path *Path, input any) NodeList {
+	return e.s.Select(path.q, nil, input, path.opts)
+}
+
+// TrySelect returns the nodes that path selects from input, as
+// [Path.TrySelect] does, returning an [ErrBudgetExceeded] error if
+// evaluation exceeds the limits configured by [WithMaxNodes] or
+// [WithTimeout]. The returned [NodeList] remains valid only until the next
+// call to a method of e.
+func (e *Evaluator) TrySelect(path *Path, input any) (NodeList, error) {
+	nodes, err := e.s.TrySelect(path.q, nil, input, path.opts)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// Reset clears e's buffers so that they no longer refer to previously
+// selected nodes, and discards buffers that have grown very large.
+// [EvaluatorPool.Put] calls it before returning e to the pool.
+func (e *Evaluator) Reset() {
+	e.s.Reset()
+}
+
+// EvaluatorPool is a pool of [Evaluator] values for use by multiple
+// goroutines, backed by a [sync.Pool]. The zero value is ready to use:
+//
+//	var pool jsonpath.EvaluatorPool
+//
+//	func handle(path *jsonpath.Path, doc any) {
+//		e := pool.Get()
+//		defer pool.Put(e)
+//		for _, node := range e.Select(path, doc) {
+//			// ...
+//		}
+//	}
+//
+// An EvaluatorPool must not be copied after first use.
+type EvaluatorPool struct {
+	pool sync.Pool
+}
+
+// Get returns an [Evaluator] from p, or a new one if p is empty.
+func (p *EvaluatorPool) Get() *Evaluator {
+	if e, ok := p.pool.Get().(*Evaluator); ok {
+		return e
+	}
+	return NewEvaluator()
+}
+
+// Put resets e and returns it to p. Callers must not use e or the nodes it
+// has selected after calling Put.
+func (p *EvaluatorPool) Put(e *Evaluator) {
+	e.Reset()
+	p.pool.Put(e)
+}
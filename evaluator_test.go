@@ -0,0 +1,114 @@
+package jsonpath
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := specExampleJSON(t)
+	e := NewEvaluator()
+	for _, path := range []*Path{
+		MustParse(`$`),
+		MustParse(`$.store.book[*].author`),
+		MustParse(`$..book[-1:]`),
+		MustParse(`$..book[?@.price < 10].title`),
+		MustParse(`$.nonesuch`),
+		NewParser(WithMaxDepth(1)).MustParse(`$..price`),
+	} {
+		exp := path.Select(input)
+		a.Equal(exp, e.Select(path, input), path.String())
+		nodes, err := e.TrySelect(path, input)
+		a.NoError(err)
+		a.Equal(exp, nodes, path.String())
+	}
+
+	// Results should remain valid until the next selection.
+	path := MustParse(`$.store.book[*].category`)
+	nodes := e.Select(path, input)
+	a.Equal(NodeList{"reference", "fiction", "fiction", "fiction"}, nodes)
+	e.Reset()
+	a.Equal(NodeList{nil, nil, nil, nil}, nodes)
+
+	// Should return errors.
+	path = NewParser(WithMaxNodes(3)).MustParse(`$..*`)
+	nodes, err := e.TrySelect(path, input)
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	a.Nil(nodes)
+	a.Nil(e.Select(path, input))
+}
+
+func TestEvaluatorPool(t *testing.T) {
+	t.Parallel()
+
+	var pool EvaluatorPool
+	input := specExampleJSON(t)
+	path := MustParse(`$..book[?@.price < 10].title`)
+	exp := path.Select(input)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				e := pool.Get()
+				assert.Equal(t, exp, e.Select(path, input))
+				pool.Put(e)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+//nolint:paralleltest // AllocsPerRun panics in parallel tests.
+func TestEvaluatorAllocs(t *testing.T) {
+	a := assert.New(t)
+
+	input := specExampleJSON(t)
+	e := NewEvaluator()
+	for _, path := range []*Path{
+		MustParse(`$.store.book[*].author`),
+		MustParse(`$..author`),
+		MustParse(`$.store.book[0:2]`),
+	} {
+		a.Zero(testing.AllocsPerRun(100, func() {
+			e.Select(path, input)
+			e.Reset()
+		}), path.String())
+	}
+}
+
+func BenchmarkEvaluator(b *testing.B) {
+	input := map[string]any{
+		"store": map[string]any{
+			"book": []any{
+				map[string]any{"author": "Nigel Rees", "price": 8.95},
+				map[string]any{"author": "Evelyn Waugh", "price": 12.99},
+			},
+			"bicycle": map[string]any{"color": "red", "price": 399},
+		},
+	}
+	path := MustParse(`$..book[*].author`)
+
+	b.Run("path", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			path.Select(input)
+		}
+	})
+
+	b.Run("evaluator", func(b *testing.B) {
+		e := NewEvaluator()
+		b.ReportAllocs()
+		for range b.N {
+			e.Select(path, input)
+		}
+	})
+}
@@ -0,0 +1,242 @@
+// Package gjsonpath provides interoperability between RFC 9535 JSONPath
+// queries and [gjson]. Use [Value] to execute compiled paths over
+// [gjson.Result] trees, and [ToGJSON] and [FromGJSON] to translate simple
+// singular paths to and from gjson path syntax.
+//
+// [gjson]: https://github.com/tidwall/gjson
+package gjsonpath
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+	"github.com/tidwall/gjson"
+)
+
+var (
+	_ jsonpath.Object = Object{}
+	_ jsonpath.Array  = Array{}
+)
+
+// ErrUnsupported errors are returned by [ToGJSON] and [FromGJSON] for paths
+// that cannot be translated.
+var ErrUnsupported = errors.New("gjsonpath")
+
+// Value returns res for use in a JSONPath query. It converts JSON objects to
+// [Object], JSON arrays to [Array], numbers to float64, strings to string,
+// booleans to bool, and null or nonexistent results to nil.
+func Value(res gjson.Result) any {
+	switch res.Type {
+	case gjson.False:
+		return false
+	case gjson.True:
+		return true
+	case gjson.Number:
+		return res.Num
+	case gjson.String:
+		return res.Str
+	case gjson.JSON:
+		if res.IsArray() {
+			return Array{res}
+		}
+		return Object{res}
+	default:
+		return nil
+	}
+}
+
+// Object wraps a [gjson.Result] that contains a JSON object to implement
+// [jsonpath.Object]. Queries return Object values for selected objects; use
+// Result to access the original value.
+type Object struct {
+	Result gjson.Result
+}
+
+// Get returns the value of the first member named name. Defined by
+// [jsonpath.Object].
+func (o Object) Get(name string) (any, bool) {
+	var val any
+	found := false
+	o.Result.ForEach(func(key, value gjson.Result) bool {
+		if key.Str == name {
+			val, found = Value(value), true
+			return false
+		}
+		return true
+	})
+	return val, found
+}
+
+// Len returns the number of members in o. Defined by [jsonpath.Object].
+func (o Object) Len() int {
+	n := 0
+	o.Result.ForEach(func(_, _ gjson.Result) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Iterate returns an iterator over the names and values of the members of o
+// in the order in which they appear in the JSON source. Defined by
+// [jsonpath.Object].
+func (o Object) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		o.Result.ForEach(func(key, value gjson.Result) bool {
+			return yield(key.Str, Value(value))
+		})
+	}
+}
+
+// Array wraps a [gjson.Result] that contains a JSON array to implement
+// [jsonpath.Array]. Queries return Array values for selected arrays; use
+// Result to access the original value.
+type Array struct {
+	Result gjson.Result
+}
+
+// Get returns the value at index. Defined by [jsonpath.Array].
+func (a Array) Get(index int) (any, bool) {
+	if index < 0 {
+		return nil, false
+	}
+	var val any
+	found := false
+	i := 0
+	a.Result.ForEach(func(_, value gjson.Result) bool {
+		if i == index {
+			val, found = Value(value), true
+			return false
+		}
+		i++
+		return true
+	})
+	return val, found
+}
+
+// Len returns the number of elements in a. Defined by [jsonpath.Array].
+func (a Array) Len() int {
+	n := 0
+	a.Result.ForEach(func(_, _ gjson.Result) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Iterate returns an iterator over the indexes and values of a. Defined by
+// [jsonpath.Array].
+func (a Array) Iterate() iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		i := 0
+		a.Result.ForEach(func(_, value gjson.Result) bool {
+			ok := yield(i, Value(value))
+			i++
+			return ok
+		})
+	}
+}
+
+// ToGJSON translates path into gjson path syntax. Returns an
+// [ErrUnsupported] error unless path is a singular query consisting only of
+// non-empty name selectors and non-negative index selectors.
+func ToGJSON(path *jsonpath.Path) (string, error) {
+	q := path.Query()
+	if q.Singular() == nil {
+		return "", fmt.Errorf("%w: %v is not a singular query", ErrUnsupported, path)
+	}
+
+	parts := make([]string, 0, len(q.Segments()))
+	for _, seg := range q.Segments() {
+		switch sel := seg.Selectors()[0].(type) {
+		case spec.Name:
+			if sel == "" {
+				return "", fmt.Errorf("%w: cannot translate empty name in %v", ErrUnsupported, path)
+			}
+			parts = append(parts, gjson.Escape(string(sel)))
+		case spec.Index:
+			if sel < 0 {
+				return "", fmt.Errorf("%w: cannot translate negative index in %v", ErrUnsupported, path)
+			}
+			parts = append(parts, strconv.Itoa(int(sel)))
+		}
+	}
+	if len(parts) == 0 {
+		return "@this", nil
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// FromGJSON translates a simple gjson path, consisting only of
+// dot-separated keys and array indexes, into a [jsonpath.Path]. Components
+// that consist only of digits become index selectors; all others become
+// name selectors. Returns an [ErrUnsupported] error for paths that use
+// wildcards, queries, modifiers, multipaths, or other gjson extensions.
+func FromGJSON(path string) (*jsonpath.Path, error) {
+	if path == "@this" {
+		return jsonpath.New(spec.Query(true)), nil
+	}
+
+	segs := []*spec.Segment{}
+	var buf strings.Builder
+	escaped := false
+	for i := range len(path) {
+		c := path[i]
+		switch {
+		case escaped:
+			buf.WriteByte(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '.':
+			seg, err := component(path, buf.String())
+			if err != nil {
+				return nil, err
+			}
+			segs = append(segs, seg)
+			buf.Reset()
+		case isSafePathKeyChar(c):
+			buf.WriteByte(c)
+		default:
+			return nil, fmt.Errorf(
+				"%w: unsupported character %q in gjson path %q",
+				ErrUnsupported, c, path,
+			)
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("%w: trailing backslash in gjson path %q", ErrUnsupported, path)
+	}
+	seg, err := component(path, buf.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonpath.New(spec.Query(true, append(segs, seg)...)), nil
+}
+
+// component converts a single unescaped gjson path component into a child
+// segment.
+func component(path, comp string) (*spec.Segment, error) {
+	if comp == "" {
+		return nil, fmt.Errorf("%w: empty component in gjson path %q", ErrUnsupported, path)
+	}
+	if idx, err := strconv.Atoi(comp); err == nil && idx >= 0 && comp[0] != '+' {
+		return spec.Child(spec.Index(idx)), nil
+	}
+	return spec.Child(spec.Name(comp)), nil
+}
+
+// isSafePathKeyChar returns true for characters that need no escaping in a
+// gjson path component. Mirrors the gjson function of the same name.
+func isSafePathKeyChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9') || c <= ' ' || c > '~' || c == '_' ||
+		c == '-' || c == ':'
+}
@@ -0,0 +1,33 @@
+package gjsonpath_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/gjsonpath"
+	"github.com/tidwall/gjson"
+)
+
+// Execute a JSONPath query over a gjson result.
+func ExampleValue() {
+	res := gjson.Parse(`{"friends": [
+	  {"first": "Dale", "age": 44},
+	  {"first": "Roger", "age": 68},
+	  {"first": "Jane", "age": 47}
+	]}`)
+
+	path := jsonpath.MustParse(`$.friends[?@.age > 45].first`)
+	fmt.Println(path.Select(gjsonpath.Value(res)))
+	// Output: [Roger Jane]
+}
+
+// Translate an existing gjson path into a JSONPath query.
+func ExampleFromGJSON() {
+	path, err := gjsonpath.FromGJSON(`friends.1.first`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(path)
+	// Output: $["friends"][1]["first"]
+}
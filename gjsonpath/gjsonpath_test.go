@@ -0,0 +1,189 @@
+package gjsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"github.com/tidwall/gjson"
+)
+
+const doc = `{
+  "name": {"first": "Tom", "last": "Anderson"},
+  "age": 37,
+  "children": ["Sara", "Alex", "Jack"],
+  "fav.movie": "Deer Hunter",
+  "a*b?": "glob",
+  "über": "unicode",
+  "friends": [
+    {"first": "Dale", "last": "Murphy", "age": 44, "active": true},
+    {"first": "Roger", "last": "Craig", "age": 68, "active": false},
+    {"first": "Jane", "last": "Murphy", "age": 47, "nets": null}
+  ]
+}`
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+	root := Value(gjson.Parse(doc))
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  []any
+	}{
+		{"name", `$.name.last`, []any{"Anderson"}},
+		{"number", `$.age`, []any{37.0}},
+		{"index", `$.children[1]`, []any{"Alex"}},
+		{"negative_index", `$.children[-1]`, []any{"Jack"}},
+		{"out_of_range", `$.children[3]`, []any{}},
+		{"slice", `$.children[::-1]`, []any{"Jack", "Alex", "Sara"}},
+		{"wildcard_order", `$.name.*`, []any{"Tom", "Anderson"}},
+		{"dotted_name", `$['fav.movie']`, []any{"Deer Hunter"}},
+		{"filter", `$.friends[?@.age > 45].first`, []any{"Roger", "Jane"}},
+		{"filter_bool", `$.friends[?@.active == false].first`, []any{"Roger"}},
+		{"filter_null", `$.friends[?@.nets == null].first`, []any{"Jane"}},
+		{"length", `$.friends[?length(@.last) == 6].first`, []any{"Dale", "Jane"}},
+		{"object_length", `$.friends[?length(@) == 4].age`, []any{44.0, 68.0, 47.0}},
+		{"descendant", `$..last`, []any{"Anderson", "Murphy", "Craig", "Murphy"}},
+		{"missing", `$.nope`, []any{}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			res := jsonpath.MustParse(tc.path).Select(root)
+			assert.Equal(t, tc.exp, []any(res))
+		})
+	}
+
+	t.Run("container_result", func(t *testing.T) {
+		t.Parallel()
+		res := jsonpath.MustParse(`$.name`).Select(root)
+		require.Len(t, res, 1)
+		obj, ok := res[0].(Object)
+		require.True(t, ok)
+		assert.JSONEq(t, `{"first": "Tom", "last": "Anderson"}`, obj.Result.Raw)
+	})
+}
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Nil(Value(gjson.Result{}))
+	a.Nil(Value(gjson.Parse(`null`)))
+	a.Equal(true, Value(gjson.Parse(`true`)))
+	a.Equal(false, Value(gjson.Parse(`false`)))
+	a.Equal(1.5, Value(gjson.Parse(`1.5`)))
+	a.Equal("hi", Value(gjson.Parse(`"hi"`)))
+
+	arr, ok := Value(gjson.Parse(`[1, [2]]`)).(Array)
+	a.True(ok)
+	a.Equal(2, arr.Len())
+	v, ok := arr.Get(1)
+	a.True(ok)
+	a.IsType(Array{}, v)
+	_, ok = arr.Get(-1)
+	a.False(ok)
+
+	obj, ok := Value(gjson.Parse(`{"a": 1, "a": 2, "b": {}}`)).(Object)
+	a.True(ok)
+	a.Equal(3, obj.Len())
+	v, ok = obj.Get("a")
+	a.True(ok)
+	a.Equal(1.0, v)
+	v, ok = obj.Get("b")
+	a.True(ok)
+	a.IsType(Object{}, v)
+
+	// Stop iteration early.
+	n := 0
+	for range obj.Iterate() {
+		n++
+		break
+	}
+	for range arr.Iterate() {
+		n++
+		break
+	}
+	a.Equal(2, n)
+}
+
+func TestToGJSON(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  string
+		err  string
+	}{
+		{"root", `$`, "@this", ""},
+		{"names", `$.name.last`, "name.last", ""},
+		{"index", `$.children[1]`, "children.1", ""},
+		{"escape_dot", `$['fav.movie']`, `fav\.movie`, ""},
+		{"escape_glob", `$['a*b?']`, `a\*b\?`, ""},
+		{"unicode", `$['über']`, "über", ""},
+		{"not_singular", `$.children[*]`, "", `gjsonpath: $["children"][*] is not a singular query`},
+		{"descendant", `$..name`, "", `gjsonpath: $..["name"] is not a singular query`},
+		{"negative_index", `$.children[-1]`, "", `gjsonpath: cannot translate negative index in $["children"][-1]`},
+		{"empty_name", `$['']`, "", `gjsonpath: cannot translate empty name in $[""]`},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			res, err := ToGJSON(jsonpath.MustParse(tc.path))
+			if tc.err == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tc.exp, res)
+				// Make sure gjson and jsonpath select the same value.
+				assert.Equal(
+					t,
+					jsonpath.MustParse(tc.path).Select(Value(gjson.Parse(doc))),
+					jsonpath.NodeList{Value(gjson.Get(doc, res))},
+				)
+			} else {
+				require.EqualError(t, err, tc.err)
+				require.ErrorIs(t, err, ErrUnsupported)
+			}
+		})
+	}
+}
+
+func TestFromGJSON(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  string
+		err  string
+	}{
+		{"this", "@this", `$`, ""},
+		{"name", "age", `$["age"]`, ""},
+		{"names", "name.last", `$["name"]["last"]`, ""},
+		{"index", "children.1", `$["children"][1]`, ""},
+		{"escaped", `fav\.movie`, `$["fav.movie"]`, ""},
+		{"escaped_special", `a\*b\?c`, `$["a*b?c"]`, ""},
+		{"signed", "a.-1", `$["a"]["-1"]`, ""},
+		{"wildcard", "child*", "", `gjsonpath: unsupported character '*' in gjson path "child*"`},
+		{"query", "friends.#.first", "", `gjsonpath: unsupported character '#' in gjson path "friends.#.first"`},
+		{"modifier", "@reverse", "", `gjsonpath: unsupported character '@' in gjson path "@reverse"`},
+		{"pipe", "a|b", "", `gjsonpath: unsupported character '|' in gjson path "a|b"`},
+		{"empty", "", "", `gjsonpath: empty component in gjson path ""`},
+		{"empty_component", "a..b", "", `gjsonpath: empty component in gjson path "a..b"`},
+		{"trailing_dot", "a.", "", `gjsonpath: empty component in gjson path "a."`},
+		{"trailing_backslash", `a\`, "", `gjsonpath: trailing backslash in gjson path "a\\"`},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			res, err := FromGJSON(tc.path)
+			if tc.err == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tc.exp, res.String())
+			} else {
+				require.EqualError(t, err, tc.err)
+				require.ErrorIs(t, err, ErrUnsupported)
+				assert.Nil(t, res)
+			}
+		})
+	}
+}
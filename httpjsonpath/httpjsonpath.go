@@ -0,0 +1,283 @@
+// Package httpjsonpath provides an [http.Handler] for endpoints that apply
+// RFC 9535 JSONPath queries to JSON documents, so that services need not
+// re-implement the request parsing, limits, and error handling such
+// endpoints require. Use [New] to create a [Handler]:
+//
+//	http.Handle("POST /query", httpjsonpath.New())
+//
+// Clients POST a JSON document as the request body and pass the query in
+// the path parameter of the URL, as in /query?path=$.store.book[*].title.
+// The handler responds with a JSON object whose nodes member contains an
+// array of the selected nodes:
+//
+//	{"nodes": ["Sayings of the Century", "Sword of Honour"]}
+//
+// Pass located=true to instead select the nodes with their normalized
+// paths, as objects with path and node members:
+//
+//	{"nodes": [{"path": "$['store']['book'][0]['title']", "node": "Sayings of the Century"}]}
+//
+// On failure, it responds with a 4xx status code, or 503 if the request's
+// context is canceled during evaluation, and a JSON object whose error
+// member describes the failure with code and message members, and,
+// for query parse errors, the start, end, and hint members of
+// [jsonpath.ParseError]:
+//
+//	{"error": {"code": "syntax", "message": "unexpected eof", "start": 5, "end": 5}}
+package httpjsonpath
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/theory/jsonpath"
+)
+
+const (
+	// DefaultMaxBodySize is the default maximum size of request bodies, in
+	// bytes.
+	DefaultMaxBodySize = 10 << 20
+
+	// DefaultMaxPathLength is the default maximum length of queries, in
+	// bytes.
+	DefaultMaxPathLength = 4096
+
+	// DefaultMaxNodes is the maximum number of nodes that the default parser
+	// allows queries to visit; see [jsonpath.WithMaxNodes].
+	DefaultMaxNodes = 1_000_000
+
+	// DefaultTimeout is the maximum time that the default parser allows
+	// queries to run; see [jsonpath.WithTimeout].
+	DefaultTimeout = time.Second
+)
+
+var (
+	// errMultiple errors are returned by decode for request bodies that
+	// contain more than one JSON value.
+	errMultiple = errors.New("request body contains more than one JSON value")
+
+	// errEmpty errors are returned by decode for empty request bodies.
+	errEmpty = errors.New("empty request body")
+)
+
+// Handler is an [http.Handler] that applies the JSONPath query in the path
+// parameter of each request to the JSON document in its body. See the
+// package documentation for details.
+type Handler struct {
+	parser     *jsonpath.Parser
+	maxBody    int64
+	maxPathLen int
+}
+
+// Option defines a [Handler] option.
+type Option func(*Handler)
+
+// WithParser configures a [Handler] to parse queries with p. Use it to
+// support function extensions or to change the evaluation limits of the
+// default parser, which limits evaluation to [DefaultMaxNodes] and
+// [DefaultTimeout]. Configure p with [jsonpath.WithMaxNodes] or
+// [jsonpath.WithTimeout] to bound the work done by queries from untrusted
+// clients.
+func WithParser(p *jsonpath.Parser) Option {
+	return func(h *Handler) { h.parser = p }
+}
+
+// WithMaxBodySize configures a [Handler] to reject request bodies larger
+// than n bytes. Zero or a negative n means [DefaultMaxBodySize].
+func WithMaxBodySize(n int64) Option {
+	return func(h *Handler) { h.maxBody = n }
+}
+
+// WithMaxPathLength configures a [Handler] to reject queries longer than n
+// bytes. Zero or a negative n means [DefaultMaxPathLength].
+func WithMaxPathLength(n int) Option {
+	return func(h *Handler) { h.maxPathLen = n }
+}
+
+// New creates a new [Handler] configured by opt.
+func New(opt ...Option) *Handler {
+	h := &Handler{}
+	for _, o := range opt {
+		o(h)
+	}
+
+	if h.parser == nil {
+		h.parser = jsonpath.NewParser(
+			jsonpath.WithMaxNodes(DefaultMaxNodes),
+			jsonpath.WithTimeout(DefaultTimeout),
+		)
+	}
+	if h.maxBody <= 0 {
+		h.maxBody = DefaultMaxBodySize
+	}
+	if h.maxPathLen <= 0 {
+		h.maxPathLen = DefaultMaxPathLength
+	}
+
+	return h
+}
+
+// Error describes the failure of a request, as returned in the error member
+// of the response body.
+type Error struct {
+	// Code identifies the kind of failure: one of the codes of
+	// [jsonpath.ParseError] for queries that fail to parse, or
+	// method-not-allowed, unsupported-media-type, missing-path,
+	// path-too-long, invalid-parameter, body-too-large, invalid-json,
+	// evaluation, or canceled.
+	Code string `json:"code"`
+	// Message describes the failure.
+	Message string `json:"message"`
+}
+
+// response is the body of a response.
+type response struct {
+	Nodes any `json:"nodes,omitempty"`
+	Error any `json:"error,omitempty"`
+}
+
+// ServeHTTP applies the query in the path parameter of r to the JSON
+// document in its body and writes the selected nodes to w. Stops
+// evaluation when the context of r is canceled or its deadline passes, as
+// when the client disconnects or a server or [http.TimeoutHandler]
+// timeout expires, and responds with a 503 status code and a canceled
+// error.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method-not-allowed", "method "+r.Method+" not allowed")
+		return
+	}
+
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mt != "application/json" && !strings.HasSuffix(mt, "+json")) {
+			writeError(w, http.StatusUnsupportedMediaType, "unsupported-media-type", "content type "+ct+" is not JSON")
+			return
+		}
+	}
+
+	params := r.URL.Query()
+	query := params.Get("path")
+	switch {
+	case query == "":
+		writeError(w, http.StatusBadRequest, "missing-path", "missing path parameter")
+		return
+	case len(query) > h.maxPathLen:
+		writeError(w, http.StatusBadRequest, "path-too-long", "path parameter longer than "+strconv.Itoa(h.maxPathLen)+" bytes")
+		return
+	}
+
+	located := false
+	if val := params.Get("located"); val != "" {
+		var err error
+		if located, err = strconv.ParseBool(val); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid-parameter", "invalid located parameter "+strconv.Quote(val))
+			return
+		}
+	}
+
+	path, err := h.parser.Parse(query)
+	if err != nil {
+		var pe *jsonpath.ParseError
+		if errors.As(err, &pe) {
+			writeJSON(w, http.StatusBadRequest, response{Error: pe})
+			return
+		}
+		writeError(w, http.StatusBadRequest, "syntax", err.Error())
+		return
+	}
+
+	doc, status, err := h.decode(w, r)
+	if err != nil {
+		code := "invalid-json"
+		if status == http.StatusRequestEntityTooLarge {
+			code = "body-too-large"
+		}
+		writeError(w, status, code, err.Error())
+		return
+	}
+
+	nodes, err := selectNodes(r.Context(), path, doc, located)
+	switch {
+	case err == nil:
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusServiceUnavailable, "canceled", err.Error())
+		return
+	default:
+		writeError(w, http.StatusUnprocessableEntity, "evaluation", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, response{Nodes: nodes})
+}
+
+// selectNodes selects nodes from doc with path, with their normalized paths
+// if located is true. Selects with [jsonpath.Path.SelectChan], so that
+// evaluation stops once ctx is canceled. Returns an empty list rather than
+// nil if path selects no nodes, so that it encodes as an empty JSON array.
+func selectNodes(ctx context.Context, path *jsonpath.Path, doc any, located bool) (any, error) {
+	ch, errFn := path.SelectChan(ctx, doc)
+	if located {
+		nodes := jsonpath.LocatedNodeList{}
+		for node := range ch {
+			nodes = append(nodes, node)
+		}
+		return nodes, errFn()
+	}
+	nodes := jsonpath.NodeList{}
+	for node := range ch {
+		nodes = append(nodes, node.Node)
+	}
+	return nodes, errFn()
+}
+
+// decode decodes the single JSON value in the body of r, which may be no
+// larger than h's maximum body size. Returns the status code of the error
+// response if decoding fails.
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxBody))
+	dec.UseNumber()
+
+	var doc any
+	err := dec.Decode(&doc)
+	if err == nil {
+		// Allow only trailing blank space.
+		if _, err = dec.Token(); errors.Is(err, io.EOF) {
+			return doc, 0, nil
+		}
+		if err == nil {
+			err = errMultiple
+		}
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, http.StatusRequestEntityTooLarge, err
+	}
+	if errors.Is(err, io.EOF) {
+		err = errEmpty
+	}
+	return nil, http.StatusBadRequest, err
+}
+
+// writeError writes a response with status and an [Error] with code and
+// message.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, response{Error: Error{Code: code, Message: message}})
+}
+
+// writeJSON writes a response with status and body encoded as JSON.
+func writeJSON(w http.ResponseWriter, status int, body response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	//nolint:errchkjson
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,43 @@
+package httpjsonpath_test
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/theory/jsonpath/httpjsonpath"
+)
+
+// Serve a query endpoint and select values from a document with it.
+func ExampleNew() {
+	mux := http.NewServeMux()
+	mux.Handle("POST /query", httpjsonpath.New())
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	res, err := http.Post(
+		srv.URL+"/query?path="+url.QueryEscape("$.books[?@.price < 10].title"),
+		"application/json",
+		strings.NewReader(`{"books": [
+		  {"title": "Sayings of the Century", "price": 8.95},
+		  {"title": "Sword of Honour", "price": 12.99}
+		]}`),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(res.Status, "\n", string(body))
+	// Output:
+	// 200 OK
+	// {"nodes":["Sayings of the Century"]}
+}
@@ -0,0 +1,270 @@
+package httpjsonpath
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theory/jsonpath"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	h := New()
+	a.Equal(int64(DefaultMaxBodySize), h.maxBody)
+	a.Equal(DefaultMaxPathLength, h.maxPathLen)
+	a.NotNil(h.parser)
+
+	p := jsonpath.NewParser()
+	h = New(WithParser(p), WithMaxBodySize(10), WithMaxPathLength(20))
+	a.Same(p, h.parser)
+	a.Equal(int64(10), h.maxBody)
+	a.Equal(20, h.maxPathLen)
+
+	h = New(WithMaxBodySize(-1), WithMaxPathLength(0))
+	a.Equal(int64(DefaultMaxBodySize), h.maxBody)
+	a.Equal(DefaultMaxPathLength, h.maxPathLen)
+}
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	const doc = `{"a": [1, 2.50, {"b": "x"}], "c": true}`
+	limited := New(
+		WithParser(jsonpath.NewParser(jsonpath.WithMaxNodes(2))),
+		WithMaxBodySize(64),
+		WithMaxPathLength(8),
+	)
+
+	for _, tc := range []struct {
+		test    string
+		handler *Handler
+		method  string
+		params  url.Values
+		ctype   string
+		body    string
+		status  int
+		exp     string
+	}{
+		{
+			test:   "values",
+			params: url.Values{"path": {"$.a[*]"}},
+			body:   doc,
+			status: http.StatusOK,
+			exp:    `{"nodes": [1, 2.50, {"b": "x"}]}`,
+		},
+		{
+			test:   "located",
+			params: url.Values{"path": {"$..b"}, "located": {"true"}},
+			body:   doc,
+			status: http.StatusOK,
+			exp:    `{"nodes": [{"path": "$['a'][2]['b']", "node": "x"}]}`,
+		},
+		{
+			test:   "not_located",
+			params: url.Values{"path": {"$.c"}, "located": {"0"}},
+			body:   doc,
+			status: http.StatusOK,
+			exp:    `{"nodes": [true]}`,
+		},
+		{
+			test:   "no_values",
+			params: url.Values{"path": {"$.x"}},
+			body:   doc,
+			status: http.StatusOK,
+			exp:    `{"nodes": []}`,
+		},
+		{
+			test:   "no_located",
+			params: url.Values{"path": {"$.x"}, "located": {"1"}},
+			body:   doc,
+			status: http.StatusOK,
+			exp:    `{"nodes": []}`,
+		},
+		{
+			test:   "json_suffix",
+			params: url.Values{"path": {"$.c"}},
+			ctype:  "application/merge-patch+json; charset=utf-8",
+			body:   doc + "\n\n",
+			status: http.StatusOK,
+			exp:    `{"nodes": [true]}`,
+		},
+		{
+			test:   "method",
+			method: http.MethodGet,
+			params: url.Values{"path": {"$"}},
+			status: http.StatusMethodNotAllowed,
+			exp:    `{"error": {"code": "method-not-allowed", "message": "method GET not allowed"}}`,
+		},
+		{
+			test:   "content_type",
+			params: url.Values{"path": {"$"}},
+			ctype:  "text/plain",
+			body:   doc,
+			status: http.StatusUnsupportedMediaType,
+			exp:    `{"error": {"code": "unsupported-media-type", "message": "content type text/plain is not JSON"}}`,
+		},
+		{
+			test:   "missing_path",
+			body:   doc,
+			status: http.StatusBadRequest,
+			exp:    `{"error": {"code": "missing-path", "message": "missing path parameter"}}`,
+		},
+		{
+			test:    "path_too_long",
+			handler: limited,
+			params:  url.Values{"path": {"$.abcdefgh"}},
+			body:    doc,
+			status:  http.StatusBadRequest,
+			exp:     `{"error": {"code": "path-too-long", "message": "path parameter longer than 8 bytes"}}`,
+		},
+		{
+			test:   "invalid_located",
+			params: url.Values{"path": {"$"}, "located": {"maybe"}},
+			body:   doc,
+			status: http.StatusBadRequest,
+			exp:    `{"error": {"code": "invalid-parameter", "message": "invalid located parameter \"maybe\""}}`,
+		},
+		{
+			test:   "parse_error",
+			params: url.Values{"path": {"$.a["}},
+			body:   doc,
+			status: http.StatusBadRequest,
+			exp:    `{"error": {"code": "syntax", "message": "unexpected eof", "start": 4, "end": 4}}`,
+		},
+		{
+			test:   "unknown_function",
+			params: url.Values{"path": {"$[?nope(@)]"}},
+			body:   doc,
+			status: http.StatusBadRequest,
+			exp:    `{"error": {"code": "unknown-function", "message": "unknown function nope()", "start": 3, "end": 7, "hint": "register function extensions with a registry.Registry"}}`,
+		},
+		{
+			test:   "empty_body",
+			params: url.Values{"path": {"$"}},
+			status: http.StatusBadRequest,
+			exp:    `{"error": {"code": "invalid-json", "message": "empty request body"}}`,
+		},
+		{
+			test:   "invalid_json",
+			params: url.Values{"path": {"$"}},
+			body:   `{"a": }`,
+			status: http.StatusBadRequest,
+			exp:    `{"error": {"code": "invalid-json", "message": "invalid character '}' looking for beginning of value"}}`,
+		},
+		{
+			test:   "multiple_values",
+			params: url.Values{"path": {"$"}},
+			body:   `{} {}`,
+			status: http.StatusBadRequest,
+			exp:    `{"error": {"code": "invalid-json", "message": "request body contains more than one JSON value"}}`,
+		},
+		{
+			test:   "trailing_garbage",
+			params: url.Values{"path": {"$"}},
+			body:   `{}}`,
+			status: http.StatusBadRequest,
+			exp:    `{"error": {"code": "invalid-json", "message": "invalid character '}' looking for beginning of value"}}`,
+		},
+		{
+			test:    "body_too_large",
+			handler: limited,
+			params:  url.Values{"path": {"$"}},
+			body:    `{"a": "` + strings.Repeat("x", 64) + `"}`,
+			status:  http.StatusRequestEntityTooLarge,
+			exp:     `{"error": {"code": "body-too-large", "message": "http: request body too large"}}`,
+		},
+		{
+			test:    "budget_exceeded",
+			handler: limited,
+			params:  url.Values{"path": {"$..*"}},
+			body:    `[[[1]]]`,
+			status:  http.StatusUnprocessableEntity,
+			exp:     `{"error": {"code": "evaluation", "message": "evaluation budget exceeded: visited more than 2 nodes"}}`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			h := tc.handler
+			if h == nil {
+				h = New()
+			}
+			method := tc.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/?"+tc.params.Encode(), strings.NewReader(tc.body))
+			if tc.ctype != "" {
+				req.Header.Set("Content-Type", tc.ctype)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			a.Equal(tc.status, rec.Code)
+			a.Equal("application/json", rec.Header().Get("Content-Type"))
+			a.Equal("nosniff", rec.Header().Get("X-Content-Type-Options"))
+			a.JSONEq(tc.exp, rec.Body.String())
+			if tc.status == http.StatusMethodNotAllowed {
+				a.Equal(http.MethodPost, rec.Header().Get("Allow"))
+			}
+		})
+	}
+}
+
+func TestServeHTTPTimeout(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	h := New(WithParser(jsonpath.NewParser(jsonpath.WithTimeout(time.Nanosecond))))
+	doc := "[" + strings.Repeat(`[1, 2, 3],`, 10000) + "0]"
+	req := httptest.NewRequest(http.MethodPost, "/?path="+url.QueryEscape("$..*"), strings.NewReader(doc))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	a.Equal(http.StatusUnprocessableEntity, rec.Code)
+	a.Contains(rec.Body.String(), `"code":"evaluation"`)
+}
+
+func TestServeHTTPContext(t *testing.T) {
+	t.Parallel()
+
+	doc := "[" + strings.Repeat(`[1, 2, 3],`, 10000) + "0]"
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	t.Cleanup(cancel)
+
+	for _, tc := range []struct {
+		test string
+		ctx  context.Context //nolint:containedctx
+		exp  string
+	}{
+		{"canceled", canceled, `{"error": {"code": "canceled", "message": "context canceled"}}`},
+		{"deadline", expired, `{"error": {"code": "canceled", "message": "context deadline exceeded"}}`},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			for _, located := range []string{"false", "true"} {
+				req := httptest.NewRequestWithContext(
+					tc.ctx, http.MethodPost,
+					"/?located="+located+"&path="+url.QueryEscape("$..*"), strings.NewReader(doc),
+				)
+				rec := httptest.NewRecorder()
+				New().ServeHTTP(rec, req)
+				a.Equal(http.StatusServiceUnavailable, rec.Code)
+				a.JSONEq(tc.exp, rec.Body.String())
+			}
+		})
+	}
+}
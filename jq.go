@@ -0,0 +1,350 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// ErrJQ errors are returned by [Path.ToJQ] for paths that cannot be
+// translated to jq.
+var ErrJQ = errors.New("jsonpath: cannot translate to jq")
+
+// ToJQ translates p into an equivalent [jq] filter, which selects the same
+// values from a JSON document as p, in the same order. It supports:
+//
+//   - Name, index, and wildcard selectors
+//   - Slice selectors with a step of 1, and [::-1]
+//   - Segments with multiple selectors and descendant segments
+//   - Filter selectors with comparisons, existence tests, logical
+//     operators, and the length(), count(), value(), match(), and
+//     search() functions
+//
+// To preserve RFC 9535 semantics, the filter guards selectors so that they
+// select nothing rather than null for missing object members and array
+// elements, and compares values only as RFC 9535 allows. This makes the
+// filter more verbose than a hand-written equivalent. Note that jq
+// evaluates the regular expressions passed to match() and search() with
+// the Oniguruma engine rather than as I-Regexp patterns.
+//
+// Returns an [ErrJQ] error listing each construct of p that it cannot
+// translate, such as slices with other steps and function extensions other
+// than those defined by RFC 9535.
+//
+// [jq]: https://jqlang.org
+func (p *Path) ToJQ() (string, error) {
+	t := &jqTranslator{path: p}
+	steps := t.steps(p.q.Segments())
+	if len(t.errs) > 0 {
+		return "", errors.Join(t.errs...)
+	}
+
+	prog := "."
+	if len(steps) > 0 {
+		prog = strings.Join(steps, " | ")
+	}
+	if t.usesRoot {
+		prog = ". as $root | " + prog
+	}
+	return prog, nil
+}
+
+// jqTranslator translates a query into a jq filter, collecting errors for
+// the constructs it cannot translate.
+type jqTranslator struct {
+	path     *Path
+	usesRoot bool
+	errs     []error
+}
+
+// unsupported records an error for a construct that t cannot translate.
+func (t *jqTranslator) unsupported(format string, args ...any) {
+	t.errs = append(t.errs, fmt.Errorf(
+		"%w: %v in %v", ErrJQ, fmt.Sprintf(format, args...), t.path,
+	))
+}
+
+// steps translates segs into a list of jq filters to pipe together.
+func (t *jqTranslator) steps(segs []*spec.Segment) []string {
+	steps := make([]string, 0, len(segs))
+	for _, seg := range segs {
+		sels := seg.Selectors()
+		step := ""
+		if len(sels) == 1 {
+			step = t.selector(sels[0])
+		} else {
+			exprs := make([]string, len(sels))
+			for i, sel := range sels {
+				exprs[i] = "(" + t.selector(sel) + ")"
+			}
+			step = "(" + strings.Join(exprs, ", ") + ")"
+		}
+		if seg.IsDescendant() {
+			step = ".. | " + step
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// query translates a filter query with the selectors translated to steps,
+// relative to the root value if root is true and to the current value if it
+// is false.
+func (t *jqTranslator) query(root bool, steps []string) string {
+	if root {
+		t.usesRoot = true
+		steps = append([]string{"$root"}, steps...)
+	}
+	if len(steps) == 0 {
+		return "."
+	}
+	return "(" + strings.Join(steps, " | ") + ")"
+}
+
+// selector translates sel.
+func (t *jqTranslator) selector(sel spec.Selector) string {
+	switch sel := sel.(type) {
+	case spec.Name:
+		return jqName(string(sel))
+	case spec.Index:
+		return jqIndex(int(sel))
+	case spec.WildcardSelector:
+		return ".[]?"
+	case spec.SliceSelector:
+		return t.slice(sel)
+	case *spec.FilterSelector:
+		return ".[]? | select(" + t.or(sel.LogicalOr) + ")"
+	default:
+		t.unsupported("selector %v", sel)
+		return ""
+	}
+}
+
+// jqName returns a jq filter that selects the member name of an object,
+// and nothing from objects without it or from other values.
+func jqName(name string) string {
+	return "objects | select(has(" + jqString(name) + ")) | " + jqField(name)
+}
+
+// jqIndex returns a jq filter that selects the element at idx in an array,
+// and nothing from arrays without it or from other values.
+func jqIndex(idx int) string {
+	cond := "length > " + strconv.Itoa(idx)
+	if idx < 0 {
+		cond = "length >= " + strconv.Itoa(-idx)
+	}
+	return "arrays | select(" + cond + ") | .[" + strconv.Itoa(idx) + "]"
+}
+
+// slice translates s, which must have a step of 1 or select an entire
+// array in reverse.
+func (t *jqTranslator) slice(s spec.SliceSelector) string {
+	start, end := s.Start(), s.End()
+	switch s.Step() {
+	case 1:
+		bounds := ""
+		if start != 0 {
+			bounds = strconv.Itoa(start)
+		}
+		if end != math.MaxInt {
+			bounds += ":" + strconv.Itoa(end)
+		} else if bounds != "" {
+			bounds += ":"
+		}
+		if bounds == "" {
+			// Select every element, as .[:][] would not.
+			return "arrays | .[]"
+		}
+		return "arrays | .[" + bounds + "][]"
+	case -1:
+		if start == math.MaxInt && end == math.MinInt {
+			return "arrays | reverse[]"
+		}
+	}
+	t.unsupported("slice selector %v", s)
+	return ""
+}
+
+// or translates lo to a jq boolean expression.
+func (t *jqTranslator) or(lo spec.LogicalOr) string {
+	ands := make([]string, len(lo))
+	for i, la := range lo {
+		exprs := make([]string, len(la))
+		for j, expr := range la {
+			exprs[j] = t.expr(expr)
+		}
+		ands[i] = strings.Join(exprs, " and ")
+	}
+	return strings.Join(ands, " or ")
+}
+
+// expr translates expr to a jq boolean expression.
+func (t *jqTranslator) expr(expr spec.BasicExpr) string {
+	switch e := expr.(type) {
+	case *spec.ParenExpr:
+		return "(" + t.or(e.LogicalOr) + ")"
+	case *spec.NotParenExpr:
+		return "((" + t.or(e.LogicalOr) + ") | not)"
+	case *spec.ExistExpr:
+		return "(isempty(" + t.nodes(e.PathQuery) + ") | not)"
+	case *spec.NonExistExpr:
+		return "isempty(" + t.nodes(e.PathQuery) + ")"
+	case spec.NonExistExpr:
+		return t.expr(&e)
+	case *spec.CompExpr:
+		return t.comparison(e)
+	case *spec.FuncExpr:
+		return t.logical(e)
+	case spec.NotFuncExpr:
+		return "(" + t.logical(e.FuncExpr) + " | not)"
+	default:
+		t.unsupported("expression %v", e)
+		return ""
+	}
+}
+
+// nodes translates q to a jq filter that selects the nodes q selects.
+func (t *jqTranslator) nodes(q *spec.PathQuery) string {
+	return t.query(q.IsRoot(), t.steps(q.Segments()))
+}
+
+// comparison translates ce. Equality compares arrays of the zero or one
+// values of each operand, so that two missing values are equal, while
+// ordering requires two numbers or two strings.
+func (t *jqTranslator) comparison(ce *spec.CompExpr) string {
+	left, right := t.value(ce.Left()), t.value(ce.Right())
+	switch ce.Op() {
+	case spec.EqualTo:
+		return "([" + left + "] == [" + right + "])"
+	case spec.NotEqualTo:
+		return "([" + left + "] != [" + right + "])"
+	case spec.LessThan:
+		return jqLess(left, right, false)
+	case spec.GreaterThan:
+		return jqLess(right, left, false)
+	case spec.LessThanEqualTo:
+		return jqLess(left, right, true)
+	default:
+		return jqLess(right, left, true)
+	}
+}
+
+// jqLess returns a jq boolean expression that is true if left and right are
+// both numbers or both strings and left is less than right or, if orEqual
+// is true, if left and right are equal values.
+func jqLess(left, right string, orEqual bool) string {
+	cmp := `(map(type) | . == ["number", "number"] or . == ["string", "string"]) and .[0] < .[1]`
+	if orEqual {
+		cmp = "(" + cmp + ") or .[0] == .[1]"
+	}
+	return "([" + left + ", " + right + "] | length == 2 and (" + cmp + "))"
+}
+
+// value translates val, a comparison operand or function argument, to a
+// jq filter that selects its value, or nothing if it has no value.
+func (t *jqTranslator) value(val any) string {
+	switch v := val.(type) {
+	case *spec.LiteralArg:
+		return jqLiteral(v.Value())
+	case *spec.SingularQueryExpr:
+		steps := make([]string, len(v.Selectors()))
+		for i, sel := range v.Selectors() {
+			steps[i] = t.selector(sel)
+		}
+		return t.query(v.IsRoot(), steps)
+	case *spec.PathQuery:
+		return t.nodes(v)
+	case *spec.FuncExpr:
+		return t.function(v)
+	default:
+		t.unsupported("argument %v", v)
+		return ""
+	}
+}
+
+// function translates fe, a call to length(), count(), or value(), to a jq
+// filter that selects its result, or nothing if it returns Nothing.
+func (t *jqTranslator) function(fe *spec.FuncExpr) string {
+	args := fe.Args()
+	switch name := fe.Func().Name(); name {
+	case "length":
+		return "(" + t.value(args[0]) +
+			` | select(type == "string" or type == "array" or type == "object") | length)`
+	case "count":
+		return "([" + t.value(args[0]) + "] | length)"
+	case "value":
+		return "([" + t.value(args[0]) + "] | select(length == 1) | .[0])"
+	default:
+		t.unsupported("function %v()", name)
+		return ""
+	}
+}
+
+// logical translates fe, a call to match() or search(), to a jq boolean
+// expression.
+func (t *jqTranslator) logical(fe *spec.FuncExpr) string {
+	args := fe.Args()
+	name := fe.Func().Name()
+	if name != "match" && name != "search" {
+		t.unsupported("function %v()", name)
+		return ""
+	}
+
+	re := "$re"
+	if name == "match" {
+		re = `"^(?:" + $re + ")$"`
+	}
+	return "([" + t.value(args[0]) + ", " + t.value(args[1]) + "] | length == 2 and " +
+		`map(type) == ["string", "string"] and (.[1] as $re | .[0] | test(` + re + ")))"
+}
+
+// jqLiteral returns the jq literal for val.
+func jqLiteral(val any) string {
+	if n, ok := val.(json.Number); ok {
+		return n.String()
+	}
+	buf := new(strings.Builder)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(val); err != nil {
+		return "null"
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// jqString returns name as a jq string literal.
+func jqString(name string) string {
+	return jqLiteral(name)
+}
+
+// jqKeywords are jq keywords that cannot appear as unquoted field names in
+// older versions of jq.
+var jqKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "if": true, "then": true,
+	"elif": true, "else": true, "end": true, "as": true, "def": true,
+	"reduce": true, "foreach": true, "try": true, "catch": true,
+	"label": true, "import": true, "include": true, "__loc__": true,
+}
+
+// jqField returns a jq filter that selects the member name of an object,
+// as in .name, or .["name"] if name is not an identifier.
+func jqField(name string) string {
+	if name == "" || jqKeywords[name] {
+		return ".[" + jqString(name) + "]"
+	}
+	for i := range len(name) {
+		c := name[i]
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return ".[" + jqString(name) + "]"
+		}
+	}
+	return "." + name
+}
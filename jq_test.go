@@ -0,0 +1,184 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToJQ(t *testing.T) {
+	t.Parallel()
+
+	const (
+		nameA = `objects | select(has("a")) | .a`
+		curA  = `(` + nameA + `)`
+	)
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  string
+		err  string
+	}{
+		{test: "root", path: `$`, exp: `.`},
+		{test: "name", path: `$.a`, exp: nameA},
+		{test: "quoted_name", path: `$['a b']`, exp: `objects | select(has("a b")) | .["a b"]`},
+		{test: "keyword_name", path: `$.and`, exp: `objects | select(has("and")) | .["and"]`},
+		{test: "empty_name", path: `$['']`, exp: `objects | select(has("")) | .[""]`},
+		{test: "index", path: `$[1]`, exp: `arrays | select(length > 1) | .[1]`},
+		{test: "negative_index", path: `$[-2]`, exp: `arrays | select(length >= 2) | .[-2]`},
+		{test: "wildcard", path: `$.*`, exp: `.[]?`},
+		{test: "slice", path: `$[1:3]`, exp: `arrays | .[1:3][]`},
+		{test: "open_slice", path: `$[-2:]`, exp: `arrays | .[-2:][]`},
+		{test: "head_slice", path: `$[:2]`, exp: `arrays | .[:2][]`},
+		{test: "full_slice", path: `$[:]`, exp: `arrays | .[]`},
+		{test: "full_slice_step", path: `$[::1]`, exp: `arrays | .[]`},
+		{test: "full_slice_start", path: `$[0:]`, exp: `arrays | .[]`},
+		{test: "reverse", path: `$[::-1]`, exp: `arrays | reverse[]`},
+		{test: "union", path: `$[0,'a']`, exp: `((arrays | select(length > 0) | .[0]), (` + nameA + `))`},
+		{test: "descendant", path: `$..a`, exp: `.. | ` + nameA},
+		{test: "exists", path: `$[?@.a]`, exp: `.[]? | select((isempty(` + curA + `) | not))`},
+		{test: "not_exists", path: `$[?!@.a]`, exp: `.[]? | select(isempty(` + curA + `))`},
+		{test: "equal", path: `$[?@.a == "x<y"]`, exp: `.[]? | select(([` + curA + `] == ["x<y"]))`},
+		{test: "not_equal", path: `$[?@ != null]`, exp: `.[]? | select(([.] != [null]))`},
+		{
+			test: "less",
+			path: `$[?@ < 2]`,
+			exp:  `.[]? | select(([., 2] | length == 2 and ((map(type) | . == ["number", "number"] or . == ["string", "string"]) and .[0] < .[1])))`,
+		},
+		{
+			test: "greater_equal",
+			path: `$[?@ >= 2.5]`,
+			exp:  `.[]? | select(([2.5, .] | length == 2 and (((map(type) | . == ["number", "number"] or . == ["string", "string"]) and .[0] < .[1]) or .[0] == .[1])))`,
+		},
+		{
+			test: "root_query",
+			path: `$[?@ == $.a]`,
+			exp:  `. as $root | .[]? | select(([.] == [($root | ` + nameA + `)]))`,
+		},
+		{
+			test: "logical",
+			path: `$[?@.a && (@.b || !(@.c))]`,
+			exp: `.[]? | select((isempty(` + curA + `) | not) and ((isempty((objects | select(has("b")) | .b)) | not) or ` +
+				`(((isempty((objects | select(has("c")) | .c)) | not)) | not)))`,
+		},
+		{
+			test: "length",
+			path: `$[?length(@) == 2]`,
+			exp:  `.[]? | select(([(. | select(type == "string" or type == "array" or type == "object") | length)] == [2]))`,
+		},
+		{test: "count", path: `$[?count(@.*) == 2]`, exp: `.[]? | select(([([(.[]?)] | length)] == [2]))`},
+		{test: "value", path: `$[?value(@..a) == 2]`, exp: `.[]? | select(([([(.. | ` + nameA + `)] | select(length == 1) | .[0])] == [2]))`},
+		{
+			test: "match",
+			path: `$[?match(@, "a.")]`,
+			exp:  `.[]? | select(([., "a."] | length == 2 and map(type) == ["string", "string"] and (.[1] as $re | .[0] | test("^(?:" + $re + ")$"))))`,
+		},
+		{
+			test: "not_search",
+			path: `$[?!search(@, "a")]`,
+			exp:  `.[]? | select((([., "a"] | length == 2 and map(type) == ["string", "string"] and (.[1] as $re | .[0] | test($re))) | not))`,
+		},
+		{test: "step", path: `$[::2]`, err: `jsonpath: cannot translate to jq: slice selector ::2 in $[::2]`},
+		{
+			test: "multiple",
+			path: `$[1:5:-1][?@[::3]]`,
+			err: `jsonpath: cannot translate to jq: slice selector 1:5:-1 in $[1:5:-1][?@[::3]]` + "\n" +
+				`jsonpath: cannot translate to jq: slice selector ::3 in $[1:5:-1][?@[::3]]`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			filter, err := MustParse(tc.path).ToJQ()
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				require.ErrorIs(t, err, ErrJQ)
+				assert.Empty(t, filter)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.exp, filter)
+		})
+	}
+}
+
+func TestToJQExec(t *testing.T) {
+	t.Parallel()
+	jq, err := exec.LookPath("jq")
+	if err != nil {
+		t.Skip("jq not installed")
+	}
+
+	const doc = `{
+		"store": {"book": [
+			{"author": "Nigel Rees", "price": 8.95},
+			{"author": "Herman Melville", "price": 8.99, "isbn": "0-553-21311-3"},
+			{"author": "J. R. R. Tolkien", "price": 22.99, "isbn": "0-395-19395-8"}
+		]},
+		"a": [1, 2, "3", null, true, [4], {"b": 5}],
+		"limit": 9
+	}`
+	var input any
+	require.NoError(t, json.Unmarshal([]byte(doc), &input))
+
+	for _, path := range []string{
+		`$.store.book[*].author`,
+		`$..author`,
+		`$..*`,
+		`$.a[1:3]`,
+		`$.a[-2:]`,
+		`$.a[:]`,
+		`$.a[::1]`,
+		`$.a[0:]`,
+		`$..[:]`,
+		`$.a[::-1]`,
+		`$.a[0,-1,9,-9]`,
+		`$.a[?@ > 1]`,
+		`$.a[?@ <= 2]`,
+		`$.a[?@ == null]`,
+		`$.a[?@.b]`,
+		`$.a[?!@.b]`,
+		`$.a[?length(@) == 1]`,
+		`$..[?@.price < $.limit].author`,
+		`$..book[?@.missing == @.absent].author`,
+		`$..book[?@.missing <= @.absent].author`,
+		`$..book[?count(@.*) == 3].author`,
+		`$..book[?value(@.isbn) > "0-4"].author`,
+		`$..book[?match(@.author, "[HJ].*")].price`,
+		`$..book[?!search(@.author, "Mel")].price`,
+	} {
+		p := MustParse(path)
+		filter, err := p.ToJQ()
+		require.NoError(t, err, path)
+
+		cmd := exec.Command(jq, "-c", filter)
+		cmd.Stdin = strings.NewReader(doc)
+		out, err := cmd.Output()
+		require.NoError(t, err, path)
+
+		// Compare sorted JSON, because Go maps are unordered.
+		exp := []string{}
+		for _, v := range p.Select(input) {
+			b, err := json.Marshal(v)
+			require.NoError(t, err)
+			exp = append(exp, string(b))
+		}
+		got := []string{}
+		dec := json.NewDecoder(strings.NewReader(string(out)))
+		for dec.More() {
+			var v any
+			require.NoError(t, dec.Decode(&v))
+			b, err := json.Marshal(v)
+			require.NoError(t, err)
+			got = append(got, string(b))
+		}
+		slices.Sort(exp)
+		slices.Sort(got)
+		assert.Equal(t, exp, got, path)
+	}
+}
@@ -0,0 +1,273 @@
+// Package jsonpathtest provides assertions for tests that apply RFC 9535
+// JSONPath queries to JSON fixtures. The assertions follow the conventions
+// of [testify]: each takes a [TestingT], such as a [*testing.T], reports
+// failures with its Errorf method, and returns true if it passes.
+//
+//	func TestStore(t *testing.T) {
+//		doc := jsonpathtest.Decode(t, fixture)
+//		jsonpathtest.AssertSelects(t, doc, "$.store.book[*].author", "Nigel Rees", "Evelyn Waugh")
+//		jsonpathtest.AssertGolden(t, doc, "$..price", "testdata/prices.json")
+//	}
+//
+// Each assertion accepts a query as a string, which it parses with
+// [jsonpath.Parse], or as a [*jsonpath.Path], which may use a custom
+// registry or options, and a document as a value, such as one returned by
+// [Decode], or as JSON in a []byte.
+//
+// [testify]: https://github.com/stretchr/testify
+package jsonpathtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/theory/jsonpath"
+)
+
+// errTrailing errors are returned by decode for JSON documents followed by
+// more data.
+var errTrailing = errors.New("unexpected data after JSON value")
+
+// TestingT is the subset of [testing.TB] used by the assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Update causes [AssertGolden] to write golden files rather than compare
+// with them. It defaults to true if the JSONPATH_UPDATE_GOLDEN environment
+// variable is set to a non-empty value. Set it from a test flag to update
+// golden files with, for example, go test -update:
+//
+//	var update = flag.Bool("update", false, "update golden files")
+//
+//	func TestMain(m *testing.M) {
+//		flag.Parse()
+//		jsonpathtest.Update = *update
+//		os.Exit(m.Run())
+//	}
+//
+//nolint:gochecknoglobals
+var Update = os.Getenv("JSONPATH_UPDATE_GOLDEN") != ""
+
+// Decode decodes data, a JSON document, into a value for use with the
+// assertions, preserving the precision of numbers as [json.Number] values.
+// Reports an error and returns nil if data is not a single valid JSON
+// value.
+func Decode(t TestingT, data []byte) any {
+	t.Helper()
+	doc, err := decode(data)
+	if err != nil {
+		t.Errorf("jsonpathtest: invalid JSON document: %v", err)
+		return nil
+	}
+	return doc
+}
+
+// AssertSelects asserts that path selects exactly the nodes in expected
+// from doc, in order. It compares nodes as JSON values, so that numbers
+// compare equal regardless of type or precision, such as int 1, float64 1,
+// and json.Number("1.0"), and arrays and objects compare equal regardless
+// of Go type, such as []string{"a"} and []any{"a"}.
+func AssertSelects(t TestingT, doc, path any, expected ...any) bool {
+	t.Helper()
+	p, doc, ok := prepare(t, doc, path)
+	if !ok {
+		return false
+	}
+
+	nodes, err := p.TrySelect(doc)
+	if err != nil {
+		t.Errorf("jsonpathtest: %v failed: %v", p, err)
+		return false
+	}
+
+	got, err := normalize([]any(nodes))
+	if err != nil {
+		t.Errorf("jsonpathtest: cannot compare nodes selected by %v: %v", p, err)
+		return false
+	}
+	want, err := normalize(expected)
+	if err != nil {
+		t.Errorf("jsonpathtest: cannot compare expected nodes: %v", err)
+		return false
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf(
+			"jsonpathtest: %v selected unexpected nodes\nexpected: %v\n  actual: %v",
+			p, encode(want, ""), encode(got, ""),
+		)
+		return false
+	}
+	return true
+}
+
+// AssertSelectsNone asserts that path selects no nodes from doc.
+func AssertSelectsNone(t TestingT, doc, path any) bool {
+	t.Helper()
+	return AssertSelects(t, doc, path)
+}
+
+// AssertPathEqual asserts that expected and actual, each a query string or
+// a [*jsonpath.Path], are the same query, regardless of notation and
+// spacing, so that $["a"][ 0 ] and $.a[0] are equal.
+func AssertPathEqual(t TestingT, expected, actual any) bool {
+	t.Helper()
+	want, ok := parse(t, expected)
+	if !ok {
+		return false
+	}
+	got, ok := parse(t, actual)
+	if !ok {
+		return false
+	}
+
+	if want.String() != got.String() {
+		t.Errorf("jsonpathtest: paths differ\nexpected: %v\n  actual: %v", want, got)
+		return false
+	}
+	return true
+}
+
+// AssertGolden asserts that the nodes path selects from doc, with their
+// normalized paths, match the contents of the golden file named by file.
+// It formats the nodes as an indented JSON array of objects with path and
+// node members, sorted by path so that the order of object members cannot
+// change the result. If [Update] is true, it instead writes the nodes to
+// file, creating its directory if necessary.
+func AssertGolden(t TestingT, doc, path any, file string) bool {
+	t.Helper()
+	p, doc, ok := prepare(t, doc, path)
+	if !ok {
+		return false
+	}
+
+	nodes, err := p.TrySelectLocated(doc)
+	if err != nil {
+		t.Errorf("jsonpathtest: %v failed: %v", p, err)
+		return false
+	}
+	if nodes == nil {
+		nodes = jsonpath.LocatedNodeList{}
+	}
+	nodes.Sort()
+	got := encode(nodes, "  ") + "\n"
+
+	if Update {
+		if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+			t.Errorf("jsonpathtest: %v", err)
+			return false
+		}
+		if err := os.WriteFile(file, []byte(got), 0o600); err != nil {
+			t.Errorf("jsonpathtest: %v", err)
+			return false
+		}
+		return true
+	}
+
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Errorf("jsonpathtest: %v; set jsonpathtest.Update to create it", err)
+		return false
+	}
+	if string(want) != got {
+		t.Errorf(
+			"jsonpathtest: %v does not match golden file %v\nexpected:\n%v\nactual:\n%v",
+			p, file, string(want), got,
+		)
+		return false
+	}
+	return true
+}
+
+// prepare parses path and decodes doc if it's a []byte. Reports an error
+// and returns false if either fails.
+func prepare(t TestingT, doc, path any) (*jsonpath.Path, any, bool) {
+	t.Helper()
+	p, ok := parse(t, path)
+	if !ok {
+		return nil, nil, false
+	}
+	if data, isJSON := doc.([]byte); isJSON {
+		var err error
+		if doc, err = decode(data); err != nil {
+			t.Errorf("jsonpathtest: invalid JSON document: %v", err)
+			return nil, nil, false
+		}
+	}
+	return p, doc, true
+}
+
+// parse returns path, a query string or a [*jsonpath.Path], as a
+// [*jsonpath.Path]. Reports an error and returns false if path is neither
+// or fails to parse.
+func parse(t TestingT, path any) (*jsonpath.Path, bool) {
+	t.Helper()
+	switch path := path.(type) {
+	case *jsonpath.Path:
+		return path, true
+	case string:
+		p, err := jsonpath.Parse(path)
+		if err != nil {
+			t.Errorf("jsonpathtest: invalid path %q: %v", path, err)
+			return nil, false
+		}
+		return p, true
+	default:
+		t.Errorf("jsonpathtest: path must be a string or *jsonpath.Path, not %T", path)
+		return nil, false
+	}
+}
+
+// decode decodes data, a single JSON value, using [json.Number] for
+// numbers.
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w at offset %v", errTrailing, dec.InputOffset())
+	}
+	return doc, nil
+}
+
+// normalize returns values encoded as JSON and decoded again, with numbers
+// as float64 values, so that equal JSON values are deeply equal.
+func normalize(values []any) (any, error) {
+	if values == nil {
+		values = []any{}
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	var res any
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return res, nil
+}
+
+// encode returns val encoded as JSON, indented by indent if it's not empty.
+func encode(val any, indent string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if indent != "" {
+		enc.SetIndent("", indent)
+	}
+	if err := enc.Encode(val); err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
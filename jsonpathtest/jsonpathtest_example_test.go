@@ -0,0 +1,35 @@
+package jsonpathtest_test
+
+import (
+	"fmt"
+
+	"github.com/theory/jsonpath/jsonpathtest"
+)
+
+// Assert the nodes that queries select from a JSON fixture. This example
+// uses a TestingT that prints failures in place of the *testing.T passed
+// to a test function.
+func ExampleAssertSelects() {
+	t := &printer{}
+	doc := jsonpathtest.Decode(t, []byte(`{"books": [
+	  {"title": "Sayings of the Century", "price": 8.95},
+	  {"title": "Sword of Honour", "price": 12.99}
+	]}`))
+
+	jsonpathtest.AssertSelects(t, doc, "$.books[*].price", 8.95, 12.99)
+	jsonpathtest.AssertSelects(t, doc, "$.books[?@.price < 10].title", "Sword of Honour")
+	jsonpathtest.AssertPathEqual(t, `$["books"][ 0 ]`, "$.books[0]")
+	// Output:
+	// jsonpathtest: $["books"][?@["price"] < 10]["title"] selected unexpected nodes
+	// expected: ["Sword of Honour"]
+	//   actual: ["Sayings of the Century"]
+}
+
+// printer is a jsonpathtest.TestingT that prints failures.
+type printer struct{}
+
+func (*printer) Helper() {}
+
+func (*printer) Errorf(format string, args ...any) {
+	fmt.Printf(format+"\n", args...)
+}
@@ -0,0 +1,254 @@
+package jsonpathtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+)
+
+// recorder records the failures reported by the assertions.
+type recorder struct {
+	errs []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+const testDoc = `{"a": [1, 2.50, {"b": "x"}], "c": {"d": true, "e": null}}`
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	r := &recorder{}
+	a.Equal(map[string]any{"a": json.Number("1.0")}, Decode(r, []byte(`{"a": 1.0} `)))
+	a.Empty(r.errs)
+
+	a.Nil(Decode(r, []byte(`{"a": }`)))
+	a.Equal([]string{"jsonpathtest: invalid JSON document: invalid character '}' looking for beginning of value"}, r.errs)
+
+	r = &recorder{}
+	a.Nil(Decode(r, []byte(`{}}`)))
+	a.Equal([]string{"jsonpathtest: invalid JSON document: unexpected data after JSON value at offset 2"}, r.errs)
+}
+
+func TestAssertSelects(t *testing.T) {
+	t.Parallel()
+	doc := Decode(t, []byte(testDoc))
+
+	for _, tc := range []struct {
+		test string
+		doc  any
+		path any
+		exp  []any
+		errs []string
+	}{
+		{
+			test: "values",
+			doc:  doc,
+			path: "$.a[*]",
+			exp:  []any{1, 2.5, map[string]string{"b": "x"}},
+		},
+		{
+			test: "json",
+			doc:  []byte(testDoc),
+			path: "$.a[0,1]",
+			exp:  []any{json.Number("1"), float32(2.5)},
+		},
+		{
+			test: "path",
+			doc:  doc,
+			path: jsonpath.MustParse("$.c.e"),
+			exp:  []any{nil},
+		},
+		{
+			test: "go_values",
+			doc:  map[string]any{"x": []string{"a", "b"}},
+			path: "$.x",
+			exp:  []any{[]any{"a", "b"}},
+		},
+		{
+			test: "none",
+			doc:  doc,
+			path: "$.nope",
+		},
+		{
+			test: "mismatch",
+			doc:  doc,
+			path: "$.a[0]",
+			exp:  []any{2},
+			errs: []string{"jsonpathtest: $[\"a\"][0] selected unexpected nodes\nexpected: [2]\n  actual: [1]"},
+		},
+		{
+			test: "order",
+			doc:  doc,
+			path: "$.a[0,1]",
+			exp:  []any{2.5, 1},
+			errs: []string{"jsonpathtest: $[\"a\"][0,1] selected unexpected nodes\nexpected: [2.5,1]\n  actual: [1,2.5]"},
+		},
+		{
+			test: "unexpected",
+			doc:  doc,
+			path: "$.c.d",
+			errs: []string{"jsonpathtest: $[\"c\"][\"d\"] selected unexpected nodes\nexpected: []\n  actual: [true]"},
+		},
+		{
+			test: "invalid_path",
+			doc:  doc,
+			path: "$.a[",
+			errs: []string{`jsonpathtest: invalid path "$.a[": jsonpath: unexpected eof at position 5`},
+		},
+		{
+			test: "path_type",
+			doc:  doc,
+			path: 42,
+			errs: []string{"jsonpathtest: path must be a string or *jsonpath.Path, not int"},
+		},
+		{
+			test: "invalid_json",
+			doc:  []byte(`{`),
+			path: "$",
+			errs: []string{"jsonpathtest: invalid JSON document: unexpected EOF"},
+		},
+		{
+			test: "budget",
+			doc:  []byte(`[[[1]]]`),
+			path: jsonpath.NewParser(jsonpath.WithMaxNodes(1)).MustParse("$..*"),
+			errs: []string{"jsonpathtest: $..[*] failed: evaluation budget exceeded: visited more than 1 nodes"},
+		},
+		{
+			test: "unmarshalable",
+			doc:  map[string]any{"f": func() {}},
+			path: "$.f",
+			errs: []string{"jsonpathtest: cannot compare nodes selected by $[\"f\"]: json: unsupported type: func()"},
+		},
+		{
+			test: "unmarshalable_expected",
+			doc:  doc,
+			path: "$.c.d",
+			exp:  []any{make(chan int)},
+			errs: []string{"jsonpathtest: cannot compare expected nodes: json: unsupported type: chan int"},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			r := &recorder{}
+			assert.Equal(t, tc.errs == nil, AssertSelects(r, tc.doc, tc.path, tc.exp...))
+			assert.Equal(t, tc.errs, r.errs)
+		})
+	}
+}
+
+func TestAssertSelectsNone(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	r := &recorder{}
+	a.True(AssertSelectsNone(r, []byte(testDoc), "$.nope"))
+	a.Empty(r.errs)
+	a.False(AssertSelectsNone(r, []byte(testDoc), "$.c.e"))
+	a.Equal([]string{"jsonpathtest: $[\"c\"][\"e\"] selected unexpected nodes\nexpected: []\n  actual: [null]"}, r.errs)
+}
+
+func TestAssertPathEqual(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test     string
+		expected any
+		actual   any
+		errs     []string
+	}{
+		{"same", "$.a[0]", "$.a[0]", nil},
+		{"notation", `$["a"][ 0 ]`, "$.a[0]", nil},
+		{"path", jsonpath.MustParse("$..*"), "$..[*]", nil},
+		{"filters", `$[?@.x=="y"]`, jsonpath.MustParse(`$[?@["x"] == 'y']`), nil},
+		{"differ", "$.a", "$.b", []string{"jsonpathtest: paths differ\nexpected: $[\"a\"]\n  actual: $[\"b\"]"}},
+		{"invalid_expected", "$[", "$", []string{`jsonpathtest: invalid path "$[": jsonpath: unexpected eof at position 3`}},
+		{"invalid_actual", "$", "x", []string{`jsonpathtest: invalid path "x": jsonpath: unexpected identifier at position 1`}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			r := &recorder{}
+			assert.Equal(t, tc.errs == nil, AssertPathEqual(r, tc.expected, tc.actual))
+			assert.Equal(t, tc.errs, r.errs)
+		})
+	}
+}
+
+//nolint:paralleltest // Sets Update.
+func TestAssertGolden(t *testing.T) {
+	a := assert.New(t)
+	doc := Decode(t, []byte(testDoc))
+
+	// Compare with the checked-in golden file.
+	r := &recorder{}
+	a.True(AssertGolden(r, doc, "$..*", filepath.Join("testdata", "descendants.json")))
+	a.Empty(r.errs)
+
+	// Should report a missing file.
+	dir := t.TempDir()
+	file := filepath.Join(dir, "sub", "golden.json")
+	a.False(AssertGolden(r, doc, "$.c.*", file))
+	require.Len(t, r.errs, 1)
+	a.Contains(r.errs[0], "no such file or directory; set jsonpathtest.Update to create it")
+
+	// Should create the file.
+	r = &recorder{}
+	Update = true
+	t.Cleanup(func() { Update = false })
+	a.True(AssertGolden(r, doc, "$.c.*", file))
+	a.Empty(r.errs)
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	a.Equal(`[
+  {
+    "node": true,
+    "path": "$['c']['d']"
+  },
+  {
+    "node": null,
+    "path": "$['c']['e']"
+  }
+]
+`, string(data))
+
+	// Should match the new file.
+	Update = false
+	a.True(AssertGolden(r, doc, "$.c.*", file))
+	a.Empty(r.errs)
+
+	// Should report a mismatch.
+	a.False(AssertGolden(r, doc, "$.nope", file))
+	a.Equal([]string{
+		"jsonpathtest: $[\"nope\"] does not match golden file " + file +
+			"\nexpected:\n" + string(data) + "\nactual:\n[]\n",
+	}, r.errs)
+
+	// Should report parse and evaluation errors.
+	r = &recorder{}
+	a.False(AssertGolden(r, doc, "$[", file))
+	a.False(AssertGolden(r, []byte(`[[[1]]]`), jsonpath.NewParser(jsonpath.WithMaxNodes(1)).MustParse("$..*"), file))
+	a.Equal([]string{
+		`jsonpathtest: invalid path "$[": jsonpath: unexpected eof at position 3`,
+		"jsonpathtest: $..[*] failed: evaluation budget exceeded: visited more than 1 nodes",
+	}, r.errs)
+
+	// Should report write errors.
+	r = &recorder{}
+	Update = true
+	a.False(AssertGolden(r, doc, "$", filepath.Join(file, "x.json")))
+	a.False(AssertGolden(r, doc, "$", dir))
+	require.Len(t, r.errs, 2)
+	a.Contains(r.errs[0], "not a directory")
+	a.Contains(r.errs[1], "is a directory")
+}
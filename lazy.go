@@ -0,0 +1,71 @@
+package jsonpath
+
+import "sync"
+
+// LazyPath is a JSONPath query that is parsed and optimized on first use,
+// for large sets of queries, such as those defined by configuration files,
+// most of which may never be evaluated. It parses the query at most once,
+// caching the resulting [Path] or parse error, and is safe for concurrent
+// use by multiple goroutines. Create one with [Lazy] or [Parser.Lazy].
+type LazyPath struct {
+	query  string
+	parser *Parser
+	once   sync.Once
+	path   *Path
+	err    error
+}
+
+// Lazy returns a [LazyPath] that parses query with the RFC 9535 functions
+// and default options on first use.
+func Lazy(query string) *LazyPath {
+	return NewParser().Lazy(query)
+}
+
+// Lazy returns a [LazyPath] that parses query with c on first use.
+func (c *Parser) Lazy(query string) *LazyPath {
+	return &LazyPath{query: query, parser: c}
+}
+
+// String returns the query from which l was created, without parsing it.
+func (l *LazyPath) String() string {
+	return l.query
+}
+
+// Path parses and optimizes l's query, if it has not already done so, and
+// returns the resulting [Path], or an [ErrPathParse] error if the query is
+// invalid. Subsequent calls return the same Path or error. See
+// [Path.Optimize] for details of the optimizations.
+func (l *LazyPath) Path() (*Path, error) {
+	l.once.Do(func() {
+		path, err := l.parser.Parse(l.query)
+		if err != nil {
+			l.err = err
+			return
+		}
+		l.path = path.Optimize()
+	})
+	return l.path, l.err
+}
+
+// Select returns the nodes that l's query selects from input, parsing it
+// first if necessary. Returns an [ErrPathParse] error if the query is
+// invalid, or an [ErrBudgetExceeded] error if evaluation exceeds the limits
+// configured by [WithMaxNodes] or [WithTimeout]. See [Path.TrySelect].
+func (l *LazyPath) Select(input any) (NodeList, error) {
+	path, err := l.Path()
+	if err != nil {
+		return nil, err
+	}
+	return path.TrySelect(input)
+}
+
+// SelectLocated returns the nodes that l's query selects from input, with
+// their normalized paths, parsing it first if necessary. Returns the same
+// errors as [LazyPath.Select]. See [Path.TrySelectLocated].
+func (l *LazyPath) SelectLocated(input any) (LocatedNodeList, error) {
+	path, err := l.Path()
+	if err != nil {
+		return nil, err
+	}
+	return path.TrySelectLocated(input)
+}
@@ -0,0 +1,100 @@
+package jsonpath
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestLazy(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	input := map[string]any{"a": []any{1, 2, 3}}
+
+	// Should not parse until first use.
+	l := Lazy(`$.a[2,1,0]`)
+	a.Equal(`$.a[2,1,0]`, l.String())
+	a.Nil(l.path)
+
+	nodes, err := l.Select(input)
+	r.NoError(err)
+	a.Equal(NodeList{3, 2, 1}, nodes)
+	located, err := l.SelectLocated(input)
+	r.NoError(err)
+	a.Equal(LocatedNodeList{
+		{Path: norm("a", 2), Node: 3},
+		{Path: norm("a", 1), Node: 2},
+		{Path: norm("a", 0), Node: 1},
+	}, located)
+
+	// Should optimize and cache the path.
+	path, err := l.Path()
+	r.NoError(err)
+	a.Equal(`$["a"][2::-1]`, path.String())
+	again, err := l.Path()
+	r.NoError(err)
+	a.Same(path, again)
+	a.Equal(`$.a[2,1,0]`, l.String())
+
+	// Should cache parse errors.
+	l = Lazy(`$.a[`)
+	for range 2 {
+		path, err = l.Path()
+		r.ErrorIs(err, ErrPathParse)
+		a.Nil(path)
+		nodes, err = l.Select(input)
+		r.ErrorIs(err, ErrPathParse)
+		a.Nil(nodes)
+		located, err = l.SelectLocated(input)
+		r.ErrorIs(err, ErrPathParse)
+		a.Nil(located)
+	}
+
+	// Should parse with the parser's options.
+	l = NewParser(WithMaxNodes(1)).Lazy(`$..*`)
+	nodes, err = l.Select(input)
+	r.ErrorIs(err, ErrBudgetExceeded)
+	a.Nil(nodes)
+	located, err = l.SelectLocated(input)
+	r.ErrorIs(err, ErrBudgetExceeded)
+	a.Nil(located)
+}
+
+func TestLazyConcurrency(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Count parses with a function whose validator runs at parse time.
+	var parses atomic.Int32
+	parser := NewParser()
+	require.NoError(t, parser.Registry().Register(
+		"parsed",
+		spec.FuncLogical,
+		func([]spec.FuncExprArg) error {
+			parses.Add(1)
+			return nil
+		},
+		func([]spec.PathValue) spec.PathValue { return spec.LogicalTrue },
+	))
+
+	input := []any{1, 2}
+	l := parser.Lazy(`$[?parsed()]`)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodes, err := l.Select(input)
+			assert.NoError(t, err)
+			assert.Equal(t, NodeList{1, 2}, nodes)
+		}()
+	}
+	wg.Wait()
+	a.Equal(int32(1), parses.Load())
+}
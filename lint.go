@@ -0,0 +1,93 @@
+package jsonpath
+
+import (
+	"errors"
+
+	"github.com/theory/jsonpath/parser"
+	"github.com/theory/jsonpath/spec"
+)
+
+// Diagnostic describes a problem with a JSONPath query found by [Lint]. See
+// [spec.Diagnostic] for details.
+type Diagnostic = spec.Diagnostic
+
+// Schema describes the structure of the values that a [Path] queries, for
+// use by [Path.CheckSchema]. See [spec.Schema] for details.
+type Schema = spec.Schema
+
+// ErrSchema errors are returned by [ParseSchema] for invalid schemas.
+var ErrSchema = spec.ErrSchema
+
+// ParseSchema parses data, a [JSON Schema], into a [Schema] for use by
+// [Path.CheckSchema]. Returns an [ErrSchema] error if data is not a valid
+// schema. See [spec.ParseSchema] for the keywords it supports.
+//
+// [JSON Schema]: https://json-schema.org
+func ParseSchema(data []byte) (*Schema, error) {
+	//nolint:wrapcheck
+	return spec.ParseSchema(data)
+}
+
+// CheckSchema analyzes p against schema, which describes the values p
+// queries, and returns a [Diagnostic] for each selector that can never
+// select a value, such as a misspelled member name, and for each
+// comparison that is always false because schema never allows the
+// compared value to have the type of the literal it's compared to. Use it
+// to validate queries, such as those in configuration files, before
+// evaluating them. Returns nil if it finds no problems. See
+// [spec.PathQuery.CheckSchema] for details.
+func (p *Path) CheckSchema(schema *Schema) []Diagnostic {
+	return p.q.CheckSchema(schema)
+}
+
+// Lint validates query, a JSONPath query string, without evaluating it, and
+// returns a [Diagnostic] for each problem it finds. Pass [Option] values to
+// configure the [Parser] that parses query. Returns nil if query is valid and
+// free of problems. See [Parser.Lint] for details.
+func Lint(query string, opt ...Option) []Diagnostic {
+	return NewParser(opt...).Lint(query)
+}
+
+// Lint validates query, a JSONPath query string, without evaluating it, and
+// returns a [Diagnostic] for each problem it finds. If query fails to parse,
+// it returns a single diagnostic with the code [spec.CodeUnknownFunction]
+// for a call to a function not found in the registry, or [spec.CodeParse]
+// for any other parse error. Otherwise it reports the use of number syntax
+// allowed only by [WithLenientNumbers] and the problems reported by
+// [spec.PathQuery.Lint]. Returns nil if query is valid and free of
+// problems.
+func (c *Parser) Lint(query string) []Diagnostic {
+	q, err := parser.ParseMode(c.Registry(), query, c.mode)
+	if err != nil {
+		code := spec.CodeParse
+		if errors.Is(err, ErrUnknownFunction) {
+			code = spec.CodeUnknownFunction
+		}
+		return []Diagnostic{errDiagnostic(spec.SeverityError, code, "", err)}
+	}
+
+	var diags []Diagnostic
+	if c.mode&parser.LenientNumbers != 0 {
+		_, err := parser.ParseMode(c.Registry(), query, c.mode&^parser.LenientNumbers)
+		if err != nil {
+			diags = append(diags, errDiagnostic(
+				spec.SeverityWarning, spec.CodeExtension,
+				"lenient number syntax is not defined by RFC 9535: ", err,
+			))
+		}
+	}
+
+	return append(diags, q.Lint()...)
+}
+
+// errDiagnostic returns a [Diagnostic] with severity and code for err, a
+// parse error, with a message of prefix followed by the error message and
+// the position and hint of err.
+func errDiagnostic(severity spec.Severity, code, prefix string, err error) Diagnostic {
+	diag := Diagnostic{Severity: severity, Code: code, Message: prefix + err.Error()}
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		diag.Start, diag.End, diag.Hint = pe.Start, pe.End, pe.Hint
+	}
+	return diag
+}
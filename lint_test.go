@@ -0,0 +1,187 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	reg := registry.New()
+	if err := reg.Register(
+		"first",
+		spec.FuncValue,
+		func([]spec.FuncExprArg) error { return nil },
+		func([]spec.PathValue) spec.PathValue { return nil },
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		test  string
+		query string
+		opts  []Option
+		exp   []Diagnostic
+	}{
+		{
+			test:  "valid",
+			query: `$.a[?@.b == 1 && length(@.c) > 2]`,
+		},
+		{
+			test:  "parse_error",
+			query: `$[`,
+			exp: []Diagnostic{{
+				Severity: spec.SeverityError,
+				Code:     spec.CodeParse,
+				Message:  "jsonpath: unexpected eof at position 3",
+				Start:    2,
+				End:      2,
+			}},
+		},
+		{
+			test:  "unknown_function",
+			query: `$[?first(@.*) == 1]`,
+			exp: []Diagnostic{{
+				Severity: spec.SeverityError,
+				Code:     spec.CodeUnknownFunction,
+				Message:  "jsonpath: unknown function first() at position 4",
+				Start:    3,
+				End:      8,
+				Hint:     "register function extensions with a registry.Registry",
+			}},
+		},
+		{
+			test:  "extension_function",
+			query: `$[?first(@.*) == 1]`,
+			opts:  []Option{WithRegistry(reg)},
+			exp: []Diagnostic{{
+				Severity: spec.SeverityInfo,
+				Code:     spec.CodeExtension,
+				Message:  "function first() is not defined by RFC 9535",
+			}},
+		},
+		{
+			test:  "lenient_numbers",
+			query: `$[01]`,
+			opts:  []Option{WithLenientNumbers()},
+			exp: []Diagnostic{{
+				Severity: spec.SeverityWarning,
+				Code:     spec.CodeExtension,
+				Message:  "lenient number syntax is not defined by RFC 9535: jsonpath: invalid number literal at position 3",
+				Start:    2,
+				End:      3,
+			}},
+		},
+		{
+			test:  "lenient_numbers_unused",
+			query: `$[1]`,
+			opts:  []Option{WithLenientNumbers()},
+		},
+		{
+			test:  "constant_filters",
+			query: `$[?1 == 1].a[?@.b && 1 > 2]`,
+			exp: []Diagnostic{
+				{
+					Severity: spec.SeverityWarning,
+					Code:     spec.CodeConstantFilter,
+					Message:  `filter in segment 1 of $[?@]["a"][?!@] is always true and selects the same values as *`,
+				},
+				{
+					Severity: spec.SeverityWarning,
+					Code:     spec.CodeConstantFilter,
+					Message:  `filter in segment 3 of $[?@]["a"][?!@] is always false and selects nothing`,
+				},
+			},
+		},
+		{
+			test:  "unreachable",
+			query: `$[0][1:1].a`,
+			exp: []Diagnostic{
+				{
+					Severity: spec.SeverityWarning,
+					Code:     spec.CodeUnreachable,
+					Message:  `selector 1:1 in segment 2 of $[0][1:1]["a"] selects nothing`,
+				},
+				{
+					Severity: spec.SeverityWarning,
+					Code:     spec.CodeUnreachable,
+					Message:  `segments after segment 2 of $[0][1:1]["a"] are unreachable because it selects nothing`,
+				},
+			},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			a.Equal(tc.exp, Lint(tc.query, tc.opts...))
+			a.Equal(tc.exp, NewParser(tc.opts...).Lint(tc.query))
+		})
+	}
+}
+
+func TestDiagnosticJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	data, err := json.Marshal(Lint(`$[?@.* == 1]`))
+	a.NoError(err)
+	a.JSONEq(`[{
+		"severity": "error",
+		"code": "parse",
+		"message": "jsonpath: cannot compare non-singular query at position 4",
+		"start": 3,
+		"end": 4,
+		"hint": "use only name and index selectors in queries compared to other values"
+	}]`, string(data))
+
+	data, err = json.Marshal(Lint(`$[0][1:1]`))
+	a.NoError(err)
+	a.JSONEq(`[{
+		"severity": "warning",
+		"code": "unreachable",
+		"message": "selector 1:1 in segment 2 of $[0][1:1] selects nothing",
+		"start": 0,
+		"end": 0
+	}]`, string(data))
+}
+
+func TestCheckSchema(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	schema, err := ParseSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"user": {
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {"email": {"type": "string"}, "age": {"type": "integer"}}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	a.Empty(MustParse(`$.user.email`).CheckSchema(schema))
+	a.Empty(MustParse(`$.other[0]`).CheckSchema(schema))
+	a.Equal([]Diagnostic{{
+		Severity: spec.SeverityWarning,
+		Code:     spec.CodeSchemaMismatch,
+		Message:  `selector "emial" in segment 2 of $["user"]["emial"] never selects a value because the schema defines no member "emial"`,
+		Hint:     `did you mean "email"?`,
+	}}, MustParse(`$.user.emial`).CheckSchema(schema))
+	a.Equal([]Diagnostic{{
+		Severity: spec.SeverityWarning,
+		Code:     spec.CodeTypeMismatch,
+		Message:  `comparison $["user"]["age"] > "21" is always false because $["user"]["age"] is never a string`,
+	}}, MustParse(`$[?$.user.age > "21"]`).CheckSchema(schema))
+
+	schema, err = ParseSchema([]byte(`{"type": 1}}`))
+	require.ErrorIs(t, err, ErrSchema)
+	a.Nil(schema)
+}
@@ -0,0 +1,40 @@
+package mmapjson
+
+// File is a JSON file opened by [Open]. The [Object] and [Array] values
+// returned by [File.Value], and the objects and arrays that queries select
+// from them, refer to the contents of the file, which on Unix systems are
+// memory-mapped, so they must not be used after [File.Close]. Strings and
+// numbers are copies and remain valid.
+type File struct {
+	data  []byte
+	val   any
+	unmap func() error
+}
+
+// Value returns the JSON value in f, as returned by [Parse].
+func (f *File) Value() any { return f.val }
+
+// Bytes returns the contents of f. Callers must not modify it.
+func (f *File) Bytes() []byte { return f.data }
+
+// Close releases the contents of f. Calling Close more than once does
+// nothing.
+func (f *File) Close() error {
+	if f.unmap == nil {
+		return nil
+	}
+	unmap := f.unmap
+	f.data, f.val, f.unmap = nil, nil, nil
+	return unmap()
+}
+
+// newFile creates a File from data, calling unmap to release data if it
+// fails to parse.
+func newFile(data []byte, unmap func() error) (*File, error) {
+	val, err := Parse(data)
+	if err != nil {
+		_ = unmap()
+		return nil, err
+	}
+	return &File{data: data, val: val, unmap: unmap}, nil
+}
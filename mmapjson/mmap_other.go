@@ -0,0 +1,17 @@
+//go:build !unix
+
+package mmapjson
+
+import "os"
+
+// Open reads the named JSON file into memory and parses it with [Parse].
+// Returns an [ErrSyntax] error if the file does not contain a JSON value.
+// Systems other than Unix do not support memory-mapping, so Open reads the
+// entire file; call [File.Close] to release it.
+func Open(name string) (*File, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return newFile(data, func() error { return nil })
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package mmapjson
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Open memory-maps the named JSON file read-only and parses it with
+// [Parse]. Returns an [ErrSyntax] error if the file does not contain a JSON
+// value. Call [File.Close] to unmap it.
+func Open(name string) (*File, error) {
+	fh, err := os.Open(name)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	defer fh.Close()
+
+	info, err := fh.Stat()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, fmt.Errorf("%w: %v is empty", ErrSyntax, name)
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("mmapjson: %v is too large to map", name)
+	}
+
+	data, err := syscall.Mmap(int(fh.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, &os.PathError{Op: "mmap", Path: name, Err: err}
+	}
+	return newFile(data, func() error { return syscall.Munmap(data) })
+}
@@ -0,0 +1,430 @@
+// Package mmapjson executes RFC 9535 JSONPath queries against JSON
+// documents parsed lazily from byte slices, including memory-mapped files,
+// for batch jobs that probe a few fields in many large documents. Rather
+// than decode a document, [Parse] returns an [Object] or [Array] that
+// indexes the offsets of its members or elements the first time a query
+// selects from it, and decodes strings and numbers only when a query
+// selects or compares them. Queries thus read only the bytes that precede
+// the values they select in each container, and allocate memory in
+// proportion to the containers they visit rather than to the size of the
+// document.
+//
+//	f, err := mmapjson.Open("large.json")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//	ids := path.Select(f.Value())
+//
+// Parse does not validate the JSON it indexes, so that it need not read an
+// entire document. Queries against invalid JSON never panic, but select
+// values from it only up to the first error. Use [encoding/json.Valid] to
+// validate documents first if necessary.
+package mmapjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/theory/jsonpath"
+)
+
+var (
+	_ jsonpath.Object = (*Object)(nil)
+	_ jsonpath.Array  = (*Array)(nil)
+)
+
+// ErrSyntax errors are returned by [Parse] and [Open] for data that does
+// not start with a JSON value.
+var ErrSyntax = errors.New("mmapjson: invalid JSON")
+
+// mapThreshold is the number of members above which an [Object] indexes
+// its members by name.
+const mapThreshold = 16
+
+// Parse returns the JSON value in data for use in a JSONPath query. It
+// returns objects as [*Object] values and arrays as [*Array] values that
+// refer to data, which therefore must not change while they remain in use,
+// strings as string values, numbers as [encoding/json.Number] values,
+// booleans as bool values, and null as nil. Returns an [ErrSyntax] error if
+// data does not start with a JSON value or, for objects and arrays, does
+// not end with the corresponding closing bracket, so that it detects
+// truncated documents without reading their contents.
+func Parse(data []byte) (any, error) {
+	start := skipSpace(data, 0)
+	if start == len(data) {
+		return nil, fmt.Errorf("%w: no value", ErrSyntax)
+	}
+
+	end := len(data)
+	switch data[start] {
+	case '{', '[':
+		for end > start && isSpace(data[end-1]) {
+			end--
+		}
+		if end-start < 2 || data[end-1] != data[start]+2 {
+			// '}' and ']' follow '{' and '[' by two.
+			return nil, fmt.Errorf("%w: unterminated value at offset %d", ErrSyntax, start)
+		}
+	default:
+		if end = skipValue(data, start); end < 0 {
+			return nil, fmt.Errorf("%w at offset %d", ErrSyntax, start)
+		}
+	}
+
+	val, ok := value(data[start:end])
+	if !ok {
+		return nil, fmt.Errorf("%w at offset %d", ErrSyntax, start)
+	}
+	return val, nil
+}
+
+// Object is a lazily-indexed JSON object that implements
+// [jsonpath.Object]. Queries return Object values for selected objects.
+// It's safe for concurrent use.
+type Object struct {
+	raw     []byte
+	once    sync.Once
+	members []member
+	names   map[string]int
+}
+
+// member is an indexed member of an [Object].
+type member struct {
+	name string
+	val  any
+	raw  []byte
+}
+
+// Get returns the value of the first member named name. Defined by
+// [jsonpath.Object].
+func (o *Object) Get(name string) (any, bool) {
+	o.index()
+	if o.names != nil {
+		if i, ok := o.names[name]; ok {
+			return o.members[i].value(), true
+		}
+		return nil, false
+	}
+	for i := range o.members {
+		if o.members[i].name == name {
+			return o.members[i].value(), true
+		}
+	}
+	return nil, false
+}
+
+// Len returns the number of members in o. Defined by [jsonpath.Object].
+func (o *Object) Len() int {
+	o.index()
+	return len(o.members)
+}
+
+// Iterate returns an iterator over the names and values of the members of
+// o, in document order. Defined by [jsonpath.Object].
+func (o *Object) Iterate() iter.Seq2[string, any] {
+	o.index()
+	return func(yield func(string, any) bool) {
+		for i := range o.members {
+			if !yield(o.members[i].name, o.members[i].value()) {
+				return
+			}
+		}
+	}
+}
+
+// Bytes returns the raw JSON of o. Callers must not modify it.
+func (o *Object) Bytes() []byte { return o.raw }
+
+// MarshalJSON returns a copy of the raw JSON of o. Implements
+// [json.Marshaler].
+func (o *Object) MarshalJSON() ([]byte, error) {
+	return bytes.Clone(o.raw), nil
+}
+
+// index indexes the members of o, once.
+func (o *Object) index() {
+	o.once.Do(func() {
+		data := o.raw
+		for i := skipSpace(data, 1); i < len(data) && data[i] != '}'; {
+			end := skipString(data, i)
+			if end < 0 {
+				break
+			}
+			name, ok := decodeString(data[i:end])
+			if !ok {
+				break
+			}
+			i = skipSpace(data, end)
+			if i >= len(data) || data[i] != ':' {
+				break
+			}
+			i = skipSpace(data, i+1)
+			if end = skipValue(data, i); end < 0 {
+				break
+			}
+			o.members = append(o.members, newMember(name, data[i:end]))
+			if i = skipSpace(data, end); i < len(data) && data[i] == ',' {
+				i = skipSpace(data, i+1)
+			}
+		}
+
+		if len(o.members) > mapThreshold {
+			o.names = make(map[string]int, len(o.members))
+			for i := len(o.members) - 1; i >= 0; i-- {
+				o.names[o.members[i].name] = i
+			}
+		}
+	})
+}
+
+// Array is a lazily-indexed JSON array that implements [jsonpath.Array].
+// Queries return Array values for selected arrays. It's safe for
+// concurrent use.
+type Array struct {
+	raw      []byte
+	once     sync.Once
+	elements []member
+}
+
+// Get returns the value at index. Defined by [jsonpath.Array].
+func (a *Array) Get(index int) (any, bool) {
+	a.index()
+	if index < 0 || index >= len(a.elements) {
+		return nil, false
+	}
+	return a.elements[index].value(), true
+}
+
+// Len returns the number of elements in a. Defined by [jsonpath.Array].
+func (a *Array) Len() int {
+	a.index()
+	return len(a.elements)
+}
+
+// Iterate returns an iterator over the indexes and values of the elements
+// of a. Defined by [jsonpath.Array].
+func (a *Array) Iterate() iter.Seq2[int, any] {
+	a.index()
+	return func(yield func(int, any) bool) {
+		for i := range a.elements {
+			if !yield(i, a.elements[i].value()) {
+				return
+			}
+		}
+	}
+}
+
+// Bytes returns the raw JSON of a. Callers must not modify it.
+func (a *Array) Bytes() []byte { return a.raw }
+
+// MarshalJSON returns a copy of the raw JSON of a. Implements
+// [json.Marshaler].
+func (a *Array) MarshalJSON() ([]byte, error) {
+	return bytes.Clone(a.raw), nil
+}
+
+// index indexes the elements of a, once.
+func (a *Array) index() {
+	a.once.Do(func() {
+		data := a.raw
+		for i := skipSpace(data, 1); i < len(data) && data[i] != ']'; {
+			end := skipValue(data, i)
+			if end < 0 {
+				break
+			}
+			a.elements = append(a.elements, newMember("", data[i:end]))
+			if i = skipSpace(data, end); i < len(data) && data[i] == ',' {
+				i = skipSpace(data, i+1)
+			}
+		}
+	})
+}
+
+// newMember creates a member named name with the raw JSON value raw.
+// Creates the [Object] or [Array] for containers so that they index their
+// own values only once.
+func newMember(name string, raw []byte) member {
+	switch raw[0] {
+	case '{':
+		return member{name: name, val: &Object{raw: raw}}
+	case '[':
+		return member{name: name, val: &Array{raw: raw}}
+	default:
+		return member{name: name, raw: raw}
+	}
+}
+
+// value returns the value of m, decoding scalars. Returns nil for invalid
+// scalars.
+func (m *member) value() any {
+	if m.raw == nil {
+		return m.val
+	}
+	if val, ok := value(m.raw); ok {
+		return val
+	}
+	return nil
+}
+
+// value decodes raw, a single JSON value. Returns false if raw is not a
+// valid JSON value.
+func value(raw []byte) (any, bool) {
+	switch raw[0] {
+	case '{':
+		return &Object{raw: raw}, true
+	case '[':
+		return &Array{raw: raw}, true
+	case '"':
+		return decodeString(raw)
+	case 't':
+		return true, string(raw) == "true"
+	case 'f':
+		return false, string(raw) == "false"
+	case 'n':
+		return nil, string(raw) == "null"
+	default:
+		if !isNumber(raw) {
+			return nil, false
+		}
+		return json.Number(string(raw)), true
+	}
+}
+
+// isNumber returns true if raw is a valid JSON number.
+func isNumber(raw []byte) bool {
+	i := 0
+	if i < len(raw) && raw[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(raw) && raw[i] == '0':
+		i++
+	case i < len(raw) && isDigit(raw[i]):
+		i = skipDigits(raw, i)
+	default:
+		return false
+	}
+	if i < len(raw) && raw[i] == '.' {
+		if i++; i == len(raw) || !isDigit(raw[i]) {
+			return false
+		}
+		i = skipDigits(raw, i)
+	}
+	if i < len(raw) && (raw[i] == 'e' || raw[i] == 'E') {
+		if i++; i < len(raw) && (raw[i] == '+' || raw[i] == '-') {
+			i++
+		}
+		if i == len(raw) || !isDigit(raw[i]) {
+			return false
+		}
+		i = skipDigits(raw, i)
+	}
+	return i == len(raw)
+}
+
+// isDigit returns true if c is an ASCII digit.
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// skipDigits returns the offset of the first byte at or after i in raw that
+// is not an ASCII digit.
+func skipDigits(raw []byte, i int) int {
+	for i < len(raw) && isDigit(raw[i]) {
+		i++
+	}
+	return i
+}
+
+// isSpace returns true if c is JSON whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// decodeString decodes raw, a JSON string including its quotation marks.
+func decodeString(raw []byte) (string, bool) {
+	if bytes.IndexByte(raw, '\\') < 0 && utf8.Valid(raw) {
+		return string(raw[1 : len(raw)-1]), true
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return "", false
+	}
+	return str, true
+}
+
+// skipSpace returns the offset of the first byte at or after i in data that
+// is not JSON whitespace.
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+	return i
+}
+
+// skipString returns the offset just past the JSON string that starts at i
+// in data. Returns -1 if no string starts at i or it is not terminated.
+func skipString(data []byte, i int) int {
+	if i >= len(data) || data[i] != '"' {
+		return -1
+	}
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// skipValue returns the offset just past the JSON value that starts at i
+// in data, matching the brackets of objects and arrays without validating
+// their contents. Returns -1 if no value starts at i or it is not
+// terminated.
+func skipValue(data []byte, i int) int {
+	if i >= len(data) {
+		return -1
+	}
+	switch data[i] {
+	case '"':
+		return skipString(data, i)
+	case '{', '[':
+		depth := 0
+		for ; i < len(data); i++ {
+			switch data[i] {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				if depth--; depth == 0 {
+					return i + 1
+				}
+			case '"':
+				if i = skipString(data, i); i < 0 {
+					return -1
+				}
+				i--
+			}
+		}
+		return -1
+	case '}', ']', ',', ':':
+		return -1
+	default:
+		start := i
+		for ; i < len(data); i++ {
+			switch data[i] {
+			case ',', '}', ']', ':', ' ', '\t', '\n', '\r', '"', '{', '[':
+				if i == start {
+					return -1
+				}
+				return i
+			}
+		}
+		return i
+	}
+}
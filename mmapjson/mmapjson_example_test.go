@@ -0,0 +1,25 @@
+package mmapjson_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/mmapjson"
+)
+
+// Execute a JSONPath query over lazily parsed JSON.
+func ExampleParse() {
+	root, err := mmapjson.Parse([]byte(`{"friends": [
+	  {"first": "Dale", "age": 44},
+	  {"first": "Roger", "age": 68},
+	  {"first": "Jane", "age": 47}
+	]}`))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	path := jsonpath.MustParse(`$.friends[?@.age > 45].first`)
+	fmt.Println(path.Select(root))
+	// Output: [Roger Jane]
+}
@@ -0,0 +1,247 @@
+package mmapjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+)
+
+const doc = `{
+  "name": {"first": "Tom", "last": "Anderson"},
+  "age": 37,
+  "children": ["Sara", "Alex", "Jack"],
+  "fav.movie": "Deer Hunter",
+  "esc": "tab\there \"quoted\" über",
+  "über": "unicode",
+  "friends": [
+    {"first": "Dale", "last": "Murphy", "age": 44, "active": true},
+    {"first": "Roger", "last": "Craig", "age": 68, "active": false},
+    {"first": "Jane", "last": "Murphy", "age": 4.7e1, "nets": null}
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		json string
+		exp  any
+		err  string
+	}{
+		{"string", ` "hi" `, "hi", ""},
+		{"escaped", `"a\nbé"`, "a\nbé", ""},
+		{"integer", `42`, json.Number("42"), ""},
+		{"float", `-1.5e-3`, json.Number("-1.5e-3"), ""},
+		{"true", `true`, true, ""},
+		{"false", "false\n", false, ""},
+		{"null", `null`, nil, ""},
+		{"empty", ``, nil, "mmapjson: invalid JSON: no value"},
+		{"space", " \n\t", nil, "mmapjson: invalid JSON: no value"},
+		{"close", ` ]`, nil, "mmapjson: invalid JSON at offset 1"},
+		{"bad_literal", `tru`, nil, "mmapjson: invalid JSON at offset 0"},
+		{"bad_number", `01`, nil, "mmapjson: invalid JSON at offset 0"},
+		{"hex", `0x1p-2`, nil, "mmapjson: invalid JSON at offset 0"},
+		{"unterminated_string", `"hi`, nil, "mmapjson: invalid JSON at offset 0"},
+		{"truncated_object", `{"a": [1, 2`, nil, "mmapjson: invalid JSON: unterminated value at offset 0"},
+		{"mismatched", `[1, 2}`, nil, "mmapjson: invalid JSON: unterminated value at offset 0"},
+		{"bracket", `{`, nil, "mmapjson: invalid JSON: unterminated value at offset 0"},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			val, err := Parse([]byte(tc.json))
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				require.ErrorIs(t, err, ErrSyntax)
+				assert.Nil(t, val)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.exp, val)
+		})
+	}
+}
+
+func TestContainers(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	val, err := Parse([]byte(" " + doc + "\n"))
+	r.NoError(err)
+	obj, ok := val.(*Object)
+	r.True(ok)
+	a.Equal(doc, string(obj.Bytes()))
+	a.Equal(7, obj.Len())
+
+	v, ok := obj.Get("age")
+	a.True(ok)
+	a.Equal(json.Number("37"), v)
+	v, ok = obj.Get("esc")
+	a.True(ok)
+	a.Equal("tab\there \"quoted\" über", v)
+	_, ok = obj.Get("nope")
+	a.False(ok)
+
+	names := []string{}
+	for name := range obj.Iterate() {
+		names = append(names, name)
+	}
+	a.Equal([]string{"name", "age", "children", "fav.movie", "esc", "über", "friends"}, names)
+
+	v, _ = obj.Get("children")
+	arr, ok := v.(*Array)
+	r.True(ok)
+	a.Equal(3, arr.Len())
+	v, ok = arr.Get(2)
+	a.True(ok)
+	a.Equal("Jack", v)
+	_, ok = arr.Get(3)
+	a.False(ok)
+	_, ok = arr.Get(-1)
+	a.False(ok)
+	a.Equal(`["Sara", "Alex", "Jack"]`, string(arr.Bytes()))
+
+	// Containers index their values once.
+	v2, _ := obj.Get("children")
+	a.Same(arr, v2)
+
+	// Decode the document.
+	js, err := json.Marshal(obj)
+	r.NoError(err)
+	var exp, got any
+	r.NoError(json.Unmarshal([]byte(doc), &exp))
+	r.NoError(json.Unmarshal(js, &got))
+	a.Equal(exp, got)
+
+	// Index large objects by name, returning the first duplicate.
+	members := make([]string, 0, 30)
+	for i := range 30 {
+		members = append(members, fmt.Sprintf(`"k%d": %d`, i%25, i))
+	}
+	val, err = Parse([]byte("{" + strings.Join(members, ",") + "}"))
+	r.NoError(err)
+	obj, ok = val.(*Object)
+	r.True(ok)
+	a.Equal(30, obj.Len())
+	r.NotNil(obj.names)
+	v, ok = obj.Get("k3")
+	a.True(ok)
+	a.Equal(json.Number("3"), v)
+	_, ok = obj.Get("k25")
+	a.False(ok)
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+	root, err := Parse([]byte(doc))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  []any
+	}{
+		{"name", `$.name.last`, []any{"Anderson"}},
+		{"number", `$.age`, []any{json.Number("37")}},
+		{"index", `$.children[1]`, []any{"Alex"}},
+		{"negative_index", `$.children[-1]`, []any{"Jack"}},
+		{"slice", `$.children[::-1]`, []any{"Jack", "Alex", "Sara"}},
+		{"dotted_name", `$["fav.movie"]`, []any{"Deer Hunter"}},
+		{"unicode", `$.über`, []any{"unicode"}},
+		{"filter", `$.friends[?@.age > 45].first`, []any{"Roger", "Jane"}},
+		{"filter_string", `$.friends[?@.last == "Murphy"].first`, []any{"Dale", "Jane"}},
+		{"filter_bool", `$.friends[?@.active == true].first`, []any{"Dale"}},
+		{"filter_null", `$.friends[?@.nets == null].first`, []any{"Jane"}},
+		{"exists", `$.friends[?@.nets].first`, []any{"Jane"}},
+		{"length", `$.friends[?length(@.first) == 5].first`, []any{"Roger"}},
+		{"match", `$.friends[?match(@.last, "M.*")].first`, []any{"Dale", "Jane"}},
+		{"descendant", `$..last`, []any{"Anderson", "Murphy", "Craig", "Murphy"}},
+		{"missing", `$.nope`, []any{}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, []any(jsonpath.MustParse(tc.path).Select(root)))
+		})
+	}
+
+	// Select containers and their normalized paths.
+	nodes := jsonpath.MustParse(`$.friends[?@.age < 45]`).SelectLocated(root)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, `$['friends'][0]`, nodes[0].Path.String())
+	js, err := json.Marshal(nodes[0].Node)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"first": "Dale", "last": "Murphy", "age": 44, "active": true}`, string(js))
+}
+
+func TestMalformed(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		json string
+		path string
+		exp  []any
+	}{
+		{"bad_member_value", `{"a": 1, "b": tru, "c": 3}`, `$.*`, []any{json.Number("1"), nil, json.Number("3")}},
+		{"missing_colon", `{"a": 1, "b" 2, "c": 3}`, `$.*`, []any{json.Number("1")}},
+		{"bad_name", `{"a": 1, b: 2}`, `$.*`, []any{json.Number("1")}},
+		{"unterminated_member", `{"a": [1, "b": 2}`, `$.a[*]`, []any{json.Number("1"), "b"}},
+		{"bad_escape", `{"a": 1, "\x": 2}`, `$.*`, []any{json.Number("1")}},
+		{"bad_element", `[1, ], 3]`, `$[*]`, []any{json.Number("1")}},
+		{"unterminated_nested", `[[1, 2, [3]`, `$..*`, []any{}},
+		{"bad_string", `["a\qb", "c"]`, `$[*]`, []any{nil, "c"}},
+		{"no_commas", `[1 2 3]`, `$[*]`, []any{json.Number("1"), json.Number("2"), json.Number("3")}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			root, err := Parse([]byte(tc.json))
+			require.NoError(t, err)
+			assert.Equal(t, tc.exp, []any(jsonpath.MustParse(tc.path).Select(root)))
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	a := assert.New(t)
+	r := require.New(t)
+
+	name := filepath.Join(dir, "doc.json")
+	r.NoError(os.WriteFile(name, []byte(doc+"\n"), 0o600))
+	f, err := Open(name)
+	r.NoError(err)
+	a.Equal(doc+"\n", string(f.Bytes()))
+	a.Equal(
+		[]any{"Roger", "Jane"},
+		[]any(jsonpath.MustParse(`$.friends[?@.age > 45].first`).Select(f.Value())),
+	)
+	r.NoError(f.Close())
+	a.Nil(f.Value())
+	a.Nil(f.Bytes())
+	r.NoError(f.Close())
+
+	// Missing file.
+	_, err = Open(filepath.Join(dir, "nope.json"))
+	r.ErrorIs(err, os.ErrNotExist)
+
+	// Empty file.
+	empty := filepath.Join(dir, "empty.json")
+	r.NoError(os.WriteFile(empty, nil, 0o600))
+	_, err = Open(empty)
+	r.ErrorIs(err, ErrSyntax)
+
+	// Truncated file.
+	truncated := filepath.Join(dir, "truncated.json")
+	r.NoError(os.WriteFile(truncated, []byte(doc[:50]), 0o600))
+	_, err = Open(truncated)
+	r.ErrorIs(err, ErrSyntax)
+}
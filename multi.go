@@ -0,0 +1,116 @@
+package jsonpath
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// SelectMany applies p to each document yielded by docs and returns an
+// iterator over the selected nodes, paired with the zero-based index of the
+// document from which they were selected. Each [spec.LocatedNode] contains
+// the node and its normalized path within its document. Use
+// [NDJSONReader.Documents] to select from newline-delimited JSON. Like
+// [Path.SelectLocated], it selects no nodes from documents for which
+// evaluation aborts, as when it exceeds the limits configured by
+// [WithMaxNodes] or [WithTimeout], and logs the error to the logger
+// configured by [WithLogger], if any.
+func (p *Path) SelectMany(docs iter.Seq[any]) iter.Seq2[int, *spec.LocatedNode] {
+	return func(yield func(int, *spec.LocatedNode) bool) {
+		idx := 0
+		for doc := range docs {
+			for _, node := range p.q.SelectLocatedWith(nil, doc, spec.Normalized(), p.opts) {
+				if !yield(idx, node) {
+					return
+				}
+			}
+			idx++
+		}
+	}
+}
+
+// SelectChan returns a channel that receives the nodes that p selects from
+// input as [spec.LocatedNode] values, in the same order as
+// [Path.SelectLocated]. A goroutine sends each node as soon as traversal
+// finds it, and the channel is unbuffered, so traversal proceeds only as
+// fast as the receiver consumes the nodes. Useful for pipelines that
+// process each node concurrently with the traversal. See
+// [spec.PathQuery.SelectLocatedSeq] for details.
+//
+// The channel closes once traversal completes, ctx is canceled, or
+// evaluation aborts, as when it exceeds the limits configured by
+// [WithMaxNodes] or [WithTimeout] or encounters a soft failure in a path
+// configured by [WithStrict]. Once the channel closes, the returned
+// function returns the error that stopped traversal, such as an
+// [ErrBudgetExceeded] error or the cause of ctx's cancellation, or nil if
+// the results are complete. The logger configured by [WithLogger], if any,
+// also receives a warning when exceeded limits truncate the results.
+// Traversal never descends in parallel.
+func (p *Path) SelectChan(ctx context.Context, input any) (<-chan *spec.LocatedNode, func() error) {
+	ch := make(chan *spec.LocatedNode)
+	var err error
+	go func() {
+		defer close(ch)
+		for node, e := range p.q.SelectLocatedSeq(ctx, nil, input, spec.Normalized(), p.opts) {
+			if e != nil {
+				if p.opts.Logger != nil && errors.Is(e, ErrBudgetExceeded) {
+					p.opts.Logger.Warn("jsonpath: results truncated", "path", p.String(), "error", e)
+				}
+				err = e
+				return
+			}
+			select {
+			case ch <- node:
+			case <-ctx.Done():
+				err = context.Cause(ctx)
+				return
+			}
+		}
+	}()
+	// Closing ch happens before receiving its zero value, so the receiver
+	// may read err once ch closes.
+	return ch, func() error { return err }
+}
+
+// NDJSONReader reads a sequence of JSON documents, such as newline-delimited
+// JSON (NDJSON) or JSON Lines, from an [io.Reader].
+type NDJSONReader struct {
+	dec *json.Decoder
+	err error
+}
+
+// NewNDJSONReader creates an [NDJSONReader] that reads from r.
+func NewNDJSONReader(r io.Reader) *NDJSONReader {
+	return &NDJSONReader{dec: json.NewDecoder(r)}
+}
+
+// UseNumber causes the reader to decode numbers into [json.Number] values
+// instead of float64.
+func (r *NDJSONReader) UseNumber() { r.dec.UseNumber() }
+
+// Documents returns an iterator over the documents in r. It stops at the end
+// of the input or at the first error, which [NDJSONReader.Err] then returns.
+func (r *NDJSONReader) Documents() iter.Seq[any] {
+	return func(yield func(any) bool) {
+		for r.err == nil {
+			var doc any
+			if err := r.dec.Decode(&doc); err != nil {
+				if !errors.Is(err, io.EOF) {
+					r.err = err
+				}
+				return
+			}
+			if !yield(doc) {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the first error encountered while reading documents, if any.
+// Returns nil at the end of the input.
+func (r *NDJSONReader) Err() error { return r.err }
@@ -0,0 +1,214 @@
+package jsonpath
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestSelectMany(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	docs := slices.Values([]any{
+		map[string]any{"level": "info", "msg": "a"},
+		map[string]any{"level": "error", "msg": "b"},
+		[]any{1, 2},
+		map[string]any{"level": "error", "msg": "c"},
+	})
+
+	type result struct {
+		idx  int
+		node *spec.LocatedNode
+	}
+	collect := func(p *Path) []result {
+		res := []result{}
+		for idx, node := range p.SelectMany(docs) {
+			res = append(res, result{idx, node})
+		}
+		return res
+	}
+
+	a.Equal([]result{
+		{0, &spec.LocatedNode{Path: norm("msg"), Node: "a"}},
+		{1, &spec.LocatedNode{Path: norm("msg"), Node: "b"}},
+		{3, &spec.LocatedNode{Path: norm("msg"), Node: "c"}},
+	}, collect(MustParse(`$.msg`)))
+
+	a.Equal([]result{
+		{1, &spec.LocatedNode{Path: norm("level"), Node: "error"}},
+		{3, &spec.LocatedNode{Path: norm("level"), Node: "error"}},
+	}, collect(MustParse(`$[?@ == 'error']`)))
+
+	a.Equal([]result{
+		{2, &spec.LocatedNode{Path: norm(1), Node: 2}},
+	}, collect(MustParse(`$[1]`)))
+
+	a.Empty(collect(MustParse(`$.nope`)))
+
+	// Stop early.
+	n := 0
+	for range MustParse(`$.*`).SelectMany(docs) {
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	a.Equal(3, n)
+}
+
+func TestSelectChan(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := make([]any, 1000)
+	for i := range input {
+		input[i] = map[string]any{"id": i, "tags": []any{"x", "y"}}
+	}
+	path := MustParse(`$..id`)
+
+	// Should receive all nodes in order.
+	got := LocatedNodeList{}
+	ch, errFunc := path.SelectChan(context.Background(), input)
+	for node := range ch {
+		got = append(got, node)
+	}
+	a.Equal(path.SelectLocated(input), got)
+	a.NoError(errFunc())
+
+	// Should stop on cancellation.
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, errFunc = path.SelectChan(ctx, input)
+	a.Equal(&spec.LocatedNode{Path: spec.Normalized(spec.Index(0), spec.Name("id")), Node: 0}, <-ch)
+	cancel()
+	count := 0
+	for range ch {
+		count++
+	}
+	a.Less(count, len(input))
+	a.ErrorIs(errFunc(), context.Canceled)
+
+	// Should stop when exceeding the budget.
+	path = NewParser(WithMaxNodes(100)).MustParse(`$..id`)
+	count = 0
+	ch, errFunc = path.SelectChan(context.Background(), input)
+	for range ch {
+		count++
+	}
+	a.Positive(count)
+	a.Less(count, 100)
+	a.ErrorIs(errFunc(), ErrBudgetExceeded)
+
+	// Should stop on strict failures.
+	path = NewParser(WithStrict()).MustParse(`$[?@.id > "x"]`)
+	count = 0
+	ch, errFunc = path.SelectChan(context.Background(), input)
+	for range ch {
+		count++
+	}
+	a.Zero(count)
+	a.ErrorIs(errFunc(), ErrEvaluation)
+
+	// Should log truncation.
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	path = NewParser(WithMaxNodes(100), WithLogger(logger)).MustParse(`$..id`)
+	count = 0
+	ch, errFunc = path.SelectChan(context.Background(), input)
+	for range ch {
+		count++
+	}
+	a.Less(count, 100)
+	a.ErrorIs(errFunc(), ErrBudgetExceeded)
+	a.Contains(buf.String(), `level=WARN msg="jsonpath: results truncated" path="$..[\"id\"]" error="evaluation budget exceeded: visited more than 100 nodes"`)
+}
+
+func TestNDJSONReader(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test   string
+		input  string
+		number bool
+		exp    []any
+		err    string
+	}{
+		{
+			test:  "empty",
+			input: "",
+			exp:   []any{},
+		},
+		{
+			test:  "lines",
+			input: "{\"x\": 1}\n{\"x\": 2}\n[3]\n",
+			exp:   []any{map[string]any{"x": 1.0}, map[string]any{"x": 2.0}, []any{3.0}},
+		},
+		{
+			test:  "no_trailing_newline",
+			input: "1\n\"two\"\nnull",
+			exp:   []any{1.0, "two", nil},
+		},
+		{
+			test:   "use_number",
+			input:  "12345678901234567890\n",
+			number: true,
+			exp:    []any{json.Number("12345678901234567890")},
+		},
+		{
+			test:  "error",
+			input: "{\"x\": 1}\n{\"x\": \n",
+			exp:   []any{map[string]any{"x": 1.0}},
+			err:   "unexpected EOF",
+		},
+		{
+			test:  "syntax_error",
+			input: "true\n{nope}\n3\n",
+			exp:   []any{true},
+			err:   "invalid character 'n' looking for beginning of object key string",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			r := NewNDJSONReader(strings.NewReader(tc.input))
+			if tc.number {
+				r.UseNumber()
+			}
+			docs := []any{}
+			for doc := range r.Documents() {
+				docs = append(docs, doc)
+			}
+			a.Equal(tc.exp, docs)
+			if tc.err == "" {
+				require.NoError(t, r.Err())
+			} else {
+				require.EqualError(t, r.Err(), tc.err)
+				// Subsequent iteration yields nothing.
+				for range r.Documents() {
+					t.Fatal("unexpected document")
+				}
+			}
+		})
+	}
+
+	t.Run("break", func(t *testing.T) {
+		t.Parallel()
+		r := NewNDJSONReader(strings.NewReader("1\n2\n3\n"))
+		for doc := range r.Documents() {
+			assert.Equal(t, 1.0, doc)
+			break
+		}
+		docs := slices.Collect(r.Documents())
+		assert.Equal(t, []any{2.0, 3.0}, docs)
+		assert.NoError(t, r.Err())
+	})
+}
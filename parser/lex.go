@@ -50,6 +50,9 @@ const (
 	// Token literals.
 	nullByte = 0x00
 
+	// lowSurrogate is the first low surrogate code point.
+	lowSurrogate = 0xdc00
+
 	// blanks selects blank space characters.
 	blanks = uint64(1<<'\t' | 1<<'\n' | 1<<'\r' | 1<<' ')
 )
@@ -87,7 +90,7 @@ func (tok token) err() error {
 	if tok.tok != invalid {
 		return nil
 	}
-	return fmt.Errorf("%w: %v at %v", ErrPathParse, tok.val, tok.pos)
+	return newParseError(ErrSyntax, tok.pos, tok.val, fmt.Sprintf("%v: %v at %v", ErrPathParse, tok.val, tok.pos))
 }
 
 // errToken creates and returns an error token.
@@ -122,11 +125,15 @@ type lexer struct {
 
 	// Last scanned token.
 	prev token
+
+	// lenientNumbers enables the scanning of numbers with leading zeros and
+	// plus signs.
+	lenientNumbers bool
 }
 
 // newLexer creates a new lexer for the given input.
 func newLexer(buf string) *lexer {
-	lex := lexer{buf, -1, 0, 0, token{}}
+	lex := lexer{buf: buf, r: -1}
 
 	// Prime the lexer by calling .next
 	lex.next()
@@ -138,6 +145,8 @@ func (lex *lexer) scan() token {
 	switch {
 	case lex.r < 0:
 		lex.prev = token{eof, "", lex.rPos}
+	case lex.invalidUTF8():
+		lex.prev = lex.errToken(lex.rPos, "invalid UTF-8 encoding")
 	case lex.r == '$':
 		if isIdentRune(lex.peek(), 0) {
 			lex.prev = lex.scanIdentifier()
@@ -149,6 +158,12 @@ func (lex *lexer) scan() token {
 		lex.prev = lex.scanIdentifier()
 	case isDigit(lex.r) || lex.r == '-':
 		lex.prev = lex.scanNumber()
+	case lex.r == '+' && lex.lenientNumbers && isDigit(lex.peek()):
+		// Skip the plus sign, but report the position of the number from it.
+		pos := lex.rPos
+		lex.next()
+		lex.prev = lex.scanNumber()
+		lex.prev.pos = pos
 	case lex.r == '"' || lex.r == '\'':
 		lex.prev = lex.scanString()
 	case isBlankSpace(lex.r):
@@ -182,6 +197,12 @@ func (lex *lexer) next() rune {
 	return lex.r
 }
 
+// invalidUTF8 returns true if lex.r is [utf8.RuneError] because the input
+// at its position is not valid UTF-8, rather than an encoded U+FFFD.
+func (lex *lexer) invalidUTF8() bool {
+	return lex.r == utf8.RuneError && lex.nextPos-lex.rPos == 1
+}
+
 // peek returns the next byte in the stream (the one after lex.r).
 // Note: a single byte is peeked at - if there's a rune longer than a byte
 // there, only its first byte is returned. Returns eof if there is no next
@@ -241,6 +262,9 @@ func (lex *lexer) scanIdentifier() token {
 
 	// Scan the identifier as long as we have legit identifier runes.
 	for isIdentRune(lex.r, 1) {
+		if lex.invalidUTF8() {
+			return lex.errToken(lex.rPos, "invalid UTF-8 encoding")
+		}
 		buf.WriteRune(lex.r)
 		lex.next()
 	}
@@ -277,19 +301,24 @@ func isIdentRune(r rune, i int) bool {
 		(0xE000 <= r && r <= 0x10FFFF)
 }
 
-// scanNumber scans an integer or decimal number.
+// scanNumber scans an integer or decimal number. Allows leading zeros if
+// lex.lenientNumbers is true.
 func (lex *lexer) scanNumber() token {
 	startPos := lex.rPos
 
 	// Start with integer.
 	switch lex.r {
 	case '0':
-		next := lex.next()
-		if next != '.' && next != 'e' {
-			if isDigit(next) {
+		switch next := lex.next(); {
+		case isDigit(next):
+			if !lex.lenientNumbers {
 				// No leading zeros for integers.
 				return lex.errToken(startPos, "invalid number literal")
 			}
+			for isDigit(lex.r) {
+				lex.next()
+			}
+		case next != '.' && next != 'e' && next != 'E':
 			// Standalone zero.
 			return token{integer, "0", startPos}
 		}
@@ -298,7 +327,7 @@ func (lex *lexer) scanNumber() token {
 		next := lex.next()
 		switch {
 		case next == '0':
-			if isDigit(lex.peek()) {
+			if isDigit(lex.peek()) && !lex.lenientNumbers {
 				// No leading zeros.
 				return lex.errToken(startPos, "invalid number literal")
 			}
@@ -366,7 +395,12 @@ func (lex *lexer) scanExponent(startPos int) token {
 }
 
 // scanString scans and parses a single- or double-quoted JavaScript string.
-// Token.Val contains the parsed value. Returns an error token on error.
+// Token.Val contains the parsed value. Returns an error token on error,
+// including for escapes and characters disallowed by the string literal
+// grammar of [RFC 9535 Section 2.3.1.1]: invalid escapes, unpaired
+// surrogates, unescaped control characters, and invalid UTF-8.
+//
+// [RFC 9535 Section 2.3.1.1]: https://www.rfc-editor.org/rfc/rfc9535.html#section-2.3.1.1
 func (lex *lexer) scanString() token {
 	startPos := lex.rPos
 	q := lex.r
@@ -374,20 +408,24 @@ func (lex *lexer) scanString() token {
 	buf := new(strings.Builder)
 
 NEXT:
-	for lex.r > 0 {
+	for lex.r >= 0 {
 		switch {
+		case lex.invalidUTF8():
+			return lex.errToken(lex.rPos, "invalid UTF-8 encoding")
 		case isUnescaped(lex.r, q):
 			// Regular character.
 			buf.WriteRune(lex.r)
 			lex.next()
 		case lex.r == '\\':
-			if !lex.writeEscape(q, buf) {
+			if msg := lex.writeEscape(q, buf); msg != "" {
 				pos := lex.rPos
 				lex.next()
-				return lex.errToken(pos, "invalid escape after backslash")
+				return lex.errToken(pos, msg)
 			}
+		case lex.r < ' ':
+			return lex.errToken(lex.rPos, fmt.Sprintf("unescaped control character %U", lex.r))
 		default:
-			// End of string or buffer.
+			// End of string.
 			break NEXT
 		}
 	}
@@ -402,27 +440,31 @@ NEXT:
 
 // writeEscape handles string escapes in the context of a string. Set q to '
 // or " to indicate a single or double-quoted string context, respectively,
-// and -1 for an identifier. lex.r should be set to the rune following the
-// backslash that initiated the escape.
-func (lex *lexer) writeEscape(q rune, buf *strings.Builder) bool {
+// and -1 for an identifier. lex.r should be set to the backslash that
+// initiates the escape. Returns an error message, with lex.rPos set to the
+// position of the error, if the escape is invalid, and an empty string if
+// it is valid.
+func (lex *lexer) writeEscape(q rune, buf *strings.Builder) string {
 	// Starting an escape sequence.
 	next := lex.next()
 	if r := unescape(next, q); r > 0 {
 		// A single-character escape.
 		buf.WriteRune(r)
 		lex.next()
-		return true
+		return ""
 	}
 
-	if next == '\u0075' { // uXXXX U+XXXX
-		// \uXXXX unicode escape.
-		if r := lex.parseUnicode(); r >= 0 {
-			buf.WriteRune(r)
-			lex.next()
-			return true
-		}
+	if next != '\u0075' { // uXXXX U+XXXX
+		return "invalid escape after backslash"
 	}
-	return false
+
+	// \uXXXX unicode escape.
+	r, msg := lex.parseUnicode()
+	if msg == "" {
+		buf.WriteRune(r)
+		lex.next()
+	}
+	return msg
 }
 
 // Returns true if r is a regular, non-escaped character. Pass q as " or ' to
@@ -467,46 +509,52 @@ func unescape(r rune, q rune) rune {
 	}
 }
 
-// Parses a \u unicode escape sequence. Returns invalid (-1) on error.
-func (lex *lexer) parseUnicode() rune {
+// parseUnicode parses a \u unicode escape sequence, including a surrogate
+// pair of escapes. lex.r should be set to the u that starts the escape.
+// Returns an error message, with lex.rPos set to the position of the error,
+// if the escape is invalid.
+func (lex *lexer) parseUnicode() (rune, string) {
 	if !isHexDigit(lex.next()) {
-		return rune(invalid)
+		return invalid, "invalid escape after backslash"
 	}
 
-	if lex.r != 'd' && lex.r != 'D' {
-		// non-surrogate DIGIT / "A"/"B"/"C" / "E"/"F"
-		return lex.scanUnicode()
+	startPos := lex.rPos
+	r := lex.scanUnicode()
+	switch {
+	case r < nullByte:
+		return invalid, "invalid escape after backslash"
+	case utf16.IsSurrogate(r) && r >= lowSurrogate:
+		// low-surrogate "D" ("C"/"D"/"E"/"F") 2HEXDIG without high-surrogate
+		lex.rPos = startPos
+		return invalid, "unpaired low surrogate in unicode escape"
+	case !utf16.IsSurrogate(r):
+		// non-surrogate
+		return r, ""
 	}
 
-	switch lex.peek() {
-	case '0', '1', '2', '3', '4', '5', '6', '7':
-		// non-surrogate "D" %x30-37 2HEXDIG
-		return lex.scanUnicode()
+	// high-surrogate "D" ("8"/"9"/"A"/"B") 2HEXDIG must be followed by \u
+	if lex.peek() != '\\' {
+		lex.rPos = startPos
+		return invalid, "unpaired high surrogate in unicode escape"
 	}
-
-	// potential high-surrogate "D" ("8"/"9"/"A"/"B") 2HEXDIG
-	high := lex.scanUnicode()
-
-	// Must be followed by \u
-	if high < nullByte || lex.next() != '\\' || lex.next() != 'u' {
-		return rune(invalid)
+	lex.next()
+	if lex.next() != 'u' || !isHexDigit(lex.next()) {
+		return invalid, "invalid escape after backslash"
 	}
 
-	// potential low-surrogate "D" ("C"/"D"/"E"/"F") 2HEXDIG
-	lex.next()
+	// low-surrogate "D" ("C"/"D"/"E"/"F") 2HEXDIG
+	lowPos := lex.rPos
 	low := lex.scanUnicode()
 	if low < nullByte {
-		return rune(invalid)
+		return invalid, "invalid escape after backslash"
 	}
 
 	// Merge and return the surrogate pair, if valid.
-	if dec := utf16.DecodeRune(high, low); dec != unicode.ReplacementChar {
-		return dec
+	if dec := utf16.DecodeRune(r, low); dec != unicode.ReplacementChar {
+		return dec, ""
 	}
-	// Adjust position to start of second \u escape for error reporting.
-	lex.rPos -= 3
-
-	return rune(invalid)
+	lex.rPos = lowPos
+	return invalid, "expected low surrogate in unicode escape"
 }
 
 // scanUnicode scans the current rune plus the next four and merges them into
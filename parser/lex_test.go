@@ -126,17 +126,42 @@ func TestScanString(t *testing.T) {
 		{
 			test: "surrogate_low_not_d_dq",
 			in:   `\uD834\uED1E`,
-			tok:  token{invalid, "invalid escape after backslash", 9},
+			tok:  token{invalid, "expected low surrogate in unicode escape", 9},
 		},
 		{
 			test: "surrogate_low_not_a_f_dq",
 			in:   `\uD834\ud11E`,
-			tok:  token{invalid, "invalid escape after backslash", 9},
+			tok:  token{invalid, "expected low surrogate in unicode escape", 9},
 		},
 		{
 			test: "no_surrogate_low_dq",
 			in:   `\uD834 oops`,
-			tok:  token{invalid, "invalid escape after backslash", 7},
+			tok:  token{invalid, "unpaired high surrogate in unicode escape", 3},
+		},
+		{
+			test: "high_surrogate_at_end_dq",
+			in:   `oops \uDBFF`,
+			tok:  token{invalid, "unpaired high surrogate in unicode escape", 8},
+		},
+		{
+			test: "high_surrogate_high_surrogate_dq",
+			in:   `\uD834\uD834`,
+			tok:  token{invalid, "expected low surrogate in unicode escape", 9},
+		},
+		{
+			test: "high_surrogate_bad_escape_dq",
+			in:   `\uD834\n`,
+			tok:  token{invalid, "invalid escape after backslash", 8},
+		},
+		{
+			test: "lone_low_surrogate_dq",
+			in:   `oops \uDC00 yikes`,
+			tok:  token{invalid, "unpaired low surrogate in unicode escape", 8},
+		},
+		{
+			test: "low_surrogate_pair_dq",
+			in:   `\uDFFF\uD834`,
+			tok:  token{invalid, "unpaired low surrogate in unicode escape", 3},
 		},
 		{
 			test: "bad_escape_dq",
@@ -415,6 +440,12 @@ func TestScanNumber(t *testing.T) {
 			tok:  token{number, "0e12", 0},
 			num:  float64(0e12),
 		},
+		{
+			test: "zero_upper_exp",
+			in:   "0E12",
+			tok:  token{number, "0E12", 0},
+			num:  float64(0e12),
+		},
 		{
 			test: "numb_exp",
 			in:   "42E124",
@@ -550,6 +581,78 @@ func TestScanNumber(t *testing.T) {
 	}
 }
 
+func TestScanLenientNumber(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test   string
+		in     string
+		strict token
+		tok    token
+	}{
+		{
+			test:   "integer",
+			in:     "42",
+			strict: token{integer, "42", 0},
+			tok:    token{integer, "42", 0},
+		},
+		{
+			test:   "leading_zero",
+			in:     "032",
+			strict: token{invalid, "invalid number literal", 0},
+			tok:    token{integer, "032", 0},
+		},
+		{
+			test:   "leading_zeros_frac",
+			in:     "001.5",
+			strict: token{invalid, "invalid number literal", 0},
+			tok:    token{number, "001.5", 0},
+		},
+		{
+			test:   "neg_leading_zero",
+			in:     "-05e2",
+			strict: token{invalid, "invalid number literal", 0},
+			tok:    token{number, "-05e2", 0},
+		},
+		{
+			test:   "plus",
+			in:     "+42",
+			strict: token{'+', "", 0},
+			tok:    token{integer, "42", 0},
+		},
+		{
+			test:   "plus_leading_zero",
+			in:     "+01.5",
+			strict: token{'+', "", 0},
+			tok:    token{number, "01.5", 0},
+		},
+		{
+			test:   "plus_minus",
+			in:     "+-1",
+			strict: token{'+', "", 0},
+			tok:    token{'+', "", 0},
+		},
+		{
+			test:   "plus_invalid",
+			in:     "+1.x",
+			strict: token{'+', "", 0},
+			tok:    token{invalid, "invalid number literal", 0},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			lex := newLexer(tc.in)
+			a.Equal(tc.strict, lex.scan())
+
+			lex = newLexer(tc.in)
+			lex.lenientNumbers = true
+			a.Equal(tc.tok, lex.scan())
+		})
+	}
+}
+
 func TestScanBlankSpace(t *testing.T) {
 	t.Parallel()
 
@@ -743,7 +846,65 @@ func TestScanTokens(t *testing.T) {
 			tokens: []token{
 				{number, "98.6", 0},
 				{blankSpace, " ", 4},
-				{invalid, "invalid escape after backslash", 17},
+				{invalid, "expected low surrogate in unicode escape", 17},
+			},
+		},
+		{
+			test: "string_control_character",
+			in:   "'foo\nbar'",
+			tokens: []token{
+				{invalid, "unescaped control character U+000A", 4},
+			},
+		},
+		{
+			test: "string_null_byte",
+			in:   "\"foo\x00\"",
+			tokens: []token{
+				{invalid, "unescaped control character U+0000", 4},
+			},
+		},
+		{
+			test: "string_unit_separator",
+			in:   "[\"\x1f\"]",
+			tokens: []token{
+				{'[', "", 0},
+				{invalid, "unescaped control character U+001F", 2},
+			},
+		},
+		{
+			test: "string_delete",
+			in:   "'\x7f'",
+			tokens: []token{
+				{goString, "\x7f", 0},
+			},
+		},
+		{
+			test: "string_invalid_utf8",
+			in:   "'ab\xffc'",
+			tokens: []token{
+				{invalid, "invalid UTF-8 encoding", 3},
+			},
+		},
+		{
+			test: "string_replacement_char",
+			in:   "'ab\ufffdc'",
+			tokens: []token{
+				{goString, "ab\ufffdc", 0},
+			},
+		},
+		{
+			test: "identifier_invalid_utf8",
+			in:   "ab\xc3",
+			tokens: []token{
+				{invalid, "invalid UTF-8 encoding", 2},
+			},
+		},
+		{
+			test: "invalid_utf8",
+			in:   "[\xc3",
+			tokens: []token{
+				{'[', "", 0},
+				{invalid, "invalid UTF-8 encoding", 1},
 			},
 		},
 	} {
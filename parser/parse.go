@@ -4,19 +4,145 @@
 package parser
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/theory/jsonpath/registry"
 	"github.com/theory/jsonpath/spec"
 )
 
-// ErrPathParse errors are returned for path parse errors.
+// ErrPathParse errors are returned for path parse errors. Each also wraps
+// one of [ErrSyntax], [ErrType], [ErrNotSingular], [ErrUnknownFunction],
+// [ErrDisabledFunction], or [ErrLimitExceeded], which identify the kind of
+// parse error.
 var ErrPathParse = errors.New("jsonpath")
 
-func makeError(tok token, msg string) error {
-	return fmt.Errorf("%w: %v at position %v", ErrPathParse, msg, tok.pos+1)
+var (
+	// ErrSyntax errors are returned for paths that violate the JSONPath
+	// grammar, such as unexpected tokens and invalid literals.
+	ErrSyntax = errors.New("syntax error")
+
+	// ErrType errors are returned for expressions that are not well-typed,
+	// such as comparisons to the results of logical functions and invalid
+	// function arguments.
+	ErrType = errors.New("type error")
+
+	// ErrNotSingular errors are returned for comparisons of queries that may
+	// select more than one node.
+	ErrNotSingular = errors.New("non-singular query")
+
+	// ErrUnknownFunction errors are returned for calls to functions not
+	// found in the registry.
+	ErrUnknownFunction = errors.New("unknown function")
+
+	// ErrDisabledFunction errors are returned for calls to functions
+	// disabled by [registry.Registry.Disable].
+	ErrDisabledFunction = errors.New("disabled function")
+
+	// ErrLimitExceeded errors are returned for integers outside the range
+	// allowed by RFC 9535 or by [IJSONNumbers]. The same error identifies
+	// evaluations that exceed their budgets; see [spec.ErrLimitExceeded].
+	ErrLimitExceeded = spec.ErrLimitExceeded
+)
+
+// ParseError describes a path parse error. It wraps both [ErrPathParse]
+// and the kind of error, one of [ErrSyntax], [ErrType], [ErrNotSingular],
+// [ErrUnknownFunction], [ErrDisabledFunction], or [ErrLimitExceeded]. Use
+// [errors.As] to retrieve it from errors returned by [Parse] and
+// [ParseMode]. Its fields marshal to JSON for tools, such as query editors,
+// that highlight errors in queries.
+type ParseError struct {
+	// Code identifies the kind of error: syntax, type, not-singular,
+	// unknown-function, disabled-function, or limit-exceeded.
+	Code string `json:"code"`
+
+	// Message describes the error, without its position.
+	Message string `json:"message"`
+
+	// Start and End are the zero-based byte offsets of the start and end of
+	// the text in the path at which the error occurred, usually a single
+	// token. They are equal for errors at the end of the path.
+	Start int `json:"start"`
+	End   int `json:"end"`
+
+	// Hint suggests how to fix the error. Empty if there is no suggestion.
+	Hint string `json:"hint,omitempty"`
+
+	kind error
+	msg  string
+}
+
+// Error returns the error message.
+func (e *ParseError) Error() string { return e.msg }
+
+// Unwrap returns [ErrPathParse] and the kind of e.
+func (e *ParseError) Unwrap() []error { return []error{ErrPathParse, e.kind} }
+
+// errorCodes maps the kinds of parse errors to the values of
+// [ParseError].Code.
+//
+//nolint:gochecknoglobals
+var errorCodes = map[error]string{
+	ErrSyntax:           "syntax",
+	ErrType:             "type",
+	ErrNotSingular:      "not-singular",
+	ErrUnknownFunction:  "unknown-function",
+	ErrDisabledFunction: "disabled-function",
+	ErrLimitExceeded:    "limit-exceeded",
+}
+
+// errorHints maps the kinds of parse errors to the values of
+// [ParseError].Hint.
+//
+//nolint:gochecknoglobals
+var errorHints = map[error]string{
+	ErrNotSingular:      "use only name and index selectors in queries compared to other values",
+	ErrUnknownFunction:  "register function extensions with a registry.Registry",
+	ErrDisabledFunction: "rewrite the query without calls to disabled functions",
+	ErrLimitExceeded:    "use integers between -(2^53)+1 and (2^53)-1",
+}
+
+// newParseError creates a [ParseError] of the kind identified by kind, with
+// msg describing the error at pos and full the complete error message.
+func newParseError(kind error, pos int, msg, full string) *ParseError {
+	return &ParseError{
+		Code:    errorCodes[kind],
+		Message: msg,
+		Start:   pos,
+		End:     pos,
+		Hint:    errorHints[kind],
+		kind:    kind,
+		msg:     full,
+	}
+}
+
+// setEnd sets e.End to the end of the token at e.Start in path.
+func (e *ParseError) setEnd(path string) {
+	if e.Start >= len(path) {
+		return
+	}
+	lex := newLexer(path[e.Start:])
+	lex.scan()
+	e.End = e.Start + max(lex.rPos, 1)
+}
+
+// The I-JSON interoperable integer range, to which RFC 9535 limits indexes
+// and slice parameters.
+const (
+	minIJSONInt = -1<<53 + 1
+	maxIJSONInt = 1<<53 - 1
+)
+
+// makeError creates and returns a parse error of the kind identified by kind,
+// with msg describing the error at the position of tok.
+func makeError(kind error, tok token, msg string) error {
+	return newParseError(
+		kind, tok.pos, msg,
+		fmt.Sprintf("%v: %v at position %v", ErrPathParse, msg, tok.pos+1),
+	)
 }
 
 // unexpected creates and returns an error for an unexpected token. For
@@ -25,22 +151,99 @@ func makeError(tok token, msg string) error {
 func unexpected(tok token) error {
 	if tok.tok == invalid {
 		// Lex error message in the token value.
-		return makeError(tok, tok.val)
+		return makeError(ErrSyntax, tok, tok.val)
 	}
-	return makeError(tok, "unexpected "+tok.name())
+	return makeError(ErrSyntax, tok, "unexpected "+tok.name())
 }
 
+// Mode configures optional parsing behaviors. Combine modes with |.
+type Mode uint8
+
+const (
+	// LenientNumbers accepts number syntax disallowed by RFC 9535: leading
+	// zeros, such as 01, a leading plus sign, such as +1, and -0 as an index
+	// or slice parameter. Use to parse queries written for implementations
+	// that accept such numbers.
+	LenientNumbers Mode = 1 << iota
+
+	// IJSONNumbers rejects integer literals in filter expressions outside
+	// the [I-JSON] interoperable range [-(2^53)+1, (2^53)-1], which other
+	// implementations may not compare exactly. By default the parser
+	// preserves such integers as [json.Number] values.
+	//
+	// [I-JSON]: https://www.rfc-editor.org/rfc/rfc7493#section-2.2
+	IJSONNumbers
+)
+
 type parser struct {
-	lex *lexer
-	reg *registry.Registry
+	lex  *lexer
+	reg  *registry.Registry
+	mode Mode
+
+	// spans collects the spans of the segments of the top-level query, if
+	// not nil.
+	spans *[]Span
+}
+
+// Span identifies the text of a segment in a query string by the
+// zero-based byte offsets of its start and end, as returned by
+// [ParseSpans].
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Contains returns true if offset falls within s, including at its end,
+// as when a cursor follows the last character of the segment.
+func (s Span) Contains(offset int) bool {
+	return s.Start <= offset && offset <= s.End
 }
 
 // Parse parses path, a JSONPath query string, into a [spec.PathQuery].
-// Returns a [ErrPathParse] on parse failure.
+// Returns a [ErrPathParse] on parse failure, which is also a [*ParseError].
 func Parse(reg *registry.Registry, path string) (*spec.PathQuery, error) {
+	return ParseMode(reg, path, 0)
+}
+
+// ParseMode parses path, a JSONPath query string, into a [spec.PathQuery]
+// with the optional behaviors configured by mode. Returns a [ErrPathParse]
+// on parse failure, which is also a [*ParseError].
+func ParseMode(reg *registry.Registry, path string, mode Mode) (*spec.PathQuery, error) {
+	q, err := parseMode(reg, path, mode, nil)
+	if err != nil {
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			pe.setEnd(path)
+		}
+		return nil, err
+	}
+	return q, nil
+}
+
+// ParseSpans parses path as [ParseMode] does, and also returns the [Span]
+// of each segment of the resulting query, in the order of
+// [spec.PathQuery.Segments]. Use it to relate segments to the text that
+// defines them, as in editors that describe the segment under the cursor.
+func ParseSpans(reg *registry.Registry, path string, mode Mode) (*spec.PathQuery, []Span, error) {
+	spans := []Span{}
+	q, err := parseMode(reg, path, mode, &spans)
+	if err != nil {
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			pe.setEnd(path)
+		}
+		return nil, nil, err
+	}
+	return q, spans, nil
+}
+
+// parseMode implements [ParseMode], collecting the spans of the segments
+// of the query in spans if it's not nil.
+func parseMode(reg *registry.Registry, path string, mode Mode, spans *[]Span) (*spec.PathQuery, error) {
 	lex := newLexer(path)
+	lex.lenientNumbers = mode&LenientNumbers != 0
 	tok := lex.scan()
-	p := parser{lex, reg}
+	p := parser{lex: lex, reg: reg, mode: mode, spans: spans}
 
 	switch tok.tok {
 	case '$':
@@ -56,7 +259,10 @@ func Parse(reg *registry.Registry, path string) (*spec.PathQuery, error) {
 		return q, nil
 	case eof:
 		// The token contained nothing.
-		return nil, fmt.Errorf("%w: unexpected end of input", ErrPathParse)
+		return nil, newParseError(
+			ErrSyntax, tok.pos, "unexpected end of input",
+			ErrPathParse.Error()+": unexpected end of input",
+		)
 	default:
 		return nil, unexpected(tok)
 	}
@@ -67,7 +273,18 @@ func Parse(reg *registry.Registry, path string) (*spec.PathQuery, error) {
 func (p *parser) parseQuery(root bool) (*spec.PathQuery, error) {
 	segs := []*spec.Segment{}
 	lex := p.lex
+
+	// Record the spans of the segments of the top-level query only.
+	spans := p.spans
+	p.spans = nil
+	record := func(start int) {
+		if spans != nil {
+			*spans = append(*spans, Span{start, lex.rPos})
+		}
+	}
+
 	for {
+		start := lex.rPos
 		switch {
 		case lex.r == '[':
 			// Start of segment; scan selectors
@@ -77,6 +294,7 @@ func (p *parser) parseQuery(root bool) (*spec.PathQuery, error) {
 				return nil, err
 			}
 			segs = append(segs, spec.Child(selectors...))
+			record(start)
 		case lex.r == '.':
 			// Start of a name selector, wildcard, or descendant segment.
 			lex.scan()
@@ -88,6 +306,7 @@ func (p *parser) parseQuery(root bool) (*spec.PathQuery, error) {
 					return nil, err
 				}
 				segs = append(segs, seg)
+				record(start)
 				continue
 			}
 			// Child segment with a name or wildcard selector.
@@ -96,6 +315,7 @@ func (p *parser) parseQuery(root bool) (*spec.PathQuery, error) {
 				return nil, err
 			}
 			segs = append(segs, spec.Child(sel))
+			record(start)
 		case isBlankSpace(lex.r):
 			switch lex.peekPastBlankSpace() {
 			case '.', '[':
@@ -147,12 +367,16 @@ func (p *parser) parseDescendant() (*spec.Segment, error) {
 func makeNumErr(tok token, err error) error {
 	var numError *strconv.NumError
 	if errors.As(err, &numError) {
-		return makeError(tok, fmt.Sprintf(
+		kind := ErrSyntax
+		if errors.Is(numError.Err, strconv.ErrRange) {
+			kind = ErrLimitExceeded
+		}
+		return makeError(kind, tok, fmt.Sprintf(
 			"cannot parse %q, %v",
 			numError.Num, numError.Err.Error(),
 		))
 	}
-	return makeError(tok, err.Error())
+	return makeError(ErrSyntax, tok, err.Error())
 }
 
 // parseSelectors parses Selectors from a bracket segment. lex.r should be '['
@@ -176,14 +400,14 @@ func (p *parser) parseSelectors() ([]spec.Selector, error) {
 			// Index or slice?
 			if lex.skipBlankSpace() == ':' {
 				// Slice.
-				slice, err := parseSlice(lex, tok)
+				slice, err := p.parseSlice(tok)
 				if err != nil {
 					return nil, err
 				}
 				selectors = append(selectors, slice)
 			} else {
 				// Index.
-				idx, err := parsePathInt(tok)
+				idx, err := p.parsePathInt(tok)
 				if err != nil {
 					return nil, err
 				}
@@ -191,7 +415,7 @@ func (p *parser) parseSelectors() ([]spec.Selector, error) {
 			}
 		case ':':
 			// Slice.
-			slice, err := parseSlice(lex, tok)
+			slice, err := p.parseSlice(tok)
 			if err != nil {
 				return nil, err
 			}
@@ -220,10 +444,11 @@ func (p *parser) parseSelectors() ([]spec.Selector, error) {
 }
 
 // parsePathInt parses an integer as used in index values and steps, which must be
-// within the interval [-(253)+1, (253)-1].
-func parsePathInt(tok token) (int64, error) {
-	if tok.val == "-0" {
-		return 0, makeError(tok, fmt.Sprintf(
+// within the interval [-(253)+1, (253)-1]. Rejects -0 unless p.mode includes
+// [LenientNumbers].
+func (p *parser) parsePathInt(tok token) (int64, error) {
+	if tok.val == "-0" && p.mode&LenientNumbers == 0 {
+		return 0, makeError(ErrSyntax, tok, fmt.Sprintf(
 			"invalid integer path value %q", tok.val,
 		))
 	}
@@ -231,12 +456,8 @@ func parsePathInt(tok token) (int64, error) {
 	if err != nil {
 		return 0, makeNumErr(tok, err)
 	}
-	const (
-		minVal = -1<<53 + 1
-		maxVal = 1<<53 - 1
-	)
-	if idx > maxVal || idx < minVal {
-		return 0, makeError(tok, fmt.Sprintf(
+	if idx > maxIJSONInt || idx < minIJSONInt {
+		return 0, makeError(ErrLimitExceeded, tok, fmt.Sprintf(
 			"cannot parse %q, value out of range",
 			tok.val,
 		))
@@ -246,8 +467,9 @@ func parsePathInt(tok token) (int64, error) {
 
 // parseSlice parses a slice selector, <start>:<end>:<step>. Returns the
 // parsed SliceSelector.
-func parseSlice(lex *lexer, tok token) (spec.SliceSelector, error) {
+func (p *parser) parseSlice(tok token) (spec.SliceSelector, error) {
 	var args [3]any
+	lex := p.lex
 
 	// Parse the three parts: start, end, and step.
 	i := 0
@@ -258,7 +480,7 @@ func parseSlice(lex *lexer, tok token) (spec.SliceSelector, error) {
 			i++
 		case integer:
 			// Parse the integer.
-			num, err := parsePathInt(tok)
+			num, err := p.parsePathInt(tok)
 			if err != nil {
 				return spec.SliceSelector{}, err
 			}
@@ -282,13 +504,14 @@ func parseSlice(lex *lexer, tok token) (spec.SliceSelector, error) {
 }
 
 // parseFilter parses a [Filter] from Lex. A [Filter] consists of a single
-// [LogicalOrExpr] (logical-or-expr).
+// [LogicalOrExpr] (logical-or-expr). Folds sub-expressions composed entirely
+// of literals with [spec.LogicalOr.Fold].
 func (p *parser) parseFilter() (*spec.FilterSelector, error) {
 	lor, err := p.parseLogicalOrExpr()
 	if err != nil {
 		return nil, err
 	}
-	return spec.Filter(lor...), nil
+	return spec.Filter(lor.Fold()...), nil
 }
 
 // parseLogicalOrExpr parses a [LogicalOrExpr] from lex. A [LogicalOrExpr] is
@@ -308,7 +531,7 @@ func (p *parser) parseLogicalOrExpr() (spec.LogicalOr, error) {
 		lex.scan()
 		next := lex.scan()
 		if next.tok != '|' {
-			return nil, makeError(next, fmt.Sprintf("expected '|' but found %v", next.name()))
+			return nil, makeError(ErrSyntax, next, fmt.Sprintf("expected '|' but found %v", next.name()))
 		}
 		land, err := p.parseLogicalAndExpr()
 		if err != nil {
@@ -335,7 +558,7 @@ func (p *parser) parseLogicalAndExpr() (spec.LogicalAnd, error) {
 		lex.scan()
 		next := lex.scan()
 		if next.tok != '&' {
-			return nil, makeError(next, fmt.Sprintf("expected '&' but found %v", next.name()))
+			return nil, makeError(ErrSyntax, next, fmt.Sprintf("expected '&' but found %v", next.name()))
 		}
 		expr, err := p.parseBasicExpr()
 		if err != nil {
@@ -381,27 +604,26 @@ func (p *parser) parseBasicExpr() (spec.BasicExpr, error) {
 		return p.parseParenExpr()
 	case goString, integer, number, boolFalse, boolTrue, jsonNull:
 		// comparison-expr
-		left, err := parseLiteral(tok)
+		left, err := p.parseLiteral(tok)
 		if err != nil {
 			return nil, err
 		}
 		return p.parseComparableExpr(left)
 	case identifier:
-		if lex.r == '(' {
-			return p.parseFunctionFilterExpr(tok)
-		}
+		return p.parseFunctionFilterExpr(tok)
 	case '@', '$':
 		q, err := p.parseFilterQuery(tok)
 		if err != nil {
 			return nil, err
 		}
 
-		if sing := q.Singular(); sing != nil {
-			switch lex.skipBlankSpace() {
+		if p.compares() {
 			// comparison-expr
-			case '=', '!', '<', '>':
-				return p.parseComparableExpr(sing)
+			sing := q.Singular()
+			if sing == nil {
+				return nil, makeError(ErrNotSingular, tok, "cannot compare non-singular query")
 			}
+			return p.parseComparableExpr(sing)
 		}
 		return spec.Existence(q), nil
 	}
@@ -411,28 +633,51 @@ func (p *parser) parseBasicExpr() (spec.BasicExpr, error) {
 
 // parseFunctionFilterExpr parses a [BasicExpr] (basic-expr) that starts with
 // ident, which must be an identifier token that's expected to be the name of
-// a function. The return value will be either a [spec.FuncExpr]
-// (function-expr), if the function return value is a logical (boolean) value.
-// Otherwise it will be a [ComparisonExpr] (comparison-expr), as long as the
-// function call is compared to another expression. Any other configuration
-// returns an error.
+// a function. The return value will be a [spec.FuncExpr] (function-expr) if
+// the function returns a logical (boolean) value or a node list and is not
+// compared to another expression. It will be a [ComparisonExpr]
+// (comparison-expr) if the function returns a value and is compared to
+// another expression. Any other configuration returns an error, as required
+// by the well-typedness rules of [RFC 9535 Section 2.4.3].
+//
+// [RFC 9535 Section 2.4.3]: https://www.rfc-editor.org/rfc/rfc9535.html#section-2.4.3
 func (p *parser) parseFunctionFilterExpr(ident token) (spec.BasicExpr, error) {
 	f, err := p.parseFunction(ident)
 	if err != nil {
 		return nil, err
 	}
 
-	if f.ResultType() == spec.FuncLogical {
+	compares := p.compares()
+	switch f.ResultType() {
+	case spec.FuncLogical:
+		if compares {
+			return nil, makeError(ErrType, ident, "cannot compare result of logical function")
+		}
+		return f, nil
+	case spec.FuncNodes:
+		if compares {
+			return nil, makeError(ErrType, ident, "cannot compare result of nodes function")
+		}
 		return f, nil
 	}
 
-	switch p.lex.skipBlankSpace() {
-	case '=', '!', '<', '>':
+	if compares {
 		// comparison-expr
 		return p.parseComparableExpr(f)
 	}
 
-	return nil, makeError(p.lex.scan(), "missing comparison to function result")
+	return nil, makeError(ErrType, p.lex.scan(), "missing comparison to function result")
+}
+
+// compares skips blank space and returns true if the next character starts
+// a comparison operator.
+func (p *parser) compares() bool {
+	switch p.lex.skipBlankSpace() {
+	case '=', '!', '<', '>':
+		return true
+	default:
+		return false
+	}
 }
 
 // parseNonExistExpr parses a [spec.NonExistExpr] (non-existence) from lex.
@@ -467,7 +712,7 @@ func (p *parser) parseInnerParenExpr() (spec.LogicalOr, error) {
 	next := p.lex.scan()
 	if next.tok != ')' {
 		return nil, makeError(
-			next, fmt.Sprintf("expected ')' but found %v", next.name()),
+			ErrSyntax, next, fmt.Sprintf("expected ')' but found %v", next.name()),
 		)
 	}
 
@@ -499,12 +744,28 @@ func (p *parser) parseNotParenExpr() (*spec.NotParenExpr, error) {
 
 // parseFunction parses a function named tok.val from lex. tok should be the
 // token just before the next call to lex.scan, and must be an identifier
-// token naming the function. Returns an error if the function is not found in
-// the registry or if arguments are invalid for the function.
+// token naming the function. Returns an error if tok is not immediately
+// followed by '(', as RFC 9535 allows no blank space between a function name
+// and its arguments, if the function is disabled or not found in the
+// registry, or if arguments are invalid for the function.
 func (p *parser) parseFunction(tok token) (*spec.FuncExpr, error) {
+	switch {
+	case p.lex.r == '(':
+	case isBlankSpace(p.lex.r) && p.lex.peekPastBlankSpace() == '(':
+		return nil, makeError(
+			ErrSyntax, token{blankSpace, "", p.lex.rPos},
+			"unexpected blank space between function name and '('",
+		)
+	default:
+		return nil, unexpected(tok)
+	}
+
 	function := p.reg.Get(tok.val)
 	if function == nil {
-		return nil, makeError(tok, fmt.Sprintf("unknown function %v()", tok.val))
+		if p.reg.Disabled(tok.val) {
+			return nil, makeError(ErrDisabledFunction, tok, fmt.Sprintf("function %v() is disabled", tok.val))
+		}
+		return nil, makeError(ErrUnknownFunction, tok, fmt.Sprintf("unknown function %v()", tok.val))
 	}
 
 	paren := p.lex.scan() // Drop (
@@ -514,7 +775,7 @@ func (p *parser) parseFunction(tok token) (*spec.FuncExpr, error) {
 	}
 
 	if err := function.Validate(args); err != nil {
-		return nil, makeError(paren, fmt.Sprintf("function %v() %v", tok.val, err.Error()))
+		return nil, makeError(ErrType, paren, fmt.Sprintf("function %v() %v", tok.val, err.Error()))
 	}
 
 	return spec.Function(function, args...), nil
@@ -530,7 +791,7 @@ func (p *parser) parseFunctionArgs() ([]spec.FuncExprArg, error) {
 		switch tok := p.lex.scan(); tok.tok {
 		case goString, integer, number, boolFalse, boolTrue, jsonNull:
 			// literal
-			val, err := parseLiteral(tok)
+			val, err := p.parseLiteral(tok)
 			if err != nil {
 				return nil, err
 			}
@@ -545,9 +806,6 @@ func (p *parser) parseFunctionArgs() ([]spec.FuncExprArg, error) {
 			res = append(res, q.Expression())
 		case identifier:
 			// function-expr
-			if p.lex.skipBlankSpace() != '(' {
-				return nil, unexpected(tok)
-			}
 			f, err := p.parseFunction(tok)
 			if err != nil {
 				return nil, err
@@ -586,13 +844,22 @@ func (p *parser) parseFunctionArgs() ([]spec.FuncExprArg, error) {
 // parseLiteral parses the literal value from tok into native Go values and
 // returns them as spec.LiteralArg. tok.tok must be one of goString, integer,
 // number, boolFalse, boolTrue, or jsonNull.
-func parseLiteral(tok token) (*spec.LiteralArg, error) {
+func (p *parser) parseLiteral(tok token) (*spec.LiteralArg, error) {
 	switch tok.tok {
 	case goString:
 		return spec.Literal(tok.val), nil
 	case integer:
 		integer, err := strconv.ParseInt(tok.val, 10, 64)
+		if p.mode&IJSONNumbers != 0 && (err != nil || integer < minIJSONInt || integer > maxIJSONInt) {
+			return nil, makeError(ErrLimitExceeded, tok, fmt.Sprintf(
+				"cannot parse %q, value out of I-JSON range", tok.val,
+			))
+		}
 		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				// Preserve integers too large for int64.
+				return spec.Literal(json.Number(tok.val)), nil
+			}
 			return nil, makeNumErr(tok, err)
 		}
 		return spec.Literal(integer), nil
@@ -601,6 +868,10 @@ func parseLiteral(tok token) (*spec.LiteralArg, error) {
 		if err != nil {
 			return nil, makeNumErr(tok, err)
 		}
+		if exceedsFloatPrecision(tok.val) {
+			// Preserve digits that float64 cannot represent.
+			return spec.Literal(json.Number(tok.val)), nil
+		}
 		return spec.Literal(num), nil
 	case boolTrue:
 		return spec.Literal(true), nil
@@ -613,6 +884,15 @@ func parseLiteral(tok token) (*spec.LiteralArg, error) {
 	}
 }
 
+// exceedsFloatPrecision returns true if the decimal number num has more
+// significant digits than float64 can represent without loss.
+func exceedsFloatPrecision(num string) bool {
+	const maxDigits = 15
+	mantissa, _, _ := strings.Cut(strings.ToLower(num), "e")
+	digits := strings.Trim(strings.Replace(strings.TrimPrefix(mantissa, "-"), ".", "", 1), "0")
+	return len(digits) > maxDigits
+}
+
 // parseComparableExpr parses a [ComparisonExpr] (comparison-expr) from lex.
 func (p *parser) parseComparableExpr(left spec.CompVal) (*spec.CompExpr, error) {
 	// Skip blank space.
@@ -640,21 +920,28 @@ func (p *parser) parseComparableVal(tok token) (spec.CompVal, error) {
 	switch tok.tok {
 	case goString, integer, number, boolFalse, boolTrue, jsonNull:
 		// literal
-		return parseLiteral(tok)
+		return p.parseLiteral(tok)
 	case '@', '$':
 		// singular-query
-		return parseSingularQuery(tok, p.lex)
+		q, err := p.parseFilterQuery(tok)
+		if err != nil {
+			return nil, err
+		}
+		if sing := q.Singular(); sing != nil {
+			return sing, nil
+		}
+		return nil, makeError(ErrNotSingular, tok, "cannot compare non-singular query")
 	case identifier:
 		// function-expr
-		if p.lex.r != '(' {
-			return nil, unexpected(tok)
-		}
 		f, err := p.parseFunction(tok)
 		if err != nil {
 			return nil, err
 		}
-		if f.ResultType() == spec.FuncLogical {
-			return nil, makeError(tok, "cannot compare result of logical function")
+		switch f.ResultType() {
+		case spec.FuncLogical:
+			return nil, makeError(ErrType, tok, "cannot compare result of logical function")
+		case spec.FuncNodes:
+			return nil, makeError(ErrType, tok, "cannot compare result of nodes function")
 		}
 		return f, nil
 	default:
@@ -690,48 +977,5 @@ func parseCompOp(lex *lexer) (spec.CompOp, error) {
 		return spec.GreaterThan, nil
 	}
 
-	return 0, makeError(tok, "invalid comparison operator")
-}
-
-// parseSingularQuery parses a [spec.SingularQueryExpr] (singular-query) from
-// lex. A singular query consists only of single-selector nodes.
-func parseSingularQuery(startToken token, lex *lexer) (*spec.SingularQueryExpr, error) {
-	selectors := []spec.Selector{}
-	for {
-		switch lex.r {
-		case '[':
-			// Index or name selector.
-			lex.skipBlankSpace()
-			lex.scan()
-			switch tok := lex.scan(); tok.tok {
-			case goString:
-				selectors = append(selectors, spec.Name(tok.val))
-			case integer:
-				idx, err := parsePathInt(tok)
-				if err != nil {
-					return nil, err
-				}
-				selectors = append(selectors, spec.Index(idx))
-			default:
-				return nil, unexpected(tok)
-			}
-			// Look for closing bracket.
-			lex.skipBlankSpace()
-			tok := lex.scan()
-			if tok.tok != ']' {
-				return nil, unexpected(tok)
-			}
-		case '.':
-			// Start of a name selector.
-			lex.scan()
-			tok := lex.scan()
-			if tok.tok != identifier {
-				return nil, unexpected(tok)
-			}
-			selectors = append(selectors, spec.Name(tok.val))
-		default:
-			// Done parsing.
-			return spec.SingularQuery(startToken.tok == '$', selectors...), nil
-		}
-	}
+	return 0, makeError(ErrSyntax, tok, "invalid comparison operator")
 }
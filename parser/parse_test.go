@@ -1,8 +1,10 @@
 package parser
 
 import (
+	"encoding/json"
 	"errors"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -229,6 +231,15 @@ func TestParseFilter(t *testing.T) {
 		},
 	)
 	trueFunc := reg.Get("__true")
+	_ = reg.Register(
+		"__nodes",
+		spec.FuncNodes,
+		func([]spec.FuncExprArg) error { return nil },
+		func([]spec.PathValue) spec.PathValue {
+			return spec.Nodes(1)
+		},
+	)
+	nodesFunc := reg.Get("__nodes")
 
 	for _, tc := range []struct {
 		test   string
@@ -517,6 +528,11 @@ func TestParseFilter(t *testing.T) {
 			)),
 		},
 		// FunExpr
+		{
+			test:   "nodes_function",
+			query:  "__nodes()",
+			filter: spec.Filter(spec.And(spec.Function(nodesFunc, []spec.FuncExprArg{}...))),
+		},
 		{
 			test:  "function_current",
 			query: "__true(@)",
@@ -644,12 +660,53 @@ func TestParseFilter(t *testing.T) {
 		},
 		// ComparisonExpr
 		{
-			test:  "literal_comparison",
-			query: `42 == 42`,
+			test:   "literal_comparison",
+			query:  `42 == 42`,
+			filter: spec.Filter(spec.And(spec.Existence(spec.Query(false)))),
+		},
+		{
+			test:   "false_literal_comparison",
+			query:  `42 < 42`,
+			filter: spec.Filter(spec.And(spec.Nonexistence(spec.Query(false)))),
+		},
+		{
+			test:   "fold_true_and",
+			query:  `1 < 2 && @.a`,
+			filter: spec.Filter(spec.And(spec.Existence(spec.Query(false, spec.Child(spec.Name("a")))))),
+		},
+		{
+			test:   "fold_false_and",
+			query:  `1 > 2 && @.a`,
+			filter: spec.Filter(spec.And(spec.Nonexistence(spec.Query(false)))),
+		},
+		{
+			test:   "fold_false_or",
+			query:  `@.a || "x" == "y"`,
+			filter: spec.Filter(spec.And(spec.Existence(spec.Query(false, spec.Child(spec.Name("a")))))),
+		},
+		{
+			test:   "fold_true_or",
+			query:  `@.a || true == true`,
+			filter: spec.Filter(spec.And(spec.Existence(spec.Query(false)))),
+		},
+		{
+			test:  "fold_paren",
+			query: `(@.a && null == null) && !(1 == 2 || @.b)`,
 			filter: spec.Filter(spec.And(
-				spec.Comparison(spec.Literal(int64(42)), spec.EqualTo, spec.Literal(int64(42))),
+				spec.Paren(spec.And(spec.Existence(spec.Query(false, spec.Child(spec.Name("a")))))),
+				spec.NotParen(spec.And(spec.Existence(spec.Query(false, spec.Child(spec.Name("b")))))),
 			)),
 		},
+		{
+			test:   "fold_not_paren",
+			query:  `!(1 == 2) && !(1 == 1) || @.c`,
+			filter: spec.Filter(spec.And(spec.Existence(spec.Query(false, spec.Child(spec.Name("c")))))),
+		},
+		{
+			test:   "no_fold_ordering_different_types",
+			query:  `1 < "a"`,
+			filter: spec.Filter(spec.And(spec.Comparison(spec.Literal(int64(1)), spec.LessThan, spec.Literal("a")))),
+		},
 		{
 			test:  "literal_singular_comparison",
 			query: `42 != $.a.b`,
@@ -948,6 +1005,41 @@ func TestParseFilter(t *testing.T) {
 			query: `42 == __true()`,
 			err:   `jsonpath: cannot compare result of logical function at position 7`,
 		},
+		{
+			test:  "cannot_compare_logical_func_left",
+			query: `__true() == true`,
+			err:   `jsonpath: cannot compare result of logical function at position 1`,
+		},
+		{
+			test:  "cannot_compare_nodes_func",
+			query: `42 == __nodes()`,
+			err:   `jsonpath: cannot compare result of nodes function at position 7`,
+		},
+		{
+			test:  "cannot_compare_nodes_func_left",
+			query: `__nodes() < 42`,
+			err:   `jsonpath: cannot compare result of nodes function at position 1`,
+		},
+		{
+			test:  "cannot_compare_non_singular_left",
+			query: `@.* == 42`,
+			err:   `jsonpath: cannot compare non-singular query at position 1`,
+		},
+		{
+			test:  "cannot_compare_descendant_left",
+			query: `$..x < 42`,
+			err:   `jsonpath: cannot compare non-singular query at position 1`,
+		},
+		{
+			test:  "cannot_compare_non_singular_right",
+			query: `42 == @[0, 1]`,
+			err:   `jsonpath: cannot compare non-singular query at position 7`,
+		},
+		{
+			test:  "cannot_compare_slice_right",
+			query: `@.x != $.y[1:]`,
+			err:   `jsonpath: cannot compare non-singular query at position 8`,
+		},
 		{
 			test:  "function_wrong_arg_count",
 			query: `match("foo")`,
@@ -998,6 +1090,11 @@ func TestParseFilter(t *testing.T) {
 				a.Nil(filter, tc.test)
 				r.EqualError(err, tc.err, tc.test)
 				r.ErrorIs(err, ErrPathParse, tc.test)
+				a.Equal(
+					strings.Contains(tc.err, "unknown function"),
+					errors.Is(err, ErrUnknownFunction),
+					tc.test,
+				)
 			}
 		})
 	}
@@ -1338,6 +1435,21 @@ func TestParseSelectors(t *testing.T) {
 			path: `$["fo\uu0f8"]`,
 			err:  `jsonpath: invalid escape after backslash at position 8`,
 		},
+		{
+			test: "unpaired_surrogate_escape",
+			path: `$["fo\uDC00"]`,
+			err:  `jsonpath: unpaired low surrogate in unicode escape at position 8`,
+		},
+		{
+			test: "unescaped_control_character",
+			path: "$['fo\to']",
+			err:  `jsonpath: unescaped control character U+0009 at position 6`,
+		},
+		{
+			test: "filter_unescaped_control_character",
+			path: "$[?@.x == 'fo\no']",
+			err:  `jsonpath: unescaped control character U+000A at position 14`,
+		},
 		{
 			test: "invalid_integer",
 			path: `$[170141183460469231731687303715884105727]`, // too large
@@ -1469,17 +1581,30 @@ func TestParsePathInt(t *testing.T) {
 	for _, tc := range []struct {
 		test  string
 		input string
+		mode  Mode
 		exp   int64
 		err   string
 	}{
-		{"zero", "0", 0, ""},
-		{"1000", "1000", 1000, ""},
-		{"neg_1000", "-1000", -1000, ""},
+		{test: "zero", input: "0", exp: 0},
+		{test: "1000", input: "1000", exp: 1000},
+		{test: "neg_1000", input: "-1000", exp: -1000},
 		{
 			test:  "neg_zero",
 			input: "-0",
 			err:   `jsonpath: invalid integer path value "-0" at position 4`,
 		},
+		{
+			test:  "lenient_neg_zero",
+			input: "-0",
+			mode:  LenientNumbers,
+			exp:   0,
+		},
+		{
+			test:  "lenient_leading_zero",
+			input: "007",
+			mode:  LenientNumbers,
+			exp:   7,
+		},
 		{
 			test:  "too_big",
 			input: "9007199254740992",
@@ -1496,7 +1621,7 @@ func TestParsePathInt(t *testing.T) {
 			a := assert.New(t)
 			r := require.New(t)
 
-			num, err := parsePathInt(token{integer, tc.input, 3})
+			num, err := (&parser{mode: tc.mode}).parsePathInt(token{integer, tc.input, 3})
 			if tc.err == "" {
 				r.NoError(err)
 				a.Equal(tc.exp, num)
@@ -1515,6 +1640,7 @@ func TestParseLiteral(t *testing.T) {
 	for _, tc := range []struct {
 		test string
 		tok  token
+		mode Mode
 		exp  any
 		err  string
 	}{
@@ -1549,15 +1675,66 @@ func TestParseLiteral(t *testing.T) {
 			exp:  nil,
 		},
 		{
-			test: "invalid_int",
+			test: "big_int",
 			tok:  token{integer, "170141183460469231731687303715884105727", 5},
-			err:  `jsonpath: cannot parse "170141183460469231731687303715884105727", value out of range at position 6`,
+			exp:  json.Number("170141183460469231731687303715884105727"),
+		},
+		{
+			test: "precise_float",
+			tok:  token{number, "12345678901234567.89", 0},
+			exp:  json.Number("12345678901234567.89"),
+		},
+		{
+			test: "fifteen_digit_float",
+			tok:  token{number, "-0.00123456789012345e10", 0},
+			exp:  float64(-0.00123456789012345e10),
+		},
+		{
+			test: "sixteen_digit_float",
+			tok:  token{number, "-0.001234567890123456e10", 0},
+			exp:  json.Number("-0.001234567890123456e10"),
 		},
 		{
 			test: "invalid_float",
 			tok:  token{number, "99e+1234", 3},
 			err:  `jsonpath: cannot parse "99e+1234", value out of range at position 4`,
 		},
+		{
+			test: "ijson_max",
+			tok:  token{integer, "9007199254740991", 0},
+			mode: IJSONNumbers,
+			exp:  int64(9007199254740991),
+		},
+		{
+			test: "ijson_min",
+			tok:  token{integer, "-9007199254740991", 0},
+			mode: IJSONNumbers,
+			exp:  int64(-9007199254740991),
+		},
+		{
+			test: "ijson_float",
+			tok:  token{number, "1e300", 0},
+			mode: IJSONNumbers,
+			exp:  float64(1e300),
+		},
+		{
+			test: "ijson_too_big",
+			tok:  token{integer, "9007199254740992", 3},
+			mode: IJSONNumbers,
+			err:  `jsonpath: cannot parse "9007199254740992", value out of I-JSON range at position 4`,
+		},
+		{
+			test: "ijson_too_small",
+			tok:  token{integer, "-9007199254740992", 3},
+			mode: IJSONNumbers,
+			err:  `jsonpath: cannot parse "-9007199254740992", value out of I-JSON range at position 4`,
+		},
+		{
+			test: "ijson_huge",
+			tok:  token{integer, "170141183460469231731687303715884105727", 3},
+			mode: IJSONNumbers,
+			err:  `jsonpath: cannot parse "170141183460469231731687303715884105727", value out of I-JSON range at position 4`,
+		},
 		{
 			test: "non_literal_token",
 			tok:  token{eof, "", 3},
@@ -1569,7 +1746,7 @@ func TestParseLiteral(t *testing.T) {
 			a := assert.New(t)
 			r := require.New(t)
 
-			lit, err := parseLiteral(tc.tok)
+			lit, err := (&parser{mode: tc.mode}).parseLiteral(tc.tok)
 			if tc.err == "" {
 				r.NoError(err)
 				a.Equal(spec.Literal(tc.exp), lit)
@@ -1581,3 +1758,478 @@ func TestParseLiteral(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		test string
+		path string
+		mode Mode
+		exp  string
+		err  string
+	}{
+		{
+			test: "strict_leading_zero",
+			path: "$[01]",
+			err:  "jsonpath: invalid number literal at position 3",
+		},
+		{
+			test: "lenient_leading_zero",
+			path: "$[01]",
+			mode: LenientNumbers,
+			exp:  "$[1]",
+		},
+		{
+			test: "lenient_neg_zero",
+			path: "$[-0]",
+			mode: LenientNumbers,
+			exp:  "$[0]",
+		},
+		{
+			test: "lenient_slice",
+			path: "$[+1:-0:02]",
+			mode: LenientNumbers,
+			exp:  "$[1:0:2]",
+		},
+		{
+			test: "strict_plus",
+			path: "$[?@.x == +1]",
+			err:  "jsonpath: unexpected '+' at position 11",
+		},
+		{
+			test: "lenient_plus",
+			path: "$[?@.x == +1]",
+			mode: LenientNumbers,
+			exp:  "$[?@[\"x\"] == 1]",
+		},
+		{
+			test: "lenient_literal_leading_zero",
+			path: "$[?@.x == 01.5]",
+			mode: LenientNumbers,
+			exp:  "$[?@[\"x\"] == 1.5]",
+		},
+		{
+			test: "neg_zero_literal",
+			path: "$[?@.x == -0]",
+			exp:  "$[?@[\"x\"] == 0]",
+		},
+		{
+			test: "big_int",
+			path: "$[?@.x == 9007199254740992]",
+			exp:  "$[?@[\"x\"] == 9007199254740992]",
+		},
+		{
+			test: "ijson_big_int",
+			path: "$[?@.x == 9007199254740992]",
+			mode: IJSONNumbers,
+			err:  `jsonpath: cannot parse "9007199254740992", value out of I-JSON range at position 11`,
+		},
+		{
+			test: "ijson_lenient",
+			path: "$[?@.x == +09007199254740991]",
+			mode: IJSONNumbers | LenientNumbers,
+			exp:  "$[?@[\"x\"] == 9007199254740991]",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			q, err := ParseMode(reg, tc.path, tc.mode)
+			if tc.err == "" {
+				r.NoError(err)
+				a.Equal(tc.exp, q.String())
+			} else {
+				a.Nil(q)
+				r.EqualError(err, tc.err)
+				r.ErrorIs(err, ErrPathParse)
+			}
+		})
+	}
+}
+
+func TestParseSpans(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		test string
+		path string
+		mode Mode
+		exp  []string
+		err  string
+	}{
+		{
+			test: "root",
+			path: "$",
+			exp:  []string{},
+		},
+		{
+			test: "shorthand",
+			path: "$.a.*..b..*",
+			exp:  []string{".a", ".*", "..b", "..*"},
+		},
+		{
+			test: "brackets",
+			path: `$["a", 1]..[*][?@.x == $.y[0]]`,
+			exp:  []string{`["a", 1]`, "..[*]", "[?@.x == $.y[0]]"},
+		},
+		{
+			test: "blank_space",
+			path: "$ .a\n[ 1 ] ..b",
+			exp:  []string{".a", "[ 1 ]", "..b"},
+		},
+		{
+			test: "unicode",
+			path: "$.été['日本']",
+			exp:  []string{".été", "['日本']"},
+		},
+		{
+			test: "lenient",
+			path: "$[01].a",
+			mode: LenientNumbers,
+			exp:  []string{"[01]", ".a"},
+		},
+		{
+			test: "error",
+			path: "$.a[",
+			err:  "jsonpath: unexpected eof at position 5",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			q, spans, err := ParseSpans(reg, tc.path, tc.mode)
+			if tc.err != "" {
+				a.Nil(q)
+				a.Nil(spans)
+				a.EqualError(err, tc.err)
+				var pe *ParseError
+				a.ErrorAs(err, &pe)
+				a.Equal(4, pe.Start)
+				return
+			}
+
+			a.NoError(err)
+			exp, err := ParseMode(reg, tc.path, tc.mode)
+			a.NoError(err)
+			a.Equal(exp, q)
+			a.Len(spans, len(q.Segments()))
+			texts := make([]string, len(spans))
+			for i, span := range spans {
+				texts[i] = tc.path[span.Start:span.End]
+				a.True(span.Contains(span.Start))
+				a.True(span.Contains(span.End))
+				a.False(span.Contains(span.Start - 1))
+				a.False(span.Contains(span.End + 1))
+			}
+			a.Equal(tc.exp, texts)
+		})
+	}
+}
+
+func TestParseBlankSpace(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+
+	// RFC 9535 allows only space, tab, LF, and CR as blank space, and only
+	// between segments, around selectors and operators, and inside
+	// parentheses.
+	for _, tc := range []struct {
+		test string
+		path string
+		err  string
+	}{
+		{test: "before_segment", path: "$ \t\n\r.a"},
+		{test: "before_bracket", path: "$ [0]"},
+		{test: "before_descendant", path: "$ ..a"},
+		{test: "in_brackets", path: "$[ 0 , 1 ]"},
+		{test: "in_slice", path: "$[ 1 : 2 : 1 ]"},
+		{test: "after_question", path: "$[? @.a]"},
+		{test: "after_not", path: "$[?! @.a]"},
+		{test: "after_not_paren", path: "$[?! (@.a)]"},
+		{test: "around_operators", path: "$[?@.a\r\n==\t1 &&\n@.b ||\r@.c]"},
+		{test: "in_parens", path: "$[?( @.a )]"},
+		{test: "in_function", path: "$[?length( @.a ) == 1]"},
+		{test: "between_args", path: "$[?match( @.a , 'x' )]"},
+		{test: "singular_query_segments", path: "$[?@ .a [0] == 1]"},
+		{
+			test: "leading",
+			path: " $",
+			err:  "jsonpath: unexpected blank space at position 1",
+		},
+		{
+			test: "trailing",
+			path: "$ ",
+			err:  "jsonpath: unexpected blank space at position 2",
+		},
+		{
+			test: "after_dot",
+			path: "$. a",
+			err:  "jsonpath: unexpected blank space at position 3",
+		},
+		{
+			test: "after_dot_wildcard",
+			path: "$.\t*",
+			err:  "jsonpath: unexpected blank space at position 3",
+		},
+		{
+			test: "after_dot_dot",
+			path: "$.. a",
+			err:  "jsonpath: unexpected blank space at position 4",
+		},
+		{
+			test: "after_dot_dot_bracket",
+			path: "$..\n[0]",
+			err:  "jsonpath: unexpected blank space at position 4",
+		},
+		{
+			test: "filter_after_dot",
+			path: "$[?@. a]",
+			err:  "jsonpath: unexpected blank space at position 6",
+		},
+		{
+			test: "in_operator",
+			path: "$[?@.a & & @.b]",
+			err:  "jsonpath: expected '&' but found blank space at position 9",
+		},
+		{
+			test: "in_number",
+			path: "$[- 1]",
+			err:  "jsonpath: invalid number literal at position 3",
+		},
+		{
+			test: "before_function_paren",
+			path: "$[?length (@.a) == 1]",
+			err:  "jsonpath: unexpected blank space between function name and '(' at position 10",
+		},
+		{
+			test: "before_not_function_paren",
+			path: "$[?!match\t(@.a, 'x')]",
+			err:  "jsonpath: unexpected blank space between function name and '(' at position 10",
+		},
+		{
+			test: "before_compared_function_paren",
+			path: "$[?@.a == length\n(@.b)]",
+			err:  "jsonpath: unexpected blank space between function name and '(' at position 17",
+		},
+		{
+			test: "before_arg_function_paren",
+			path: "$[?length(value (@..a)) == 1]",
+			err:  "jsonpath: unexpected blank space between function name and '(' at position 16",
+		},
+		{
+			test: "vertical_tab",
+			path: "$[?@.a\v]",
+			err:  `jsonpath: unexpected '\v' at position 7`,
+		},
+		{
+			test: "form_feed",
+			path: "$\f.a",
+			err:  `jsonpath: unexpected '\f' at position 2`,
+		},
+		{
+			test: "no_break_space",
+			path: "$[\u00a00]",
+			err:  "jsonpath: unexpected identifier at position 3",
+		},
+		{
+			test: "ideographic_space",
+			path: "$\u3000.a",
+			err:  "jsonpath: unexpected identifier at position 1",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			q, err := Parse(reg, tc.path)
+			if tc.err == "" {
+				r.NoError(err)
+				a.NotNil(q)
+			} else {
+				a.Nil(q)
+				r.EqualError(err, tc.err)
+				r.ErrorIs(err, ErrPathParse)
+			}
+		})
+	}
+}
+
+func TestParseErrorKinds(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+	kinds := []error{ErrSyntax, ErrType, ErrNotSingular, ErrUnknownFunction, ErrLimitExceeded}
+
+	for _, tc := range []struct {
+		test  string
+		query string
+		mode  Mode
+		kind  error
+	}{
+		{"empty", "", 0, ErrSyntax},
+		{"unexpected", "$[", 0, ErrSyntax},
+		{"invalid_string", `$["a`, 0, ErrSyntax},
+		{"invalid_number", "$[01]", 0, ErrSyntax},
+		{"negative_zero", "$[-0]", 0, ErrSyntax},
+		{"invalid_operator", "$[?@.a = 1]", 0, ErrSyntax},
+		{"blank_before_args", "$[?length (@) == 1]", 0, ErrSyntax},
+		{"index_range", "$[9007199254740992]", 0, ErrLimitExceeded},
+		{"index_int64_range", "$[99999999999999999999]", 0, ErrLimitExceeded},
+		{"ijson_range", "$[?@ == 9007199254740992]", IJSONNumbers, ErrLimitExceeded},
+		{"non_singular", "$[?@.* == 1]", 0, ErrNotSingular},
+		{"non_singular_right", "$[?1 == @..a]", 0, ErrNotSingular},
+		{"logical_comparison", "$[?match(@, 'a') == true]", 0, ErrType},
+		{"nodes_comparison", "$[?1 == match(@, 'a')]", 0, ErrType},
+		{"missing_comparison", "$[?length(@)]", 0, ErrType},
+		{"invalid_args", "$[?length(@.*) == 1]", 0, ErrType},
+		{"unknown_function", "$[?nonesuch(@)]", 0, ErrUnknownFunction},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			_, err := ParseMode(reg, tc.query, tc.mode)
+			a.ErrorIs(err, ErrPathParse)
+			for _, kind := range kinds {
+				if kind == tc.kind {
+					a.ErrorIs(err, kind)
+				} else {
+					a.NotErrorIs(err, kind)
+				}
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+	reg.Disable("search")
+
+	for _, tc := range []struct {
+		test  string
+		query string
+		exp   *ParseError
+		text  string
+	}{
+		{
+			test:  "empty",
+			query: "",
+			exp:   &ParseError{Code: "syntax", Message: "unexpected end of input"},
+		},
+		{
+			test:  "eof",
+			query: "$[",
+			exp:   &ParseError{Code: "syntax", Message: "unexpected eof", Start: 2, End: 2},
+		},
+		{
+			test:  "blank_space",
+			query: "$.a  x",
+			exp:   &ParseError{Code: "syntax", Message: "unexpected blank space", Start: 3, End: 5},
+			text:  "  ",
+		},
+		{
+			test:  "invalid_operator",
+			query: "$[?@.a =~ 1]",
+			exp:   &ParseError{Code: "syntax", Message: "invalid comparison operator", Start: 7, End: 8},
+			text:  "=",
+		},
+		{
+			test:  "index_range",
+			query: "$[9007199254740992]",
+			exp: &ParseError{
+				Code:    "limit-exceeded",
+				Message: `cannot parse "9007199254740992", value out of range`,
+				Start:   2,
+				End:     18,
+				Hint:    "use integers between -(2^53)+1 and (2^53)-1",
+			},
+			text: "9007199254740992",
+		},
+		{
+			test:  "non_singular",
+			query: "$[?@.* == 1]",
+			exp: &ParseError{
+				Code:    "not-singular",
+				Message: "cannot compare non-singular query",
+				Start:   3,
+				End:     4,
+				Hint:    "use only name and index selectors in queries compared to other values",
+			},
+			text: "@",
+		},
+		{
+			test:  "type",
+			query: "$[?match(@, 'a') == true]",
+			exp: &ParseError{
+				Code:    "type",
+				Message: "cannot compare result of logical function",
+				Start:   3,
+				End:     8,
+			},
+			text: "match",
+		},
+		{
+			test:  "unknown_function",
+			query: "$[?nonesuch(@)]",
+			exp: &ParseError{
+				Code:    "unknown-function",
+				Message: "unknown function nonesuch()",
+				Start:   3,
+				End:     11,
+				Hint:    "register function extensions with a registry.Registry",
+			},
+			text: "nonesuch",
+		},
+		{
+			test:  "disabled_function",
+			query: "$[?@.a && search(@.b, 'x')]",
+			exp: &ParseError{
+				Code:    "disabled-function",
+				Message: "function search() is disabled",
+				Start:   10,
+				End:     16,
+				Hint:    "rewrite the query without calls to disabled functions",
+			},
+			text: "search",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			_, err := Parse(reg, tc.query)
+			var pe *ParseError
+			r.ErrorAs(err, &pe)
+			a.Equal(tc.exp.Code, pe.Code)
+			a.Equal(tc.exp.Message, pe.Message)
+			a.Equal(tc.exp.Start, pe.Start)
+			a.Equal(tc.exp.End, pe.End)
+			a.Equal(tc.exp.Hint, pe.Hint)
+			a.Equal(tc.text, tc.query[pe.Start:pe.End])
+
+			// Should marshal to JSON.
+			data, err := json.Marshal(pe)
+			r.NoError(err)
+			fields := map[string]any{
+				"code":    tc.exp.Code,
+				"message": tc.exp.Message,
+				"start":   tc.exp.Start,
+				"end":     tc.exp.End,
+			}
+			if tc.exp.Hint != "" {
+				fields["hint"] = tc.exp.Hint
+			}
+			exp, err := json.Marshal(fields)
+			r.NoError(err)
+			a.JSONEq(string(exp), string(data))
+		})
+	}
+}
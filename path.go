@@ -1,24 +1,168 @@
 // Package jsonpath implements RFC 9535 JSONPath query expressions.
-// It operates on any type of slice or string-keyed map.
+// It operates on any type of slice, array, string-keyed map, or struct, as
+// well as on custom types that implement [Object] or [Array].
 package jsonpath
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"iter"
+	"log/slog"
 	"slices"
+	"sync/atomic"
+	"time"
 
 	"github.com/theory/jsonpath/parser"
 	"github.com/theory/jsonpath/registry"
 	"github.com/theory/jsonpath/spec"
 )
 
-// ErrPathParse errors are returned for path parse errors.
+// ErrPathParse errors are returned for path parse errors. Each also wraps
+// one of [ErrSyntax], [ErrType], [ErrNotSingular], [ErrUnknownFunction],
+// [ErrDisabledFunction], or [ErrLimitExceeded], so use [errors.Is] to
+// identify the kind of error.
 var ErrPathParse = parser.ErrPathParse
 
-// Path represents a [RFC 9535] JSONPath query.
+var (
+	// ErrSyntax errors are returned for paths that violate the JSONPath
+	// grammar, such as unexpected tokens and invalid literals.
+	ErrSyntax = parser.ErrSyntax
+
+	// ErrType errors are returned for paths with filter expressions that
+	// are not well-typed, such as comparisons to the results of logical
+	// functions and invalid function arguments.
+	ErrType = parser.ErrType
+
+	// ErrNotSingular errors are returned for paths that compare queries
+	// that may select more than one node.
+	ErrNotSingular = parser.ErrNotSingular
+
+	// ErrUnknownFunction errors are returned for paths that call functions
+	// not found in the registry.
+	ErrUnknownFunction = parser.ErrUnknownFunction
+
+	// ErrDisabledFunction errors are returned for paths that call functions
+	// disabled by [WithDisabledFunctions] or [registry.Registry.Disable].
+	ErrDisabledFunction = parser.ErrDisabledFunction
+
+	// ErrLimitExceeded errors are returned for paths with integers outside
+	// the range allowed by RFC 9535 or [WithIJSONNumbers], and by
+	// [Path.TrySelect] and [Path.TrySelectLocated] as [ErrBudgetExceeded]
+	// and [ErrDepthExceeded] errors.
+	ErrLimitExceeded = spec.ErrLimitExceeded
+)
+
+// ParseError describes a path parse error, including the position in the
+// path at which it occurred. Use [errors.As] to retrieve it from the errors
+// returned by [Parse] and [Parser.Parse]. See [parser.ParseError] for
+// details.
+type ParseError = parser.ParseError
+
+// ErrBudgetExceeded errors are returned by [Path.TrySelect] and
+// [Path.TrySelectLocated] when evaluation exceeds the limits configured by
+// [WithMaxNodes] or [WithTimeout]. They also wrap [ErrLimitExceeded].
+var ErrBudgetExceeded = spec.ErrBudgetExceeded
+
+// ErrDepthExceeded errors are returned by [Path.TrySelect] and
+// [Path.TrySelectLocated] when a descendant segment descends deeper than
+// the limit configured by [WithMaxDocumentDepth]. They also wrap
+// [ErrLimitExceeded].
+var ErrDepthExceeded = spec.ErrDepthExceeded
+
+// ErrCycle errors are returned by [Path.TrySelect] and
+// [Path.TrySelectLocated] when a descendant segment finds a value that
+// contains itself, such as a struct with a pointer to its parent. See
+// [spec.ErrCycle] for details.
+var ErrCycle = spec.ErrCycle
+
+// ErrEvaluation errors are returned by [Path.SelectE], and by
+// [Path.TrySelect] and [Path.TrySelectLocated] for paths configured by
+// [WithStrict], when evaluation encounters a soft failure, such as a
+// regular expression that fails to compile.
+var ErrEvaluation = spec.ErrEvaluation
+
+// ErrRewrite errors are returned by [Path.Rewrite] for replacements that
+// would produce an invalid path.
+var ErrRewrite = spec.ErrRewrite
+
+// errDecode is returned by [Path.UnmarshalJSON] and [Path.Scan] for
+// values other than strings.
+var errDecode = errors.New("jsonpath: cannot decode Path")
+
+// Nothing represents the absence of a value, as returned by
+// [Path.SelectValue] when a query selects no value. It's distinct from the
+// JSON null value, which Go represents as nil. See [spec.Nothing] for
+// details.
+var Nothing = spec.Nothing
+
+// Object defines the interface for custom JSON object types, such as ordered
+// maps or lazily-loaded data. Queries select members from values that
+// implement Object as they do from map[string]any. See [spec.Object] for
+// details.
+type Object = spec.Object
+
+// Array defines the interface for custom JSON array types, such as
+// lazily-loaded sequences. Queries select elements from values that
+// implement Array as they do from []any. See [spec.Array] for details.
+type Array = spec.Array
+
+// Comparable defines the interface for user-defined scalar types that
+// participate in filter expression comparisons. See [spec.Comparable] for
+// details.
+type Comparable = spec.Comparable
+
+// Collator defines the interface for collating strings in ordering
+// comparisons, such as *collate.Collator from golang.org/x/text/collate.
+// Configure one with [WithCollator]. See [spec.Collator] for details.
+type Collator = spec.Collator
+
+// Hook defines the interface for instrumentation of the evaluation of a
+// [Path], such as counting the nodes it visits. Configure one with
+// [WithHook]. See [spec.Hook] for details.
+type Hook = spec.Hook
+
+// Stats summarizes the work done to evaluate a [Path]. Returned by
+// [Path.SelectStats]. See [spec.Stats] for details.
+type Stats = spec.Stats
+
+// CostEstimate summarizes the worst-case cost of evaluating a [Path].
+// Returned by [Path.Cost]. See [spec.CostEstimate] for details.
+type CostEstimate = spec.CostEstimate
+
+// FormatOpts configures the formatting of a [Path] by [Path.Format]. See
+// [spec.FormatOpts] for details.
+type FormatOpts = spec.FormatOpts
+
+// ASTNode describes a node in the abstract syntax tree of a [Path], as
+// returned by [Path.Describe]. See [spec.ASTNode] for details.
+type ASTNode = spec.ASTNode
+
+// Trace describes the evaluation of a [Path] by [Path.Explain]. See
+// [spec.Trace] for details.
+type Trace = spec.Trace
+
+// Path represents a [RFC 9535] JSONPath query. A Path is immutable once
+// parsed and safe for concurrent use by multiple goroutines: they may call
+// Select and its variants, [Path.Compile], and the methods that derive new
+// paths, such as [Path.Append], at the same time without locking, while
+// other goroutines register function extensions and parse paths with the
+// same [registry.Registry]. Paths resolve function extensions when parsed,
+// so later registrations never affect them. The exceptions are the decoding
+// methods, such as [Path.UnmarshalJSON] and [Path.Scan], which replace the
+// query of the Path they decode into and therefore must not be called
+// concurrently with other methods on it, and the slices returned by the
+// accessors of its [spec.PathQuery], which callers must not modify. Use
+// [Path.Clone] for a copy that is safe to decode into or modify.
+//
+// Concurrent evaluations share the [Hook] configured by [WithHook], which
+// must therefore be safe for concurrent use, as must function extensions.
 //
 // [RFC 9535]: https://www.rfc-editor.org/rfc/rfc9535.html
 type Path struct {
-	q *spec.PathQuery
+	q    *spec.PathQuery
+	opts spec.Options
 }
 
 // New creates and returns a new [Path] consisting of q.
@@ -38,14 +182,30 @@ func MustParse(path string) *Path {
 	return NewParser().MustParse(path)
 }
 
+// Format parses query, a JSONPath query string, and returns it formatted in
+// the canonical style, as [Parser.Format] does for a [Parser] with no
+// options. Returns an [ErrPathParse] on parse failure.
+func Format(query string) (string, error) {
+	return NewParser().Format(query)
+}
+
+// canonicalFormat configures the canonical style of [Parser.Format].
+var canonicalFormat = FormatOpts{Shorthand: true, SingleQuotes: true}
+
 // MarshalText encodes p into UTF-8-encoded text and returns the result.
-// Implements [encoding.TextMarshaler].
+// Formats p with [Path.Format] in the style of [Path.String], but escapes
+// names and string literals exactly as RFC 9535 requires, so that the
+// result always decodes back into an equivalent path. Implements
+// [encoding.TextMarshaler].
 func (p *Path) MarshalText() ([]byte, error) {
-	return []byte(p.q.String()), nil
+	return []byte(p.q.Format(FormatOpts{})), nil
 }
 
-// UnmarshalText decodes UTF-8-encoded text into p. Implements
-// [encoding.TextUnmarshaler].
+// UnmarshalText decodes UTF-8-encoded text into p. Parses the text with the
+// default parser returned by [NewParser], so it must use only the standard
+// function extensions, not those of a registry configured by
+// [WithRegistry]. Replaces p's query but retains its other options.
+// Implements [encoding.TextUnmarshaler].
 func (p *Path) UnmarshalText(data []byte) error {
 	parsed, err := NewParser().Parse(string(data))
 	if err != nil {
@@ -67,79 +227,651 @@ func (p *Path) UnmarshalBinary(data []byte) error {
 	return p.UnmarshalText(data)
 }
 
+// MarshalJSON encodes p as a JSON string in the same format as
+// [Path.MarshalText]. Implements [json.Marshaler].
+func (p *Path) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.q.Format(FormatOpts{}))
+}
+
+// UnmarshalJSON decodes a JSON string into p with [Path.UnmarshalText],
+// returning an [ErrPathParse] error if it is not a valid path. Leaves p
+// unchanged for JSON null. Implements [json.Unmarshaler].
+func (p *Path) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("%w from JSON %s", errDecode, data)
+	}
+	return p.UnmarshalText([]byte(str))
+}
+
+// Value returns p for storage in a database as a string in the same format
+// as [Path.MarshalText], or nil if p is nil. Implements [driver.Valuer].
+func (p *Path) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil //nolint:nilnil
+	}
+	return p.q.Format(FormatOpts{}), nil
+}
+
+// Scan decodes src, a string or byte slice read from a database, into p
+// with [Path.UnmarshalText], returning an [ErrPathParse] error if it is not
+// a valid path. Use
+// [database/sql.Null] to scan columns that may be NULL. Implements
+// [database/sql.Scanner].
+func (p *Path) Scan(src any) error {
+	switch src := src.(type) {
+	case string:
+		return p.UnmarshalText([]byte(src))
+	case []byte:
+		return p.UnmarshalText(src)
+	default:
+		return fmt.Errorf("%w from %T", errDecode, src)
+	}
+}
+
 // String returns a string representation of p.
 func (p *Path) String() string {
 	return p.q.String()
 }
 
-// Query returns p's root [spec.PathQuery].
+// Format returns a string representation of p formatted as configured by
+// opts, which choose between shorthand and bracket notation, single and
+// double quotation marks, and spaced or compact operators. Unlike
+// [Path.String], the result always parses back into an equivalent [Path].
+func (p *Path) Format(opts FormatOpts) string {
+	return p.q.Format(opts)
+}
+
+// Describe returns the abstract syntax tree of p: an [ASTNode] for the query
+// and for each of its segments, selectors, and filter expressions.
+func (p *Path) Describe() *ASTNode {
+	return p.q.Describe()
+}
+
+// Tree returns the abstract syntax tree of p as an indented string, with a
+// line for each node that names its kind, such as "descendant-segment",
+// "filter", or "comparison", followed by its text. Useful for debugging
+// complex queries.
+func (p *Path) Tree() string {
+	return p.q.Describe().String()
+}
+
+// Query returns p's root [spec.PathQuery]. Callers must not modify the
+// slices returned by its accessors; use [Path.Clone] to modify a copy.
 func (p *Path) Query() *spec.PathQuery {
 	return p.q
 }
 
-// Select returns the nodes that JSONPath query p selects from input.
+// Clone returns a deep copy of p with the same options that shares no
+// mutable state with p, so that decoding into the copy with a method such
+// as [Path.UnmarshalJSON] or modifying its [spec.PathQuery] never affects p.
+// The copy shares p's [Hook], logger, and function extensions. See
+// [spec.PathQuery.Clone] for details.
+func (p *Path) Clone() *Path {
+	return &Path{q: p.q.Clone(), opts: p.opts}
+}
+
+// SingularPrefix splits p into its leading name and non-negative index
+// segments, returned as a [spec.NormalizedPath], and a [Path] with the same
+// options for the remaining segments. Selecting the remainder from the
+// value at the prefix selects the same nodes as p, so callers can look up
+// the prefix directly, or in an index, before evaluating the remainder, and
+// routers can shard data by the prefix. Filters in the remainder that select
+// from the root node ($) select from the value passed to it, however. See
+// [spec.PathQuery.SingularPrefix] for details.
+func (p *Path) SingularPrefix() (spec.NormalizedPath, *Path) {
+	prefix, rest := p.q.SingularPrefix()
+	return prefix, &Path{q: rest, opts: p.opts}
+}
+
+// IsSingular returns true if p is a singular query, one that selects at
+// most one node, such as $.store.book[0].title, so that callers can
+// determine whether a user-supplied path identifies a single location, as
+// required to convert it to a JSON Pointer, before attempting it.
+func (p *Path) IsSingular() bool {
+	return p.q.IsSingular()
+}
+
+// AsSingular returns the [spec.SingularQueryExpr] variant of p and true if
+// p is a singular query, and nil and false if it is not. See
+// [Path.IsSingular].
+func (p *Path) AsSingular() (*spec.SingularQueryExpr, bool) {
+	sq := p.q.Singular()
+	return sq, sq != nil
+}
+
+// Append returns a new [Path] with the same options as p that appends
+// segments to the segments of p. It never modifies p.
+func (p *Path) Append(segments ...*spec.Segment) *Path {
+	q := spec.Query(p.q.IsRoot(), slices.Concat(p.q.Segments(), segments)...)
+	return &Path{q: q, opts: p.opts}
+}
+
+// Join returns a new [Path] with the same options as p that appends the
+// segments of relative, usually a query that selects from the current node
+// (@), to the segments of p, so that it selects what relative selects from
+// each node that p selects. It never modifies p or relative.
+func (p *Path) Join(relative *Path) *Path {
+	return p.Append(relative.q.Segments()...)
+}
+
+// Optimize returns a copy of p rewritten to select the same nodes in the
+// same order with less work, or p itself if no rewrites apply. It folds
+// runs of consecutive indexes into slices, replaces always-true filters with
+// wildcards, and removes selectors and segments that cannot select any
+// nodes. See [spec.PathQuery.Optimize] for details.
+func (p *Path) Optimize() *Path {
+	q := p.q.Optimize()
+	if q == p.q {
+		return p
+	}
+	return &Path{q: q, opts: p.opts}
+}
+
+// Rewrite returns a new [Path] with the same options as p, in which fn has
+// replaced the nodes of the abstract syntax tree of p, such as to prefix it
+// with segments, rename object members, or remove filters. It never
+// modifies p. Returns an [ErrRewrite] error for replacements that would
+// produce an invalid path. See [spec.Rewrite] for details.
+func (p *Path) Rewrite(fn func(node any) any) (*Path, error) {
+	q, err := spec.Rewrite(p.q, fn)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	return &Path{q: q, opts: p.opts}, nil
+}
+
+// Subsumes returns true if p selects every node that other selects from
+// any input, and false if it doesn't or if that depends on the input, such
+// as the results of filters. Useful to determine whether a path that grants
+// access to fields covers a path that requests them. See
+// [spec.PathQuery.Subsumes] for details.
+func (p *Path) Subsumes(other *Path) bool {
+	return p.q.Subsumes(other.q)
+}
+
+// Overlaps returns true if p and other may select the same node from some
+// input, and false if they never do. See [spec.PathQuery.Overlaps] for
+// details.
+func (p *Path) Overlaps(other *Path) bool {
+	return p.q.Overlaps(other.q)
+}
+
+// MatchesPath returns true if p selects the node at np without evaluating p
+// against a document, for access control and routing layers that filter
+// events keyed by normalized paths. It is exact for paths without filters,
+// negative indexes, or slices whose results depend on the length of an
+// array, and otherwise returns true if p might select the node. See
+// [spec.PathQuery.MatchesPath] for details.
+func (p *Path) MatchesPath(np spec.NormalizedPath) bool {
+	return p.q.MatchesPath(np)
+}
+
+// Select returns the nodes that JSONPath query p selects from input. If
+// evaluation aborts, as when it exceeds the limits configured by
+// [WithMaxNodes], [WithTimeout], or [WithMaxDocumentDepth], or on a soft
+// failure in a path configured by [WithStrict], Select discards any nodes
+// selected so far and returns nil, logging the error to the logger
+// configured by [WithLogger], if any. Use [Path.TrySelect] to distinguish
+// an aborted evaluation from one that selects nothing.
 func (p *Path) Select(input any) NodeList {
-	return p.q.Select(nil, input)
+	return p.q.SelectWith(nil, input, p.opts)
+}
+
+// TrySelect returns the nodes that JSONPath query p selects from input.
+// Returns an [ErrBudgetExceeded] error if evaluation exceeds the limits
+// configured by [WithMaxNodes] or [WithTimeout], and an [ErrEvaluation]
+// error on soft failures in paths configured by [WithStrict].
+func (p *Path) TrySelect(input any) (NodeList, error) {
+	nodes, err := p.q.TrySelect(nil, input, p.opts)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// SelectE returns the nodes that JSONPath query p selects from input,
+// evaluated in strict mode, as if p were configured by [WithStrict].
+// Returns an [ErrEvaluation] error on the first soft failure, such as an
+// ordering comparison of values of different types or a regular expression
+// that fails to compile, which otherwise evaluate to false. Also returns an
+// [ErrBudgetExceeded] error if evaluation exceeds the limits configured by
+// [WithMaxNodes] or [WithTimeout]. Useful for data-quality pipelines that
+// prefer loud failures over quietly missing values.
+func (p *Path) SelectE(input any) (NodeList, error) {
+	opts := p.opts
+	opts.Strict = true
+	nodes, err := p.q.TrySelect(nil, input, opts)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// SelectValue returns the first node that JSONPath query p selects from
+// input, or [Nothing] if it selects none. Unlike [Path.Select], it
+// distinguishes a query that selects a JSON null, returned as nil, from one
+// that selects nothing, as when a member does not exist. Best used with
+// singular queries, which select at most one node, such as $.a.b or
+// $.items[0]. Returns [Nothing] if evaluation aborts, as [Path.Select]
+// returns nil.
+func (p *Path) SelectValue(input any) any {
+	return p.q.SelectValue(nil, input, p.opts)
+}
+
+// Explain selects the nodes that JSONPath query p selects from input and
+// returns a [Trace] that describes how: the nodes each segment considered,
+// the nodes each selector matched, and the result of each filter expression
+// for each node it tested. Use it to answer questions such as "why doesn't
+// my filter match?" Explain ignores the limits configured by [WithMaxNodes],
+// [WithTimeout], and [WithMaxDocumentDepth], and never aborts for paths
+// configured by [WithStrict], so use it only to diagnose queries against
+// small inputs.
+func (p *Path) Explain(input any) *Trace {
+	return p.q.Explain(nil, input, p.opts)
+}
+
+// Cost estimates the worst-case cost of evaluating p without evaluating it:
+// the numbers of descendant segments, wildcards, filters, and function and
+// regular expression calls, whether p is singular, and a multiplier that
+// estimates the number of values p visits. Use it to reject or throttle
+// expensive queries, such as those submitted by users, before running
+// them. See [spec.CostEstimate] for details.
+func (p *Path) Cost() CostEstimate {
+	return p.q.Cost()
+}
+
+// SelectStats returns the nodes that JSONPath query p selects from input,
+// along with [Stats] that describe the work done to select them: the nodes
+// visited, the greatest depth of descent, the numbers of filter expression
+// evaluations, function calls, and regular expression evaluations, and the
+// duration. Also notifies p's [Hook], if any. Useful for capacity planning
+// and finding expensive queries; use [Path.Select] otherwise.
+func (p *Path) SelectStats(input any) (NodeList, Stats) {
+	c := new(spec.Collector)
+	opts := p.opts
+	if opts.Hook == nil {
+		opts.Hook = c
+	} else {
+		opts.Hook = &hookPair{opts.Hook, c}
+	}
+
+	start := time.Now()
+	nodes := p.q.SelectWith(nil, input, opts)
+	stats := c.Stats()
+	stats.Duration = time.Since(start)
+	return nodes, stats
+}
+
+// hookPair is a [Hook] that notifies two hooks.
+type hookPair struct {
+	first, second spec.Hook
+}
+
+// Visit notifies both hooks of a visit. Defined by [Hook].
+func (h *hookPair) Visit(depth int) {
+	h.first.Visit(depth)
+	h.second.Visit(depth)
+}
+
+// Filter notifies both hooks of a filter evaluation. Defined by [Hook].
+func (h *hookPair) Filter() {
+	h.first.Filter()
+	h.second.Filter()
+}
+
+// Function notifies both hooks of a function call. Defined by [Hook].
+func (h *hookPair) Function(name string) {
+	h.first.Function(name)
+	h.second.Function(name)
 }
 
 // SelectLocated returns the nodes that JSONPath query p selects from input as
 // [spec.LocatedNode] values that pair the nodes with the [normalized paths]
 // that identify them. Unless you have a specific need for the unique
 // [spec.NormalizedPath] for each value, you probably want to use
-// [Path.Select].
+// [Path.Select]. Like [Path.Select], it discards any nodes selected so far
+// and returns nil if evaluation aborts; use [Path.TrySelectLocated] to
+// detect aborted evaluations.
 //
 // [normalized paths]: https://www.rfc-editor.org/rfc/rfc9535#section-2.7
 func (p *Path) SelectLocated(input any) LocatedNodeList {
-	return p.q.SelectLocated(nil, input, spec.Normalized())
+	return p.q.SelectLocatedWith(nil, input, spec.Normalized(), p.opts)
 }
 
-// Parser parses JSONPath strings into [Path] values.
+// SelectMap returns the nodes that JSONPath query p selects from input in a
+// map keyed by the string representations of their [normalized paths], such
+// as $['store']['book'][0]['title']. Useful to export selected values to
+// flat key/value sinks, such as metrics labels, environment-style
+// configuration, and spreadsheets. Nodes selected more than once appear
+// only once. Returns an empty map if p selects nothing.
+//
+// [normalized paths]: https://www.rfc-editor.org/rfc/rfc9535#section-2.7
+func (p *Path) SelectMap(input any) map[string]any {
+	nodes := p.SelectLocated(input)
+	res := make(map[string]any, len(nodes))
+	for _, node := range nodes {
+		res[node.Path.String()] = node.Node
+	}
+	return res
+}
+
+// TrySelectLocated returns the nodes that JSONPath query p selects from
+// input as [spec.LocatedNode] values. Returns an [ErrBudgetExceeded] error
+// if evaluation exceeds the limits configured by [WithMaxNodes] or
+// [WithTimeout], and an [ErrEvaluation] error on soft failures in paths
+// configured by [WithStrict].
+func (p *Path) TrySelectLocated(input any) (LocatedNodeList, error) {
+	nodes, err := p.q.TrySelectLocated(nil, input, spec.Normalized(), p.opts)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// Parser parses JSONPath strings into [Path] values. Each Parser owns its
+// [registry.Registry] and options, and the package keeps no global registry
+// or other mutable state, so that parsers configured with different
+// function extensions and options, such as by different subsystems of a
+// program, never affect one another. [Parse], [MustParse], [Format], and
+// decoding methods such as [Path.UnmarshalJSON] each use a new Parser with
+// the RFC 9535 functions and default options. A Parser is safe for
+// concurrent use by multiple goroutines, including while other goroutines
+// register function extensions with its [registry.Registry].
 type Parser struct {
-	reg *registry.Registry
+	reg      atomic.Pointer[registry.Registry]
+	opts     spec.Options
+	mode     parser.Mode
+	disabled []string
 }
 
-// Option defines a parser option.
+// Option defines a parser option. Some options configure the evaluation of
+// the [Path] values returned by the parser.
 type Option func(*Parser)
 
 // WithRegistry configures a [Parser] with a [registry.Registry], which may
 // contain function extensions.
 func WithRegistry(reg *registry.Registry) Option {
-	return func(p *Parser) { p.reg = reg }
+	return func(p *Parser) { p.reg.Store(reg) }
+}
+
+// WithBytesAsStrings configures a [Parser] to return [Path] values that
+// treat []byte values as UTF-8 strings in filter expression comparisons and
+// function extension arguments, including those to length(), match(), and
+// search(). Useful for documents loaded from databases that store strings
+// as byte slices. Queries still select []byte values unchanged.
+func WithBytesAsStrings() Option {
+	return func(p *Parser) { p.opts.BytesAsStrings = true }
+}
+
+// WithMaxDepth configures a [Parser] to return [Path] values whose
+// descendant segments select from values no more than depth levels below
+// the values to which they apply. Use to bound the evaluation of queries
+// such as $..x over deeply-nested input. Zero or a negative depth means no
+// limit.
+func WithMaxDepth(depth int) Option {
+	return func(p *Parser) { p.opts.MaxDepth = depth }
+}
+
+// WithParallelism configures a [Parser] to return [Path] values whose
+// descendant segments use up to n goroutines to descend into arrays of at
+// least 1,024 elements, merging their results in document order. Useful for
+// queries such as $..x over very large documents. Filter expressions and
+// function extensions must therefore be safe for concurrent use. Values of
+// n less than two disable parallel evaluation.
+func WithParallelism(n int) Option {
+	return func(p *Parser) { p.opts.Parallelism = n }
+}
+
+// WithHook configures a [Parser] to return [Path] values that notify hook
+// of the work done to evaluate them, for custom instrumentation. Paths call
+// hook from every goroutine that selects with them, as well as from the
+// goroutines configured by [WithParallelism], so it must be safe for
+// concurrent use. Use [spec.Collector] to collect [Stats] across many
+// selections, or [Path.SelectStats] for the Stats of a single selection.
+func WithHook(hook Hook) Option {
+	return func(p *Parser) { p.opts.Hook = hook }
+}
+
+// WithMaxNodes configures a [Parser] to return [Path] values that abort
+// evaluation after visiting more than n nodes, including the nodes visited
+// by queries in filter expressions. Use to place a hard ceiling on the work
+// done by untrusted queries such as $..*..*, regardless of their input.
+// [Path.TrySelect] and [Path.TrySelectLocated] return an
+// [ErrBudgetExceeded] error when evaluation aborts; other methods, such as
+// [Path.Select], return no nodes and log the error to the logger configured
+// by [WithLogger], if any. Zero or a negative n means no limit.
+func WithMaxNodes(n int) Option {
+	return func(p *Parser) { p.opts.MaxNodes = n }
+}
+
+// WithTimeout configures a [Parser] to return [Path] values that abort
+// evaluation once it takes longer than d, independent of any context
+// cancellation. Evaluation checks the time periodically as it visits nodes.
+// [Path.TrySelect] and [Path.TrySelectLocated] return an
+// [ErrBudgetExceeded] error when evaluation aborts; other methods, such as
+// [Path.Select], return no nodes and log the error to the logger configured
+// by [WithLogger], if any. Zero or a negative d means no limit.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Parser) { p.opts.Timeout = d }
+}
+
+// WithMaxDocumentDepth configures a [Parser] to return [Path] values that
+// abort evaluation when a descendant segment reaches a value more than
+// depth levels below the value to which it applies. Use to reject
+// adversarial, deeply-nested documents selected by queries such as $..x.
+// Unlike [WithMaxDepth], which quietly stops descending, [Path.TrySelect]
+// and [Path.TrySelectLocated] return an [ErrDepthExceeded] error when
+// evaluation aborts; other methods, such as [Path.Select], return no nodes
+// and log the error to the logger configured by [WithLogger], if any. Zero
+// or a negative depth means no limit.
+func WithMaxDocumentDepth(depth int) Option {
+	return func(p *Parser) { p.opts.MaxDocumentDepth = depth }
+}
+
+// WithLogger configures a [Parser] to return [Path] values that log
+// warnings to logger for soft failures that RFC 9535 requires evaluation to
+// ignore: regular expressions that fail to compile, ordering comparisons of
+// values of different types, descendant segments that stop at the depth
+// configured by [WithMaxDepth], and, for [Path.SelectChan], results
+// truncated by the limits configured by [WithMaxNodes] or [WithTimeout].
+// Logging ordering comparisons disables some optimizations, so prefer to
+// enable it only while debugging.
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Parser) { p.opts.Logger = logger }
+}
+
+// WithStrict configures a [Parser] to return [Path] values that evaluate in
+// strict mode, aborting on the first soft failure that RFC 9535 otherwise
+// requires evaluation to ignore: an ordering comparison of values of
+// different types, a regular expression that fails to compile, or a
+// function extension that returns a warning via [spec.WithWarning].
+// [Path.TrySelect] and [Path.TrySelectLocated] return an [ErrEvaluation]
+// error when evaluation aborts; other methods, such as [Path.Select], return
+// no nodes and log the error to the logger configured by [WithLogger], if
+// any. See also [Path.SelectE].
+func WithStrict() Option {
+	return func(p *Parser) { p.opts.Strict = true }
+}
+
+// WithSortedMembers configures a [Parser] to return [Path] values that
+// select the members of objects in ascending order of their names, rather
+// than in the order in which the objects iterate over them, which for Go
+// maps is random. Wildcard selectors, filter selectors, and descendant
+// segments thus return reproducible results, for consumers such as
+// snapshot tests and diffs that compare output across runs. Use
+// [spec.LocatedNode.Key] to get the member names of nodes returned by
+// [Path.SelectLocated]. See [spec.Options].SortMembers for details.
+func WithSortedMembers() Option {
+	return func(p *Parser) { p.opts.SortMembers = true }
+}
+
+// WithCollator configures a [Parser] to return [Path] values that use
+// collator to compare strings in the ordering comparisons of filter
+// expressions, <, <=, >, and >=, rather than comparing the Unicode code
+// points of their characters, for queries over localized data. Equality
+// comparisons still compare strings exactly. See [spec.Options].Collator
+// for details.
+func WithCollator(collator Collator) Option {
+	return func(p *Parser) { p.opts.Collator = collator }
+}
+
+// WithUniqueLocations configures a [Parser] to return [Path] values that
+// select each location at most once, removing from their results the
+// values whose normalized paths they have already selected, such as the
+// second "a" selected by $['a','a'] or the overlapping descendants selected
+// by $..*..*. Useful for consumers such as patch generators that apply a
+// change to each location selected. Without it, paths return every node
+// selected, including duplicates, as RFC 9535 requires. See
+// [spec.Options].UniqueLocations for details.
+func WithUniqueLocations() Option {
+	return func(p *Parser) { p.opts.UniqueLocations = true }
+}
+
+// WithLenientNumbers configures a [Parser] to accept number syntax that
+// RFC 9535 disallows: leading zeros, such as 01, a leading plus sign, such
+// as +1, and -0 as an index or slice parameter. Use to parse queries written
+// for implementations that accept such numbers.
+func WithLenientNumbers() Option {
+	return func(p *Parser) { p.mode |= parser.LenientNumbers }
+}
+
+// WithIJSONNumbers configures a [Parser] to reject integer literals in
+// filter expressions outside the [I-JSON] interoperable range
+// [-(2^53)+1, (2^53)-1]. By default the parser preserves such integers as
+// [encoding/json.Number] values.
+//
+// [I-JSON]: https://www.rfc-editor.org/rfc/rfc7493#section-2.2
+func WithIJSONNumbers() Option {
+	return func(p *Parser) { p.mode |= parser.IJSONNumbers }
+}
+
+// WithDisabledFunctions configures a [Parser] to reject paths that call
+// the function extensions named by names with an [ErrDisabledFunction]
+// error. The Parser disables them in a copy of its [registry.Registry], so
+// that the registry configured by [WithRegistry] remains unchanged. See
+// [registry.Registry.Disable] for details.
+func WithDisabledFunctions(names ...string) Option {
+	return func(p *Parser) { p.disabled = append(p.disabled, names...) }
 }
 
-// NewParser creates a new [Parser] configured by opt.
+// WithoutRegexFunctions configures a [Parser] to reject paths that call
+// the match() and search() functions, whose regular expressions are the
+// costliest part of evaluating untrusted queries, with an
+// [ErrDisabledFunction] error. Equivalent to
+// WithDisabledFunctions("match", "search").
+func WithoutRegexFunctions() Option {
+	return WithDisabledFunctions("match", "search")
+}
+
+// NewParser creates a new [Parser] configured by opt. Unless configured by
+// [WithRegistry], it creates a new [registry.Registry] containing only the
+// RFC 9535 functions.
 func NewParser(opt ...Option) *Parser {
 	p := &Parser{}
 	for _, o := range opt {
 		o(p)
 	}
 
-	if p.reg == nil {
-		p.reg = registry.New()
+	if p.reg.Load() == nil {
+		p.reg.Store(registry.New())
 	}
+	p.reg.Store(p.disable(p.reg.Load()))
 
 	return p
 }
 
+// disable returns a copy of reg with the functions configured by
+// [WithDisabledFunctions] disabled, or reg itself if there are none.
+func (c *Parser) disable(reg *registry.Registry) *registry.Registry {
+	if len(c.disabled) == 0 {
+		return reg
+	}
+	reg = reg.Clone()
+	reg.Disable(c.disabled...)
+	return reg
+}
+
 // Parse parses path, a JSONPath query string, into a [Path]. Returns an
 // [ErrPathParse] on parse failure.
 func (c *Parser) Parse(path string) (*Path, error) {
-	q, err := parser.Parse(c.reg, path)
+	q, err := parser.ParseMode(c.Registry(), path, c.mode)
 	if err != nil {
 		//nolint:wrapcheck
 		return nil, err
 	}
-	return New(q), nil
+	return &Path{q: q, opts: c.opts}, nil
+}
+
+// Format parses query, a JSONPath query string, and returns it formatted in
+// the canonical style: shorthand notation where possible, single-quoted
+// names and strings, spaces around logical and comparison operators, and
+// no other blank space, as in $.store.book[?@.price < 10].title. Use it to
+// keep stored queries consistent, as gofmt does for Go source. Formatting
+// a formatted query returns it unchanged. Returns an [ErrPathParse] on
+// parse failure.
+func (c *Parser) Format(query string) (string, error) {
+	path, err := c.Parse(query)
+	if err != nil {
+		return "", err
+	}
+	return path.Format(canonicalFormat), nil
+}
+
+// Registry returns the [registry.Registry] c uses to look up function
+// extensions.
+func (c *Parser) Registry() *registry.Registry {
+	return c.reg.Load()
+}
+
+// SwapRegistry atomically replaces the [registry.Registry] c uses to look
+// up function extensions with reg and returns the previous registry, so
+// that long-running services can reload function extensions without
+// creating a new Parser. If c is configured by [WithDisabledFunctions], it
+// swaps in a copy of reg with those functions disabled, so that they remain
+// disabled. It first parses each of queries with the registry, such as
+// queries loaded from configuration, and if any fails to parse, returns its
+// [ErrPathParse] error and leaves c unchanged. Parses in progress complete
+// with the registry they started with, and previously parsed [Path] values
+// continue to call the functions they were parsed with.
+//
+// To add or replace functions in the current registry, swap in a modified
+// copy rather than modifying it, so that it changes atomically:
+//
+//	reg := parser.Registry().Clone()
+//	reg.Unregister("plugin")
+//	if err := reg.Register("plugin", spec.FuncValue, validator, evaluator); err != nil {
+//		return err
+//	}
+//	if _, err := parser.SwapRegistry(reg, queries...); err != nil {
+//		return err
+//	}
+func (c *Parser) SwapRegistry(reg *registry.Registry, queries ...string) (*registry.Registry, error) {
+	reg = c.disable(reg)
+	for _, query := range queries {
+		if _, err := parser.ParseMode(reg, query, c.mode); err != nil {
+			//nolint:wrapcheck
+			return nil, err
+		}
+	}
+	return c.reg.Swap(reg), nil
 }
 
 // MustParse parses path, a JSONPath query string, into a [Path]. Panics with
 // an [ErrPathParse] on parse failure.
 func (c *Parser) MustParse(path string) *Path {
-	q, err := parser.Parse(c.reg, path)
+	q, err := parser.ParseMode(c.Registry(), path, c.mode)
 	if err != nil {
 		panic(err)
 	}
-	return New(q)
+	return &Path{q: q, opts: c.opts}
 }
 
 // NodeList is a list of nodes selected by a JSONPath query. Each node
@@ -1,10 +1,19 @@
 package jsonpath_test
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/theory/jsonpath"
 	"github.com/theory/jsonpath/registry"
@@ -294,6 +303,863 @@ func firstFunc(jv []spec.PathValue) spec.PathValue {
 	return spec.Value(nodes[0])
 }
 
+// Use WithBytesAsStrings to create a [Parser] for paths that compare []byte
+// values as strings, such as values loaded from a database.
+func ExampleWithBytesAsStrings() {
+	parser := jsonpath.NewParser(jsonpath.WithBytesAsStrings())
+	path := parser.MustParse(`$[?@.name == 'Alice' || length(@.name) == 4].id`)
+
+	input := []map[string]any{
+		{"id": 1, "name": []byte("Alice")},
+		{"id": 2, "name": []byte("Bob")},
+		{"id": 3, "name": []byte("über")},
+	}
+	fmt.Printf("%v\n", path.Select(input))
+	// Output: [1 3]
+}
+
+// Compile a path to select nodes from many inputs with less overhead.
+func ExamplePath_Compile() {
+	c := jsonpath.MustParse(`$.store.book..price`).Compile()
+	fmt.Printf("%v\n", c.Select(bookstore()))
+	// Output: [8.95 12.99 8.99 22.99]
+}
+
+// Use an EvaluatorPool to select nodes without allocating scratch buffers
+// for each request. Copy the selected nodes to retain them after returning
+// the Evaluator to the pool.
+func ExampleEvaluatorPool() {
+	var pool jsonpath.EvaluatorPool
+	path := jsonpath.MustParse(`$.store.book[*].author`)
+
+	e := pool.Get()
+	authors := slices.Clone(e.Select(path, bookstore()))
+	pool.Put(e)
+	fmt.Printf("%q\n", authors)
+	// Output: ["Nigel Rees" "Evelyn Waugh" "Herman Melville" "J. R. R. Tolkien"]
+}
+
+// Use Lazy to defer parsing queries until they're used, such as for queries
+// loaded from configuration files.
+func ExampleLazy() {
+	queries := map[string]*jsonpath.LazyPath{
+		"authors": jsonpath.Lazy(`$.store.book[*].author`),
+		"broken":  jsonpath.Lazy(`$.store[`),
+	}
+
+	// Only the authors query is parsed.
+	authors, err := queries["authors"].Select(bookstore())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%q\n", authors)
+	// Output: ["Nigel Rees" "Evelyn Waugh" "Herman Melville" "J. R. R. Tolkien"]
+}
+
+// Use a PathCache to avoid parsing the same queries repeatedly, such as
+// queries sent with requests.
+func ExamplePathCache() {
+	cache := jsonpath.NewPathCache(1024, jsonpath.WithCacheTTL(time.Hour))
+	for _, query := range []string{`$.store.bicycle.color`, `$.store.bicycle.color`} {
+		path, err := cache.Get(query)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%q\n", path.Select(bookstore()))
+	}
+	stats := cache.Stats()
+	fmt.Printf("hits: %v, misses: %v\n", stats.Hits, stats.Misses)
+	// Output:
+	// ["red"]
+	// ["red"]
+	// hits: 1, misses: 1
+}
+
+// Use a Policy to enforce field-level authorization of queries and
+// documents.
+func ExamplePolicy() {
+	policy := jsonpath.NewPolicy(
+		[]*jsonpath.Path{jsonpath.MustParse(`$.store.book[*]`)},
+		[]*jsonpath.Path{jsonpath.MustParse(`$.store.book[*].isbn`)},
+	)
+
+	// Check queries before evaluating them.
+	for _, query := range []string{
+		`$.store.book[*].title`,
+		`$.store.book[?@.isbn].title`,
+		`$.store.bicycle`,
+	} {
+		fmt.Printf("%v: %v\n", query, policy.Permits(jsonpath.MustParse(query)))
+	}
+
+	// Filter documents down to permitted fields.
+	doc, err := policy.Filter(bookstore())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%q\n", jsonpath.MustParse(`$..isbn`).Select(doc))
+	fmt.Printf("%q\n", jsonpath.MustParse(`$..title`).Select(doc))
+	// Output:
+	// $.store.book[*].title: true
+	// $.store.book[?@.isbn].title: false
+	// $.store.bicycle: false
+	// []
+	// ["Sayings of the Century" "Sword of Honour" "Moby Dick" "The Lord of the Rings"]
+}
+
+// Use Optimize to rewrite a path to select the same nodes with less work.
+func ExamplePath_Optimize() {
+	path := jsonpath.MustParse(`$.store.book[2,1,0].author`)
+	opt := path.Optimize()
+	fmt.Printf("%v\n", opt)
+	fmt.Printf("%q\n", opt.Select(bookstore()))
+	// Output:
+	// $["store"]["book"][2::-1]["author"]
+	// ["Herman Melville" "Evelyn Waugh" "Nigel Rees"]
+}
+
+// Use Explain to learn why a filter does or does not select a node.
+func ExamplePath_Explain() {
+	path := jsonpath.MustParse(`$.books[?@.price < 10 && @.author]`)
+	trace := path.Explain(map[string]any{"books": []any{
+		map[string]any{"price": 8.95, "author": "Nigel Rees"},
+		map[string]any{"price": 12.99, "author": "Evelyn Waugh"},
+		map[string]any{"price": 8.99},
+	}})
+	fmt.Print(trace)
+	// Output:
+	// query $["books"][?@["price"] < 10 && @["author"]]
+	// segment ["books"]
+	//   considered $
+	//   selector "books" matched $['books']
+	// segment [?@["price"] < 10 && @["author"]]
+	//   considered $['books']
+	//   selector ?@["price"] < 10 && @["author"] matched $['books'][0]
+	//     $['books'][0]: true
+	//       @["price"] < 10: true
+	//       @["author"]: true
+	//     $['books'][1]: false
+	//       @["price"] < 10: false
+	//       @["author"]: true
+	//     $['books'][2]: false
+	//       @["price"] < 10: true
+	//       @["author"]: false
+	// result $['books'][0]
+}
+
+func ExamplePath_Tree() {
+	path := jsonpath.MustParse(`$.store..book[?@.price < 10 && length(@.tags) > 0].title`)
+	fmt.Print(path.Tree())
+	// Output:
+	// query $
+	//   child-segment ["store"]
+	//     name "store"
+	//   descendant-segment ..["book"]
+	//     name "book"
+	//   child-segment [?@["price"] < 10 && length(@["tags"]) > 0]
+	//     filter ?@["price"] < 10 && length(@["tags"]) > 0
+	//       and @["price"] < 10 && length(@["tags"]) > 0
+	//         comparison <
+	//           singular-query @
+	//             name "price"
+	//           literal 10
+	//         comparison >
+	//           function length
+	//             singular-query @
+	//               name "tags"
+	//           literal 0
+	//   child-segment ["title"]
+	//     name "title"
+}
+
+func ExampleFormat() {
+	for _, query := range []string{
+		`$["store"]['book'][ ?@.price<10 && @["category"]=="fiction" ].title`,
+		`$..*[0:2]["first name"]`,
+	} {
+		formatted, err := jsonpath.Format(query)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(formatted)
+	}
+	// Output:
+	// $.store.book[?@.price < 10 && @.category == 'fiction'].title
+	// $..*[:2]['first name']
+}
+
+func ExamplePath_Format() {
+	path := jsonpath.MustParse(`$["store"].book[?@.price<10 && @["category"]=="fiction"].title`)
+	fmt.Println(path.Format(jsonpath.FormatOpts{}))
+	fmt.Println(path.Format(jsonpath.FormatOpts{Shorthand: true, SingleQuotes: true}))
+	fmt.Println(path.Format(jsonpath.FormatOpts{Shorthand: true, Compact: true}))
+	// Output:
+	// $["store"]["book"][?@["price"] < 10 && @["category"] == "fiction"]["title"]
+	// $.store.book[?@.price < 10 && @.category == 'fiction'].title
+	// $.store.book[?@.price<10&&@.category=="fiction"].title
+}
+
+// Use Lint to validate queries without evaluating them.
+func ExampleLint() {
+	for _, query := range []string{
+		`$.store.book[?@.price < 10].title`,
+		`$.store.book[?@.price < 10 || 1 == 1].title`,
+		`$.store.book[1:1].title`,
+		`$.store.book[?nonesuch(@)]`,
+	} {
+		fmt.Println(query)
+		for _, d := range jsonpath.Lint(query) {
+			fmt.Printf("  %v\n", d)
+		}
+	}
+	// Output:
+	// $.store.book[?@.price < 10].title
+	// $.store.book[?@.price < 10 || 1 == 1].title
+	//   warning: filter in segment 3 of $["store"]["book"][?@]["title"] is always true and selects the same values as * (constant-filter)
+	// $.store.book[1:1].title
+	//   warning: selector 1:1 in segment 3 of $["store"]["book"][1:1]["title"] selects nothing (unreachable)
+	//   warning: segments after segment 3 of $["store"]["book"][1:1]["title"] are unreachable because it selects nothing (unreachable)
+	// $.store.book[?nonesuch(@)]
+	//   error: jsonpath: unknown function nonesuch() at position 15 (unknown-function)
+}
+
+// Use Hover and Complete to describe and complete queries in editors, and
+// Lint to report their problems.
+func ExampleParser_Hover() {
+	schema, err := jsonpath.ParseSchema([]byte(`{
+	  "type": "object",
+	  "properties": {
+	    "users": {
+	      "type": "array",
+	      "items": {
+	        "type": "object",
+	        "properties": {"email": {}, "age": {}}
+	      }
+	    }
+	  }
+	}`))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p := jsonpath.NewParser()
+	hover := p.Hover(`$.users[?@.age >= 21]`, 10)
+	fmt.Printf("%v-%v: %v\n", hover.Start, hover.End, hover.Description)
+
+	for _, c := range p.Complete(`$.users[0].e`, 12, schema) {
+		fmt.Printf("%v %v-%v: %v\n", c.Kind, c.Start, c.End, c.Text)
+	}
+	// Output:
+	// 7-21: selects the members and elements that match filter ?@["age"] >= 21 of each node
+	// member 10-12: .email
+}
+
+// Use CheckSchema to find typos and type errors in queries against a JSON
+// Schema.
+func ExamplePath_CheckSchema() {
+	schema, err := jsonpath.ParseSchema([]byte(`{
+	  "type": "object",
+	  "properties": {
+	    "users": {
+	      "type": "array",
+	      "items": {
+	        "type": "object",
+	        "additionalProperties": false,
+	        "properties": {
+	          "email": {"type": "string"},
+	          "age": {"type": "integer"}
+	        }
+	      }
+	    }
+	  }
+	}`))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	path := jsonpath.MustParse(`$.users[?@.age >= "21"].emial`)
+	for _, d := range path.CheckSchema(schema) {
+		fmt.Println(d)
+		if d.Hint != "" {
+			fmt.Println("  hint:", d.Hint)
+		}
+	}
+	// Output:
+	// warning: comparison @["age"] >= "21" is always false because @["age"] is never a string (type-mismatch)
+	// warning: selector "emial" in segment 3 of $["users"][?@["age"] >= "21"]["emial"] never selects a value because the schema defines no member "emial" (schema-mismatch)
+	//   hint: did you mean "email"?
+}
+
+func ExamplePath_SelectStats() {
+	path := jsonpath.MustParse(`$.store.book[?@.price < 10].title`)
+	nodes, stats := path.SelectStats(bookstore())
+	fmt.Printf("%q\n", nodes)
+	fmt.Printf("Nodes visited: %v\n", stats.Nodes)
+	fmt.Printf("Filter evaluations: %v\n", stats.Filters)
+	// Output:
+	// ["Sayings of the Century" "Moby Dick"]
+	// Nodes visited: 9
+	// Filter evaluations: 4
+}
+
+// Use Cost to reject expensive queries before running them.
+func ExamplePath_Cost() {
+	for _, expr := range []string{
+		`$.store.book[0].title`,
+		`$.store.book[?@.price < 10].title`,
+		`$..*[?search(@.title, "Moby")]`,
+	} {
+		cost := jsonpath.MustParse(expr).Cost()
+		if cost.Multiplier > 1000 {
+			fmt.Printf("%v: too expensive (%v)\n", expr, cost.Multiplier)
+			continue
+		}
+		fmt.Printf("%v: singular: %v, filters: %v\n", expr, cost.Singular, cost.Filters)
+	}
+	// Output:
+	// $.store.book[0].title: singular: true, filters: 0
+	// $.store.book[?@.price < 10].title: singular: false, filters: 1
+	// $..*[?search(@.title, "Moby")]: too expensive (11100)
+}
+
+// Use SelectValue to distinguish a JSON null from a missing value.
+func ExamplePath_SelectValue() {
+	input := map[string]any{"name": "Kamala", "nickname": nil}
+	for _, expr := range []string{`$.name`, `$.nickname`, `$.title`} {
+		switch val := jsonpath.MustParse(expr).SelectValue(input); val {
+		case jsonpath.Nothing:
+			fmt.Printf("%v: no value\n", expr)
+		case nil:
+			fmt.Printf("%v: null\n", expr)
+		default:
+			fmt.Printf("%v: %v\n", expr, val)
+		}
+	}
+	// Output:
+	// $.name: Kamala
+	// $.nickname: null
+	// $.title: no value
+}
+
+// Use WithMaxNodes to limit the work done by untrusted queries.
+func ExamplePath_TrySelect() {
+	parser := jsonpath.NewParser(jsonpath.WithMaxNodes(20))
+	for _, expr := range []string{`$.store.book[0].author`, `$..*..*`} {
+		nodes, err := parser.MustParse(expr).TrySelect(bookstore())
+		if err != nil {
+			fmt.Printf("%v: %v\n", expr, err)
+			continue
+		}
+		fmt.Printf("%v: %q\n", expr, nodes)
+	}
+	// Output:
+	// $.store.book[0].author: ["Nigel Rees"]
+	// $..*..*: evaluation budget exceeded: visited more than 20 nodes
+}
+
+// Use SelectE to fail loudly on data that RFC 9535 quietly skips, such as
+// a price stored as a string.
+func ExamplePath_SelectE() {
+	input := []any{
+		map[string]any{"item": "pen", "price": 2},
+		map[string]any{"item": "ink", "price": "8"},
+	}
+	path := jsonpath.MustParse(`$[?@.price < 10].item`)
+	fmt.Printf("Select: %q\n", path.Select(input))
+	if _, err := path.SelectE(input); err != nil {
+		fmt.Printf("SelectE: %v\n", err)
+	}
+	// Output:
+	// Select: ["pen"]
+	// SelectE: jsonpath: comparison of values of different types: expr="@[\"price\"] < 10" left=string right=int64
+}
+
+// Use a Builder to assemble a path from names and filter expressions
+// without escaping them in a query string.
+func ExampleBuilder() {
+	author := "Evelyn Waugh"
+	path := jsonpath.NewBuilder().
+		Child("store").
+		Child("book").
+		Filter(spec.And(spec.Comparison(
+			spec.SingularQuery(false, spec.Name("author")),
+			spec.EqualTo,
+			spec.Literal(author),
+		))).
+		Child("title").
+		MustBuild()
+	fmt.Printf("%v\n", path)
+	fmt.Printf("%q\n", path.Select(bookstore()))
+	// Output:
+	// $["store"]["book"][?@["author"] == "Evelyn Waugh"]["title"]
+	// ["Sword of Honour"]
+}
+
+// Use Rewrite to adapt a path to a schema change, here by moving all
+// members under a "data" object and renaming "cost" to "price".
+func ExamplePath_Rewrite() {
+	path := jsonpath.MustParse(`$.items[?@.cost < 10].name`)
+	path, err := path.Rewrite(func(node any) any {
+		switch node := node.(type) {
+		case *spec.PathQuery:
+			if node.IsRoot() {
+				segs := append([]*spec.Segment{spec.Child(spec.Name("data"))}, node.Segments()...)
+				return spec.Query(true, segs...)
+			}
+		case spec.Name:
+			if node == "cost" {
+				return spec.Name("price")
+			}
+		}
+		return node
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(path)
+	// Output: $["data"]["items"][?@["price"] < 10]["name"]
+}
+
+// Use Subsumes to check that a path granting access to fields covers the
+// paths that a client requests, and Overlaps to find requests that touch
+// any of them.
+func ExamplePath_Subsumes() {
+	grant := jsonpath.MustParse(`$.store.book[*]["title","author"]`)
+	for _, req := range []string{
+		`$.store.book[0].title`,
+		`$.store.book[?@.price < 10].author`,
+		`$.store.book[*].price`,
+		`$..author`,
+	} {
+		path := jsonpath.MustParse(req)
+		fmt.Printf("%v: subsumed=%v overlaps=%v\n", req, grant.Subsumes(path), grant.Overlaps(path))
+	}
+	// Output:
+	// $.store.book[0].title: subsumed=true overlaps=true
+	// $.store.book[?@.price < 10].author: subsumed=true overlaps=true
+	// $.store.book[*].price: subsumed=false overlaps=false
+	// $..author: subsumed=false overlaps=true
+}
+
+// Use SelectMap to export selected values keyed by their normalized paths.
+func ExamplePath_SelectMap() {
+	doc := map[string]any{
+		"env": map[string]any{"HOST": "localhost", "PORT": 8080},
+	}
+	vals := jsonpath.MustParse(`$.env.*`).SelectMap(doc)
+	for _, key := range slices.Sorted(maps.Keys(vals)) {
+		fmt.Printf("%v = %v\n", key, vals[key])
+	}
+	// Output:
+	// $['env']['HOST'] = localhost
+	// $['env']['PORT'] = 8080
+}
+
+// Use Diff to report the changes between two documents as normalized paths.
+func ExampleDiff() {
+	before := map[string]any{"name": "web", "replicas": 2, "ports": []any{80}}
+	after := map[string]any{"name": "web", "replicas": 3, "ports": []any{80, 443}}
+	for _, change := range jsonpath.Diff(before, after) {
+		fmt.Println(change)
+	}
+	// Output:
+	// $['ports'][1]: Nothing -> 443
+	// $['replicas']: 2 -> 3
+}
+
+// Use Path.Diff to scope a diff to the parts of two documents that a path
+// selects.
+func ExamplePath_Diff() {
+	before := map[string]any{
+		"metadata": map[string]any{"generation": 1},
+		"spec":     map[string]any{"image": "web:1.0", "replicas": 2},
+	}
+	after := map[string]any{
+		"metadata": map[string]any{"generation": 2},
+		"spec":     map[string]any{"image": "web:1.1", "replicas": 2},
+	}
+	for _, change := range jsonpath.MustParse(`$.spec`).Diff(before, after) {
+		fmt.Printf("%v changed from %v to %v\n", change.Path, change.Old, change.New)
+	}
+	// Output: $['spec']['image'] changed from web:1.0 to web:1.1
+}
+
+// Use Project to return only the fields a client requests.
+func ExamplePath_Project() {
+	var doc any
+	if err := json.Unmarshal([]byte(`{
+	  "store": {
+	    "name": "Books & Co",
+	    "book": [
+	      {"title": "Sayings of the Century", "author": "Nigel Rees", "price": 8.95},
+	      {"title": "Moby Dick", "author": "Herman Melville", "price": 8.99}
+	    ]
+	  }
+	}`), &doc); err != nil {
+		log.Fatal(err)
+	}
+
+	path := jsonpath.MustParse(`$.store.book[*]["title","price"]`)
+	out, err := json.Marshal(path.Project(doc))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(out))
+	// Output: {"store":{"book":[{"price":8.95,"title":"Sayings of the Century"},{"price":8.99,"title":"Moby Dick"}]}}
+}
+
+// Use MatchesPath to route change events, keyed by normalized path, to the
+// subscribers whose paths select them.
+func ExamplePath_MatchesPath() {
+	sub := jsonpath.MustParse(`$.store.book[*].price`)
+	for _, np := range []spec.NormalizedPath{
+		spec.Normalized(spec.Name("store"), spec.Name("book"), spec.Index(2), spec.Name("price")),
+		spec.Normalized(spec.Name("store"), spec.Name("book"), spec.Index(2), spec.Name("title")),
+		spec.Normalized(spec.Name("store"), spec.Name("bicycle"), spec.Name("price")),
+	} {
+		fmt.Printf("%v: %v\n", np, sub.MatchesPath(np))
+	}
+	// Output:
+	// $['store']['book'][2]['price']: true
+	// $['store']['book'][2]['title']: false
+	// $['store']['bicycle']['price']: false
+}
+
+// Use SingularPrefix to look up the static part of a path directly, here
+// as a JSON Pointer, and select the rest from the value it identifies.
+func ExamplePath_SingularPrefix() {
+	path := jsonpath.MustParse(`$.store.book[?@.price < 10].title`)
+	prefix, rest := path.SingularPrefix()
+	fmt.Printf("prefix: %v\n", prefix.Pointer())
+	fmt.Printf("rest: %v\n", rest)
+	// Output:
+	// prefix: /store/book
+	// rest: $[?@["price"] < 10]["title"]
+}
+
+// Use Join and Append to compose a base path, such as from configuration,
+// with per-request suffixes without assembling query strings.
+func ExamplePath_Join() {
+	base := jsonpath.MustParse(`$.store.book[?@.price < 10]`)
+	suffix := jsonpath.New(spec.Query(false, spec.Child(spec.Name("title"))))
+	fmt.Println(base.Join(suffix).Select(bookstore()))
+	fmt.Println(base.Append(spec.Child(spec.Name("author"))).Select(bookstore()))
+	// Output:
+	// [Sayings of the Century Moby Dick]
+	// [Nigel Rees Herman Melville]
+}
+
+// Use FromPointer to select the value identified by a JSON Pointer, such
+// as from a JSON Patch operation, and NormalizedPath.Pointer to convert the
+// locations of selected nodes to JSON Pointers.
+func ExampleFromPointer() {
+	path, err := jsonpath.FromPointer("/store/book/1/title")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(path)
+	for _, node := range path.SelectLocated(bookstore()) {
+		fmt.Printf("%v: %v\n", node.Path.Pointer(), node.Node)
+	}
+	// Output:
+	// $["store"]["book"]["1",1]["title"]
+	// /store/book/1/title: Sword of Honour
+}
+
+// Use ToJQ to translate a path into a jq filter for debugging with the jq
+// command-line tool.
+func ExamplePath_ToJQ() {
+	path := jsonpath.MustParse(`$.store.book[*].author`)
+	filter, err := path.ToJQ()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(filter)
+	// Output: objects | select(has("store")) | .store | objects | select(has("book")) | .book | .[]? | objects | select(has("author")) | .author
+}
+
+// Use a PathSet to select several paths in a single traversal of the
+// input.
+func ExamplePathSet() {
+	set := jsonpath.NewPathSet(
+		jsonpath.MustParse(`$.store.book[*].author`),
+		jsonpath.MustParse(`$.store.book[*].title`),
+		jsonpath.MustParse(`$.store.bicycle.color`),
+	)
+	for i, nodes := range set.Select(bookstore()) {
+		fmt.Printf("%v: %q\n", set.Paths()[i], nodes)
+	}
+	// Output:
+	// $["store"]["book"][*]["author"]: ["Nigel Rees" "Evelyn Waugh" "Herman Melville" "J. R. R. Tolkien"]
+	// $["store"]["book"][*]["title"]: ["Sayings of the Century" "Sword of Honour" "Moby Dick" "The Lord of the Rings"]
+	// $["store"]["bicycle"]["color"]: ["red"]
+}
+
+// Use Extract to select labeled values for an ETL mapping in a single
+// traversal.
+func ExampleExtract() {
+	fields, err := jsonpath.Extract(bookstore(), map[string]*jsonpath.Path{
+		"authors": jsonpath.MustParse(`$.store.book[*].author`),
+		"cheap":   jsonpath.MustParse(`$.store.book[?@.price < 10].title`),
+		"color":   jsonpath.MustParse(`$.store.bicycle.color`),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, label := range slices.Sorted(maps.Keys(fields)) {
+		fmt.Printf("%v: %q\n", label, fields[label])
+	}
+	// Output:
+	// authors: ["Nigel Rees" "Evelyn Waugh" "Herman Melville" "J. R. R. Tolkien"]
+	// cheap: ["Sayings of the Century" "Moby Dick"]
+	// color: ["red"]
+}
+
+// Use a Watcher to keep the results of a path current as a document
+// changes.
+func ExampleWatcher() {
+	doc := map[string]any{
+		"services": []any{
+			map[string]any{"name": "web", "port": 8080},
+			map[string]any{"name": "db", "port": 5432},
+		},
+	}
+	w := jsonpath.NewWatcher(jsonpath.MustParse(`$.services[*].port`), doc)
+	fmt.Println(slices.Collect(w.Results().Nodes()))
+
+	changed, err := w.Update(
+		spec.Normalized(spec.Name("services"), spec.Index(1), spec.Name("port")),
+		5433,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(changed, slices.Collect(w.Results().Nodes()))
+
+	changed, err = w.Update(
+		spec.Normalized(spec.Name("services"), spec.Index(0), spec.Name("name")),
+		"www",
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(changed, slices.Collect(w.Results().Nodes()))
+	// Output:
+	// [8080 5432]
+	// true [8080 5433]
+	// false [8080 5433]
+}
+
+// Use FuncMap to select values with JSONPath queries in templates.
+func ExampleFuncMap() {
+	tmpl := template.Must(template.New("report").Funcs(jsonpath.FuncMap()).Parse(
+		`Owner: {{ jsonpathFirst . "$.owner.name" }}
+{{ range jsonpath . "$.items[?@.active == true].name" }}- {{ . }}
+{{ end }}`,
+	))
+	data := map[string]any{
+		"owner": map[string]any{"name": "Ann"},
+		"items": []any{
+			map[string]any{"name": "alpha", "active": true},
+			map[string]any{"name": "beta", "active": false},
+			map[string]any{"name": "gamma", "active": true},
+		},
+	}
+	if err := tmpl.Execute(os.Stdout, data); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// Owner: Ann
+	// - alpha
+	// - gamma
+}
+
+// Use Stream to select nodes from JSON read from an [io.Reader], without
+// decoding the parts of the input that the query doesn't select.
+func ExampleSelectAs() {
+	type Book struct {
+		Title string  `json:"title"`
+		Price float64 `json:"price"`
+	}
+
+	p := jsonpath.MustParse(`$.store.book[?@.price < 10]`)
+	books, err := jsonpath.SelectAs[Book](p, bookstore())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, b := range books {
+		fmt.Printf("%v: %v\n", b.Title, b.Price)
+	}
+
+	prices, err := jsonpath.SelectAs[int](jsonpath.MustParse(`$..price`), bookstore())
+	fmt.Println(prices, err)
+	// Output:
+	// Sayings of the Century: 8.95
+	// Moby Dick: 8.99
+	// [] jsonpath: cannot convert value at $['store']['book'][0]['price']: no conversion from float64 to int
+}
+
+func ExamplePath_DecodeInto() {
+	var bicycle struct {
+		Color string  `json:"color"`
+		Price float64 `json:"price"`
+	}
+	if err := jsonpath.MustParse(`$.store.bicycle`).DecodeInto(bookstore(), &bicycle); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%+v\n", bicycle)
+
+	var authors []string
+	if err := jsonpath.MustParse(`$..author`).DecodeInto(bookstore(), &authors); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(authors)
+	// Output:
+	// {Color:red Price:399}
+	// [Nigel Rees Evelyn Waugh Herman Melville J. R. R. Tolkien]
+}
+
+func ExampleStream() {
+	input := strings.NewReader(`{
+	  "logs": [
+	    {"level": "info", "msg": "starting"},
+	    {"level": "error", "msg": "disk full"},
+	    {"level": "info", "msg": "stopping"}
+	  ],
+	  "archive": [{"level": "debug", "msg": "ignored"}]
+	}`)
+
+	path := jsonpath.MustParse(`$.logs[*].msg`)
+	for loc, node := range jsonpath.Stream(input, path) {
+		if err, ok := node.(error); ok && loc == nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%v: %v\n", loc, node)
+	}
+	// Output:
+	// $['logs'][0]['msg']: starting
+	// $['logs'][1]['msg']: disk full
+	// $['logs'][2]['msg']: stopping
+}
+
+// Use SelectMany with an NDJSONReader to apply a query to each document in
+// newline-delimited JSON, such as a log file.
+func ExamplePath_SelectMany() {
+	logs := jsonpath.NewNDJSONReader(strings.NewReader(
+		`{"level": "info", "msg": "starting"}
+{"level": "error", "msg": "disk full", "code": 28}
+{"level": "error", "msg": "retrying"}
+`))
+
+	path := jsonpath.MustParse(`$.code`)
+	for idx, node := range path.SelectMany(logs.Documents()) {
+		fmt.Printf("line %d: %v = %v\n", idx+1, node.Path, node.Node)
+	}
+	if err := logs.Err(); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// line 2: $['code'] = 28
+}
+
+// Use SelectChan to process nodes as the traversal finds them.
+func ExamplePath_SelectChan() {
+	path := jsonpath.MustParse(`$..book[?@.price < 10].title`)
+	nodes, errFunc := path.SelectChan(context.Background(), bookstore())
+	for node := range nodes {
+		fmt.Printf("%v: %v\n", node.Path, node.Node)
+	}
+	if err := errFunc(); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// $['store']['book'][0]['title']: Sayings of the Century
+	// $['store']['book'][2]['title']: Moby Dick
+}
+
+// orderedMap is a minimal ordered map that implements [jsonpath.Object].
+type orderedMap struct {
+	keys []string
+	vals map[string]any
+}
+
+func (m *orderedMap) Get(name string) (any, bool) {
+	v, ok := m.vals[name]
+	return v, ok
+}
+
+func (m *orderedMap) Len() int { return len(m.keys) }
+
+func (m *orderedMap) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, k := range m.keys {
+			if !yield(k, m.vals[k]) {
+				return
+			}
+		}
+	}
+}
+
+// Implement [jsonpath.Object] to query custom types such as ordered maps.
+// Wildcard and descendant segments select members in the order returned by
+// Iterate.
+func ExampleObject() {
+	input := &orderedMap{
+		keys: []string{"z", "y", "x"},
+		vals: map[string]any{"z": 1, "y": 2, "x": []any{3, 4}},
+	}
+
+	path := jsonpath.MustParse(`$..*`)
+	for node := range path.Select(input).All() {
+		fmt.Println(node)
+	}
+	// Output:
+	// 1
+	// 2
+	// [3 4]
+	// 3
+	// 4
+}
+
+// semver is a minimal semantic version that implements
+// [jsonpath.Comparable].
+type semver struct{ major, minor, patch int }
+
+func (v semver) CompareTo(other any) (int, bool) {
+	var o semver
+	switch other := other.(type) {
+	case semver:
+		o = other
+	case string:
+		if _, err := fmt.Sscanf(other, "%d.%d.%d", &o.major, &o.minor, &o.patch); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if c := cmp.Compare(v.major, o.major); c != 0 {
+		return c, true
+	}
+	if c := cmp.Compare(v.minor, o.minor); c != 0 {
+		return c, true
+	}
+	return cmp.Compare(v.patch, o.patch), true
+}
+
+// Implement [jsonpath.Comparable] to compare custom scalar types in filter
+// expressions.
+func ExampleComparable() {
+	input := []any{
+		map[string]any{"name": "a", "version": semver{1, 9, 0}},
+		map[string]any{"name": "b", "version": semver{1, 10, 2}},
+		map[string]any{"name": "c", "version": semver{2, 0, 0}},
+	}
+
+	path := jsonpath.MustParse(`$[?@.version >= '1.10.0' && @.version < '2.0.0'].name`)
+	fmt.Println(path.Select(input))
+	// Output: [b]
+}
+
 // bookstore returns an unmarshaled JSON object.
 func bookstore() any {
 	src := []byte(`{
@@ -1,13 +1,22 @@
 package jsonpath
 
 import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
 	"encoding"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -156,6 +165,15 @@ func TestParseSpecExamples(t *testing.T) {
 			a.Equal(p.q, p.Query())
 			a.Equal(p.q.String(), p.String())
 
+			// Test JSON encoding.
+			a.Implements((*json.Marshaler)(nil), p)
+			a.Implements((*json.Unmarshaler)(nil), p)
+			data, err = json.Marshal(p)
+			r.NoError(err)
+			p.q = nil
+			r.NoError(json.Unmarshal(data, p))
+			a.Equal(p.q.String(), p.String())
+
 			// Test execution.
 			res := p.Select(val)
 			loc := p.SelectLocated(val)
@@ -296,6 +314,86 @@ func TestParseCompliance(t *testing.T) {
 	}
 }
 
+func TestParserNumbers(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		path string
+		opts []Option
+		exp  string
+		err  string
+	}{
+		{
+			test: "strict",
+			path: "$[01]",
+			err:  "jsonpath: invalid number literal at position 3",
+		},
+		{
+			test: "lenient",
+			path: "$[01, -0, +2]",
+			opts: []Option{WithLenientNumbers()},
+			exp:  "$[1,0,2]",
+		},
+		{
+			test: "big_int",
+			path: "$[?@ == 9007199254740992]",
+			exp:  "$[?@ == 9007199254740992]",
+		},
+		{
+			test: "ijson",
+			path: "$[?@ == 9007199254740992]",
+			opts: []Option{WithIJSONNumbers()},
+			err:  `jsonpath: cannot parse "9007199254740992", value out of I-JSON range at position 9`,
+		},
+		{
+			test: "ijson_lenient",
+			path: "$[?@ == +01]",
+			opts: []Option{WithIJSONNumbers(), WithLenientNumbers()},
+			exp:  "$[?@ == 1]",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			p, err := NewParser(tc.opts...).Parse(tc.path)
+			if tc.err == "" {
+				r.NoError(err)
+				a.Equal(tc.exp, p.String())
+			} else {
+				a.Nil(p)
+				r.EqualError(err, tc.err)
+				r.ErrorIs(err, ErrPathParse)
+			}
+		})
+	}
+}
+
+func TestParseErrorKinds(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		path string
+		kind error
+	}{
+		{"$[", ErrSyntax},
+		{"$[?match(@, 'a') == true]", ErrType},
+		{"$[?@.* == 1]", ErrNotSingular},
+		{"$[?nonesuch(@)]", ErrUnknownFunction},
+		{"$[9007199254740992]", ErrLimitExceeded},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			_, err := Parse(tc.path)
+			a.ErrorIs(err, ErrPathParse)
+			a.ErrorIs(err, tc.kind)
+		})
+	}
+}
+
 func TestParser(t *testing.T) {
 	t.Parallel()
 	reg := registry.New()
@@ -335,8 +433,9 @@ func TestParser(t *testing.T) {
 				parser = NewParser()
 			} else {
 				parser = NewParser(WithRegistry(tc.reg))
-				a.Equal(tc.reg, parser.reg)
+				a.Same(tc.reg, parser.Registry())
 			}
+			a.Same(parser.reg.Load(), parser.Registry())
 
 			// Test Parse and MustParse methods.
 			p, err := parser.Parse(tc.path)
@@ -380,6 +479,169 @@ func TestParser(t *testing.T) {
 	}
 }
 
+func TestSwapRegistry(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// Register a version of the plugin function.
+	plugin := func(reg *registry.Registry, version string) {
+		t.Helper()
+		reg.Unregister("plugin")
+		r.NoError(reg.Register(
+			"plugin",
+			spec.FuncValue,
+			func([]spec.FuncExprArg) error { return nil },
+			func([]spec.PathValue) spec.PathValue { return spec.Value(version) },
+		))
+	}
+	parser := NewParser()
+	plugin(parser.Registry(), "v1")
+	input := []any{"v1", "v2"}
+	v1 := parser.MustParse(`$[?@ == plugin()]`)
+	a.Equal(NodeList{"v1"}, v1.Select(input))
+
+	// Swap in a copy with a new version.
+	old := parser.Registry()
+	reg := old.Clone()
+	plugin(reg, "v2")
+	prev, err := parser.SwapRegistry(reg, `$[?@ == plugin()]`, `$.x`)
+	r.NoError(err)
+	a.Same(old, prev)
+	a.Same(reg, parser.Registry())
+	a.Equal(NodeList{"v2"}, parser.MustParse(`$[?@ == plugin()]`).Select(input))
+
+	// Paths should continue to call the functions they were parsed with.
+	a.Equal(NodeList{"v1"}, v1.Select(input))
+
+	// Should not swap a registry that fails to parse the queries.
+	prev, err = parser.SwapRegistry(registry.New(), `$.x`, `$[?@ == plugin()]`)
+	r.ErrorIs(err, ErrUnknownFunction)
+	r.ErrorIs(err, ErrPathParse)
+	a.Nil(prev)
+	a.Same(reg, parser.Registry())
+
+	// Should apply the parser's mode.
+	lenient := NewParser(WithLenientNumbers())
+	_, err = lenient.SwapRegistry(registry.New(), `$[01]`)
+	r.NoError(err)
+	_, err = parser.SwapRegistry(registry.New(), `$[01]`)
+	r.ErrorIs(err, ErrSyntax)
+}
+
+func TestSwapRegistryConcurrency(t *testing.T) {
+	t.Parallel()
+
+	// Run with -race to detect data races.
+	parser := NewParser()
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				path, err := parser.Parse(`$[?length(@) > 1]`)
+				if assert.NoError(t, err) {
+					assert.Equal(t, NodeList{"ab"}, path.Select([]any{"a", "ab"}))
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				_, err := parser.SwapRegistry(parser.Registry().Clone(), `$[?length(@) > 1]`)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDisabledFunctions(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	reg := registry.New()
+	parser := NewParser(WithRegistry(reg), WithoutRegexFunctions(), WithDisabledFunctions("value"))
+	for _, query := range []string{
+		`$[?match(@, "a")]`,
+		`$[?search(@, "a")]`,
+		`$[?value(@.a) == 1]`,
+	} {
+		_, err := parser.Parse(query)
+		r.ErrorIs(err, ErrDisabledFunction, query)
+		r.ErrorIs(err, ErrPathParse, query)
+		r.NotErrorIs(err, ErrUnknownFunction, query)
+	}
+	_, err := parser.Parse(`$[?nonesuch(@)]`)
+	r.ErrorIs(err, ErrUnknownFunction)
+	path, err := parser.Parse(`$[?length(@) > 1]`)
+	r.NoError(err)
+	a.Equal(NodeList{"ab"}, path.Select([]any{"a", "ab"}))
+
+	// Should not modify the configured registry.
+	a.NotSame(reg, parser.Registry())
+	a.NotNil(reg.Get("match"))
+	a.False(reg.Disabled("match"))
+
+	// Should keep functions disabled in swapped registries.
+	_, err = parser.SwapRegistry(reg, `$[?length(@) > 1]`)
+	r.NoError(err)
+	a.NotSame(reg, parser.Registry())
+	_, err = parser.Parse(`$[?match(@, "a")]`)
+	r.ErrorIs(err, ErrDisabledFunction)
+	_, err = parser.SwapRegistry(reg, `$[?search(@, "a")]`)
+	r.ErrorIs(err, ErrDisabledFunction)
+}
+
+func TestParserIsolation(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// Register different functions with the same name in two parsers.
+	register := func(p *Parser, val string) {
+		t.Helper()
+		r.NoError(p.Registry().Register(
+			"tag",
+			spec.FuncValue,
+			func([]spec.FuncExprArg) error { return nil },
+			func([]spec.PathValue) spec.PathValue { return spec.Value(val) },
+		))
+	}
+	p1 := NewParser(WithStrict())
+	p2 := NewParser(WithLenientNumbers())
+	register(p1, "a")
+	register(p2, "b")
+	a.NotSame(p1.Registry(), p2.Registry())
+
+	input := []any{"a", "b"}
+	a.Equal(NodeList{"a"}, p1.MustParse(`$[?@ == tag()]`).Select(input))
+	a.Equal(NodeList{"b"}, p2.MustParse(`$[?@ == tag()]`).Select(input))
+
+	// Options should apply only to their parsers.
+	_, err := p1.Parse(`$[01]`)
+	r.ErrorIs(err, ErrSyntax)
+	a.Equal(NodeList{"b"}, p2.MustParse(`$[01]`).Select(input))
+	a.True(p1.MustParse(`$`).opts.Strict)
+	a.False(p2.MustParse(`$`).opts.Strict)
+
+	// Neither should affect new parsers or the package functions.
+	for _, p := range []*Parser{NewParser(), nil} {
+		parse := Parse
+		if p != nil {
+			parse = p.Parse
+		}
+		_, err = parse(`$[?@ == tag()]`)
+		r.ErrorIs(err, ErrUnknownFunction)
+		_, err = parse(`$[01]`)
+		r.ErrorIs(err, ErrSyntax)
+	}
+	r.ErrorIs(new(Path).UnmarshalText([]byte(`$[?@ == tag()]`)), ErrUnknownFunction)
+	a.NotContains(registry.New().Names(), "tag")
+}
+
 func norm(sel ...any) spec.NormalizedPath {
 	path := make(spec.NormalizedPath, len(sel))
 	for i, s := range sel {
@@ -395,6 +657,1203 @@ func norm(sel ...any) spec.NormalizedPath {
 	return path
 }
 
+func TestArbitraryPrecision(t *testing.T) {
+	t.Parallel()
+
+	dec := json.NewDecoder(strings.NewReader(`[
+		{"id": 1, "amount": 12345678901234567890.12},
+		{"id": 2, "amount": 12345678901234567890.13},
+		{"id": 3, "amount": 170141183460469231731687303715884105727}
+	]`))
+	dec.UseNumber()
+	var input any
+	require.NoError(t, dec.Decode(&input))
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  NodeList
+	}{
+		{
+			test: "decimal_eq",
+			path: `$[?@.amount == 12345678901234567890.13].id`,
+			exp:  NodeList{json.Number("2")},
+		},
+		{
+			test: "decimal_lt",
+			path: `$[?@.amount < 12345678901234567890.13].id`,
+			exp:  NodeList{json.Number("1")},
+		},
+		{
+			test: "big_int_eq",
+			path: `$[?@.amount == 170141183460469231731687303715884105727].id`,
+			exp:  NodeList{json.Number("3")},
+		},
+		{
+			test: "big_int_gt",
+			path: `$[?@.amount > 170141183460469231731687303715884105726].id`,
+			exp:  NodeList{json.Number("3")},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, MustParse(tc.path).Select(input))
+		})
+	}
+
+	// Values of arbitrary-precision types.
+	vals := []any{big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 100)}
+	assert.Equal(
+		t,
+		NodeList{vals[1]},
+		MustParse(`$[?@ == 1267650600228229401496703205376]`).Select(vals),
+	)
+
+	// Large integers, such as uint64 IDs, compare exactly.
+	ids := []any{
+		uint64(1 << 53), uint64(1<<53 + 1), uint64(math.MaxUint64 - 1), uint64(math.MaxUint64),
+	}
+	a := assert.New(t)
+	a.Equal(NodeList{ids[1]}, MustParse(`$[?@ == 9007199254740993]`).Select(ids))
+	a.Equal(NodeList{ids[0]}, MustParse(`$[?@ < 9007199254740993]`).Select(ids))
+	a.Equal(NodeList{ids[1]}, MustParse(`$[?@ == $[1]]`).Select(ids))
+	a.Equal(NodeList{ids[3]}, MustParse(`$[?@ > $[2]]`).Select(ids))
+	a.Equal(NodeList{ids[3]}, MustParse(`$[?@ == 18446744073709551615]`).Select(ids))
+	a.Equal(NodeList{ids[0]}, MustParse(`$[?@ == 9007199254740992.0]`).Select(ids))
+}
+
+func TestTimeComparison(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	type event struct {
+		Name string    `json:"name"`
+		At   time.Time `json:"at"`
+	}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	input := []event{
+		{"a", start},
+		{"b", start.Add(time.Hour)},
+		{"c", start.Add(48 * time.Hour)},
+	}
+
+	a.Equal(
+		NodeList{"b", "c"},
+		MustParse(`$[?@.at > '2025-01-01T00:00:00Z'].name`).Select(input),
+	)
+	a.Equal(
+		NodeList{"a", "b"},
+		MustParse(`$[?@.at <= '2025-01-01T02:00:00+01:00'].name`).Select(input),
+	)
+	a.Equal(
+		NodeList{"a"},
+		MustParse(`$[?@.at < $[1].at].name`).Select(input),
+	)
+	a.Equal(
+		NodeList{"b"},
+		MustParse(`$[?@.at == '2025-01-01T01:00:00Z'].name`).Select(input),
+	)
+}
+
+func TestSortedMembers(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{
+		"users": map[string]any{
+			"carol": map[string]any{"age": 41},
+			"alice": map[string]any{"age": 37},
+			"bob":   map[string]any{"age": 25},
+			"dave":  map[string]any{"age": 52},
+		},
+	}
+	path := NewParser(WithSortedMembers()).MustParse(`$.users[?@.age > 30]`)
+	for range 10 {
+		keys := []string{}
+		for _, node := range path.SelectLocated(input) {
+			key, ok := node.Key()
+			a.True(ok)
+			keys = append(keys, key)
+		}
+		a.Equal([]string{"alice", "carol", "dave"}, keys)
+		a.Equal(NodeList{
+			map[string]any{"age": 37}, map[string]any{"age": 41}, map[string]any{"age": 52},
+		}, path.Select(input))
+	}
+}
+
+func TestUniqueLocations(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{"a": []any{1, 2, 3}}
+	src := `$.a[0, 2, 0, -1]`
+	a.Equal(NodeList{1, 3, 1, 3}, MustParse(src).Select(input))
+
+	path := NewParser(WithUniqueLocations()).MustParse(src)
+	a.Equal(NodeList{1, 3}, path.Select(input))
+	a.Equal(LocatedNodeList{
+		{Path: spec.Normalized(spec.Name("a"), spec.Index(0)), Node: 1},
+		{Path: spec.Normalized(spec.Name("a"), spec.Index(2)), Node: 3},
+	}, path.SelectLocated(input))
+	a.Equal(NodeList{1, 3}, path.Compile().Select(input))
+}
+
+// foldCollator collates strings case-insensitively.
+type foldCollator struct{}
+
+func (*foldCollator) CompareString(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func TestCollator(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := []any{"apple", "Banana", "cherry", "Apple"}
+	src := `$[?@ < "b"]`
+	a.Equal(NodeList{"apple", "Banana", "Apple"}, MustParse(src).Select(input))
+
+	parser := NewParser(WithCollator(&foldCollator{}))
+	a.Equal(NodeList{"apple", "Apple"}, parser.MustParse(src).Select(input))
+	a.Equal(NodeList{"apple"}, parser.MustParse(`$[?@ == "apple"]`).Select(input))
+
+	// Should collate comparisons of literals.
+	src = `$[?"a" < "B"]`
+	a.Equal(NodeList{}, MustParse(src).Select(input))
+	a.Equal(NodeList(input), parser.MustParse(src).Select(input))
+}
+
+func TestBytesAsStrings(t *testing.T) {
+	t.Parallel()
+
+	input := []any{
+		map[string]any{"id": 1, "name": []byte("über")},
+		map[string]any{"id": 2, "name": []byte("Alice")},
+		map[string]any{"id": 3, "name": "Bob"},
+	}
+
+	for _, tc := range []struct {
+		test  string
+		path  string
+		exp   NodeList
+		bytes NodeList
+	}{
+		{
+			test:  "compare",
+			path:  `$[?@.name == 'Alice'].id`,
+			exp:   NodeList{},
+			bytes: NodeList{2},
+		},
+		{
+			test:  "compare_lt",
+			path:  `$[?@.name < 'B'].id`,
+			exp:   NodeList{},
+			bytes: NodeList{2},
+		},
+		{
+			test:  "compare_queries",
+			path:  `$[?@.name == $[1].name].id`,
+			exp:   NodeList{2},
+			bytes: NodeList{2},
+		},
+		{
+			test:  "length",
+			path:  `$[?length(@.name) == 4].id`,
+			exp:   NodeList{},
+			bytes: NodeList{1},
+		},
+		{
+			test:  "length_bytes",
+			path:  `$[?length(@.name) == 5].id`,
+			exp:   NodeList{1, 2},
+			bytes: NodeList{2},
+		},
+		{
+			test:  "match",
+			path:  `$[?match(@.name, 'A.*')].id`,
+			exp:   NodeList{},
+			bytes: NodeList{2},
+		},
+		{
+			test:  "search",
+			path:  `$[?search(@.name, 'b')].id`,
+			exp:   NodeList{3},
+			bytes: NodeList{1, 3},
+		},
+		{
+			test:  "value",
+			path:  `$[?value(@..name) == 'über'].id`,
+			exp:   NodeList{},
+			bytes: NodeList{1},
+		},
+		{
+			test:  "select",
+			path:  `$[?@.name == 'Alice'].name`,
+			exp:   NodeList{},
+			bytes: NodeList{[]byte("Alice")},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			a.Equal(tc.exp, MustParse(tc.path).Select(input))
+			path := NewParser(WithBytesAsStrings()).MustParse(tc.path)
+			a.Equal(tc.bytes, path.Select(input))
+			a.Equal(tc.bytes, NodeList(slices.Collect(path.SelectLocated(input).Nodes())))
+		})
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := []any{
+		map[string]any{"id": 1, "kids": []any{map[string]any{"id": 2}}},
+	}
+	a.Equal(NodeList{1, 2}, MustParse(`$..id`).Select(input))
+
+	path := NewParser(WithMaxDepth(1)).MustParse(`$..id`)
+	a.Equal(NodeList{1}, path.Select(input))
+	a.Equal(NodeList{1}, path.Compile().Select(input))
+	a.Equal(
+		LocatedNodeList{{Path: norm(0, "id"), Node: 1}},
+		path.SelectLocated(input),
+	)
+}
+
+func TestMaxDocumentDepth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := []any{
+		map[string]any{"id": 1, "kids": []any{map[string]any{"id": 2}}},
+	}
+	path := NewParser(WithMaxDocumentDepth(3)).MustParse(`$..id`)
+	nodes, err := path.TrySelect(input)
+	require.NoError(t, err)
+	a.Equal(NodeList{1, 2}, nodes)
+
+	path = NewParser(WithMaxDocumentDepth(2)).MustParse(`$..id`)
+	nodes, err = path.TrySelect(input)
+	require.ErrorIs(t, err, ErrDepthExceeded)
+	require.ErrorIs(t, err, ErrLimitExceeded)
+	a.EqualError(err, "document depth exceeded: descended more than 2 levels")
+	a.Nil(nodes)
+	located, err := path.TrySelectLocated(input)
+	require.ErrorIs(t, err, ErrDepthExceeded)
+	a.Nil(located)
+
+	// Methods that cannot return an error should return no nodes.
+	a.Nil(path.Select(input))
+	a.Nil(path.SelectLocated(input))
+	a.Equal(Nothing, NewParser(WithMaxDocumentDepth(2)).MustParse(`$..nope`).SelectValue(input))
+	for range path.SelectMany(slices.Values([]any{input})) {
+		a.Fail("SelectMany should yield no nodes")
+	}
+	a.Len(path.Explain(input).Result, 2)
+
+	// WithMaxDepth stops descending before reaching the limit.
+	path = NewParser(WithMaxDocumentDepth(2), WithMaxDepth(2)).MustParse(`$..id`)
+	nodes, err = path.TrySelect(input)
+	require.NoError(t, err)
+	a.Equal(NodeList{1}, nodes)
+}
+
+func TestParallelism(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := make([]any, 5000)
+	for i := range input {
+		input[i] = []any{map[string]any{"id": i}}
+	}
+	path := MustParse(`$..id`)
+	exp := path.Select(input)
+	a.Len(exp, 5000)
+
+	path = NewParser(WithParallelism(8)).MustParse(`$..id`)
+	a.Equal(exp, path.Select(input))
+	a.Equal(exp, path.Compile().Select(input))
+	a.Equal(MustParse(`$..id`).SelectLocated(input), path.SelectLocated(input))
+}
+
+func TestOptimize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	path := MustParse(`$.a`)
+	a.Same(path, path.Optimize())
+
+	input := map[string]any{"a": []any{1, 2, 3, 4}}
+	path = NewParser(WithMaxDepth(1)).MustParse(`$..[0,1,2][?@]`)
+	opt := path.Optimize()
+	a.NotSame(path, opt)
+	a.Equal(`$..[:3][*]`, opt.String())
+	a.Equal(path.opts, opt.opts)
+	a.Equal(path.Select(input), opt.Select(input))
+	a.Equal(path.SelectLocated(input), opt.SelectLocated(input))
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		path string
+	}{
+		{"root", `$`},
+		{"names", `$.a["b c"]..d`},
+		{"wildcards", `$.*..*[*]`},
+		{"mixed", `$["a",1,*,2:5:2,?@]`},
+		{"escapes", `$["'\"\\\b\f\n\r\t\u0000\u001f\u007f"]`},
+		{"filter", `$[?@.a == 'x' && !@.b || length(@.c) > 1]`},
+		{"parens", `$[?(@.a || @.b) && !(@.c != null)]`},
+		{"functions", `$[?count(@..x) < 2 && !match(@.y, "a'b") || search(@.z, 'a"b')]`},
+		{"not_function", `$[?!match(@.y, "a")]`},
+		{"neg_step_zero_start", `$[0::-1]`},
+		{"neg_step_zero_start_end", `$[0:-3:-2]`},
+		{"neg_step_slices", `$[::-1,5:0:-1,:2:-1,-1::-2]`},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			input := []any{0, 1, 2, 3, 4, 5, 6}
+			path := MustParse(tc.path)
+			for _, opts := range []FormatOpts{
+				{},
+				{Shorthand: true},
+				{SingleQuotes: true},
+				{Compact: true},
+				{Shorthand: true, SingleQuotes: true, Compact: true},
+			} {
+				// Should round-trip to an equivalent path.
+				str := path.Format(opts)
+				p, err := Parse(str)
+				r.NoError(err, str)
+				a.Equal(path.String(), p.String(), str)
+				a.Equal(str, p.Format(opts))
+				a.Equal(path.Select(input), p.Select(input), str)
+			}
+		})
+	}
+}
+
+func TestFormatQuery(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test  string
+		query string
+		exp   string
+		err   string
+	}{
+		{"root", `$`, `$`, ""},
+		{"shorthand", `$["a"]['b'][*]..["c"]..[*]`, `$.a.b.*..c..*`, ""},
+		{"brackets", `$["a b", 1]['1x']`, `$['a b',1]['1x']`, ""},
+		{"quotes", `$["it's"][?@.x == "y"]`, `$['it\'s'][?@.x == 'y']`, ""},
+		{"spacing", `$[ ?@.a<1&&@.b ]`, `$[?@.a < 1 && @.b]`, ""},
+		{"functions", `$[?length( @.a )>=2||match(@.b,"x.*")]`, `$[?length(@.a) >= 2 || match(@.b, 'x.*')]`, ""},
+		{"slices", `$[0:2:1, ::-1]`, `$[:2,::-1]`, ""},
+		{"parse_error", `$.a[`, "", "jsonpath: unexpected eof at position 5"},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			for _, format := range []func(string) (string, error){
+				Format,
+				NewParser(WithRegistry(registry.New())).Format,
+			} {
+				got, err := format(tc.query)
+				if tc.err != "" {
+					a.Empty(got)
+					a.EqualError(err, tc.err)
+					a.ErrorIs(err, ErrPathParse)
+					continue
+				}
+				a.NoError(err)
+				a.Equal(tc.exp, got)
+
+				// Should be idempotent.
+				again, err := format(got)
+				a.NoError(err)
+				a.Equal(got, again)
+			}
+		})
+	}
+}
+
+func TestSelectStats(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{"a": []any{
+		map[string]any{"x": "hi"},
+		map[string]any{"x": "bye"},
+	}}
+	path := MustParse(`$.a[?match(@.x, "h.*")]`)
+	nodes, stats := path.SelectStats(input)
+	a.Equal(path.Select(input), nodes)
+	a.Positive(stats.Duration)
+	stats.Duration = 0
+	a.Equal(Stats{Nodes: 4, Filters: 2, Functions: 2, Regexes: 2}, stats)
+
+	// Should also notify the path's hook.
+	c := new(spec.Collector)
+	path = NewParser(WithHook(c), WithMaxDepth(1)).MustParse(`$..x`)
+	nodes, stats = path.SelectStats(input)
+	a.Empty(nodes)
+	stats.Duration = 0
+	a.Equal(Stats{Nodes: 2, MaxDepth: 1}, stats)
+	a.Equal(stats, c.Stats())
+
+	path.Select(input)
+	a.Equal(Stats{Nodes: 4, MaxDepth: 1}, c.Stats())
+}
+
+func TestTrySelect(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{"a": []any{
+		map[string]any{"x": []any{1, 2}},
+		map[string]any{"x": []any{3, 4}},
+	}}
+	path := MustParse(`$..*..*`)
+	nodes, err := path.TrySelect(input)
+	a.NoError(err)
+	a.Equal(path.Select(input), nodes)
+	located, err := path.TrySelectLocated(input)
+	a.NoError(err)
+	a.Equal(path.SelectLocated(input), located)
+
+	for _, opt := range []Option{WithMaxNodes(10), WithTimeout(time.Hour)} {
+		path = NewParser(opt).MustParse(`$.a[*].x[0]`)
+		nodes, err = path.TrySelect(input)
+		a.NoError(err)
+		a.Equal(NodeList{1, 3}, nodes)
+		located, err = path.TrySelectLocated(input)
+		a.NoError(err)
+		a.Equal(path.SelectLocated(input), located)
+	}
+
+	path = NewParser(WithMaxNodes(10)).MustParse(`$..*..*`)
+	nodes, err = path.TrySelect(input)
+	a.ErrorIs(err, ErrBudgetExceeded)
+	a.ErrorIs(err, ErrLimitExceeded)
+	a.NotErrorIs(err, ErrPathParse)
+	a.EqualError(err, "evaluation budget exceeded: visited more than 10 nodes")
+	a.Nil(nodes)
+	located, err = path.TrySelectLocated(input)
+	a.ErrorIs(err, ErrBudgetExceeded)
+	a.Nil(located)
+
+	// Methods that cannot return an error should return no nodes.
+	a.Nil(path.Select(input))
+	a.Nil(path.SelectLocated(input))
+	a.Equal(Nothing, NewParser(WithMaxNodes(1)).MustParse(`$.a[1].x[1]`).SelectValue(input))
+	a.Nil(NewPathSet(path).Select(input)[0])
+	for range path.SelectMany(slices.Values([]any{input})) {
+		a.Fail("SelectMany should yield no nodes")
+	}
+
+	// And log the error.
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	path = NewParser(WithMaxNodes(10), WithLogger(logger)).MustParse(`$..*..*`)
+	a.Nil(path.Select(input))
+	a.Contains(buf.String(), `msg="jsonpath: evaluation aborted" error="evaluation budget exceeded: visited more than 10 nodes"`)
+}
+
+func TestSelectE(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := []any{
+		map[string]any{"a": "x", "n": 1},
+		map[string]any{"a": "y", "n": "2"},
+	}
+
+	// Should select without error.
+	path := MustParse(`$[?@.a == 'x']`)
+	nodes, err := path.SelectE(input)
+	a.NoError(err)
+	a.Equal(NodeList{input[0]}, nodes)
+
+	// Should return an error for an ordering comparison of different types.
+	path = MustParse(`$[?@.n > 0]`)
+	a.Equal(NodeList{input[0]}, path.Select(input))
+	nodes, err = path.SelectE(input)
+	a.ErrorIs(err, ErrEvaluation)
+	a.EqualError(err, `jsonpath: comparison of values of different types: expr="@[\"n\"] > 0" left=string right=int64`)
+	a.Nil(nodes)
+
+	// Should return an error for an invalid regular expression.
+	path = MustParse(`$[?search(@.a, '[')]`)
+	a.Equal(NodeList{}, path.Select(input))
+	nodes, err = path.SelectE(input)
+	a.ErrorIs(err, ErrEvaluation)
+	a.EqualError(err, "jsonpath: invalid regular expression: function=search regex=[ error=\"error parsing regexp: missing closing ]: `[`\"")
+	a.Nil(nodes)
+
+	// Should return budget errors.
+	path = NewParser(WithMaxNodes(1)).MustParse(`$..*`)
+	_, err = path.SelectE(input)
+	a.ErrorIs(err, ErrBudgetExceeded)
+
+	// Should not fold away ordering comparisons of literals of different
+	// types.
+	_, err = MustParse(`$[?1 < "a"]`).SelectE(input)
+	a.ErrorIs(err, ErrEvaluation)
+
+	// WithStrict should apply to all methods.
+	path = NewParser(WithStrict()).MustParse(`$[?@.n > 0]`)
+	nodes, err = path.TrySelect(input)
+	a.ErrorIs(err, ErrEvaluation)
+	a.Nil(nodes)
+	located, err := path.TrySelectLocated(input)
+	a.ErrorIs(err, ErrEvaluation)
+	a.Nil(located)
+
+	// Methods that cannot return an error should return no nodes.
+	a.Nil(path.Select(input))
+	a.Nil(path.SelectLocated(input))
+	a.Equal(Nothing, path.SelectValue(input))
+	for range path.SelectMany(slices.Values([]any{input})) {
+		a.Fail("SelectMany should yield no nodes")
+	}
+	a.Nil(NewPathSet(path).Select(input)[0])
+	a.Equal([]*spec.LocatedNode{{Path: spec.Normalized(spec.Index(0)), Node: input[0]}}, path.Explain(input).Result)
+}
+
+func TestRewrite(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{"data": map[string]any{"a": []any{1, 2}}}
+	path := NewParser(WithMaxNodes(10)).MustParse(`$.a[?@ > 1]`)
+
+	// Should prefix the query and keep the options.
+	res, err := path.Rewrite(func(node any) any {
+		if q, ok := node.(*spec.PathQuery); ok && q.IsRoot() {
+			return spec.Query(true, append([]*spec.Segment{spec.Child(spec.Name("data"))}, q.Segments()...)...)
+		}
+		return node
+	})
+	require.NoError(t, err)
+	a.Equal(`$["data"]["a"][?@ > 1]`, res.String())
+	a.Equal(NodeList{2}, res.Select(input))
+	a.Equal(path.opts, res.opts)
+	a.Equal(`$["a"][?@ > 1]`, path.String())
+
+	// Should return errors.
+	res, err = path.Rewrite(func(node any) any {
+		if _, ok := node.(*spec.FilterSelector); ok {
+			return spec.Child(spec.Wildcard())
+		}
+		return node
+	})
+	a.ErrorIs(err, ErrRewrite)
+	a.EqualError(err, "jsonpath: invalid rewrite: cannot replace *spec.FilterSelector with *spec.Segment")
+	a.Nil(res)
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{"a": []any{1, 2, 3}}
+	path := NewParser(WithMaxNodes(10)).MustParse(`$.a[?@ > 1]`)
+	clone := path.Clone()
+	a.NotSame(path, clone)
+	a.NotSame(path.q, clone.q)
+	a.Equal(path.q, clone.q)
+	a.Equal(path.opts, clone.opts)
+	a.Equal(path.Select(input), clone.Select(input))
+
+	// Modifying the clone should not affect the original.
+	clone.Query().Segments()[0] = spec.Child(spec.Name("b"))
+	a.Equal(`$["b"][?@ > 1]`, clone.String())
+	a.Equal(`$["a"][?@ > 1]`, path.String())
+	clone = path.Clone()
+	require.NoError(t, clone.UnmarshalText([]byte(`$.b`)))
+	a.Equal(`$["b"]`, clone.String())
+	a.Equal(`$["a"][?@ > 1]`, path.String())
+}
+
+func TestConcurrency(t *testing.T) {
+	t.Parallel()
+
+	// Run with -race to detect data races.
+	input := map[string]any{
+		"store": map[string]any{
+			"book": []any{
+				map[string]any{"title": "A", "price": 8},
+				map[string]any{"title": "B", "price": 12},
+			},
+		},
+	}
+	reg := registry.New()
+	parser := NewParser(WithRegistry(reg))
+	path := parser.MustParse(`$..book[?@.price < 10 && length(@.title) == 1].title`)
+	compiled := path.Compile()
+	exp := NodeList{"A"}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				assert.Equal(t, exp, path.Select(input))
+				assert.Equal(t, exp, compiled.Select(input))
+				assert.Equal(t, exp, path.Clone().Select(input))
+				assert.Len(t, path.SelectLocated(input), 1)
+				nodes, err := path.TrySelect(input)
+				assert.NoError(t, err)
+				assert.Equal(t, exp, nodes)
+				assert.Equal(t, `$..["book"][?@["price"] < 10 && length(@["title"]) == 1]["title"]`, path.String())
+			}
+		}()
+
+		// Register and use functions while selecting.
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("fn%v", i)
+			assert.NoError(t, reg.Register(
+				name,
+				spec.FuncLogical,
+				func([]spec.FuncExprArg) error { return nil },
+				func([]spec.PathValue) spec.PathValue { return spec.LogicalTrue },
+			))
+			p, err := parser.Parse(`$..book[?` + name + `()].title`)
+			if assert.NoError(t, err) {
+				assert.Equal(t, NodeList{"A", "B"}, p.Select(input))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, reg.Names(), 13)
+}
+
+func TestSingularPrefix(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{
+		"store": map[string]any{
+			"book": []any{
+				map[string]any{"title": "A", "price": 8},
+				map[string]any{"title": "B", "price": 12},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		path   string
+		prefix string
+		rest   string
+	}{
+		{`$`, `$`, `$`},
+		{`$.store.book[1].title`, `$['store']['book'][1]['title']`, `$`},
+		{`$.store.book[?@.price < 10].title`, `$['store']['book']`, `$[?@["price"] < 10]["title"]`},
+		{`$.store..title`, `$['store']`, `$..["title"]`},
+		{`$.store.book[-1]`, `$['store']['book']`, `$[-1]`},
+		{`$["store","x"].book`, `$`, `$["store","x"]["book"]`},
+	} {
+		path := NewParser(WithMaxDepth(5)).MustParse(tc.path)
+		prefix, rest := path.SingularPrefix()
+		a.Equal(tc.prefix, prefix.String(), tc.path)
+		a.Equal(tc.rest, rest.String(), tc.path)
+		a.Equal(path.opts, rest.opts, tc.path)
+
+		// Selecting the rest from the value at the prefix should select the
+		// same values.
+		val := MustParse(prefix.String()).SelectValue(input)
+		a.Equal(path.Select(input), rest.Select(val), tc.path)
+	}
+}
+
+func TestIsSingular(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		path string
+		exp  string
+	}{
+		{`$`, `$`},
+		{`$.store.book[0].title`, `$["store"]["book"][0]["title"]`},
+		{`$.a[-1]`, `$["a"][-1]`},
+		{`$.store.book[*]`, ""},
+		{`$.store..title`, ""},
+		{`$["a","b"]`, ""},
+		{`$.a[1:2]`, ""},
+		{`$.a[?@.b]`, ""},
+	} {
+		path := MustParse(tc.path)
+		a.Equal(tc.exp != "", path.IsSingular(), tc.path)
+		sq, ok := path.AsSingular()
+		a.Equal(tc.exp != "", ok, tc.path)
+		if tc.exp == "" {
+			a.Nil(sq, tc.path)
+		} else {
+			a.Equal(tc.exp, sq.String(), tc.path)
+		}
+	}
+}
+
+func TestAppend(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{"a": []any{
+		map[string]any{"b": 1, "c": 2},
+		map[string]any{"b": 3},
+	}}
+	base := NewParser(WithMaxDepth(5)).MustParse(`$.a`)
+
+	// Should append segments.
+	path := base.Append(spec.Child(spec.Wildcard()), spec.Child(spec.Name("b")))
+	a.Equal(`$["a"][*]["b"]`, path.String())
+	a.Equal(NodeList{1, 3}, path.Select(input))
+	a.Equal(base.opts, path.opts)
+	a.Equal(`$["a"]`, base.String())
+	a.Equal(base, base.Append())
+
+	// Should not share segments between paths.
+	other := base.Append(spec.Child(spec.Index(1)))
+	a.Equal(`$["a"][*]["b"]`, path.String())
+	a.Equal(`$["a"][1]`, other.String())
+
+	// Should join relative paths.
+	rel := New(spec.Query(false, spec.Descendant(spec.Name("c"))))
+	path = base.Join(rel)
+	a.Equal(`$["a"]..["c"]`, path.String())
+	a.Equal(NodeList{2}, path.Select(input))
+	a.Equal(base.opts, path.opts)
+	a.Equal(`@..["c"]`, rel.String())
+
+	// Should join the segments of root paths.
+	path = base.Join(MustParse(`$[0].b`))
+	a.Equal(`$["a"][0]["b"]`, path.String())
+	a.Equal(NodeList{1}, path.Select(input))
+
+	// Should join to relative paths.
+	path = rel.Join(MustParse(`$[0]`))
+	a.Equal(`@..["c"][0]`, path.String())
+}
+
+func TestPathEncoding(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	type config struct {
+		Path  *Path  `json:"path"`
+		Other *Path  `json:"other"`
+		Name  string `json:"name"`
+	}
+
+	// Should marshal and unmarshal JSON.
+	cfg := config{Path: MustParse(`$.a[?@.b == "x"]`), Name: "test"}
+	data, err := json.Marshal(cfg)
+	r.NoError(err)
+	a.JSONEq(`{"path": "$[\"a\"][?@[\"b\"] == \"x\"]", "other": null, "name": "test"}`, string(data))
+	var dec config
+	r.NoError(json.Unmarshal(data, &dec))
+	a.Equal(cfg, dec)
+
+	// Should leave Path unchanged for null.
+	p := MustParse(`$.a`)
+	r.NoError(p.UnmarshalJSON([]byte("null")))
+	a.Equal(`$["a"]`, p.String())
+
+	// Should validate paths.
+	err = json.Unmarshal([]byte(`{"path": "$.a["}`), &dec)
+	r.ErrorIs(err, ErrPathParse)
+	r.EqualError(err, "jsonpath: unexpected eof at position 5")
+	err = json.Unmarshal([]byte(`{"path": 42}`), &dec)
+	r.EqualError(err, "jsonpath: cannot decode Path from JSON 42")
+
+	// Should implement database/sql interfaces.
+	a.Implements((*driver.Valuer)(nil), p)
+	a.Implements((*sql.Scanner)(nil), p)
+	val, err := p.Value()
+	r.NoError(err)
+	a.Equal(`$["a"]`, val)
+	val, err = (*Path)(nil).Value()
+	r.NoError(err)
+	a.Nil(val)
+
+	r.NoError(p.Scan(`$.b`))
+	a.Equal(`$["b"]`, p.String())
+	r.NoError(p.Scan([]byte(`$.c`)))
+	a.Equal(`$["c"]`, p.String())
+	r.ErrorIs(p.Scan(`$[`), ErrPathParse)
+	r.EqualError(p.Scan(nil), "jsonpath: cannot decode Path from <nil>")
+	r.EqualError(p.Scan(42), "jsonpath: cannot decode Path from int")
+
+	var np sql.Null[*Path]
+	r.NoError(np.Scan(nil))
+	a.False(np.Valid)
+	r.NoError(np.Scan(`$.d`))
+	a.True(np.Valid)
+	a.Equal(`$["d"]`, np.V.String())
+}
+
+func TestPathEncodingRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"\u0001": []any{0, 1, 2, 3, 4, 5, 6},
+		"a":      []any{map[string]any{"b": "\u001f"}, map[string]any{"b": "x"}},
+		"'\"":    "quotes",
+	}
+
+	for _, tc := range []struct {
+		test string
+		path string
+	}{
+		{"control_name", `$["\u0001"][1]`},
+		{"control_literal", `$.a[?@.b == "\u001f"]`},
+		{"quotes", `$["'\""]`},
+		{"neg_step_zero_start", `$["\u0001"][0::-1]`},
+		{"neg_step_zero_start_end", `$["\u0001"][0:-8:-2]`},
+		{"neg_step_defaults", `$["\u0001"][::-1]`},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+			path := MustParse(tc.path)
+			exp := path.Select(input)
+			r.NotEmpty(exp)
+
+			// Text.
+			text, err := path.MarshalText()
+			r.NoError(err)
+			a.Equal(path.Format(FormatOpts{}), string(text))
+			var p Path
+			r.NoError(p.UnmarshalText(text))
+			a.Equal(exp, p.Select(input), string(text))
+
+			// Binary.
+			bin, err := path.MarshalBinary()
+			r.NoError(err)
+			p = Path{}
+			r.NoError(p.UnmarshalBinary(bin))
+			a.Equal(exp, p.Select(input), string(bin))
+
+			// JSON.
+			data, err := json.Marshal(path)
+			r.NoError(err)
+			p = Path{}
+			r.NoError(json.Unmarshal(data, &p))
+			a.Equal(exp, p.Select(input), string(data))
+
+			// Database.
+			val, err := path.Value()
+			r.NoError(err)
+			p = Path{}
+			r.NoError(p.Scan(val))
+			a.Equal(exp, p.Select(input), val)
+		})
+	}
+
+	// Should decode with the default parser and retain other options.
+	a := assert.New(t)
+	r := require.New(t)
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"one",
+		spec.FuncValue,
+		func([]spec.FuncExprArg) error { return nil },
+		func([]spec.PathValue) spec.PathValue { return spec.Value(1) },
+	))
+	p := NewParser(WithRegistry(reg), WithMaxNodes(1)).MustParse(`$[?one() == 1]`)
+	text, err := p.MarshalText()
+	r.NoError(err)
+	a.ErrorIs(p.UnmarshalText(text), ErrPathParse)
+	r.NoError(p.UnmarshalText([]byte(`$..*`)))
+	_, err = p.TrySelect(input)
+	a.ErrorIs(err, ErrBudgetExceeded)
+}
+
+func TestCost(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  CostEstimate
+	}{
+		{"root", `$`, CostEstimate{Singular: true, Multiplier: 1}},
+		{"names", `$.a[0].b`, CostEstimate{Singular: true, Multiplier: 3}},
+		{"union", `$["a","b"].c`, CostEstimate{Multiplier: 3}},
+		{"wildcard", `$.a.*.b`, CostEstimate{Wildcards: 1, Multiplier: 12}},
+		{"slice", `$[1:4].a`, CostEstimate{Multiplier: 4}},
+		{"descendant", `$..a`, CostEstimate{Descendants: 1, Multiplier: 100}},
+		{"descendant_wildcard", `$..*.a`, CostEstimate{Descendants: 1, Wildcards: 1, Multiplier: 1100}},
+		{"filter", `$[?@.a == 1]`, CostEstimate{Filters: 1, Multiplier: 11}},
+		{
+			"filter_functions",
+			`$[?match(@.a, "x") && count(@.*) > 2]`,
+			CostEstimate{Wildcards: 1, Filters: 1, Functions: 2, Regexes: 1, Multiplier: 21},
+		},
+		{
+			"nested",
+			`$..[?@..[?search(@, "x")]]`,
+			CostEstimate{Descendants: 2, Filters: 2, Functions: 1, Regexes: 1, Multiplier: 100_100},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, MustParse(tc.path).Cost())
+		})
+	}
+}
+
+func TestSubsumes(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test     string
+		path     string
+		other    string
+		subsumes bool
+		overlaps bool
+	}{
+		{"root", `$`, `$`, true, true},
+		{"root_child", `$`, `$.a`, false, false},
+		{"child_root", `$.a`, `$`, false, false},
+		{"same_name", `$.a.b`, `$.a.b`, true, true},
+		{"other_name", `$.a.b`, `$.a.c`, false, false},
+		{"names", `$["a","b"]`, `$.a`, true, true},
+		{"name_names", `$.a`, `$["a","b"]`, false, true},
+		{"name_index", `$.a`, `$[0]`, false, false},
+		{"wildcard_name", `$.a.*`, `$.a.b`, true, true},
+		{"wildcard_deeper", `$.a.*`, `$.a.b.c`, false, false},
+		{"wildcard_filter", `$.a[*]`, `$.a[?@.b]`, true, true},
+		{"filter_wildcard", `$.a[?@.b]`, `$.a[*]`, false, true},
+		{"same_filter", `$[?@.b]`, `$[?@.b]`, true, true},
+		{"other_filter", `$[?@.b > 0]`, `$[?@.b > 1]`, false, true},
+		{"filter_union", `$[?@.a]`, `$[?@.a,?@.b]`, false, true},
+		{"union_filter", `$[?@.a,?@.b]`, `$[?@.b]`, true, true},
+		{"filter_order", `$[?@.a][?@.b]`, `$[?@.b][?@.a]`, false, true},
+		{"descendant_child", `$..a`, `$.x[?@.b].a`, true, true},
+		{"descendant_all", `$..*`, `$.a.b[1]`, true, true},
+		{"descendant_prefix", `$..b`, `$.a..b`, true, true},
+		{"prefix_descendant", `$.a..b`, `$..b`, false, true},
+		{"descendant_self", `$.a..*`, `$.a`, false, false},
+		{"descendants", `$..a..b`, `$.a.b`, true, true},
+		{"descendant_names", `$..a`, `$..b`, false, false},
+		{"slice_slice", `$[0:10]`, `$[2:8:2]`, true, true},
+		{"slice_indexes", `$[2:8:2]`, `$[2,4,6]`, true, true},
+		{"slice_odd_index", `$[2:8:2]`, `$[2,4,6,7]`, false, true},
+		{"open_slice_index", `$[::2]`, `$[4]`, true, true},
+		{"open_slice_odd", `$[::2]`, `$[5]`, false, false},
+		{"open_slice_steps", `$[::3]`, `$[::6]`, true, true},
+		{"open_slice_offset", `$[1::3]`, `$[::6]`, false, false},
+		{"long_slice", `$[0:1000]`, `$[999]`, true, true},
+		{"past_slice", `$[0:1000]`, `$[1000]`, false, false},
+		{"large_step", `$[1:3:1000]`, `$[1]`, false, true},
+		{"zero_step", `$[*]`, `$[0:10:0]`, true, false},
+		{"same_negative", `$[-1]`, `$[-1]`, true, true},
+		{"wildcard_negative", `$[*]`, `$[-1]`, true, true},
+		{"negative_index", `$[-1]`, `$[0]`, false, true},
+		{"negative_slice", `$[1:]`, `$[1:-1]`, false, true},
+		{"negative_name", `$[-1]`, `$.a`, false, false},
+		{"indexes_slice", `$[0,1,2,3,4,5,6,7,8,9,10]`, `$[::7]`, false, true},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			path, other := MustParse(tc.path), MustParse(tc.other)
+			a.Equal(tc.subsumes, path.Subsumes(other), "subsumes")
+			a.Equal(tc.overlaps, path.Overlaps(other), "overlaps")
+			a.Equal(tc.overlaps, other.Overlaps(path), "overlaps reversed")
+			a.True(path.Subsumes(path))
+		})
+	}
+}
+
+func TestSelectMap(t *testing.T) {
+	t.Parallel()
+
+	doc := map[string]any{
+		"a": []any{"x", map[string]any{"b": true}},
+		"c": "it's",
+	}
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  map[string]any
+	}{
+		{"root", `$`, map[string]any{"$": doc}},
+		{"nothing", `$.nope`, map[string]any{}},
+		{"name", `$.c`, map[string]any{`$['c']`: "it's"}},
+		{"index", `$.a[0]`, map[string]any{`$['a'][0]`: "x"}},
+		{
+			"descendants", `$..*`,
+			map[string]any{
+				`$['a']`:         doc["a"],
+				`$['a'][0]`:      "x",
+				`$['a'][1]`:      map[string]any{"b": true},
+				`$['a'][1]['b']`: true,
+				`$['c']`:         "it's",
+			},
+		},
+		{"duplicates", `$["c","c"]`, map[string]any{`$['c']`: "it's"}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, MustParse(tc.path).SelectMap(doc))
+		})
+	}
+}
+
+func TestMatchesPath(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		path string
+		np   spec.NormalizedPath
+		exp  bool
+	}{
+		{"root", `$`, spec.Normalized(), true},
+		{"name", `$.a.b`, spec.Normalized(spec.Name("a"), spec.Name("b")), true},
+		{"other_name", `$.a.b`, spec.Normalized(spec.Name("a"), spec.Name("c")), false},
+		{"wildcard", `$.a[*].b`, spec.Normalized(spec.Name("a"), spec.Index(2), spec.Name("b")), true},
+		{"descendant", `$..b`, spec.Normalized(spec.Name("a"), spec.Index(2), spec.Name("b")), true},
+		{"filter", `$.a[?@.x > 1].b`, spec.Normalized(spec.Name("a"), spec.Index(2), spec.Name("b")), true},
+		{"filter_deeper", `$.a[?@.x > 1]`, spec.Normalized(spec.Name("a"), spec.Index(2), spec.Name("b")), false},
+		{"slice", `$[1:10:3]`, spec.Normalized(spec.Index(7)), true},
+		{"slice_step", `$[1:10:3]`, spec.Normalized(spec.Index(6)), false},
+		{"negative_index", `$[-1]`, spec.Normalized(spec.Index(6)), true},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, MustParse(tc.path).MatchesPath(tc.np))
+		})
+	}
+}
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))
+	input := []any{map[string]any{"a": "x"}, map[string]any{"a": 2}}
+
+	// Should log an invalid regular expression.
+	path := NewParser(WithLogger(logger)).MustParse(`$[?search(@.a, '[')]`)
+	a.Equal(NodeList{}, path.Select(input))
+	a.Equal(
+		`level=WARN msg="jsonpath: invalid regular expression" function=search regex=[ error="error parsing regexp: missing closing ]: `+"`[`\"\n",
+		buf.String(),
+	)
+
+	// Should log a comparison of different types.
+	buf.Reset()
+	path = NewParser(WithLogger(logger)).MustParse(`$[?@.a > 1]`)
+	a.Equal(NodeList{input[1]}, path.Select(input))
+	a.Equal(
+		`level=WARN msg="jsonpath: comparison of values of different types" expr="@[\"a\"] > 1" left=string right=int64`+"\n",
+		buf.String(),
+	)
+
+	// Should log nothing without a logger.
+	buf.Reset()
+	a.Equal(NodeList{input[1]}, MustParse(`$[?@.a > 1]`).Select(input))
+	a.Empty(buf.String())
+}
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := []any{[]any{[]any{1}}, []byte("hi")}
+	for _, tc := range []struct {
+		test string
+		path *Path
+	}{
+		{"default", MustParse(`$..[0]`)},
+		{"max_depth", NewParser(WithMaxDepth(1)).MustParse(`$..[0]`)},
+		{"bytes", NewParser(WithBytesAsStrings()).MustParse(`$[?@ == "hi"]`)},
+		{"max_nodes", NewParser(WithMaxNodes(1)).MustParse(`$..*`)},
+	} {
+		trace := tc.path.Explain(input)
+		a.Equal(tc.path.String(), trace.Query, tc.test)
+		if tc.test != "max_nodes" {
+			a.Equal([]*spec.LocatedNode(tc.path.SelectLocated(input)), trace.Result, tc.test)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	path := MustParse(`$.a[?@.b]`)
+	a.Equal(path.Query().Describe(), path.Describe())
+	a.Equal(path.Describe().String(), path.Tree())
+	a.Equal("query $\n", MustParse("$").Tree())
+}
+
+func TestSelectValue(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"a": nil,
+		"b": []any{"x", nil},
+		"c": map[string]any{"d": 1},
+	}
+	for _, tc := range []struct {
+		path string
+		exp  any
+	}{
+		{path: `$`, exp: input},
+		{path: `$.a`, exp: nil},
+		{path: `$.b[0]`, exp: "x"},
+		{path: `$.b[1]`, exp: nil},
+		{path: `$.b[-1]`, exp: nil},
+		{path: `$.c.d`, exp: 1},
+		{path: `$.nope`, exp: Nothing},
+		{path: `$.a.b`, exp: Nothing},
+		{path: `$.b[2]`, exp: Nothing},
+		{path: `$.b[*]`, exp: "x"},
+		{path: `$..d`, exp: 1},
+		{path: `$.b[?@ == null]`, exp: nil},
+		{path: `$..nope`, exp: Nothing},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			val := MustParse(tc.path).SelectValue(input)
+			a.Equal(tc.exp, val)
+			a.Equal(tc.exp == Nothing, val == Nothing)
+		})
+	}
+}
+
 func TestNodeList(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,217 @@
+package jsonpath
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestPathSet(t *testing.T) {
+	t.Parallel()
+
+	input := []any{
+		map[string]any{"id": 1, "kids": []any{map[string]any{"id": 2}}},
+		map[string]any{"id": 3, "name": "x"},
+	}
+	deep := NewParser(WithMaxDepth(1))
+	hooked := NewParser(WithHook(funcHook(func(int) {})))
+
+	for _, tc := range []struct {
+		test  string
+		paths []*Path
+	}{
+		{
+			test: "none",
+		},
+		{
+			test:  "one",
+			paths: []*Path{MustParse(`$[0].id`)},
+		},
+		{
+			test: "shared_prefix",
+			paths: []*Path{
+				MustParse(`$[0].id`),
+				MustParse(`$[0].kids[*].id`),
+				MustParse(`$[*].name`),
+			},
+		},
+		{
+			test: "descendants",
+			paths: []*Path{
+				MustParse(`$..id`),
+				MustParse(`$..name`),
+				MustParse(`$..[?@.id > 1]`),
+			},
+		},
+		{
+			test: "mixed_options",
+			paths: []*Path{
+				MustParse(`$..id`),
+				deep.MustParse(`$..id`),
+				MustParse(`$..kids`),
+				deep.MustParse(`$..name`),
+			},
+		},
+		{
+			test: "func_hook",
+			paths: []*Path{
+				hooked.MustParse(`$..id`),
+				MustParse(`$..name`),
+				hooked.MustParse(`$[0].kids`),
+			},
+		},
+		{
+			test: "relative",
+			paths: []*Path{
+				New(spec.Query(false, spec.Descendant(spec.Name("id")))),
+				MustParse(`$[1]`),
+			},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			set := NewPathSet(tc.paths...)
+			a.Equal(tc.paths, set.Paths())
+
+			vals := set.Select(input)
+			located := set.SelectLocated(input)
+			a.Len(vals, len(tc.paths))
+			a.Len(located, len(tc.paths))
+			res, err := set.TrySelect(input)
+			a.NoError(err)
+			a.Equal(vals, res)
+			for i, p := range tc.paths {
+				a.Equal(p.Select(input), vals[i], p.String())
+				a.Equal(p.SelectLocated(input), located[i], p.String())
+			}
+		})
+	}
+}
+
+// funcHook is a [spec.Hook] that passes visits to a function. Functions
+// are not comparable, so neither are [spec.Options] that use a funcHook.
+type funcHook func(depth int)
+
+func (f funcHook) Visit(depth int) { f(depth) }
+func (funcHook) Filter()           {}
+func (funcHook) Function(string)   {}
+
+func TestNewPathSetGroups(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	deep := NewParser(WithMaxDepth(1))
+	hook := funcHook(func(int) {})
+	hooked := NewParser(WithHook(hook))
+	collector := &spec.Collector{}
+	collected := NewParser(WithHook(collector))
+
+	for _, tc := range []struct {
+		test   string
+		paths  []*Path
+		groups [][]int
+	}{
+		{"none", nil, [][]int{}},
+		{"same", paths(`$.a`, `$.b`), [][]int{{0, 1}}},
+		{"max_depth", []*Path{MustParse(`$.a`), deep.MustParse(`$.b`), deep.MustParse(`$.c`)}, [][]int{{0}, {1, 2}}},
+		{"same_hook", []*Path{collected.MustParse(`$.a`), MustParse(`$.b`), collected.MustParse(`$.c`)}, [][]int{{0, 2}, {1}}},
+		{"func_hook", []*Path{hooked.MustParse(`$.a`), MustParse(`$.b`), hooked.MustParse(`$.c`)}, [][]int{{0}, {1}, {2}}},
+	} {
+		set := NewPathSet(tc.paths...)
+		groups := make([][]int, len(set.groups))
+		for i, g := range set.groups {
+			groups[i] = g.indexes
+		}
+		a.Equal(tc.groups, groups, tc.test)
+	}
+}
+
+// collatorFunc is a [spec.Collator] implemented by a function, and so not
+// comparable.
+type collatorFunc func(a, b string) int
+
+func (f collatorFunc) CompareString(a, b string) int { return f(a, b) }
+
+func TestPathSetCollator(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := []any{"apple", "Banana", "cherry", "Apple"}
+	folded := NewParser(WithCollator(collatorFunc(func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})))
+	set := NewPathSet(
+		folded.MustParse(`$[?@ < "b"]`),
+		MustParse(`$[?@ < "b"]`),
+		folded.MustParse(`$[?@ >= "b"]`),
+	)
+	a.Equal([]NodeList{
+		{"apple", "Apple"},
+		{"apple", "Banana", "Apple"},
+		{"Banana", "cherry"},
+	}, set.Select(input))
+	res, err := set.TrySelect(input)
+	a.NoError(err)
+	a.Equal(set.Select(input), res)
+	a.Len(set.SelectLocated(input), 3)
+}
+
+func TestPathSetTrySelect(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{"a": []any{1, "x", []any{2, 3}}}
+	set := NewPathSet(
+		MustParse(`$.a[*]`),
+		NewParser(WithMaxNodes(2)).MustParse(`$..*`),
+	)
+	res, err := set.TrySelect(input)
+	a.ErrorIs(err, ErrBudgetExceeded)
+	a.EqualError(err, "evaluation budget exceeded: visited more than 2 nodes")
+	a.Nil(res)
+
+	set = NewPathSet(
+		MustParse(`$..*`),
+		NewParser(WithStrict()).MustParse(`$.a[?@ > 1]`),
+	)
+	res, err = set.TrySelect(input)
+	a.ErrorIs(err, ErrEvaluation)
+	a.Nil(res)
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{
+		"id":   42,
+		"meta": map[string]any{"tags": []any{"a", "b"}, "owner": map[string]any{"id": 7}},
+	}
+	res, err := Extract(input, map[string]*Path{
+		"id":      MustParse(`$.id`),
+		"ids":     MustParse(`$..id`),
+		"tags":    MustParse(`$.meta.tags[*]`),
+		"missing": MustParse(`$.nope`),
+	})
+	a.NoError(err)
+	a.Equal(map[string][]any{
+		"id":      {42},
+		"ids":     {42, 7},
+		"tags":    {"a", "b"},
+		"missing": {},
+	}, res)
+
+	res, err = Extract(input, nil)
+	a.NoError(err)
+	a.Empty(res)
+
+	res, err = Extract(input, map[string]*Path{
+		"all": NewParser(WithMaxNodes(2)).MustParse(`$..*`),
+	})
+	a.ErrorIs(err, ErrBudgetExceeded)
+	a.Nil(res)
+}
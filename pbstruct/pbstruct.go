@@ -0,0 +1,123 @@
+// Package pbstruct executes RFC 9535 JSONPath queries against
+// [google.protobuf.Struct] values, traversing their fields directly rather
+// than converting them to maps and slices.
+//
+// [google.protobuf.Struct]: https://pkg.go.dev/google.golang.org/protobuf/types/known/structpb
+package pbstruct
+
+import (
+	"iter"
+
+	"github.com/theory/jsonpath"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var (
+	_ jsonpath.Object = Struct{}
+	_ jsonpath.Array  = List{}
+)
+
+// Value returns val for use in a JSONPath query. It converts
+// [*structpb.Struct] values to [Struct], [*structpb.ListValue] values to
+// [List], and the scalars in [*structpb.Value] values to nil, float64,
+// string, or bool. Returns any other value unchanged.
+func Value(val any) any {
+	switch val := val.(type) {
+	case *structpb.Struct:
+		if val == nil {
+			return nil
+		}
+		return Struct{val}
+	case *structpb.ListValue:
+		if val == nil {
+			return nil
+		}
+		return List{val}
+	case *structpb.Value:
+		return value(val)
+	default:
+		return val
+	}
+}
+
+// value converts v to a value for use in a JSONPath query.
+func value(v *structpb.Value) any {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		return kind.NumberValue
+	case *structpb.Value_StringValue:
+		return kind.StringValue
+	case *structpb.Value_BoolValue:
+		return kind.BoolValue
+	case *structpb.Value_StructValue:
+		return Value(kind.StructValue)
+	case *structpb.Value_ListValue:
+		return Value(kind.ListValue)
+	default:
+		// NullValue or unset.
+		return nil
+	}
+}
+
+// Struct wraps a [*structpb.Struct] to implement [jsonpath.Object].
+// Queries return Struct values for selected structs; use the embedded
+// pointer to access the original message.
+type Struct struct {
+	*structpb.Struct
+}
+
+// Get returns the value of the field named name. Defined by
+// [jsonpath.Object].
+func (s Struct) Get(name string) (any, bool) {
+	if v, ok := s.GetFields()[name]; ok {
+		return value(v), true
+	}
+	return nil, false
+}
+
+// Len returns the number of fields in s. Defined by [jsonpath.Object].
+func (s Struct) Len() int { return len(s.GetFields()) }
+
+// Iterate returns an iterator over the names and values of the fields in s.
+// Like map iteration, the order is not specified. Defined by
+// [jsonpath.Object].
+func (s Struct) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for k, v := range s.GetFields() {
+			if !yield(k, value(v)) {
+				return
+			}
+		}
+	}
+}
+
+// List wraps a [*structpb.ListValue] to implement [jsonpath.Array]. Queries
+// return List values for selected lists; use the embedded pointer to access
+// the original message.
+type List struct {
+	*structpb.ListValue
+}
+
+// Get returns the value at index. Defined by [jsonpath.Array].
+func (l List) Get(index int) (any, bool) {
+	values := l.GetValues()
+	if index < 0 || index >= len(values) {
+		return nil, false
+	}
+	return value(values[index]), true
+}
+
+// Len returns the number of values in l. Defined by [jsonpath.Array].
+func (l List) Len() int { return len(l.GetValues()) }
+
+// Iterate returns an iterator over the indexes and values of l. Defined by
+// [jsonpath.Array].
+func (l List) Iterate() iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for i, v := range l.GetValues() {
+			if !yield(i, value(v)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package pbstruct_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/pbstruct"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Select values from a google.protobuf.Struct payload without first
+// converting it to a map.
+func ExampleValue() {
+	payload, err := structpb.NewStruct(map[string]any{
+		"items": []any{
+			map[string]any{"sku": "a", "qty": 2},
+			map[string]any{"sku": "b", "qty": 5},
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	path := jsonpath.MustParse(`$.items[?@.qty > 3].sku`)
+	fmt.Println(path.Select(pbstruct.Value(payload)))
+	// Output: [b]
+}
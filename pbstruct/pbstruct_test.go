@@ -0,0 +1,94 @@
+package pbstruct
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	doc, err := structpb.NewStruct(map[string]any{
+		"name":    "order-1",
+		"total":   42.5,
+		"paid":    true,
+		"coupon":  nil,
+		"items":   []any{map[string]any{"sku": "a", "qty": 2}, map[string]any{"sku": "b", "qty": 5}},
+		"address": map[string]any{"city": "Lisbon"},
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  []any
+	}{
+		{"string", `$.name`, []any{"order-1"}},
+		{"number", `$.total`, []any{42.5}},
+		{"bool", `$.paid`, []any{true}},
+		{"null", `$.coupon`, []any{nil}},
+		{"missing", `$.nope`, []any{}},
+		{"nested", `$.address.city`, []any{"Lisbon"}},
+		{"index", `$.items[-1].sku`, []any{"b"}},
+		{"slice", `$.items[:].qty`, []any{float64(2), float64(5)}},
+		{"filter", `$.items[?@.qty > 3].sku`, []any{"b"}},
+		{"descendant", `$..sku`, []any{"a", "b"}},
+		{"length", `$[?length(@) == 2]`, []any{Value(doc.Fields["items"])}},
+		{"null_filter", `$[?@ == null]`, []any{nil}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			res := jsonpath.MustParse(tc.path).Select(Value(doc))
+			assert.ElementsMatch(t, tc.exp, []any(res))
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Nil(Value((*structpb.Struct)(nil)))
+	a.Nil(Value((*structpb.ListValue)(nil)))
+	a.Nil(Value((*structpb.Value)(nil)))
+	a.Nil(Value(structpb.NewNullValue()))
+	a.Equal("x", Value("x"))
+	a.Equal(1.5, Value(structpb.NewNumberValue(1.5)))
+
+	list, err := structpb.NewList([]any{1, "two", []any{3}})
+	require.NoError(t, err)
+	arr, ok := Value(structpb.NewListValue(list)).(List)
+	a.True(ok)
+	a.Same(list, arr.ListValue)
+	a.Equal(3, arr.Len())
+	v, ok := arr.Get(2)
+	a.True(ok)
+	a.IsType(List{}, v)
+	_, ok = arr.Get(3)
+	a.False(ok)
+	_, ok = arr.Get(-1)
+	a.False(ok)
+
+	st, err := structpb.NewStruct(map[string]any{"a": 1, "b": 2})
+	require.NoError(t, err)
+	obj, ok := Value(structpb.NewStructValue(st)).(Struct)
+	a.True(ok)
+	a.Same(st, obj.Struct)
+	a.Equal(2, obj.Len())
+
+	// Stop iteration early.
+	n := 0
+	for range obj.Iterate() {
+		n++
+		break
+	}
+	for range arr.Iterate() {
+		n++
+		break
+	}
+	a.Equal(2, n)
+}
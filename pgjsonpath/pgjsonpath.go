@@ -0,0 +1,372 @@
+// Package pgjsonpath translates RFC 9535 JSONPath queries into [PostgreSQL
+// SQL/JSON path] expressions, so that services can select the same values
+// in the application, with [jsonpath.Path.Select], and in the database, with
+// jsonb_path_query and related functions. Use [ToPostgres] to translate a
+// path.
+//
+// [PostgreSQL SQL/JSON path]: https://www.postgresql.org/docs/current/functions-json.html#FUNCTIONS-SQLJSON-PATH
+package pgjsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+)
+
+// ErrUnsupported errors are returned by [ToPostgres] for paths that cannot
+// be translated.
+var ErrUnsupported = errors.New("pgjsonpath")
+
+// ToPostgres translates path into a PostgreSQL SQL/JSON path expression in
+// strict mode. It supports:
+//
+//   - Name, index, and wildcard selectors
+//   - Slice selectors with a step of 1
+//   - Segments with multiple index and slice selectors
+//   - Descendant segments, translated to the .** accessor
+//   - Filter selectors with comparisons, existence tests, logical
+//     operators, and the match() and search() functions with literal
+//     regular expressions, translated to like_regex
+//
+// Strict mode, like RFC 9535, neither unwraps arrays nor wraps other values
+// in arrays to apply selectors. Outside filter expressions and descendant
+// segments, however, PostgreSQL raises an error for name selectors applied
+// to values other than objects with that member and for indexes out of the
+// bounds of arrays, where RFC 9535 selects nothing. Pass silent => true to
+// jsonb_path_query, or use the @? operator, to suppress those errors for
+// singular queries. In filter expressions, such errors make existence
+// tests and comparisons unknown, rather than false or true, so that
+// nonexistence tests and != comparisons with missing values are false.
+//
+// Returns an [ErrUnsupported] error listing each construct of path that it
+// cannot translate, such as unions of names, slices with other steps, and
+// the length(), count(), and value() functions.
+func ToPostgres(path *jsonpath.Path) (string, error) {
+	t := &translator{path: path}
+	t.buf.WriteString("strict ")
+	t.query(path.Query())
+	if len(t.errs) > 0 {
+		return "", errors.Join(t.errs...)
+	}
+	return t.buf.String(), nil
+}
+
+// translator translates a query into a SQL/JSON path expression, collecting
+// errors for the constructs it cannot translate.
+type translator struct {
+	path *jsonpath.Path
+	buf  strings.Builder
+	errs []error
+}
+
+// unsupported records an error for a construct that t cannot translate.
+func (t *translator) unsupported(format string, args ...any) {
+	t.errs = append(t.errs, fmt.Errorf(
+		"%w: cannot translate %v in %v",
+		ErrUnsupported, fmt.Sprintf(format, args...), t.path,
+	))
+}
+
+// query translates q.
+func (t *translator) query(q *spec.PathQuery) {
+	t.root(q.IsRoot())
+	for _, seg := range q.Segments() {
+		t.segment(seg)
+	}
+}
+
+// root writes the identifier for the root node, $, if root is true, and
+// for the current node, @, if it is false.
+func (t *translator) root(root bool) {
+	if root {
+		t.buf.WriteByte('$')
+	} else {
+		t.buf.WriteByte('@')
+	}
+}
+
+// segment translates seg. Wildcards and filters in descendant segments
+// select all descendants, while other selectors in descendant segments
+// select from the value of each descendant and the value itself.
+func (t *translator) segment(seg *spec.Segment) {
+	sels := seg.Selectors()
+	levels := "{1}"
+	if seg.IsDescendant() {
+		levels = "{1 to last}"
+	}
+
+	if len(sels) == 1 {
+		switch sel := sels[0].(type) {
+		case spec.WildcardSelector:
+			t.buf.WriteString(".**" + levels)
+			return
+		case *spec.FilterSelector:
+			t.buf.WriteString(".**" + levels + " ? (")
+			t.or(sel.LogicalOr)
+			t.buf.WriteByte(')')
+			return
+		}
+	}
+
+	if seg.IsDescendant() {
+		t.buf.WriteString(".**")
+	}
+	if len(sels) == 1 {
+		if name, ok := sels[0].(spec.Name); ok {
+			t.name(name)
+			return
+		}
+	}
+	t.subscripts(seg)
+}
+
+// name translates a name selector to a member accessor, quoting it unless
+// it is an identifier.
+func (t *translator) name(name spec.Name) {
+	t.buf.WriteByte('.')
+	if isIdentifier(string(name)) {
+		t.buf.WriteString(string(name))
+	} else {
+		writeString(&t.buf, string(name))
+	}
+}
+
+// subscripts translates the selectors of seg, which must be indexes and
+// slices, to an array accessor, as in [0, 2 to 4, last].
+func (t *translator) subscripts(seg *spec.Segment) {
+	t.buf.WriteByte('[')
+	for i, sel := range seg.Selectors() {
+		if i > 0 {
+			t.buf.WriteString(", ")
+		}
+		switch sel := sel.(type) {
+		case spec.Index:
+			t.buf.WriteString(subscript(int(sel)))
+		case spec.SliceSelector:
+			t.slice(sel)
+		default:
+			t.unsupported("%v selector %v in union", selectorKind(sel), sel)
+		}
+	}
+	t.buf.WriteByte(']')
+}
+
+// slice translates s to a subscript range, as in 1 to last.
+func (t *translator) slice(s spec.SliceSelector) {
+	if s.Step() != 1 {
+		t.unsupported("slice with step %v [%v]", s.Step(), s)
+		return
+	}
+	t.buf.WriteString(subscript(s.Start()))
+	t.buf.WriteString(" to ")
+	switch end := s.End(); {
+	case end == math.MaxInt:
+		t.buf.WriteString("last")
+	case end > 0:
+		t.buf.WriteString(strconv.Itoa(end - 1))
+	default:
+		t.buf.WriteString(subscript(end - 1))
+	}
+}
+
+// subscript returns the array subscript for idx, counting from the end of
+// the array with last for a negative idx.
+func subscript(idx int) string {
+	switch {
+	case idx >= 0:
+		return strconv.Itoa(idx)
+	case idx == -1:
+		return "last"
+	default:
+		return "last - " + strconv.Itoa(-idx-1)
+	}
+}
+
+// selectorKind returns the kind of sel for use in error messages.
+func selectorKind(sel spec.Selector) string {
+	switch sel.(type) {
+	case spec.Name:
+		return "name"
+	case spec.WildcardSelector:
+		return "wildcard"
+	default:
+		return "filter"
+	}
+}
+
+// or translates lo, joining its expressions with ||.
+func (t *translator) or(lo spec.LogicalOr) {
+	for i, and := range lo {
+		if i > 0 {
+			t.buf.WriteString(" || ")
+		}
+		t.and(and)
+	}
+}
+
+// and translates la, joining its expressions with &&.
+func (t *translator) and(la spec.LogicalAnd) {
+	for i, expr := range la {
+		if i > 0 {
+			t.buf.WriteString(" && ")
+		}
+		t.expr(expr)
+	}
+}
+
+// expr translates expr.
+func (t *translator) expr(expr spec.BasicExpr) {
+	switch e := expr.(type) {
+	case *spec.ParenExpr:
+		t.buf.WriteByte('(')
+		t.or(e.LogicalOr)
+		t.buf.WriteByte(')')
+	case *spec.NotParenExpr:
+		t.buf.WriteString("!(")
+		t.or(e.LogicalOr)
+		t.buf.WriteByte(')')
+	case *spec.ExistExpr:
+		t.buf.WriteString("exists(")
+		t.query(e.PathQuery)
+		t.buf.WriteByte(')')
+	case *spec.NonExistExpr:
+		t.buf.WriteString("!exists(")
+		t.query(e.PathQuery)
+		t.buf.WriteByte(')')
+	case spec.NonExistExpr:
+		t.expr(&e)
+	case *spec.CompExpr:
+		t.value(e.Left())
+		t.buf.WriteString(" " + e.Op().String() + " ")
+		t.value(e.Right())
+	case *spec.FuncExpr:
+		t.function(e)
+	case spec.NotFuncExpr:
+		t.buf.WriteString("!(")
+		t.function(e.FuncExpr)
+		t.buf.WriteByte(')')
+	}
+}
+
+// value translates val, a comparison operand or function argument.
+func (t *translator) value(val any) {
+	switch v := val.(type) {
+	case *spec.LiteralArg:
+		t.literal(v.Value())
+	case *spec.SingularQueryExpr:
+		t.root(v.IsRoot())
+		for _, sel := range v.Selectors() {
+			switch sel := sel.(type) {
+			case spec.Name:
+				t.name(sel)
+			case spec.Index:
+				t.buf.WriteString("[" + subscript(int(sel)) + "]")
+			}
+		}
+	case *spec.PathQuery:
+		t.query(v)
+	case *spec.FuncExpr:
+		t.unsupported("function %v()", v.Func().Name())
+	default:
+		t.unsupported("argument %v", v)
+	}
+}
+
+// function translates fe, which must be a call to match() or search() with
+// a literal regular expression, to a like_regex predicate.
+func (t *translator) function(fe *spec.FuncExpr) {
+	name := fe.Func().Name()
+	args := fe.Args()
+	if name != "match" && name != "search" {
+		t.unsupported("function %v()", name)
+		return
+	}
+	var re string
+	lit, ok := args[1].(*spec.LiteralArg)
+	if ok {
+		re, ok = lit.Value().(string)
+	}
+	if !ok {
+		t.unsupported("%v() with non-literal regular expression %v", name, args[1])
+		return
+	}
+	if name == "match" {
+		re = "^(?:" + re + ")$"
+	}
+	t.value(args[0])
+	t.buf.WriteString(" like_regex ")
+	writeString(&t.buf, re)
+}
+
+// literal writes the SQL/JSON path literal for val.
+func (t *translator) literal(val any) {
+	switch v := val.(type) {
+	case nil:
+		t.buf.WriteString("null")
+	case string:
+		writeString(&t.buf, v)
+	case bool:
+		t.buf.WriteString(strconv.FormatBool(v))
+	case int64:
+		t.buf.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		t.buf.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	case json.Number:
+		t.buf.WriteString(v.String())
+	default:
+		// Literals created by spec.Literal with other types.
+		t.buf.WriteString(fmt.Sprint(v))
+	}
+}
+
+// isIdentifier returns true if s can be written as an unquoted member
+// accessor: an ASCII letter or underscore followed by ASCII letters,
+// digits, or underscores.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := range len(s) {
+		c := s[i]
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// writeString writes s to buf as a double-quoted SQL/JSON path string
+// literal, escaping quotation marks, backslashes, and control characters.
+func writeString(buf *strings.Builder, s string) {
+	const hex = "0123456789abcdef"
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hex[r>>4])
+			buf.WriteByte(hex[r&0xf])
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+}
@@ -0,0 +1,21 @@
+package pgjsonpath_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/pgjsonpath"
+)
+
+// Translate a JSONPath query into a PostgreSQL SQL/JSON path expression to
+// pass to jsonb_path_query.
+func ExampleToPostgres() {
+	path := jsonpath.MustParse(`$.friends[?@.age > 45 && match(@.first, "R.*")].first`)
+	expr, err := pgjsonpath.ToPostgres(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(expr)
+	// Output: strict $.friends.**{1} ? (@.age > 45 && @.first like_regex "^(?:R.*)$").first
+}
@@ -0,0 +1,99 @@
+package pgjsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+)
+
+func TestToPostgres(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  string
+		err  string
+	}{
+		{test: "root", path: `$`, exp: `strict $`},
+		{test: "names", path: `$.name.last`, exp: `strict $.name.last`},
+		{test: "underscore", path: `$._x1`, exp: `strict $._x1`},
+		{test: "quoted_name", path: `$['fav movie']`, exp: `strict $."fav movie"`},
+		{test: "digit_name", path: `$['1a']`, exp: `strict $."1a"`},
+		{test: "empty_name", path: `$['']`, exp: `strict $.""`},
+		{test: "unicode_name", path: `$['über']`, exp: `strict $."über"`},
+		{test: "escaped_name", path: `$['a"b\\c\n\u0001']`, exp: `strict $."a\"b\\c\n\u0001"`},
+		{test: "index", path: `$.a[1]`, exp: `strict $.a[1]`},
+		{test: "last", path: `$.a[-1]`, exp: `strict $.a[last]`},
+		{test: "from_last", path: `$.a[-3]`, exp: `strict $.a[last - 2]`},
+		{test: "slice", path: `$.a[1:3]`, exp: `strict $.a[1 to 2]`},
+		{test: "open_slice", path: `$.a[2:]`, exp: `strict $.a[2 to last]`},
+		{test: "negative_slice", path: `$.a[-3:-1]`, exp: `strict $.a[last - 2 to last - 1]`},
+		{test: "head_slice", path: `$.a[:2]`, exp: `strict $.a[0 to 1]`},
+		{test: "indexes", path: `$.a[0,2,-1]`, exp: `strict $.a[0, 2, last]`},
+		{test: "index_slice", path: `$.a[0,2:4]`, exp: `strict $.a[0, 2 to 3]`},
+		{test: "wildcard", path: `$.a[*]`, exp: `strict $.a.**{1}`},
+		{test: "descendant_name", path: `$..name`, exp: `strict $.**.name`},
+		{test: "descendant_index", path: `$..[0]`, exp: `strict $.**[0]`},
+		{test: "descendant_wildcard", path: `$..*`, exp: `strict $.**{1 to last}`},
+		{test: "filter", path: `$.a[?@.x > 2]`, exp: `strict $.a.**{1} ? (@.x > 2)`},
+		{test: "filter_root", path: `$.a[?@.x == $.y[0]]`, exp: `strict $.a.**{1} ? (@.x == $.y[0])`},
+		{test: "filter_literals", path: `$[?@.a == null || @.b != true && @.c <= 1.5 || @.d >= -3 || @.e < "x\"y"]`, exp: `strict $.**{1} ? (@.a == null || @.b != true && @.c <= 1.5 || @.d >= -3 || @.e < "x\"y")`},
+		{test: "filter_exists", path: `$[?@.a && !@.b]`, exp: `strict $.**{1} ? (exists(@.a) && !exists(@.b))`},
+		{test: "filter_exists_query", path: `$[?@..a[*]]`, exp: `strict $.**{1} ? (exists(@.**.a.**{1}))`},
+		{test: "filter_paren", path: `$[?(@.a || @.b) && !(@.c == 1)]`, exp: `strict $.**{1} ? ((exists(@.a) || exists(@.b)) && !(@.c == 1))`},
+		{test: "descendant_filter", path: `$..[?@.a]`, exp: `strict $.**{1 to last} ? (exists(@.a))`},
+		{test: "match", path: `$[?match(@.a, "a.c")]`, exp: `strict $.**{1} ? (@.a like_regex "^(?:a.c)$")`},
+		{test: "search", path: `$[?search(@.a, "\\d+")]`, exp: `strict $.**{1} ? (@.a like_regex "\\d+")`},
+		{test: "not_match", path: `$[?!match(@.a, "x")]`, exp: `strict $.**{1} ? (!(@.a like_regex "^(?:x)$"))`},
+		{
+			test: "name_union",
+			path: `$['a','b']`,
+			err: `pgjsonpath: cannot translate name selector "a" in union in $["a","b"]` + "\n" +
+				`pgjsonpath: cannot translate name selector "b" in union in $["a","b"]`,
+		},
+		{
+			test: "step",
+			path: `$.a[::2]`,
+			err:  `pgjsonpath: cannot translate slice with step 2 [::2] in $["a"][::2]`,
+		},
+		{
+			test: "length",
+			path: `$[?length(@.a) > 2]`,
+			err:  `pgjsonpath: cannot translate function length() in $[?length(@["a"]) > 2]`,
+		},
+		{
+			test: "count",
+			path: `$[?count(@.*) == 1]`,
+			err:  `pgjsonpath: cannot translate function count() in $[?count(@[*]) == 1]`,
+		},
+		{
+			test: "regex_query",
+			path: `$[?match(@.a, @.b)]`,
+			err:  `pgjsonpath: cannot translate match() with non-literal regular expression @["b"] in $[?match(@["a"], @["b"])]`,
+		},
+		{
+			test: "multiple",
+			path: `$['a',*][::-1][?value(@.b) == 1]`,
+			err: `pgjsonpath: cannot translate name selector "a" in union in $["a",*][::-1][?value(@["b"]) == 1]` + "\n" +
+				`pgjsonpath: cannot translate wildcard selector * in union in $["a",*][::-1][?value(@["b"]) == 1]` + "\n" +
+				`pgjsonpath: cannot translate slice with step -1 [::-1] in $["a",*][::-1][?value(@["b"]) == 1]` + "\n" +
+				`pgjsonpath: cannot translate function value() in $["a",*][::-1][?value(@["b"]) == 1]`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			res, err := ToPostgres(jsonpath.MustParse(tc.path))
+			if tc.err == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tc.exp, res)
+			} else {
+				require.EqualError(t, err, tc.err)
+				require.ErrorIs(t, err, ErrUnsupported)
+				assert.Empty(t, res)
+			}
+		})
+	}
+}
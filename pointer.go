@@ -0,0 +1,30 @@
+package jsonpath
+
+import "github.com/theory/jsonpath/spec"
+
+// ErrPointer errors are returned by [FromPointer] for invalid JSON Pointers.
+var ErrPointer = spec.ErrPointer
+
+// FromPointer converts ptr, an [RFC 6901 JSON Pointer], into a [Path] that
+// selects the value ptr identifies. Because a JSON Pointer does not
+// distinguish array indexes from object member names, FromPointer converts
+// reference tokens that are valid array indexes, such as 0 or 12, into
+// segments that select both the member with that name and the element at
+// that index, as in ["12",12]. Such a segment selects at most one value,
+// since a value is either an object or an array. Other reference tokens,
+// including "-", become name selectors.
+//
+// Returns an [ErrPointer] error if ptr does not start with "/" or contains
+// a "~" not followed by "0" or "1". To convert a singular [Path] to a JSON
+// Pointer, use [spec.NormalizedPath.Pointer] on the prefix returned by
+// [Path.SingularPrefix]. See [spec.PointerQuery] for details.
+//
+// [RFC 6901 JSON Pointer]: https://www.rfc-editor.org/rfc/rfc6901
+func FromPointer(ptr string) (*Path, error) {
+	q, err := spec.PointerQuery(ptr)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	return New(q), nil
+}
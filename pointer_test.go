@@ -0,0 +1,97 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromPointer(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"foo":  []any{"bar", "baz"},
+		"":     0,
+		"a/b":  1,
+		"c%d":  2,
+		"e^f":  3,
+		"g|h":  4,
+		"i\\j": 5,
+		"k\"l": 6,
+		" ":    7,
+		"m~n":  8,
+		"~1":   9,
+		"0":    map[string]any{"01": "x", "-": "y"},
+	}
+
+	for _, tc := range []struct {
+		test string
+		ptr  string
+		path string
+		exp  NodeList
+	}{
+		// https://www.rfc-editor.org/rfc/rfc6901#section-5
+		{"whole", "", `$`, NodeList{input}},
+		{"array", "/foo", `$["foo"]`, NodeList{input["foo"]}},
+		{"index", "/foo/0", `$["foo"]["0",0]`, NodeList{"bar"}},
+		{"empty_name", "/", `$[""]`, NodeList{0}},
+		{"slash", "/a~1b", `$["a/b"]`, NodeList{1}},
+		{"percent", "/c%d", `$["c%d"]`, NodeList{2}},
+		{"caret", "/e^f", `$["e^f"]`, NodeList{3}},
+		{"pipe", "/g|h", `$["g|h"]`, NodeList{4}},
+		{"backslash", "/i\\j", `$["i\\j"]`, NodeList{5}},
+		{"quote", "/k\"l", `$["k\"l"]`, NodeList{6}},
+		{"space", "/ ", `$[" "]`, NodeList{7}},
+		{"tilde", "/m~0n", `$["m~n"]`, NodeList{8}},
+		{"escape_order", "/~01", `$["~1"]`, NodeList{9}},
+		// Other tokens.
+		{"numeric_name", "/0/01", `$["0",0]["01"]`, NodeList{"x"}},
+		{"dash", "/0/-", `$["0",0]["-"]`, NodeList{"y"}},
+		{"past_end", "/foo/-", `$["foo"]["-"]`, NodeList{}},
+		{"out_of_range", "/foo/2", `$["foo"]["2",2]`, NodeList{}},
+		{"max_index", "/foo/9007199254740991", `$["foo"]["9007199254740991",9007199254740991]`, NodeList{}},
+		{"large_index", "/foo/9007199254740992", `$["foo"]["9007199254740992"]`, NodeList{}},
+		{"overflow", "/foo/99999999999999999999", `$["foo"]["99999999999999999999"]`, NodeList{}},
+		{"signed", "/foo/+1", `$["foo"]["+1"]`, NodeList{}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			path, err := FromPointer(tc.ptr)
+			require.NoError(t, err)
+			a.Equal(tc.path, path.String())
+			a.Equal(tc.exp, path.Select(input))
+
+			// Should round-trip through Pointer.
+			for _, node := range path.SelectLocated(input) {
+				a.Equal(tc.ptr, node.Path.Pointer())
+			}
+		})
+	}
+}
+
+func TestFromPointerErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		ptr  string
+		err  string
+	}{
+		{"no_slash", "foo", `jsonpath: invalid JSON Pointer: "foo" does not start with /`},
+		{"bad_escape", "/a~2", `jsonpath: invalid JSON Pointer: invalid escape in "a~2" in "/a~2"`},
+		{"trailing_tilde", "/a/b~", `jsonpath: invalid JSON Pointer: invalid escape in "b~" in "/a/b~"`},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			path, err := FromPointer(tc.ptr)
+			a.ErrorIs(err, ErrPointer)
+			a.EqualError(err, tc.err)
+			a.Nil(path)
+		})
+	}
+}
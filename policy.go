@@ -0,0 +1,256 @@
+package jsonpath
+
+import (
+	"slices"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Policy enforces field-level authorization with lists of paths that allow
+// and deny access to nodes, for API gateways and services that return
+// only the fields a client may read. A node is permitted if it is, or is a
+// descendant of, a node selected by an allow path, and is neither a node
+// selected by a deny path nor one of its descendants, so that deny paths
+// take precedence. A Policy with no allow paths permits nothing; allow $
+// to permit every node not denied. Create one with [NewPolicy]. It's safe
+// for concurrent use.
+type Policy struct {
+	allow      []*Path
+	deny       []*Path
+	allowBelow []*spec.PathQuery
+	denyBelow  []*spec.PathQuery
+}
+
+// NewPolicy creates a [Policy] that permits the nodes selected by allow,
+// and their descendants, except for the nodes selected by deny and their
+// descendants.
+func NewPolicy(allow, deny []*Path) *Policy {
+	p := &Policy{
+		allow:      allow,
+		deny:       deny,
+		allowBelow: make([]*spec.PathQuery, len(allow)),
+		denyBelow:  make([]*spec.PathQuery, len(deny)),
+	}
+	for i, path := range allow {
+		p.allowBelow[i] = below(path.q)
+	}
+	for i, path := range deny {
+		p.denyBelow[i] = below(path.q)
+	}
+	return p
+}
+
+// Allow returns the paths that allow access to nodes.
+func (p *Policy) Allow() []*Path {
+	return p.allow
+}
+
+// Deny returns the paths that deny access to nodes.
+func (p *Policy) Deny() []*Path {
+	return p.deny
+}
+
+// Filter returns a new document that contains only the nodes of doc that p
+// permits, nested as they are in doc, as [Path.Project] does for the
+// nodes selected by the allow paths, but omitting the nodes selected by
+// the deny paths. It copies the objects and arrays that contain denied
+// nodes, so that it never modifies doc, and shares other values with doc.
+// Objects and arrays from which it removes every member or element remain
+// in the result, empty. Returns nil if p permits no nodes of doc.
+//
+// Filter fails closed: it evaluates the paths with [Path.TrySelectLocated]
+// and returns nil and the error if evaluation of any of them aborts, as
+// when it exceeds the limits configured by [WithMaxNodes] or [WithTimeout],
+// rather than permit nodes that a deny path might have selected.
+func (p *Policy) Filter(doc any) (any, error) {
+	root := &projection{}
+	for _, path := range p.allow {
+		nodes, err := path.TrySelectLocated(doc)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			root.add(node.Path, node.Node)
+		}
+	}
+	for _, path := range p.deny {
+		nodes, err := path.TrySelectLocated(doc)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			if root.remove(node.Path) {
+				root = &projection{}
+			}
+		}
+	}
+	if !root.whole && root.names == nil && root.indexes == nil {
+		return nil, nil
+	}
+	return root.build(), nil
+}
+
+// Permits returns true if p permits query to read the nodes it selects,
+// and the nodes read by the queries in its filter expressions, from any
+// input, so that gateways can reject unauthorized queries before
+// evaluating them. It uses [Path.Subsumes] to determine that the allow
+// paths cover those nodes and [Path.Overlaps] to determine that no deny
+// path selects them, their descendants, or their ancestors. It therefore
+// returns false if that depends on the input, as when query or a deny path
+// contains filters that may select the same nodes, or when query contains
+// a filter that reads a field that p does not permit, such as
+// $.users[?@.ssn == "123"].name when p denies $.users[*].ssn.
+func (p *Policy) Permits(query *Path) bool {
+	for _, q := range reads(query.q, nil) {
+		if !p.allows(q) || p.denies(q) {
+			return false
+		}
+	}
+	return true
+}
+
+// allows returns true if the allow paths of p select every node that q
+// selects or an ancestor of it.
+func (p *Policy) allows(q *spec.PathQuery) bool {
+	for i, path := range p.allow {
+		if path.q.Subsumes(q) || p.allowBelow[i].Subsumes(q) {
+			return true
+		}
+	}
+	return false
+}
+
+// denies returns true if the deny paths of p may select a node that q
+// selects, or an ancestor or descendant of it.
+func (p *Policy) denies(q *spec.PathQuery) bool {
+	qBelow := below(q)
+	for i, path := range p.deny {
+		if path.q.Overlaps(q) || p.denyBelow[i].Overlaps(q) || path.q.Overlaps(qBelow) {
+			return true
+		}
+	}
+	return false
+}
+
+// below returns a query that selects the descendants of the nodes that q
+// selects.
+func below(q *spec.PathQuery) *spec.PathQuery {
+	return spec.Query(q.IsRoot(), slices.Concat(q.Segments(), []*spec.Segment{
+		spec.Descendant(spec.Wildcard()),
+	})...)
+}
+
+// reads returns root queries that together select every node that q
+// reads: the nodes it selects and the nodes selected by the queries in its
+// filter expressions. If q is relative, current contains the segments that
+// select its current node (@).
+func reads(q *spec.PathQuery, current []*spec.Segment) []*spec.PathQuery {
+	segs := q.Segments()
+	offset := 0
+	if !q.IsRoot() {
+		offset = len(current)
+		segs = slices.Concat(current, segs)
+	}
+	res := []*spec.PathQuery{spec.Query(true, segs...)}
+
+	for i, seg := range q.Segments() {
+		for _, sel := range seg.Selectors() {
+			filter, ok := sel.(*spec.FilterSelector)
+			if !ok {
+				continue
+			}
+			// The current node of the filter's queries may be any node
+			// that seg selects.
+			wild := spec.Child(spec.Wildcard())
+			if seg.IsDescendant() {
+				wild = spec.Descendant(spec.Wildcard())
+			}
+			v := &readVisitor{current: slices.Concat(segs[:offset+i], []*spec.Segment{wild})}
+			v.filter = spec.Query(false, spec.Child(filter))
+			spec.Walk(v.filter, v)
+			res = append(res, v.reads...)
+		}
+	}
+	return res
+}
+
+// readVisitor is a [spec.Visitor] that collects the [reads] of the queries
+// in the filter expression of the filter query, whose current node is
+// selected by current.
+type readVisitor struct {
+	filter  *spec.PathQuery
+	current []*spec.Segment
+	reads   []*spec.PathQuery
+}
+
+// Enter collects the reads of queries and singular queries other than
+// v.filter, and skips their children, which reads itself visits.
+func (v *readVisitor) Enter(node any) bool {
+	switch node := node.(type) {
+	case *spec.PathQuery:
+		if node == v.filter {
+			return true
+		}
+		v.reads = append(v.reads, reads(node, v.current)...)
+		return false
+	case *spec.SingularQueryExpr:
+		segs := make([]*spec.Segment, len(node.Selectors()))
+		for i, sel := range node.Selectors() {
+			segs[i] = spec.Child(sel)
+		}
+		v.reads = append(v.reads, reads(spec.Query(node.IsRoot(), segs...), v.current)...)
+		return false
+	default:
+		return true
+	}
+}
+
+// Exit does nothing.
+func (*readVisitor) Exit(any) {}
+
+// remove removes the node at path, relative to p, from the projection,
+// copying nodes included in their entirety as necessary. Returns true if
+// p itself should be removed, because path is empty or because removing
+// the node left p empty and p was not itself included in its entirety.
+func (p *projection) remove(path spec.NormalizedPath) bool {
+	if len(path) == 0 {
+		return true
+	}
+	if p.whole {
+		p.expand()
+	}
+
+	var next *projection
+	switch sel := path[0].(type) {
+	case spec.Name:
+		next = p.names[string(sel)]
+	case spec.Index:
+		next = p.indexes[int(sel)]
+	}
+	if next == nil || !next.remove(path[1:]) {
+		return false
+	}
+
+	switch sel := path[0].(type) {
+	case spec.Name:
+		delete(p.names, string(sel))
+	case spec.Index:
+		delete(p.indexes, int(sel))
+	}
+	return !p.expanded && len(p.names) == 0 && len(p.indexes) == 0
+}
+
+// expand replaces a projection that includes a value in its entirety with
+// projections of each of its members or elements, so that they may be
+// removed. Leaves scalars and empty objects and arrays unchanged.
+func (p *projection) expand() {
+	children := spec.Query(false, spec.Child(spec.Wildcard()))
+	nodes := children.SelectLocated(p.value, p.value, nil)
+	if len(nodes) == 0 {
+		return
+	}
+	*p = projection{expanded: true}
+	for _, node := range nodes {
+		p.add(node.Path, node.Node)
+	}
+}
@@ -0,0 +1,197 @@
+package jsonpath
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// paths parses queries into paths.
+func paths(queries ...string) []*Path {
+	res := make([]*Path, len(queries))
+	for i, q := range queries {
+		res[i] = MustParse(q)
+	}
+	return res
+}
+
+func TestPolicyFilter(t *testing.T) {
+	t.Parallel()
+
+	doc := map[string]any{
+		"id": 1,
+		"user": map[string]any{
+			"name":  "Alice",
+			"ssn":   "123",
+			"cards": []any{map[string]any{"num": "4111", "exp": "12/30"}, map[string]any{"num": "5500"}},
+		},
+		"tags": []any{"a", "b", "c"},
+	}
+
+	for _, tc := range []struct {
+		test  string
+		allow []string
+		deny  []string
+		exp   any
+	}{
+		{
+			test: "no_allow",
+			deny: []string{`$.user`},
+			exp:  nil,
+		},
+		{
+			test:  "allow_all",
+			allow: []string{`$`},
+			exp:   doc,
+		},
+		{
+			test:  "allow_fields",
+			allow: []string{`$.id`, `$.user.name`, `$.nonesuch`},
+			exp:   map[string]any{"id": 1, "user": map[string]any{"name": "Alice"}},
+		},
+		{
+			test:  "deny_member",
+			allow: []string{`$`},
+			deny:  []string{`$.user.ssn`},
+			exp: map[string]any{
+				"id": 1,
+				"user": map[string]any{
+					"name":  "Alice",
+					"cards": []any{map[string]any{"num": "4111", "exp": "12/30"}, map[string]any{"num": "5500"}},
+				},
+				"tags": []any{"a", "b", "c"},
+			},
+		},
+		{
+			test:  "deny_descendants",
+			allow: []string{`$.user`},
+			deny:  []string{`$..num`, `$.user.ssn`},
+			exp: map[string]any{"user": map[string]any{
+				"name":  "Alice",
+				"cards": []any{map[string]any{"exp": "12/30"}, map[string]any{}},
+			}},
+		},
+		{
+			test:  "deny_elements",
+			allow: []string{`$.tags`, `$.id`},
+			deny:  []string{`$.tags[0,2]`},
+			exp:   map[string]any{"id": 1, "tags": []any{"b"}},
+		},
+		{
+			test:  "deny_filter",
+			allow: []string{`$.user.cards`},
+			deny:  []string{`$.user.cards[?@.exp]`},
+			exp:   map[string]any{"user": map[string]any{"cards": []any{map[string]any{"num": "5500"}}}},
+		},
+		{
+			test:  "deny_allowed",
+			allow: []string{`$.id`, `$.user.name`},
+			deny:  []string{`$.user.name`},
+			exp:   map[string]any{"id": 1},
+		},
+		{
+			test:  "deny_all_allowed",
+			allow: []string{`$.user.name`},
+			deny:  []string{`$.user`},
+			exp:   nil,
+		},
+		{
+			test:  "deny_root",
+			allow: []string{`$`},
+			deny:  []string{`$`},
+			exp:   nil,
+		},
+		{
+			test:  "deny_unallowed",
+			allow: []string{`$.id`},
+			deny:  []string{`$.user.ssn`, `$.id.x`},
+			exp:   map[string]any{"id": 1},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			policy := NewPolicy(paths(tc.allow...), paths(tc.deny...))
+			a.Len(policy.Allow(), len(tc.allow))
+			a.Len(policy.Deny(), len(tc.deny))
+			res, err := policy.Filter(doc)
+			a.NoError(err)
+			a.Equal(tc.exp, res)
+		})
+	}
+
+	// Should not modify the document.
+	assert.Equal(t, "123", doc["user"].(map[string]any)["ssn"])
+	assert.Len(t, doc["tags"], 3)
+
+	// Should fail closed when evaluation aborts.
+	users := make([]any, 1000)
+	for i := range users {
+		users[i] = map[string]any{"id": i, "ssn": "123"}
+	}
+	for _, tc := range []struct {
+		test  string
+		allow *Path
+		deny  *Path
+	}{
+		{"deny_max_nodes", MustParse(`$`), NewParser(WithMaxNodes(3)).MustParse(`$..ssn`)},
+		{"deny_timeout", MustParse(`$`), NewParser(WithTimeout(time.Nanosecond)).MustParse(`$..ssn`)},
+		{"allow_max_nodes", NewParser(WithMaxNodes(3)).MustParse(`$..*`), MustParse(`$..ssn`)},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			res, err := NewPolicy([]*Path{tc.allow}, []*Path{tc.deny}).Filter(users)
+			a.ErrorIs(err, ErrBudgetExceeded)
+			a.Nil(res)
+		})
+	}
+}
+
+func TestPolicyPermits(t *testing.T) {
+	t.Parallel()
+
+	policy := NewPolicy(
+		paths(`$.users[*]`, `$.meta`),
+		paths(`$.users[*].ssn`, `$.meta.secret`),
+	)
+
+	for _, tc := range []struct {
+		query string
+		exp   bool
+	}{
+		{`$.users[*].name`, true},
+		{`$.users[0].name`, true},
+		{`$.users[*]['name', 'email']`, true},
+		{`$.users[?@.age > 21].name`, true},
+		{`$.meta.version`, true},
+		{`$.meta[?@ == 1]`, false},
+		{`$.users[*].ssn`, false},
+		{`$.users[0]['name', 'ssn']`, false},
+		{`$.users[*]`, false},
+		{`$.users`, false},
+		{`$..name`, false},
+		{`$.meta`, false},
+		{`$.meta.secret.x`, false},
+		{`$.other`, false},
+		{`$`, false},
+		{`$.users[?@.ssn == "123"].name`, false},
+		{`$.users[?@..ssn].name`, false},
+		{`$.users[?length(@.ssn) > 0].name`, false},
+		{`$.users[?$.other].name`, false},
+		{`$.users[?$.meta.version == 1].name`, true},
+		{`$.users[?count(@.friends[?$.users[0].ssn]) > 0].name`, false},
+		{`$.users[?count(@.friends[?@.ssn]) > 0].name`, true},
+		{`$.users[?count(@.friends[?@.id]) > 0].name`, true},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, policy.Permits(MustParse(tc.query)))
+		})
+	}
+
+	// Should permit nothing without allow paths.
+	assert.False(t, NewPolicy(nil, nil).Permits(MustParse(`$.x`)))
+	assert.True(t, NewPolicy(paths(`$`), nil).Permits(MustParse(`$..x`)))
+}
@@ -0,0 +1,97 @@
+package jsonpath
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Project returns a new document that contains only the nodes that p
+// selects from doc, nested as they are in doc, for "sparse fieldsets" that
+// return only the fields a client requests. It reconstructs the objects and
+// arrays along the normalized path to each selected node as map[string]any
+// and []any values, and includes each selected node in its entirety,
+// sharing its value with doc rather than copying it.
+//
+// Arrays in the result contain only the selected elements and those that
+// contain selected nodes, in their original order, so that projecting
+// $.items[*].id from {"items": [{"id": 1, "x": 2}, {"id": 3}]} returns
+// {"items": [{"id": 1}, {"id": 3}]}, while projecting $.items[1].id returns
+// {"items": [{"id": 3}]}. Returns doc itself if p selects the root node, and
+// nil if p selects nothing.
+func (p *Path) Project(doc any) any {
+	root := &projection{}
+	for _, node := range p.SelectLocated(doc) {
+		root.add(node.Path, node.Node)
+	}
+	if !root.whole && root.names == nil && root.indexes == nil {
+		return nil
+	}
+	return root.build()
+}
+
+// projection is a node in the tree of selected paths built by
+// [Path.Project]: either a selected value, or an object or array that
+// contains selected values. Expanded projections are selected values
+// replaced by their members or elements by [Policy.Filter].
+type projection struct {
+	whole    bool
+	expanded bool
+	value    any
+	names    map[string]*projection
+	indexes  map[int]*projection
+}
+
+// add adds val, the node at path relative to p, to the projection. Ignores
+// nodes inside nodes already selected in their entirety.
+func (p *projection) add(path spec.NormalizedPath, val any) {
+	for _, sel := range path {
+		if p.whole {
+			return
+		}
+		var next *projection
+		switch sel := sel.(type) {
+		case spec.Name:
+			if p.names == nil {
+				p.names = map[string]*projection{}
+			}
+			if next = p.names[string(sel)]; next == nil {
+				next = &projection{}
+				p.names[string(sel)] = next
+			}
+		case spec.Index:
+			if p.indexes == nil {
+				p.indexes = map[int]*projection{}
+			}
+			if next = p.indexes[int(sel)]; next == nil {
+				next = &projection{}
+				p.indexes[int(sel)] = next
+			}
+		}
+		p = next
+	}
+	*p = projection{whole: true, value: val}
+}
+
+// build returns the value of p: the selected value, or an object or array
+// of the values of its children.
+func (p *projection) build() any {
+	switch {
+	case p.whole:
+		return p.value
+	case p.names != nil:
+		obj := make(map[string]any, len(p.names))
+		for name, child := range p.names {
+			obj[name] = child.build()
+		}
+		return obj
+	default:
+		indexes := slices.Sorted(maps.Keys(p.indexes))
+		arr := make([]any, len(indexes))
+		for i, idx := range indexes {
+			arr[i] = p.indexes[idx].build()
+		}
+		return arr
+	}
+}
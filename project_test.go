@@ -0,0 +1,78 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestProject(t *testing.T) {
+	t.Parallel()
+
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": 7,
+		"name": "store",
+		"items": [
+			{"id": 1, "title": "a", "tags": ["x", "y"]},
+			{"id": 2, "title": "b", "tags": []},
+			{"id": 3, "title": "c", "tags": ["z"]}
+		],
+		"owner": {"name": "Ann", "email": "ann@example.com"}
+	}`), &doc))
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  string
+	}{
+		{"root", `$`, `{"id": 7, "name": "store", "items": [{"id": 1, "title": "a", "tags": ["x", "y"]}, {"id": 2, "title": "b", "tags": []}, {"id": 3, "title": "c", "tags": ["z"]}], "owner": {"name": "Ann", "email": "ann@example.com"}}`},
+		{"nothing", `$.nope`, `null`},
+		{"name", `$.name`, `{"name": "store"}`},
+		{"names", `$["id","name"]`, `{"id": 7, "name": "store"}`},
+		{"nested", `$.owner.email`, `{"owner": {"email": "ann@example.com"}}`},
+		{"wildcard", `$.items[*].id`, `{"items": [{"id": 1}, {"id": 2}, {"id": 3}]}`},
+		{"index", `$.items[1].title`, `{"items": [{"title": "b"}]}`},
+		{"indexes_order", `$.items[2,0].id`, `{"items": [{"id": 1}, {"id": 3}]}`},
+		{"negative_index", `$.items[-1].id`, `{"items": [{"id": 3}]}`},
+		{"filter", `$.items[?@.id > 1]["id","tags"]`, `{"items": [{"id": 2, "tags": []}, {"id": 3, "tags": ["z"]}]}`},
+		{"container", `$.owner`, `{"owner": {"name": "Ann", "email": "ann@example.com"}}`},
+		{"duplicates", `$["owner",'owner']["name"]`, `{"owner": {"name": "Ann"}}`},
+		{"descendant_names", `$..name`, `{"name": "store", "owner": {"name": "Ann"}}`},
+		{"descendant_ancestor", `$..[?@ == 'Ann' || @.email]`, `{"owner": {"name": "Ann", "email": "ann@example.com"}}`},
+		{"nested_arrays", `$.items[*].tags[0]`, `{"items": [{"tags": ["x"]}, {"tags": ["z"]}]}`},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			got, err := json.Marshal(MustParse(tc.path).Project(doc))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.exp, string(got))
+		})
+	}
+}
+
+func TestProjectShares(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	owner := map[string]any{"name": "Ann"}
+	doc := map[string]any{"owner": owner, "id": 1}
+	res, ok := MustParse(`$.owner`).Project(doc).(map[string]any)
+	a.True(ok)
+	a.Equal(map[string]any{"owner": owner}, res)
+	owner["name"] = "Bob"
+	a.Equal("Bob", res["owner"].(map[string]any)["name"])
+
+	a.Equal(42, MustParse(`$`).Project(42))
+	a.Nil(MustParse(`$.x`).Project(42))
+
+	// Selecting an ancestor after a descendant replaces the descendant.
+	p := &projection{}
+	p.add(spec.Normalized(spec.Name("owner"), spec.Name("name")), "Bob")
+	p.add(spec.Normalized(spec.Name("owner")), owner)
+	p.add(spec.Normalized(spec.Name("owner"), spec.Name("x")), "y")
+	a.Equal(map[string]any{"owner": owner}, p.build())
+}
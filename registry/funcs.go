@@ -3,7 +3,6 @@ package registry
 import (
 	"errors"
 	"fmt"
-	"reflect"
 	"regexp"
 	"regexp/syntax"
 	"unicode/utf8"
@@ -33,9 +32,10 @@ func checkLengthArgs(args []spec.FuncExprArg) error {
 //   - if jv[0] is nil, the result is nil
 //   - If jv[0] is a string, the result is the number of Unicode scalar values
 //     in the string.
-//   - If jv[0] is a slice, the result is the number of elements in the slice.
-//   - If jv[0] is a string-keyed map, the result is the number of members in
-//     the map.
+//   - If jv[0] is a slice, array, or [spec.Array], the result is the number
+//     of elements in the array.
+//   - If jv[0] is a string-keyed map, map[any]any, struct, or [spec.Object],
+//     the result is the number of members in the object.
 //   - For any other value, the result is nil.
 func lengthFunc(jv []spec.PathValue) spec.PathValue {
 	v := spec.ValueFrom(jv[0])
@@ -51,18 +51,13 @@ func lengthFunc(jv []spec.PathValue) spec.PathValue {
 	case map[string]any:
 		return spec.Value(len(v))
 	default:
-		val := reflect.ValueOf(v)
-		switch val.Kind() {
-		case reflect.Slice:
-			return spec.Value(val.Len())
-		case reflect.Map:
-			if val.Type().Key().Kind() == reflect.String {
-				return spec.Value(val.Len())
-			}
-			return nil
-		default:
-			return nil
+		if arr, ok := spec.AsArray(v); ok {
+			return spec.Value(arr.Len())
+		}
+		if obj, ok := spec.AsObject(v); ok {
+			return spec.Value(obj.Len())
 		}
+		return nil
 	}
 }
 
@@ -139,13 +134,15 @@ func checkMatchArgs(args []spec.FuncExprArg) error {
 // jv[1] evaluate to strings, the second is compiled into a regular expression with
 // implied \A and \z anchors and used to match the first, returning LogicalTrue for
 // a match and LogicalFalse for no match. Returns LogicalFalse if either jv value
-// is not a string or if jv[1] fails to compile.
+// is not a string, and LogicalFalse with a warning if jv[1] fails to compile.
 func matchFunc(jv []spec.PathValue) spec.PathValue {
 	if v, ok := spec.ValueFrom(jv[0]).Value().(string); ok {
 		if r, ok := spec.ValueFrom(jv[1]).Value().(string); ok {
-			if rc := compileRegex(`\A` + r + `\z`); rc != nil {
-				return spec.Logical(rc.MatchString(v))
+			rc, err := compileRegex(`\A` + r + `\z`)
+			if err != nil {
+				return regexWarning(r, err)
 			}
+			return spec.Logical(rc.MatchString(v))
 		}
 	}
 	return spec.LogicalFalse
@@ -172,36 +169,48 @@ func checkSearchArgs(args []spec.FuncExprArg) error {
 // searchFunc implements the [RFC 9535]-standard search function. If both jv[0]
 // and jv[1] contain strings, the latter is compiled into a regular expression and used
 // to match the former, returning LogicalTrue for a match and LogicalFalse for no
-// match. Returns LogicalFalse if either value is not a string, or if jv[1]
-// fails to compile.
+// match. Returns LogicalFalse if either value is not a string, and
+// LogicalFalse with a warning if jv[1] fails to compile.
 func searchFunc(jv []spec.PathValue) spec.PathValue {
 	if val, ok := spec.ValueFrom(jv[0]).Value().(string); ok {
 		if r, ok := spec.ValueFrom(jv[1]).Value().(string); ok {
-			if rc := compileRegex(r); rc != nil {
-				return spec.Logical(rc.MatchString(val))
+			rc, err := compileRegex(r)
+			if err != nil {
+				return regexWarning(r, err)
 			}
+			return spec.Logical(rc.MatchString(val))
 		}
 	}
 	return spec.LogicalFalse
 }
 
+// regexWarning returns LogicalFalse with a warning that the regular
+// expression regex failed to compile with err.
+func regexWarning(regex string, err error) spec.PathValue {
+	return spec.WithWarning(
+		spec.LogicalFalse,
+		"jsonpath: invalid regular expression",
+		"regex", regex, "error", err,
+	)
+}
+
 // compileRegex compiles str into a regular expression or returns an error. To
 // comply with RFC 9485 regular expression semantics, all instances of "." are
 // replaced with "[^\n\r]". This sadly requires compiling the regex twice:
 // once to produce an AST to replace "." nodes, and a second time for the
 // final regex.
-func compileRegex(str string) *regexp.Regexp {
+func compileRegex(str string) (*regexp.Regexp, error) {
 	// First compile AST and replace "." with [^\n\r].
 	// https://www.rfc-editor.org/rfc/rfc9485.html#name-pcre-re2-and-ruby-regexps
 	r, err := syntax.Parse(str, syntax.Perl|syntax.DotNL)
 	if err != nil {
-		// Could use some way to log these errors rather than failing silently.
-		return nil
+		//nolint:wrapcheck
+		return nil, err
 	}
 
 	replaceDot(r)
-	re, _ := regexp.Compile(r.String())
-	return re
+	//nolint:wrapcheck
+	return regexp.Compile(r.String())
 }
 
 //nolint:gochecknoglobals
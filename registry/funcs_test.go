@@ -118,6 +118,40 @@ func TestLengthFunc(t *testing.T) {
 			vals: []spec.PathValue{spec.Value(map[string]string{"x": "x", "y": "y"})},
 			exp:  2,
 		},
+		{
+			test: "go_array",
+			vals: []spec.PathValue{spec.Value([4]int{1, 2, 3, 4})},
+			exp:  4,
+		},
+		{
+			test: "struct",
+			vals: []spec.PathValue{spec.Value(struct {
+				X int `json:"x"`
+				Y int `json:"y,omitempty"`
+				Z int `json:"z"`
+			}{X: 1})},
+			exp: 2,
+		},
+		{
+			test: "array_pointer",
+			vals: []spec.PathValue{spec.Value(&[]any{1, 2})},
+			exp:  2,
+		},
+		{
+			test: "object_pointer",
+			vals: []spec.PathValue{spec.Value(&map[string]any{"x": 1})},
+			exp:  1,
+		},
+		{
+			test: "nil_pointer",
+			vals: []spec.PathValue{spec.Value((*[]any)(nil))},
+			exp:  -1,
+		},
+		{
+			test: "any_keyed_object",
+			vals: []spec.PathValue{spec.Value(map[any]any{"x": 1, 2: "y", true: 3})},
+			exp:  3,
+		},
 		{
 			test: "int_keyed_object",
 			vals: []spec.PathValue{spec.Value(map[int]string{1: "x", 2: "c"})},
@@ -469,8 +503,21 @@ func TestRegexFuncs(t *testing.T) {
 			t.Parallel()
 			a := assert.New(t)
 
-			a.Equal(spec.Logical(tc.match), matchFunc([]spec.PathValue{tc.input, tc.regex}))
-			a.Equal(spec.Logical(tc.search), searchFunc([]spec.PathValue{tc.input, tc.regex}))
+			if tc.test != "invalid_regex" {
+				a.Equal(spec.Logical(tc.match), matchFunc([]spec.PathValue{tc.input, tc.regex}))
+				a.Equal(spec.Logical(tc.search), searchFunc([]spec.PathValue{tc.input, tc.regex}))
+				return
+			}
+
+			// Should return false with a warning.
+			regex, _ := spec.ValueFrom(tc.regex).Value().(string)
+			_, err := compileRegex(`\A` + regex + `\z`)
+			a.Equal(regexWarning(regex, err), matchFunc([]spec.PathValue{tc.input, tc.regex}))
+			_, err = compileRegex(regex)
+			a.Equal(regexWarning(regex, err), searchFunc([]spec.PathValue{tc.input, tc.regex}))
+			reg := New()
+			a.Equal(spec.LogicalFalse, reg.Get("match").Evaluate([]spec.PathValue{tc.input, tc.regex}))
+			a.Equal(spec.LogicalFalse, reg.Get("search").Evaluate([]spec.PathValue{tc.input, tc.regex}))
 		})
 	}
 }
@@ -491,6 +538,14 @@ func TestExecRegexFuncs(t *testing.T) {
 			match:  true,
 			search: true,
 		},
+		{
+			test: "first_nothing",
+			vals: []spec.PathValue{spec.Nothing, spec.Value("x")},
+		},
+		{
+			test: "second_nil",
+			vals: []spec.PathValue{spec.Value("x"), nil},
+		},
 		{
 			test: "first_not_value",
 			vals: []spec.PathValue{spec.Nodes(), spec.Value("x")},
@@ -4,18 +4,23 @@ package registry
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
 
 	"github.com/theory/jsonpath/spec"
 )
 
 // Registry maintains a registry of JSONPath function extensions, including
-// both [RFC 9535]-required functions and custom functions.
+// both [RFC 9535]-required functions and custom functions. A Registry is
+// safe for concurrent use by multiple goroutines, so that some may register
+// functions while others parse queries with it.
 //
 // [RFC 9535]: https://www.rfc-editor.org/rfc/rfc9535.html
 type Registry struct {
-	mu    sync.RWMutex
-	funcs map[string]*spec.FuncExtension
+	mu       sync.RWMutex
+	funcs    map[string]*spec.FuncExtension
+	disabled map[string]struct{}
 }
 
 // New returns a new [Registry] loaded with the [RFC 9535]-mandated function
@@ -58,8 +63,8 @@ var ErrRegister = errors.New("register")
 //   - evaluator: The implementation of the function itself that executes
 //     against args and returns the result of the type defined by resultType.
 //
-// Returns [ErrRegister] if validator or evaluator is nil or if r already
-// contains name.
+// Returns [ErrRegister] if validator or evaluator is nil, if r already
+// contains name, or if name has been disabled by [Registry.Disable].
 func (r *Registry) Register(
 	name string,
 	resultType spec.FuncType,
@@ -81,6 +86,9 @@ func (r *Registry) Register(
 			ErrRegister, name,
 		)
 	}
+	if _, off := r.disabled[name]; off {
+		return fmt.Errorf("%w: function %v is disabled", ErrRegister, name)
+	}
 
 	r.funcs[name] = spec.Extension(name, resultType, validator, evaluator)
 	return nil
@@ -95,3 +103,60 @@ func (r *Registry) Get(name string) *spec.FuncExtension {
 	function := r.funcs[name]
 	return function
 }
+
+// Unregister removes the function extension named name from r. Returns
+// false if r contains no such function. Queries already parsed with r
+// continue to call the function. Use with [Registry.Clone] to replace a
+// function in a copy of a registry in use by a parser.
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.funcs[name]
+	delete(r.funcs, name)
+	return ok
+}
+
+// Disable removes the function extensions named by names from r and
+// prevents their registration, so that parsers using r reject queries that
+// call them with a disabled function error rather than an unknown function
+// error. Use it to disable functions that untrusted queries could abuse,
+// such as match() and search(), whose regular expressions may be expensive
+// to compile and evaluate. Queries already parsed with r continue to call
+// the functions.
+func (r *Registry) Disable(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.disabled == nil {
+		r.disabled = make(map[string]struct{}, len(names))
+	}
+	for _, name := range names {
+		delete(r.funcs, name)
+		r.disabled[name] = struct{}{}
+	}
+}
+
+// Disabled returns true if the function extension named name has been
+// disabled by [Registry.Disable].
+func (r *Registry) Disabled(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.disabled[name]
+	return ok
+}
+
+// Clone returns a new [Registry] containing the same function extensions
+// and disabled functions as r. Registering, unregistering, or disabling
+// functions in either registry does not affect the other.
+func (r *Registry) Clone() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return &Registry{funcs: maps.Clone(r.funcs), disabled: maps.Clone(r.disabled)}
+}
+
+// Names returns the sorted names of the function extensions registered with
+// r.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Sorted(maps.Keys(r.funcs))
+}
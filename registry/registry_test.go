@@ -72,6 +72,91 @@ func TestRegistry(t *testing.T) {
 	}
 }
 
+func TestNames(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	reg := New()
+	a.Equal([]string{"count", "length", "match", "search", "value"}, reg.Names())
+
+	r.NoError(reg.Register(
+		"first",
+		spec.FuncValue,
+		func([]spec.FuncExprArg) error { return nil },
+		func([]spec.PathValue) spec.PathValue { return nil },
+	))
+	a.Equal([]string{"count", "first", "length", "match", "search", "value"}, reg.Names())
+}
+
+func TestUnregister(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	reg := New()
+	a.True(reg.Unregister("match"))
+	a.Nil(reg.Get("match"))
+	a.False(reg.Unregister("match"))
+	a.Equal([]string{"count", "length", "search", "value"}, reg.Names())
+}
+
+func TestDisable(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	reg := New()
+	a.False(reg.Disabled("match"))
+	reg.Disable("match", "search", "nonesuch")
+	a.Equal([]string{"count", "length", "value"}, reg.Names())
+	for _, name := range []string{"match", "search", "nonesuch"} {
+		a.True(reg.Disabled(name))
+		a.Nil(reg.Get(name))
+	}
+	a.False(reg.Disabled("length"))
+
+	// Should not register disabled functions.
+	err := reg.Register(
+		"match",
+		spec.FuncLogical,
+		func([]spec.FuncExprArg) error { return nil },
+		func([]spec.PathValue) spec.PathValue { return nil },
+	)
+	r.ErrorIs(err, ErrRegister)
+	r.EqualError(err, "register: function match is disabled")
+	a.Nil(reg.Get("match"))
+
+	// Should clone disabled functions.
+	clone := reg.Clone()
+	a.True(clone.Disabled("match"))
+	clone.Disable("count")
+	a.True(clone.Disabled("count"))
+	a.False(reg.Disabled("count"))
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	reg := New()
+	clone := reg.Clone()
+	a.NotSame(reg, clone)
+	a.Equal(reg.Names(), clone.Names())
+	a.Same(reg.Get("length"), clone.Get("length"))
+
+	// Changes to one should not affect the other.
+	r.NoError(clone.Register(
+		"first",
+		spec.FuncValue,
+		func([]spec.FuncExprArg) error { return nil },
+		func([]spec.PathValue) spec.PathValue { return nil },
+	))
+	a.True(reg.Unregister("count"))
+	a.Equal([]string{"length", "match", "search", "value"}, reg.Names())
+	a.Equal([]string{"count", "first", "length", "match", "search", "value"}, clone.Names())
+}
+
 func TestRegisterErr(t *testing.T) {
 	t.Parallel()
 	reg := New()
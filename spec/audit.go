@@ -0,0 +1,37 @@
+package spec
+
+// Auditor receives the normalized paths of the values selected by each
+// evaluation of a [PathQuery], but not the values themselves, for audit
+// trails of the fields read from sensitive documents. Set one with
+// [Options].Auditor. The select methods of [PathQuery], [CompiledQuery],
+// and [Scratch] call Audit once for each evaluation that completes, with
+// the paths of the values they return, or, for
+// [PathQuery.SelectLocatedSeq], once the loop over its iterator ends, with
+// the paths of the values it yielded. Queries in filter expressions do not
+// call it. Queries may be evaluated concurrently, so implementations must
+// be safe for concurrent use.
+//
+// Queries that select values rather than [LocatedNode] values must compute
+// their normalized paths to report them, so setting an Auditor makes
+// [PathQuery.SelectWith] and [PathQuery.TrySelect] as costly as
+// [PathQuery.SelectLocatedWith] and disables the optimizations of
+// [CompiledQuery].
+type Auditor interface {
+	// Audit is called with the query evaluated and the normalized paths of
+	// the values it selected, in the order selected. Audit may retain
+	// paths.
+	Audit(query *PathQuery, paths []NormalizedPath)
+}
+
+// audit reports the paths of nodes, selected by q, to ev's [Auditor], if
+// any.
+func (ev *evaluation) audit(q *PathQuery, nodes []*LocatedNode) {
+	if ev.opts.Auditor == nil {
+		return
+	}
+	paths := make([]NormalizedPath, len(nodes))
+	for i, n := range nodes {
+		paths[i] = n.Path
+	}
+	ev.opts.Auditor.Audit(q, paths)
+}
@@ -0,0 +1,171 @@
+package spec
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditLog is an [Auditor] that records the paths it receives.
+type auditLog struct {
+	mu    sync.Mutex
+	calls [][]NormalizedPath
+}
+
+func (l *auditLog) Audit(_ *PathQuery, paths []NormalizedPath) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, paths)
+}
+
+func TestAuditor(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"a": []any{
+			map[string]any{"x": 1, "ok": true},
+			map[string]any{"x": 2},
+			map[string]any{"x": 3, "ok": true},
+		},
+	}
+	// $.a[?@.ok].x
+	query := Query(true,
+		Child(Name("a")),
+		Child(Filter(And(Existence(Query(false, Child(Name("ok"))))))),
+		Child(Name("x")),
+	)
+	all := []NormalizedPath{
+		Normalized(Name("a"), Index(0), Name("x")),
+		Normalized(Name("a"), Index(2), Name("x")),
+	}
+	first := all[:1]
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		eval  func(q *PathQuery, opts Options) any
+		exp   any
+		calls [][]NormalizedPath
+	}{
+		{
+			test: "select_with",
+			eval: func(q *PathQuery, opts Options) any { return q.SelectWith(nil, input, opts) },
+			exp:  []any{1, 3},
+		},
+		{
+			test: "try_select",
+			eval: func(q *PathQuery, opts Options) any {
+				res, err := q.TrySelect(nil, input, opts)
+				require.NoError(t, err)
+				return res
+			},
+			exp: []any{1, 3},
+		},
+		{
+			test:  "select_value",
+			eval:  func(q *PathQuery, opts Options) any { return q.SelectValue(nil, input, opts) },
+			exp:   1,
+			calls: [][]NormalizedPath{first},
+		},
+		{
+			test:  "select_value_singular",
+			query: Query(true, Child(Name("a")), Child(Index(1)), Child(Name("x"))),
+			eval:  func(q *PathQuery, opts Options) any { return q.SelectValue(nil, input, opts) },
+			exp:   2,
+			calls: [][]NormalizedPath{{Normalized(Name("a"), Index(1), Name("x"))}},
+		},
+		{
+			test:  "select_value_nothing",
+			query: Query(true, Child(Name("nonesuch"))),
+			eval:  func(q *PathQuery, opts Options) any { return q.SelectValue(nil, input, opts) },
+			exp:   Nothing,
+			calls: [][]NormalizedPath{{}},
+		},
+		{
+			test: "select_located_with",
+			eval: func(q *PathQuery, opts Options) any {
+				return len(q.SelectLocatedWith(nil, input, Normalized(), opts))
+			},
+			exp: 2,
+		},
+		{
+			test:  "select_located_relative",
+			query: Query(false, Child(Index(0)), Child(Name("x"))),
+			eval: func(q *PathQuery, opts Options) any {
+				res, err := q.TrySelectLocated(input["a"], input, Normalized(Name("a")), opts)
+				require.NoError(t, err)
+				return len(res)
+			},
+			exp:   1,
+			calls: [][]NormalizedPath{first},
+		},
+		{
+			test: "select_located_seq",
+			eval: func(q *PathQuery, opts Options) any {
+				n := 0
+				for _, err := range q.SelectLocatedSeq(context.Background(), nil, input, Normalized(), opts) {
+					require.NoError(t, err)
+					n++
+				}
+				return n
+			},
+			exp: 2,
+		},
+		{
+			test: "select_located_seq_break",
+			eval: func(q *PathQuery, opts Options) any {
+				for node := range q.SelectLocatedSeq(context.Background(), nil, input, Normalized(), opts) {
+					return node.Node
+				}
+				return nil
+			},
+			exp:   1,
+			calls: [][]NormalizedPath{first},
+		},
+		{
+			test: "compiled",
+			eval: func(q *PathQuery, opts Options) any { return q.Compile().SelectWith(nil, input, opts) },
+			exp:  []any{1, 3},
+		},
+		{
+			test: "scratch",
+			eval: func(q *PathQuery, opts Options) any {
+				var s Scratch
+				return s.Select(q, nil, input, opts)
+			},
+			exp: []any{1, 3},
+		},
+		{
+			test: "budget_exceeded",
+			eval: func(q *PathQuery, opts Options) any {
+				opts.MaxNodes = 2
+				_, err := q.TrySelect(nil, input, opts)
+				return err != nil
+			},
+			exp:   true,
+			calls: [][]NormalizedPath{},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			q := tc.query
+			if q == nil {
+				q = query
+			}
+			if tc.calls == nil {
+				tc.calls = [][]NormalizedPath{all}
+			}
+
+			log := &auditLog{calls: [][]NormalizedPath{}}
+			a.Equal(tc.exp, tc.eval(q, Options{Auditor: log}))
+			a.Equal(tc.calls, log.calls)
+
+			// Should return the same result without an auditor.
+			a.Equal(tc.exp, tc.eval(q, Options{}))
+		})
+	}
+}
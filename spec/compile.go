@@ -0,0 +1,230 @@
+package spec
+
+// CompiledQuery is a [PathQuery] lowered into a chain of functions, each
+// specialized for one of its segments. Name, index, and wildcard selectors
+// compile to functions that select directly from their inputs, descendant
+// segments apply them in a single traversal, and singular queries
+// compile to a sequence of lookups that allocate nothing until they find a
+// value. This eliminates the interface dispatch, type switches, and
+// intermediate slices of [PathQuery.Select] for each node. Filter
+// expressions evaluate as they do for [PathQuery.Select].
+//
+// A CompiledQuery selects the same values in the same order as its
+// [PathQuery]. Create one with [PathQuery.Compile]. It's safe for
+// concurrent use.
+type CompiledQuery struct {
+	query   *PathQuery
+	steps   []stepFunc
+	lookups []lookupFunc
+}
+
+// stepFunc appends the values selected from node to dst and returns the
+// result.
+type stepFunc func(ev *evaluation, node any, dst []any) []any
+
+// step calls f. Defined by [stepper].
+func (f stepFunc) step(ev *evaluation, node any, dst []any) []any {
+	return f(ev, node, dst)
+}
+
+// stepper appends the values selected from node to dst and returns the
+// result. Implemented by [stepFunc] and by [*Segment], which [descend]
+// accepts without the allocation of a method value.
+type stepper interface {
+	step(ev *evaluation, node any, dst []any) []any
+}
+
+// lookupFunc selects a single value from node. Returns false if node
+// contains no such value.
+type lookupFunc func(node any) (any, bool)
+
+// Compile compiles q into a [CompiledQuery].
+func (q *PathQuery) Compile() *CompiledQuery {
+	cq := &CompiledQuery{query: q}
+	if q.isSingular() {
+		cq.lookups = make([]lookupFunc, len(q.segments))
+		for i, seg := range q.segments {
+			cq.lookups[i] = compileLookup(seg.selectors[0])
+		}
+		return cq
+	}
+
+	cq.steps = make([]stepFunc, len(q.segments))
+	for i, seg := range q.segments {
+		cq.steps[i] = compileSegment(seg)
+	}
+	return cq
+}
+
+// Query returns the [PathQuery] from which cq was compiled.
+func (cq *CompiledQuery) Query() *PathQuery {
+	return cq.query
+}
+
+// String returns a string representation of cq's [PathQuery].
+func (cq *CompiledQuery) String() string {
+	return cq.query.String()
+}
+
+// Select selects the values from current or root and returns the results.
+// Returns the same values as [PathQuery.Select].
+func (cq *CompiledQuery) Select(current, root any) []any {
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	return cq.selectFrom(current, ev)
+}
+
+// SelectWith selects the values from current or root as configured by opts
+// and returns the results. Returns the same values as
+// [PathQuery.SelectWith].
+func (cq *CompiledQuery) SelectWith(current, root any, opts Options) []any {
+	ev := newEvaluation(root, opts)
+	defer ev.dropAbort()
+	if ev.locates() {
+		return cq.query.locateFrom(current, ev)
+	}
+	return cq.selectFrom(current, ev)
+}
+
+// selectFrom selects the values from current or ev.root and returns the
+// results.
+func (cq *CompiledQuery) selectFrom(current any, ev *evaluation) []any {
+	node := current
+	if cq.query.root {
+		node = ev.root
+	}
+
+	if cq.lookups != nil {
+		for _, lookup := range cq.lookups {
+			ev.visit(0)
+			var ok bool
+			if node, ok = lookup(node); !ok {
+				return make([]any, 0)
+			}
+		}
+		return []any{node}
+	}
+
+	res := []any{node}
+	for i, step := range cq.steps {
+		next := make([]any, 0, len(res))
+		// Descendant steps notify ev of their own visits.
+		child := !cq.query.segments[i].descendant
+		for _, v := range res {
+			if child {
+				ev.visit(0)
+			}
+			next = step(ev, v, next)
+		}
+		res = next
+	}
+	return res
+}
+
+// compileSegment compiles seg into a [stepFunc] that applies each of its
+// selectors and, for a descendant segment, recurses into the descendants of
+// its input.
+func compileSegment(seg *Segment) stepFunc {
+	sels := make([]stepFunc, len(seg.selectors))
+	for i, sel := range seg.selectors {
+		sels[i] = compileSelector(sel)
+	}
+
+	var step stepFunc
+	if len(sels) == 1 {
+		step = sels[0]
+	} else {
+		step = func(ev *evaluation, node any, dst []any) []any {
+			for _, sel := range sels {
+				dst = sel(ev, node, dst)
+			}
+			return dst
+		}
+	}
+
+	if seg.names != nil {
+		multi := step
+		step = func(ev *evaluation, node any, dst []any) []any {
+			if dst, ok := seg.names.appendFrom(dst, node); ok {
+				return dst
+			}
+			return multi(ev, node, dst)
+		}
+	}
+
+	if !seg.descendant {
+		return step
+	}
+
+	return func(ev *evaluation, node any, dst []any) []any {
+		return descend(dst, node, ev, step)
+	}
+}
+
+// compileSelector compiles sel into a [stepFunc]. Name, Index, and wildcard
+// selectors compile to specialized functions; all others delegate to sel.
+func compileSelector(sel Selector) stepFunc {
+	switch sel := sel.(type) {
+	case Name, Index:
+		lookup := compileLookup(sel)
+		return func(_ *evaluation, node any, dst []any) []any {
+			if v, ok := lookup(node); ok {
+				dst = append(dst, v)
+			}
+			return dst
+		}
+	case WildcardSelector:
+		return selectAll
+	default:
+		return func(ev *evaluation, node any, dst []any) []any {
+			return appendWith(dst, sel, node, ev)
+		}
+	}
+}
+
+// compileLookup compiles sel, which must be a [Name] or [Index], into a
+// [lookupFunc].
+func compileLookup(sel Selector) lookupFunc {
+	if name, ok := sel.(Name); ok {
+		key := string(name)
+		return func(node any) (any, bool) {
+			if obj, ok := node.(map[string]any); ok {
+				v, ok := obj[key]
+				return v, ok
+			}
+			if obj, ok := AsObject(node); ok {
+				return obj.Get(key)
+			}
+			return nil, false
+		}
+	}
+
+	idx := int(sel.(Index))
+	if idx >= 0 {
+		return func(node any) (any, bool) {
+			if arr, ok := node.([]any); ok {
+				if idx < len(arr) {
+					return arr[idx], true
+				}
+				return nil, false
+			}
+			if arr, ok := AsArray(node); ok {
+				return arr.Get(idx)
+			}
+			return nil, false
+		}
+	}
+
+	return func(node any) (any, bool) {
+		if arr, ok := node.([]any); ok {
+			if i := len(arr) + idx; i >= 0 {
+				return arr[i], true
+			}
+			return nil, false
+		}
+		if arr, ok := AsArray(node); ok {
+			return arr.Get(arr.Len() + idx)
+		}
+		return nil, false
+	}
+}
@@ -0,0 +1,97 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+
+	// Ordered input for exact comparisons.
+	ordered := []any{
+		pairs{{"a", 1}, {"b", []any{"x", "y", pairs{{"a", 2}}}}},
+		squares(4),
+		[]any{pairs{{"a", 3}}, "z"},
+		person{Name: "Ana", Age: 42},
+		nil,
+	}
+	// Map input for unordered comparisons.
+	unordered := map[string]any{
+		"a": 1,
+		"b": []any{map[string]any{"a": 2, "c": []any{3, 4}}, "x"},
+		"c": map[string]any{"a": map[string]any{"a": 5}},
+		"d": []int{6, 7},
+	}
+	gt := Filter(And(Comparison(SingularQuery(false), GreaterThan, Literal(2))))
+
+	for _, tc := range []struct {
+		test string
+		root bool
+		segs []*Segment
+	}{
+		{"root", true, nil},
+		{"current", false, nil},
+		{"name", true, []*Segment{Child(Name("a"))}},
+		{"index", true, []*Segment{Child(Index(1))}},
+		{"neg_index", true, []*Segment{Child(Index(-1))}},
+		{"out_of_bounds", true, []*Segment{Child(Index(10))}},
+		{"neg_out_of_bounds", true, []*Segment{Child(Index(-10))}},
+		{"singular_chain", true, []*Segment{Child(Index(0)), Child(Name("b")), Child(Index(-1)), Child(Name("a"))}},
+		{"singular_miss", true, []*Segment{Child(Index(0)), Child(Name("nope")), Child(Index(0))}},
+		{"wildcard", true, []*Segment{Child(Wildcard())}},
+		{"wildcard_twice", true, []*Segment{Child(Wildcard()), Child(Wildcard())}},
+		{"multi", true, []*Segment{Child(Wildcard()), Child(Name("a"), Index(0), Name("b"))}},
+		{"slice", true, []*Segment{Child(Wildcard()), Child(Slice(1, nil, 2))}},
+		{"no_selectors", true, []*Segment{Child()}},
+		{"descendant_name", true, []*Segment{Descendant(Name("a"))}},
+		{"descendant_index", true, []*Segment{Descendant(Index(0))}},
+		{"descendant_wildcard", true, []*Segment{Descendant(Wildcard())}},
+		{"descendant_multi", true, []*Segment{Descendant(Name("a"), Index(1))}},
+		{"descendant_filter", true, []*Segment{Descendant(gt)}},
+		{"filter", true, []*Segment{Child(Wildcard()), Child(gt)}},
+		{"filter_root", true, []*Segment{Descendant(Filter(And(Comparison(
+			SingularQuery(false), EqualTo, SingularQuery(true, Index(0), Name("a")),
+		))))}},
+		{"struct", true, []*Segment{Child(Index(3)), Child(Name("name"))}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			q := Query(tc.root, tc.segs...)
+			cq := q.Compile()
+			a.Same(q, cq.Query())
+			a.Equal(q.String(), cq.String())
+
+			a.Equal(q.Select(ordered, ordered), cq.Select(ordered, ordered))
+			a.ElementsMatch(q.Select(unordered, unordered), cq.Select(unordered, unordered))
+			a.Equal(
+				q.SelectWith(ordered, ordered, Options{BytesAsStrings: true}),
+				cq.SelectWith(ordered, ordered, Options{BytesAsStrings: true}),
+			)
+		})
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	input := make([]any, 1000)
+	for i := range input {
+		input[i] = map[string]any{"id": i, "tags": []any{"a", "b", map[string]any{"x": i}}}
+	}
+	q := Query(true, Descendant(Name("x")))
+
+	b.Run("interpreted", func(b *testing.B) {
+		for range b.N {
+			q.Select(nil, input)
+		}
+	})
+	b.Run("compiled", func(b *testing.B) {
+		cq := q.Compile()
+		b.ResetTimer()
+		for range b.N {
+			cq.Select(nil, input)
+		}
+	})
+}
@@ -0,0 +1,655 @@
+package spec
+
+import (
+	"encoding"
+	"encoding/json"
+	"iter"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Object defines the interface for JSON object values other than
+// map[string]any. Implement it to query ordered maps, lazily-loaded data,
+// and other custom types. Selectors and function extensions also use
+// internal implementations of Object for string-keyed maps and structs.
+type Object interface {
+	// Get returns the value of the member named name and true, or false if
+	// no such member exists.
+	Get(name string) (any, bool)
+
+	// Len returns the number of members.
+	Len() int
+
+	// Iterate returns an iterator over the names and values of the members.
+	// The order of iteration determines the order in which wildcard and
+	// descendant segments select members.
+	Iterate() iter.Seq2[string, any]
+}
+
+// Array defines the interface for JSON array values other than []any.
+// Implement it to query lazily-loaded sequences and other custom types.
+// Selectors and function extensions also use an internal implementation of
+// Array for slices and arrays.
+type Array interface {
+	// Get returns the value at index and true, or false if index is out of
+	// range.
+	Get(index int) (any, bool)
+
+	// Len returns the number of elements.
+	Len() int
+
+	// Iterate returns an iterator over the indexes and values of the
+	// elements.
+	Iterate() iter.Seq2[int, any]
+}
+
+// leafContainer is implemented by containers that know whether their values
+// may be containers, allowing descendant segments to skip them.
+type leafContainer interface {
+	// leavesOnly returns true if none of the container's values can be an
+	// object or array.
+	leavesOnly() bool
+}
+
+// AsObject returns val as an [Object] if it implements Object or is a
+// string-keyed map, a map with interface keys such as map[any]any, or a
+// struct. Dereferences pointers to any of these types, returning false for
+// nil pointers. Structs that implement [json.Marshaler] or
+// [encoding.TextMarshaler] are considered scalars, not objects, as are maps
+// and structs that implement [Array]. Returns false for any other type.
+// Selectors use AsObject only as a fallback for values that are not
+// map[string]any.
+func AsObject(val any) (Object, bool) {
+	switch val := val.(type) {
+	case Object:
+		return val, true
+	case Array:
+		return nil, false
+	case map[string]any:
+		return anyMap(val), true
+	}
+
+	value := reflect.ValueOf(val)
+	switch value.Kind() {
+	case reflect.Map:
+		switch value.Type().Key().Kind() {
+		case reflect.String:
+			return mapObject{value}, true
+		case reflect.Interface:
+			return anyKeyObject{value}, true
+		default:
+			return nil, false
+		}
+	case reflect.Pointer:
+		if value.IsNil() || isMarshaler(value.Type()) {
+			return nil, false
+		}
+		if elem := value.Elem(); elem.Kind() == reflect.Struct {
+			// Avoid copying the struct.
+			return newStructObject(elem), true
+		}
+		return AsObject(value.Elem().Interface())
+	case reflect.Struct:
+		if isMarshaler(value.Type()) {
+			return nil, false
+		}
+		return newStructObject(value), true
+	}
+	return nil, false
+}
+
+// AsArray returns val as an [Array] if it implements Array or is a slice or
+// array that does not implement [Object]. Dereferences pointers to any of
+// these types, returning false for nil pointers. Slices and arrays that
+// implement [json.Marshaler] or [encoding.TextMarshaler] are considered
+// scalars, not arrays. Returns false for any other type. Selectors use
+// AsArray only as a fallback for values that are not []any.
+func AsArray(val any) (Array, bool) {
+	switch val := val.(type) {
+	case Array:
+		return val, true
+	case Object:
+		return nil, false
+	case []any:
+		return anySlice(val), true
+	}
+
+	value := reflect.ValueOf(val)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		if isMarshaler(value.Type()) {
+			return nil, false
+		}
+		return sliceArray{value}, true
+	case reflect.Pointer:
+		if value.IsNil() || isMarshaler(value.Type()) {
+			return nil, false
+		}
+		return AsArray(value.Elem().Interface())
+	default:
+		return nil, false
+	}
+}
+
+// leavesOnly returns true if c implements [leafContainer] and its leavesOnly
+// method returns true.
+func leavesOnly(c any) bool {
+	lc, ok := c.(leafContainer)
+	return ok && lc.leavesOnly()
+}
+
+// anyMap implements [Object] for map[string]any.
+type anyMap map[string]any
+
+// Get returns the value for key name.
+func (m anyMap) Get(name string) (any, bool) {
+	v, ok := m[name]
+	return v, ok
+}
+
+// Len returns the number of keys in m.
+func (m anyMap) Len() int { return len(m) }
+
+// Iterate returns an iterator over the keys and values of m.
+func (m anyMap) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for k, v := range m {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// anySlice implements [Array] for []any.
+type anySlice []any
+
+// Get returns the value at index.
+func (s anySlice) Get(index int) (any, bool) {
+	if index < 0 || index >= len(s) {
+		return nil, false
+	}
+	return s[index], true
+}
+
+// Len returns the number of elements in s.
+func (s anySlice) Len() int { return len(s) }
+
+// Iterate returns an iterator over the indexes and values of s.
+func (s anySlice) Iterate() iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for i, v := range s {
+			if !yield(i, v) {
+				return
+			}
+		}
+	}
+}
+
+// sortedObject implements [Object] for the members of another object sorted
+// by name, so that they iterate in a reproducible order. Created by
+// evaluations with [Options].SortMembers.
+type sortedObject []sortedMember
+
+// sortedMember is a member of a [sortedObject].
+type sortedMember struct {
+	name string
+	val  any
+}
+
+// newSortedObject returns the members of obj sorted by name. Members with
+// the same name remain in the order obj iterates over them.
+func newSortedObject(obj Object) sortedObject {
+	members := make(sortedObject, 0, obj.Len())
+	for name, val := range obj.Iterate() {
+		members = append(members, sortedMember{name, val})
+	}
+	slices.SortStableFunc(members, func(a, b sortedMember) int {
+		return strings.Compare(a.name, b.name)
+	})
+	return members
+}
+
+// Get returns the value of the first member named name.
+func (s sortedObject) Get(name string) (any, bool) {
+	i, ok := slices.BinarySearchFunc(s, name, func(m sortedMember, name string) int {
+		return strings.Compare(m.name, name)
+	})
+	if !ok {
+		return nil, false
+	}
+	return s[i].val, true
+}
+
+// Len returns the number of members in s.
+func (s sortedObject) Len() int { return len(s) }
+
+// Iterate returns an iterator over the names and values of the members of
+// s, in ascending order of their names.
+func (s sortedObject) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, m := range s {
+			if !yield(m.name, m.val) {
+				return
+			}
+		}
+	}
+}
+
+// mapObject uses reflection to implement [Object] for string-keyed maps.
+type mapObject struct {
+	reflect.Value
+}
+
+// Get returns the value for key name.
+func (m mapObject) Get(name string) (any, bool) {
+	key := reflect.ValueOf(name).Convert(m.Type().Key())
+	if v := m.MapIndex(key); v.IsValid() {
+		return v.Interface(), true
+	}
+	return nil, false
+}
+
+// Iterate returns an iterator over the keys and values of m.
+func (m mapObject) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		iter := m.MapRange()
+		for iter.Next() {
+			if !yield(iter.Key().String(), iter.Value().Interface()) {
+				return
+			}
+		}
+	}
+}
+
+// leavesOnly returns true if m's values cannot be containers.
+func (m mapObject) leavesOnly() bool {
+	return !mayContain(m.Type().Elem())
+}
+
+// anyKeyObject uses reflection to implement [Object] for maps with interface
+// keys, such as the map[any]any values produced by YAML decoders. It
+// converts string, boolean, and numeric keys to member names and ignores
+// keys of any other type.
+type anyKeyObject struct {
+	reflect.Value
+}
+
+// Get returns the value for the key named name. It prefers a string key
+// equal to name, but otherwise returns the value of the first key it finds
+// that converts to name.
+func (m anyKeyObject) Get(name string) (any, bool) {
+	if key := reflect.ValueOf(name); key.Type().AssignableTo(m.Type().Key()) {
+		if v := m.MapIndex(key); v.IsValid() {
+			return v.Interface(), true
+		}
+	}
+	for k, v := range m.Iterate() {
+		if k == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Len returns the number of keys in m that convert to member names.
+func (m anyKeyObject) Len() int {
+	n := 0
+	for range m.Iterate() {
+		n++
+	}
+	return n
+}
+
+// Iterate returns an iterator over the keys of m converted to member names
+// and their values.
+func (m anyKeyObject) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		iter := m.MapRange()
+		for iter.Next() {
+			if name, ok := keyName(iter.Key()); ok {
+				if !yield(name, iter.Value().Interface()) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// leavesOnly returns true if m's values cannot be containers.
+func (m anyKeyObject) leavesOnly() bool {
+	return !mayContain(m.Type().Elem())
+}
+
+// keyName converts the map key k to a member name. Returns false if k is not
+// a string, boolean, or number.
+func keyName(k reflect.Value) (string, bool) {
+	if k.Kind() == reflect.Interface {
+		k = k.Elem()
+	}
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(k.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(k.Float(), 'g', -1, k.Type().Bits()), true
+	default:
+		return "", false
+	}
+}
+
+// sliceArray uses reflection to implement [Array] for slices and arrays.
+type sliceArray struct {
+	reflect.Value
+}
+
+// Get returns the value at index.
+func (s sliceArray) Get(index int) (any, bool) {
+	if index < 0 || index >= s.Len() {
+		return nil, false
+	}
+	return s.Index(index).Interface(), true
+}
+
+// Iterate returns an iterator over the indexes and values of s.
+func (s sliceArray) Iterate() iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for i := range s.Len() {
+			if !yield(i, s.Index(i).Interface()) {
+				return
+			}
+		}
+	}
+}
+
+// leavesOnly returns true if s's values cannot be containers.
+func (s sliceArray) leavesOnly() bool {
+	return !mayContain(s.Type().Elem())
+}
+
+// mayContain returns true if values of type t may be objects or arrays.
+func mayContain(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Interface,
+		reflect.Pointer, reflect.Struct:
+		return true
+	default:
+		return false
+	}
+}
+
+//nolint:gochecknoglobals
+var (
+	jsonMarshaler = reflect.TypeFor[json.Marshaler]()
+	textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// isMarshaler returns true if t or a pointer to t implements
+// [json.Marshaler] or [encoding.TextMarshaler].
+func isMarshaler(t reflect.Type) bool {
+	if t.Kind() != reflect.Pointer {
+		t = reflect.PointerTo(t)
+	}
+	return t.Implements(jsonMarshaler) || t.Implements(textMarshaler)
+}
+
+// structObject uses reflection to implement [Object] for structs, using
+// the same rules as [encoding/json] to map exported fields to member names.
+type structObject struct {
+	value  reflect.Value
+	fields *structFields
+}
+
+// newStructObject creates a structObject for value, which must be a struct.
+func newStructObject(value reflect.Value) structObject {
+	return structObject{value: value, fields: fieldsOf(value.Type())}
+}
+
+// Get returns the value of the field that encodes to JSON as name.
+func (s structObject) Get(name string) (any, bool) {
+	if i, ok := s.fields.byName[name]; ok {
+		return s.fields.list[i].value(s.value)
+	}
+	return nil, false
+}
+
+// Len returns the number of fields that encode to JSON.
+func (s structObject) Len() int {
+	n := 0
+	for range s.Iterate() {
+		n++
+	}
+	return n
+}
+
+// Iterate returns an iterator over the JSON names and values of the
+// fields in s, in the order in which they're declared.
+func (s structObject) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, f := range s.fields.list {
+			if v, ok := f.value(s.value); ok {
+				if !yield(f.name, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// leavesOnly returns true if none of s's fields can be containers.
+func (s structObject) leavesOnly() bool {
+	return s.fields.leavesOnly
+}
+
+// structField describes a struct field that encodes to JSON.
+type structField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	omitZero  bool
+	typ       reflect.Type
+}
+
+// value returns the value of f in the struct v. Returns false if f is
+// embedded in a nil struct pointer, or if f is empty and tagged with
+// omitempty or omitzero.
+func (f *structField) value(v reflect.Value) (any, bool) {
+	for _, i := range f.index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+
+	if (f.omitEmpty && isEmptyValue(v)) || (f.omitZero && v.IsZero()) || !v.CanInterface() {
+		return nil, false
+	}
+
+	return v.Interface(), true
+}
+
+// isEmptyValue returns true for values that [encoding/json] considers empty
+// for the purposes of the omitempty option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Interface, reflect.Pointer,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.IsZero()
+	default:
+		return false
+	}
+}
+
+// structFields describes the fields of a struct type that encode to JSON.
+type structFields struct {
+	list       []structField
+	byName     map[string]int
+	leavesOnly bool
+}
+
+//nolint:gochecknoglobals
+var fieldCache sync.Map // map[reflect.Type]*structFields
+
+// fieldsOf returns the JSON fields for struct type t, loading them from a
+// cache when possible.
+func fieldsOf(t reflect.Type) *structFields {
+	if f, ok := fieldCache.Load(t); ok {
+		//nolint:forcetypeassert
+		return f.(*structFields)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	//nolint:forcetypeassert
+	return f.(*structFields)
+}
+
+// typeFields returns the fields of struct type t that [encoding/json]
+// encodes, following its rules for names, tags, and embedded structs: it
+// walks embedded structs breadth-first and, for fields with the same name,
+// keeps the shallowest one, preferring a tagged field if there are several
+// at the same depth, and dropping them all if there are several tagged or
+// untagged fields at that depth.
+//
+//nolint:gocognit,gocyclo
+func typeFields(t reflect.Type) *structFields {
+	type queued struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	var (
+		fields  []structField
+		next    = []queued{{typ: t}}
+		visited = map[reflect.Type]bool{}
+		// have records the names of fields added or dropped as ambiguous
+		// at a shallower level, which hide deeper fields of the same name.
+		have = map[string]bool{}
+	)
+
+	for len(next) > 0 {
+		current := next
+		next = nil
+		count := map[string]int{}
+		var level []structField
+
+		for _, q := range current {
+			if visited[q.typ] {
+				continue
+			}
+
+			for i := range q.typ.NumField() {
+				sf := q.typ.Field(i)
+				ft := sf.Type
+				if sf.Anonymous {
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(slices.Clone(q.index), i)
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					// Walk embedded struct at the next level.
+					next = append(next, queued{typ: ft, index: index})
+					continue
+				}
+
+				f := structField{
+					name:   name,
+					index:  index,
+					tagged: name != "",
+					typ:    sf.Type,
+				}
+				if f.name == "" {
+					f.name = sf.Name
+				}
+				for _, opt := range strings.Split(opts, ",") {
+					switch opt {
+					case "omitempty":
+						f.omitEmpty = true
+					case "omitzero":
+						f.omitZero = true
+					}
+				}
+				count[f.name]++
+				level = append(level, f)
+			}
+		}
+
+		for _, q := range current {
+			visited[q.typ] = true
+		}
+
+		// Add fields from this level that are not hidden by a shallower
+		// field and that are not ambiguous at this level.
+		for _, f := range level {
+			if have[f.name] {
+				continue
+			}
+			if count[f.name] > 1 {
+				dominant, ok := dominantField(f.name, level)
+				if !ok || !slices.Equal(dominant.index, f.index) {
+					continue
+				}
+			}
+			fields = append(fields, f)
+		}
+		for _, f := range level {
+			have[f.name] = true
+		}
+	}
+
+	// Sort by field index to preserve declaration order.
+	slices.SortFunc(fields, func(a, b structField) int {
+		return slices.Compare(a.index, b.index)
+	})
+
+	sf := &structFields{list: fields, byName: make(map[string]int, len(fields)), leavesOnly: true}
+	for i, f := range fields {
+		sf.byName[f.name] = i
+		if mayContain(f.typ) {
+			sf.leavesOnly = false
+		}
+	}
+	return sf
+}
+
+// dominantField returns the sole tagged field named name in fields. Returns
+// false if there are no tagged fields named name or more than one.
+func dominantField(name string, fields []structField) (structField, bool) {
+	var found structField
+	n := 0
+	for _, f := range fields {
+		if f.name == name && f.tagged {
+			found = f
+			n++
+		}
+	}
+	return found, n == 1
+}
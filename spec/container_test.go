@@ -0,0 +1,682 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	Street string `json:"street"`
+	City   string `json:"city,omitempty"`
+}
+
+type Timestamps struct {
+	Created string `json:"created"`
+	Updated string `json:"updated,omitempty"`
+}
+
+type person struct {
+	Timestamps
+	Name     string            `json:"name"`
+	Age      int               `json:"age,omitempty"`
+	Nick     string            `json:",omitempty"`
+	Secret   string            `json:"-"`
+	Dash     string            `json:"-,"`
+	Home     *address          `json:"home,omitempty"`
+	Work     address           `json:"work,omitzero"`
+	Tags     []string          `json:"tags"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Born     time.Time         `json:"born"`
+	private  string
+	Untagged bool
+}
+
+type label string
+
+func TestStructObject(t *testing.T) {
+	t.Parallel()
+	born := time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+	p := person{
+		Timestamps: Timestamps{Created: "yesterday"},
+		Name:       "Ana",
+		Secret:     "shh",
+		Dash:       "dash",
+		Home:       &address{Street: "Main St"},
+		Tags:       []string{"a", "b"},
+		Born:       born,
+		private:    "hidden",
+		Untagged:   true,
+	}
+
+	for _, tc := range []struct {
+		test string
+		sel  Selector
+		src  any
+		exp  []any
+		loc  []*LocatedNode
+	}{
+		{
+			test: "tagged_name",
+			sel:  Name("name"),
+			src:  p,
+			exp:  []any{"Ana"},
+			loc:  []*LocatedNode{{Path: Normalized(Name("name")), Node: "Ana"}},
+		},
+		{
+			test: "pointer",
+			sel:  Name("name"),
+			src:  &p,
+			exp:  []any{"Ana"},
+			loc:  []*LocatedNode{{Path: Normalized(Name("name")), Node: "Ana"}},
+		},
+		{
+			test: "field_name",
+			sel:  Name("Untagged"),
+			src:  p,
+			exp:  []any{true},
+			loc:  []*LocatedNode{{Path: Normalized(Name("Untagged")), Node: true}},
+		},
+		{
+			test: "not_field_name_when_tagged",
+			sel:  Name("Name"),
+			src:  p,
+			exp:  []any{},
+			loc:  []*LocatedNode{},
+		},
+		{
+			test: "omitempty",
+			sel:  Name("age"),
+			src:  p,
+			exp:  []any{},
+			loc:  []*LocatedNode{},
+		},
+		{
+			test: "omitempty_name_from_field",
+			sel:  Name("Nick"),
+			src:  p,
+			exp:  []any{},
+			loc:  []*LocatedNode{},
+		},
+		{
+			test: "omitzero",
+			sel:  Name("work"),
+			src:  p,
+			exp:  []any{},
+			loc:  []*LocatedNode{},
+		},
+		{
+			test: "ignored",
+			sel:  Name("Secret"),
+			src:  p,
+			exp:  []any{},
+			loc:  []*LocatedNode{},
+		},
+		{
+			test: "dash_name",
+			sel:  Name("-"),
+			src:  p,
+			exp:  []any{"dash"},
+			loc:  []*LocatedNode{{Path: Normalized(Name("-")), Node: "dash"}},
+		},
+		{
+			test: "private",
+			sel:  Name("private"),
+			src:  p,
+			exp:  []any{},
+			loc:  []*LocatedNode{},
+		},
+		{
+			test: "embedded",
+			sel:  Name("created"),
+			src:  p,
+			exp:  []any{"yesterday"},
+			loc:  []*LocatedNode{{Path: Normalized(Name("created")), Node: "yesterday"}},
+		},
+		{
+			test: "wildcard",
+			sel:  Wildcard(),
+			src:  p.Home,
+			exp:  []any{"Main St"},
+			loc:  []*LocatedNode{{Path: Normalized(Name("street")), Node: "Main St"}},
+		},
+		{
+			test: "wildcard_order",
+			sel:  Wildcard(),
+			src:  p,
+			exp:  []any{"yesterday", "Ana", "dash", p.Home, p.Tags, born, true},
+			loc: []*LocatedNode{
+				{Path: Normalized(Name("created")), Node: "yesterday"},
+				{Path: Normalized(Name("name")), Node: "Ana"},
+				{Path: Normalized(Name("-")), Node: "dash"},
+				{Path: Normalized(Name("home")), Node: p.Home},
+				{Path: Normalized(Name("tags")), Node: p.Tags},
+				{Path: Normalized(Name("born")), Node: born},
+				{Path: Normalized(Name("Untagged")), Node: true},
+			},
+		},
+		{
+			test: "marshaler_is_not_object",
+			sel:  Wildcard(),
+			src:  born,
+			exp:  []any{},
+			loc:  []*LocatedNode{},
+		},
+		{
+			test: "nil_pointer",
+			sel:  Wildcard(),
+			src:  (*person)(nil),
+			exp:  []any{},
+			loc:  []*LocatedNode{},
+		},
+		{
+			test: "named_key_map",
+			sel:  Name("x"),
+			src:  map[label]int{"x": 1},
+			exp:  []any{1},
+			loc:  []*LocatedNode{{Path: Normalized(Name("x")), Node: 1}},
+		},
+		{
+			test: "go_array",
+			sel:  Index(-1),
+			src:  [3]int{1, 2, 3},
+			exp:  []any{3},
+			loc:  []*LocatedNode{{Path: Normalized(Index(2)), Node: 3}},
+		},
+		{
+			test: "go_array_slice",
+			sel:  Slice(nil, nil, -2),
+			src:  [3]int{1, 2, 3},
+			exp:  []any{3, 1},
+			loc: []*LocatedNode{
+				{Path: Normalized(Index(2)), Node: 3},
+				{Path: Normalized(Index(0)), Node: 1},
+			},
+		},
+		{
+			test: "filter_structs",
+			sel: Filter(And(Comparison(
+				SingularQuery(false, Name("street")), EqualTo, Literal("Elm St"),
+			))),
+			src: []address{{Street: "Main St"}, {Street: "Elm St"}},
+			exp: []any{address{Street: "Elm St"}},
+			loc: []*LocatedNode{{Path: Normalized(Index(1)), Node: address{Street: "Elm St"}}},
+		},
+		{
+			test: "filter_struct_fields",
+			sel:  Filter(And(Existence(Query(false, Child(Index(1)))))),
+			src:  p,
+			exp:  []any{p.Tags},
+			loc:  []*LocatedNode{{Path: Normalized(Name("tags")), Node: p.Tags}},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			a.Equal(tc.exp, tc.sel.Select(tc.src, nil))
+			a.Equal(tc.loc, tc.sel.SelectLocated(tc.src, nil, NormalizedPath{}))
+		})
+	}
+}
+
+func TestStructDescendant(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	type node struct {
+		Name     string  `json:"name"`
+		Children []*node `json:"children,omitempty"`
+	}
+	tree := &node{Name: "root", Children: []*node{
+		{Name: "a", Children: []*node{{Name: "a1"}}},
+		{Name: "b"},
+	}}
+
+	seg := Descendant(Name("name"))
+	a.Equal([]any{"root", "a", "a1", "b"}, seg.Select(tree, nil))
+	a.Equal([]*LocatedNode{
+		{Path: Normalized(Name("name")), Node: "root"},
+		{Path: Normalized(Name("children"), Index(0), Name("name")), Node: "a"},
+		{Path: Normalized(Name("children"), Index(0), Name("children"), Index(0), Name("name")), Node: "a1"},
+		{Path: Normalized(Name("children"), Index(1), Name("name")), Node: "b"},
+	}, seg.SelectLocated(tree, nil, NormalizedPath{}))
+
+	// Structs with only scalar fields are leaves.
+	a.Equal(
+		[]any{address{Street: "x"}, "x"},
+		Descendant(Wildcard()).Select([]address{{Street: "x"}}, nil),
+	)
+	a.True(leavesOnly(newStructObject(reflect.ValueOf(address{}))))
+	a.False(leavesOnly(newStructObject(reflect.ValueOf(node{}))))
+}
+
+func TestTypeFields(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		A string
+		B string `json:"b"`
+	}
+	type Other struct {
+		A string
+		C int
+	}
+	type tagged struct {
+		A string `json:"a"`
+	}
+	type Deep struct {
+		Inner
+	}
+	type X struct{ X int }
+	type Y struct{ X int }
+	type Z struct{ X int }
+	type DeepX struct{ Z }
+
+	for _, tc := range []struct {
+		test string
+		val  any
+		exp  []string
+	}{
+		{
+			test: "shallow_wins",
+			val: struct {
+				Inner
+				A string
+			}{},
+			exp: []string{"b", "A"},
+		},
+		{
+			test: "ambiguous_dropped",
+			val: struct {
+				Inner
+				Other
+			}{},
+			exp: []string{"b", "C"},
+		},
+		{
+			test: "tagged_dominates",
+			val: struct {
+				Inner
+				X string `json:"A"`
+			}{},
+			exp: []string{"b", "A"},
+		},
+		{
+			test: "tagged_embedded",
+			val: struct {
+				Inner `json:"inner"`
+			}{},
+			exp: []string{"inner"},
+		},
+		{
+			test: "embedded_pointer",
+			val: struct {
+				*tagged
+			}{},
+			exp: []string{"a"},
+		},
+		{
+			test: "deep",
+			val: struct {
+				Deep
+				B int
+			}{},
+			exp: []string{"A", "b", "B"},
+		},
+		{
+			test: "ambiguous_hides_deeper",
+			val: struct {
+				X
+				Y
+				DeepX
+			}{},
+			exp: []string{},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			fields := fieldsOf(reflect.TypeOf(tc.val))
+			names := make([]string, 0, len(fields.list))
+			for _, f := range fields.list {
+				names = append(names, f.name)
+			}
+			assert.Equal(t, tc.exp, names)
+		})
+	}
+}
+
+func TestStructObjectNilEmbedded(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	type Inner struct {
+		A string `json:"a"`
+	}
+	val := struct {
+		*Inner
+		B string `json:"b"`
+	}{B: "hi"}
+
+	obj, ok := AsObject(val)
+	a.True(ok)
+	a.Equal(1, obj.Len())
+	_, ok = obj.Get("a")
+	a.False(ok)
+	v, ok := obj.Get("b")
+	a.True(ok)
+	a.Equal("hi", v)
+
+	val.Inner = &Inner{A: "yo"}
+	obj, _ = AsObject(val)
+	a.Equal(2, obj.Len())
+	v, _ = obj.Get("a")
+	a.Equal("yo", v)
+}
+
+// pairs implements Object with members in declaration order.
+type pairs []struct {
+	name string
+	val  any
+}
+
+func (p pairs) Get(name string) (any, bool) {
+	for _, kv := range p {
+		if kv.name == name {
+			return kv.val, true
+		}
+	}
+	return nil, false
+}
+
+func (p pairs) Len() int { return len(p) }
+
+func (p pairs) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, kv := range p {
+			if !yield(kv.name, kv.val) {
+				return
+			}
+		}
+	}
+}
+
+// squares implements Array by computing its elements on demand.
+type squares int
+
+func (s squares) Get(index int) (any, bool) {
+	if index < 0 || index >= int(s) {
+		return nil, false
+	}
+	return index * index, true
+}
+
+func (s squares) Len() int { return int(s) }
+
+func (s squares) Iterate() iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for i := range int(s) {
+			if !yield(i, i*i) {
+				return
+			}
+		}
+	}
+}
+
+func TestCustomContainers(t *testing.T) {
+	t.Parallel()
+
+	obj := pairs{{"b", 1}, {"a", squares(3)}, {"c", pairs{{"d", true}}}}
+
+	for _, tc := range []struct {
+		test string
+		seg  *Segment
+		src  any
+		exp  []*LocatedNode
+	}{
+		{
+			test: "object_name",
+			seg:  Child(Name("b")),
+			src:  obj,
+			exp:  []*LocatedNode{{Path: Normalized(Name("b")), Node: 1}},
+		},
+		{
+			test: "object_missing",
+			seg:  Child(Name("x")),
+			src:  obj,
+			exp:  []*LocatedNode{},
+		},
+		{
+			test: "object_wildcard",
+			seg:  Child(Wildcard()),
+			src:  obj,
+			exp: []*LocatedNode{
+				{Path: Normalized(Name("b")), Node: 1},
+				{Path: Normalized(Name("a")), Node: squares(3)},
+				{Path: Normalized(Name("c")), Node: pairs{{"d", true}}},
+			},
+		},
+		{
+			test: "array_index",
+			seg:  Child(Index(-1)),
+			src:  squares(5),
+			exp:  []*LocatedNode{{Path: Normalized(Index(4)), Node: 16}},
+		},
+		{
+			test: "array_slice",
+			seg:  Child(Slice(1, nil, 2)),
+			src:  squares(5),
+			exp: []*LocatedNode{
+				{Path: Normalized(Index(1)), Node: 1},
+				{Path: Normalized(Index(3)), Node: 9},
+			},
+		},
+		{
+			test: "array_filter",
+			seg: Child(Filter(And(Comparison(
+				SingularQuery(false), GreaterThan, Literal(2),
+			)))),
+			src: squares(3),
+			exp: []*LocatedNode{{Path: Normalized(Index(2)), Node: 4}},
+		},
+		{
+			test: "descendant",
+			seg:  Descendant(Wildcard()),
+			src:  obj,
+			exp: []*LocatedNode{
+				{Path: Normalized(Name("b")), Node: 1},
+				{Path: Normalized(Name("a")), Node: squares(3)},
+				{Path: Normalized(Name("c")), Node: pairs{{"d", true}}},
+				{Path: Normalized(Name("a"), Index(0)), Node: 0},
+				{Path: Normalized(Name("a"), Index(1)), Node: 1},
+				{Path: Normalized(Name("a"), Index(2)), Node: 4},
+				{Path: Normalized(Name("c"), Name("d")), Node: true},
+			},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			exp := make([]any, len(tc.exp))
+			for i, n := range tc.exp {
+				exp[i] = n.Node
+			}
+			a.Equal(exp, tc.seg.Select(tc.src, nil))
+			a.Equal(tc.exp, tc.seg.SelectLocated(tc.src, nil, NormalizedPath{}))
+		})
+	}
+}
+
+func TestAsContainer(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Custom implementations take precedence over reflection.
+	obj, ok := AsObject(pairs{{"x", 1}})
+	a.True(ok)
+	a.Equal(pairs{{"x", 1}}, obj)
+	arr, ok := AsArray(squares(2))
+	a.True(ok)
+	a.Equal(squares(2), arr)
+
+	// Slices that implement Object are not arrays.
+	_, ok = AsArray(pairs{})
+	a.False(ok)
+	_, ok = AsObject(squares(1))
+	a.False(ok)
+	_, ok = AsArray(map[string]any{})
+	a.False(ok)
+	_, ok = AsArray(json.RawMessage(`[1]`))
+	a.False(ok)
+	_, ok = AsObject(42)
+	a.False(ok)
+}
+
+func TestAnyKeyObject(t *testing.T) {
+	t.Parallel()
+
+	// As decoded from YAML.
+	doc := map[any]any{
+		"name":  "app",
+		"ports": []any{80, 443},
+		"env": map[any]any{
+			"debug": false,
+			1:       "one",
+			"1.5":   "string",
+			1.5:     "float",
+			true:    "yes",
+			uint(7): "seven",
+		},
+		[2]int{1, 2}: "ignored",
+	}
+
+	for _, tc := range []struct {
+		test string
+		segs []*Segment
+		exp  []*LocatedNode
+	}{
+		{
+			test: "name",
+			segs: []*Segment{Child(Name("name"))},
+			exp:  []*LocatedNode{{Path: Normalized(Name("name")), Node: "app"}},
+		},
+		{
+			test: "nested",
+			segs: []*Segment{Child(Name("ports")), Child(Index(1))},
+			exp:  []*LocatedNode{{Path: Normalized(Name("ports"), Index(1)), Node: 443}},
+		},
+		{
+			test: "int_key",
+			segs: []*Segment{Child(Name("env")), Child(Name("1"))},
+			exp:  []*LocatedNode{{Path: Normalized(Name("env"), Name("1")), Node: "one"}},
+		},
+		{
+			test: "string_key_preferred",
+			segs: []*Segment{Child(Name("env")), Child(Name("1.5"))},
+			exp:  []*LocatedNode{{Path: Normalized(Name("env"), Name("1.5")), Node: "string"}},
+		},
+		{
+			test: "bool_key",
+			segs: []*Segment{Child(Name("env")), Child(Name("true"))},
+			exp:  []*LocatedNode{{Path: Normalized(Name("env"), Name("true")), Node: "yes"}},
+		},
+		{
+			test: "uint_key",
+			segs: []*Segment{Child(Name("env")), Child(Name("7"))},
+			exp:  []*LocatedNode{{Path: Normalized(Name("env"), Name("7")), Node: "seven"}},
+		},
+		{
+			test: "filter",
+			segs: []*Segment{Child(Wildcard()), Child(Filter(And(Comparison(
+				SingularQuery(false), EqualTo, Literal(false),
+			))))},
+			exp: []*LocatedNode{{Path: Normalized(Name("env"), Name("debug")), Node: false}},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			q := Query(true, tc.segs...)
+			a.Equal(tc.exp, q.SelectLocated(nil, doc, NormalizedPath{}))
+		})
+	}
+
+	// Keys that are not strings, booleans, or numbers are ignored.
+	obj, ok := AsObject(doc)
+	assert.True(t, ok)
+	assert.Equal(t, 3, obj.Len())
+	_, ok = obj.Get("[1 2]")
+	assert.False(t, ok)
+
+	// Does not panic for keys to which strings are not assignable.
+	obj, ok = AsObject(map[fmt.Stringer]int{})
+	assert.True(t, ok)
+	_, ok = obj.Get("x")
+	assert.False(t, ok)
+}
+
+func TestPointerContainers(t *testing.T) {
+	t.Parallel()
+
+	obj := map[string]any{"x": 1}
+	arr := []any{"a", "b"}
+	objPtr := &obj
+	typed := map[string]int{"y": 2}
+	strs := []string{"c"}
+	inner := address{Street: "Elm St"}
+	innerPtr := &inner
+	var iface any = arr
+
+	for _, tc := range []struct {
+		test string
+		sel  Selector
+		src  any
+		exp  []any
+	}{
+		{"map_ptr", Name("x"), &obj, []any{1}},
+		{"map_ptr_ptr", Name("x"), &objPtr, []any{1}},
+		{"typed_map_ptr", Wildcard(), &typed, []any{2}},
+		{"slice_ptr", Index(1), &arr, []any{"b"}},
+		{"slice_ptr_wildcard", Wildcard(), &arr, []any{"a", "b"}},
+		{"slice_ptr_slice", Slice(nil, nil, -1), &arr, []any{"b", "a"}},
+		{"typed_slice_ptr", Index(0), &strs, []any{"c"}},
+		{"struct_ptr_ptr", Name("street"), &innerPtr, []any{"Elm St"}},
+		{"interface_ptr", Index(0), &iface, []any{"a"}},
+		{"nil_map_ptr", Wildcard(), (*map[string]any)(nil), []any{}},
+		{"nil_slice_ptr", Index(0), (*[]any)(nil), []any{}},
+		{"nil_struct_ptr_ptr", Name("street"), (**address)(nil), []any{}},
+		{"nil_inner_ptr", Name("street"), new(*address), []any{}},
+		{"nil_map", Wildcard(), new(map[string]any), []any{}},
+		{"marshaler_ptr", Wildcard(), &noon, []any{}},
+		{
+			"filter_ptr",
+			Filter(And(Comparison(SingularQuery(false), GreaterThan, Literal(0)))),
+			&map[string]any{"a": -1},
+			[]any{},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, tc.sel.Select(tc.src, nil))
+		})
+	}
+
+	t.Run("descendant", func(t *testing.T) {
+		t.Parallel()
+		nested := &map[string]any{"a": &[]any{&map[string]any{"b": 1}}}
+		assert.Equal(t, []any{1}, Descendant(Name("b")).Select(nested, nil))
+	})
+
+	t.Run("located", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(
+			t,
+			[]*LocatedNode{{Path: Normalized(Index(1)), Node: "b"}},
+			Index(-1).SelectLocated(&arr, nil, NormalizedPath{}),
+		)
+	})
+}
@@ -0,0 +1,166 @@
+package spec
+
+import "math"
+
+const (
+	// costFanout is the number of children that [PathQuery.Cost] assumes
+	// each object and array has.
+	costFanout = 10
+
+	// costSubtree is the number of values that [PathQuery.Cost] assumes a
+	// descendant segment visits below each value to which it applies,
+	// including the value itself.
+	costSubtree = 100
+)
+
+// CostEstimate summarizes the worst-case cost of evaluating a [PathQuery],
+// as estimated by [PathQuery.Cost] without evaluating it. Services that
+// evaluate untrusted queries can use it to reject or throttle expensive
+// queries before running them; use [Stats] to measure the actual cost of
+// evaluating a query.
+type CostEstimate struct {
+	// Singular is true if the query is a singular query, which selects at
+	// most one node by visiting at most one value per segment.
+	Singular bool `json:"singular"`
+	// Descendants is the number of descendant segments, including those of
+	// queries in filter expressions.
+	Descendants int `json:"descendants"`
+	// Wildcards is the number of wildcard selectors, including those of
+	// queries in filter expressions.
+	Wildcards int `json:"wildcards"`
+	// Filters is the number of filter selectors, including those nested in
+	// filter expressions.
+	Filters int `json:"filters"`
+	// Functions is the number of function expressions.
+	Functions int `json:"functions"`
+	// Regexes is the number of calls to the match() and search()
+	// functions, each of which evaluates a regular expression for every
+	// value to which its filter applies.
+	Regexes int `json:"regexes"`
+	// Multiplier estimates the number of values that evaluating the query
+	// visits, including the values visited by the queries in filter
+	// expressions, assuming that every object and array has 10 children,
+	// that a descendant segment visits 100 values, and that every filter
+	// selects all of its candidates. It's 1 for the root query $ and grows
+	// multiplicatively with wildcards, slices, filters, and descendant
+	// segments, so it's most useful to compare queries or to enforce a
+	// threshold, rather than to predict the actual cost for a particular
+	// input.
+	Multiplier float64 `json:"multiplier"`
+}
+
+// Cost estimates the worst-case cost of evaluating q without evaluating
+// it, counting its descendant segments, wildcards, filters, and function
+// and regular expression calls, and estimating the number of values it
+// visits. See [CostEstimate] for details.
+func (q *PathQuery) Cost() CostEstimate {
+	cost := CostEstimate{Singular: q.isSingular()}
+	Walk(q, visitorFunc(func(node any) bool {
+		switch n := node.(type) {
+		case *Segment:
+			if n.descendant {
+				cost.Descendants++
+			}
+		case WildcardSelector:
+			cost.Wildcards++
+		case *FilterSelector:
+			cost.Filters++
+		case *FuncExpr:
+			cost.countFunc(n)
+		case NotFuncExpr:
+			cost.countFunc(n.FuncExpr)
+		}
+		return true
+	}))
+	visits, _ := queryVisits(q)
+	cost.Multiplier = math.Max(visits, 1)
+	return cost
+}
+
+// countFunc counts a call to fe, and to a regular expression if fe calls
+// match() or search().
+func (c *CostEstimate) countFunc(fe *FuncExpr) {
+	c.Functions++
+	if name := fe.fn.Name(); name == "match" || name == "search" {
+		c.Regexes++
+	}
+}
+
+// queryVisits estimates the number of values that evaluating q visits and
+// the number of nodes it selects.
+func queryVisits(q *PathQuery) (float64, float64) {
+	visits, width := 0.0, 1.0
+	for _, seg := range q.segments {
+		in := width
+		if seg.descendant {
+			in *= costSubtree
+		}
+		visits += in
+
+		out := 0.0
+		for _, sel := range seg.selectors {
+			switch sel := sel.(type) {
+			case Name, Index:
+				out++
+			case SliceSelector:
+				out += sliceWidth(sel)
+			case WildcardSelector:
+				out += costFanout
+			case *FilterSelector:
+				out += costFanout
+				visits += in * costFanout * exprVisits(sel.LogicalOr)
+			}
+		}
+		width = in * out
+	}
+	return visits, width
+}
+
+// sliceWidth estimates the number of elements s selects: the exact number
+// for slices with non-negative bounds, and otherwise all of the children
+// assumed by [costFanout].
+func sliceWidth(s SliceSelector) float64 {
+	if s.step == 0 {
+		return 0
+	}
+	lo, hi, step := s.start, s.end, s.step
+	if step < 0 {
+		lo, hi, step = s.end+1, s.start+1, -step
+	}
+	if lo < 0 || hi < 0 || hi == math.MaxInt || s.start == math.MaxInt {
+		return costFanout
+	}
+	if hi <= lo {
+		return 0
+	}
+	return math.Min(math.Ceil(float64(hi-lo)/float64(step)), costFanout)
+}
+
+// exprVisits estimates the number of values that the queries in expr visit
+// for each evaluation of expr.
+func exprVisits(expr any) float64 {
+	visits := 0.0
+	walk(expr, visitorFunc(func(node any) bool {
+		switch n := node.(type) {
+		case *PathQuery:
+			v, _ := queryVisits(n)
+			visits += v
+			return false
+		case *SingularQueryExpr:
+			visits += float64(len(n.selectors))
+			return false
+		}
+		return true
+	}))
+	return visits
+}
+
+// visitorFunc is a [Visitor] that calls itself for Enter and does nothing
+// on Exit.
+type visitorFunc func(node any) bool
+
+// Enter calls f. Defined by [Visitor].
+func (f visitorFunc) Enter(node any) bool { return f(node) }
+
+// Exit does nothing. Defined by [Visitor].
+func (visitorFunc) Exit(any) {}
@@ -0,0 +1,86 @@
+package spec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCost(t *testing.T) {
+	t.Parallel()
+
+	match := Extension("match", FuncLogical, nil, nil)
+	other := Extension("other", FuncValue, nil, nil)
+
+	for _, tc := range []struct {
+		test string
+		q    *PathQuery
+		exp  CostEstimate
+	}{
+		{"root", Query(true), CostEstimate{Singular: true, Multiplier: 1}},
+		{"name", Query(true, Child(Name("a"))), CostEstimate{Singular: true, Multiplier: 1}},
+		{
+			"wildcard_names",
+			Query(true, Child(Wildcard()), Child(Name("a"), Name("b"))),
+			CostEstimate{Wildcards: 1, Multiplier: 11},
+		},
+		{
+			"descendant",
+			Query(true, Descendant(Name("a")), Child(Index(0))),
+			CostEstimate{Descendants: 1, Multiplier: 200},
+		},
+		{
+			"filter",
+			Query(true, Child(Filter(And(
+				Comparison(SingularQuery(false, Name("a"), Name("b")), EqualTo, Literal(1)),
+				Function(match, SingularQuery(false, Name("a")), Literal("x")),
+				NotFuncExpr{Function(match, SingularQuery(true), Literal("y"))},
+			)))),
+			CostEstimate{Filters: 1, Functions: 2, Regexes: 2, Multiplier: 31},
+		},
+		{
+			"filter_query",
+			Query(true, Child(Filter(And(
+				Existence(Query(false, Child(Wildcard()), Child(Wildcard()))),
+				Comparison(Function(other, Query(false, Descendant(Wildcard()))), EqualTo, Literal(1)),
+			)))),
+			CostEstimate{Descendants: 1, Wildcards: 3, Filters: 1, Functions: 1, Multiplier: 1111},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, tc.q.Cost())
+		})
+	}
+}
+
+func TestSliceWidth(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test  string
+		slice SliceSelector
+		exp   float64
+	}{
+		{"all", Slice(), costFanout},
+		{"bounded", Slice(1, 4), 3},
+		{"step", Slice(0, 5, 2), 3},
+		{"empty", Slice(3, 1), 0},
+		{"zero_step", Slice(0, 5, 0), 0},
+		{"wide", Slice(0, 1000), costFanout},
+		{"huge", Slice(0, math.MaxInt-1), costFanout},
+		{"negative_start", Slice(-3, 5), costFanout},
+		{"negative_end", Slice(0, -1), costFanout},
+		{"open_end", Slice(2), costFanout},
+		{"reverse", Slice(nil, nil, -1), costFanout},
+		{"reverse_bounded", Slice(5, 1, -1), 4},
+		{"reverse_step", Slice(5, 0, -2), 3},
+		{"reverse_to_start", Slice(3, nil, -1), costFanout},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.InDelta(t, tc.exp, sliceWidth(tc.slice), 0)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package spec
+
+import "strings"
+
+// ASTNode describes a node in the abstract syntax tree of a [PathQuery], as
+// returned by [PathQuery.Describe]. Kind identifies the type of the node:
+//
+//   - query: a [PathQuery]; Text is $ or @
+//   - singular-query: a [SingularQueryExpr]; Text is $ or @
+//   - child-segment, descendant-segment: a [Segment]
+//   - name, index, slice, wildcard, filter: a [Selector]
+//   - or, and: a [LogicalOr] or [LogicalAnd] of more than one expression
+//   - paren, not-paren: a [ParenExpr] or [NotParenExpr]
+//   - exists, not-exists: an [ExistExpr] or [NonExistExpr]
+//   - comparison: a [CompExpr]; Text is its operator
+//   - function, not-function: a [FuncExpr] or [NotFuncExpr]; Text is the
+//     function name
+//   - literal: a [LiteralArg]
+//
+// Text contains the string representation of the node, except where noted,
+// and Children contains its child nodes, in order.
+type ASTNode struct {
+	Kind     string     `json:"kind"`
+	Text     string     `json:"text"`
+	Children []*ASTNode `json:"children,omitempty"`
+}
+
+// String returns an indented tree representation of n, with a line for n
+// followed by a line for each of its descendants, indented two spaces
+// deeper than its parent. Each line contains the Kind and Text of a node.
+func (n *ASTNode) String() string {
+	var buf strings.Builder
+	n.writeTree(&buf, 0)
+	return buf.String()
+}
+
+// writeTree writes the tree representation of n to buf, indented for
+// depth.
+func (n *ASTNode) writeTree(buf *strings.Builder, depth int) {
+	buf.WriteString(strings.Repeat("  ", depth))
+	buf.WriteString(n.Kind)
+	if n.Text != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(n.Text)
+	}
+	buf.WriteByte('\n')
+	for _, c := range n.Children {
+		c.writeTree(buf, depth+1)
+	}
+}
+
+// Describe returns the abstract syntax tree of q, with an [ASTNode] for q
+// and for each of its segments and selectors, and for the expressions of its
+// filter selectors. Use its String method to print the tree.
+func (q *PathQuery) Describe() *ASTNode {
+	node := &ASTNode{Kind: "query", Text: queryIdent(q.root)}
+	for _, seg := range q.segments {
+		node.Children = append(node.Children, describeSegment(seg))
+	}
+	return node
+}
+
+// queryIdent returns $ if root is true and @ if it is not.
+func queryIdent(root bool) string {
+	if root {
+		return "$"
+	}
+	return "@"
+}
+
+// describeSegment returns the [ASTNode] for seg.
+func describeSegment(seg *Segment) *ASTNode {
+	node := &ASTNode{Kind: "child-segment", Text: seg.String()}
+	if seg.descendant {
+		node.Kind = "descendant-segment"
+	}
+	for _, sel := range seg.selectors {
+		node.Children = append(node.Children, describeSelector(sel))
+	}
+	return node
+}
+
+// describeSelector returns the [ASTNode] for sel.
+func describeSelector(sel Selector) *ASTNode {
+	node := &ASTNode{Text: selectorString(sel)}
+	switch sel := sel.(type) {
+	case Name:
+		node.Kind = "name"
+	case Index:
+		node.Kind = "index"
+	case SliceSelector:
+		node.Kind = "slice"
+	case WildcardSelector:
+		node.Kind = "wildcard"
+	case *FilterSelector:
+		node.Kind = "filter"
+		node.Children = []*ASTNode{describeExpr(sel.LogicalOr)}
+	default:
+		node.Kind = "selector"
+	}
+	return node
+}
+
+// describeExpr returns the [ASTNode] for expr, a filter expression,
+// comparison operand, or function argument. Omits a [LogicalOr] or
+// [LogicalAnd] of a single expression in favor of the expression itself.
+func describeExpr(expr stringWriter) *ASTNode {
+	var buf strings.Builder
+	expr.writeTo(&buf)
+	node := &ASTNode{Text: buf.String()}
+
+	switch e := expr.(type) {
+	case LogicalOr:
+		if len(e) == 1 {
+			return describeExpr(e[0])
+		}
+		node.Kind = "or"
+		for _, and := range e {
+			node.Children = append(node.Children, describeExpr(and))
+		}
+	case LogicalAnd:
+		if len(e) == 1 {
+			return describeExpr(e[0])
+		}
+		node.Kind = "and"
+		for _, ex := range e {
+			node.Children = append(node.Children, describeExpr(ex))
+		}
+	case *ParenExpr:
+		node.Kind = "paren"
+		node.Children = []*ASTNode{describeExpr(e.LogicalOr)}
+	case *NotParenExpr:
+		node.Kind = "not-paren"
+		node.Children = []*ASTNode{describeExpr(e.LogicalOr)}
+	case *ExistExpr:
+		node.Kind = "exists"
+		node.Children = []*ASTNode{e.Describe()}
+	case *NonExistExpr:
+		node.Kind = "not-exists"
+		node.Children = []*ASTNode{e.Describe()}
+	case NonExistExpr:
+		node.Kind = "not-exists"
+		node.Children = []*ASTNode{e.Describe()}
+	case *CompExpr:
+		node.Kind = "comparison"
+		node.Text = e.op.String()
+		node.Children = []*ASTNode{describeExpr(e.left), describeExpr(e.right)}
+	case *FuncExpr:
+		node.Kind = "function"
+		node.Text = e.fn.Name()
+		node.Children = describeArgs(e.args)
+	case NotFuncExpr:
+		node.Kind = "not-function"
+		node.Text = e.fn.Name()
+		node.Children = describeArgs(e.args)
+	case *PathQuery:
+		return e.Describe()
+	case *SingularQueryExpr:
+		node.Kind = "singular-query"
+		node.Text = queryIdent(!e.relative)
+		for _, sel := range e.selectors {
+			node.Children = append(node.Children, describeSelector(sel))
+		}
+	case *LiteralArg:
+		node.Kind = "literal"
+	default:
+		node.Kind = "expression"
+	}
+	return node
+}
+
+// describeArgs returns the [ASTNode] values for args.
+func describeArgs(args []FuncExprArg) []*ASTNode {
+	nodes := make([]*ASTNode, len(args))
+	for i, arg := range args {
+		nodes[i] = describeExpr(arg)
+	}
+	return nodes
+}
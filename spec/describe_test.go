@@ -0,0 +1,123 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		exp   *ASTNode
+		tree  string
+	}{
+		{
+			test:  "root",
+			query: Query(true),
+			exp:   &ASTNode{Kind: "query", Text: "$"},
+			tree:  "query $\n",
+		},
+		{
+			test:  "selectors",
+			query: Query(false, Child(Name("a"), Index(1)), Descendant(Slice(1, 3), Wildcard())),
+			exp: &ASTNode{Kind: "query", Text: "@", Children: []*ASTNode{
+				{Kind: "child-segment", Text: `["a",1]`, Children: []*ASTNode{
+					{Kind: "name", Text: `"a"`},
+					{Kind: "index", Text: "1"},
+				}},
+				{Kind: "descendant-segment", Text: `..[1:3,*]`, Children: []*ASTNode{
+					{Kind: "slice", Text: "1:3"},
+					{Kind: "wildcard", Text: "*"},
+				}},
+			}},
+			tree: `query @
+  child-segment ["a",1]
+    name "a"
+    index 1
+  descendant-segment ..[1:3,*]
+    slice 1:3
+    wildcard *
+`,
+		},
+		{
+			test: "filter",
+			query: Query(true, Descendant(Filter(
+				And(
+					Comparison(SingularQuery(false, Name("x"), Index(0)), GreaterThan, Literal(int64(1))),
+					Existence(Query(false, Child(Name("y")))),
+				),
+				And(
+					NotParen(And(Nonexistence(Query(true, Child(Wildcard()))))),
+					Paren(And(Function(newTrueFunc(), Query(false, Child(Name("z"))), Literal("hi")))),
+				),
+				And(NotFunction(Function(newTrueFunc(), LogicalOr{And(Existence(Query(false)))}))),
+			))),
+			tree: `query $
+  descendant-segment ..[?@["x"][0] > 1 && @["y"] || !(!$[*]) && (__true(@["z"], "hi")) || !__true(@)]
+    filter ?@["x"][0] > 1 && @["y"] || !(!$[*]) && (__true(@["z"], "hi")) || !__true(@)
+      or @["x"][0] > 1 && @["y"] || !(!$[*]) && (__true(@["z"], "hi")) || !__true(@)
+        and @["x"][0] > 1 && @["y"]
+          comparison >
+            singular-query @
+              name "x"
+              index 0
+            literal 1
+          exists @["y"]
+            query @
+              child-segment ["y"]
+                name "y"
+        and !(!$[*]) && (__true(@["z"], "hi"))
+          not-paren !(!$[*])
+            not-exists !$[*]
+              query $
+                child-segment [*]
+                  wildcard *
+          paren (__true(@["z"], "hi"))
+            function __true
+              query @
+                child-segment ["z"]
+                  name "z"
+              literal "hi"
+        not-function __true
+          exists @
+            query @
+`,
+		},
+		{
+			test:  "single_expression",
+			query: Query(true, Child(Filter(And(Comparison(SingularQuery(true), EqualTo, Literal(nil)))))),
+			exp: &ASTNode{Kind: "query", Text: "$", Children: []*ASTNode{
+				{Kind: "child-segment", Text: `[?$ == null]`, Children: []*ASTNode{
+					{Kind: "filter", Text: `?$ == null`, Children: []*ASTNode{
+						{Kind: "comparison", Text: "==", Children: []*ASTNode{
+							{Kind: "singular-query", Text: "$"},
+							{Kind: "literal", Text: "null"},
+						}},
+					}},
+				}},
+			}},
+			tree: `query $
+  child-segment [?$ == null]
+    filter ?$ == null
+      comparison ==
+        singular-query $
+        literal null
+`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			node := tc.query.Describe()
+			if tc.exp != nil {
+				a.Equal(tc.exp, node)
+			}
+			a.Equal(tc.tree, node.String())
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package spec
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Change describes a difference between two JSON values, as returned by
+// [Diff]: a value added, removed, or replaced at a [NormalizedPath].
+type Change struct {
+	// Path identifies the location of the changed value.
+	Path NormalizedPath `json:"path"`
+	// Old is the original value at Path, or [Nothing] if the change adds a
+	// value.
+	Old any `json:"old"`
+	// New is the new value at Path, or [Nothing] if the change removes a
+	// value.
+	New any `json:"new"`
+}
+
+// String returns a string representation of c, such as
+// "$['a'][0]: 1 -> 2".
+func (c Change) String() string {
+	return fmt.Sprintf("%v: %v -> %v", c.Path, c.Old, c.New)
+}
+
+// Diff compares a and b, two JSON values, and returns the changes that
+// transform a into b, ordered by [NormalizedPath.Compare]. It prefixes the
+// path of each change with parent, the location of a and b in a larger
+// value, if any. It compares the members of objects by name and the
+// elements of arrays by index, so that inserting an element into an array
+// replaces the elements that follow it and adds a new last element. Values
+// that differ in type, such as an object and an array, are replaced in
+// their entirety. Numbers compare as equal if they have the same value,
+// even if they have different Go types.
+//
+// Diff supports the same objects and arrays as [PathQuery.Select],
+// including those that implement [Object] or [Array], and treats [Nothing]
+// as an absent value, so that Diff(Nothing, b, parent) returns a single
+// change that adds b. Returns nil if a and b are equal.
+func Diff(a, b any, parent NormalizedPath) []Change {
+	var changes []Change
+	path := append(make(NormalizedPath, 0, len(parent)+4), parent...)
+	diffValues(path, a, b, &changes)
+	slices.SortFunc(changes, func(x, y Change) int {
+		return x.Path.Compare(y.Path)
+	})
+	return changes
+}
+
+// diffValues appends the changes that transform a into b at path to
+// changes.
+func diffValues(path NormalizedPath, a, b any, changes *[]Change) {
+	_, aNothing := a.(NothingType)
+	_, bNothing := b.(NothingType)
+	switch {
+	case aNothing && bNothing:
+		return
+	case aNothing || bNothing:
+		addChange(path, a, b, changes)
+		return
+	}
+
+	if ao, ok := AsObject(a); ok {
+		if bo, ok := AsObject(b); ok {
+			diffObjects(path, ao, bo, changes)
+			return
+		}
+	} else if aa, ok := AsArray(a); ok {
+		if ba, ok := AsArray(b); ok {
+			diffArrays(path, aa, ba, changes)
+			return
+		}
+	}
+
+	if isContainer(a) || isContainer(b) || !valueEqualTo(a, b) {
+		addChange(path, a, b, changes)
+	}
+}
+
+// diffObjects appends the changes that transform the members of object a
+// into those of object b at path to changes.
+func diffObjects(path NormalizedPath, a, b Object, changes *[]Change) {
+	for name, av := range a.Iterate() {
+		bv, ok := b.Get(name)
+		if !ok {
+			bv = Nothing
+		}
+		diffValues(append(path, Name(name)), av, bv, changes)
+	}
+	for name, bv := range b.Iterate() {
+		if _, ok := a.Get(name); !ok {
+			addChange(append(path, Name(name)), Nothing, bv, changes)
+		}
+	}
+}
+
+// diffArrays appends the changes that transform the elements of array a
+// into those of array b at path to changes.
+func diffArrays(path NormalizedPath, a, b Array, changes *[]Change) {
+	for i, av := range a.Iterate() {
+		bv, ok := b.Get(i)
+		if !ok {
+			bv = Nothing
+		}
+		diffValues(append(path, Index(i)), av, bv, changes)
+	}
+	for i, bv := range b.Iterate() {
+		if i >= a.Len() {
+			addChange(append(path, Index(i)), Nothing, bv, changes)
+		}
+	}
+}
+
+// addChange appends a [Change] from before to after at path to changes,
+// copying path.
+func addChange(path NormalizedPath, before, after any, changes *[]Change) {
+	*changes = append(*changes, Change{Path: append(NormalizedPath(nil), path...), Old: before, New: after})
+}
+
+// isContainer returns true if val is an object or array.
+func isContainer(val any) bool {
+	if _, ok := AsObject(val); ok {
+		return true
+	}
+	_, ok := AsArray(val)
+	return ok
+}
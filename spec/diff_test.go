@@ -0,0 +1,179 @@
+package spec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	type point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+
+	for _, tc := range []struct {
+		test   string
+		a      any
+		b      any
+		parent NormalizedPath
+		exp    []Change
+	}{
+		{
+			test: "equal_scalars",
+			a:    "x",
+			b:    "x",
+		},
+		{
+			test: "equal_numbers",
+			a:    1,
+			b:    1.0,
+		},
+		{
+			test: "equal_number_types",
+			a:    map[string]any{"a": 1, "b": json.Number("2")},
+			b:    map[string]any{"a": 1.0, "b": int64(2)},
+		},
+		{
+			test: "nulls",
+			a:    nil,
+			b:    nil,
+		},
+		{
+			test: "nothing",
+			a:    Nothing,
+			b:    Nothing,
+		},
+		{
+			test: "replace_root",
+			a:    1,
+			b:    "1",
+			exp:  []Change{{Path: Normalized(), Old: 1, New: "1"}},
+		},
+		{
+			test: "add_root",
+			a:    Nothing,
+			b:    nil,
+			exp:  []Change{{Path: Normalized(), Old: Nothing, New: nil}},
+		},
+		{
+			test: "remove_root",
+			a:    true,
+			b:    Nothing,
+			exp:  []Change{{Path: Normalized(), Old: true, New: Nothing}},
+		},
+		{
+			test: "object_members",
+			a:    map[string]any{"a": 1, "b": 2, "c": 3},
+			b:    map[string]any{"a": 1, "b": 5, "d": 4},
+			exp: []Change{
+				{Path: Normalized(Name("b")), Old: 2, New: 5},
+				{Path: Normalized(Name("c")), Old: 3, New: Nothing},
+				{Path: Normalized(Name("d")), Old: Nothing, New: 4},
+			},
+		},
+		{
+			test: "array_elements",
+			a:    []any{1, 2, 3},
+			b:    []any{1, 4},
+			exp: []Change{
+				{Path: Normalized(Index(1)), Old: 2, New: 4},
+				{Path: Normalized(Index(2)), Old: 3, New: Nothing},
+			},
+		},
+		{
+			test: "array_append",
+			a:    []any{1},
+			b:    []any{1, 2, 3},
+			exp: []Change{
+				{Path: Normalized(Index(1)), Old: Nothing, New: 2},
+				{Path: Normalized(Index(2)), Old: Nothing, New: 3},
+			},
+		},
+		{
+			test: "nested",
+			a:    map[string]any{"a": []any{map[string]any{"b": "x"}, 1}},
+			b:    map[string]any{"a": []any{map[string]any{"b": "y", "c": nil}, 1}},
+			exp: []Change{
+				{Path: Normalized(Name("a"), Index(0), Name("b")), Old: "x", New: "y"},
+				{Path: Normalized(Name("a"), Index(0), Name("c")), Old: Nothing, New: nil},
+			},
+		},
+		{
+			test: "object_to_array",
+			a:    map[string]any{"a": map[string]any{}},
+			b:    map[string]any{"a": []any{}},
+			exp: []Change{
+				{Path: Normalized(Name("a")), Old: map[string]any{}, New: []any{}},
+			},
+		},
+		{
+			test: "array_to_scalar",
+			a:    []any{"x"},
+			b:    "x",
+			exp:  []Change{{Path: Normalized(), Old: []any{"x"}, New: "x"}},
+		},
+		{
+			test: "null_to_object",
+			a:    nil,
+			b:    map[string]any{},
+			exp:  []Change{{Path: Normalized(), Old: nil, New: map[string]any{}}},
+		},
+		{
+			test: "structs",
+			a:    []point{{1, 2}},
+			b:    []any{map[string]any{"x": 1, "y": 3}},
+			exp:  []Change{{Path: Normalized(Index(0), Name("y")), Old: 2, New: 3}},
+		},
+		{
+			test:   "parent",
+			a:      map[string]any{"a": 1},
+			b:      map[string]any{"a": 2},
+			parent: Normalized(Name("x"), Index(3)),
+			exp: []Change{
+				{Path: Normalized(Name("x"), Index(3), Name("a")), Old: 1, New: 2},
+			},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, Diff(tc.a, tc.b, tc.parent))
+		})
+	}
+}
+
+func TestChange(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal(
+		"$['a'][0]: 1 -> 2",
+		Change{Path: Normalized(Name("a"), Index(0)), Old: 1, New: 2}.String(),
+	)
+	a.Equal(
+		"$['a']: Nothing -> x",
+		Change{Path: Normalized(Name("a")), Old: Nothing, New: "x"}.String(),
+	)
+
+	parent := make(NormalizedPath, 1, 8)
+	parent[0] = Name("p")
+	changes := Diff(
+		map[string]any{"a": 1, "b": []any{1, 2}},
+		map[string]any{"a": 2, "b": []any{3, 4}},
+		parent,
+	)
+	// Each change has its own path.
+	a.Equal([]Change{
+		{Path: Normalized(Name("p"), Name("a")), Old: 1, New: 2},
+		{Path: Normalized(Name("p"), Name("b"), Index(0)), Old: 1, New: 3},
+		{Path: Normalized(Name("p"), Name("b"), Index(1)), Old: 2, New: 4},
+	}, changes)
+	a.Equal(Normalized(Name("p")), parent)
+
+	data, err := json.Marshal(changes[0])
+	a.NoError(err)
+	a.JSONEq(`{"path": "$['p']['a']", "old": 1, "new": 2}`, string(data))
+}
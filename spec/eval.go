@@ -0,0 +1,576 @@
+package spec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrLimitExceeded errors are returned when parsing or evaluating a query
+// exceeds a limit, such as the range of integers RFC 9535 allows in a query
+// or the budget set by [Options].MaxNodes or [Options].Timeout.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// ErrBudgetExceeded errors are returned by [PathQuery.TrySelect] and
+// [PathQuery.TrySelectLocated] when evaluation exceeds the limits set by
+// [Options].MaxNodes or [Options].Timeout. They also wrap
+// [ErrLimitExceeded].
+var ErrBudgetExceeded error = &subError{"evaluation budget exceeded", ErrLimitExceeded}
+
+// ErrDepthExceeded errors are returned by [PathQuery.TrySelect] and
+// [PathQuery.TrySelectLocated] when a descendant segment descends deeper
+// than [Options].MaxDocumentDepth. They also wrap [ErrLimitExceeded].
+var ErrDepthExceeded error = &subError{"document depth exceeded", ErrLimitExceeded}
+
+// ErrCycle errors are returned by [PathQuery.TrySelect] and
+// [PathQuery.TrySelectLocated] when a descendant segment finds a value that
+// contains itself, such as a struct with a pointer to its parent, which it
+// would otherwise descend into forever. Descendant segments detect cycles
+// only through pointers, maps, and slices, and only once they have
+// descended 1,000 levels; set [Options].MaxDepth or
+// [Options].MaxDocumentDepth to stop sooner, and [Options].MaxNodes or
+// [Options].Timeout to bound custom [Object] and [Array] values that
+// generate values without end.
+var ErrCycle = errors.New("cycle detected")
+
+// ErrEvaluation errors are returned by [PathQuery.TrySelect] and
+// [PathQuery.TrySelectLocated] when [Options].Strict is set and evaluation
+// encounters a soft failure, such as a regular expression that fails to
+// compile.
+var ErrEvaluation = errors.New("evaluation error")
+
+// subError is a sentinel error for a subset of the errors identified by a
+// more general sentinel error, which it wraps.
+type subError struct {
+	msg    string
+	parent error
+}
+
+// Error returns the error message.
+func (e *subError) Error() string { return e.msg }
+
+// Unwrap returns the more general error e wraps.
+func (e *subError) Unwrap() error { return e.parent }
+
+// Options configures the evaluation of a [PathQuery]. The zero value
+// evaluates queries as defined by RFC 9535.
+type Options struct {
+	// BytesAsStrings treats []byte values as UTF-8 strings in filter
+	// expression comparisons and function extension arguments. The length()
+	// function thus returns the number of Unicode characters in a []byte
+	// value, rather than the number of bytes, and match() and search()
+	// match against its characters. Queries still select []byte values
+	// unchanged.
+	BytesAsStrings bool
+
+	// MaxDepth limits descendant segments to values no more than MaxDepth
+	// levels below the value to which they apply: a MaxDepth of 1 selects
+	// from a value and its children, but not its grandchildren. Protects
+	// against excessive evaluation of deeply-nested input. Zero or a
+	// negative value means no limit.
+	MaxDepth int
+
+	// Parallelism sets the maximum number of goroutines with which
+	// descendant segments descend into arrays of at least 1,024 elements.
+	// Each goroutine descends into a contiguous range of elements, including
+	// any nested arrays, and their results merge in document order. Values
+	// less than two disable parallel evaluation.
+	Parallelism int
+
+	// Hook, if set, receives notifications of the work done to evaluate a
+	// query, such as the values visited by its segments and the evaluation
+	// of its filter expressions. See [Collector] for a Hook that collects
+	// [Stats].
+	Hook Hook
+
+	// MaxNodes aborts evaluation once segments have visited more than
+	// MaxNodes values, as counted by [Stats].Nodes, including the values
+	// visited by queries in filter expressions. Timeout aborts evaluation
+	// once it has taken longer than Timeout, checked periodically as
+	// segments visit values. Together they place a hard ceiling on the work
+	// a query such as $..*..* may do, regardless of its input. Zero or a
+	// negative value means no limit.
+	//
+	// [PathQuery.TrySelect] and [PathQuery.TrySelectLocated] return an
+	// [ErrBudgetExceeded] error when evaluation aborts; other methods
+	// return no values and log the error to Logger, if set.
+	MaxNodes int
+	Timeout  time.Duration
+
+	// MaxDocumentDepth aborts evaluation when a descendant segment reaches
+	// a value more than MaxDocumentDepth levels below the value to which it
+	// applies. Unlike MaxDepth, which quietly stops descending, it fails
+	// evaluation, so that services can reject adversarial, deeply-nested
+	// documents rather than return partial results for them. Zero or a
+	// negative value means no limit.
+	//
+	// [PathQuery.TrySelect] and [PathQuery.TrySelectLocated] return an
+	// [ErrDepthExceeded] error when evaluation aborts; other methods
+	// return no values and log the error to Logger, if set.
+	MaxDocumentDepth int
+
+	// Logger, if set, receives warnings of soft failures that RFC 9535
+	// requires evaluation to ignore, but which may hide problems with
+	// queries or data: regular expressions that fail to compile, ordering
+	// comparisons of values of different types, which are always false,
+	// and descendant segments that stop descending at MaxDepth.
+	Logger *slog.Logger
+
+	// SortMembers selects the members of objects in ascending order of
+	// their names, compared byte-wise as by [NormalizedPath.Compare], rather
+	// than in the order in which the objects iterate over them, which for
+	// Go maps is random. Wildcard selectors, filter selectors, and
+	// descendant segments thus select values from objects in a reproducible
+	// order, at the cost of sorting the members of each object they visit.
+	// Results still follow the order of selectors within each segment, as
+	// RFC 9535 requires, so that $['b','a'] selects b before a.
+	SortMembers bool
+
+	// Collator, if set, collates strings in the ordering comparisons of
+	// filter expressions, <, <=, >, and >=, rather than comparing the
+	// Unicode code points of their characters, so that queries such as
+	// $[?@.name < "M"] select localized data in the order its users expect.
+	// Equality comparisons, == and !=, still compare strings exactly, even
+	// if the Collator sorts them equally. See [Collator] for details.
+	Collator Collator
+
+	// UniqueLocations removes from the results of a query the values whose
+	// locations, as identified by their normalized paths, it has already
+	// selected, keeping the first. RFC 9535 allows a query to select a node
+	// more than once, as $['a','a'] and $..*..* do, which consumers such as
+	// patch generators cannot apply, while consumers that count selections
+	// may want the duplicates. Applies to the select methods of
+	// [PathQuery], [CompiledQuery], and [Scratch], but not [QuerySet].
+	// Queries in filter expressions, whose results functions such as
+	// count() measure, are unaffected.
+	//
+	// Queries that select values rather than [LocatedNode] values must
+	// compute their normalized paths to find duplicates, so UniqueLocations
+	// makes [PathQuery.SelectWith] as costly as [PathQuery.SelectLocatedWith]
+	// and disables the optimizations of [CompiledQuery].
+	UniqueLocations bool
+
+	// Auditor, if set, receives the normalized paths of the values selected
+	// by each evaluation of a query, for audit trails. See [Auditor] for
+	// details.
+	Auditor Auditor
+
+	// Strict aborts evaluation with an [ErrEvaluation] error on the first
+	// soft failure that RFC 9535 otherwise requires evaluation to ignore:
+	// a regular expression that fails to compile, an ordering comparison
+	// of values of different types, such as 1 < "2", or a function
+	// extension that returns a warning via [WithWarning]. Comparisons with
+	// missing values remain false. [PathQuery.TrySelect] and
+	// [PathQuery.TrySelectLocated] return the error; other methods return
+	// no values and log the error to Logger, if set. Useful for pipelines
+	// that prefer to fail loudly than to quietly skip values.
+	Strict bool
+}
+
+// budgetCheckInterval is the number of visits between checks of the
+// deadline set by [Options].Timeout. A power of two.
+const budgetCheckInterval = 256
+
+// budget tracks the visits and deadline of an evaluation limited by
+// [Options].MaxNodes or [Options].Timeout, or by the cancellation of ctx.
+// Shared by the goroutines of parallel evaluations.
+type budget struct {
+	visits   atomic.Int64
+	max      int64
+	deadline time.Time
+	timeout  time.Duration
+	ctx      context.Context //nolint:containedctx
+}
+
+// evalAbort is the value with which an evaluation panics when it exceeds
+// its budget, its context is canceled, or it encounters a soft failure in
+// strict mode, recovered by [catchAbort].
+type evalAbort struct {
+	err error
+}
+
+// spend counts a visit and panics with an [evalAbort] if it exceeds b's
+// limits.
+func (b *budget) spend() {
+	n := b.visits.Add(1)
+	if b.max > 0 && n > b.max {
+		panic(evalAbort{fmt.Errorf(
+			"%w: visited more than %d nodes", ErrBudgetExceeded, b.max,
+		)})
+	}
+	if n%budgetCheckInterval != 0 {
+		return
+	}
+	if b.timeout > 0 && time.Now().After(b.deadline) {
+		panic(evalAbort{fmt.Errorf(
+			"%w: took longer than %v", ErrBudgetExceeded, b.timeout,
+		)})
+	}
+	if b.ctx != nil && b.ctx.Err() != nil {
+		panic(evalAbort{context.Cause(b.ctx)})
+	}
+}
+
+// catchAbort recovers an [evalAbort] panic and assigns its error to
+// err. Re-panics any other value. Call with defer.
+func catchAbort(err *error) {
+	if r := recover(); r != nil {
+		be, ok := r.(evalAbort)
+		if !ok {
+			panic(r)
+		}
+		*err = be.err
+	}
+}
+
+// dropAbort recovers an [evalAbort] panic for the methods that return no
+// error and logs its error as a warning to ev's Logger, if any. Those
+// methods discard any values selected before evaluation aborted and
+// return the zero values of their results, such as nil, or [Nothing] for
+// [PathQuery.SelectValue]. Re-panics any other value. Call with defer.
+func (ev *evaluation) dropAbort() {
+	if r := recover(); r != nil {
+		be, ok := r.(evalAbort)
+		if !ok {
+			panic(r)
+		}
+		ev.warn("jsonpath: evaluation aborted", "error", be.err)
+	}
+}
+
+// parallelThreshold is the minimum length of an array into which descendant
+// segments descend in parallel.
+const parallelThreshold = 1024
+
+// evaluation carries the root value and [Options] for a single evaluation
+// of a [PathQuery] through segments, selectors, and filter expressions.
+type evaluation struct {
+	root any
+	opts Options
+	// stack holds a stack for reuse by [descend].
+	stack []descent
+	// budget limits the evaluation if opts sets MaxNodes or Timeout.
+	budget *budget
+	// truncated records whether ev has logged a descent stopped at
+	// opts.MaxDepth, so that it logs at most one. Nil unless opts sets both
+	// MaxDepth and Logger.
+	truncated *atomic.Bool
+}
+
+// newEvaluation creates an evaluation of root configured by opts, starting
+// its budget, if any.
+func newEvaluation(root any, opts Options) *evaluation {
+	ev := &evaluation{}
+	ev.reset(root, opts)
+	return ev
+}
+
+// reset prepares ev for an evaluation of root configured by opts, starting
+// its budget, if any. Retains ev's stack for reuse.
+func (ev *evaluation) reset(root any, opts Options) {
+	*ev = evaluation{root: root, opts: opts, stack: ev.stack[:0]}
+	if opts.MaxNodes > 0 || opts.Timeout > 0 {
+		ev.budget = &budget{max: int64(opts.MaxNodes), timeout: opts.Timeout}
+		if opts.Timeout > 0 {
+			ev.budget.deadline = time.Now().Add(opts.Timeout)
+		}
+	}
+	if opts.Logger != nil && opts.MaxDepth > 0 {
+		ev.truncated = new(atomic.Bool)
+	}
+}
+
+// maxScratchBuffer is the capacity above which [Scratch.Reset] discards
+// a buffer rather than retaining it for reuse.
+const maxScratchBuffer = 1 << 16
+
+// Scratch evaluates queries with scratch buffers that it reuses from one
+// evaluation to the next: the stack with which descendant segments traverse
+// values, and the buffers that hold the results of each segment. Once its
+// buffers have grown to fit the queries and values it evaluates, it
+// selects values without allocating memory for queries that select without
+// filter expressions from []any and map[string]any values. The zero value
+// is ready to use. A Scratch is not safe for concurrent use; use a
+// [sync.Pool] to share Scratch values between goroutines.
+type Scratch struct {
+	ev       evaluation
+	res, buf []any
+}
+
+// Select selects the values from current or root as configured by opts and
+// returns the results, as [PathQuery.SelectWith] does. The results use e's
+// buffer, so they remain valid only until the next call to a method of e;
+// copy them to retain them.
+func (e *Scratch) Select(q *PathQuery, current, root any, opts Options) []any {
+	e.ev.reset(root, opts)
+	defer e.ev.dropAbort()
+	return e.selectFrom(q, current)
+}
+
+// TrySelect selects the values from current or root as configured by opts
+// and returns the results, as [PathQuery.TrySelect] does. Returns an
+// [ErrBudgetExceeded] error if evaluation exceeds the limits set by
+// opts.MaxNodes or opts.Timeout. Otherwise the same as [Scratch.Select].
+func (e *Scratch) TrySelect(q *PathQuery, current, root any, opts Options) (res []any, err error) {
+	e.ev.reset(root, opts)
+	defer catchAbort(&err)
+	return e.selectFrom(q, current), nil
+}
+
+// selectFrom selects the values from current or e.ev.root into e's
+// buffers and returns the results.
+func (e *Scratch) selectFrom(q *PathQuery, current any) []any {
+	if e.ev.locates() {
+		e.res = q.locateFrom(current, &e.ev)
+		return e.res
+	}
+	if e.buf == nil {
+		// Return an empty slice rather than nil, as SelectWith does.
+		e.buf = make([]any, 0)
+	}
+	e.res, e.buf = q.selectInto(current, &e.ev, e.res, e.buf)
+	return e.res
+}
+
+// Reset clears e's buffers, so that they no longer refer to the values of
+// previous evaluations, and discards buffers that have grown larger than
+// 65,536 elements, so that a Scratch retained in a [sync.Pool] after
+// evaluating a large document does not retain excessive memory.
+func (e *Scratch) Reset() {
+	e.res = resetBuffer(e.res)
+	e.buf = resetBuffer(e.buf)
+	e.ev.stack = resetBuffer(e.ev.stack)
+	e.ev = evaluation{stack: e.ev.stack}
+}
+
+// resetBuffer clears buf and returns it truncated, or returns nil if its
+// capacity exceeds maxScratchBuffer.
+func resetBuffer[T any](buf []T) []T {
+	if cap(buf) > maxScratchBuffer {
+		return nil
+	}
+	clear(buf[:cap(buf)])
+	return buf[:0]
+}
+
+// watch configures ev to abort if ctx is canceled, checked periodically as
+// segments visit values.
+func (ev *evaluation) watch(ctx context.Context) {
+	if ctx.Done() == nil {
+		return
+	}
+	if ev.budget == nil {
+		ev.budget = &budget{}
+	}
+	ev.budget.ctx = ctx
+}
+
+// serial returns a copy of ev for a separate traversal that disables
+// parallelism and shares ev's budget.
+func (ev *evaluation) serial() *evaluation {
+	sev := &evaluation{root: ev.root, opts: ev.opts, budget: ev.budget, truncated: ev.truncated}
+	sev.opts.Parallelism = 0
+	return sev
+}
+
+// value converts val for use in a filter expression or function argument as
+// configured by ev's options.
+func (ev *evaluation) value(val any) any {
+	if ev.opts.BytesAsStrings {
+		if b, ok := val.([]byte); ok {
+			return string(b)
+		}
+	}
+	return val
+}
+
+// nodes converts each value in list with [evaluation.value] and returns the
+// result as a [NodesType]. Copies list rather than modifying it, as it may
+// be a slice from the input.
+func (ev *evaluation) nodes(list []any) NodesType {
+	if !ev.opts.BytesAsStrings {
+		return NodesType(list)
+	}
+	ret := make(NodesType, len(list))
+	for i, v := range list {
+		ret[i] = ev.value(v)
+	}
+	return ret
+}
+
+// visit notifies ev's [Hook], if any, of a visit to a value at depth, and
+// spends it from ev's budget, if any. Panics with an [evalAbort] if depth
+// exceeds ev.opts.MaxDocumentDepth.
+func (ev *evaluation) visit(depth int) {
+	if ev.opts.MaxDocumentDepth > 0 && depth > ev.opts.MaxDocumentDepth {
+		panic(evalAbort{fmt.Errorf(
+			"%w: descended more than %d levels", ErrDepthExceeded, ev.opts.MaxDocumentDepth,
+		)})
+	}
+	if ev.opts.Hook != nil {
+		ev.opts.Hook.Visit(depth)
+	}
+	if ev.budget != nil {
+		ev.budget.spend()
+	}
+}
+
+// filter notifies ev's [Hook], if any, of the evaluation of a filter
+// expression.
+func (ev *evaluation) filter() {
+	if ev.opts.Hook != nil {
+		ev.opts.Hook.Filter()
+	}
+}
+
+// function notifies ev's [Hook], if any, of a call to the function
+// extension named name.
+func (ev *evaluation) function(name string) {
+	if ev.opts.Hook != nil {
+		ev.opts.Hook.Function(name)
+	}
+}
+
+// warn logs msg and args as a warning to ev's Logger, if any.
+func (ev *evaluation) warn(msg string, args ...any) {
+	if ev.opts.Logger != nil {
+		ev.opts.Logger.Warn(msg, args...)
+	}
+}
+
+// checked returns true if ev reports soft failures, either to a Logger or
+// as errors in strict mode.
+func (ev *evaluation) checked() bool {
+	return ev.opts.Logger != nil || ev.opts.Strict
+}
+
+// fail reports a soft failure described by msg and the key/value pairs in
+// args. In strict mode it aborts evaluation with an [ErrEvaluation] error;
+// otherwise it logs a warning to ev's Logger, if any.
+func (ev *evaluation) fail(msg string, args ...any) {
+	if ev.opts.Strict {
+		panic(evalAbort{&evalError{msg: msg, args: args}})
+	}
+	ev.warn(msg, args...)
+}
+
+// evalError describes a soft failure that aborted a strict evaluation.
+type evalError struct {
+	msg  string
+	args []any
+}
+
+// Error returns the error message, followed by its key/value pairs.
+func (e *evalError) Error() string {
+	var buf strings.Builder
+	buf.WriteString(e.msg)
+	for i := 0; i+1 < len(e.args); i += 2 {
+		if i == 0 {
+			buf.WriteByte(':')
+		}
+		val := fmt.Sprint(e.args[i+1])
+		if val == "" || strings.ContainsAny(val, " =\"") {
+			val = strconv.Quote(val)
+		}
+		fmt.Fprintf(&buf, " %v=%v", e.args[i], val)
+	}
+	return buf.String()
+}
+
+// Unwrap returns [ErrEvaluation].
+func (e *evalError) Unwrap() error { return ErrEvaluation }
+
+// truncate logs a warning to ev's Logger, if any, the first time a
+// descendant segment stops descending at opts.MaxDepth above node, a value
+// that contains arrays or objects from which the segment might have selected
+// values.
+func (ev *evaluation) truncate(node any, depth int) {
+	if ev.truncated == nil || ev.truncated.Load() || len(pushChildren(nil, node, 0)) == 0 {
+		return
+	}
+	if ev.truncated.CompareAndSwap(false, true) {
+		ev.warn(
+			"jsonpath: descendant segment stopped at maximum depth",
+			"max_depth", ev.opts.MaxDepth, "depth", depth,
+		)
+	}
+}
+
+// parallelElements returns the elements of node if ev's options enable
+// parallel evaluation and node is an array of at least [parallelThreshold]
+// elements that may contain arrays or objects.
+func (ev *evaluation) parallelElements(node any) ([]any, bool) {
+	if ev.opts.Parallelism < 2 {
+		return nil, false
+	}
+	if val, ok := node.([]any); ok {
+		return val, len(val) >= parallelThreshold
+	}
+	arr, ok := AsArray(node)
+	if !ok || arr.Len() < parallelThreshold || leavesOnly(arr) {
+		return nil, false
+	}
+	return slices.Collect(func(yield func(any) bool) {
+		for _, v := range arr.Iterate() {
+			if !yield(v) {
+				return
+			}
+		}
+	}), true
+}
+
+// sorted returns a [sortedObject] for node if ev's options enable
+// SortMembers and node is an object with more than one member, and
+// otherwise returns node, so that segments and selectors that iterate over
+// the members of node do so in ascending order of their names.
+func (ev *evaluation) sorted(node any) any {
+	if !ev.opts.SortMembers {
+		return node
+	}
+	switch node.(type) {
+	case []any, sortedObject:
+		return node
+	}
+	if obj, ok := AsObject(node); ok && obj.Len() > 1 {
+		return newSortedObject(obj)
+	}
+	return node
+}
+
+// parallelize divides n items into contiguous ranges, one for each of up to
+// ev.opts.Parallelism goroutines, and calls work for each range in its own
+// goroutine. Each call to work receives a copy of ev that disables further
+// parallelism. Returns the results of each call in range order. If any call
+// to work panics, parallelize panics with the same value once all
+// goroutines have finished.
+func parallelize[T any](ev *evaluation, n int, work func(ev *evaluation, lo, hi int) []T) [][]T {
+	size := (n + ev.opts.Parallelism - 1) / ev.opts.Parallelism
+	workers := (n + size - 1) / size
+	results := make([][]T, workers)
+	panics := make([]any, workers)
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { panics[w] = recover() }()
+			results[w] = work(ev.serial(), w*size, min((w+1)*size, n))
+		}()
+	}
+	wg.Wait()
+
+	for _, p := range panics {
+		if p != nil {
+			panic(p)
+		}
+	}
+	return results
+}
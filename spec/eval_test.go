@@ -0,0 +1,864 @@
+package spec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluationValue(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ev := &evaluation{}
+	a.Equal([]byte("hi"), ev.value([]byte("hi")))
+	a.Equal("hi", ev.value("hi"))
+	list := []any{[]byte("hi"), 1}
+	a.Equal(NodesType(list), ev.nodes(list))
+
+	ev = &evaluation{opts: Options{BytesAsStrings: true}}
+	a.Equal("hi", ev.value([]byte("hi")))
+	a.Equal(1, ev.value(1))
+	a.Equal(NodesType{"hi", 1}, ev.nodes(list))
+	a.Equal([]any{[]byte("hi"), 1}, list, "should not modify list")
+}
+
+func TestSelectWith(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := []any{[]byte("x"), "x", []byte("y")}
+	q := Query(true, Child(Filter(And(Comparison(
+		SingularQuery(false),
+		EqualTo,
+		Literal("x"),
+	)))))
+
+	a.Equal([]any{"x"}, q.Select(nil, input))
+	a.Equal([]any{"x"}, q.SelectWith(nil, input, Options{}))
+	a.Equal([]any{[]byte("x"), "x"}, q.SelectWith(nil, input, Options{BytesAsStrings: true}))
+
+	a.Equal(
+		[]*LocatedNode{{Path: Normalized(Index(1)), Node: "x"}},
+		q.SelectLocated(nil, input, Normalized()),
+	)
+	a.Equal(
+		[]*LocatedNode{
+			{Path: Normalized(Index(0)), Node: []byte("x")},
+			{Path: Normalized(Index(1)), Node: "x"},
+		},
+		q.SelectLocatedWith(nil, input, Normalized(), Options{BytesAsStrings: true}),
+	)
+}
+
+func TestTrySelect(t *testing.T) {
+	t.Parallel()
+
+	// 1 + 10 + 100 + 1000 nodes.
+	input := make([]any, 10)
+	for i := range input {
+		mid := make([]any, 10)
+		for j := range mid {
+			mid[j] = make([]any, 10)
+		}
+		input[i] = mid
+	}
+	wide := make([]any, parallelThreshold*2)
+	for i := range wide {
+		wide[i] = []any{i}
+	}
+	q := Query(true, Descendant(Wildcard()), Descendant(Wildcard()))
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		input any
+		opts  Options
+		err   string
+	}{
+		{
+			test:  "no_budget",
+			input: input,
+		},
+		{
+			test:  "within_max_nodes",
+			input: input,
+			opts:  Options{MaxNodes: 10_000},
+		},
+		{
+			test:  "within_timeout",
+			input: input,
+			opts:  Options{Timeout: time.Hour},
+		},
+		{
+			test:  "exceed_max_nodes",
+			input: input,
+			opts:  Options{MaxNodes: 100},
+			err:   "evaluation budget exceeded: visited more than 100 nodes",
+		},
+		{
+			test:  "exceed_timeout",
+			input: input,
+			opts:  Options{Timeout: time.Nanosecond},
+			err:   "evaluation budget exceeded: took longer than 1ns",
+		},
+		{
+			test:  "exceed_in_parallel",
+			input: wide,
+			opts:  Options{MaxNodes: parallelThreshold, Parallelism: 4},
+			err:   "evaluation budget exceeded: visited more than 1024 nodes",
+		},
+		{
+			test: "exceed_in_filter",
+			query: Query(true, Child(Filter(And(Existence(
+				Query(false, Descendant(Name("x"))),
+			))))),
+			input: input,
+			opts:  Options{MaxNodes: 50},
+			err:   "evaluation budget exceeded: visited more than 50 nodes",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			q := q
+			if tc.query != nil {
+				q = tc.query
+			}
+
+			res, err := q.TrySelect(nil, tc.input, tc.opts)
+			located, lerr := q.TrySelectLocated(nil, tc.input, Normalized(), tc.opts)
+			if tc.err == "" {
+				a.NoError(err)
+				a.NoError(lerr)
+				a.Equal(q.Select(nil, tc.input), res)
+				a.Equal(q.SelectLocated(nil, tc.input, Normalized()), located)
+				return
+			}
+
+			a.ErrorIs(err, ErrBudgetExceeded)
+			a.ErrorIs(err, ErrLimitExceeded)
+			a.EqualError(err, tc.err)
+			a.Nil(res)
+			a.ErrorIs(lerr, ErrBudgetExceeded)
+			a.EqualError(lerr, tc.err)
+			a.Nil(located)
+
+			// Methods that cannot return an error return no values.
+			a.Nil(q.SelectWith(nil, tc.input, tc.opts))
+			a.Nil(q.SelectLocatedWith(nil, tc.input, Normalized(), tc.opts))
+			a.Nil(q.Compile().SelectWith(nil, tc.input, tc.opts))
+			a.Nil(NewQuerySet(q).SelectWith(nil, tc.input, tc.opts))
+			a.Nil(NewQuerySet(q).SelectLocatedWith(nil, tc.input, Normalized(), tc.opts))
+			var scratch Scratch
+			a.Nil(scratch.Select(q, nil, tc.input, tc.opts))
+
+			// And log the error.
+			var buf bytes.Buffer
+			opts := tc.opts
+			opts.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+			a.Nil(q.SelectWith(nil, tc.input, opts))
+			a.Contains(buf.String(), `msg="jsonpath: evaluation aborted" error="`+tc.err+`"`)
+		})
+	}
+
+	// Should re-panic with anything else.
+	a := assert.New(t)
+	a.PanicsWithValue("boom", func() {
+		var err error
+		defer catchAbort(&err)
+		panic("boom")
+	})
+	a.PanicsWithValue("boom", func() {
+		defer (&evaluation{}).dropAbort()
+		panic("boom")
+	})
+}
+
+func TestMaxDocumentDepth(t *testing.T) {
+	t.Parallel()
+
+	// 100 levels deep.
+	deep := any("x")
+	for range 100 {
+		deep = []any{map[string]any{"a": deep}}
+	}
+	wide := make([]any, parallelThreshold*2)
+	for i := range wide {
+		wide[i] = []any{[]any{i}}
+	}
+	desc := Query(true, Descendant(Wildcard()))
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		input any
+		opts  Options
+		err   bool
+	}{
+		{
+			test:  "no_limit",
+			query: desc,
+			input: deep,
+		},
+		{
+			test:  "within_limit",
+			query: desc,
+			input: deep,
+			opts:  Options{MaxDocumentDepth: 199},
+		},
+		{
+			test:  "exceed_limit",
+			query: desc,
+			input: deep,
+			opts:  Options{MaxDocumentDepth: 198},
+			err:   true,
+		},
+		{
+			test:  "max_depth_first",
+			query: desc,
+			input: deep,
+			opts:  Options{MaxDocumentDepth: 10, MaxDepth: 10},
+		},
+		{
+			test:  "child_segments",
+			query: Query(true, Child(Index(0)), Child(Name("a")), Child(Index(0)), Child(Name("a"))),
+			input: deep,
+			opts:  Options{MaxDocumentDepth: 1},
+		},
+		{
+			test:  "relative_to_segment",
+			query: Query(true, Child(Index(0)), Child(Name("a")), Descendant(Name("b"))),
+			input: deep,
+			opts:  Options{MaxDocumentDepth: 197},
+		},
+		{
+			test:  "exceed_in_filter",
+			query: Query(true, Child(Filter(And(Existence(Query(false, Descendant(Name("x")))))))),
+			input: deep,
+			opts:  Options{MaxDocumentDepth: 5},
+			err:   true,
+		},
+		{
+			test:  "exceed_in_parallel",
+			query: desc,
+			input: wide,
+			opts:  Options{MaxDocumentDepth: 1, Parallelism: 4},
+			err:   true,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			q := tc.query
+
+			res, err := q.TrySelect(nil, tc.input, tc.opts)
+			located, lerr := q.TrySelectLocated(nil, tc.input, Normalized(), tc.opts)
+			if !tc.err {
+				a.NoError(err)
+				a.NoError(lerr)
+				a.Len(located, len(res))
+				return
+			}
+
+			msg := fmt.Sprintf("document depth exceeded: descended more than %d levels", tc.opts.MaxDocumentDepth)
+			a.ErrorIs(err, ErrDepthExceeded)
+			a.ErrorIs(err, ErrLimitExceeded)
+			a.NotErrorIs(err, ErrBudgetExceeded)
+			a.EqualError(err, msg)
+			a.Nil(res)
+			a.ErrorIs(lerr, ErrDepthExceeded)
+			a.EqualError(lerr, msg)
+			a.Nil(located)
+
+			// Methods that cannot return an error return no values.
+			a.Nil(q.SelectWith(nil, tc.input, tc.opts))
+			a.Nil(q.Compile().SelectWith(nil, tc.input, tc.opts))
+		})
+	}
+}
+
+// cycleNode is a tree node with a pointer to its parent, for testing
+// cycle detection.
+type cycleNode struct {
+	Name   string       `json:"name"`
+	Parent *cycleNode   `json:"parent"`
+	Kids   []*cycleNode `json:"kids"`
+}
+
+func TestCycles(t *testing.T) {
+	t.Parallel()
+
+	tree := &cycleNode{Name: "root"}
+	tree.Kids = []*cycleNode{{Name: "a", Parent: tree}, {Name: "b", Parent: tree}}
+	self := map[string]any{"x": 1}
+	self["self"] = self
+	list := []any{1, nil}
+	list[1] = list
+	wide := make([]any, parallelThreshold*2)
+	for i := range wide {
+		wide[i] = self
+	}
+
+	// Deeper than cycleCheckDepth, sharing but never containing itself.
+	shared := &cycleNode{Name: "leaf"}
+	deep := any(map[string]any{"a": shared, "b": shared})
+	for range cycleCheckDepth + 10 {
+		deep = []any{deep}
+	}
+
+	desc := Query(true, Descendant(Name("name")))
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		input any
+		opts  Options
+		exp   int
+		err   string
+	}{
+		{
+			test:  "struct_parent",
+			query: desc,
+			input: tree,
+			err:   "cycle detected: []*spec.cycleNode contains itself",
+		},
+		{
+			test:  "self_map",
+			query: Query(true, Descendant(Wildcard())),
+			input: self,
+			err:   "cycle detected: map[string]interface {} contains itself",
+		},
+		{
+			test:  "self_slice",
+			query: Query(true, Descendant(Wildcard())),
+			input: list,
+			err:   "cycle detected: []interface {} contains itself",
+		},
+		{
+			test:  "in_filter",
+			query: Query(true, Child(Filter(And(Existence(Query(false, Descendant(Name("nope")))))))),
+			input: []any{tree},
+			err:   "cycle detected: []*spec.cycleNode contains itself",
+		},
+		{
+			test:  "in_parallel",
+			query: Query(true, Descendant(Name("nope"))),
+			input: wide,
+			opts:  Options{Parallelism: 4},
+			err:   "cycle detected: map[string]interface {} contains itself",
+		},
+		{
+			test:  "max_depth",
+			query: desc,
+			input: tree,
+			opts:  Options{MaxDepth: 4},
+			exp:   5,
+		},
+		{
+			test:  "deep_shared",
+			query: desc,
+			input: deep,
+			exp:   2,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			q := tc.query
+
+			res, err := q.TrySelect(nil, tc.input, tc.opts)
+			located, lerr := q.TrySelectLocated(nil, tc.input, Normalized(), tc.opts)
+			if tc.err == "" {
+				a.NoError(err)
+				a.NoError(lerr)
+				a.Len(res, tc.exp)
+				a.Len(located, tc.exp)
+				return
+			}
+
+			a.ErrorIs(err, ErrCycle)
+			a.NotErrorIs(err, ErrLimitExceeded)
+			a.EqualError(err, tc.err)
+			a.Nil(res)
+			a.ErrorIs(lerr, ErrCycle)
+			a.EqualError(lerr, tc.err)
+			a.Nil(located)
+
+			// Methods that cannot return an error return no values.
+			a.Nil(q.Select(nil, tc.input))
+			a.Nil(q.SelectLocated(nil, tc.input, Normalized()))
+			a.Nil(q.SelectWith(nil, tc.input, tc.opts))
+			a.Nil(q.Compile().Select(nil, tc.input))
+			a.Nil(q.Compile().SelectWith(nil, tc.input, tc.opts))
+			a.Nil(q.Explain(nil, tc.input, tc.opts))
+		})
+	}
+}
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	warner := Extension(
+		"warner", FuncLogical, nil,
+		func([]PathValue) PathValue { return WithWarning(LogicalFalse, "oops", "reason", "test") },
+	)
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		input any
+		opts  Options
+		exp   []any
+		log   string
+	}{
+		{
+			test: "function_warning",
+			query: Query(true, Child(Filter(And(
+				Function(warner, SingularQuery(false)),
+			)))),
+			input: []any{1},
+			exp:   []any{},
+			log:   "level=WARN msg=oops function=warner reason=test\n",
+		},
+		{
+			test: "comparison_mismatch",
+			query: Query(true, Child(Filter(And(
+				Comparison(SingularQuery(false), LessThan, Literal(int64(3))),
+			)))),
+			input: []any{1, "x"},
+			exp:   []any{1},
+			log:   `level=WARN msg="jsonpath: comparison of values of different types" expr="@ < 3" left=string right=int64` + "\n",
+		},
+		{
+			test: "comparison_missing",
+			query: Query(true, Child(Filter(And(
+				Comparison(SingularQuery(false, Name("x")), GreaterThan, Literal(int64(3))),
+			)))),
+			input: []any{map[string]any{"x": 4}, map[string]any{}},
+			exp:   []any{map[string]any{"x": 4}},
+		},
+		{
+			test: "comparison_equal",
+			query: Query(true, Child(Filter(And(
+				Comparison(SingularQuery(false), EqualTo, Literal(int64(1))),
+			)))),
+			input: []any{1, "x"},
+			exp:   []any{1},
+		},
+		{
+			test:  "max_depth",
+			query: Query(true, Descendant(Name("x"))),
+			input: map[string]any{"a": map[string]any{"b": map[string]any{"x": 1}}},
+			opts:  Options{MaxDepth: 1},
+			exp:   []any{},
+			log:   `level=WARN msg="jsonpath: descendant segment stopped at maximum depth" max_depth=1 depth=1` + "\n",
+		},
+		{
+			test:  "max_depth_once",
+			query: Query(true, Descendant(Name("x"))),
+			input: []any{[]any{[]any{1}}, []any{[]any{2}}},
+			opts:  Options{MaxDepth: 1},
+			exp:   []any{},
+			log:   `level=WARN msg="jsonpath: descendant segment stopped at maximum depth" max_depth=1 depth=1` + "\n",
+		},
+		{
+			test:  "max_depth_scalars",
+			query: Query(true, Descendant(Name("x"))),
+			input: map[string]any{"a": map[string]any{"x": 1}},
+			opts:  Options{MaxDepth: 1},
+			exp:   []any{1},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			// Should select the same values with and without a logger.
+			a.Equal(tc.exp, tc.query.SelectWith(nil, tc.input, tc.opts))
+
+			var buf bytes.Buffer
+			tc.opts.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+					if attr.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return attr
+				},
+			}))
+			a.Equal(tc.exp, tc.query.SelectWith(nil, tc.input, tc.opts))
+			a.Equal(tc.log, buf.String())
+		})
+	}
+}
+
+func TestStrict(t *testing.T) {
+	t.Parallel()
+
+	warner := Extension(
+		"warner", FuncLogical, nil,
+		func([]PathValue) PathValue { return WithWarning(LogicalFalse, "oops", "reason", "a test") },
+	)
+	wide := make([]any, parallelThreshold*2)
+	for i := range wide {
+		wide[i] = []any{map[string]any{"v": i}}
+	}
+	wide[parallelThreshold+5] = []any{map[string]any{"v": "x"}}
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		input any
+		opts  Options
+		exp   []any
+		err   string
+	}{
+		{
+			test: "function_warning",
+			query: Query(true, Child(Filter(And(
+				Function(warner, SingularQuery(false)),
+			)))),
+			input: []any{1},
+			err:   `oops: function=warner reason="a test"`,
+		},
+		{
+			test: "comparison_mismatch",
+			query: Query(true, Child(Filter(And(
+				Comparison(SingularQuery(false), LessThan, Literal(int64(3))),
+			)))),
+			input: []any{1, "x"},
+			err:   `jsonpath: comparison of values of different types: expr="@ < 3" left=string right=int64`,
+		},
+		{
+			test: "comparison_mismatch_parallel",
+			query: Query(true, Descendant(Filter(And(
+				Comparison(SingularQuery(false, Name("v")), GreaterThanEqualTo, Literal(int64(0))),
+			)))),
+			input: wide,
+			opts:  Options{Parallelism: 4},
+			err:   `jsonpath: comparison of values of different types: expr="@[\"v\"] >= 0" left=string right=int64`,
+		},
+		{
+			test: "comparison_missing",
+			query: Query(true, Child(Filter(And(
+				Comparison(SingularQuery(false, Name("x")), GreaterThan, Literal(int64(3))),
+			)))),
+			input: []any{map[string]any{"x": 4}, map[string]any{}},
+			exp:   []any{map[string]any{"x": 4}},
+		},
+		{
+			test: "comparison_equal",
+			query: Query(true, Child(Filter(And(
+				Comparison(SingularQuery(false), EqualTo, Literal(int64(1))),
+			)))),
+			input: []any{1, "x"},
+			exp:   []any{1},
+		},
+		{
+			test:  "max_depth",
+			query: Query(true, Descendant(Name("x"))),
+			input: map[string]any{"a": map[string]any{"b": map[string]any{"x": 1}}},
+			opts:  Options{MaxDepth: 1},
+			exp:   []any{},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			// Should select without error when not strict.
+			res, err := tc.query.TrySelect(nil, tc.input, tc.opts)
+			a.NoError(err)
+			if tc.exp != nil {
+				a.Equal(tc.exp, res)
+			}
+
+			tc.opts.Strict = true
+			res, err = tc.query.TrySelect(nil, tc.input, tc.opts)
+			located, lerr := tc.query.TrySelectLocated(nil, tc.input, Normalized(), tc.opts)
+			if tc.err == "" {
+				a.NoError(err)
+				a.NoError(lerr)
+				a.Equal(tc.exp, res)
+				return
+			}
+
+			a.ErrorIs(err, ErrEvaluation)
+			a.NotErrorIs(err, ErrBudgetExceeded)
+			a.EqualError(err, tc.err)
+			a.Nil(res)
+			a.ErrorIs(lerr, ErrEvaluation)
+			a.EqualError(lerr, tc.err)
+			a.Nil(located)
+
+			// Methods that cannot return an error return no values.
+			a.Nil(tc.query.SelectWith(nil, tc.input, tc.opts))
+			a.Nil(tc.query.SelectLocatedWith(nil, tc.input, Normalized(), tc.opts))
+		})
+	}
+}
+
+func TestSortMembers(t *testing.T) {
+	t.Parallel()
+
+	type record struct {
+		Zed   int `json:"zed"`
+		Alpha int `json:"alpha"`
+	}
+	input := map[string]any{
+		"e": 5, "b": 2, "d": 4, "a": 1, "c": 3, "f": "x",
+		"nest": map[string]any{
+			"y": map[string]any{"q": 1, "p": 2},
+			"x": []any{map[string]any{"n": 3, "m": 4}},
+		},
+		"rec": record{Zed: 1, Alpha: 2},
+	}
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		exp   []string
+	}{
+		{
+			test:  "wildcard",
+			query: Query(true, Child(Name("nest")), Child(Wildcard()), Child(Wildcard())),
+			exp:   []string{`$['nest']['x'][0]`, `$['nest']['y']['p']`, `$['nest']['y']['q']`},
+		},
+		{
+			test:  "struct",
+			query: Query(true, Child(Name("rec")), Child(Wildcard())),
+			exp:   []string{`$['rec']['alpha']`, `$['rec']['zed']`},
+		},
+		{
+			test:  "names",
+			query: Query(true, Child(Name("e"), Name("b"), Name("d"), Name("a"))),
+			exp:   []string{`$['e']`, `$['b']`, `$['d']`, `$['a']`},
+		},
+		{
+			test: "filter",
+			query: Query(true, Child(Filter(And(
+				Comparison(SingularQuery(false), GreaterThan, Literal(int64(2))),
+			)))),
+			exp: []string{`$['c']`, `$['d']`, `$['e']`},
+		},
+		{
+			test:  "descendant",
+			query: Query(true, Child(Name("nest")), Descendant(Wildcard())),
+			exp: []string{
+				`$['nest']['x']`, `$['nest']['y']`,
+				`$['nest']['x'][0]`, `$['nest']['x'][0]['m']`, `$['nest']['x'][0]['n']`,
+				`$['nest']['y']['p']`, `$['nest']['y']['q']`,
+			},
+		},
+		{
+			test:  "descendant_names",
+			query: Query(true, Descendant(Name("p"), Name("n"), Name("m"), Name("q"))),
+			exp: []string{
+				`$['nest']['x'][0]['n']`, `$['nest']['x'][0]['m']`,
+				`$['nest']['y']['p']`, `$['nest']['y']['q']`,
+			},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			opts := Options{SortMembers: true}
+
+			// Map order is random, so repeat to catch unsorted results.
+			for range 10 {
+				located := tc.query.SelectLocatedWith(nil, input, Normalized(), opts)
+				paths := make([]string, len(located))
+				vals := make([]any, len(located))
+				for i, n := range located {
+					paths[i] = n.Path.String()
+					vals[i] = n.Node
+				}
+				a.Equal(tc.exp, paths)
+				a.Equal(vals, tc.query.SelectWith(nil, input, opts))
+				a.Equal(vals, tc.query.Compile().SelectWith(nil, input, opts))
+
+				var seq []any
+				for n, err := range tc.query.SelectLocatedSeq(context.Background(), nil, input, Normalized(), opts) {
+					a.NoError(err)
+					seq = append(seq, n.Node)
+				}
+				a.Equal(vals, seq)
+			}
+		})
+	}
+}
+
+func TestUniqueLocations(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"a": []any{map[string]any{"x": 1}, map[string]any{"x": 2}},
+		"b": map[string]any{"x": 3},
+	}
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		dupes []string
+		exp   []string
+	}{
+		{
+			test:  "union",
+			query: Query(true, Child(Name("b"), Name("a"), Name("b"))),
+			dupes: []string{`$['b']`, `$['a']`, `$['b']`},
+			exp:   []string{`$['b']`, `$['a']`},
+		},
+		{
+			test:  "index_and_slice",
+			query: Query(true, Child(Name("a")), Child(Index(1), Slice(), Index(-1))),
+			dupes: []string{`$['a'][1]`, `$['a'][0]`, `$['a'][1]`, `$['a'][1]`},
+			exp:   []string{`$['a'][1]`, `$['a'][0]`},
+		},
+		{
+			test: "filter_and_index",
+			query: Query(true, Child(Name("a")), Child(
+				Filter(And(Existence(Query(false, Child(Name("x")))))), Index(1),
+			)),
+			dupes: []string{`$['a'][0]`, `$['a'][1]`, `$['a'][1]`},
+			exp:   []string{`$['a'][0]`, `$['a'][1]`},
+		},
+		{
+			test:  "nested_descendants",
+			query: Query(true, Descendant(Wildcard()), Descendant(Name("x"))),
+			dupes: []string{
+				`$['a'][0]['x']`, `$['a'][1]['x']`, `$['b']['x']`,
+				`$['a'][0]['x']`, `$['a'][1]['x']`,
+			},
+			exp: []string{`$['a'][0]['x']`, `$['a'][1]['x']`, `$['b']['x']`},
+		},
+		{
+			test:  "no_dupes",
+			query: Query(true, Child(Name("b")), Child(Name("x"))),
+			dupes: []string{`$['b']['x']`},
+			exp:   []string{`$['b']['x']`},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			opts := Options{SortMembers: true}
+
+			paths := func(nodes []*LocatedNode) []string {
+				res := make([]string, len(nodes))
+				for i, n := range nodes {
+					res[i] = n.Path.String()
+				}
+				return res
+			}
+
+			// Duplicates by default.
+			located := tc.query.SelectLocatedWith(nil, input, Normalized(), opts)
+			a.Equal(tc.dupes, paths(located))
+			a.Len(tc.query.SelectWith(nil, input, opts), len(tc.dupes))
+
+			// Unique locations.
+			opts.UniqueLocations = true
+			located = tc.query.SelectLocatedWith(nil, input, Normalized(), opts)
+			a.Equal(tc.exp, paths(located))
+			vals := make([]any, len(located))
+			for i, n := range located {
+				vals[i] = n.Node
+			}
+			a.Equal(vals, tc.query.SelectWith(nil, input, opts))
+			a.Equal(vals, tc.query.Compile().SelectWith(nil, input, opts))
+			var scratch Scratch
+			a.Equal(vals, scratch.Select(tc.query, nil, input, opts))
+
+			var seq []*LocatedNode
+			for n, err := range tc.query.SelectLocatedSeq(context.Background(), nil, input, Normalized(), opts) {
+				a.NoError(err)
+				seq = append(seq, n)
+			}
+			a.Equal(tc.exp, paths(seq))
+		})
+	}
+}
+
+func TestScratch(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{
+		"a": []any{1, 2, map[string]any{"b": 3, "c": []any{4}}},
+		"x": "y",
+	}
+	var s Scratch
+	for _, q := range []*PathQuery{
+		Query(true),
+		Query(true, Child(Name("a"))),
+		Query(true, Child(Name("a")), Child(Wildcard())),
+		Query(true, Child(Name("nope"))),
+		Query(true, Descendant(Wildcard())),
+		Query(true, Descendant(Name("b"), Index(0))),
+		Query(true, Child(Name("a")), Child(Filter(And(
+			Comparison(SingularQuery(false), GreaterThan, Literal(1)),
+		)))),
+		Query(false, Child(Name("x"))),
+	} {
+		exp := q.SelectWith(input, input, Options{})
+		a.ElementsMatch(exp, s.Select(q, input, input, Options{}), q.String())
+		res, err := s.TrySelect(q, input, input, Options{})
+		a.NoError(err)
+		a.ElementsMatch(exp, res, q.String())
+	}
+
+	// Should return errors.
+	q := Query(true, Descendant(Wildcard()))
+	res, err := s.TrySelect(q, nil, input, Options{MaxNodes: 2})
+	a.ErrorIs(err, ErrBudgetExceeded)
+	a.Nil(res)
+
+	// Should recover after an error.
+	a.Len(s.Select(q, nil, input, Options{}), 8)
+
+	// Reset should clear the buffers.
+	s.Reset()
+	a.Empty(s.res)
+	for _, v := range s.res[:cap(s.res)] {
+		a.Nil(v)
+	}
+	for _, v := range s.buf[:cap(s.buf)] {
+		a.Nil(v)
+	}
+	a.Empty(s.ev.stack)
+	a.Nil(s.ev.root)
+
+	// Reset should discard large buffers.
+	big := make([]any, maxScratchBuffer+1)
+	a.Len(s.Select(Query(true, Child(Wildcard())), nil, big, Options{}), maxScratchBuffer+1)
+	s.Reset()
+	a.Nil(s.res)
+	a.NotNil(s.buf)
+}
+
+//nolint:paralleltest // AllocsPerRun panics in parallel tests.
+func TestScratchAllocs(t *testing.T) {
+	a := assert.New(t)
+
+	input := map[string]any{
+		"store": map[string]any{
+			"book": []any{
+				map[string]any{"author": "Nigel Rees", "price": 8.95},
+				map[string]any{"author": "Evelyn Waugh", "price": 12.99},
+			},
+			"bicycle": map[string]any{"color": "red", "price": 399},
+		},
+	}
+	var s Scratch
+	for _, q := range []*PathQuery{
+		Query(true, Child(Name("store")), Child(Name("book")), Child(Wildcard()), Child(Name("author"))),
+		Query(true, Descendant(Name("price"))),
+		Query(true, Child(Name("store")), Child(Name("book")), Child(Slice(0, 1), Index(-1))),
+	} {
+		a.Zero(testing.AllocsPerRun(100, func() { s.Select(q, nil, input, Options{}) }), q.String())
+	}
+}
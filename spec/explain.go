@@ -0,0 +1,217 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Trace describes the evaluation of a [PathQuery] by [PathQuery.Explain]:
+// the nodes each segment considered, the nodes each of its selectors
+// selected, and the result of each filter expression for each node it
+// tested. Use it to learn why a query selects the nodes it does, or why it
+// fails to select a node.
+type Trace struct {
+	// Query is the query evaluated.
+	Query string `json:"query"`
+	// Segments traces the evaluation of each segment of Query, in order.
+	Segments []*SegmentTrace `json:"segments"`
+	// Result contains the nodes Query selected.
+	Result []*LocatedNode `json:"result"`
+}
+
+// SegmentTrace describes the evaluation of a [Segment] in a [Trace].
+type SegmentTrace struct {
+	// Segment is the segment evaluated.
+	Segment string `json:"segment"`
+	// Considered contains the nodes to which the segment applied its
+	// selectors: the nodes selected by the previous segment, or the root
+	// node for the first segment, and, for a descendant segment, their
+	// descendant arrays and objects. It omits descendant scalars, from
+	// which selectors select nothing.
+	Considered []*LocatedNode `json:"considered"`
+	// Selectors traces the evaluation of each of the segment's selectors,
+	// in order.
+	Selectors []*SelectorTrace `json:"selectors"`
+	// Result contains the nodes the segment selected, in the order selected.
+	Result []*LocatedNode `json:"result"`
+}
+
+// SelectorTrace describes the evaluation of a [Selector] in a
+// [SegmentTrace].
+type SelectorTrace struct {
+	// Selector is the selector evaluated.
+	Selector string `json:"selector"`
+	// Matched contains the nodes the selector selected from all of the
+	// nodes considered by its segment.
+	Matched []*LocatedNode `json:"matched"`
+	// Filters traces the evaluation of a filter selector for each child of
+	// the nodes considered by its segment. Nil for other selectors.
+	Filters []*FilterTrace `json:"filters,omitempty"`
+}
+
+// FilterTrace describes the evaluation of a [FilterSelector] for a single
+// node in a [SelectorTrace].
+type FilterTrace struct {
+	// Node is the node tested, the current node (@) of the filter.
+	Node *LocatedNode `json:"node"`
+	// Result is true if the filter selected Node.
+	Result bool `json:"result"`
+	// Exprs contains the result of each operand of the filter's logical
+	// operators for Node, in order. Unlike the filter itself, Explain
+	// evaluates every operand, even when the result of an operator does not
+	// depend on it.
+	Exprs []*ExprTrace `json:"exprs"`
+}
+
+// ExprTrace describes the result of a filter expression in a
+// [FilterTrace].
+type ExprTrace struct {
+	// Expr is the expression evaluated.
+	Expr string `json:"expr"`
+	// Result is the result of the expression.
+	Result bool `json:"result"`
+}
+
+// Explain evaluates q against current and root, as configured by opts, and
+// returns a [Trace] that describes the evaluation of each of its segments,
+// selectors, and filter expressions. The Result of the trace contains the
+// same nodes as [PathQuery.SelectLocatedWith]. Explain evaluates the
+// segments of q serially and without the limits of opts.MaxNodes,
+// opts.Timeout, and opts.MaxDocumentDepth, so use it only to diagnose
+// queries against small inputs. It logs soft failures to opts.Logger, if
+// set, rather than abort in opts.Strict mode. Returns nil if a descendant
+// segment finds a value that contains itself, as described for [ErrCycle].
+func (q *PathQuery) Explain(current, root any, opts Options) *Trace {
+	opts.Parallelism, opts.MaxNodes, opts.Timeout = 0, 0, 0
+	opts.MaxDocumentDepth, opts.Strict = 0, false
+	ev := &evaluation{root: root, opts: opts}
+	defer ev.dropAbort()
+	if q.root {
+		current = root
+	}
+
+	trace := &Trace{
+		Query:    q.String(),
+		Segments: make([]*SegmentTrace, len(q.segments)),
+	}
+	nodes := []*LocatedNode{newLocatedNode(Normalized(), current)}
+	for i, seg := range q.segments {
+		trace.Segments[i] = seg.explain(nodes, ev)
+		nodes = trace.Segments[i].Result
+	}
+	trace.Result = nodes
+	return trace
+}
+
+// explain evaluates s against inputs with ev and returns a [SegmentTrace]
+// describing the evaluation.
+func (s *Segment) explain(inputs []*LocatedNode, ev *evaluation) *SegmentTrace {
+	st := &SegmentTrace{
+		Segment:    s.String(),
+		Considered: inputs,
+		Selectors:  make([]*SelectorTrace, len(s.selectors)),
+		Result:     []*LocatedNode{},
+	}
+	for i, sel := range s.selectors {
+		st.Selectors[i] = &SelectorTrace{Selector: selectorString(sel), Matched: []*LocatedNode{}}
+		if _, ok := sel.(*FilterSelector); ok {
+			st.Selectors[i].Filters = []*FilterTrace{}
+		}
+	}
+
+	if s.descendant {
+		st.Considered = []*LocatedNode{}
+		for _, in := range inputs {
+			st.Considered = descendLocated(
+				st.Considered, in.Node, 0, ev, in.Path,
+				func(dst []*LocatedNode, node any, _ *evaluation, path NormalizedPath) []*LocatedNode {
+					return append(dst, newLocatedNode(path, node))
+				},
+			)
+		}
+	}
+
+	for _, node := range st.Considered {
+		for i, sel := range s.selectors {
+			var matched []*LocatedNode
+			if f, ok := sel.(*FilterSelector); ok {
+				matched = f.explain(st.Selectors[i], node, ev)
+			} else {
+				matched = sel.SelectLocated(node.Node, ev.root, node.Path)
+			}
+			st.Selectors[i].Matched = append(st.Selectors[i].Matched, matched...)
+			st.Result = append(st.Result, matched...)
+		}
+	}
+	return st
+}
+
+// explain evaluates f against the children of node with ev, appending a
+// [FilterTrace] for each child to st.Filters, and returns the children f
+// selects.
+func (f *FilterSelector) explain(st *SelectorTrace, node *LocatedNode, ev *evaluation) []*LocatedNode {
+	var matched []*LocatedNode
+	for _, child := range Wildcard().SelectLocated(node.Node, ev.root, node.Path) {
+		ft := &FilterTrace{Node: child, Exprs: []*ExprTrace{}}
+		for _, and := range f.LogicalOr {
+			for _, expr := range and {
+				var buf strings.Builder
+				expr.writeTo(&buf)
+				ft.Exprs = append(ft.Exprs, &ExprTrace{
+					Expr:   buf.String(),
+					Result: expr.testFilter(child.Node, ev),
+				})
+			}
+		}
+		if ft.Result = f.testFilter(child.Node, ev); ft.Result {
+			matched = append(matched, child)
+		}
+		st.Filters = append(st.Filters, ft)
+	}
+	return matched
+}
+
+// selectorString returns the string representation of sel.
+func selectorString(sel Selector) string {
+	var buf strings.Builder
+	sel.writeTo(&buf)
+	return buf.String()
+}
+
+// String returns a human-readable representation of t, listing the nodes
+// considered and matched by each segment and selector, and the result of
+// each filter expression for each node.
+func (t *Trace) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "query %v\n", t.Query)
+	for _, st := range t.Segments {
+		fmt.Fprintf(&buf, "segment %v\n", st.Segment)
+		fmt.Fprintf(&buf, "  considered %v\n", pathList(st.Considered))
+		for _, sel := range st.Selectors {
+			fmt.Fprintf(&buf, "  selector %v matched %v\n", sel.Selector, pathList(sel.Matched))
+			for _, ft := range sel.Filters {
+				fmt.Fprintf(&buf, "    %v: %v\n", ft.Node.Path, ft.Result)
+				if len(ft.Exprs) > 1 {
+					for _, e := range ft.Exprs {
+						fmt.Fprintf(&buf, "      %v: %v\n", e.Expr, e.Result)
+					}
+				}
+			}
+		}
+	}
+	fmt.Fprintf(&buf, "result %v\n", pathList(t.Result))
+	return buf.String()
+}
+
+// pathList returns the normalized paths of nodes as a comma-delimited
+// string, or "nothing" if nodes is empty.
+func pathList(nodes []*LocatedNode) string {
+	if len(nodes) == 0 {
+		return "nothing"
+	}
+	paths := make([]string, len(nodes))
+	for i, n := range nodes {
+		paths[i] = n.Path.String()
+	}
+	return strings.Join(paths, ", ")
+}
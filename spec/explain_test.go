@@ -0,0 +1,152 @@
+package spec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// $.a..[?@.x > 1 && @.y, 0]
+	q := Query(
+		true,
+		Child(Name("a")),
+		Descendant(
+			Filter(And(
+				Comparison(SingularQuery(false, Name("x")), GreaterThan, Literal(int64(1))),
+				Existence(Query(false, Child(Name("y")))),
+			)),
+			Index(0),
+		),
+	)
+	doc := map[string]any{"a": []any{
+		map[string]any{"x": 2},
+		[]any{map[string]any{"x": 3, "y": true}},
+	}}
+	loc := func(node any, path ...NormalSelector) *LocatedNode {
+		return newLocatedNode(Normalized(path...), node)
+	}
+	a0 := doc["a"].([]any)[0]
+	a1 := doc["a"].([]any)[1]
+	a10 := a1.([]any)[0]
+
+	trace := q.Explain(nil, doc, Options{})
+	a.Equal(q.String(), trace.Query)
+	a.Equal(q.SelectLocated(nil, doc, Normalized()), trace.Result)
+	r.Len(trace.Segments, 2)
+
+	a.Equal(&SegmentTrace{
+		Segment:    `["a"]`,
+		Considered: []*LocatedNode{loc(doc)},
+		Selectors: []*SelectorTrace{{
+			Selector: `"a"`,
+			Matched:  []*LocatedNode{loc(doc["a"], Name("a"))},
+		}},
+		Result: []*LocatedNode{loc(doc["a"], Name("a"))},
+	}, trace.Segments[0])
+
+	filter := func(node *LocatedNode, x, y bool) *FilterTrace {
+		return &FilterTrace{Node: node, Result: x && y, Exprs: []*ExprTrace{
+			{Expr: `@["x"] > 1`, Result: x},
+			{Expr: `@["y"]`, Result: y},
+		}}
+	}
+	seg := trace.Segments[1]
+	a.Equal(`..[?@["x"] > 1 && @["y"],0]`, seg.Segment)
+	a.Equal([]*LocatedNode{
+		loc(doc["a"], Name("a")),
+		loc(a0, Name("a"), Index(0)),
+		loc(a1, Name("a"), Index(1)),
+		loc(a10, Name("a"), Index(1), Index(0)),
+	}, seg.Considered)
+	r.Len(seg.Selectors, 2)
+	a.Equal(`?@["x"] > 1 && @["y"]`, seg.Selectors[0].Selector)
+	a.Equal([]*LocatedNode{loc(a10, Name("a"), Index(1), Index(0))}, seg.Selectors[0].Matched)
+	a.Equal([]*FilterTrace{
+		filter(loc(a0, Name("a"), Index(0)), true, false),
+		filter(loc(a1, Name("a"), Index(1)), false, false),
+		filter(loc(2, Name("a"), Index(0), Name("x")), false, false),
+		filter(loc(a10, Name("a"), Index(1), Index(0)), true, true),
+	}, seg.Selectors[0].Filters[:4])
+	a.Equal(&SelectorTrace{
+		Selector: "0",
+		Matched: []*LocatedNode{
+			loc(a0, Name("a"), Index(0)),
+			loc(a10, Name("a"), Index(1), Index(0)),
+		},
+	}, seg.Selectors[1])
+	a.Equal([]*LocatedNode{
+		loc(a0, Name("a"), Index(0)),
+		loc(a10, Name("a"), Index(1), Index(0)),
+		loc(a10, Name("a"), Index(1), Index(0)),
+	}, seg.Result)
+	a.Equal(seg.Result, trace.Result)
+
+	// Should marshal to JSON.
+	data, err := json.Marshal(trace)
+	r.NoError(err)
+	var got map[string]any
+	r.NoError(json.Unmarshal(data, &got))
+	a.Equal(q.String(), got["query"])
+	a.Len(got["segments"], 2)
+}
+
+func TestExplainCurrent(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	q := Query(false, Child(Index(1)))
+	trace := q.Explain([]any{1, 2}, []any{3, 4}, Options{})
+	a.Equal([]*LocatedNode{newLocatedNode(Normalized(Index(1)), 2)}, trace.Result)
+
+	// Empty query.
+	trace = Query(true).Explain(nil, []any{3}, Options{})
+	a.Empty(trace.Segments)
+	a.Equal([]*LocatedNode{newLocatedNode(Normalized(), []any{3})}, trace.Result)
+	a.Equal("query $\nresult $\n", trace.String())
+
+	// MaxDepth limits descendants.
+	q = Query(true, Descendant(Wildcard()))
+	trace = q.Explain(nil, []any{[]any{1}}, Options{MaxDepth: 0, MaxNodes: 1})
+	a.Len(trace.Segments[0].Considered, 2)
+	trace = q.Explain(nil, []any{[]any{1}}, Options{MaxDepth: 1})
+	a.Len(trace.Segments[0].Considered, 2)
+	trace = q.Explain(nil, []any{[]any{[]any{1}}}, Options{MaxDepth: 1})
+	a.Len(trace.Segments[0].Considered, 2)
+	a.Equal(q.SelectLocatedWith(nil, []any{[]any{[]any{1}}}, Normalized(), Options{MaxDepth: 1}), trace.Result)
+}
+
+func TestTraceString(t *testing.T) {
+	t.Parallel()
+
+	q := Query(true, Child(Name("a"), Filter(
+		And(Comparison(SingularQuery(false), EqualTo, Literal(int64(1)))),
+		And(Comparison(SingularQuery(false), EqualTo, Literal(int64(3)))),
+	)), Child(Filter(And(Existence(Query(false))))))
+	doc := []any{1, 2, []any{5}}
+	assert.Equal(t, `query $["a",?@ == 1 || @ == 3][?@]
+segment ["a",?@ == 1 || @ == 3]
+  considered $
+  selector "a" matched nothing
+  selector ?@ == 1 || @ == 3 matched $[0]
+    $[0]: true
+      @ == 1: true
+      @ == 3: false
+    $[1]: false
+      @ == 1: false
+      @ == 3: false
+    $[2]: false
+      @ == 1: false
+      @ == 3: false
+segment [?@]
+  considered $[0]
+  selector ?@ matched nothing
+result nothing
+`, q.Explain(nil, doc, Options{}).String())
+}
@@ -1,7 +1,9 @@
 package spec
 
 import (
+	"iter"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -94,7 +96,7 @@ func TestLogicalAndExpr(t *testing.T) {
 			a := assert.New(t)
 
 			andExpr := LogicalAnd(tc.expr)
-			a.Equal(tc.exp, andExpr.testFilter(tc.current, tc.root))
+			a.Equal(tc.exp, andExpr.testFilter(tc.current, &evaluation{root: tc.root}))
 			a.Equal(tc.str, bufString(andExpr))
 		})
 	}
@@ -179,8 +181,8 @@ func TestLogicalOrExpr(t *testing.T) {
 
 			orExpr := LogicalOr(tc.expr)
 			a.Equal(FuncLogical, orExpr.ResultType())
-			a.Equal(tc.exp, orExpr.testFilter(tc.current, tc.root))
-			a.Equal(Logical(tc.exp), orExpr.evaluate(tc.current, tc.root))
+			a.Equal(tc.exp, orExpr.testFilter(tc.current, &evaluation{root: tc.root}))
+			a.Equal(Logical(tc.exp), orExpr.evaluate(tc.current, &evaluation{root: tc.root}))
 			a.Equal(tc.str, bufString(orExpr))
 			a.True(orExpr.ConvertsTo(FuncLogical))
 			a.False(orExpr.ConvertsTo(FuncValue))
@@ -188,17 +190,47 @@ func TestLogicalOrExpr(t *testing.T) {
 
 			// Test ParenExpr.
 			pExpr := Paren(orExpr...)
-			a.Equal(tc.exp, pExpr.testFilter(tc.current, tc.root))
+			a.Equal(tc.exp, pExpr.testFilter(tc.current, &evaluation{root: tc.root}))
 			a.Equal("("+tc.str+")", bufString(pExpr))
 
 			// Test NotParenExpr.
 			npExpr := NotParen(orExpr...)
-			a.Equal(!tc.exp, npExpr.testFilter(tc.current, tc.root))
+			a.Equal(!tc.exp, npExpr.testFilter(tc.current, &evaluation{root: tc.root}))
 			a.Equal("!("+tc.str+")", bufString(npExpr))
 		})
 	}
 }
 
+// tripwire implements Object and records any attempt to access its
+// members.
+type tripwire struct{ touched *atomic.Bool }
+
+func (tw tripwire) Get(string) (any, bool) { tw.touched.Store(true); return nil, false }
+func (tw tripwire) Len() int               { tw.touched.Store(true); return 0 }
+func (tw tripwire) Iterate() iter.Seq2[string, any] {
+	tw.touched.Store(true)
+	return func(func(string, any) bool) {}
+}
+
+func TestExistExprShortCircuit(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	tw := tripwire{new(atomic.Bool)}
+	input := []any{[]any{map[string]any{"error": 1}, tw}}
+
+	// Filter on the existence of a descendant.
+	q := Query(true, Child(Filter(And(Existence(
+		Query(false, Descendant(Name("error"))),
+	)))))
+	a.Equal([]any{input[0]}, q.Select(nil, input))
+	a.False(tw.touched.Load())
+
+	// Full selection visits the tripwire.
+	a.Equal([]any{1}, Query(true, Descendant(Name("error"))).Select(nil, input))
+	a.True(tw.touched.Load())
+}
+
 func TestExistExpr(t *testing.T) {
 	t.Parallel()
 
@@ -233,21 +265,68 @@ func TestExistExpr(t *testing.T) {
 			root:  map[string]any{"y": 0},
 			exp:   false,
 		},
+		{
+			test:    "descendant",
+			query:   Query(false, Descendant(Name("x"))),
+			current: []any{[]any{1, map[string]any{"x": nil}}},
+			exp:     true,
+		},
+		{
+			test:    "descendant_false",
+			query:   Query(false, Descendant(Name("x"))),
+			current: []any{[]any{1, map[string]any{"y": 0}}},
+			exp:     false,
+		},
+		{
+			test:    "descendant_then_child",
+			query:   Query(false, Descendant(Name("x")), Child(Index(1))),
+			current: []any{map[string]any{"x": []any{1}}, map[string]any{"x": []any{1, 2}}},
+			exp:     true,
+		},
+		{
+			test:    "descendant_then_child_false",
+			query:   Query(false, Descendant(Name("x")), Child(Index(1))),
+			current: []any{map[string]any{"x": []any{1}}, map[string]any{"x": "hi"}},
+			exp:     false,
+		},
+		{
+			test:    "child_then_descendant",
+			query:   Query(false, Child(Wildcard()), Descendant(Index(0))),
+			current: []any{map[string]any{"x": 1}, map[string]any{"y": []any{2}}},
+			exp:     true,
+		},
+		{
+			test: "nested_filter",
+			query: Query(false, Descendant(Filter(And(Existence(
+				Query(false, Descendant(Name("x"))),
+			))))),
+			current: []any{1, []any{2, []any{map[string]any{"x": 3}}}},
+			exp:     true,
+		},
+		{
+			test:    "no_segments",
+			query:   Query(false),
+			current: nil,
+			exp:     true,
+		},
 	} {
 		t.Run(tc.test, func(t *testing.T) {
 			t.Parallel()
 			a := assert.New(t)
+			a.Equal(tc.exp, len(tc.query.Select(tc.current, tc.root)) > 0)
 
 			// Test existExpr.
 			exist := ExistExpr{tc.query}
-			a.Equal(tc.exp, exist.testFilter(tc.current, tc.root))
+			a.Same(tc.query, exist.Query())
+			a.Equal(tc.exp, exist.testFilter(tc.current, &evaluation{root: tc.root}))
 			buf := new(strings.Builder)
 			exist.writeTo(buf)
 			a.Equal(tc.query.String(), buf.String())
 
 			// Test NonExistExpr.
 			ne := NonExistExpr{tc.query}
-			a.Equal(!tc.exp, ne.testFilter(tc.current, tc.root))
+			a.Same(tc.query, ne.Query())
+			a.Equal(!tc.exp, ne.testFilter(tc.current, &evaluation{root: tc.root}))
 			buf.Reset()
 			ne.writeTo(buf)
 			a.Equal("!"+tc.query.String(), buf.String())
@@ -0,0 +1,144 @@
+package spec
+
+// constant identifies the result of folding a filter expression: whether it
+// is always true, always false, or depends on the values it filters.
+type constant uint8
+
+const (
+	notConstant constant = iota
+	alwaysTrue
+	alwaysFalse
+)
+
+// Fold returns a copy of lo with its sub-expressions composed entirely of
+// literals evaluated in advance. Comparisons between literals, such as
+// 1 < 2, fold into true or false, which then fold the && and || operators
+// and parenthesized expressions that contain them. True expressions drop
+// from && operators, false expressions drop from || operators, and an
+// expression that is always true or always false folds into @ or !@,
+// respectively. Thus ?1 < 2 && @.a folds into ?@.a, and ?1 > 2 && @.a
+// folds into ?!@, which selects nothing. Ordering comparisons of literals
+// of different types, such as 1 < "a", do not fold, so that evaluation
+// reports them as soft failures to [Options].Logger or in [Options].Strict
+// mode, nor do ordering comparisons of strings, which evaluation compares
+// with [Options].Collator, if set. Returns lo itself if it contains no such sub-expressions.
+func (lo LogicalOr) Fold() LogicalOr {
+	res, _, _ := foldOr(lo)
+	return res
+}
+
+// foldOr folds the expressions in lo and returns the result, whether it is
+// constant, and whether it differs from lo. Returns lo itself if nothing
+// folds.
+func foldOr(lo LogicalOr) (LogicalOr, constant, bool) {
+	var res LogicalOr
+	for i, and := range lo {
+		folded, c, changed := foldAnd(and)
+		switch {
+		case c == alwaysTrue:
+			return LogicalOr{LogicalAnd{currentExistence()}}, alwaysTrue, true
+		case res == nil && (changed || c == alwaysFalse):
+			res = append(make(LogicalOr, 0, len(lo)), lo[:i]...)
+		}
+		if res != nil && c == notConstant {
+			res = append(res, folded)
+		}
+	}
+
+	switch {
+	case res == nil:
+		return lo, notConstant, false
+	case len(res) == 0:
+		return LogicalOr{LogicalAnd{currentNonexistence()}}, alwaysFalse, true
+	default:
+		return res, notConstant, true
+	}
+}
+
+// foldAnd folds the expressions in la and returns the result, whether it is
+// constant, and whether it differs from la. Returns la itself if nothing
+// folds.
+func foldAnd(la LogicalAnd) (LogicalAnd, constant, bool) {
+	var res LogicalAnd
+	for i, expr := range la {
+		folded, c, changed := foldExpr(expr)
+		switch {
+		case c == alwaysFalse:
+			return LogicalAnd{currentNonexistence()}, alwaysFalse, true
+		case res == nil && (changed || c == alwaysTrue):
+			res = append(make(LogicalAnd, 0, len(la)), la[:i]...)
+		}
+		if res != nil && c == notConstant {
+			res = append(res, folded)
+		}
+	}
+
+	switch {
+	case res == nil:
+		return la, notConstant, false
+	case len(res) == 0:
+		return LogicalAnd{currentExistence()}, alwaysTrue, true
+	default:
+		return res, notConstant, true
+	}
+}
+
+// foldExpr folds expr and returns the result, whether it is constant, and
+// whether it differs from expr. Returns expr itself if nothing folds.
+func foldExpr(expr BasicExpr) (BasicExpr, constant, bool) {
+	switch e := expr.(type) {
+	case *CompExpr:
+		left, leftLit := e.left.(*LiteralArg)
+		right, rightLit := e.right.(*LiteralArg)
+		switch {
+		case !leftLit || !rightLit:
+			return expr, notConstant, false
+		case e.op.isOrdering() && !sameType(left.asValue(nil, nil), right.asValue(nil, nil)):
+			// Leave soft failures for evaluation to report.
+			return expr, notConstant, false
+		case e.op.isOrdering() && isString(left.literal) && isString(right.literal):
+			// Leave strings for evaluation to collate.
+			return expr, notConstant, false
+		case e.testFilter(nil, &evaluation{}):
+			return expr, alwaysTrue, true
+		default:
+			return expr, alwaysFalse, true
+		}
+	case *ParenExpr:
+		folded, c, changed := foldOr(e.LogicalOr)
+		if c != notConstant || !changed {
+			return expr, c, changed
+		}
+		return &ParenExpr{LogicalOr: folded}, notConstant, true
+	case *NotParenExpr:
+		folded, c, changed := foldOr(e.LogicalOr)
+		switch {
+		case c == alwaysTrue:
+			return expr, alwaysFalse, true
+		case c == alwaysFalse:
+			return expr, alwaysTrue, true
+		case !changed:
+			return expr, notConstant, false
+		default:
+			return &NotParenExpr{LogicalOr: folded}, notConstant, true
+		}
+	default:
+		return expr, notConstant, false
+	}
+}
+
+// currentExistence returns @, which is always true.
+func currentExistence() *ExistExpr {
+	return Existence(Query(false))
+}
+
+// currentNonexistence returns !@, which is always false.
+func currentNonexistence() *NonExistExpr {
+	return Nonexistence(Query(false))
+}
+
+// isString returns true if val is a string.
+func isString(val any) bool {
+	_, ok := val.(string)
+	return ok
+}
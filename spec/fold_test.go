@@ -0,0 +1,119 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFold(t *testing.T) {
+	t.Parallel()
+
+	yes := Comparison(Literal(1), LessThan, Literal(2))
+	no := Comparison(Literal("x"), EqualTo, Literal("y"))
+	hasA := Existence(Query(false, Child(Name("a"))))
+	hasB := Existence(Query(false, Child(Name("b"))))
+	aIsOne := Comparison(SingularQuery(false, Name("a")), EqualTo, Literal(1))
+
+	for _, tc := range []struct {
+		test string
+		expr LogicalOr
+		exp  string
+		same bool
+	}{
+		{
+			test: "nothing_to_fold",
+			expr: Or(And(hasA, aIsOne), And(Paren(And(hasB)), NotParen(And(hasA)))),
+			exp:  `@["a"] && @["a"] == 1 || (@["b"]) && !(@["a"])`,
+			same: true,
+		},
+		{
+			test: "true",
+			expr: Or(And(yes)),
+			exp:  `@`,
+		},
+		{
+			test: "false",
+			expr: Or(And(no)),
+			exp:  `!@`,
+		},
+		{
+			test: "drop_true_from_and",
+			expr: Or(And(yes, hasA, yes)),
+			exp:  `@["a"]`,
+		},
+		{
+			test: "false_and",
+			expr: Or(And(hasA, no, hasB)),
+			exp:  `!@`,
+		},
+		{
+			test: "drop_false_from_or",
+			expr: Or(And(hasA), And(no), And(hasB, no), And(hasB)),
+			exp:  `@["a"] || @["b"]`,
+		},
+		{
+			test: "true_or",
+			expr: Or(And(hasA), And(hasB, no), And(yes)),
+			exp:  `@`,
+		},
+		{
+			test: "paren",
+			expr: Or(And(Paren(And(yes, hasA)), Paren(And(no), And(yes)))),
+			exp:  `(@["a"])`,
+		},
+		{
+			test: "not_paren",
+			expr: Or(And(NotParen(And(no), And(hasA)), NotParen(And(no)))),
+			exp:  `!(@["a"])`,
+		},
+		{
+			test: "false_not_paren",
+			expr: Or(And(hasA, NotParen(And(hasB), And(yes)))),
+			exp:  `!@`,
+		},
+		{
+			test: "literal_and_query",
+			expr: Or(And(Comparison(Literal(1), EqualTo, SingularQuery(false, Name("a"))))),
+			exp:  `1 == @["a"]`,
+			same: true,
+		},
+		{
+			test: "ordering_different_types",
+			expr: Or(And(Comparison(Literal(1), LessThan, Literal("a")), yes)),
+			exp:  `1 < "a"`,
+		},
+		{
+			test: "ordering_strings",
+			expr: Or(And(Comparison(Literal("a"), LessThan, Literal("B")), yes)),
+			exp:  `"a" < "B"`,
+		},
+		{
+			test: "equality_different_types",
+			expr: Or(And(Comparison(Literal(1), EqualTo, Literal("a"))), And(hasA)),
+			exp:  `@["a"]`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			folded := tc.expr.Fold()
+			a.Equal(tc.exp, folded.String())
+			if tc.same {
+				a.Equal(&tc.expr[0], &folded[0])
+			}
+
+			// Should filter the same values.
+			for _, v := range []any{
+				nil, 1, "x", map[string]any{"a": 1}, map[string]any{"a": 2, "b": 1},
+				map[string]any{"b": 0}, map[string]any{"c": true},
+			} {
+				a.Equal(tc.expr.testFilter(v, &evaluation{}), folded.testFilter(v, &evaluation{}))
+			}
+
+			// Should be idempotent.
+			a.Equal(&folded[0], &folded.Fold()[0])
+		})
+	}
+}
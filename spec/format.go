@@ -0,0 +1,264 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatOpts configures the formatting of a query by [PathQuery.Format].
+// The zero value formats a query in the same style as [PathQuery.String]:
+// bracket notation, double-quoted strings, and spaces around operators.
+type FormatOpts struct {
+	// Shorthand formats segments that contain a single name or wildcard
+	// selector in dot notation, such as .name, ..name, .*, and ..*, rather
+	// than bracket notation. Names that are not valid member name shorthand,
+	// such as those that start with a digit or contain spaces, retain
+	// bracket notation.
+	Shorthand bool
+
+	// SingleQuotes quotes names and string literals with single quotation
+	// marks rather than double quotation marks.
+	SingleQuotes bool
+
+	// Compact omits the spaces around logical and comparison operators and
+	// after the commas that separate function arguments.
+	Compact bool
+}
+
+// Format returns a string representation of q formatted as configured by
+// opts. Unlike [PathQuery.String], it escapes names and string literals
+// exactly as RFC 9535 requires, so that the result always parses back into
+// an equivalent query. Use it to render queries in a consistent style, such
+// as in configuration files.
+func (q *PathQuery) Format(opts FormatOpts) string {
+	f := formatter{opts: opts}
+	f.query(q)
+	return f.buf.String()
+}
+
+// formatter writes the string representations of queries and expressions as
+// configured by opts.
+type formatter struct {
+	buf  strings.Builder
+	opts FormatOpts
+}
+
+// query writes q to f.
+func (f *formatter) query(q *PathQuery) {
+	if q.root {
+		f.buf.WriteByte('$')
+	} else {
+		f.buf.WriteByte('@')
+	}
+	for _, seg := range q.segments {
+		f.segment(seg.descendant, seg.selectors)
+	}
+}
+
+// singular writes sq to f.
+func (f *formatter) singular(sq *SingularQueryExpr) {
+	if sq.relative {
+		f.buf.WriteByte('@')
+	} else {
+		f.buf.WriteByte('$')
+	}
+	for _, sel := range sq.selectors {
+		f.segment(false, []Selector{sel})
+	}
+}
+
+// segment writes a segment of sels to f, preceded by .. if descendant is
+// true.
+func (f *formatter) segment(descendant bool, sels []Selector) {
+	if descendant {
+		f.buf.WriteString("..")
+	}
+	if f.opts.Shorthand && len(sels) == 1 {
+		switch sel := sels[0].(type) {
+		case Name:
+			if isShorthand(string(sel)) {
+				if !descendant {
+					f.buf.WriteByte('.')
+				}
+				f.buf.WriteString(string(sel))
+				return
+			}
+		case WildcardSelector:
+			if !descendant {
+				f.buf.WriteByte('.')
+			}
+			f.buf.WriteByte('*')
+			return
+		}
+	}
+
+	f.buf.WriteByte('[')
+	for i, sel := range sels {
+		if i > 0 {
+			f.buf.WriteByte(',')
+		}
+		switch sel := sel.(type) {
+		case Name:
+			f.string(string(sel))
+		case *FilterSelector:
+			f.buf.WriteByte('?')
+			f.or(sel.LogicalOr)
+		default:
+			sel.writeTo(&f.buf)
+		}
+	}
+	f.buf.WriteByte(']')
+}
+
+// or writes lo to f.
+func (f *formatter) or(lo LogicalOr) {
+	for i, and := range lo {
+		if i > 0 {
+			f.operator("||")
+		}
+		for j, expr := range and {
+			if j > 0 {
+				f.operator("&&")
+			}
+			f.expr(expr)
+		}
+	}
+}
+
+// expr writes expr to f.
+func (f *formatter) expr(expr BasicExpr) {
+	switch e := expr.(type) {
+	case *ParenExpr:
+		f.buf.WriteByte('(')
+		f.or(e.LogicalOr)
+		f.buf.WriteByte(')')
+	case *NotParenExpr:
+		f.buf.WriteString("!(")
+		f.or(e.LogicalOr)
+		f.buf.WriteByte(')')
+	case *ExistExpr:
+		f.query(e.PathQuery)
+	case *NonExistExpr:
+		f.buf.WriteByte('!')
+		f.query(e.PathQuery)
+	case NonExistExpr:
+		f.buf.WriteByte('!')
+		f.query(e.PathQuery)
+	case *CompExpr:
+		f.value(e.left)
+		f.operator(e.op.String())
+		f.value(e.right)
+	case *FuncExpr:
+		f.function(e)
+	case NotFuncExpr:
+		f.buf.WriteByte('!')
+		f.function(e.FuncExpr)
+	case LogicalOr:
+		f.or(e)
+	case LogicalAnd:
+		f.or(LogicalOr{e})
+	default:
+		expr.writeTo(&f.buf)
+	}
+}
+
+// value writes val, a comparison operand or function argument, to f.
+func (f *formatter) value(val stringWriter) {
+	switch v := val.(type) {
+	case *LiteralArg:
+		if str, ok := v.literal.(string); ok {
+			f.string(str)
+		} else {
+			v.writeTo(&f.buf)
+		}
+	case *SingularQueryExpr:
+		f.singular(v)
+	case *PathQuery:
+		f.query(v)
+	case BasicExpr:
+		f.expr(v)
+	default:
+		val.writeTo(&f.buf)
+	}
+}
+
+// function writes fe to f.
+func (f *formatter) function(fe *FuncExpr) {
+	f.buf.WriteString(fe.fn.Name())
+	f.buf.WriteByte('(')
+	for i, arg := range fe.args {
+		if i > 0 {
+			f.buf.WriteByte(',')
+			if !f.opts.Compact {
+				f.buf.WriteByte(' ')
+			}
+		}
+		f.value(arg)
+	}
+	f.buf.WriteByte(')')
+}
+
+// operator writes op to f, surrounded by spaces unless f.opts.Compact is
+// true.
+func (f *formatter) operator(op string) {
+	if f.opts.Compact {
+		f.buf.WriteString(op)
+		return
+	}
+	f.buf.WriteByte(' ')
+	f.buf.WriteString(op)
+	f.buf.WriteByte(' ')
+}
+
+// string writes str to f as a quoted JSONPath string literal, escaping the
+// quotation mark, backslashes, and control characters.
+func (f *formatter) string(str string) {
+	quote := '"'
+	if f.opts.SingleQuotes {
+		quote = '\''
+	}
+	f.buf.WriteRune(quote)
+	for _, r := range str {
+		switch r {
+		case quote, '\\':
+			f.buf.WriteByte('\\')
+			f.buf.WriteRune(r)
+		case '\b':
+			f.buf.WriteString(`\b`)
+		case '\f':
+			f.buf.WriteString(`\f`)
+		case '\n':
+			f.buf.WriteString(`\n`)
+		case '\r':
+			f.buf.WriteString(`\r`)
+		case '\t':
+			f.buf.WriteString(`\t`)
+		default:
+			if r < ' ' {
+				fmt.Fprintf(&f.buf, `\u%04x`, r)
+				continue
+			}
+			f.buf.WriteRune(r)
+		}
+	}
+	f.buf.WriteRune(quote)
+}
+
+// isShorthand returns true if name is valid [member name shorthand].
+//
+// [member name shorthand]: https://www.rfc-editor.org/rfc/rfc9535#section-2.5.1.1
+func isShorthand(name string) bool {
+	if name == "" || !utf8.ValidString(name) {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 0x80, 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && '0' <= r && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	short := FormatOpts{Shorthand: true}
+	single := FormatOpts{SingleQuotes: true}
+	compact := FormatOpts{Compact: true}
+	all := FormatOpts{Shorthand: true, SingleQuotes: true, Compact: true}
+
+	// ?@.a == "x" && !@.b || __val(@.c, $.d) > 1
+	filter := Filter(
+		And(
+			Comparison(SingularQuery(false, Name("a")), EqualTo, Literal("x")),
+			Nonexistence(Query(false, Child(Name("b")))),
+		),
+		And(Comparison(
+			Function(newValueFunc(1), SingularQuery(false, Name("c")), SingularQuery(true, Name("d"))),
+			GreaterThan,
+			Literal(int64(1)),
+		)),
+	)
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		opts  FormatOpts
+		exp   string
+	}{
+		{
+			test:  "root",
+			query: Query(true),
+			exp:   "$",
+		},
+		{
+			test:  "current",
+			query: Query(false),
+			opts:  all,
+			exp:   "@",
+		},
+		{
+			test:  "names",
+			query: Query(true, Child(Name("a")), Descendant(Name("b"))),
+			exp:   `$["a"]..["b"]`,
+		},
+		{
+			test:  "names_shorthand",
+			query: Query(true, Child(Name("a")), Descendant(Name("b"))),
+			opts:  short,
+			exp:   `$.a..b`,
+		},
+		{
+			test:  "names_single",
+			query: Query(true, Child(Name("a")), Descendant(Name("b"))),
+			opts:  single,
+			exp:   `$['a']..['b']`,
+		},
+		{
+			test:  "wildcards_shorthand",
+			query: Query(true, Child(Wildcard()), Descendant(Wildcard())),
+			opts:  short,
+			exp:   `$.*..*`,
+		},
+		{
+			test:  "wildcards_brackets",
+			query: Query(true, Child(Wildcard()), Descendant(Wildcard())),
+			exp:   `$[*]..[*]`,
+		},
+		{
+			test: "not_shorthand",
+			query: Query(
+				true,
+				Child(Name("1a")),
+				Child(Name("a b")),
+				Child(Name("")),
+				Child(Name("a"), Name("b")),
+				Child(Name("a"), Wildcard()),
+				Child(Index(0)),
+				Child(Slice(1, 3)),
+			),
+			opts: short,
+			exp:  `$["1a"]["a b"][""]["a","b"]["a",*][0][1:3]`,
+		},
+		{
+			test:  "shorthand_chars",
+			query: Query(true, Child(Name("_a1")), Child(Name("été")), Child(Name("Z9_")), Descendant(Name("\U0001F600"))),
+			opts:  short,
+			exp:   "$._a1.été.Z9_..\U0001F600",
+		},
+		{
+			test:  "shorthand_invalid_utf8",
+			query: Query(true, Child(Name("a\xff"))),
+			opts:  short,
+			exp:   "$[\"a�\"]",
+		},
+		{
+			test:  "escapes",
+			query: Query(true, Child(Name("'\"\\\b\f\n\r\t\x00\x1f\x7f/é"))),
+			exp:   `$["'\"\\\b\f\n\r\t\u0000\u001f` + "\x7f/é\"]",
+		},
+		{
+			test:  "escapes_single",
+			query: Query(true, Child(Name("'\"\\\b\f\n\r\t\x00\x1f\x7f/é"))),
+			opts:  single,
+			exp:   `$['\'"\\\b\f\n\r\t\u0000\u001f` + "\x7f/é']",
+		},
+		{
+			test:  "filter",
+			query: Query(true, Child(filter)),
+			exp:   `$[?@["a"] == "x" && !@["b"] || __val(@["c"], $["d"]) > 1]`,
+		},
+		{
+			test:  "filter_shorthand",
+			query: Query(true, Child(filter)),
+			opts:  short,
+			exp:   `$[?@.a == "x" && !@.b || __val(@.c, $.d) > 1]`,
+		},
+		{
+			test:  "filter_single",
+			query: Query(true, Child(filter)),
+			opts:  single,
+			exp:   `$[?@['a'] == 'x' && !@['b'] || __val(@['c'], $['d']) > 1]`,
+		},
+		{
+			test:  "filter_compact",
+			query: Query(true, Child(filter)),
+			opts:  compact,
+			exp:   `$[?@["a"]=="x"&&!@["b"]||__val(@["c"],$["d"])>1]`,
+		},
+		{
+			test:  "filter_all",
+			query: Query(true, Descendant(filter)),
+			opts:  all,
+			exp:   `$..[?@.a=='x'&&!@.b||__val(@.c,$.d)>1]`,
+		},
+		{
+			test: "filter_parens",
+			query: Query(true, Child(Filter(And(
+				Paren(And(Existence(Query(false, Child(Name("a"))))), And(Existence(Query(true, Descendant(Wildcard()))))),
+				NotParen(And(Comparison(Literal(nil), NotEqualTo, Literal("it's")))),
+			)))),
+			opts: short,
+			exp:  `$[?(@.a || $..*) && !(null != "it's")]`,
+		},
+		{
+			test: "filter_functions",
+			query: Query(true, Child(Filter(
+				And(Function(newTrueFunc(), Query(false, Child(Name("a"), Index(1))))),
+				And(NotFunction(Function(newTrueFunc(), Literal(true), Literal(float64(1.5))))),
+				And(Function(newTrueFunc(), LogicalOr{And(Existence(Query(false, Child(Name("x")))))})),
+			))),
+			opts: FormatOpts{Shorthand: true, SingleQuotes: true},
+			exp:  `$[?__true(@['a',1]) || !__true(true, 1.5) || __true(@.x)]`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			a.Equal(tc.exp, tc.query.Format(tc.opts))
+			if tc.opts == (FormatOpts{}) {
+				// Should format the same as String for printable names.
+				if tc.test != "escapes" {
+					a.Equal(tc.query.String(), tc.query.Format(tc.opts))
+				}
+			}
+		})
+	}
+}
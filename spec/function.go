@@ -5,6 +5,7 @@ package spec
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"strings"
 )
 
@@ -35,6 +36,7 @@ const (
 //   - [ValueType]
 //   - [LogicalType]
 //   - [NodesType]
+//   - [NothingType]
 //
 // [RFC 9535 Section 2.4.1]: https://www.rfc-editor.org/rfc/rfc9535.html#section-2.4.1
 type PathValue interface {
@@ -75,14 +77,14 @@ func (NodesType) FuncType() FuncType { return FuncNodes }
 //   - [NodesType]: returns value
 //   - [ValueType]: Returns a [NodesType] containing that single value
 //   - [LogicalType]: Panics
-//   - nil: Returns an empty [NodesType]
+//   - nil, [Nothing]: Returns an empty [NodesType]
 func NodesFrom(value PathValue) NodesType {
 	switch v := value.(type) {
 	case NodesType:
 		return v
 	case *ValueType:
 		return NodesType([]any{v.any})
-	case nil:
+	case nil, NothingType:
 		return NodesType(make([]any, 0))
 	case LogicalType:
 		panic("cannot convert LogicalType to NodesType")
@@ -145,14 +147,14 @@ func (LogicalType) FuncType() FuncType { return FuncLogical }
 //   - [NodesType]: Returns [LogicalFalse] if value is empty and [LogicalTrue]
 //     if it is not
 //   - [ValueType]: Panics
-//   - nil: Returns [LogicalFalse]
+//   - nil, [Nothing]: Returns [LogicalFalse]
 func LogicalFrom(value any) LogicalType {
 	switch v := value.(type) {
 	case LogicalType:
 		return v
 	case NodesType:
 		return Logical(len(v) > 0)
-	case nil:
+	case nil, NothingType:
 		return LogicalFalse
 	case *ValueType:
 		panic("cannot convert ValueType to LogicalType")
@@ -171,7 +173,8 @@ func (lt LogicalType) writeTo(buf *strings.Builder) {
 // result, as defined by [RFC 9535 Section 2.4.1]. It can also be used as in
 // filter expression. The underlying value should be a string, integer,
 // [json.Number], float, nil, true, false, slice, or string-keyed map. A nil
-// ValueType pointer indicates no value. Interfaces implemented:
+// ValueType pointer, like [Nothing], indicates no value. Interfaces
+// implemented:
 //
 //   - [PathValue]
 //   - [BasicExpr]
@@ -189,8 +192,14 @@ func Value(val any) *ValueType {
 	return &ValueType{val}
 }
 
-// Value returns the underlying value of vt.
-func (vt *ValueType) Value() any { return vt.any }
+// Value returns the underlying value of vt, or nil if vt is nil, as
+// [ValueFrom] returns for [Nothing].
+func (vt *ValueType) Value() any {
+	if vt == nil {
+		return nil
+	}
+	return vt.any
+}
 
 // String returns the string representation of vt.
 func (vt *ValueType) String() string { return fmt.Sprintf("%v", vt.any) }
@@ -208,12 +217,12 @@ func (*ValueType) FuncType() FuncType { return FuncValue }
 //   - [ValueType]: returns value
 //   - [NodesType]: Panics
 //   - [ValueType]: Panics
-//   - nil: Returns nil
+//   - nil, [Nothing]: Returns nil
 func ValueFrom(value PathValue) *ValueType {
 	switch v := value.(type) {
 	case *ValueType:
 		return v
-	case nil:
+	case nil, NothingType:
 		return nil
 	case LogicalType:
 		panic("cannot convert LogicalType to ValueType")
@@ -225,7 +234,7 @@ func ValueFrom(value PathValue) *ValueType {
 
 // Returns true if vt.any is truthy. Defined by the BasicExpr interface.
 // Defined by [BasicExpr].
-func (vt *ValueType) testFilter(_, _ any) bool {
+func (vt *ValueType) testFilter(_ any, _ *evaluation) bool {
 	switch v := vt.any.(type) {
 	case nil:
 		return false
@@ -255,11 +264,8 @@ func (vt *ValueType) testFilter(_, _ any) bool {
 		return v != float32(0)
 	case float64:
 		return v != float64(0)
-	case json.Number:
-		if f, err := v.Float64(); err == nil {
-			return f != float64(0)
-		}
-		return true
+	case json.Number, *big.Int, *big.Float, *big.Rat, Decimal:
+		return !isZeroNumber(v)
 	default:
 		return true
 	}
@@ -271,6 +277,51 @@ func (vt *ValueType) writeTo(buf *strings.Builder) {
 	buf.WriteString(vt.String())
 }
 
+// NothingType represents the absence of a value, the special result Nothing
+// defined by [RFC 9535 Section 2.4.1]. It's distinct from the JSON null
+// value, which Go represents as nil. [Nothing] is its only value, returned
+// by [PathQuery.SelectValue] when a query selects no value. [Evaluator]
+// functions may return Nothing, or nil, to indicate that they have no
+// result. Interfaces implemented:
+//
+//   - [PathValue]
+//   - [fmt.Stringer]
+//
+// [RFC 9535 Section 2.4.1]: https://www.rfc-editor.org/rfc/rfc9535.html#section-2.4.1
+type NothingType struct{}
+
+// Nothing represents the absence of a value. Compare values to Nothing with
+// ==, or use [IsNothing] for [PathValue] values.
+//
+//nolint:gochecknoglobals
+var Nothing NothingType
+
+// FuncType returns [FuncValue]. Defined by the [PathValue] interface.
+func (NothingType) FuncType() FuncType { return FuncValue }
+
+// String returns "Nothing".
+func (NothingType) String() string { return "Nothing" }
+
+// writeTo writes "Nothing" to buf. Defined by [stringWriter].
+func (n NothingType) writeTo(buf *strings.Builder) {
+	buf.WriteString(n.String())
+}
+
+// IsNothing returns true if value represents the absence of a value: nil,
+// [Nothing], or a nil [ValueType] pointer. Use in [Evaluator] functions to
+// distinguish a missing argument from a JSON null, which is a [ValueType]
+// containing nil.
+func IsNothing(value PathValue) bool {
+	switch v := value.(type) {
+	case nil, NothingType:
+		return true
+	case *ValueType:
+		return v == nil
+	default:
+		return false
+	}
+}
+
 // FuncExprArg defines the interface for function argument expressions.
 // Implementations:
 //
@@ -283,7 +334,7 @@ type FuncExprArg interface {
 	stringWriter
 	// evaluate evaluates the function expression against current and root and
 	// returns the resulting PathValue.
-	evaluate(current, root any) PathValue
+	evaluate(current any, ev *evaluation) PathValue
 	// ResultType returns the [FuncType] that defines the type of the return
 	// value of the [FuncExprArg].
 	ResultType() FuncType
@@ -295,7 +346,8 @@ type FuncExprArg interface {
 
 // LiteralArg represents a literal JSON value, excluding objects and arrays.
 // Its underlying value there must be one of string, integer, float,
-// [json.Number], nil, true, or false.
+// [json.Number], nil, true, or false. The parser uses [json.Number] for
+// numeric literals that exceed the precision of int64 or float64.
 //
 // Interfaces implemented:
 //   - [FuncExprArg]
@@ -304,12 +356,14 @@ type FuncExprArg interface {
 type LiteralArg struct {
 	// Number, string, bool, or null
 	literal any
+	// literal as a scalar, converted once for all comparisons.
+	scalar scalar
 }
 
 // Literal creates and returns a new [LiteralArg] consisting of lit, which
 // must ge one of string, integer, float, [json.Number], nil, true, or false.
 func Literal(lit any) *LiteralArg {
-	return &LiteralArg{lit}
+	return &LiteralArg{literal: lit, scalar: toScalar(lit)}
 }
 
 // Value returns the underlying value of la.
@@ -317,15 +371,14 @@ func (la *LiteralArg) Value() any { return la.literal }
 
 // String returns the JSON string representation of la.
 func (la *LiteralArg) String() string {
-	if la.literal == nil {
-		return "null"
-	}
-	return fmt.Sprintf("%#v", la.literal)
+	var buf strings.Builder
+	la.writeTo(&buf)
+	return buf.String()
 }
 
 // evaluate returns a [ValueType] containing the literal value. Defined by the
 // [FuncExprArg] interface.
-func (la *LiteralArg) evaluate(_, _ any) PathValue {
+func (la *LiteralArg) evaluate(_ any, _ *evaluation) PathValue {
 	return &ValueType{la.literal}
 }
 
@@ -341,19 +394,32 @@ func (*LiteralArg) ConvertsTo(ft FuncType) bool { return ft == FuncValue }
 // writeTo writes a JSON string representation of la to buf. Defined by
 // [stringWriter].
 func (la *LiteralArg) writeTo(buf *strings.Builder) {
-	if la.literal == nil {
+	switch lit := la.literal.(type) {
+	case nil:
 		buf.WriteString("null")
-	} else {
-		fmt.Fprintf(buf, "%#v", la.literal)
+	case json.Number:
+		buf.WriteString(string(lit))
+	default:
+		fmt.Fprintf(buf, "%#v", lit)
 	}
 }
 
 // asValue returns la.literal as a [ValueType]. Defined by the [CompVal]
 // interface.
-func (la *LiteralArg) asValue(_, _ any) PathValue {
+func (la *LiteralArg) asValue(_ any, _ *evaluation) PathValue {
 	return &ValueType{la.literal}
 }
 
+// asScalar returns la.literal as a [scalar]. Defined by the [scalarVal]
+// interface.
+func (la *LiteralArg) asScalar(_ any, _ *evaluation) scalar {
+	if la.scalar.kind == scalarOther {
+		// Not created by Literal, or not a scalar.
+		return scalar{val: la.literal}
+	}
+	return la.scalar
+}
+
 // SingularQueryExpr represents a query that produces a single [ValueType]
 // (JSON value) or nothing. Used in contexts that require a singular value,
 // such as comparison operations and function arguments. Interfaces
@@ -376,23 +442,83 @@ func SingularQuery(root bool, selectors ...Selector) *SingularQueryExpr {
 	return &SingularQueryExpr{relative: !root, selectors: selectors}
 }
 
+// SingularRel creates and returns a relative [SingularQueryExpr] that
+// selects the value at the path of object member names in name from the
+// current node, such as @.price for SingularRel("price").
+func SingularRel(name ...string) *SingularQueryExpr {
+	return SingularQuery(false, names(name)...)
+}
+
+// SingularAbs creates and returns an absolute [SingularQueryExpr] that
+// selects the value at the path of object member names in name from the
+// root node, such as $.limits.price for SingularAbs("limits", "price").
+func SingularAbs(name ...string) *SingularQueryExpr {
+	return SingularQuery(true, names(name)...)
+}
+
+// names returns a [Name] selector for each name in name.
+func names(name []string) []Selector {
+	sels := make([]Selector, len(name))
+	for i, n := range name {
+		sels[i] = Name(n)
+	}
+	return sels
+}
+
+// IsRoot returns true if sq selects from the root node ($) and false if it
+// selects from the current node (@).
+func (sq *SingularQueryExpr) IsRoot() bool { return !sq.relative }
+
+// Selectors returns the [Name] and [Index] selectors of sq.
+func (sq *SingularQueryExpr) Selectors() []Selector { return sq.selectors }
+
 // evaluate returns a [ValueType] containing the return value of executing sq.
 // Defined by the [FuncExprArg] interface.
-func (sq *SingularQueryExpr) evaluate(current, root any) PathValue {
-	target := root
+func (sq *SingularQueryExpr) evaluate(current any, ev *evaluation) PathValue {
+	if target, ok := sq.lookup(current, ev); ok {
+		return &ValueType{ev.value(target)}
+	}
+	return nil
+}
+
+// lookup selects the value sq selects from current or ev.root. Returns false
+// if sq selects nothing. Looks up [Name] and [Index] selectors directly,
+// without allocating a slice of results.
+func (sq *SingularQueryExpr) lookup(current any, ev *evaluation) (any, bool) {
+	target := ev.root
 	if sq.relative {
 		target = current
 	}
 
-	for _, seg := range sq.selectors {
-		res := seg.Select(target, nil)
-		if len(res) == 0 {
-			return nil
+	for _, sel := range sq.selectors {
+		ev.visit(0)
+		var ok bool
+		switch sel := sel.(type) {
+		case Name:
+			target, ok = lookupName(target, string(sel))
+		case Index:
+			target, ok = lookupIndex(target, int(sel))
+		default:
+			res := sel.Select(target, nil)
+			if ok = len(res) > 0; ok {
+				target = res[0]
+			}
+		}
+		if !ok {
+			return nil, false
 		}
-		target = res[0]
 	}
 
-	return &ValueType{target}
+	return target, true
+}
+
+// asScalar returns the result of executing sq against current and root as
+// a [scalar]. Defined by the [scalarVal] interface.
+func (sq *SingularQueryExpr) asScalar(current any, ev *evaluation) scalar {
+	if target, ok := sq.lookup(current, ev); ok {
+		return toScalar(ev.value(target))
+	}
+	return scalar{kind: scalarNothing}
 }
 
 // ResultType returns [FuncValue]. Defined by the [FuncExprArg] interface.
@@ -408,8 +534,8 @@ func (*SingularQueryExpr) ConvertsTo(ft FuncType) bool {
 
 // asValue returns the result of executing sq.execute against current and
 // root. Defined by the [CompVal] interface.
-func (sq *SingularQueryExpr) asValue(current, root any) PathValue {
-	return sq.evaluate(current, root)
+func (sq *SingularQueryExpr) asValue(current any, ev *evaluation) PathValue {
+	return sq.evaluate(current, ev)
 }
 
 // writeTo writes a string representation of sq to buf. Defined by
@@ -486,9 +612,33 @@ func (f *FuncExtension) Name() string { return f.name }
 func (f *FuncExtension) ReturnType() FuncType { return f.returnType }
 
 // Evaluate executes the [FuncExtension] against args and returns a result of
-// type [ResultType].
+// type [ResultType]. Discards any warning returned by [WithWarning].
 func (f *FuncExtension) Evaluate(args []PathValue) PathValue {
-	return f.evaluator(args)
+	val := f.evaluator(args)
+	if w, ok := val.(*warningValue); ok {
+		return w.PathValue
+	}
+	return val
+}
+
+// WithWarning returns val with a warning that the evaluation of a
+// [FuncExtension] hides a problem, such as a regular expression that fails
+// to compile. [Evaluator] functions return it rather than val to report such
+// soft failures to the [Options].Logger of the evaluation, if any, as a
+// warning with msg and the key/value pairs in args, as in
+// [log/slog.Logger.Warn]. The evaluation proceeds with val, unless
+// [Options].Strict is set, in which case it aborts with an [ErrEvaluation]
+// error.
+func WithWarning(val PathValue, msg string, args ...any) PathValue {
+	return &warningValue{PathValue: val, msg: msg, args: args}
+}
+
+// warningValue wraps a [PathValue] with a warning, as returned by
+// [WithWarning].
+type warningValue struct {
+	PathValue
+	msg  string
+	args []any
 }
 
 // Validate executes at parse time to validate that all the args to the are
@@ -516,6 +666,19 @@ func Function(fn *FuncExtension, args ...FuncExprArg) *FuncExpr {
 	return &FuncExpr{args: args, fn: fn}
 }
 
+// Func returns the [FuncExtension] fe executes.
+func (fe *FuncExpr) Func() *FuncExtension { return fe.fn }
+
+// Args returns the arguments of fe.
+func (fe *FuncExpr) Args() []FuncExprArg { return fe.args }
+
+// Validate uses the [FuncExtension] of fe to validate its arguments, as the
+// parser does for function expressions in query strings. Use to validate
+// function expressions created by [Function].
+func (fe *FuncExpr) Validate() error {
+	return fe.fn.Validate(fe.args)
+}
+
 // writeTo writes the string representation of fe to buf. Defined by
 // [stringWriter].
 func (fe *FuncExpr) writeTo(buf *strings.Builder) {
@@ -538,14 +701,24 @@ func (fe *FuncExpr) String() string {
 
 // evaluate returns a [PathValue] containing the result of executing each
 // [FuncExprArg] in fe (as passed to [Function]) and passing them to fe's
-// [FuncExtension].
-func (fe *FuncExpr) evaluate(current, root any) PathValue {
+// [FuncExtension]. Returns nil if the function returns [Nothing], as do
+// other expressions that produce no value.
+func (fe *FuncExpr) evaluate(current any, ev *evaluation) PathValue {
 	res := make([]PathValue, len(fe.args))
 	for i, a := range fe.args {
-		res[i] = a.evaluate(current, root)
+		res[i] = a.evaluate(current, ev)
 	}
 
-	return fe.fn.Evaluate(res)
+	ev.function(fe.fn.Name())
+	val := fe.fn.evaluator(res)
+	if w, ok := val.(*warningValue); ok {
+		ev.fail(w.msg, append([]any{"function", fe.fn.Name()}, w.args...)...)
+		val = w.PathValue
+	}
+	if _, ok := val.(NothingType); ok {
+		return nil
+	}
+	return val
 }
 
 // ResultType returns the result type of fe's [FuncExtension]. Defined by the
@@ -561,8 +734,8 @@ func (fe *FuncExpr) ConvertsTo(ft FuncType) bool {
 
 // asValue returns the result of executing fe.evaluate against current and
 // root. Defined by the [CompVal] interface.
-func (fe *FuncExpr) asValue(current, root any) PathValue {
-	return fe.evaluate(current, root)
+func (fe *FuncExpr) asValue(current any, ev *evaluation) PathValue {
+	return fe.evaluate(current, ev)
 }
 
 // testFilter executes fe and returns true if the function returns a truthy
@@ -574,12 +747,12 @@ func (fe *FuncExpr) asValue(current, root any) PathValue {
 //   - If the result is [LogicalType], returns the underlying boolean.
 //
 // Returns false in all other cases. Defined by [BasicExpr].
-func (fe *FuncExpr) testFilter(current, root any) bool {
-	switch res := fe.evaluate(current, root).(type) {
+func (fe *FuncExpr) testFilter(current any, ev *evaluation) bool {
+	switch res := fe.evaluate(current, ev).(type) {
 	case NodesType:
 		return len(res) > 0
 	case *ValueType:
-		return res.testFilter(current, root)
+		return res.testFilter(current, ev)
 	case LogicalType:
 		return res.Bool()
 	default:
@@ -608,8 +781,15 @@ func (nf NotFuncExpr) String() string {
 	return "!" + nf.FuncExpr.String()
 }
 
+// writeTo writes the string representation of nf to buf. Defined by
+// [stringWriter].
+func (nf NotFuncExpr) writeTo(buf *strings.Builder) {
+	buf.WriteRune('!')
+	nf.FuncExpr.writeTo(buf)
+}
+
 // testFilter returns the inverse of [FuncExpr.testFilter]. Defined by
 // [BasicExpr].
-func (nf NotFuncExpr) testFilter(current, root any) bool {
-	return !nf.FuncExpr.testFilter(current, root)
+func (nf NotFuncExpr) testFilter(current any, ev *evaluation) bool {
+	return !nf.FuncExpr.testFilter(current, ev)
 }
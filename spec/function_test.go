@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 	"testing"
 
@@ -60,6 +61,7 @@ func TestNodesType(t *testing.T) {
 		{"nodes", NodesType([]any{1, 2}), Nodes(1, 2), "[1 2]", ""},
 		{"value", Value(1), Nodes(1), "[1]", ""},
 		{"nil", nil, Nodes([]any{}...), "[]", ""},
+		{"nothing", Nothing, Nodes([]any{}...), "[]", ""},
 		{"logical", LogicalTrue, nil, "", "cannot convert LogicalType to NodesType"},
 		{"unknown", newValueType{}, nil, "", "unexpected argument of type spec.newValueType"},
 	} {
@@ -96,6 +98,7 @@ func TestLogicalType(t *testing.T) {
 		{"empty_nodes", Nodes(), LogicalFalse, false, "", "false"},
 		{"nodes", Nodes(1), LogicalTrue, true, "", "true"},
 		{"null", nil, LogicalFalse, false, "", "false"},
+		{"nothing", Nothing, LogicalFalse, false, "", "false"},
 		{"value", Value(1), LogicalFalse, false, "cannot convert ValueType to LogicalType", ""},
 		{"unknown", newValueType{}, LogicalFalse, false, "unexpected argument of type spec.newValueType", ""},
 	} {
@@ -156,6 +159,14 @@ func TestValueType(t *testing.T) {
 		{"json_number_float", json.Number("98.6"), true},
 		{"json_number_float_zero", json.Number("0.0"), false},
 		{"json_number_invalid", json.Number("not a number"), true},
+		{"big_int", big.NewInt(-3), true},
+		{"big_int_zero", new(big.Int), false},
+		{"big_float", big.NewFloat(0.5), true},
+		{"big_float_zero", new(big.Float), false},
+		{"big_rat", big.NewRat(1, 3), true},
+		{"big_rat_zero", new(big.Rat), false},
+		{"decimal", decimal("0.000000000000000000001"), true},
+		{"decimal_zero", decimal("0.0"), false},
 		{"uint64", uint64(1), true},
 		{"uint64_zero", uint64(0), false},
 		{"float32", float32(1), true},
@@ -191,6 +202,7 @@ func TestValueFrom(t *testing.T) {
 	}{
 		{"valueType", Value(42), Value(42), ""},
 		{"nil", nil, nil, ""},
+		{"nothing", Nothing, nil, ""},
 		{"logical", LogicalFalse, nil, "cannot convert LogicalType to ValueType"},
 		{"nodes", Nodes(1), nil, "cannot convert NodesType to ValueType"},
 		{"unknown", newValueType{}, nil, "unexpected argument of type spec.newValueType"},
@@ -205,6 +217,32 @@ func TestValueFrom(t *testing.T) {
 			}
 			val := ValueFrom(tc.val)
 			a.Equal(tc.exp, val)
+			if tc.exp == nil {
+				a.Nil(val.Value())
+			}
+		})
+	}
+}
+
+func TestIsNothing(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		val  PathValue
+		exp  bool
+	}{
+		{"nil", nil, true},
+		{"nothing", Nothing, true},
+		{"nil_value", (*ValueType)(nil), true},
+		{"null", Value(nil), false},
+		{"value", Value(42), false},
+		{"empty_nodes", Nodes(), false},
+		{"logical", LogicalFalse, false},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, IsNothing(tc.val))
 		})
 	}
 }
@@ -221,6 +259,7 @@ func TestPathValueInterface(t *testing.T) {
 		{"nodes", Nodes(), FuncNodes, "[]"},
 		{"logical", LogicalType(1), FuncLogical, "true"},
 		{"value", &ValueType{}, FuncValue, "<nil>"},
+		{"nothing", Nothing, FuncValue, "Nothing"},
 	} {
 		t.Run(tc.test, func(t *testing.T) {
 			t.Parallel()
@@ -283,6 +322,7 @@ func TestLiteralArg(t *testing.T) {
 	}{
 		{"string", "hi", `"hi"`},
 		{"number", 42, "42"},
+		{"json_number", json.Number("12345678901234567890.5"), "12345678901234567890.5"},
 		{"true", true, "true"},
 		{"false", false, "false"},
 		{"null", nil, "null"},
@@ -369,19 +409,48 @@ func TestSingularQuery(t *testing.T) {
 
 			// Start with absolute query.
 			a.False(sq.relative)
-			a.Equal(tc.exp, sq.evaluate(nil, tc.input))
-			a.Equal(tc.exp, sq.asValue(nil, tc.input))
+			a.True(sq.IsRoot())
+			a.Equal(tc.selectors, sq.Selectors())
+			a.Equal(tc.exp, sq.evaluate(nil, &evaluation{root: tc.input}))
+			a.Equal(tc.exp, sq.asValue(nil, &evaluation{root: tc.input}))
 			a.Equal("$"+tc.str, bufString(sq))
 
 			// Try a relative query.
 			sq.relative = true
-			a.Equal(tc.exp, sq.evaluate(tc.input, nil))
-			a.Equal(tc.exp, sq.asValue(tc.input, nil))
+			a.False(sq.IsRoot())
+			a.Equal(tc.exp, sq.evaluate(tc.input, &evaluation{}))
+			a.Equal(tc.exp, sq.asValue(tc.input, &evaluation{}))
 			a.Equal("@"+tc.str, bufString(sq))
 		})
 	}
 }
 
+func TestSingularNames(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := map[string]any{"a": map[string]any{"b": 1}, "b": 2}
+	a.Equal(SingularQuery(false, Name("a"), Name("b")), SingularRel("a", "b"))
+	a.Equal(SingularQuery(true, Name("a"), Name("b")), SingularAbs("a", "b"))
+	a.Equal(`@["a"]["b"]`, SingularRel("a", "b").String())
+	a.Equal(`$["b"]`, SingularAbs("b").String())
+	a.Equal(`@`, SingularRel().String())
+	a.Equal(Value(1), SingularRel("a", "b").evaluate(input, &evaluation{}))
+	a.Equal(Value(2), SingularAbs("b").evaluate(nil, &evaluation{root: input}))
+
+	// Should validate function arguments.
+	fe := Function(
+		Extension("__one", FuncValue, func(args []FuncExprArg) error {
+			if len(args) != 1 {
+				return errors.New("expected 1 argument")
+			}
+			return nil
+		}, nil),
+		SingularRel("a"), SingularRel("b"),
+	)
+	a.EqualError(fe.Validate(), "expected 1 argument")
+}
+
 func TestFilterQuery(t *testing.T) {
 	t.Parallel()
 
@@ -435,7 +504,7 @@ func TestFilterQuery(t *testing.T) {
 
 			q := tc.query
 			a.Equal(tc.typeKind, q.ResultType())
-			a.Equal(NodesType(tc.exp), q.evaluate(tc.current, tc.root))
+			a.Equal(NodesType(tc.exp), q.evaluate(tc.current, &evaluation{root: tc.root}))
 			a.Equal(tc.query.String(), bufString(q))
 			a.Equal(tc.typeKind == FuncValue, q.ConvertsTo(FuncValue))
 			a.True(q.ConvertsTo(FuncNodes))
@@ -504,6 +573,15 @@ func newTypeFunc() *FuncExtension {
 	)
 }
 
+func newNothingFunc() *FuncExtension {
+	return Extension(
+		"__nothing",
+		FuncValue,
+		func([]FuncExprArg) error { return nil },
+		func([]PathValue) PathValue { return Nothing },
+	)
+}
+
 func TestFunc(t *testing.T) {
 	t.Parallel()
 
@@ -593,6 +671,14 @@ func TestFuncExpr(t *testing.T) {
 			logical: true,
 			str:     `__true()`,
 		},
+		{
+			test:    "nothing",
+			fn:      newNothingFunc(),
+			args:    []FuncExprArg{},
+			exp:     nil,
+			logical: false,
+			str:     `__nothing()`,
+		},
 		{
 			test:    "__new_type",
 			fn:      newTypeFunc(),
@@ -607,12 +693,17 @@ func TestFuncExpr(t *testing.T) {
 			a := assert.New(t)
 
 			fe := Function(tc.fn, tc.args...)
+			a.Same(tc.fn, fe.Func())
+			a.Equal(tc.args, fe.Args())
+			a.NoError(fe.Validate())
 			a.Equal(tc.fn.ReturnType(), fe.ResultType())
-			a.Equal(tc.exp, fe.evaluate(tc.current, tc.root))
-			a.Equal(tc.exp, fe.asValue(tc.current, tc.root))
-			a.Equal(tc.logical, fe.testFilter(tc.current, tc.root))
-			a.Equal(!tc.logical, NotFunction(fe).testFilter(tc.current, tc.root))
+			a.Equal(tc.exp, fe.evaluate(tc.current, &evaluation{root: tc.root}))
+			a.Equal(tc.exp, fe.asValue(tc.current, &evaluation{root: tc.root}))
+			a.Equal(tc.logical, fe.testFilter(tc.current, &evaluation{root: tc.root}))
+			a.Equal(!tc.logical, NotFunction(fe).testFilter(tc.current, &evaluation{root: tc.root}))
 			a.Equal(tc.str, fe.String())
+			a.Equal("!"+tc.str, NotFunction(fe).String())
+			a.Equal("!"+tc.str, bufString(NotFunction(fe)))
 			a.Equal(tc.fn.ReturnType() == FuncValue, fe.ConvertsTo(FuncValue))
 			a.Equal(tc.fn.ReturnType() == FuncNodes, fe.ConvertsTo(FuncNodes))
 			a.Equal(tc.fn.ReturnType() == FuncLogical, fe.ConvertsTo(FuncLogical))
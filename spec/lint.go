@@ -0,0 +1,251 @@
+package spec
+
+import "fmt"
+
+// Severity indicates the severity of a [Diagnostic].
+type Severity uint8
+
+const (
+	// SeverityInfo diagnostics describe valid queries that may not be
+	// portable to other implementations.
+	SeverityInfo Severity = iota
+
+	// SeverityWarning diagnostics describe valid queries that likely do not
+	// select what their authors intended.
+	SeverityWarning
+
+	// SeverityError diagnostics describe invalid queries.
+	SeverityError
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", uint8(s))
+	}
+}
+
+// MarshalText returns the name of the severity, so that it marshals to JSON
+// as a string.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Diagnostic codes identify the kinds of problems described by a
+// [Diagnostic].
+const (
+	// CodeParse identifies queries that fail to parse.
+	CodeParse = "parse"
+
+	// CodeUnknownFunction identifies queries that call a function not
+	// found in the registry.
+	CodeUnknownFunction = "unknown-function"
+
+	// CodeExtension identifies queries that use syntax or functions that
+	// extend RFC 9535.
+	CodeExtension = "extension"
+
+	// CodeConstantFilter identifies filters that are always true or always
+	// false.
+	CodeConstantFilter = "constant-filter"
+
+	// CodeUnreachable identifies selectors that can never select a value
+	// and the segments that follow them.
+	CodeUnreachable = "unreachable"
+
+	// CodeSchemaMismatch identifies selectors that can never select a value
+	// from values described by a [Schema].
+	CodeSchemaMismatch = "schema-mismatch"
+
+	// CodeTypeMismatch identifies comparisons that are always false, or
+	// always true, because a [Schema] never allows the compared values to
+	// have the same type.
+	CodeTypeMismatch = "type-mismatch"
+)
+
+// Diagnostic describes a problem with a query. Its fields marshal to JSON
+// for tools, such as query editors, that highlight problems in queries.
+type Diagnostic struct {
+	// Severity indicates the severity of the problem.
+	Severity Severity `json:"severity"`
+	// Code identifies the kind of problem, one of the Code constants.
+	Code string `json:"code"`
+	// Message describes the problem.
+	Message string `json:"message"`
+	// Start and End are the zero-based byte offsets of the start and end of
+	// the text in the query string to which the problem applies. Both are
+	// zero for problems that apply to the query as a whole, including all
+	// of those reported by [PathQuery.Lint], which has no query string.
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Hint suggests how to fix the problem. Empty if there is no
+	// suggestion.
+	Hint string `json:"hint,omitempty"`
+}
+
+// String returns a string representation of d.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%v: %v (%v)", d.Severity, d.Message, d.Code)
+}
+
+// standardFunctions contains the names of the function extensions defined
+// by RFC 9535.
+var standardFunctions = map[string]bool{
+	"count":  true,
+	"length": true,
+	"match":  true,
+	"search": true,
+	"value":  true,
+}
+
+// Lint analyzes q without evaluating it and returns a [Diagnostic] for each
+// problem it finds, in the order they appear in q. It reports:
+//
+//   - Filters that are always true, such as ?1 == 1, which select the same
+//     values as a wildcard, or always false, such as ?!@
+//   - Selectors that can never select a value, such as [1:1] and [::0]
+//   - Segments that follow a segment in which no selector can select a
+//     value, and are therefore unreachable
+//   - Calls to functions other than those defined by RFC 9535
+//
+// It analyzes the queries in filter expressions, too. Returns nil if it
+// finds no problems.
+func (q *PathQuery) Lint() []Diagnostic {
+	var l linter
+	l.query(q)
+	return l.diags
+}
+
+// linter collects the diagnostics for [PathQuery.Lint].
+type linter struct {
+	diags []Diagnostic
+}
+
+// add appends a diagnostic with severity and code and a message formatted
+// from format and args.
+func (l *linter) add(severity Severity, code, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// query lints q.
+func (l *linter) query(q *PathQuery) {
+	for i, seg := range q.segments {
+		empty := true
+		for _, sel := range seg.selectors {
+			if !l.selector(q, i+1, sel) {
+				empty = false
+			}
+		}
+		if empty && i < len(q.segments)-1 {
+			l.add(
+				SeverityWarning, CodeUnreachable,
+				"segments after segment %v of %v are unreachable because it selects nothing",
+				i+1, q,
+			)
+			return
+		}
+	}
+}
+
+// selector lints sel, a selector in segment number seg of q, and returns
+// true if it can never select a value.
+func (l *linter) selector(q *PathQuery, seg int, sel Selector) bool {
+	f, ok := sel.(*FilterSelector)
+	if !ok {
+		if selectsNothing(sel) {
+			l.add(
+				SeverityWarning, CodeUnreachable,
+				"selector %v in segment %v of %v selects nothing",
+				sel, seg, q,
+			)
+			return true
+		}
+		return false
+	}
+
+	l.or(f.LogicalOr)
+	folded := &FilterSelector{f.Fold()}
+	switch {
+	case selectsAll(folded):
+		l.add(
+			SeverityWarning, CodeConstantFilter,
+			"filter in segment %v of %v is always true and selects the same values as *",
+			seg, q,
+		)
+	case selectsNothing(folded):
+		l.add(
+			SeverityWarning, CodeConstantFilter,
+			"filter in segment %v of %v is always false and selects nothing",
+			seg, q,
+		)
+		return true
+	}
+	return false
+}
+
+// or lints the expressions in lo.
+func (l *linter) or(lo LogicalOr) {
+	for _, and := range lo {
+		for _, expr := range and {
+			l.expr(expr)
+		}
+	}
+}
+
+// expr lints expr.
+func (l *linter) expr(expr BasicExpr) {
+	switch e := expr.(type) {
+	case *ParenExpr:
+		l.or(e.LogicalOr)
+	case *NotParenExpr:
+		l.or(e.LogicalOr)
+	case *ExistExpr:
+		l.query(e.PathQuery)
+	case *NonExistExpr:
+		l.query(e.PathQuery)
+	case NonExistExpr:
+		l.query(e.PathQuery)
+	case *CompExpr:
+		l.value(e.left)
+		l.value(e.right)
+	case *FuncExpr:
+		l.function(e)
+	case NotFuncExpr:
+		l.function(e.FuncExpr)
+	case LogicalOr:
+		l.or(e)
+	case LogicalAnd:
+		l.or(LogicalOr{e})
+	}
+}
+
+// value lints val, a comparison operand or function argument.
+func (l *linter) value(val stringWriter) {
+	switch v := val.(type) {
+	case *PathQuery:
+		l.query(v)
+	case BasicExpr:
+		l.expr(v)
+	}
+}
+
+// function lints fe and its arguments.
+func (l *linter) function(fe *FuncExpr) {
+	if name := fe.fn.Name(); !standardFunctions[name] {
+		l.add(SeverityInfo, CodeExtension, "function %v() is not defined by RFC 9535", name)
+	}
+	for _, arg := range fe.args {
+		l.value(arg)
+	}
+}
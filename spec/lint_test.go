@@ -0,0 +1,137 @@
+package spec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverity(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal("info", SeverityInfo.String())
+	a.Equal("warning", SeverityWarning.String())
+	a.Equal("error", SeverityError.String())
+	a.Equal("Severity(9)", Severity(9).String())
+
+	text, err := SeverityWarning.MarshalText()
+	a.NoError(err)
+	a.Equal([]byte("warning"), text)
+}
+
+func TestDiagnostic(t *testing.T) {
+	t.Parallel()
+
+	a := assert.New(t)
+
+	d := Diagnostic{Severity: SeverityWarning, Code: CodeUnreachable, Message: "oops"}
+	a.Equal("warning: oops (unreachable)", d.String())
+
+	data, err := json.Marshal(d)
+	a.NoError(err)
+	a.JSONEq(`{"severity": "warning", "code": "unreachable", "message": "oops", "start": 0, "end": 0}`, string(data))
+}
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	count := Extension(
+		"count",
+		FuncValue,
+		func([]FuncExprArg) error { return nil },
+		func([]PathValue) PathValue { return Value(1) },
+	)
+	warn := func(code, msg string) Diagnostic {
+		return Diagnostic{Severity: SeverityWarning, Code: code, Message: msg}
+	}
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		exp   []Diagnostic
+	}{
+		{
+			test:  "root",
+			query: Query(true),
+		},
+		{
+			test: "clean",
+			query: Query(
+				true,
+				Child(Name("a"), Index(0)),
+				Descendant(Slice(1, 3)),
+				Child(Filter(And(Existence(Query(false, Child(Name("x"))))))),
+			),
+		},
+		{
+			test:  "empty_slice",
+			query: Query(true, Child(Slice(1, 1), Name("a")), Child(Name("b"))),
+			exp:   []Diagnostic{warn(CodeUnreachable, `selector 1:1 in segment 1 of $[1:1,"a"]["b"] selects nothing`)},
+		},
+		{
+			test:  "unreachable_segments",
+			query: Query(true, Child(Name("a")), Child(Slice(nil, nil, 0)), Child(Name("b")), Child(Name("c"))),
+			exp: []Diagnostic{
+				warn(CodeUnreachable, `selector ::0 in segment 2 of $["a"][::0]["b"]["c"] selects nothing`),
+				warn(CodeUnreachable, `segments after segment 2 of $["a"][::0]["b"]["c"] are unreachable because it selects nothing`),
+			},
+		},
+		{
+			test:  "empty_last_segment",
+			query: Query(true, Child(Name("a")), Child(Slice(3, 1))),
+			exp:   []Diagnostic{warn(CodeUnreachable, `selector 3:1 in segment 2 of $["a"][3:1] selects nothing`)},
+		},
+		{
+			test:  "always_true",
+			query: Query(true, Child(Filter(And(Comparison(Literal(int64(1)), EqualTo, Literal(int64(1))))))),
+			exp: []Diagnostic{
+				warn(CodeConstantFilter, "filter in segment 1 of $[?1 == 1] is always true and selects the same values as *"),
+			},
+		},
+		{
+			test:  "current_existence",
+			query: Query(true, Child(Filter(And(Existence(Query(false)))))),
+			exp: []Diagnostic{
+				warn(CodeConstantFilter, "filter in segment 1 of $[?@] is always true and selects the same values as *"),
+			},
+		},
+		{
+			test: "always_false",
+			query: Query(true, Descendant(Filter(And(
+				Existence(Query(false, Child(Name("a")))),
+				Comparison(Literal(true), NotEqualTo, Literal(true)),
+			))), Child(Name("x"))),
+			exp: []Diagnostic{
+				warn(CodeConstantFilter, `filter in segment 1 of $..[?@["a"] && true != true]["x"] is always false and selects nothing`),
+				warn(CodeUnreachable, `segments after segment 1 of $..[?@["a"] && true != true]["x"] are unreachable because it selects nothing`),
+			},
+		},
+		{
+			test: "nested",
+			query: Query(true, Child(Filter(
+				And(Paren(And(Existence(Query(false, Child(Slice(2, 2))))))),
+				And(NotParen(And(Nonexistence(Query(true, Child(Index(0), Slice(0, 0))))))),
+				And(Comparison(
+					Function(newValueFunc(1), Query(false, Child(Filter(And(Nonexistence(Query(false))))))),
+					EqualTo,
+					Function(count, Query(false, Child(Wildcard()))),
+				)),
+				And(NotFunction(Function(newTrueFunc(), Literal(nil)))),
+			))),
+			exp: []Diagnostic{
+				warn(CodeUnreachable, "selector 2:2 in segment 1 of @[2:2] selects nothing"),
+				warn(CodeUnreachable, "selector :0 in segment 1 of $[0,:0] selects nothing"),
+				{Severity: SeverityInfo, Code: CodeExtension, Message: "function __val() is not defined by RFC 9535"},
+				warn(CodeConstantFilter, "filter in segment 1 of @[?!@] is always false and selects nothing"),
+				{Severity: SeverityInfo, Code: CodeExtension, Message: "function __true() is not defined by RFC 9535"},
+			},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, tc.query.Lint())
+		})
+	}
+}
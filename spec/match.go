@@ -0,0 +1,87 @@
+package spec
+
+import "slices"
+
+// MatchesPath returns true if q selects the node at np, a path from the
+// value q selects from, without evaluating q against a document. Useful
+// for access control and routing layers that filter streams of changes or
+// events keyed by normalized paths rather than documents.
+//
+// MatchesPath is exact for queries whose selectors do not depend on the
+// input: it returns true if and only if q selects the node at np from any
+// input with a value at np. It approximates selectors that do depend on
+// the input by assuming that they may select any node they could select
+// from some input, returning true if q might select the node at np:
+//
+//   - Filter selectors select every object member and array element
+//   - Negative index selectors select any array element
+//   - Slice selectors with negative bounds or negative steps other than
+//     -1 select every array element within their non-negative bounds
+//
+// Returns false for indexes in np that are negative, which normalized
+// paths never contain, unless q selects them with a wildcard or filter.
+func (q *PathQuery) MatchesPath(np NormalizedPath) bool {
+	s := initial(q)
+	for _, el := range np {
+		next := make([]byte, len(s))
+		for i, seg := range q.segments {
+			if s[i] == 0 {
+				continue
+			}
+			if seg.descendant {
+				next[i] = 1
+			}
+			if slices.ContainsFunc(seg.selectors, func(sel Selector) bool {
+				return matchesElement(sel, el)
+			}) {
+				next[i+1] = 1
+			}
+		}
+		s = states(next)
+		if !s.any() {
+			return false
+		}
+	}
+	return s.accepts()
+}
+
+// matchesElement returns true if sel may select the object member or array
+// element identified by el.
+func matchesElement(sel Selector, el NormalSelector) bool {
+	switch sel := sel.(type) {
+	case WildcardSelector, *FilterSelector:
+		return true
+	case Name:
+		name, ok := el.(Name)
+		return ok && name == sel
+	case Index:
+		idx, ok := el.(Index)
+		return ok && (idx == sel || (sel < 0 && idx >= 0))
+	case SliceSelector:
+		idx, ok := el.(Index)
+		return ok && idx >= 0 && sliceMatches(sel, int(idx))
+	default:
+		return false
+	}
+}
+
+// sliceMatches returns true if s may select the array element at idx, a
+// non-negative index. It is exact unless the elements s selects depend on
+// the length of the array, in which case it returns true for any idx within
+// the non-negative bounds of s.
+func sliceMatches(s SliceSelector, idx int) bool {
+	switch {
+	case s.step > 0:
+		if (s.start >= 0 && idx < s.start) || (s.end >= 0 && idx >= s.end) {
+			return false
+		}
+		return s.start < 0 || (idx-s.start)%s.step == 0
+	case s.step < 0:
+		// The start of a reverse slice is clamped to the last element, so
+		// for steps other than -1 the elements it selects depend on the
+		// length of the array.
+		return (s.start < 0 || idx <= s.start) && (s.end < 0 || idx > s.end)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,207 @@
+package spec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesPath(t *testing.T) {
+	t.Parallel()
+
+	filter := Filter(And(Existence(Query(false, Child(Name("x"))))))
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		path  NormalizedPath
+		exp   bool
+	}{
+		{
+			test:  "root",
+			query: Query(true),
+			path:  Normalized(),
+			exp:   true,
+		},
+		{
+			test:  "root_child",
+			query: Query(true),
+			path:  Normalized(Name("a")),
+			exp:   false,
+		},
+		{
+			test:  "name",
+			query: Query(true, Child(Name("a")), Child(Name("b"))),
+			path:  Normalized(Name("a"), Name("b")),
+			exp:   true,
+		},
+		{
+			test:  "other_name",
+			query: Query(true, Child(Name("a")), Child(Name("b"))),
+			path:  Normalized(Name("a"), Name("c")),
+			exp:   false,
+		},
+		{
+			test:  "prefix",
+			query: Query(true, Child(Name("a")), Child(Name("b"))),
+			path:  Normalized(Name("a")),
+			exp:   false,
+		},
+		{
+			test:  "longer",
+			query: Query(true, Child(Name("a"))),
+			path:  Normalized(Name("a"), Name("b")),
+			exp:   false,
+		},
+		{
+			test:  "relative",
+			query: Query(false, Child(Name("a"))),
+			path:  Normalized(Name("a")),
+			exp:   true,
+		},
+		{
+			test:  "union",
+			query: Query(true, Child(Name("a"), Index(1))),
+			path:  Normalized(Index(1)),
+			exp:   true,
+		},
+		{
+			test:  "name_index",
+			query: Query(true, Child(Name("0"))),
+			path:  Normalized(Index(0)),
+			exp:   false,
+		},
+		{
+			test:  "index_name",
+			query: Query(true, Child(Index(0))),
+			path:  Normalized(Name("0")),
+			exp:   false,
+		},
+		{
+			test:  "wildcard",
+			query: Query(true, Child(Wildcard()), Child(Name("b"))),
+			path:  Normalized(Index(3), Name("b")),
+			exp:   true,
+		},
+		{
+			test:  "descendant",
+			query: Query(true, Descendant(Name("b"))),
+			path:  Normalized(Name("a"), Index(2), Name("b")),
+			exp:   true,
+		},
+		{
+			test:  "descendant_self",
+			query: Query(true, Child(Name("a")), Descendant(Wildcard())),
+			path:  Normalized(Name("a")),
+			exp:   false,
+		},
+		{
+			test:  "descendants",
+			query: Query(true, Descendant(Name("a")), Descendant(Name("b"))),
+			path:  Normalized(Name("a"), Name("x"), Name("b")),
+			exp:   true,
+		},
+		{
+			test:  "descendants_order",
+			query: Query(true, Descendant(Name("a")), Descendant(Name("b"))),
+			path:  Normalized(Name("b"), Name("x"), Name("a")),
+			exp:   false,
+		},
+		{
+			test:  "filter_member",
+			query: Query(true, Child(filter)),
+			path:  Normalized(Name("a")),
+			exp:   true,
+		},
+		{
+			test:  "filter_element",
+			query: Query(true, Child(filter)),
+			path:  Normalized(Index(4)),
+			exp:   true,
+		},
+		{
+			test:  "negative_index",
+			query: Query(true, Child(Index(-1))),
+			path:  Normalized(Index(4)),
+			exp:   true,
+		},
+		{
+			test:  "negative_index_name",
+			query: Query(true, Child(Index(-1))),
+			path:  Normalized(Name("a")),
+			exp:   false,
+		},
+		{
+			test:  "negative_path_index",
+			query: Query(true, Child(Index(0))),
+			path:  Normalized(Index(-1)),
+			exp:   false,
+		},
+		{
+			test:  "slice",
+			query: Query(true, Child(Slice(1, 6, 2))),
+			path:  Normalized(Index(3)),
+			exp:   true,
+		},
+		{
+			test:  "slice_step",
+			query: Query(true, Child(Slice(1, 6, 2))),
+			path:  Normalized(Index(4)),
+			exp:   false,
+		},
+		{
+			test:  "slice_end",
+			query: Query(true, Child(Slice(1, 6, 2))),
+			path:  Normalized(Index(7)),
+			exp:   false,
+		},
+		{
+			test:  "slice_name",
+			query: Query(true, Child(Slice())),
+			path:  Normalized(Name("a")),
+			exp:   false,
+		},
+		{
+			test:  "slice_negative_start",
+			query: Query(true, Child(Slice(-3, nil, 2))),
+			path:  Normalized(Index(8)),
+			exp:   true,
+		},
+		{
+			test:  "slice_negative_end",
+			query: Query(true, Child(Slice(2, -1, 2))),
+			path:  Normalized(Index(3)),
+			exp:   false,
+		},
+		{
+			test:  "slice_reverse",
+			query: Query(true, Child(Slice(nil, nil, -1))),
+			path:  Normalized(Index(100)),
+			exp:   true,
+		},
+		{
+			test:  "slice_reverse_bounds",
+			query: Query(true, Child(Slice(5, 2, -1))),
+			path:  Normalized(Index(2)),
+			exp:   false,
+		},
+		{
+			test:  "slice_reverse_step",
+			query: Query(true, Child(Slice(10, 0, -3))),
+			path:  Normalized(Index(5)),
+			exp:   true,
+		},
+		{
+			test:  "slice_zero_step",
+			query: Query(true, Child(Slice(0, math.MaxInt, 0))),
+			path:  Normalized(Index(0)),
+			exp:   false,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, tc.query.MatchesPath(tc.path))
+		})
+	}
+}
@@ -39,7 +39,11 @@ func Normalized(sel ...NormalSelector) NormalizedPath {
 	return NormalizedPath(sel)
 }
 
-// String returns the string representation of np.
+// String returns the string representation of np, formatted exactly as RFC
+// 9535 requires: names in single quotes, escaping only apostrophes,
+// backslashes, and control characters, and indexes in decimal. Indexes must
+// be non-negative, as are those in the paths returned by SelectLocated
+// methods, to identify array elements independent of array length.
 func (np NormalizedPath) String() string {
 	buf := new(strings.Builder)
 	buf.WriteRune('$')
@@ -114,6 +118,17 @@ type LocatedNode struct {
 	Path NormalizedPath `json:"path"`
 }
 
+// Key returns the name of the object member that n is and true, or false
+// if n is an array element or the root node, so that consumers can group
+// or sort nodes selected from objects by name without examining n.Path.
+func (n *LocatedNode) Key() (string, bool) {
+	if len(n.Path) == 0 {
+		return "", false
+	}
+	name, ok := n.Path[len(n.Path)-1].(Name)
+	return string(name), ok
+}
+
 // newLocatedNode creates and returns a new [LocatedNode]. It copies path.
 func newLocatedNode(path NormalizedPath, node any) *LocatedNode {
 	return &LocatedNode{
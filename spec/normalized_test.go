@@ -60,6 +60,18 @@ func TestNormalSelector(t *testing.T) {
 			str:  `['\u0001\u0002\u0003\u0004\u0005\u0006\u0007\u000e\u000f']`,
 			ptr:  "\u0001\u0002\u0003\u0004\u0005\u0006\u0007\u000e\u000F",
 		},
+		{
+			test: "escape_unicode_1x",
+			elem: Name("\u0010\u0011\u0015\u001a\u001F"),
+			str:  `['\u0010\u0011\u0015\u001a\u001f']`,
+			ptr:  "\u0010\u0011\u0015\u001a\u001F",
+		},
+		{
+			test: "no_escape_printable",
+			elem: Name("\" /~\u007f\u00e9\U0001F600"),
+			str:  "['\" /~\u007f\u00e9\U0001F600']",
+			ptr:  "\" ~1~0\u007f\u00e9\U0001F600",
+		},
 		{
 			test: "escape_pointer",
 			elem: Name("this / ~that"),
@@ -145,6 +157,9 @@ func TestNormalizedPath(t *testing.T) {
 
 			a.Equal(tc.str, tc.path.String())
 			a.Equal(tc.ptr, tc.path.Pointer())
+			text, err := tc.path.MarshalText()
+			a.NoError(err)
+			a.Equal(tc.str, string(text))
 		})
 	}
 }
@@ -262,6 +277,16 @@ func TestNormalizedPathCompare(t *testing.T) {
 
 func TestLocatedNode(t *testing.T) {
 	t.Parallel()
+	a := assert.New(t)
+
+	for _, node := range []*LocatedNode{
+		{Path: Normalized(), Node: 1},
+		{Path: Normalized(Name("a"), Index(0)), Node: 1},
+	} {
+		key, ok := node.Key()
+		a.False(ok)
+		a.Empty(key)
+	}
 
 	for _, tc := range []struct {
 		test string
@@ -287,6 +312,10 @@ func TestLocatedNode(t *testing.T) {
 		t.Run(tc.test, func(t *testing.T) {
 			t.Parallel()
 
+			key, ok := tc.node.Key()
+			assert.True(t, ok)
+			assert.Equal(t, string(tc.node.Path[0].(Name)), key)
+
 			json, err := json.Marshal(tc.node)
 			require.NoError(t, err)
 			assert.JSONEq(t, tc.exp, string(json))
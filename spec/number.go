@@ -0,0 +1,205 @@
+package spec
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+)
+
+// maxExactInt is the largest integer that converts to float64 exactly, such
+// that every integer between it and its negation also converts exactly.
+const maxExactInt = 1 << 53
+
+// Decimal defines the interface for arbitrary-precision decimal numbers,
+// such as those provided by third-party decimal packages. Filter expressions
+// compare Decimal values exactly with other Decimal values, integers,
+// [json.Number] values, and [*big.Int], [*big.Float], and [*big.Rat] values,
+// and as float64 values with float32 and float64 values.
+type Decimal interface {
+	// Rat returns the value of the decimal as a rational number.
+	Rat() *big.Rat
+}
+
+// isNumber returns true if val is a numeric value.
+func isNumber(val any) bool {
+	switch val.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, json.Number, *big.Int, *big.Float, *big.Rat, Decimal:
+		return true
+	default:
+		return false
+	}
+}
+
+// isExact returns true if val is a [json.Number] or an arbitrary-precision
+// number that must be compared without conversion to float64.
+func isExact(val any) bool {
+	switch val.(type) {
+	case json.Number, *big.Int, *big.Float, *big.Rat, Decimal:
+		return true
+	default:
+		return false
+	}
+}
+
+// isFloat returns true if val is a float32 or float64.
+func isFloat(val any) bool {
+	switch val.(type) {
+	case float32, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// isLargeInt returns true if val is an integer too large to convert to
+// float64 exactly.
+func isLargeInt(val any) bool {
+	switch val := val.(type) {
+	case int:
+		return int64(val) > maxExactInt || int64(val) < -maxExactInt
+	case int64:
+		return val > maxExactInt || val < -maxExactInt
+	case uint:
+		return uint64(val) > maxExactInt
+	case uint64:
+		return val > maxExactInt
+	default:
+		return false
+	}
+}
+
+// toRats converts left and right to [*big.Rat] values for exact comparison
+// and sets ok to true if at least one is an arbitrary-precision number, and
+// neither is a float32 or float64, which compare as float64 values. Also
+// sets ok to true if at least one is an integer too large to convert to
+// float64 exactly and the other is an integer or a finite float, so that
+// large integers, such as uint64 IDs, never compare equal to neighboring
+// values. Otherwise returns false for ok.
+func toRats(left, right any) (*big.Rat, *big.Rat, bool) {
+	switch {
+	case isExact(left) || isExact(right):
+		if isFloat(left) || isFloat(right) {
+			return nil, nil, false
+		}
+	case !isLargeInt(left) && !isLargeInt(right):
+		return nil, nil, false
+	}
+	l, ok := toExactRat(left)
+	if !ok {
+		return nil, nil, false
+	}
+	r, ok := toExactRat(right)
+	if !ok {
+		return nil, nil, false
+	}
+	return l, r, true
+}
+
+// toExactRat converts val to a [*big.Rat] if it is an integer, a finite
+// float, or an arbitrary-precision number, setting ok to true. Otherwise it
+// returns false for ok.
+func toExactRat(val any) (*big.Rat, bool) {
+	var f float64
+	switch val := val.(type) {
+	case float32:
+		f = float64(val)
+	case float64:
+		f = val
+	default:
+		return toRat(val)
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, false
+	}
+	return new(big.Rat).SetFloat64(f), true
+}
+
+// toRat converts val to a [*big.Rat] if it is an integer or an
+// arbitrary-precision number, setting ok to true. Otherwise it returns false
+// for ok.
+func toRat(val any) (*big.Rat, bool) {
+	switch val := val.(type) {
+	case int:
+		return new(big.Rat).SetInt64(int64(val)), true
+	case int8:
+		return new(big.Rat).SetInt64(int64(val)), true
+	case int16:
+		return new(big.Rat).SetInt64(int64(val)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(val)), true
+	case int64:
+		return new(big.Rat).SetInt64(val), true
+	case uint:
+		return new(big.Rat).SetUint64(uint64(val)), true
+	case uint8:
+		return new(big.Rat).SetUint64(uint64(val)), true
+	case uint16:
+		return new(big.Rat).SetUint64(uint64(val)), true
+	case uint32:
+		return new(big.Rat).SetUint64(uint64(val)), true
+	case uint64:
+		return new(big.Rat).SetUint64(val), true
+	case json.Number:
+		return new(big.Rat).SetString(string(val))
+	case *big.Int:
+		if val == nil {
+			return nil, false
+		}
+		return new(big.Rat).SetInt(val), true
+	case *big.Float:
+		if val == nil || val.IsInf() {
+			return nil, false
+		}
+		r, _ := val.Rat(nil)
+		return r, true
+	case *big.Rat:
+		return val, val != nil
+	case Decimal:
+		r := val.Rat()
+		return r, r != nil
+	default:
+		return nil, false
+	}
+}
+
+// bigToFloat converts val to a float64 if it is an arbitrary-precision
+// number, setting ok to true. Otherwise it returns false for ok.
+func bigToFloat(val any) (float64, bool) {
+	switch val := val.(type) {
+	case *big.Int:
+		if val == nil {
+			return 0, false
+		}
+		f, _ := new(big.Float).SetInt(val).Float64()
+		return f, true
+	case *big.Float:
+		if val == nil {
+			return 0, false
+		}
+		f, _ := val.Float64()
+		return f, true
+	case *big.Rat:
+		if val == nil {
+			return 0, false
+		}
+		f, _ := val.Float64()
+		return f, true
+	case Decimal:
+		r := val.Rat()
+		if r == nil {
+			return 0, false
+		}
+		f, _ := r.Float64()
+		return f, true
+	default:
+		return 0, false
+	}
+}
+
+// isZeroNumber returns true if val is an arbitrary-precision number equal to
+// zero.
+func isZeroNumber(val any) bool {
+	r, ok := toRat(val)
+	return ok && r.Sign() == 0
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 )
 
 // CompOp defines the JSONPath [filter comparison operators].
@@ -29,6 +30,55 @@ const (
 	GreaterThanEqualTo // >=
 )
 
+// Comparable defines the interface for user-defined scalar types, such as
+// UUIDs, decimals, or enums, that participate in filter expression
+// comparisons. The comparison operators consult Comparable before any other
+// comparison, calling CompareTo on the left operand if it implements
+// Comparable, and otherwise on the right operand.
+type Comparable interface {
+	// CompareTo compares the receiver to other and returns a negative number
+	// if the receiver is less than other, zero if they're equal, or a
+	// positive number if the receiver is greater than other, together with
+	// true. Returns false if the receiver cannot be compared to other, in
+	// which case the values are neither equal nor ordered.
+	CompareTo(other any) (int, bool)
+}
+
+// Collator defines the interface for collating strings in ordering
+// comparisons, which otherwise compare strings by the Unicode code points
+// of their characters. Set one with [Options].Collator. The
+// *collate.Collator type of [golang.org/x/text/collate] implements it, so
+// that, for example, a collator for German sorts "ä" between "a" and "b".
+// Collators must be safe for concurrent use if queries are evaluated
+// concurrently.
+//
+// [golang.org/x/text/collate]: https://pkg.go.dev/golang.org/x/text/collate
+type Collator interface {
+	// CompareString returns a negative number if a sorts before b, zero if
+	// they sort equally, or a positive number if a sorts after b.
+	CompareString(a, b string) int
+}
+
+// compare uses [Comparable] to compare left and right. Returns false if
+// neither implements Comparable or if they cannot be compared.
+func compare(left, right any) (int, bool) {
+	if c, ok := left.(Comparable); ok {
+		return c.CompareTo(right)
+	}
+	if c, ok := right.(Comparable); ok {
+		cmp, ok := c.CompareTo(left)
+		return -cmp, ok
+	}
+	return 0, false
+}
+
+// isComparable returns true if left or right implements [Comparable].
+func isComparable(left, right any) bool {
+	_, lok := left.(Comparable)
+	_, rok := right.(Comparable)
+	return lok || rok
+}
+
 // CompVal defines the interface for comparable values in filter
 // expressions. Implemented by:
 //
@@ -38,7 +88,7 @@ const (
 type CompVal interface {
 	stringWriter
 	// asValue returns the value to be compared.
-	asValue(current, root any) PathValue
+	asValue(current any, ev *evaluation) PathValue
 }
 
 // CompExpr is a filter expression that compares two values, which themselves
@@ -58,6 +108,15 @@ func Comparison(left CompVal, op CompOp, right CompVal) *CompExpr {
 	return &CompExpr{left, op, right}
 }
 
+// Left returns the left operand of ce.
+func (ce *CompExpr) Left() CompVal { return ce.left }
+
+// Op returns the comparison operator of ce.
+func (ce *CompExpr) Op() CompOp { return ce.op }
+
+// Right returns the right operand of ce.
+func (ce *CompExpr) Right() CompVal { return ce.right }
+
 // writeTo writes a string representation of ce to buf. Defined by
 // [stringWriter].
 func (ce *CompExpr) writeTo(buf *strings.Builder) {
@@ -75,10 +134,103 @@ func (ce *CompExpr) String() string {
 
 // testFilter uses ce.Op to compare the values returned by ce.Left and
 // ce.Right relative to current and root. Defined by [BasicExpr].
-func (ce *CompExpr) testFilter(current, root any) bool {
-	left := ce.left.asValue(current, root)
-	right := ce.right.asValue(current, root)
-	switch ce.op {
+func (ce *CompExpr) testFilter(current any, ev *evaluation) bool {
+	if ce.op.isOrdering() && (ev.checked() || ev.opts.Collator != nil) {
+		return ce.testOrdering(current, ev)
+	}
+
+	// Compare scalars without allocating ValueTypes where possible.
+	if ls, ok := ce.left.(scalarVal); ok {
+		if rs, ok := ce.right.(scalarVal); ok {
+			left, right := ls.asScalar(current, ev), rs.asScalar(current, ev)
+			if left.kind != scalarOther && right.kind != scalarOther {
+				return compareScalars(ce.op, left, right)
+			}
+			return ce.op.compare(left.pathValue(), right.pathValue())
+		}
+	}
+	return ce.op.compare(ce.left.asValue(current, ev), ce.right.asValue(current, ev))
+}
+
+// testOrdering implements testFilter for ordering comparisons when ev
+// reports soft failures, which include comparisons of values of different
+// types, which are never ordered, or collates strings.
+func (ce *CompExpr) testOrdering(current any, ev *evaluation) bool {
+	left, right := ce.left.asValue(current, ev), ce.right.asValue(current, ev)
+	if ev.checked() && left != nil && right != nil && !sameType(left, right) {
+		ev.fail(
+			"jsonpath: comparison of values of different types",
+			"expr", ce.String(),
+			"left", valueTypeName(left),
+			"right", valueTypeName(right),
+		)
+		return false
+	}
+	if cmp, ok := ev.collate(left, right); ok {
+		return ce.op.ordered(cmp)
+	}
+	return ce.op.compare(left, right)
+}
+
+// collate uses ev's [Collator], if any, to compare left and right if both
+// are strings. Returns false if ev has no Collator or either is not a
+// string.
+func (ev *evaluation) collate(left, right PathValue) (int, bool) {
+	if ev.opts.Collator == nil {
+		return 0, false
+	}
+	lv, lok := left.(*ValueType)
+	rv, rok := right.(*ValueType)
+	if !lok || !rok {
+		return 0, false
+	}
+	ls, lok := lv.any.(string)
+	rs, rok := rv.any.(string)
+	if !lok || !rok {
+		return 0, false
+	}
+	return ev.opts.Collator.CompareString(ls, rs), true
+}
+
+// ordered returns true if cmp, the result of comparing two values, satisfies
+// op, which must be an ordering operator.
+func (op CompOp) ordered(cmp int) bool {
+	switch op {
+	case LessThan:
+		return cmp < 0
+	case GreaterThan:
+		return cmp > 0
+	case LessThanEqualTo:
+		return cmp <= 0
+	case GreaterThanEqualTo:
+		return cmp >= 0
+	default:
+		panic(fmt.Sprintf("Unknown operator %v", op))
+	}
+}
+
+// isOrdering returns true if op is <, >, <=, or >=.
+func (op CompOp) isOrdering() bool {
+	return op != EqualTo && op != NotEqualTo
+}
+
+// valueTypeName returns the name of the Go type of the value in val, a
+// comparison operand.
+func valueTypeName(val PathValue) string {
+	switch v := val.(type) {
+	case *ValueType:
+		return fmt.Sprintf("%T", v.any)
+	case NodesType:
+		if len(v) == 1 {
+			return fmt.Sprintf("%T", v[0])
+		}
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+// compare uses op to compare left and right.
+func (op CompOp) compare(left, right PathValue) bool {
+	switch op {
 	case EqualTo:
 		return equalTo(left, right)
 	case NotEqualTo:
@@ -92,10 +244,34 @@ func (ce *CompExpr) testFilter(current, root any) bool {
 	case GreaterThanEqualTo:
 		return sameType(left, right) && !lessThan(left, right)
 	default:
-		panic(fmt.Sprintf("Unknown operator %v", ce.op))
+		panic(fmt.Sprintf("Unknown operator %v", op))
 	}
 }
 
+// Equal returns true if a and b are equal as the == operator compares the
+// values it selects in filter expressions, so that code that filters
+// results after selecting them matches the behavior of queries. Numbers of
+// different types compare by value, exactly for integers, [json.Number],
+// [math/big] values, and [Decimal] values, and as float64 values if either
+// is a float. A [time.Time] compares to another time or to a string in RFC
+// 3339 format, and a [Comparable] value to any value it can compare to.
+// Other values, including strings, booleans, nil (JSON null), objects, and
+// arrays, compare with [reflect.DeepEqual], so that objects and arrays that
+// contain numbers of different types are not equal.
+func Equal(a, b any) bool {
+	return EqualTo.compare(Value(a), Value(b))
+}
+
+// Less returns true if a is less than b as the < operator compares the
+// values it selects in filter expressions. Numbers, strings, times, and
+// [Comparable] values compare as for [Equal], and strings compare by the
+// Unicode code points of their characters. Returns false for values of
+// different types, such as 1 and "2", and for booleans, nil, objects, and
+// arrays, which are unordered.
+func Less(a, b any) bool {
+	return LessThan.compare(Value(a), Value(b))
+}
+
 // equalTo returns true if left and right are nils, or if both are [ValueType]
 // values and [valueEqualTo] returns true for their underlying values.
 // Otherwise it returns false.
@@ -143,12 +319,28 @@ func toFloat(val any) (float64, bool) {
 		f, err := val.Float64()
 		return f, err == nil
 	default:
-		return 0, false
+		return bigToFloat(val)
 	}
 }
 
-// valueEqualTo returns true if left and right are equal.
+// valueEqualTo returns true if left and right are equal. Uses [Comparable]
+// if either implements it. Otherwise compares arbitrary-precision numbers
+// exactly unless one side is a float, and compares a [time.Time] to another
+// time or to an RFC 3339 string.
 func valueEqualTo(left, right any) bool {
+	if isComparable(left, right) {
+		cmp, ok := compare(left, right)
+		return ok && cmp == 0
+	}
+
+	if left, right, ok := toRats(left, right); ok {
+		return left.Cmp(right) == 0
+	}
+
+	if left, right, ok := toTimes(left, right); ok {
+		return left.Equal(right)
+	}
+
 	if left, ok := toFloat(left); ok {
 		if right, ok := toFloat(right); ok {
 			return left == right
@@ -159,6 +351,31 @@ func valueEqualTo(left, right any) bool {
 	return reflect.DeepEqual(left, right)
 }
 
+// toTimes converts left and right to [time.Time] values and sets ok to true
+// if at least one is a time.Time and the other is either a time.Time or a
+// string in RFC 3339 format. Otherwise it returns false for ok.
+func toTimes(left, right any) (time.Time, time.Time, bool) {
+	lt, lok := left.(time.Time)
+	rt, rok := right.(time.Time)
+	switch {
+	case lok && rok:
+		return lt, rt, true
+	case lok:
+		if str, ok := right.(string); ok {
+			if rt, err := time.Parse(time.RFC3339Nano, str); err == nil {
+				return lt, rt, true
+			}
+		}
+	case rok:
+		if str, ok := left.(string); ok {
+			if lt, err := time.Parse(time.RFC3339Nano, str); err == nil {
+				return lt, rt, true
+			}
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
 // lessThan returns true if left and right are both ValueTypes and
 // [valueLessThan] returns true for their underlying values. Otherwise it
 // returns false.
@@ -214,21 +431,41 @@ func sameType(left, right PathValue) bool {
 }
 
 // valCompType returns true if left and right are comparable types, which
-// means either both are a numeric type or are otherwise the same type.
+// means either one implements [Comparable] and can be compared to the other,
+// both are a numeric type, or are otherwise the same type.
 func valCompType(left, right any) bool {
-	switch left.(type) {
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
-		switch right.(type) {
-		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
-			return true
-		}
+	if isComparable(left, right) {
+		_, ok := compare(left, right)
+		return ok
+	}
+	if isNumber(left) && isNumber(right) {
+		return true
+	}
+	if _, _, ok := toTimes(left, right); ok {
+		return true
 	}
 	return reflect.TypeOf(left) == reflect.TypeOf(right)
 }
 
-// valueLessThan returns true if left and right are both numeric values or
-// string values and left is less than right.
+// valueLessThan returns true if left and right are both numeric values,
+// string values, or times and left is less than right. Uses [Comparable] if
+// either implements it. Otherwise compares arbitrary-precision numbers
+// exactly unless one side is a float, and compares a [time.Time] to another
+// time or to an RFC 3339 string.
 func valueLessThan(left, right any) bool {
+	if isComparable(left, right) {
+		cmp, ok := compare(left, right)
+		return ok && cmp < 0
+	}
+
+	if left, right, ok := toRats(left, right); ok {
+		return left.Cmp(right) < 0
+	}
+
+	if left, right, ok := toTimes(left, right); ok {
+		return left.Before(right)
+	}
+
 	if left, ok := toFloat(left); ok {
 		if right, ok := toFloat(right); ok {
 			return left < right
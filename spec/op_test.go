@@ -1,9 +1,14 @@
 package spec
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -26,6 +31,44 @@ func TestCompOp(t *testing.T) {
 	}
 }
 
+// decimal implements Decimal for testing.
+type decimal string
+
+func (d decimal) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(string(d))
+	return r
+}
+
+// version implements Comparable for testing, comparing to other versions
+// and to strings in "major.minor" format.
+type version struct{ major, minor int }
+
+func (v version) CompareTo(other any) (int, bool) {
+	switch o := other.(type) {
+	case version:
+		if c := cmp.Compare(v.major, o.major); c != 0 {
+			return c, true
+		}
+		return cmp.Compare(v.minor, o.minor), true
+	case string:
+		var ov version
+		if _, err := fmt.Sscanf(o, "%d.%d", &ov.major, &ov.minor); err != nil {
+			return 0, false
+		}
+		return v.CompareTo(ov)
+	default:
+		return 0, false
+	}
+}
+
+//nolint:gochecknoglobals
+var noon = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func bigInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 10)
+	return i
+}
+
 func TestEqualTo(t *testing.T) {
 	t.Parallel()
 
@@ -76,6 +119,50 @@ func TestEqualTo(t *testing.T) {
 		{"json_number_invalid", json.Number("not a number"), json.Number("0.0"), false},
 		{"int_float_true", int64(10), float64(10), true},
 		{"int_float_false", int64(10), float64(11), false},
+		{"json_number_precise", json.Number("12345678901234567891"), json.Number("12345678901234567892"), false},
+		{"json_number_int", json.Number("9007199254740993"), int64(9007199254740993), true},
+		{"json_number_float", json.Number("0.1"), 0.1, true},
+		{"int64_large_ne", int64(1<<53 + 1), int64(1 << 53), false},
+		{"int64_large_eq", int64(1<<53 + 1), int64(1<<53 + 1), true},
+		{"int64_large_neg_ne", int64(-1<<53 - 1), int64(-1 << 53), false},
+		{"int_large_float_ne", 1<<53 + 1, float64(1 << 53), false},
+		{"float_int_large_ne", float64(1 << 53), int64(1<<53 + 1), false},
+		{"int64_large_float_eq", int64(1 << 62), float64(1 << 62), true},
+		{"uint64_max_ne", uint64(math.MaxUint64), uint64(math.MaxUint64 - 1), false},
+		{"uint64_max_eq", uint64(math.MaxUint64), uint64(math.MaxUint64), true},
+		{"uint64_max_float_ne", uint64(math.MaxUint64), float64(math.MaxUint64), false},
+		{"uint64_large_int64_eq", uint64(math.MaxInt64), int64(math.MaxInt64), true},
+		{"uint64_large_int64_ne", uint64(math.MaxInt64 + 1), int64(math.MaxInt64), false},
+		{"uint_large_json_number", uint(1<<53 + 1), json.Number("9007199254740993"), true},
+		{"float32_int_large", float32(1 << 60), int64(1 << 60), true},
+		{"int_large_inf", int64(math.MaxInt64), math.Inf(1), false},
+		{"int_large_nan", int64(math.MaxInt64), math.NaN(), false},
+		{"int_large_string", int64(math.MaxInt64), "9223372036854775807", false},
+		{"big_int_eq", bigInt("123456789012345678901234567890"), json.Number("123456789012345678901234567890"), true},
+		{"big_int_ne", bigInt("123456789012345678901234567890"), bigInt("123456789012345678901234567891"), false},
+		{"big_int_int", bigInt("42"), 42, true},
+		{"big_int_float", bigInt("42"), 42.0, true},
+		{"big_float_eq", big.NewFloat(1.5), json.Number("1.5"), true},
+		{"big_float_inf", new(big.Float).SetInf(false), json.Number("1.5"), false},
+		{"big_rat_eq", big.NewRat(1, 4), decimal("0.25"), true},
+		{"decimal_eq", decimal("1234567890.123456789012345678"), json.Number("1234567890.123456789012345678"), true},
+		{"decimal_ne", decimal("1234567890.123456789012345678"), json.Number("1234567890.123456789012345679"), false},
+		{"decimal_float", decimal("0.1"), 0.1, true},
+		{"decimal_invalid", decimal("x"), json.Number("0"), false},
+		{"nil_big_int", (*big.Int)(nil), 0, false},
+		{"times_eq", noon, noon.In(time.FixedZone("x", 3600)), true},
+		{"times_ne", noon, noon.Add(time.Nanosecond), false},
+		{"time_string_eq", noon, "2024-06-01T14:00:00+02:00", true},
+		{"string_time_eq", "2024-06-01T12:00:00.000Z", noon, true},
+		{"time_string_ne", noon, "2024-06-01T12:00:01Z", false},
+		{"time_invalid_string", noon, "noon", false},
+		{"time_number", noon, noon.Unix(), false},
+		{"comparable_eq", version{1, 2}, version{1, 2}, true},
+		{"comparable_ne", version{1, 2}, version{1, 3}, false},
+		{"comparable_string", version{1, 2}, "1.2", true},
+		{"string_comparable", "1.2", version{1, 2}, true},
+		{"comparable_bad_string", version{1, 2}, "x", false},
+		{"comparable_number", version{1, 2}, 1.2, false},
 		{"empty_strings", "", "", true},
 		{"strings", "xyz", "xyz", true},
 		{"strings_false", "xyz", "abc", false},
@@ -111,6 +198,114 @@ func TestEqualTo(t *testing.T) {
 	})
 }
 
+func TestEqualLess(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test  string
+		left  any
+		right any
+		equal bool
+		less  bool
+	}{
+		{"ints", 1, 2, false, true},
+		{"int_float", 2, 2.0, true, false},
+		{"int8_uint64", int8(3), uint64(4), false, true},
+		{"float32_float64", float32(1.5), 1.5, true, false},
+		{"number_int", json.Number("12345678901234567890"), 3, false, false},
+		{"big_numbers", bigInt("12345678901234567890"), json.Number("12345678901234567891"), false, true},
+		{"decimal_float", decimal("0.5"), 0.5, true, false},
+		{"strings", "a", "b", false, true},
+		{"string_code_points", "z", "é", false, true},
+		{"same_strings", "a", "a", true, false},
+		{"string_number", "1", 1, false, false},
+		{"bools", false, true, false, false},
+		{"same_bools", true, true, true, false},
+		{"nils", nil, nil, true, false},
+		{"nil_false", nil, false, false, false},
+		{"times", noon, noon.Add(time.Hour), false, true},
+		{"time_string", noon, "2024-06-01T12:00:00Z", true, false},
+		{"comparable", version{1, 2}, "1.10", false, true},
+		{"objects", map[string]any{"a": 1}, map[string]any{"a": 1}, true, false},
+		{"objects_mixed_numbers", map[string]any{"a": 1}, map[string]any{"a": 1.0}, false, false},
+		{"arrays", []any{1, "x"}, []any{1, "x"}, true, false},
+		{"arrays_differ", []any{1}, []any{2}, false, false},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			a.Equal(tc.equal, Equal(tc.left, tc.right))
+			a.Equal(tc.equal, Equal(tc.right, tc.left))
+			a.Equal(tc.less, Less(tc.left, tc.right))
+			a.False(Less(tc.right, tc.left) && tc.less)
+
+			// Should agree with filter expressions.
+			input := []any{[]any{tc.left, tc.right}}
+			for _, op := range []struct {
+				op  CompOp
+				exp bool
+			}{{EqualTo, tc.equal}, {LessThan, tc.less}} {
+				q := Query(true, Child(Filter(And(Comparison(
+					SingularQuery(false, Index(0)), op.op, SingularQuery(false, Index(1)),
+				)))))
+				a.Equal(op.exp, len(q.Select(nil, input)) == 1, op.op.String())
+			}
+		})
+	}
+}
+
+// foldCollator collates strings case-insensitively.
+type foldCollator struct{}
+
+func (*foldCollator) CompareString(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func TestCollator(t *testing.T) {
+	t.Parallel()
+
+	input := []any{"apple", "Banana", "cherry", "Apple", 3, true}
+	for _, tc := range []struct {
+		test  string
+		op    CompOp
+		right any
+		exp   []any
+		plain []any
+	}{
+		{"lt", LessThan, "b", []any{"apple", "Apple"}, []any{"apple", "Banana", "Apple"}},
+		{"le", LessThanEqualTo, "APPLE", []any{"apple", "Apple"}, []any{}},
+		{"gt", GreaterThan, "b", []any{"Banana", "cherry"}, []any{"cherry"}},
+		{"ge", GreaterThanEqualTo, "Cherry", []any{"cherry"}, []any{"apple", "cherry"}},
+		{"eq", EqualTo, "APPLE", []any{}, []any{}},
+		{"ne", NotEqualTo, "apple", []any{"Banana", "cherry", "Apple", 3, true}, []any{"Banana", "cherry", "Apple", 3, true}},
+		{"numbers", LessThan, 5, []any{3}, []any{3}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			q := Query(true, Child(Filter(And(Comparison(
+				SingularQuery(false), tc.op, Literal(tc.right),
+			)))))
+			a.Equal(tc.plain, q.SelectWith(nil, input, Options{}))
+			a.Equal(tc.exp, q.SelectWith(nil, input, Options{Collator: &foldCollator{}}))
+		})
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		q := Query(true, Child(Filter(And(Comparison(
+			SingularQuery(false), LessThan, Literal("b"),
+		)))))
+		opts := Options{Collator: &foldCollator{}, Strict: true}
+		res, err := q.TrySelect(nil, []any{"Apple", "cherry"}, opts)
+		a.NoError(err)
+		a.Equal([]any{"Apple"}, res)
+		_, err = q.TrySelect(nil, []any{"Apple", 3}, opts)
+		a.ErrorIs(err, ErrEvaluation)
+	})
+}
+
 func TestLessThan(t *testing.T) {
 	t.Parallel()
 
@@ -160,6 +355,38 @@ func TestLessThan(t *testing.T) {
 		{"int_float_false", 99, 98.6, false},
 		{"float_int_false", 98.6, 98, false},
 		{"float_int_true", 98.6, 99, true},
+		{"int64_large_lt", int64(1 << 53), int64(1<<53 + 1), true},
+		{"int64_large_gt", int64(1<<53 + 1), int64(1 << 53), false},
+		{"int_large_float_lt", float64(1 << 53), 1<<53 + 1, true},
+		{"float_int_large_gt", 1<<53 + 1, float64(1 << 53), false},
+		{"uint64_max_lt", uint64(math.MaxUint64 - 1), uint64(math.MaxUint64), true},
+		{"uint64_max_float_lt", uint64(math.MaxUint64), float64(math.MaxUint64), true},
+		{"int64_uint64_lt", int64(math.MaxInt64), uint64(math.MaxInt64 + 1), true},
+		{"int64_neg_uint64_lt", int64(math.MinInt64), uint64(math.MaxUint64), true},
+		{"int_large_inf_lt", int64(math.MaxInt64), math.Inf(1), true},
+		{"neg_inf_int_large_lt", math.Inf(-1), int64(math.MinInt64), true},
+		{"int_large_nan", int64(math.MaxInt64), math.NaN(), false},
+		{"json_number_precise", json.Number("12345678901234567891"), json.Number("12345678901234567892"), true},
+		{"big_int_json_number", bigInt("99999999999999999999"), json.Number("100000000000000000000"), true},
+		{"big_int_int", bigInt("-1"), 0, true},
+		{"big_float_float", big.NewFloat(1.5), 1.6, true},
+		{"big_rat_decimal", big.NewRat(1, 3), decimal("0.3333333333333333333334"), true},
+		{"decimal_decimal", decimal("0.30000000000000000001"), decimal("0.3"), false},
+		{"decimal_float", decimal("0.1"), 0.2, true},
+		{"nil_big_rat", (*big.Rat)(nil), 1, false},
+		{"times_lt", noon, noon.Add(time.Second), true},
+		{"times_gt", noon.Add(time.Second), noon, false},
+		{"times_eq", noon, noon, false},
+		{"time_string_lt", noon, "2024-06-01T12:00:00.5Z", true},
+		{"time_string_gt", noon, "2024-06-01T11:59:59Z", false},
+		{"string_time_lt", "2024-06-01T13:00:00+02:00", noon, true},
+		{"time_invalid_string", noon, "2025", false},
+		{"comparable_lt", version{1, 2}, version{1, 10}, true},
+		{"comparable_gt", version{2, 0}, version{1, 10}, false},
+		{"comparable_string_lt", version{1, 2}, "1.3", true},
+		{"string_comparable_lt", "1.2", version{1, 3}, true},
+		{"string_comparable_gt", "1.4", version{1, 3}, false},
+		{"comparable_number", version{1, 2}, 2, false},
 		{"empty_string_sting", "", "x", true},
 		{"empty_strings", "", "", false},
 		{"string_a_b", "a", "b", true},
@@ -215,6 +442,16 @@ func TestSameType(t *testing.T) {
 		{"nil_vals", Value(nil), Value(nil), true},
 		{"int_float_vals", Value(1), Value(98.6), true},
 		{"int64_uint32_vals", Value(int64(1)), Value(uint32(8)), true},
+		{"big_int_float_vals", Value(big.NewInt(1)), Value(98.6), true},
+		{"decimal_json_number_vals", Value(decimal("1")), Value(json.Number("2")), true},
+		{"big_rat_string_vals", Value(big.NewRat(1, 2)), Value("x"), false},
+		{"time_vals", Value(noon), Value(noon), true},
+		{"time_string_vals", Value(noon), Value("2024-06-01T12:00:00Z"), true},
+		{"string_time_nodes", Nodes("2024-06-01T12:00:00Z"), Nodes(noon), true},
+		{"time_invalid_string_vals", Value(noon), Value("today"), false},
+		{"comparable_vals", Value(version{1, 0}), Value(version{2, 0}), true},
+		{"comparable_string_vals", Value("1.0"), Value(version{2, 0}), true},
+		{"comparable_incomparable_vals", Value(version{1, 0}), Value(true), false},
 		{"int_bool_vals", Value(1), Value(false), false},
 		{"string_obj_vals", Value("hi"), Value(map[string]any{}), false},
 		{"int64_array_vals", Value(int64(9)), Value([]any{}), false},
@@ -407,7 +644,10 @@ func TestComparisonExpr(t *testing.T) {
 					a := assert.New(t)
 
 					cmp := Comparison(tc.left, op.op, tc.right)
-					a.Equal(tc.expect[i], cmp.testFilter(tc.current, tc.root))
+					a.Equal(tc.left, cmp.Left())
+					a.Equal(op.op, cmp.Op())
+					a.Equal(tc.right, cmp.Right())
+					a.Equal(tc.expect[i], cmp.testFilter(tc.current, &evaluation{root: tc.root}))
 					a.Equal(fmt.Sprintf(tc.str, op.op), bufString(cmp))
 				})
 			}
@@ -420,7 +660,7 @@ func TestComparisonExpr(t *testing.T) {
 			cmp := Comparison(tc.left, CompOp(16), tc.right)
 			a.Equal(fmt.Sprintf(tc.str, cmp.op), bufString(cmp))
 			a.PanicsWithValue("Unknown operator CompOp(16)", func() {
-				cmp.testFilter(tc.current, tc.root)
+				cmp.testFilter(tc.current, &evaluation{root: tc.root})
 			})
 		})
 	}
@@ -0,0 +1,188 @@
+package spec
+
+import "slices"
+
+// minIndexRun is the minimum number of consecutive [Index] selectors that
+// [PathQuery.Optimize] folds into a [SliceSelector].
+const minIndexRun = 3
+
+// Optimize returns a copy of q rewritten to select the same values in the
+// same order with less work. It:
+//
+//   - Folds runs of three or more consecutive indexes in a segment into
+//     slices, e.g., [0,1,2,3] into [0:4] and [-1,-2,-3] into [-1:-4:-1]
+//   - Replaces filters true for every value, such as ?@, with wildcards
+//   - Removes selectors that cannot select any value, such as [1:1],
+//     [::0], and ?!@
+//   - Drops the segments following a segment in which every selector cannot
+//     select any value, as the query can then select nothing
+//
+// Returns q itself if none of these rewrites apply.
+func (q *PathQuery) Optimize() *PathQuery {
+	segs := make([]*Segment, 0, len(q.segments))
+	changed := false
+	for _, seg := range q.segments {
+		sels, empty := optimizeSelectors(seg.selectors)
+		if slices.Equal(sels, seg.selectors) {
+			segs = append(segs, seg)
+		} else {
+			changed = true
+			segs = append(segs, &Segment{
+				selectors:  sels,
+				descendant: seg.descendant,
+				names:      newNameSet(sels),
+			})
+		}
+		if empty {
+			changed = changed || len(segs) < len(q.segments)
+			break
+		}
+	}
+
+	if !changed {
+		return q
+	}
+	return Query(q.root, segs...)
+}
+
+// optimizeSelectors optimizes sels for [PathQuery.Optimize]. Returns true
+// and a single selector from sels if none of them can select any value.
+func optimizeSelectors(sels []Selector) ([]Selector, bool) {
+	res := make([]Selector, 0, len(sels))
+	var empty Selector
+	for i := 0; i < len(sels); {
+		if n := indexRun(sels[i:]); n >= minIndexRun {
+			res = append(res, foldIndexes(sels[i:i+n]))
+			i += n
+			continue
+		}
+
+		switch sel := sels[i]; {
+		case selectsNothing(sel):
+			if empty == nil {
+				empty = sel
+			}
+		case selectsAll(sel):
+			res = append(res, Wildcard())
+		default:
+			res = append(res, sel)
+		}
+		i++
+	}
+
+	if len(res) == 0 {
+		return []Selector{empty}, true
+	}
+	return res, false
+}
+
+// indexRun returns the number of [Index] selectors at the start of sels
+// that form a sequence of consecutive indexes of the same sign, ascending
+// or descending.
+func indexRun(sels []Selector) int {
+	first, ok := sels[0].(Index)
+	if !ok || len(sels) == 1 {
+		return 1
+	}
+
+	n, prev := 1, first
+	step := Index(0)
+	for _, sel := range sels[1:] {
+		idx, ok := sel.(Index)
+		if !ok || (idx < 0) != (first < 0) {
+			break
+		}
+		if step == 0 && (idx-prev == 1 || idx-prev == -1) {
+			step = idx - prev
+		}
+		if step == 0 || idx-prev != step {
+			break
+		}
+		n++
+		prev = idx
+	}
+	return n
+}
+
+// foldIndexes folds run, a sequence of consecutive indexes returned by
+// [indexRun], into a [SliceSelector] that selects the same values.
+func foldIndexes(run []Selector) SliceSelector {
+	first, last := int(run[0].(Index)), int(run[len(run)-1].(Index))
+	step := 1
+	if last < first {
+		step = -1
+	}
+
+	// Select through the end or start of the array rather than past it,
+	// which for -1 and 0 would wrap around to the other end.
+	if (step > 0 && last == -1) || (step < 0 && last == 0) {
+		return Slice(first, nil, step)
+	}
+	return Slice(first, last+step, step)
+}
+
+// selectsNothing returns true if sel can never select a value: a slice
+// with a step of zero or bounds of the same sign that select no index, or
+// a filter that is false for every value.
+func selectsNothing(sel Selector) bool {
+	switch sel := sel.(type) {
+	case SliceSelector:
+		if (sel.start < 0) != (sel.end < 0) {
+			return false
+		}
+		switch {
+		case sel.step > 0:
+			return sel.start >= sel.end
+		case sel.step < 0:
+			return sel.start <= sel.end
+		default:
+			return true
+		}
+	case *FilterSelector:
+		for _, and := range sel.LogicalOr {
+			if !slices.ContainsFunc(and, isCurrentNonexistence) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// selectsAll returns true if sel is a filter that is true for every value,
+// which therefore selects the same values as a wildcard.
+func selectsAll(sel Selector) bool {
+	f, ok := sel.(*FilterSelector)
+	if !ok {
+		return false
+	}
+	for _, and := range f.LogicalOr {
+		if len(and) > 0 && !slices.ContainsFunc(and, func(e BasicExpr) bool {
+			return !isCurrentExistence(e)
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCurrentExistence returns true if expr is @, the existence test for the
+// current value, which is always true.
+func isCurrentExistence(expr BasicExpr) bool {
+	e, ok := expr.(*ExistExpr)
+	return ok && !e.root && len(e.segments) == 0
+}
+
+// isCurrentNonexistence returns true if expr is !@, the nonexistence test
+// for the current value, which is always false.
+func isCurrentNonexistence(expr BasicExpr) bool {
+	switch e := expr.(type) {
+	case *NonExistExpr:
+		return !e.root && len(e.segments) == 0
+	case NonExistExpr:
+		return !e.root && len(e.segments) == 0
+	default:
+		return false
+	}
+}
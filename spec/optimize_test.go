@@ -0,0 +1,163 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptimize(t *testing.T) {
+	t.Parallel()
+
+	current := Existence(Query(false))
+	notCurrent := Nonexistence(Query(false))
+	hasX := Existence(Query(false, Child(Name("x"))))
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		exp   string
+		same  bool
+	}{
+		{
+			test:  "no_segments",
+			query: Query(true),
+			exp:   "$",
+			same:  true,
+		},
+		{
+			test:  "nothing_to_optimize",
+			query: Query(true, Child(Name("a")), Descendant(Index(0), Index(1)), Child(Slice(1, 3))),
+			exp:   `$["a"]..[0,1][1:3]`,
+			same:  true,
+		},
+		{
+			test:  "ascending_indexes",
+			query: Query(true, Child(Index(0), Index(1), Index(2), Index(3))),
+			exp:   `$[:4]`,
+		},
+		{
+			test:  "descending_indexes",
+			query: Query(true, Child(Index(5), Index(4), Index(3))),
+			exp:   `$[5:2:-1]`,
+		},
+		{
+			test:  "descending_to_zero",
+			query: Query(true, Child(Index(2), Index(1), Index(0))),
+			exp:   `$[2::-1]`,
+		},
+		{
+			test:  "ascending_negative",
+			query: Query(true, Child(Index(-5), Index(-4), Index(-3))),
+			exp:   `$[-5:-2]`,
+		},
+		{
+			test:  "ascending_to_minus_one",
+			query: Query(true, Child(Index(-3), Index(-2), Index(-1))),
+			exp:   `$[-3:]`,
+		},
+		{
+			test:  "descending_negative",
+			query: Query(true, Child(Index(-1), Index(-2), Index(-3))),
+			exp:   `$[-1:-4:-1]`,
+		},
+		{
+			test:  "mixed_sign_run",
+			query: Query(true, Child(Index(-2), Index(-1), Index(0), Index(1))),
+			exp:   `$[-2,-1,0,1]`,
+			same:  true,
+		},
+		{
+			test:  "short_runs",
+			query: Query(true, Child(Index(0), Index(1), Index(3), Index(4))),
+			exp:   `$[0,1,3,4]`,
+			same:  true,
+		},
+		{
+			test: "runs_among_selectors",
+			query: Query(true, Child(
+				Name("a"), Index(1), Index(2), Index(3), Index(3), Index(2), Index(1), Index(7),
+			)),
+			exp: `$["a",1:4,3:0:-1,7]`,
+		},
+		{
+			test:  "filter_current",
+			query: Query(true, Descendant(Filter(And(current)))),
+			exp:   `$..[*]`,
+		},
+		{
+			test:  "filter_or_current",
+			query: Query(true, Child(Filter(And(hasX), And(current, current)))),
+			exp:   `$[*]`,
+		},
+		{
+			test:  "filter_and_current",
+			query: Query(true, Child(Filter(And(hasX, current)))),
+			exp:   `$[?@["x"] && @]`,
+			same:  true,
+		},
+		{
+			test:  "drop_empty_selectors",
+			query: Query(true, Child(Slice(1, 1), Name("a"), Slice(nil, nil, 0), Filter(And(notCurrent)))),
+			exp:   `$["a"]`,
+		},
+		{
+			test:  "keep_unprovable_slices",
+			query: Query(true, Child(Slice(-1, 0), Slice(nil, nil, -1), Slice(5))),
+			exp:   `$[-1:0,::-1,5:]`,
+			same:  true,
+		},
+		{
+			test:  "empty_segment",
+			query: Query(true, Child(Name("a")), Child(Slice(3, 1), Filter(And(hasX, notCurrent))), Child(Name("b"))),
+			exp:   `$["a"][3:1]`,
+		},
+		{
+			test:  "empty_last_segment",
+			query: Query(true, Child(Name("a")), Descendant(Slice(-1, -3))),
+			exp:   `$["a"]..[-1:-3]`,
+			same:  true,
+		},
+		{
+			test:  "empty_descending_slice",
+			query: Query(true, Child(Slice(1, 3, -1), Index(0))),
+			exp:   `$[0]`,
+		},
+		{
+			test:  "relative",
+			query: Query(false, Child(Index(0), Index(1), Index(2))),
+			exp:   `@[:3]`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			opt := tc.query.Optimize()
+			a.Equal(tc.exp, opt.String())
+			if tc.same {
+				a.Same(tc.query, opt)
+			} else {
+				a.NotSame(tc.query, opt)
+			}
+
+			// Should select the same values.
+			for _, input := range []any{
+				nil,
+				[]any{},
+				[]any{0},
+				[]any{0, 1},
+				[]any{0, 1, 2},
+				[]any{0, map[string]any{"x": 1}, 2, 3, 4, 5, 6, 7},
+				pairs{{"a", []any{0, 1, 2, 3, 4, 5}}, {"x", 1}},
+				pairs{{"a", pairs{{"b", 1}, {"x", 2}}}, {"b", []any{[]any{1, 2}}}},
+			} {
+				a.Equal(tc.query.Select(input, input), opt.Select(input, input))
+				a.Equal(
+					tc.query.SelectLocated(input, input, Normalized()),
+					opt.SelectLocated(input, input, Normalized()),
+				)
+			}
+		})
+	}
+}
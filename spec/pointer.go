@@ -0,0 +1,336 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrPointer errors are returned for invalid JSON Pointers and Relative
+// JSON Pointers.
+var ErrPointer = errors.New("jsonpath: invalid JSON Pointer")
+
+// maxPointerIndex is the largest array index in a JSON Pointer that
+// [PointerQuery] converts to an [Index] selector, the largest that the
+// parser accepts.
+const maxPointerIndex = maxExactInt - 1
+
+// pointerEscaper escapes JSON Pointer reference tokens.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// ParsePointer parses ptr, an [RFC 6901 JSON Pointer], and returns its
+// reference tokens with the ~1 and ~0 escapes replaced with / and ~.
+// Returns an [ErrPointer] error if ptr is not empty and does not start with
+// "/", or contains a "~" not followed by "0" or "1".
+//
+// [RFC 6901 JSON Pointer]: https://www.rfc-editor.org/rfc/rfc6901
+func ParsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return []string{}, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("%w: %q does not start with /", ErrPointer, ptr)
+	}
+
+	tokens := strings.Split(ptr[1:], "/")
+	for i, tok := range tokens {
+		var err error
+		if tokens[i], err = unescapePointer(tok); err != nil {
+			return nil, fmt.Errorf("%w in %q", err, ptr)
+		}
+	}
+	return tokens, nil
+}
+
+// PointerQuery converts ptr, an [RFC 6901 JSON Pointer], into a
+// [PathQuery] that selects the value ptr identifies from the root node.
+// Because a JSON Pointer does not distinguish array indexes from object
+// member names, PointerQuery converts reference tokens that are valid array
+// indexes, such as 0 or 12, into segments that select both the member with
+// that name and the element at that index, as in ["12",12]. Such a segment
+// selects at most one value, since a value is either an object or an array.
+// Other reference tokens, including "-", become [Name] selectors. Returns
+// an [ErrPointer] error for an invalid JSON Pointer.
+//
+// [RFC 6901 JSON Pointer]: https://www.rfc-editor.org/rfc/rfc6901
+func PointerQuery(ptr string) (*PathQuery, error) {
+	tokens, err := ParsePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	segs := make([]*Segment, len(tokens))
+	for i, tok := range tokens {
+		if idx, ok := pointerIndex(tok); ok {
+			segs[i] = Child(Name(tok), Index(idx))
+		} else {
+			segs[i] = Child(Name(tok))
+		}
+	}
+	return Query(true, segs...), nil
+}
+
+// unescapePointer replaces the ~1 and ~0 escapes in tok, a JSON Pointer
+// reference token, with / and ~. Returns an [ErrPointer] error for a ~ not
+// followed by 0 or 1.
+func unescapePointer(tok string) (string, error) {
+	if !strings.Contains(tok, "~") {
+		return tok, nil
+	}
+	buf := new(strings.Builder)
+	for i := 0; i < len(tok); i++ {
+		if tok[i] != '~' {
+			buf.WriteByte(tok[i])
+			continue
+		}
+		i++
+		switch {
+		case i < len(tok) && tok[i] == '0':
+			buf.WriteByte('~')
+		case i < len(tok) && tok[i] == '1':
+			buf.WriteByte('/')
+		default:
+			return "", fmt.Errorf("%w: invalid escape in %q", ErrPointer, tok)
+		}
+	}
+	return buf.String(), nil
+}
+
+// pointerIndex returns the integer value of tok and true if tok is a JSON
+// Pointer array index: 0 or digits without a leading zero, no greater than
+// maxPointerIndex.
+func pointerIndex(tok string) (int, bool) {
+	if !isPointerInt(tok) {
+		return 0, false
+	}
+	idx, err := strconv.Atoi(tok)
+	if err != nil || idx > maxPointerIndex {
+		return 0, false
+	}
+	return idx, true
+}
+
+// isPointerInt returns true if s is a non-negative integer as defined by
+// JSON Pointer and Relative JSON Pointer: 0 or digits without a leading
+// zero.
+func isPointerInt(s string) bool {
+	if s == "" || (s[0] == '0' && len(s) > 1) {
+		return false
+	}
+	for i := range len(s) {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// RelativePointer is a [Relative JSON Pointer], which identifies a value
+// relative to the location of another value, such as a node selected by a
+// [PathQuery]. It consists of the number of levels to move up from the
+// location, an optional offset to add to the index of an array element,
+// and either a JSON Pointer to resolve from the resulting location or "#" to
+// identify its member name or index. Create one with
+// [ParseRelativePointer] or [NormalizedPath.RelativePointer]. Interfaces
+// implemented:
+//   - [fmt.Stringer]
+//   - [encoding.TextMarshaler]
+//
+// [Relative JSON Pointer]: https://datatracker.ietf.org/doc/html/draft-bhutton-relative-json-pointer
+type RelativePointer struct {
+	up     int
+	offset int
+	adjust bool
+	key    bool
+	tokens []string
+}
+
+// ParseRelativePointer parses ptr, a [Relative JSON Pointer], such as
+// "0/foo", "1#", or "0-1/bar". Returns an [ErrPointer] error if ptr is
+// invalid.
+//
+// [Relative JSON Pointer]: https://datatracker.ietf.org/doc/html/draft-bhutton-relative-json-pointer
+func ParseRelativePointer(ptr string) (*RelativePointer, error) {
+	rp := &RelativePointer{}
+	rest, ok := rp.parsePrefix(ptr)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid relative pointer %q", ErrPointer, ptr)
+	}
+
+	if rest == "#" {
+		rp.key = true
+		return rp, nil
+	}
+	tokens, err := ParsePointer(rest)
+	if err != nil {
+		return nil, fmt.Errorf("%w in relative pointer %q", err, ptr)
+	}
+	rp.tokens = tokens
+	return rp, nil
+}
+
+// parsePrefix parses the non-negative integer and optional index
+// manipulation at the start of ptr into rp. Returns the remainder of ptr
+// and true on success, and false if ptr does not start with them.
+func (rp *RelativePointer) parsePrefix(ptr string) (string, bool) {
+	end := strings.IndexAny(ptr, "+-/#")
+	if end < 0 {
+		end = len(ptr)
+	}
+	var ok bool
+	if rp.up, ok = parsePointerInt(ptr[:end]); !ok {
+		return "", false
+	}
+	ptr = ptr[end:]
+	if ptr == "" || (ptr[0] != '+' && ptr[0] != '-') {
+		return ptr, true
+	}
+
+	rp.adjust = true
+	end = strings.IndexAny(ptr, "/#")
+	if end < 0 {
+		end = len(ptr)
+	}
+	if rp.offset, ok = parsePointerInt(ptr[1:end]); !ok {
+		return "", false
+	}
+	if ptr[0] == '-' {
+		rp.offset = -rp.offset
+	}
+	return ptr[end:], true
+}
+
+// parsePointerInt parses s as a non-negative integer as defined by
+// Relative JSON Pointer.
+func parsePointerInt(s string) (int, bool) {
+	if !isPointerInt(s) {
+		return 0, false
+	}
+	i, err := strconv.Atoi(s)
+	return i, err == nil
+}
+
+// String returns the string representation of rp.
+func (rp *RelativePointer) String() string {
+	buf := new(strings.Builder)
+	buf.WriteString(strconv.Itoa(rp.up))
+	if rp.adjust {
+		if rp.offset >= 0 {
+			buf.WriteByte('+')
+		}
+		buf.WriteString(strconv.Itoa(rp.offset))
+	}
+	if rp.key {
+		buf.WriteByte('#')
+		return buf.String()
+	}
+	for _, tok := range rp.tokens {
+		buf.WriteByte('/')
+		buf.WriteString(pointerEscaper.Replace(tok))
+	}
+	return buf.String()
+}
+
+// MarshalText marshals rp into text. Implements [encoding.TextMarshaler].
+func (rp *RelativePointer) MarshalText() ([]byte, error) {
+	return []byte(rp.String()), nil
+}
+
+// Resolve evaluates rp from the value at location from in root, such as a
+// [LocatedNode] selected from root, and returns the value it identifies and
+// true. If rp ends in "#", returns the member name as a string or the
+// array index as an int of the location rp identifies instead. Returns
+// false if the value does not exist, including when rp moves up past root
+// or adjusts the index of a value that is not an array element.
+func (rp *RelativePointer) Resolve(root any, from NormalizedPath) (any, bool) {
+	if rp.up > len(from) {
+		return nil, false
+	}
+	loc := from[:len(from)-rp.up]
+	if rp.adjust {
+		if len(loc) == 0 {
+			return nil, false
+		}
+		idx, ok := loc[len(loc)-1].(Index)
+		if !ok || int(idx)+rp.offset < 0 {
+			return nil, false
+		}
+		loc = append(slices.Clone(loc[:len(loc)-1]), idx+Index(rp.offset))
+	}
+
+	val, ok := resolvePath(root, loc)
+	if !ok {
+		return nil, false
+	}
+	if rp.key {
+		if len(loc) == 0 {
+			return nil, false
+		}
+		switch sel := loc[len(loc)-1].(type) {
+		case Name:
+			return string(sel), true
+		case Index:
+			return int(sel), true
+		}
+	}
+
+	for _, tok := range rp.tokens {
+		if val, ok = resolveToken(val, tok); !ok {
+			return nil, false
+		}
+	}
+	return val, true
+}
+
+// resolvePath returns the value at np in root and true, or false if it
+// does not exist.
+func resolvePath(root any, np NormalizedPath) (any, bool) {
+	val, ok := root, true
+	for _, sel := range np {
+		switch sel := sel.(type) {
+		case Name:
+			val, ok = lookupName(val, string(sel))
+		case Index:
+			val, ok = lookupIndex(val, int(sel))
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return val, true
+}
+
+// resolveToken returns the value identified by the JSON Pointer reference
+// token tok in val, an array element if tok is an array index and val is
+// an array, and otherwise an object member.
+func resolveToken(val any, tok string) (any, bool) {
+	if idx, ok := pointerIndex(tok); ok {
+		if res, ok := lookupIndex(val, idx); ok {
+			return res, true
+		}
+	}
+	return lookupName(val, tok)
+}
+
+// RelativePointer returns a [RelativePointer] that identifies the value at
+// np from the value at location from, such as an ancestor of np. It moves
+// up from from to the longest path np and from share, then down to np.
+func (np NormalizedPath) RelativePointer(from NormalizedPath) *RelativePointer {
+	common := 0
+	for common < len(np) && common < len(from) && np[common] == from[common] {
+		common++
+	}
+
+	tokens := make([]string, len(np)-common)
+	for i, sel := range np[common:] {
+		switch sel := sel.(type) {
+		case Name:
+			tokens[i] = string(sel)
+		case Index:
+			tokens[i] = strconv.Itoa(int(sel))
+		}
+	}
+	return &RelativePointer{up: len(from) - common, tokens: tokens}
+}
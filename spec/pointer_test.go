@@ -0,0 +1,209 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePointer(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		ptr  string
+		exp  []string
+		err  string
+	}{
+		{test: "empty", ptr: "", exp: []string{}},
+		{test: "slash", ptr: "/", exp: []string{""}},
+		{test: "names", ptr: "/foo/0/-", exp: []string{"foo", "0", "-"}},
+		{test: "escapes", ptr: "/a~1b/m~0n/~01", exp: []string{"a/b", "m~n", "~1"}},
+		{test: "no_slash", ptr: "foo", err: `jsonpath: invalid JSON Pointer: "foo" does not start with /`},
+		{test: "bad_escape", ptr: "/a~2", err: `jsonpath: invalid JSON Pointer: invalid escape in "a~2" in "/a~2"`},
+		{test: "trailing_tilde", ptr: "/~", err: `jsonpath: invalid JSON Pointer: invalid escape in "~" in "/~"`},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			tokens, err := ParsePointer(tc.ptr)
+			if tc.err != "" {
+				a.ErrorIs(err, ErrPointer)
+				a.EqualError(err, tc.err)
+				a.Nil(tokens)
+				q, err := PointerQuery(tc.ptr)
+				a.EqualError(err, tc.err)
+				a.Nil(q)
+				return
+			}
+			require.NoError(t, err)
+			a.Equal(tc.exp, tokens)
+		})
+	}
+}
+
+func TestPointerQuery(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	q, err := PointerQuery("/foo/0/01/-/9007199254740991/9007199254740992")
+	require.NoError(t, err)
+	a.Equal(Query(
+		true,
+		Child(Name("foo")),
+		Child(Name("0"), Index(0)),
+		Child(Name("01")),
+		Child(Name("-")),
+		Child(Name("9007199254740991"), Index(maxPointerIndex)),
+		Child(Name("9007199254740992")),
+	), q)
+}
+
+func TestRelativePointer(t *testing.T) {
+	t.Parallel()
+
+	// https://datatracker.ietf.org/doc/html/draft-bhutton-relative-json-pointer-00#section-5.1
+	root := map[string]any{
+		"foo":    []any{"bar", "baz", "biz"},
+		"highly": map[string]any{"nested": map[string]any{"objects": true}},
+	}
+	baz := Normalized(Name("foo"), Index(1))
+	nested := Normalized(Name("highly"), Name("nested"))
+
+	for _, tc := range []struct {
+		test string
+		ptr  string
+		from NormalizedPath
+		exp  any
+		ok   bool
+	}{
+		{"self", "0", baz, "baz", true},
+		{"parent_index", "1/0", baz, "bar", true},
+		{"previous", "0-1", baz, "bar", true},
+		{"next", "0+1", baz, "biz", true},
+		{"grandparent", "2/highly/nested/objects", baz, true, true},
+		{"index", "0#", baz, 1, true},
+		{"previous_index", "0-1#", baz, 0, true},
+		{"parent_name", "1#", baz, "foo", true},
+		{"child", "0/objects", nested, true, true},
+		{"parent_child", "1/nested/objects", nested, true, true},
+		{"root_index", "2/foo/0", nested, "bar", true},
+		{"name", "0#", nested, "nested", true},
+		{"parent_key", "1#", nested, "highly", true},
+		{"root", "2", nested, root, true},
+		{"past_root", "3", nested, nil, false},
+		{"root_key", "2#", nested, nil, false},
+		{"adjust_name", "0+1", nested, nil, false},
+		{"adjust_root", "2+1", nested, nil, false},
+		{"before_start", "0-2", baz, nil, false},
+		{"after_end", "0+2", baz, nil, false},
+		{"after_end_key", "0+2#", baz, nil, false},
+		{"missing_member", "0/nope", nested, nil, false},
+		{"missing_from", "0", Normalized(Name("nope")), nil, false},
+		{"dash", "1/-", baz, nil, false},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			rp, err := ParseRelativePointer(tc.ptr)
+			require.NoError(t, err)
+			a.Equal(tc.ptr, rp.String())
+			text, err := rp.MarshalText()
+			require.NoError(t, err)
+			a.Equal(tc.ptr, string(text))
+
+			val, ok := rp.Resolve(root, tc.from)
+			a.Equal(tc.ok, ok)
+			a.Equal(tc.exp, val)
+		})
+	}
+}
+
+func TestParseRelativePointer(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		ptr  string
+		exp  *RelativePointer
+		str  string
+		err  string
+	}{
+		{test: "zero", ptr: "0", exp: &RelativePointer{tokens: []string{}}},
+		{test: "up", ptr: "12/a~1b", exp: &RelativePointer{up: 12, tokens: []string{"a/b"}}},
+		{test: "key", ptr: "3#", exp: &RelativePointer{up: 3, key: true}},
+		{test: "plus", ptr: "1+10/x", exp: &RelativePointer{up: 1, offset: 10, adjust: true, tokens: []string{"x"}}},
+		{test: "minus_key", ptr: "0-3#", exp: &RelativePointer{offset: -3, adjust: true, key: true}},
+		{test: "minus_zero", ptr: "0-0", exp: &RelativePointer{adjust: true, tokens: []string{}}, str: "0+0"},
+		{test: "empty", ptr: "", err: `jsonpath: invalid JSON Pointer: invalid relative pointer ""`},
+		{test: "no_int", ptr: "/foo", err: `jsonpath: invalid JSON Pointer: invalid relative pointer "/foo"`},
+		{test: "leading_zero", ptr: "01", err: `jsonpath: invalid JSON Pointer: invalid relative pointer "01"`},
+		{test: "not_int", ptr: "x/foo", err: `jsonpath: invalid JSON Pointer: invalid relative pointer "x/foo"`},
+		{test: "overflow", ptr: "99999999999999999999", err: `jsonpath: invalid JSON Pointer: invalid relative pointer "99999999999999999999"`},
+		{test: "no_offset", ptr: "0+/foo", err: `jsonpath: invalid JSON Pointer: invalid relative pointer "0+/foo"`},
+		{test: "offset_leading_zero", ptr: "0-01", err: `jsonpath: invalid JSON Pointer: invalid relative pointer "0-01"`},
+		{test: "double_sign", ptr: "0+-1", err: `jsonpath: invalid JSON Pointer: invalid relative pointer "0+-1"`},
+		{test: "key_pointer", ptr: "0#/foo", err: `jsonpath: invalid JSON Pointer: "#/foo" does not start with / in relative pointer "0#/foo"`},
+		{test: "bad_escape", ptr: "0/~2", err: `jsonpath: invalid JSON Pointer: invalid escape in "~2" in "/~2" in relative pointer "0/~2"`},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			rp, err := ParseRelativePointer(tc.ptr)
+			if tc.err != "" {
+				a.ErrorIs(err, ErrPointer)
+				a.EqualError(err, tc.err)
+				a.Nil(rp)
+				return
+			}
+			require.NoError(t, err)
+			a.Equal(tc.exp, rp)
+			if tc.str == "" {
+				tc.str = tc.ptr
+			}
+			a.Equal(tc.str, rp.String())
+		})
+	}
+}
+
+func TestNormalizedPathRelativePointer(t *testing.T) {
+	t.Parallel()
+
+	root := map[string]any{
+		"a": []any{map[string]any{"b/c": 1, "d": 2}},
+		"e": map[string]any{"f": 3},
+	}
+
+	for _, tc := range []struct {
+		test string
+		np   NormalizedPath
+		from NormalizedPath
+		exp  string
+	}{
+		{"self", Normalized(Name("a")), Normalized(Name("a")), "0"},
+		{"root", Normalized(Name("a"), Index(0), Name("b/c")), Normalized(), "0/a/0/b~1c"},
+		{"ancestor", Normalized(Name("a"), Index(0), Name("b/c")), Normalized(Name("a")), "0/0/b~1c"},
+		{"sibling", Normalized(Name("a"), Index(0), Name("d")), Normalized(Name("a"), Index(0), Name("b/c")), "1/d"},
+		{"cousin", Normalized(Name("e"), Name("f")), Normalized(Name("a"), Index(0), Name("d")), "3/e/f"},
+		{"parent", Normalized(Name("a")), Normalized(Name("a"), Index(0), Name("d")), "2"},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			rp := tc.np.RelativePointer(tc.from)
+			a.Equal(tc.exp, rp.String())
+
+			// Should resolve to the value at np.
+			exp, ok := resolvePath(root, tc.np)
+			require.True(t, ok)
+			val, ok := rp.Resolve(root, tc.from)
+			a.True(ok)
+			a.Equal(exp, val)
+		})
+	}
+}
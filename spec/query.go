@@ -1,8 +1,16 @@
 package spec
 
-import "strings"
+import (
+	"context"
+	"iter"
+	"strings"
+)
 
-// PathQuery represents a JSONPath query. Interfaces implemented:
+// PathQuery represents a JSONPath query. A PathQuery is immutable once
+// created and safe for concurrent use by multiple goroutines, provided that
+// callers do not modify the slices returned by its accessors, such as
+// [PathQuery.Segments]. Use [PathQuery.Clone] for a copy that may be
+// modified. Interfaces implemented:
 //   - [Selector]
 //   - [FuncExprArg]
 //   - [fmt.Stringer]
@@ -23,6 +31,12 @@ func (q *PathQuery) Segments() []*Segment {
 	return q.segments
 }
 
+// IsRoot returns true if q selects from the root node ($) and false if it
+// selects from the current node (@).
+func (q *PathQuery) IsRoot() bool {
+	return q.root
+}
+
 // String returns a string representation of q.
 func (q *PathQuery) String() string {
 	var buf strings.Builder
@@ -44,40 +58,338 @@ func (q *PathQuery) writeTo(buf *strings.Builder) {
 }
 
 // Select selects the values from current or root and returns the results.
-// Returns just current if q has no segments. Defined by the [Selector]
-// interface.
+// Returns just current if q has no segments, and nil if a descendant
+// segment finds a value that contains itself, as described for
+// [ErrCycle]. Defined by the [Selector] interface.
 func (q *PathQuery) Select(current, root any) []any {
-	res := []any{current}
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	return q.selectFrom(current, ev)
+}
+
+// SelectWith selects the values from current or root as configured by opts
+// and returns the results. Discards any values selected so far and returns
+// nil if evaluation aborts, as when it exceeds the limits set by
+// opts.MaxNodes or opts.Timeout; use
+// [PathQuery.TrySelect] to detect aborted evaluations. Otherwise the same
+// as [PathQuery.Select].
+func (q *PathQuery) SelectWith(current, root any, opts Options) []any {
+	ev := newEvaluation(root, opts)
+	defer ev.dropAbort()
+	return q.selectWith(current, ev)
+}
+
+// TrySelect selects the values from current or root as configured by opts
+// and returns the results. Returns an [ErrBudgetExceeded] error if
+// evaluation exceeds the limits set by opts.MaxNodes or opts.Timeout.
+// Otherwise the same as [PathQuery.SelectWith].
+func (q *PathQuery) TrySelect(current, root any, opts Options) (res []any, err error) {
+	defer catchAbort(&err)
+	return q.selectWith(current, newEvaluation(root, opts)), nil
+}
+
+// selectWith selects the values from current or ev.root as configured by
+// ev's options and returns the results.
+func (q *PathQuery) selectWith(current any, ev *evaluation) []any {
+	if ev.locates() {
+		return q.locateFrom(current, ev)
+	}
+	return q.selectFrom(current, ev)
+}
+
+// SelectValue selects the first value from current or root as configured by
+// opts and returns it, or returns [Nothing] if q selects no value. Unlike
+// [PathQuery.SelectWith], it thus distinguishes a query that selects a JSON
+// null, returned as nil, from a query that selects nothing. Most useful for
+// singular queries, which select at most one value, and for which it looks
+// up the value without allocating a slice of results. For other queries, it
+// stops evaluation as soon as it finds the first value. Returns [Nothing]
+// if evaluation aborts, as when it exceeds the limits set by opts.MaxNodes
+// or opts.Timeout.
+func (q *PathQuery) SelectValue(current, root any, opts Options) (val any) {
+	ev := newEvaluation(root, opts)
+	val = Nothing
+	defer ev.dropAbort()
+	return q.selectValue(current, ev)
+}
+
+// selectValue selects the first value from current or ev.root and returns
+// it, or returns [Nothing] if q selects no value.
+func (q *PathQuery) selectValue(current any, ev *evaluation) any {
+	if ev.opts.Auditor != nil {
+		return q.auditValue(current, ev)
+	}
+	if q.isSingular() {
+		if val, ok := singular(q).lookup(current, ev); ok {
+			return val
+		}
+		return Nothing
+	}
+
+	if q.root {
+		current = ev.root
+	}
+	val := any(Nothing)
+	existsFrom(q.segments, current, ev, func(v any) { val = v })
+	return val
+}
+
+// auditValue selects the first value from current or ev.root, reports its
+// path to ev's [Auditor], and returns it, or returns [Nothing] and reports
+// no paths if q selects no value.
+func (q *PathQuery) auditValue(current any, ev *evaluation) any {
+	node := newLocatedNode(nil, current)
+	if q.root {
+		node = newLocatedNode(nil, ev.root)
+	}
+	var first []*LocatedNode
+	yieldLocatedFrom(q.segments, node, ev, func(n *LocatedNode) bool {
+		first = append(first, n)
+		return false
+	})
+	ev.audit(q, first)
+	if len(first) == 0 {
+		return Nothing
+	}
+	return first[0].Node
+}
+
+// selectFrom selects the values from current or ev.root and returns the
+// results.
+func (q *PathQuery) selectFrom(current any, ev *evaluation) []any {
+	res, _ := q.selectInto(current, ev, make([]any, 0, 1), make([]any, 0))
+	return res
+}
+
+// selectInto selects the values from current or ev.root, using res and buf
+// as buffers for the results of alternating segments, and returns the
+// results and the other buffer. Truncates both buffers before use.
+func (q *PathQuery) selectInto(current any, ev *evaluation, res, buf []any) ([]any, []any) {
 	if q.root {
-		res[0] = root
+		current = ev.root
 	}
+	res = append(res[:0], current)
+
+	// Alternate between two buffers, appending each segment's results to
+	// the buffer that held the results of the segment before last.
 	for _, seg := range q.segments {
-		segRes := make([]any, 0, len(res))
+		buf = buf[:0]
 		for _, v := range res {
-			segRes = append(segRes, seg.Select(v, root)...)
+			buf = seg.appendFrom(buf, v, ev)
 		}
-		res = segRes
+		res, buf = buf, res
 	}
 
-	return res
+	return res, buf
+}
+
+// exists returns true if q selects at least one value from current or
+// ev.root. Unlike [PathQuery.selectFrom], it follows each selected value
+// through the remaining segments before selecting the next, and stops at
+// the first value selected by the last segment, so that filters such as
+// ?@..error need not traverse entire subtrees.
+func (q *PathQuery) exists(current any, ev *evaluation) bool {
+	if q.root {
+		current = ev.root
+	}
+	return existsFrom(q.segments, current, ev, nil)
+}
+
+// existsFrom returns true if segs select at least one value from node, and
+// passes the first value they select to found, if not nil.
+func existsFrom(segs []*Segment, node any, ev *evaluation, found func(any)) bool {
+	if len(segs) == 0 {
+		if found != nil {
+			found(node)
+		}
+		return true
+	}
+	seg, rest := segs[0], segs[1:]
+	if seg.descendant {
+		return seg.descendUntil(node, ev, func(v any) bool {
+			return existsFrom(rest, v, ev, found)
+		})
+	}
+	for _, v := range seg.appendFrom(nil, node, ev) {
+		if existsFrom(rest, v, ev, found) {
+			return true
+		}
+	}
+	return false
 }
 
 // SelectLocated values from current or root into [LocatedNode] values and
-// returns the results. Returns just current if q has no segments. Defined by
-// the [Selector] interface.
+// returns the results. Returns just current if q has no segments, and nil
+// if a descendant segment finds a value that contains itself, as described
+// for [ErrCycle]. Defined by the [Selector] interface.
 func (q *PathQuery) SelectLocated(current, root any, parent NormalizedPath) []*LocatedNode {
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	return q.selectLocatedFrom(current, ev, parent)
+}
+
+// SelectLocatedWith selects values from current or root into [LocatedNode]
+// values as configured by opts and returns the results. Discards any nodes
+// selected so far and returns nil if evaluation aborts, as when it exceeds
+// the limits set by opts.MaxNodes or opts.Timeout; use [PathQuery.TrySelectLocated] to detect aborted
+// evaluations. Otherwise the same as [PathQuery.SelectLocated].
+func (q *PathQuery) SelectLocatedWith(current, root any, parent NormalizedPath, opts Options) []*LocatedNode {
+	ev := newEvaluation(root, opts)
+	defer ev.dropAbort()
+	return q.selectLocatedWith(current, ev, parent)
+}
+
+// TrySelectLocated selects values from current or root into [LocatedNode]
+// values as configured by opts and returns the results. Returns an
+// [ErrBudgetExceeded] error if evaluation exceeds the limits set by
+// opts.MaxNodes or opts.Timeout. Otherwise the same as
+// [PathQuery.SelectLocatedWith].
+func (q *PathQuery) TrySelectLocated(current, root any, parent NormalizedPath, opts Options) (res []*LocatedNode, err error) {
+	defer catchAbort(&err)
+	return q.selectLocatedWith(current, newEvaluation(root, opts), parent), nil
+}
+
+// selectLocatedWith selects values from current or ev.root into
+// [LocatedNode] values as configured by ev's options and returns the
+// results.
+func (q *PathQuery) selectLocatedWith(current any, ev *evaluation, parent NormalizedPath) []*LocatedNode {
+	res := ev.unique(q.selectLocatedFrom(current, ev, parent))
+	ev.audit(q, res)
+	return res
+}
+
+// SelectLocatedSeq returns an iterator over the [LocatedNode] values that q
+// selects from current or root as configured by opts, in the same order as
+// [PathQuery.SelectLocatedWith]. Rather than select all the values of each
+// segment in turn, it follows each value through the remaining segments, so
+// that it yields each value selected by the last segment as soon as it
+// finds it, and stops evaluation as soon as the loop over it stops. It
+// never descends in parallel.
+//
+// If evaluation exceeds the limits set by opts.MaxNodes or opts.Timeout,
+// or ctx is canceled, the iterator yields a nil [LocatedNode] and an
+// [ErrBudgetExceeded] error or the cause of ctx's cancellation as its final
+// pair. It checks ctx periodically as segments visit values.
+func (q *PathQuery) SelectLocatedSeq(ctx context.Context, current, root any, parent NormalizedPath, opts Options) iter.Seq2[*LocatedNode, error] {
+	return func(yield func(*LocatedNode, error) bool) {
+		ev := newEvaluation(root, opts)
+		ev.opts.Parallelism = 0
+		ev.watch(ctx)
+
+		node := newLocatedNode(parent, current)
+		if q.root {
+			node = newLocatedNode(nil, root)
+		}
+
+		var err error
+		var yielded []*LocatedNode
+		var keys map[string]struct{}
+		if opts.UniqueLocations {
+			keys = map[string]struct{}{}
+		}
+		func() {
+			defer catchAbort(&err)
+			yieldLocatedFrom(q.segments, node, ev, func(node *LocatedNode) bool {
+				if keys != nil && seen(keys, node.Path.String()) {
+					return true
+				}
+				if opts.Auditor != nil {
+					yielded = append(yielded, node)
+				}
+				return yield(node, nil)
+			})
+		}()
+		ev.audit(q, yielded)
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// yieldLocatedFrom passes each [LocatedNode] that segs select from node to
+// yield, following each value selected by a segment through the remaining
+// segments before selecting the next. Returns false if yield returns false.
+func yieldLocatedFrom(segs []*Segment, node *LocatedNode, ev *evaluation, yield func(*LocatedNode) bool) bool {
+	if len(segs) == 0 {
+		return yield(node)
+	}
+	seg, rest := segs[0], segs[1:]
+	if seg.descendant {
+		return !seg.descendLocatedUntil(node.Node, ev, node.Path, func(n *LocatedNode) bool {
+			return !yieldLocatedFrom(rest, n, ev, yield)
+		})
+	}
+	for _, n := range seg.appendLocatedFrom(nil, node.Node, ev, node.Path) {
+		if !yieldLocatedFrom(rest, n, ev, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// locates returns true if ev's options require the locations of the values
+// that queries select: to report them to an [Auditor] or to remove
+// duplicates for UniqueLocations.
+func (ev *evaluation) locates() bool {
+	return ev.opts.Auditor != nil || ev.opts.UniqueLocations
+}
+
+// locateFrom selects values from current or ev.root into [LocatedNode]
+// values, removes duplicates if ev's options enable UniqueLocations,
+// reports their paths to ev's [Auditor], and returns the values.
+func (q *PathQuery) locateFrom(current any, ev *evaluation) []any {
+	nodes := ev.unique(q.selectLocatedFrom(current, ev, nil))
+	res := make([]any, len(nodes))
+	for i, n := range nodes {
+		res[i] = n.Node
+	}
+	ev.audit(q, nodes)
+	return res
+}
+
+// unique removes from nodes the nodes whose normalized paths appear earlier
+// in nodes if ev's options enable UniqueLocations, and returns the result.
+// Modifies nodes.
+func (ev *evaluation) unique(nodes []*LocatedNode) []*LocatedNode {
+	if !ev.opts.UniqueLocations || len(nodes) < 2 {
+		return nodes
+	}
+	keys := make(map[string]struct{}, len(nodes))
+	res := nodes[:0]
+	for _, n := range nodes {
+		if !seen(keys, n.Path.String()) {
+			res = append(res, n)
+		}
+	}
+	clear(nodes[len(res):])
+	return res
+}
+
+// seen returns true if key is in keys, and otherwise adds it to keys.
+func seen(keys map[string]struct{}, key string) bool {
+	if _, ok := keys[key]; ok {
+		return true
+	}
+	keys[key] = struct{}{}
+	return false
+}
+
+// selectLocatedFrom selects values from current or ev.root into
+// [LocatedNode] values and returns the results.
+func (q *PathQuery) selectLocatedFrom(current any, ev *evaluation, parent NormalizedPath) []*LocatedNode {
 	res := []*LocatedNode{nil}
 	if q.root {
-		res[0] = newLocatedNode(nil, root)
+		res[0] = newLocatedNode(nil, ev.root)
 	} else {
 		res[0] = newLocatedNode(parent, current)
 	}
+	buf := make([]*LocatedNode, 0)
 	for _, seg := range q.segments {
-		segRes := make([]*LocatedNode, 0, len(res))
+		buf = buf[:0]
 		for _, v := range res {
-			segRes = append(segRes, seg.SelectLocated(v.Node, root, v.Path)...)
+			buf = seg.appendLocatedFrom(buf, v.Node, ev, v.Path)
 		}
-		res = segRes
+		res, buf = buf, res
 	}
 
 	return res
@@ -97,6 +409,13 @@ func (q *PathQuery) isSingular() bool {
 	return true
 }
 
+// IsSingular returns true if q is a singular query: one that selects at
+// most one node because each of its segments is a child segment with a
+// single name or index selector.
+func (q *PathQuery) IsSingular() bool {
+	return q.isSingular()
+}
+
 // Singular returns the [SingularQueryExpr] variant of q if q is a singular
 // query. Otherwise it returns nil.
 func (q *PathQuery) Singular() *SingularQueryExpr {
@@ -117,10 +436,50 @@ func (q *PathQuery) Expression() FuncExprArg {
 	return q
 }
 
+// SingularPrefix splits q into its leading child segments that each
+// select a single name or non-negative index, returned as a
+// [NormalizedPath], and a query for its remaining segments, with the same
+// root or current node identifier as q. Selecting the remainder from the
+// value at the prefix selects the same nodes as q, so callers can look up
+// the prefix directly, or in an index, and evaluate only the remainder, or
+// shard data by the prefix. Queries in the filters of the remainder that
+// select from the root node still select from the root of the whole input.
+//
+// Returns an empty prefix and a query equal to q if q starts with any other
+// segment, and a query with no segments if q is a singular query without
+// negative indexes.
+func (q *PathQuery) SingularPrefix() (NormalizedPath, *PathQuery) {
+	var prefix NormalizedPath
+	for _, seg := range q.segments {
+		sel, ok := prefixSelector(seg)
+		if !ok {
+			break
+		}
+		prefix = append(prefix, sel)
+	}
+	return prefix, Query(q.root, q.segments[len(prefix):]...)
+}
+
+// prefixSelector returns the selector of seg and true if seg is a child
+// segment with a single [Name] or non-negative [Index] selector.
+func prefixSelector(seg *Segment) (NormalSelector, bool) {
+	if !seg.isSingular() {
+		return nil, false
+	}
+	switch sel := seg.selectors[0].(type) {
+	case Name:
+		return sel, true
+	case Index:
+		return sel, sel >= 0
+	default:
+		return nil, false
+	}
+}
+
 // evaluate returns a [NodesType] containing the result of executing q.
 // Defined by the [FuncExprArg] interface.
-func (q *PathQuery) evaluate(current, root any) PathValue {
-	return NodesType(q.Select(current, root))
+func (q *PathQuery) evaluate(current any, ev *evaluation) PathValue {
+	return ev.nodes(q.selectFrom(current, ev))
 }
 
 // ResultType returns [FuncValue] if q is a singular query, and [FuncNodes]
@@ -1,6 +1,7 @@
 package spec
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -108,6 +109,8 @@ func (tc queryTestCase) run(a *assert.Assertions) {
 	q := Query(false, tc.segs...)
 	a.Equal(tc.segs, q.Segments())
 	a.False(q.root)
+	a.False(q.IsRoot())
+	a.True(Query(true, tc.segs...).IsRoot())
 
 	// Test Select and SelectLocated.
 	if tc.rand {
@@ -1418,13 +1421,289 @@ func TestSingularExpr(t *testing.T) {
 
 			if tc.sing == nil {
 				a.False(tc.query.isSingular())
+				a.False(tc.query.IsSingular())
 				a.Nil(tc.query.Singular())
 				a.Equal(tc.query, tc.query.Expression())
 			} else {
 				a.True(tc.query.isSingular())
+				a.True(tc.query.IsSingular())
 				a.Equal(tc.sing, tc.query.Singular())
 				a.Equal(tc.sing, tc.query.Expression())
 			}
 		})
 	}
 }
+
+func TestSingularPrefix(t *testing.T) {
+	t.Parallel()
+
+	filter := Filter(And(Existence(Query(false, Child(Name("x"))))))
+	for _, tc := range []struct {
+		test   string
+		query  *PathQuery
+		prefix NormalizedPath
+		rest   *PathQuery
+	}{
+		{
+			test:   "root",
+			query:  Query(true),
+			prefix: Normalized(),
+			rest:   Query(true),
+		},
+		{
+			test:   "singular",
+			query:  Query(true, Child(Name("a")), Child(Index(1))),
+			prefix: Normalized(Name("a"), Index(1)),
+			rest:   Query(true),
+		},
+		{
+			test:   "relative",
+			query:  Query(false, Child(Name("a")), Child(Wildcard())),
+			prefix: Normalized(Name("a")),
+			rest:   Query(false, Child(Wildcard())),
+		},
+		{
+			test:   "filter",
+			query:  Query(true, Child(Name("a")), Child(Name("b")), Child(filter), Child(Name("c"))),
+			prefix: Normalized(Name("a"), Name("b")),
+			rest:   Query(true, Child(filter), Child(Name("c"))),
+		},
+		{
+			test:   "descendant",
+			query:  Query(true, Child(Name("a")), Descendant(Name("b"))),
+			prefix: Normalized(Name("a")),
+			rest:   Query(true, Descendant(Name("b"))),
+		},
+		{
+			test:   "union",
+			query:  Query(true, Child(Name("a"), Name("b")), Child(Name("c"))),
+			prefix: Normalized(),
+			rest:   Query(true, Child(Name("a"), Name("b")), Child(Name("c"))),
+		},
+		{
+			test:   "negative_index",
+			query:  Query(true, Child(Index(0)), Child(Index(-1)), Child(Index(2))),
+			prefix: Normalized(Index(0)),
+			rest:   Query(true, Child(Index(-1)), Child(Index(2))),
+		},
+		{
+			test:   "slice",
+			query:  Query(true, Child(Slice(1, 2)), Child(Name("a"))),
+			prefix: Normalized(),
+			rest:   Query(true, Child(Slice(1, 2)), Child(Name("a"))),
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			prefix, rest := tc.query.SingularPrefix()
+			a.Equal(tc.prefix, prefix)
+			a.Equal(tc.rest.String(), rest.String())
+			a.Equal(tc.query.IsRoot(), rest.IsRoot())
+		})
+	}
+}
+
+func TestSelectValue(t *testing.T) {
+	t.Parallel()
+
+	root := map[string]any{"a": []any{nil, map[string]any{"b": "x"}}, "c": nil}
+	current := map[string]any{"d": []any{1, 2}}
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		exp   any
+		nodes int
+	}{
+		{
+			test:  "root",
+			query: Query(true),
+			exp:   root,
+		},
+		{
+			test:  "current",
+			query: Query(false),
+			exp:   current,
+		},
+		{
+			test:  "null",
+			query: Query(true, Child(Name("c"))),
+			exp:   nil,
+			nodes: 1,
+		},
+		{
+			test:  "nothing",
+			query: Query(true, Child(Name("x"))),
+			exp:   Nothing,
+			nodes: 1,
+		},
+		{
+			test:  "null_index",
+			query: Query(true, Child(Name("a")), Child(Index(0))),
+			exp:   nil,
+			nodes: 2,
+		},
+		{
+			test:  "relative",
+			query: Query(false, Child(Name("d")), Child(Index(-1))),
+			exp:   2,
+			nodes: 2,
+		},
+		{
+			test:  "relative_nothing",
+			query: Query(false, Child(Name("d")), Child(Index(2))),
+			exp:   Nothing,
+			nodes: 2,
+		},
+		{
+			test:  "wildcard_first",
+			query: Query(false, Child(Name("d")), Child(Wildcard())),
+			exp:   1,
+			nodes: 2,
+		},
+		{
+			test:  "descendant",
+			query: Query(true, Descendant(Name("b"))),
+			exp:   "x",
+			nodes: 3,
+		},
+		{
+			test:  "descendant_nothing",
+			query: Query(true, Descendant(Name("x"))),
+			exp:   Nothing,
+			nodes: 3,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			c := new(Collector)
+			val := tc.query.SelectValue(current, root, Options{Hook: c})
+			a.Equal(tc.exp, val)
+			a.Equal(tc.exp == Nothing, val == Nothing)
+			a.Equal(tc.nodes, c.Stats().Nodes)
+		})
+	}
+}
+
+func TestSelectLocatedSeq(t *testing.T) {
+	t.Parallel()
+
+	input := pairs{
+		{"a", []any{pairs{{"x", 1}, {"y", []any{2, 3}}}, pairs{{"x", 4}}}},
+		{"b", pairs{{"x", pairs{{"x", 5}}}, {"z", []any{6, pairs{{"x", 7}}}}}},
+	}
+	current := pairs{{"x", "cur"}}
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		opts  Options
+	}{
+		{
+			test:  "root",
+			query: Query(true),
+		},
+		{
+			test:  "current",
+			query: Query(false, Child(Name("x"))),
+		},
+		{
+			test:  "children",
+			query: Query(true, Child(Wildcard()), Child(Index(0), Name("x"), Index(1))),
+		},
+		{
+			test:  "descendants",
+			query: Query(true, Descendant(Name("x"))),
+		},
+		{
+			test:  "nested_descendants",
+			query: Query(true, Descendant(Wildcard()), Descendant(Name("x"), Index(0))),
+		},
+		{
+			test: "filter",
+			query: Query(true, Descendant(Filter(And(Existence(
+				Query(false, Child(Name("x"))),
+			)))), Child(Name("x"))),
+		},
+		{
+			test:  "max_depth",
+			query: Query(true, Descendant(Name("x"))),
+			opts:  Options{MaxDepth: 2},
+		},
+		{
+			test:  "parallelism",
+			query: Query(true, Descendant(Wildcard())),
+			opts:  Options{Parallelism: 4},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			parent := Normalized(Name("cur"))
+			exp := tc.query.SelectLocatedWith(current, input, parent, tc.opts)
+			got := []*LocatedNode{}
+			for node, err := range tc.query.SelectLocatedSeq(context.Background(), current, input, parent, tc.opts) {
+				a.NoError(err)
+				got = append(got, node)
+			}
+			a.Equal(exp, got)
+		})
+	}
+
+	t.Run("stop_early", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+
+		q := Query(true, Descendant(Name("x")))
+		all, first := new(Collector), new(Collector)
+		q.SelectLocatedWith(nil, input, nil, Options{Hook: all})
+		for node, err := range q.SelectLocatedSeq(context.Background(), nil, input, nil, Options{Hook: first}) {
+			a.NoError(err)
+			a.Equal(&LocatedNode{Path: Normalized(Name("a"), Index(0), Name("x")), Node: 1}, node)
+			break
+		}
+		a.Less(first.Stats().Nodes, all.Stats().Nodes)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+
+		big := make([]any, budgetCheckInterval*2)
+		for i := range big {
+			big[i] = []any{i}
+		}
+		q := Query(true, Descendant(Index(0)))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		for _, tc := range []struct {
+			ctx  context.Context
+			opts Options
+			err  error
+		}{
+			{context.Background(), Options{MaxNodes: 10}, ErrBudgetExceeded},
+			{ctx, Options{}, context.Canceled},
+			{ctx, Options{MaxNodes: 10_000}, context.Canceled},
+		} {
+			var nodes []*LocatedNode
+			var err error
+			for node, e := range q.SelectLocatedSeq(tc.ctx, nil, big, nil, tc.opts) {
+				if e != nil {
+					a.Nil(node)
+					err = e
+					continue
+				}
+				nodes = append(nodes, node)
+			}
+			a.ErrorIs(err, tc.err)
+			a.NotEmpty(nodes)
+			a.Less(len(nodes), len(big))
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package spec
+
+// QuerySet evaluates several [PathQuery] values against the same input in a
+// single traversal. It merges the segments of the queries into trees (see
+// [Segment.Branch]), so that queries that start with the same segments,
+// such as $.a.b.c and $.a.b.d, select from each of them only once, and
+// descendant segments that apply to the same values, such as those of $..x
+// and $..y, visit each descendant only once. Create one with
+// [NewQuerySet]. It's safe for concurrent use.
+type QuerySet struct {
+	queries []*PathQuery
+	// root and current hold the trees of segments for root and relative
+	// queries.
+	root    []*Segment
+	current []*Segment
+	// ends maps each segment in the trees to the indexes of the queries
+	// that end with it, while rootEnds and currentEnds list the queries
+	// with no segments.
+	ends        map[*Segment][]int
+	rootEnds    []int
+	currentEnds []int
+}
+
+// NewQuerySet creates a [QuerySet] that evaluates queries.
+func NewQuerySet(queries ...*PathQuery) *QuerySet {
+	qs := &QuerySet{queries: queries, ends: map[*Segment][]int{}}
+	for i, q := range queries {
+		switch {
+		case len(q.segments) == 0 && q.root:
+			qs.rootEnds = append(qs.rootEnds, i)
+		case len(q.segments) == 0:
+			qs.currentEnds = append(qs.currentEnds, i)
+		case q.root:
+			var leaf *Segment
+			qs.root, leaf = mergeBranch(qs.root, q.segments)
+			qs.ends[leaf] = append(qs.ends[leaf], i)
+		default:
+			var leaf *Segment
+			qs.current, leaf = mergeBranch(qs.current, q.segments)
+			qs.ends[leaf] = append(qs.ends[leaf], i)
+		}
+	}
+	return qs
+}
+
+// Queries returns the queries in qs.
+func (qs *QuerySet) Queries() []*PathQuery {
+	return qs.queries
+}
+
+// Select selects the values from current or root for each query in qs and
+// returns the results, in the same order as the queries. Returns the same
+// values for each query as [PathQuery.Select].
+func (qs *QuerySet) Select(current, root any) [][]any {
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	return qs.selectFrom(current, ev)
+}
+
+// SelectWith selects the values from current or root for each query in qs
+// as configured by opts and returns the results, in the same order as the
+// queries. Returns the same values for each query as
+// [PathQuery.SelectWith], or nil if evaluation aborts.
+func (qs *QuerySet) SelectWith(current, root any, opts Options) [][]any {
+	ev := newEvaluation(root, opts)
+	defer ev.dropAbort()
+	return qs.selectFrom(current, ev)
+}
+
+// TrySelect selects the values from current or root for each query in qs
+// as configured by opts and returns the results, in the same order as the
+// queries. Returns an [ErrBudgetExceeded] error if evaluation exceeds the
+// limits set by opts.MaxNodes or opts.Timeout, which apply to the traversal
+// as a whole. Otherwise the same as [QuerySet.SelectWith].
+func (qs *QuerySet) TrySelect(current, root any, opts Options) (res [][]any, err error) {
+	defer catchAbort(&err)
+	return qs.selectFrom(current, newEvaluation(root, opts)), nil
+}
+
+// selectFrom selects the values from current or ev.root for each query in
+// qs and returns the results.
+func (qs *QuerySet) selectFrom(current any, ev *evaluation) [][]any {
+	res := make([][]any, len(qs.queries))
+	record := func(ends []int, vals []any) {
+		for i, idx := range ends {
+			if i == 0 {
+				res[idx] = vals
+			} else {
+				// Each query gets its own slice.
+				res[idx] = append(make([]any, 0, len(vals)), vals...)
+			}
+		}
+	}
+	visit := func(seg *Segment, vals []any) { record(qs.ends[seg], vals) }
+
+	record(qs.rootEnds, []any{ev.root})
+	record(qs.currentEnds, []any{current})
+	selectBranches(qs.root, []any{ev.root}, ev, visit)
+	selectBranches(qs.current, []any{current}, ev, visit)
+	return res
+}
+
+// SelectLocated selects the values from current or root for each query in
+// qs into [LocatedNode] values and returns the results, in the same order as
+// the queries. Returns the same values for each query as
+// [PathQuery.SelectLocated].
+func (qs *QuerySet) SelectLocated(current, root any, parent NormalizedPath) [][]*LocatedNode {
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	return qs.selectLocatedFrom(current, ev, parent)
+}
+
+// SelectLocatedWith selects the values from current or root for each query
+// in qs into [LocatedNode] values as configured by opts and returns the
+// results, in the same order as the queries. Returns the same values for
+// each query as [PathQuery.SelectLocatedWith], or nil if evaluation aborts.
+func (qs *QuerySet) SelectLocatedWith(current, root any, parent NormalizedPath, opts Options) [][]*LocatedNode {
+	ev := newEvaluation(root, opts)
+	defer ev.dropAbort()
+	return qs.selectLocatedFrom(current, ev, parent)
+}
+
+// selectLocatedFrom selects the values from current or ev.root for each
+// query in qs into [LocatedNode] values and returns the results.
+func (qs *QuerySet) selectLocatedFrom(current any, ev *evaluation, parent NormalizedPath) [][]*LocatedNode {
+	res := make([][]*LocatedNode, len(qs.queries))
+	record := func(ends []int, vals []*LocatedNode) {
+		for i, idx := range ends {
+			if i == 0 {
+				res[idx] = vals
+			} else {
+				// Each query gets its own slice.
+				res[idx] = append(make([]*LocatedNode, 0, len(vals)), vals...)
+			}
+		}
+	}
+	visit := func(seg *Segment, vals []*LocatedNode) { record(qs.ends[seg], vals) }
+
+	root := []*LocatedNode{newLocatedNode(nil, ev.root)}
+	cur := []*LocatedNode{newLocatedNode(parent, current)}
+	record(qs.rootEnds, root)
+	record(qs.currentEnds, cur)
+	selectLocatedBranches(qs.root, root, ev, visit)
+	selectLocatedBranches(qs.current, cur, ev, visit)
+	return res
+}
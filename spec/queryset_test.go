@@ -0,0 +1,188 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewQuerySet(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ab := Child(Name("a"), Name("b"))
+	x := Descendant(Name("x"))
+	y := Descendant(Name("y"))
+	queries := []*PathQuery{
+		Query(true, ab, Child(Index(0))),
+		Query(true, ab, x),
+		Query(true, Child(Name("a"), Name("b")), y),
+		Query(false, x),
+		Query(true),
+		Query(true, ab, x),
+	}
+	qs := NewQuerySet(queries...)
+	a.Equal(queries, qs.Queries())
+
+	a.Equal([]*Segment{
+		ab.Branch(Child(Index(0)), x, y),
+	}, qs.root)
+	a.Equal([]*Segment{x}, qs.current)
+	a.Equal([]int{4}, qs.rootEnds)
+	a.Nil(qs.currentEnds)
+	a.Equal(map[*Segment][]int{
+		qs.root[0].branches[0]: {0},
+		qs.root[0].branches[1]: {1, 5},
+		qs.root[0].branches[2]: {2},
+		qs.current[0]:          {3},
+	}, qs.ends)
+
+	// Should not have modified the queries' segments.
+	a.Nil(ab.Branches())
+	a.Nil(x.Branches())
+
+	// No queries.
+	qs = NewQuerySet()
+	a.Nil(qs.root)
+	a.Nil(qs.current)
+	a.Empty(qs.ends)
+	a.Equal([][]any{}, qs.Select(nil, nil))
+}
+
+func TestQuerySetSelect(t *testing.T) {
+	t.Parallel()
+
+	items := make([]any, parallelThreshold)
+	for i := range items {
+		items[i] = pairs{{"x", i}, {"y", []any{pairs{{"x", -i}}}}}
+	}
+	root := pairs{
+		{"limit", 3},
+		{"a", pairs{
+			{"b", []any{pairs{{"x", 1}, {"y", 2}}, pairs{{"x", 3}, {"z", pairs{{"y", 4}}}}}},
+			{"c", pairs{{"x", 5}, {"y", []any{6, 7}}}},
+		}},
+		{"items", items},
+	}
+	current := []any{pairs{{"x", "cur"}}}
+
+	for _, tc := range []struct {
+		test    string
+		queries []*PathQuery
+		opts    Options
+	}{
+		{
+			test: "shared_prefix",
+			queries: []*PathQuery{
+				Query(true, Child(Name("a")), Child(Name("b")), Child(Index(0)), Child(Name("x"))),
+				Query(true, Child(Name("a")), Child(Name("b")), Child(Index(1)), Child(Name("x"))),
+				Query(true, Child(Name("a")), Child(Name("c")), Child(Name("y")), Child(Index(-1))),
+				Query(true, Child(Name("a")), Child(Name("b"))),
+			},
+		},
+		{
+			test: "descendants",
+			queries: []*PathQuery{
+				Query(true, Child(Name("a")), Descendant(Name("x"))),
+				Query(true, Child(Name("a")), Descendant(Name("y"))),
+				Query(true, Child(Name("a")), Descendant(Wildcard())),
+				Query(true, Child(Name("a")), Descendant(Name("y")), Child(Index(0))),
+			},
+		},
+		{
+			test: "duplicates",
+			queries: []*PathQuery{
+				Query(true, Descendant(Name("x"))),
+				Query(true, Descendant(Name("x"))),
+				Query(true),
+				Query(true),
+			},
+		},
+		{
+			test: "root_and_current",
+			queries: []*PathQuery{
+				Query(true, Descendant(Name("x"))),
+				Query(false, Descendant(Name("x"))),
+				Query(false),
+			},
+		},
+		{
+			test: "filters",
+			queries: []*PathQuery{
+				Query(true, Descendant(Filter(And(Comparison(
+					SingularQuery(false, Name("x")),
+					LessThan,
+					SingularQuery(true, Name("limit")),
+				))))),
+				Query(true, Descendant(Filter(And(Existence(
+					Query(false, Child(Name("z"))),
+				))))),
+			},
+		},
+		{
+			test: "max_depth",
+			queries: []*PathQuery{
+				Query(true, Descendant(Name("x"))),
+				Query(true, Descendant(Name("y"))),
+			},
+			opts: Options{MaxDepth: 3},
+		},
+		{
+			test: "parallel",
+			queries: []*PathQuery{
+				Query(true, Child(Name("items")), Descendant(Name("x"))),
+				Query(true, Child(Name("items")), Descendant(Name("y"))),
+				Query(true, Descendant(Name("limit"))),
+			},
+			opts: Options{Parallelism: 4},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			qs := NewQuerySet(tc.queries...)
+
+			vals := qs.SelectWith(current, root, tc.opts)
+			located := qs.SelectLocatedWith(current, root, Normalized(Name("cur")), tc.opts)
+			a.Len(vals, len(tc.queries))
+			a.Len(located, len(tc.queries))
+			for i, q := range tc.queries {
+				a.Equal(q.SelectWith(current, root, tc.opts), vals[i], q.String())
+				a.Equal(
+					q.SelectLocatedWith(current, root, Normalized(Name("cur")), tc.opts),
+					located[i], q.String(),
+				)
+			}
+
+			res, err := qs.TrySelect(current, root, tc.opts)
+			a.NoError(err)
+			a.Equal(vals, res)
+
+			if tc.opts == (Options{}) {
+				a.Equal(vals, qs.Select(current, root))
+				a.Equal(located, qs.SelectLocated(current, root, Normalized(Name("cur"))))
+			}
+		})
+	}
+}
+
+func TestQuerySetTrySelect(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := []any{[]any{1, 2}, []any{3, []any{4, 5}}}
+	qs := NewQuerySet(
+		Query(true, Descendant(Wildcard())),
+		Query(true, Child(Index(0))),
+	)
+
+	res, err := qs.TrySelect(nil, input, Options{MaxNodes: 100})
+	a.NoError(err)
+	a.Equal(qs.Select(nil, input), res)
+
+	// The budget applies to the traversal as a whole.
+	res, err = qs.TrySelect(nil, input, Options{MaxNodes: 3})
+	a.ErrorIs(err, ErrBudgetExceeded)
+	a.EqualError(err, "evaluation budget exceeded: visited more than 3 nodes")
+	a.Nil(res)
+}
@@ -0,0 +1,250 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRewrite errors are returned by [Rewrite] for replacements that would
+// produce an invalid query.
+var ErrRewrite = errors.New("jsonpath: invalid rewrite")
+
+// Rewrite returns a copy of q in which fn has replaced its nodes. It
+// traverses the abstract syntax tree of q in depth-first order, as
+// described by [Walk], but calls fn for each node after it has rewritten
+// the children of the node, passing a copy of the node that contains the
+// rewritten children. fn returns the node to use in its place: the node
+// passed to it to leave it unchanged, or a replacement of a compatible type,
+// such as a [Selector] to replace a Selector, or a [BasicExpr] to replace a
+// BasicExpr. Return nil to remove a [*Segment] from its query or a
+// [Selector] from its segment; nil leaves other nodes unchanged. Rewrite
+// never modifies q.
+//
+// Returns an [ErrRewrite] error if fn returns a node of an incompatible
+// type, a selector other than a [Name] or [Index] for a singular query, or
+// removes all the selectors of a segment without also removing the segment.
+//
+// Use Rewrite to prefix queries with additional segments, to rename object
+// members after a schema change, or to remove filters, for example.
+func Rewrite(q *PathQuery, fn func(node any) any) (*PathQuery, error) {
+	r := rewriter{fn}
+	return r.query(q)
+}
+
+// Clone returns a deep copy of q that shares no segments, selectors, or
+// expressions with q, so that modifying the slices returned by accessors
+// such as [PathQuery.Segments] of one has no effect on the other. Function
+// expressions in the copy call the same [FuncExtension] values as q.
+func (q *PathQuery) Clone() *PathQuery {
+	res, _ := Rewrite(q, func(node any) any { return node })
+	return res
+}
+
+// rewriter implements [Rewrite].
+type rewriter struct {
+	fn func(node any) any
+}
+
+// replace passes node to r.fn and returns the result as a T. Returns false
+// if r.fn returns nil and removable is true. Returns node if r.fn returns nil
+// and removable is false.
+func replace[T any](r rewriter, node T, removable bool) (T, bool, error) {
+	out := r.fn(node)
+	if out == nil {
+		return node, !removable, nil
+	}
+	res, ok := out.(T)
+	if !ok {
+		return res, false, fmt.Errorf("%w: cannot replace %T with %T", ErrRewrite, node, out)
+	}
+	return res, true, nil
+}
+
+// query rewrites q.
+func (r rewriter) query(q *PathQuery) (*PathQuery, error) {
+	segs := make([]*Segment, 0, len(q.segments))
+	for _, seg := range q.segments {
+		seg, ok, err := r.segment(seg)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			segs = append(segs, seg)
+		}
+	}
+	q, _, err := replace(r, Query(q.root, segs...), false)
+	return q, err
+}
+
+// segment rewrites seg. Returns false if r.fn removes it.
+func (r rewriter) segment(seg *Segment) (*Segment, bool, error) {
+	sels, err := r.selectors(seg.selectors, false)
+	if err != nil {
+		return nil, false, err
+	}
+	res := Child(sels...)
+	if seg.descendant {
+		res = Descendant(sels...)
+	}
+
+	res, ok, err := replace(r, res, true)
+	if ok && len(res.selectors) == 0 {
+		return nil, false, fmt.Errorf("%w: segment %v has no selectors", ErrRewrite, seg)
+	}
+	return res, ok, err
+}
+
+// selectors rewrites sels. If singular is true, returns an error for
+// rewritten selectors that are not singular.
+func (r rewriter) selectors(sels []Selector, singular bool) ([]Selector, error) {
+	res := make([]Selector, 0, len(sels))
+	for _, sel := range sels {
+		if f, ok := sel.(*FilterSelector); ok {
+			lo, err := r.or(f.LogicalOr)
+			if err != nil {
+				return nil, err
+			}
+			sel = Filter(lo...)
+		}
+		sel, ok, err := replace(r, sel, !singular)
+		if err != nil {
+			return nil, err
+		}
+		if singular && !sel.isSingular() {
+			return nil, fmt.Errorf("%w: selector %v is not singular", ErrRewrite, sel)
+		}
+		if ok {
+			res = append(res, sel)
+		}
+	}
+	return res, nil
+}
+
+// or rewrites lo.
+func (r rewriter) or(lo LogicalOr) (LogicalOr, error) {
+	res := make(LogicalOr, len(lo))
+	for i, and := range lo {
+		var err error
+		if res[i], err = r.and(and); err != nil {
+			return nil, err
+		}
+	}
+	res, _, err := replace(r, res, false)
+	return res, err
+}
+
+// and rewrites la.
+func (r rewriter) and(la LogicalAnd) (LogicalAnd, error) {
+	res := make(LogicalAnd, len(la))
+	for i, expr := range la {
+		var err error
+		if res[i], err = r.expr(expr); err != nil {
+			return nil, err
+		}
+	}
+	res, _, err := replace(r, res, false)
+	return res, err
+}
+
+// expr rewrites expr, a [BasicExpr].
+func (r rewriter) expr(expr BasicExpr) (BasicExpr, error) {
+	var err error
+	switch e := expr.(type) {
+	case *ParenExpr:
+		var lo LogicalOr
+		if lo, err = r.or(e.LogicalOr); err == nil {
+			expr = &ParenExpr{lo}
+		}
+	case *NotParenExpr:
+		var lo LogicalOr
+		if lo, err = r.or(e.LogicalOr); err == nil {
+			expr = &NotParenExpr{lo}
+		}
+	case *ExistExpr:
+		var q *PathQuery
+		if q, err = r.query(e.PathQuery); err == nil {
+			expr = Existence(q)
+		}
+	case *NonExistExpr:
+		var q *PathQuery
+		if q, err = r.query(e.PathQuery); err == nil {
+			expr = Nonexistence(q)
+		}
+	case NonExistExpr:
+		var q *PathQuery
+		if q, err = r.query(e.PathQuery); err == nil {
+			expr = Nonexistence(q)
+		}
+	case *CompExpr:
+		var left, right CompVal
+		if left, err = r.value(e.left); err != nil {
+			return nil, err
+		}
+		if right, err = r.value(e.right); err == nil {
+			expr = Comparison(left, e.op, right)
+		}
+	case *FuncExpr:
+		expr, err = r.function(e)
+	case NotFuncExpr:
+		var fe *FuncExpr
+		if fe, err = r.function(e.FuncExpr); err == nil {
+			expr = NotFunction(fe)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expr, _, err = replace(r, expr, false)
+	return expr, err
+}
+
+// value rewrites val, a comparison operand.
+func (r rewriter) value(val CompVal) (CompVal, error) {
+	arg, err := r.arg(val.(FuncExprArg))
+	if err != nil {
+		return nil, err
+	}
+	res, ok := arg.(CompVal)
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot replace %T with %T", ErrRewrite, val, arg)
+	}
+	return res, nil
+}
+
+// arg rewrites arg, a function argument or comparison operand.
+func (r rewriter) arg(arg FuncExprArg) (FuncExprArg, error) {
+	switch a := arg.(type) {
+	case *PathQuery:
+		return r.query(a)
+	case LogicalOr:
+		return r.or(a)
+	case *FuncExpr:
+		fe, err := r.function(a)
+		if err != nil {
+			return nil, err
+		}
+		arg = fe
+	case *SingularQueryExpr:
+		sels, err := r.selectors(a.selectors, true)
+		if err != nil {
+			return nil, err
+		}
+		arg = SingularQuery(!a.relative, sels...)
+	}
+	arg, _, err := replace(r, arg, false)
+	return arg, err
+}
+
+// function rewrites the arguments of fe. The caller passes the result to
+// r.fn as a [BasicExpr] or [FuncExprArg], as appropriate.
+func (r rewriter) function(fe *FuncExpr) (*FuncExpr, error) {
+	args := make([]FuncExprArg, len(fe.args))
+	for i, arg := range fe.args {
+		var err error
+		if args[i], err = r.arg(arg); err != nil {
+			return nil, err
+		}
+	}
+	return Function(fe.fn, args...), nil
+}
@@ -0,0 +1,264 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewrite(t *testing.T) {
+	t.Parallel()
+
+	fn := newValueFunc(1)
+	// $.a[0,?@.a > 1 || (!@.a && __val(@.a) == $.a)]..*
+	query := func() *PathQuery {
+		return Query(
+			true,
+			Child(Name("a")),
+			Child(
+				Index(0),
+				Filter(
+					And(Comparison(SingularQuery(false, Name("a")), GreaterThan, Literal(1))),
+					And(NotParen(
+						And(
+							Nonexistence(Query(false, Child(Name("a")))),
+							Comparison(
+								Function(fn, Query(false, Child(Name("a")))),
+								EqualTo,
+								SingularQuery(true, Name("a")),
+							),
+						),
+					)),
+				),
+			),
+			Descendant(Wildcard()),
+		)
+	}
+
+	for _, tc := range []struct {
+		test string
+		fn   func(node any) any
+		exp  string
+		err  string
+	}{
+		{
+			test: "identity",
+			fn:   func(node any) any { return node },
+			exp:  `$["a"][0,?@["a"] > 1 || !(!@["a"] && __val(@["a"]) == $["a"])]..[*]`,
+		},
+		{
+			test: "remove_all",
+			fn:   func(any) any { return nil },
+			exp:  `$`,
+		},
+		{
+			test: "rename",
+			fn: func(node any) any {
+				if node == Name("a") {
+					return Name("b")
+				}
+				return node
+			},
+			exp: `$["b"][0,?@["b"] > 1 || !(!@["b"] && __val(@["b"]) == $["b"])]..[*]`,
+		},
+		{
+			test: "prefix_root",
+			fn: func(node any) any {
+				if q, ok := node.(*PathQuery); ok && q.IsRoot() {
+					return Query(true, append([]*Segment{Child(Name("data"))}, q.Segments()...)...)
+				}
+				return node
+			},
+			exp: `$["data"]["a"][0,?@["a"] > 1 || !(!@["a"] && __val(@["a"]) == $["a"])]..[*]`,
+		},
+		{
+			test: "strip_filters",
+			fn: func(node any) any {
+				if _, ok := node.(*FilterSelector); ok {
+					return nil
+				}
+				return node
+			},
+			exp: `$["a"][0]..[*]`,
+		},
+		{
+			test: "remove_segment",
+			fn: func(node any) any {
+				if seg, ok := node.(*Segment); ok && seg.IsDescendant() {
+					return nil
+				}
+				return node
+			},
+			exp: `$["a"][0,?@["a"] > 1 || !(!@["a"] && __val(@["a"]) == $["a"])]`,
+		},
+		{
+			test: "replace_expr",
+			fn: func(node any) any {
+				if _, ok := node.(*NotParenExpr); ok {
+					return Existence(Query(false, Child(Name("c"))))
+				}
+				return node
+			},
+			exp: `$["a"][0,?@["a"] > 1 || @["c"]]..[*]`,
+		},
+		{
+			test: "replace_operand",
+			fn: func(node any) any {
+				if lit, ok := node.(*LiteralArg); ok && lit.Value() == 1 {
+					return Literal(2)
+				}
+				return node
+			},
+			exp: `$["a"][0,?@["a"] > 2 || !(!@["a"] && __val(@["a"]) == $["a"])]..[*]`,
+		},
+		{
+			test: "replace_function",
+			fn: func(node any) any {
+				if fe, ok := node.(*FuncExpr); ok {
+					return Function(newValueFunc(2), fe.Args()...)
+				}
+				return node
+			},
+			exp: `$["a"][0,?@["a"] > 1 || !(!@["a"] && __val(@["a"]) == $["a"])]..[*]`,
+		},
+		{
+			test: "all_selectors_removed",
+			fn: func(node any) any {
+				if _, ok := node.(WildcardSelector); ok {
+					return nil
+				}
+				return node
+			},
+			err: `jsonpath: invalid rewrite: segment ..[*] has no selectors`,
+		},
+		{
+			test: "incompatible_type",
+			fn: func(node any) any {
+				if node == Index(0) {
+					return Child(Index(0))
+				}
+				return node
+			},
+			err: `jsonpath: invalid rewrite: cannot replace spec.Index with *spec.Segment`,
+		},
+		{
+			test: "incompatible_operand",
+			fn: func(node any) any {
+				if q, ok := node.(*PathQuery); ok && !q.IsRoot() {
+					return LogicalOr{}
+				}
+				return node
+			},
+			err: `jsonpath: invalid rewrite: cannot replace *spec.PathQuery with spec.LogicalOr`,
+		},
+		{
+			test: "non_singular",
+			fn: func(node any) any {
+				if node == Name("a") {
+					return Wildcard()
+				}
+				return node
+			},
+			err: `jsonpath: invalid rewrite: selector * is not singular`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			q := query()
+			orig := q.String()
+			res, err := Rewrite(q, tc.fn)
+			a.Equal(orig, q.String())
+			if tc.err != "" {
+				a.ErrorIs(err, ErrRewrite)
+				a.EqualError(err, tc.err)
+				a.Nil(res)
+				return
+			}
+			require.NoError(t, err)
+			a.Equal(tc.exp, res.String())
+			a.NotSame(q, res)
+		})
+	}
+}
+
+func TestRewriteArgs(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	fn := newTrueFunc()
+	// $[?!__true(@.a, $.a) && __true(@.a || @.b)]
+	q := Query(true, Child(Filter(And(
+		NotFunction(Function(fn, SingularQuery(false, Name("a")), SingularQuery(true, Name("a")))),
+		Function(fn, LogicalOr{
+			And(Existence(Query(false, Child(Name("a"))))),
+			And(Existence(Query(false, Child(Name("b"))))),
+		}),
+	))))
+	res, err := Rewrite(q, func(node any) any {
+		if node == Name("a") {
+			return Index(1)
+		}
+		return node
+	})
+	require.NoError(t, err)
+	a.Equal(`$[?!__true(@[1], $[1]) && __true(@[1] || @["b"])]`, res.String())
+
+	// Should rewrite a NonExistExpr value.
+	q = Query(true, Child(Filter(And(NonExistExpr{Query(false, Child(Name("a")))}))))
+	res, err = Rewrite(q, func(node any) any {
+		if node == Name("a") {
+			return Name("b")
+		}
+		return node
+	})
+	require.NoError(t, err)
+	a.Equal(`$[?!@["b"]]`, res.String())
+
+	// Should return errors from nested nodes.
+	for _, q := range []*PathQuery{
+		Query(true, Child(Filter(And(Paren(And(Existence(Query(false, Child(Name("a")))))))))),
+		Query(true, Child(Filter(And(NotFunction(Function(fn, Query(false, Child(Name("a"))))))))),
+		Query(true, Child(Filter(And(Comparison(Literal(1), EqualTo, SingularQuery(false, Name("a"))))))),
+		Query(true, Child(Filter(And(Function(fn, LogicalOr{And(Existence(Query(false, Child(Name("a")))))}))))),
+	} {
+		res, err = Rewrite(q, func(node any) any {
+			if node == Name("a") {
+				return Child(Name("a"))
+			}
+			return node
+		})
+		a.ErrorIs(err, ErrRewrite, q.String())
+		a.Nil(res)
+	}
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	fn := newValueFunc(1)
+	q := Query(
+		true,
+		Child(Name("a")),
+		Child(Filter(
+			And(Comparison(SingularQuery(false, Name("a")), GreaterThan, Literal(1))),
+			And(Function(fn, Query(false, Descendant(Wildcard())))),
+		)),
+	)
+	clone := q.Clone()
+	a.NotSame(q, clone)
+	a.Equal(q, clone)
+	a.Equal(q.String(), clone.String())
+	for i, seg := range q.Segments() {
+		a.NotSame(seg, clone.Segments()[i])
+	}
+	a.NotSame(q.Segments()[1].Selectors()[0], clone.Segments()[1].Selectors()[0])
+
+	// Modifying the clone should not affect the original.
+	clone.Segments()[1].Selectors()[0] = Index(0)
+	a.Equal(`$["a"][0]`, clone.String())
+	a.Equal(`$["a"][?@["a"] > 1 || __val(@..[*])]`, q.String())
+}
@@ -0,0 +1,161 @@
+package spec
+
+import "fmt"
+
+// scalarKind identifies the type of value represented by a [scalar].
+type scalarKind uint8
+
+const (
+	// scalarOther represents any value other than null, a boolean, a
+	// string, or a number of a built-in Go numeric type, such as an object,
+	// array, [json.Number], or [Comparable], as well as integers too large
+	// to convert to float64 exactly, which compare exactly as [ValueType]
+	// values.
+	scalarOther scalarKind = iota
+	// scalarNothing represents the absence of a value, as when a singular
+	// query selects nothing.
+	scalarNothing
+	scalarNull
+	scalarBool
+	scalarNumber
+	scalarString
+)
+
+// scalar is an unboxed representation of the values most commonly compared
+// in filter expressions: null, booleans, strings, and numbers of the
+// built-in Go numeric types, which it normalizes to float64 just as
+// comparisons of such values do. Comparisons between scalars thus need
+// neither allocate [ValueType] values nor switch on numeric types. A scalar
+// also retains the value it represents, so that comparisons with
+// [scalarOther] values may fall back on [ValueType] comparison.
+type scalar struct {
+	kind scalarKind
+	// num holds the value of a number, or 1 for true and 0 for false.
+	num float64
+	str string
+	val any
+}
+
+// scalarVal is implemented by [CompVal] values that can produce a [scalar]
+// for comparison.
+type scalarVal interface {
+	// asScalar returns the value to be compared as a scalar.
+	asScalar(current any, ev *evaluation) scalar
+}
+
+// toScalar converts val to a [scalar].
+func toScalar(val any) scalar {
+	switch v := val.(type) {
+	case nil:
+		return scalar{kind: scalarNull}
+	case bool:
+		if v {
+			return scalar{kind: scalarBool, num: 1, val: val}
+		}
+		return scalar{kind: scalarBool, val: val}
+	case string:
+		return scalar{kind: scalarString, str: v, val: val}
+	case int:
+		if isLargeInt(v) {
+			return scalar{val: val}
+		}
+		return scalar{kind: scalarNumber, num: float64(v), val: val}
+	case int8:
+		return scalar{kind: scalarNumber, num: float64(v), val: val}
+	case int16:
+		return scalar{kind: scalarNumber, num: float64(v), val: val}
+	case int32:
+		return scalar{kind: scalarNumber, num: float64(v), val: val}
+	case int64:
+		if isLargeInt(v) {
+			return scalar{val: val}
+		}
+		return scalar{kind: scalarNumber, num: float64(v), val: val}
+	case uint:
+		if isLargeInt(v) {
+			return scalar{val: val}
+		}
+		return scalar{kind: scalarNumber, num: float64(v), val: val}
+	case uint8:
+		return scalar{kind: scalarNumber, num: float64(v), val: val}
+	case uint16:
+		return scalar{kind: scalarNumber, num: float64(v), val: val}
+	case uint32:
+		return scalar{kind: scalarNumber, num: float64(v), val: val}
+	case uint64:
+		if isLargeInt(v) {
+			return scalar{val: val}
+		}
+		return scalar{kind: scalarNumber, num: float64(v), val: val}
+	case float32:
+		return scalar{kind: scalarNumber, num: float64(v), val: val}
+	case float64:
+		return scalar{kind: scalarNumber, num: v, val: val}
+	default:
+		return scalar{val: val}
+	}
+}
+
+// pathValue returns s as a [PathValue]: nil for [scalarNothing] and a
+// [ValueType] for anything else.
+func (s scalar) pathValue() PathValue {
+	if s.kind == scalarNothing {
+		return nil
+	}
+	return &ValueType{s.val}
+}
+
+// compareScalars uses op to compare left and right, neither of which may be
+// a [scalarOther] value. Returns the same result as comparing them as
+// [ValueType] values.
+func compareScalars(op CompOp, left, right scalar) bool {
+	// Values of different types never compare, while nothing compares to
+	// nothing only for equality.
+	ordered := left.kind == right.kind && left.kind != scalarNothing
+	switch op {
+	case EqualTo:
+		return left.equalTo(right)
+	case NotEqualTo:
+		return !left.equalTo(right)
+	case LessThan:
+		return ordered && left.lessThan(right)
+	case GreaterThan:
+		return ordered && !left.lessThan(right) && !left.equalTo(right)
+	case LessThanEqualTo:
+		return ordered && (left.lessThan(right) || left.equalTo(right))
+	case GreaterThanEqualTo:
+		return ordered && !left.lessThan(right)
+	default:
+		panic(fmt.Sprintf("Unknown operator %v", op))
+	}
+}
+
+// equalTo returns true if s and other have the same kind and value.
+func (s scalar) equalTo(other scalar) bool {
+	if s.kind != other.kind {
+		return false
+	}
+	switch s.kind {
+	case scalarNumber, scalarBool:
+		return s.num == other.num
+	case scalarString:
+		return s.str == other.str
+	default: // scalarNothing, scalarNull
+		return true
+	}
+}
+
+// lessThan returns true if s and other are both numbers or both strings and
+// s is less than other.
+func (s scalar) lessThan(other scalar) bool {
+	switch {
+	case s.kind != other.kind:
+		return false
+	case s.kind == scalarNumber:
+		return s.num < other.num
+	case s.kind == scalarString:
+		return s.str < other.str
+	default:
+		return false
+	}
+}
@@ -0,0 +1,135 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToScalar(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		val any
+		exp scalar
+	}{
+		{nil, scalar{kind: scalarNull}},
+		{true, scalar{kind: scalarBool, num: 1, val: true}},
+		{false, scalar{kind: scalarBool, val: false}},
+		{"hi", scalar{kind: scalarString, str: "hi", val: "hi"}},
+		{int(1), scalar{kind: scalarNumber, num: 1, val: int(1)}},
+		{int8(2), scalar{kind: scalarNumber, num: 2, val: int8(2)}},
+		{int16(3), scalar{kind: scalarNumber, num: 3, val: int16(3)}},
+		{int32(4), scalar{kind: scalarNumber, num: 4, val: int32(4)}},
+		{int64(5), scalar{kind: scalarNumber, num: 5, val: int64(5)}},
+		{uint(6), scalar{kind: scalarNumber, num: 6, val: uint(6)}},
+		{uint8(7), scalar{kind: scalarNumber, num: 7, val: uint8(7)}},
+		{uint16(8), scalar{kind: scalarNumber, num: 8, val: uint16(8)}},
+		{uint32(9), scalar{kind: scalarNumber, num: 9, val: uint32(9)}},
+		{uint64(10), scalar{kind: scalarNumber, num: 10, val: uint64(10)}},
+		{float32(1.5), scalar{kind: scalarNumber, num: 1.5, val: float32(1.5)}},
+		{float64(2.5), scalar{kind: scalarNumber, num: 2.5, val: float64(2.5)}},
+		{int64(1 << 53), scalar{kind: scalarNumber, num: 1 << 53, val: int64(1 << 53)}},
+		{int64(-1 << 53), scalar{kind: scalarNumber, num: -1 << 53, val: int64(-1 << 53)}},
+		{int(1<<53 + 1), scalar{val: int(1<<53 + 1)}},
+		{int64(-1<<53 - 1), scalar{val: int64(-1<<53 - 1)}},
+		{uint(1<<53 + 1), scalar{val: uint(1<<53 + 1)}},
+		{uint64(math.MaxUint64), scalar{val: uint64(math.MaxUint64)}},
+		{json.Number("1"), scalar{val: json.Number("1")}},
+		{[]any{1}, scalar{val: []any{1}}},
+		{map[string]any{}, scalar{val: map[string]any{}}},
+	} {
+		t.Run(fmt.Sprintf("%T_%v", tc.val, tc.val), func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, toScalar(tc.val))
+		})
+	}
+}
+
+func TestCompareScalars(t *testing.T) {
+	t.Parallel()
+
+	// Every pair of these values should compare the same as scalars as they
+	// do as ValueTypes.
+	vals := []any{
+		nil, true, false, "", "a", "b",
+		0, 1, int8(-1), int16(2), int32(1), int64(math.MaxInt64), uint(1),
+		uint8(2), uint16(0), uint32(3), uint64(math.MaxUint64),
+		float32(1), 0.5, 1.0, math.Inf(1), math.NaN(),
+		json.Number("1"), big.NewInt(2), time.Unix(0, 0).UTC(),
+		"1970-01-01T00:00:00Z", []any{1}, map[string]any{"x": 1},
+	}
+	nothing := scalar{kind: scalarNothing}
+	ops := []CompOp{
+		EqualTo, NotEqualTo, LessThan, GreaterThan, LessThanEqualTo,
+		GreaterThanEqualTo,
+	}
+
+	for _, op := range ops {
+		t.Run(op.String(), func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			ce := Comparison(
+				SingularQuery(false, Index(0)), op, SingularQuery(false, Index(1)),
+			)
+
+			for _, lv := range append(vals, nothing) {
+				ls, ok := lv.(scalar)
+				if !ok {
+					ls = toScalar(lv)
+				}
+				for _, rv := range append(vals, nothing) {
+					rs, ok := rv.(scalar)
+					if !ok {
+						rs = toScalar(rv)
+					}
+					exp := op.compare(ls.pathValue(), rs.pathValue())
+					if ls.kind != scalarOther && rs.kind != scalarOther {
+						a.Equal(exp, compareScalars(op, ls, rs), "%#v %v %#v", lv, op, rv)
+					}
+
+					// CompExpr should get the same result either way.
+					if ls.kind == scalarNothing || rs.kind == scalarNothing {
+						continue
+					}
+					a.Equal(exp, ce.testFilter([]any{lv, rv}, &evaluation{}), "%#v %v %#v", lv, op, rv)
+				}
+			}
+		})
+	}
+
+	a := assert.New(t)
+	a.PanicsWithValue("Unknown operator CompOp(16)", func() {
+		compareScalars(CompOp(16), toScalar(1), toScalar(1))
+	})
+}
+
+//nolint:paralleltest // AllocsPerRun panics in parallel tests.
+func TestScalarComparisonAllocs(t *testing.T) {
+	a := assert.New(t)
+
+	current := map[string]any{"a": []any{"x", 42.0, int64(7)}, "b": true}
+	ev := &evaluation{root: current}
+	for _, ce := range []*CompExpr{
+		Comparison(SingularQuery(false, Name("a"), Index(1)), GreaterThan, Literal(int64(10))),
+		Comparison(SingularQuery(false, Name("a"), Index(0)), EqualTo, Literal("x")),
+		Comparison(SingularQuery(false, Name("b")), NotEqualTo, Literal(false)),
+		Comparison(SingularQuery(false, Name("a"), Index(2)), LessThanEqualTo, SingularQuery(true, Name("a"), Index(1))),
+		Comparison(SingularQuery(false, Name("nope")), EqualTo, Literal(nil)),
+	} {
+		a.Zero(testing.AllocsPerRun(100, func() { ce.testFilter(current, ev) }), ce.String())
+	}
+
+	// Compare with literals that are not scalars.
+	ce := Comparison(SingularQuery(false, Name("a"), Index(1)), EqualTo, Literal(json.Number("42")))
+	a.True(ce.testFilter(current, ev))
+	ce = Comparison(Literal(json.Number("43")), GreaterThan, SingularQuery(false, Name("a"), Index(1)))
+	a.True(ce.testFilter(current, ev))
+	ce = Comparison(&LiteralArg{}, EqualTo, Literal(nil))
+	a.True(ce.testFilter(current, ev))
+}
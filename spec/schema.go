@@ -0,0 +1,771 @@
+package spec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrSchema errors are returned by [ParseSchema] for invalid schemas.
+var ErrSchema = errors.New("jsonpath: invalid schema")
+
+// Schema describes the structure of the JSON values that a [PathQuery]
+// queries, for use by [PathQuery.CheckSchema]. Create one with
+// [ParseSchema] or by unmarshaling a [JSON Schema], or construct one
+// directly as a lightweight type descriptor. The zero value allows any
+// value, as do nil *Schema fields.
+//
+// [JSON Schema]: https://json-schema.org
+type Schema struct {
+	// Types lists the JSON types the value may have: "object", "array",
+	// "string", "number", "integer", "boolean", or "null". Empty allows any
+	// type.
+	Types []string
+	// Properties maps object member names to the schemas of their values.
+	Properties map[string]*Schema
+	// PatternProperties maps regular expressions to the schemas of the
+	// values of object members whose names match them.
+	PatternProperties map[string]*Schema
+	// AdditionalProperties is the schema of the values of object members
+	// not matched by Properties or PatternProperties. Nil allows any
+	// members; set it to a Schema with False set to disallow them.
+	AdditionalProperties *Schema
+	// PrefixItems lists the schemas of the leading elements of arrays.
+	PrefixItems []*Schema
+	// Items is the schema of array elements not matched by PrefixItems.
+	Items *Schema
+	// AnyOf lists schemas one or more of which the value matches. Used only
+	// when the schema sets no other fields.
+	AnyOf []*Schema
+	// False is true for a schema that matches no value, such as the JSON
+	// Schema false.
+	False bool
+}
+
+// anySchema is the schema that allows any value.
+var anySchema = &Schema{}
+
+// ParseSchema parses data, a [JSON Schema], into a [Schema]. It uses the
+// type, enum, const, properties, patternProperties, additionalProperties,
+// prefixItems, items, additionalItems, anyOf, and oneOf keywords, and
+// resolves $ref references to locations in the same document. It ignores
+// other keywords, including allOf, and treats other references as allowing
+// any value, so that [PathQuery.CheckSchema] reports only problems that
+// the schema guarantees. Returns an [ErrSchema] error if data is not valid
+// JSON or not a schema.
+//
+// [JSON Schema]: https://json-schema.org
+func ParseSchema(data []byte) (*Schema, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSchema, err)
+	}
+	b := &schemaBuilder{root: doc, refs: map[string]*Schema{}}
+	return b.build(doc)
+}
+
+// UnmarshalJSON parses data, a [JSON Schema], into s. See [ParseSchema] for
+// details. Implements [json.Unmarshaler].
+//
+// [JSON Schema]: https://json-schema.org
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	schema, err := ParseSchema(data)
+	if err != nil {
+		return err
+	}
+	*s = *schema
+	return nil
+}
+
+// schemaBuilder builds a [Schema] from a decoded JSON Schema document.
+type schemaBuilder struct {
+	root any
+	refs map[string]*Schema
+}
+
+// build builds a [Schema] from val, a decoded JSON Schema.
+func (b *schemaBuilder) build(val any) (*Schema, error) {
+	switch val := val.(type) {
+	case bool:
+		return &Schema{False: !val}, nil
+	case map[string]any:
+		if ref, ok := val["$ref"].(string); ok {
+			return b.ref(ref)
+		}
+		s := &Schema{}
+		if err := b.keywords(s, val); err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		text, _ := json.Marshal(val)
+		return nil, fmt.Errorf("%w: %s is not a schema", ErrSchema, text)
+	}
+}
+
+// ref returns the schema at ref, a reference to a location in the root
+// document, or a schema that allows any value for other references.
+func (b *schemaBuilder) ref(ref string) (*Schema, error) {
+	if s, ok := b.refs[ref]; ok {
+		return s, nil
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return anySchema, nil
+	}
+
+	// Record the schema before building it to support recursive schemas.
+	s := &Schema{}
+	b.refs[ref] = s
+	target, ok := b.resolve(ref[1:])
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot resolve $ref %q", ErrSchema, ref)
+	}
+	built, err := b.build(target)
+	if err != nil {
+		return nil, err
+	}
+	*s = *built
+	return s, nil
+}
+
+// resolve returns the value at ptr, a JSON Pointer, in the root document.
+func (b *schemaBuilder) resolve(ptr string) (any, bool) {
+	tokens, err := ParsePointer(ptr)
+	if err != nil {
+		return nil, false
+	}
+	val, ok := b.root, true
+	for _, tok := range tokens {
+		if val, ok = resolveToken(val, tok); !ok {
+			return nil, false
+		}
+	}
+	return val, true
+}
+
+// keywords sets the fields of s from the keywords of obj.
+func (b *schemaBuilder) keywords(s *Schema, obj map[string]any) error {
+	var err error
+	switch t := obj["type"].(type) {
+	case string:
+		s.Types = []string{t}
+	case []any:
+		for _, name := range t {
+			if name, ok := name.(string); ok {
+				s.Types = append(s.Types, name)
+			}
+		}
+	}
+	if len(s.Types) == 0 {
+		s.Types = enumTypes(obj)
+	}
+
+	if s.Properties, err = b.schemaMap(obj["properties"]); err != nil {
+		return err
+	}
+	if s.PatternProperties, err = b.schemaMap(obj["patternProperties"]); err != nil {
+		return err
+	}
+	if s.AdditionalProperties, err = b.optional(obj["additionalProperties"]); err != nil {
+		return err
+	}
+
+	// Draft 2020-12 uses prefixItems and items; earlier drafts use an items
+	// array and additionalItems.
+	if items, ok := obj["items"].([]any); ok {
+		if s.PrefixItems, err = b.schemaList(items); err != nil {
+			return err
+		}
+		s.Items, err = b.optional(obj["additionalItems"])
+	} else {
+		if s.PrefixItems, err = b.schemaList(obj["prefixItems"]); err != nil {
+			return err
+		}
+		s.Items, err = b.optional(obj["items"])
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, key := range []string{"anyOf", "oneOf"} {
+		alts, err := b.schemaList(obj[key])
+		if err != nil {
+			return err
+		}
+		s.AnyOf = append(s.AnyOf, alts...)
+	}
+	return nil
+}
+
+// optional builds a schema from val, or returns nil if val is nil.
+func (b *schemaBuilder) optional(val any) (*Schema, error) {
+	if val == nil {
+		return nil, nil //nolint:nilnil
+	}
+	return b.build(val)
+}
+
+// schemaMap builds a map of schemas from val, an object of schemas.
+func (b *schemaBuilder) schemaMap(val any) (map[string]*Schema, error) {
+	obj, ok := val.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+	schemas := make(map[string]*Schema, len(obj))
+	for name, sub := range obj {
+		s, err := b.build(sub)
+		if err != nil {
+			return nil, err
+		}
+		schemas[name] = s
+	}
+	return schemas, nil
+}
+
+// schemaList builds a list of schemas from val, an array of schemas.
+func (b *schemaBuilder) schemaList(val any) ([]*Schema, error) {
+	list, ok := val.([]any)
+	if !ok {
+		return nil, nil
+	}
+	schemas := make([]*Schema, len(list))
+	for i, sub := range list {
+		s, err := b.build(sub)
+		if err != nil {
+			return nil, err
+		}
+		schemas[i] = s
+	}
+	return schemas, nil
+}
+
+// enumTypes returns the JSON types of the values of the enum or const
+// keywords of obj, or nil if it has neither.
+func enumTypes(obj map[string]any) []string {
+	vals, ok := obj["enum"].([]any)
+	if c, hasConst := obj["const"]; hasConst {
+		vals, ok = []any{c}, true
+	}
+	if !ok {
+		return nil
+	}
+	var types []string
+	for _, val := range vals {
+		if name := valueType(val).String(); !slices.Contains(types, name) {
+			types = append(types, name)
+		}
+	}
+	return types
+}
+
+// jsonTypes is a set of JSON types.
+type jsonTypes uint8
+
+const (
+	typeObject jsonTypes = 1 << iota
+	typeArray
+	typeString
+	typeNumber
+	typeBoolean
+	typeNull
+
+	typeAny = typeObject | typeArray | typeString | typeNumber | typeBoolean | typeNull
+)
+
+// typeNames are the names of the JSON types in order.
+var typeNames = []string{"object", "array", "string", "number", "boolean", "null"}
+
+// String returns the names of the types in t, joined by " or ".
+func (t jsonTypes) String() string {
+	names := []string{}
+	for i, name := range typeNames {
+		if t&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// article returns the name of t, a single type, with an indefinite
+// article, or "null" for typeNull.
+func (t jsonTypes) article() string {
+	switch t {
+	case typeNull:
+		return "null"
+	case typeObject, typeArray:
+		return "an " + t.String()
+	default:
+		return "a " + t.String()
+	}
+}
+
+// valueType returns the JSON type of val, or typeAny if val is not a JSON
+// value.
+func valueType(val any) jsonTypes {
+	switch val.(type) {
+	case nil:
+		return typeNull
+	case string:
+		return typeString
+	case bool:
+		return typeBoolean
+	case map[string]any:
+		return typeObject
+	case []any:
+		return typeArray
+	}
+	if isNumber(val) {
+		return typeNumber
+	}
+	return typeAny
+}
+
+// types returns the JSON types s allows.
+func (s *Schema) types() jsonTypes {
+	if s.False {
+		return 0
+	}
+	if len(s.Types) == 0 {
+		return typeAny
+	}
+	var types jsonTypes
+	for _, name := range s.Types {
+		switch name {
+		case "integer":
+			types |= typeNumber
+		default:
+			idx := slices.Index(typeNames, name)
+			if idx < 0 {
+				return typeAny
+			}
+			types |= 1 << idx
+		}
+	}
+	return types
+}
+
+// constrained returns true if s sets fields other than AnyOf.
+func (s *Schema) constrained() bool {
+	return s.False || len(s.Types) > 0 || s.Properties != nil ||
+		s.PatternProperties != nil || s.AdditionalProperties != nil ||
+		s.PrefixItems != nil || s.Items != nil
+}
+
+// orAny returns s, or [anySchema] if s is nil.
+func orAny(s *Schema) *Schema {
+	if s == nil {
+		return anySchema
+	}
+	return s
+}
+
+// schemaSet is a set of alternative schemas, one or more of which describe
+// a value.
+type schemaSet []*Schema
+
+// add adds s to set, replacing s with its AnyOf alternatives if it sets no
+// other fields, and omitting schemas that match no value or are already in
+// the set.
+func (set schemaSet) add(s *Schema) schemaSet {
+	s = orAny(s)
+	switch {
+	case slices.Contains(set, s), s.False:
+		return set
+	case len(s.AnyOf) > 0 && !s.constrained():
+		// Add s first to prevent infinite recursion.
+		set = append(set, s)
+		for _, alt := range s.AnyOf {
+			set = set.add(alt)
+		}
+		return set
+	default:
+		return append(set, s)
+	}
+}
+
+// types returns the JSON types that the schemas in set allow.
+func (set schemaSet) types() jsonTypes {
+	var types jsonTypes
+	for _, s := range set {
+		if len(s.AnyOf) > 0 && !s.constrained() {
+			continue
+		}
+		types |= s.types()
+	}
+	return types
+}
+
+// of returns the schemas in set that allow a type in t, omitting schemas
+// that are placeholders for their AnyOf alternatives.
+func (set schemaSet) of(t jsonTypes) schemaSet {
+	var res schemaSet
+	for _, s := range set {
+		if s.types()&t != 0 && (len(s.AnyOf) == 0 || s.constrained()) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// member returns the schemas of the values of the object members named
+// name described by set.
+func (set schemaSet) member(name string) schemaSet {
+	var res schemaSet
+	for _, s := range set.of(typeObject) {
+		if p, ok := s.Properties[name]; ok {
+			res = res.add(p)
+			continue
+		}
+		matched := false
+		for pattern, p := range s.PatternProperties {
+			if re, err := regexp.Compile(pattern); err != nil || re.MatchString(name) {
+				res = res.add(p)
+				matched = true
+			}
+		}
+		if !matched {
+			res = res.add(s.AdditionalProperties)
+		}
+	}
+	return res
+}
+
+// element returns the schemas of the array elements at idx described by
+// set.
+func (set schemaSet) element(idx int) schemaSet {
+	var res schemaSet
+	for _, s := range set.of(typeArray) {
+		switch {
+		case idx >= 0 && idx < len(s.PrefixItems):
+			res = res.add(s.PrefixItems[idx])
+		case idx < 0:
+			for _, item := range s.PrefixItems {
+				res = res.add(item)
+			}
+			res = res.add(s.Items)
+		default:
+			res = res.add(s.Items)
+		}
+	}
+	return res
+}
+
+// children returns the schemas of the values of all object members and
+// array elements described by set.
+func (set schemaSet) children() schemaSet {
+	var res schemaSet
+	for _, s := range set.of(typeObject) {
+		for _, p := range s.Properties {
+			res = res.add(p)
+		}
+		for _, p := range s.PatternProperties {
+			res = res.add(p)
+		}
+		res = res.add(s.AdditionalProperties)
+	}
+	for _, s := range set.element(-1) {
+		res = res.add(s)
+	}
+	return res
+}
+
+// descendants returns the schemas described by set and all of their
+// descendants.
+func (set schemaSet) descendants() schemaSet {
+	var res schemaSet
+	for _, s := range set {
+		res = res.add(s)
+	}
+	for i := 0; i < len(res); i++ {
+		for _, child := range (schemaSet{res[i]}).children() {
+			res = res.add(child)
+		}
+	}
+	return res
+}
+
+// names returns the sorted names of the properties defined by the schemas
+// in set.
+func (set schemaSet) names() []string {
+	var names []string
+	for _, s := range set.of(typeObject) {
+		for name := range s.Properties {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// CheckSchema analyzes q against schema, which describes the values q
+// queries, and returns a [Diagnostic] for each problem it finds. It reports
+// the following with [SeverityWarning]:
+//
+//   - [CodeSchemaMismatch]: selectors that can never select a value, such as
+//     names not defined by the schema, like $.user.emial, and index
+//     selectors applied to values the schema allows only to be objects,
+//     together with a hint naming similar member names
+//   - [CodeTypeMismatch]: comparisons of a query with a literal of a type
+//     the schema never allows the query's value to have, such as
+//     @.age == "42" where the schema allows age to be only a number, which
+//     are always false, or always true for !=
+//
+// It analyzes the queries in filter expressions, too, and reports nothing
+// after the first selector of a query that can never select a value.
+// Returns nil if it finds no problems.
+func (q *PathQuery) CheckSchema(schema *Schema) []Diagnostic {
+	c := &schemaChecker{root: schemaSet{}.add(schema)}
+	c.query(q, c.root)
+	return c.diags
+}
+
+// MemberNames returns the sorted names of the object members that schema
+// defines for the values q selects, such as the names that may follow
+// $.user. in a query, for use by tools that complete member names. Returns
+// nil if q can never select an object with defined members.
+func (q *PathQuery) MemberNames(schema *Schema) []string {
+	c := &schemaChecker{root: schemaSet{}.add(schema)}
+	return c.query(q, c.root).names()
+}
+
+// schemaChecker collects the diagnostics for [PathQuery.CheckSchema].
+type schemaChecker struct {
+	root  schemaSet
+	diags []Diagnostic
+}
+
+// add appends a warning diagnostic with code, hint, and a message formatted
+// from format and args.
+func (c *schemaChecker) add(code, hint, format string, args ...any) {
+	c.diags = append(c.diags, Diagnostic{
+		Severity: SeverityWarning,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Hint:     hint,
+	})
+}
+
+// query checks q against current, the schemas of the current node, and
+// returns the schemas of the nodes q selects. Returns nil if q can never
+// select a node.
+func (c *schemaChecker) query(q *PathQuery, current schemaSet) schemaSet {
+	set := current
+	if q.root {
+		set = c.root
+	}
+	for i, seg := range q.segments {
+		if seg.descendant {
+			set = set.descendants()
+		}
+		var next schemaSet
+		for _, sel := range seg.selectors {
+			for _, s := range c.selector(q, i+1, sel, set) {
+				next = next.add(s)
+			}
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		set = next
+	}
+	return set
+}
+
+// selector checks sel, a selector in segment number seg of q, against set,
+// the schemas of the values to which it applies, and returns the schemas of
+// the values it selects.
+func (c *schemaChecker) selector(q *PathQuery, seg int, sel Selector, set schemaSet) schemaSet {
+	var res schemaSet
+	var reason, hint string
+	switch sel := sel.(type) {
+	case Name:
+		res = set.member(string(sel))
+		if len(res) == 0 {
+			if len(set.of(typeObject)) == 0 {
+				reason = "the value is never an object"
+			} else {
+				reason = fmt.Sprintf("the schema defines no member %v", sel)
+				if similar := similarNames(string(sel), set.names()); len(similar) > 0 {
+					hint = "did you mean " + strings.Join(similar, " or ") + "?"
+				}
+			}
+		}
+	case Index:
+		res = set.element(int(sel))
+		if len(res) == 0 {
+			if len(set.of(typeArray)) == 0 {
+				reason = "the value is never an array"
+			} else {
+				reason = "the schema allows no element at that index"
+			}
+		}
+	case SliceSelector:
+		if selectsNothing(sel) {
+			// Lint reports slices that never select a value.
+			return nil
+		}
+		res = set.element(-1)
+		if len(res) == 0 {
+			reason = "the value is never an array with elements"
+			if len(set.of(typeArray)) == 0 {
+				reason = "the value is never an array"
+			}
+		}
+	case WildcardSelector:
+		res = set.children()
+		if len(res) == 0 {
+			reason = "the value is never an object or array with members or elements"
+		}
+	case *FilterSelector:
+		res = set.children()
+		if len(res) == 0 {
+			reason = "the value is never an object or array with members or elements"
+			break
+		}
+		c.or(sel.LogicalOr, res)
+	}
+
+	if reason != "" {
+		c.add(
+			CodeSchemaMismatch, hint,
+			"selector %v in segment %v of %v never selects a value because %v",
+			sel, seg, q, reason,
+		)
+	}
+	return res
+}
+
+// or checks the expressions in lo against current, the schemas of the
+// values to which the filter applies.
+func (c *schemaChecker) or(lo LogicalOr, current schemaSet) {
+	for _, and := range lo {
+		for _, expr := range and {
+			c.expr(expr, current)
+		}
+	}
+}
+
+// expr checks expr against current.
+func (c *schemaChecker) expr(expr BasicExpr, current schemaSet) {
+	switch e := expr.(type) {
+	case *ParenExpr:
+		c.or(e.LogicalOr, current)
+	case *NotParenExpr:
+		c.or(e.LogicalOr, current)
+	case *ExistExpr:
+		c.query(e.PathQuery, current)
+	case *NonExistExpr:
+		c.query(e.PathQuery, current)
+	case NonExistExpr:
+		c.query(e.PathQuery, current)
+	case *CompExpr:
+		c.comparison(e, current)
+	case *FuncExpr:
+		c.value(e, current)
+	case NotFuncExpr:
+		c.value(e.FuncExpr, current)
+	case LogicalOr:
+		c.or(e, current)
+	case LogicalAnd:
+		c.or(LogicalOr{e}, current)
+	}
+}
+
+// value checks val, a comparison operand or function argument, against
+// current and returns the JSON types its value may have.
+func (c *schemaChecker) value(val any, current schemaSet) jsonTypes {
+	switch v := val.(type) {
+	case *LiteralArg:
+		return valueType(v.literal)
+	case *SingularQueryExpr:
+		q := &PathQuery{root: !v.relative, segments: make([]*Segment, len(v.selectors))}
+		for i, sel := range v.selectors {
+			q.segments[i] = Child(sel)
+		}
+		if set := c.query(q, current); len(set) > 0 {
+			return set.types()
+		}
+	case *PathQuery:
+		c.query(v, current)
+	case *FuncExpr:
+		for _, arg := range v.args {
+			c.value(arg, current)
+		}
+		if name := v.fn.Name(); name == "length" || name == "count" {
+			return typeNumber
+		}
+	case BasicExpr:
+		c.expr(v, current)
+	}
+	return typeAny
+}
+
+// comparison checks ce against current, reporting comparisons of a value
+// with a literal of a type the schema never allows the value to have.
+func (c *schemaChecker) comparison(ce *CompExpr, current schemaSet) {
+	left, right := c.value(ce.left, current), c.value(ce.right, current)
+
+	val, lit := ce.left, right
+	if _, ok := ce.left.(*LiteralArg); ok {
+		val, lit, left = ce.right, left, right
+	} else if _, ok := ce.right.(*LiteralArg); !ok {
+		return
+	}
+	if _, ok := val.(*LiteralArg); ok || left == typeAny || lit == typeAny || left&lit != 0 {
+		return
+	}
+
+	result := "false"
+	if ce.op == NotEqualTo {
+		result = "true"
+	}
+	c.add(
+		CodeTypeMismatch, "",
+		"comparison %v is always %v because %v is never %v",
+		ce, result, val, lit.article(),
+	)
+}
+
+// similarNames returns the names in names within an edit distance of two
+// of name, or one for names of up to four bytes.
+func similarNames(name string, names []string) []string {
+	maxDist := 2
+	if len(name) <= 4 {
+		maxDist = 1
+	}
+	var similar []string
+	for _, n := range names {
+		if editDistance(name, n) <= maxDist {
+			similar = append(similar, fmt.Sprintf("%q", n))
+		}
+	}
+	return similar
+}
+
+// editDistance returns the Levenshtein distance between a and b, counting
+// runes.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
@@ -0,0 +1,418 @@
+package spec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+	"type": "object",
+	"additionalProperties": false,
+	"properties": {
+		"user": {"$ref": "#/$defs/user"},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"point": {"type": "array", "prefixItems": [{"type": "number"}, {"type": "number"}], "items": false},
+		"meta": {"patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": false},
+		"status": {"enum": ["active", "inactive"]},
+		"id": {"anyOf": [{"type": "string"}, {"type": "integer"}]},
+		"extra": {}
+	},
+	"$defs": {
+		"user": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"email": {"type": "string"},
+				"age": {"type": "integer"},
+				"friends": {"type": "array", "items": {"$ref": "#/$defs/user"}}
+			}
+		}
+	}
+}`
+
+func TestParseSchema(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	s, err := ParseSchema([]byte(testSchema))
+	require.NoError(t, err)
+	a.Equal([]string{"object"}, s.Types)
+	a.True(s.AdditionalProperties.False)
+
+	user := s.Properties["user"]
+	a.Equal([]string{"object"}, user.Types)
+	a.Same(user, user.Properties["friends"].Items)
+	a.Equal([]string{"string"}, s.Properties["status"].Types)
+	a.Len(s.Properties["id"].AnyOf, 2)
+	a.Len(s.Properties["point"].PrefixItems, 2)
+	a.True(s.Properties["point"].Items.False)
+	a.Contains(s.Properties["meta"].PatternProperties, "^x-")
+	a.Equal(&Schema{}, s.Properties["extra"])
+
+	// Should support earlier drafts and other references.
+	s, err = ParseSchema([]byte(`{
+		"type": ["array", "null"],
+		"items": [{"const": 1}, {"$ref": "https://example.com/schema"}],
+		"additionalItems": true
+	}`))
+	require.NoError(t, err)
+	a.Equal(&Schema{
+		Types:       []string{"array", "null"},
+		PrefixItems: []*Schema{{Types: []string{"number"}}, anySchema},
+		Items:       &Schema{},
+	}, s)
+
+	// Should unmarshal.
+	var schema Schema
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "string"}`), &schema))
+	a.Equal(Schema{Types: []string{"string"}}, schema)
+	require.EqualError(t, json.Unmarshal([]byte(`{"items": 1}`), &schema), "jsonpath: invalid schema: 1 is not a schema")
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test   string
+		schema string
+		err    string
+	}{
+		{"invalid_json", `{`, "jsonpath: invalid schema: unexpected end of JSON input"},
+		{"not_schema", `[]`, "jsonpath: invalid schema: [] is not a schema"},
+		{"bad_property", `{"properties": {"a": 1}}`, "jsonpath: invalid schema: 1 is not a schema"},
+		{"bad_pattern", `{"patternProperties": {"a": "x"}}`, `jsonpath: invalid schema: "x" is not a schema`},
+		{"bad_additional", `{"additionalProperties": 1}`, "jsonpath: invalid schema: 1 is not a schema"},
+		{"bad_prefix", `{"prefixItems": [1]}`, "jsonpath: invalid schema: 1 is not a schema"},
+		{"bad_items_array", `{"items": [null]}`, "jsonpath: invalid schema: null is not a schema"},
+		{"bad_any_of", `{"anyOf": [1]}`, "jsonpath: invalid schema: 1 is not a schema"},
+		{"bad_ref", `{"$ref": "#/$defs/nope"}`, `jsonpath: invalid schema: cannot resolve $ref "#/$defs/nope"`},
+		{"bad_ref_pointer", `{"$ref": "#nope"}`, `jsonpath: invalid schema: cannot resolve $ref "#nope"`},
+		{"bad_ref_target", `{"$ref": "#/a", "a": 1}`, "jsonpath: invalid schema: 1 is not a schema"},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			s, err := ParseSchema([]byte(tc.schema))
+			require.EqualError(t, err, tc.err)
+			require.ErrorIs(t, err, ErrSchema)
+			assert.Nil(t, s)
+		})
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := ParseSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	type diag struct {
+		code string
+		msg  string
+		hint string
+	}
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		exp   []diag
+	}{
+		{
+			test:  "valid",
+			query: Query(true, Child(Name("user")), Child(Name("friends")), Child(Index(0)), Child(Name("email"))),
+		},
+		{
+			test:  "typo",
+			query: Query(true, Child(Name("user")), Child(Name("emial"))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector "emial" in segment 2 of $["user"]["emial"] never selects a value because the schema defines no member "emial"`,
+				`did you mean "email"?`,
+			}},
+		},
+		{
+			test:  "unknown_no_hint",
+			query: Query(true, Child(Name("nope")), Child(Name("more"))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector "nope" in segment 1 of $["nope"]["more"] never selects a value because the schema defines no member "nope"`,
+				"",
+			}},
+		},
+		{
+			test:  "index_on_object",
+			query: Query(true, Child(Name("user")), Child(Index(0))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector 0 in segment 2 of $["user"][0] never selects a value because the value is never an array`,
+				"",
+			}},
+		},
+		{
+			test:  "name_on_array",
+			query: Query(true, Child(Name("tags")), Child(Name("x"))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector "x" in segment 2 of $["tags"]["x"] never selects a value because the value is never an object`,
+				"",
+			}},
+		},
+		{
+			test:  "index_past_prefix",
+			query: Query(true, Child(Name("point")), Child(Index(1), Index(2))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector 2 in segment 2 of $["point"][1,2] never selects a value because the schema allows no element at that index`,
+				"",
+			}},
+		},
+		{
+			test:  "slice_on_object",
+			query: Query(true, Child(Name("user")), Child(Slice(1, 3))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector 1:3 in segment 2 of $["user"][1:3] never selects a value because the value is never an array`,
+				"",
+			}},
+		},
+		{
+			test:  "slice_without_elements",
+			query: Query(true, Child(Name("tags")), Child(Wildcard()), Child(Slice(1, 3))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector 1:3 in segment 3 of $["tags"][*][1:3] never selects a value because the value is never an array`,
+				"",
+			}},
+		},
+		{
+			test:  "empty_slice",
+			query: Query(true, Child(Name("tags")), Child(Slice(1, 1))),
+		},
+		{
+			test:  "wildcard_on_string",
+			query: Query(true, Child(Name("status")), Child(Wildcard())),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector * in segment 2 of $["status"][*] never selects a value because the value is never an object or array with members or elements`,
+				"",
+			}},
+		},
+		{
+			test:  "filter_on_string",
+			query: Query(true, Child(Name("status")), Child(Filter(And(Existence(Query(false, Child(Name("x")))))))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector ?@["x"] in segment 2 of $["status"][?@["x"]] never selects a value because the value is never an object or array with members or elements`,
+				"",
+			}},
+		},
+		{
+			test:  "pattern_properties",
+			query: Query(true, Child(Name("meta")), Child(Name("x-id"), Name("id"))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector "id" in segment 2 of $["meta"]["x-id","id"] never selects a value because the schema defines no member "id"`,
+				"",
+			}},
+		},
+		{
+			test:  "any_of",
+			query: Query(true, Child(Name("id")), Child(Name("x"))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector "x" in segment 2 of $["id"]["x"] never selects a value because the value is never an object`,
+				"",
+			}},
+		},
+		{
+			test:  "unconstrained",
+			query: Query(true, Child(Name("extra")), Child(Name("x")), Descendant(Index(3))),
+		},
+		{
+			test:  "descendant",
+			query: Query(true, Child(Name("user")), Descendant(Name("email")), Descendant(Name("age"))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector "age" in segment 3 of $["user"]..["email"]..["age"] never selects a value because the value is never an object`,
+				"",
+			}},
+		},
+		{
+			test:  "recursive_descendant",
+			query: Query(true, Child(Name("user")), Descendant(Name("emails"))),
+			exp: []diag{{
+				CodeSchemaMismatch,
+				`selector "emails" in segment 2 of $["user"]..["emails"] never selects a value because the schema defines no member "emails"`,
+				`did you mean "email"?`,
+			}},
+		},
+		{
+			test: "filter",
+			query: Query(true, Child(Name("user")), Child(Name("friends")), Child(Filter(
+				And(
+					Comparison(SingularQuery(false, Name("age")), EqualTo, Literal("42")),
+					Comparison(Literal(int64(1)), NotEqualTo, SingularQuery(true, Name("tags"), Index(0))),
+				),
+				And(
+					Existence(Query(false, Child(Name("emial")))),
+					Comparison(SingularQuery(false, Name("age")), LessThan, Literal(int64(42))),
+					Comparison(SingularQuery(false, Name("email")), EqualTo, SingularQuery(false, Name("age"))),
+					Comparison(Literal(int64(1)), EqualTo, Literal("1")),
+					Comparison(Function(newFuncExt("length", FuncValue), SingularQuery(false, Name("email"))), GreaterThan, Literal(true)),
+				),
+			))),
+			exp: []diag{
+				{
+					CodeTypeMismatch,
+					`comparison @["age"] == "42" is always false because @["age"] is never a string`,
+					"",
+				},
+				{
+					CodeTypeMismatch,
+					`comparison 1 != $["tags"][0] is always true because $["tags"][0] is never a number`,
+					"",
+				},
+				{
+					CodeSchemaMismatch,
+					`selector "emial" in segment 1 of @["emial"] never selects a value because the schema defines no member "emial"`,
+					`did you mean "email"?`,
+				},
+				{
+					CodeTypeMismatch,
+					`comparison length(@["email"]) > true is always false because length(@["email"]) is never a boolean`,
+					"",
+				},
+			},
+		},
+		{
+			test: "filter_functions",
+			query: Query(true, Child(Name("tags")), Child(Filter(And(
+				NotFuncExpr{Function(newFuncExt("match", FuncLogical), SingularQuery(false), Literal("x"))},
+				Function(newFuncExt("count", FuncValue), Query(false, Child(Name("x")))),
+				Paren(And(NonExistExpr{Query(true, Child(Name("tag")))})),
+				NotParen(And(Comparison(SingularQuery(false), EqualTo, Literal(nil)))),
+			)))),
+			exp: []diag{
+				{
+					CodeSchemaMismatch,
+					`selector "x" in segment 1 of @["x"] never selects a value because the value is never an object`,
+					"",
+				},
+				{
+					CodeSchemaMismatch,
+					`selector "tag" in segment 1 of $["tag"] never selects a value because the schema defines no member "tag"`,
+					`did you mean "tags"?`,
+				},
+				{
+					CodeTypeMismatch,
+					`comparison @ == null is always false because @ is never null`,
+					"",
+				},
+			},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			diags := tc.query.CheckSchema(schema)
+			if tc.exp == nil {
+				assert.Empty(t, diags)
+				return
+			}
+			got := make([]diag, len(diags))
+			for i, d := range diags {
+				assert.Equal(t, SeverityWarning, d.Severity)
+				got[i] = diag{d.Code, d.Message, d.Hint}
+			}
+			assert.Equal(t, tc.exp, got)
+		})
+	}
+}
+
+// newFuncExt returns a function extension named name that returns
+// resultType, for use in expressions that are checked but not evaluated.
+func newFuncExt(name string, resultType FuncType) *FuncExtension {
+	return Extension(name, resultType, nil, nil)
+}
+
+func TestCheckSchemaTypes(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Should accept a lightweight descriptor and unknown types.
+	schema := &Schema{Types: []string{"object"}, Properties: map[string]*Schema{
+		"n":     {Types: []string{"integer", "null"}},
+		"odd":   {Types: []string{"decimal"}},
+		"never": {False: true},
+	}}
+	q := Query(true, Child(Filter(And(
+		Comparison(SingularQuery(false, Name("n")), EqualTo, Literal(1.5)),
+		Comparison(SingularQuery(false, Name("n")), EqualTo, Literal(nil)),
+		Comparison(SingularQuery(false, Name("odd")), EqualTo, Literal("x")),
+	))))
+	a.Empty(q.CheckSchema(schema))
+	a.Empty(Query(true, Child(Name("n"))).CheckSchema(nil))
+
+	diags := Query(true, Child(Name("never"))).CheckSchema(schema)
+	require.Len(t, diags, 1)
+	a.Equal(
+		`selector "never" in segment 1 of $["never"] never selects a value because the schema defines no member "never"`,
+		diags[0].Message,
+	)
+
+	a.Equal("object or array", (typeObject | typeArray).String())
+	a.Equal("an object", typeObject.article())
+	a.Equal("a number", typeNumber.article())
+	a.Equal("null", typeNull.article())
+	a.Equal(typeAny, valueType(struct{}{}))
+	a.Equal(typeNumber, valueType(json.Number("1")))
+}
+
+func TestMemberNames(t *testing.T) {
+	t.Parallel()
+	schema, err := ParseSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		test string
+		q    *PathQuery
+		exp  []string
+	}{
+		{"root", Query(true), []string{"extra", "id", "meta", "point", "status", "tags", "user"}},
+		{"member", Query(true, Child(Name("user"))), []string{"age", "email", "friends"}},
+		{"ref", Query(true, Child(Name("user")), Child(Name("friends")), Child(Index(0))), []string{"age", "email", "friends"}},
+		{"union", Query(true, Child(Name("user"), Name("tags"))), []string{"age", "email", "friends"}},
+		{"descendants", Query(true, Descendant(Wildcard())), []string{"age", "email", "friends"}},
+		{"patterns", Query(true, Child(Name("meta"))), nil},
+		{"scalar", Query(true, Child(Name("tags")), Child(Index(0))), nil},
+		{"unknown", Query(true, Child(Name("nope"))), nil},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, tc.q.MemberNames(schema))
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		a, b string
+		exp  int
+	}{
+		{"", "", 0},
+		{"a", "", 1},
+		{"", "abc", 3},
+		{"email", "emial", 2},
+		{"email", "emails", 1},
+		{"kitten", "sitting", 3},
+		{"über", "uber", 1},
+	} {
+		assert.Equal(t, tc.exp, editDistance(tc.a, tc.b), "%q vs %q", tc.a, tc.b)
+	}
+	assert.Equal(t, []string{`"tags"`, `"bag"`}, similarNames("tag", []string{"tags", "age", "bag", "tagline"}))
+	assert.Equal(t, []string{`"email"`}, similarNames("emial", []string{"email", "mail", "age"}))
+	assert.Empty(t, similarNames("x", nil))
+}
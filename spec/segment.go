@@ -1,6 +1,9 @@
 package spec
 
 import (
+	"encoding/json"
+	"fmt"
+	"iter"
 	"reflect"
 	"slices"
 	"strings"
@@ -13,18 +16,101 @@ import (
 type Segment struct {
 	selectors  []Selector
 	descendant bool
+	names      *nameSet
+	branches   []*Segment
 }
 
 // Child creates and returns a [Segment] that uses sel to select values from a
 // JSON object or array.
 func Child(sel ...Selector) *Segment {
-	return &Segment{selectors: sel}
+	return &Segment{selectors: sel, names: newNameSet(sel)}
 }
 
 // Descendant creates and returns a [Segment] that uses sel to select values
 // from a JSON object or array or any of its descendant objects and arrays.
 func Descendant(sel ...Selector) *Segment {
-	return &Segment{selectors: sel, descendant: true}
+	return &Segment{selectors: sel, descendant: true, names: newNameSet(sel)}
+}
+
+// nameSetSize is the minimum number of selectors in a segment consisting
+// only of [Name] selectors for which the segment creates a [nameSet].
+const nameSetSize = 4
+
+// nameSet indexes the names of a segment that consists only of [Name]
+// selectors, so that it may select all of them from an [Object] in a single
+// pass over its members, rather than a scan for each name.
+type nameSet struct {
+	// slots maps each distinct name to its slot.
+	slots map[string]int
+	// order lists the slot of each selector, in selector order.
+	order []int
+}
+
+// newNameSet creates a nameSet for sels. Returns nil if sels contains fewer
+// than [nameSetSize] selectors or any selector other than a [Name].
+func newNameSet(sels []Selector) *nameSet {
+	if len(sels) < nameSetSize {
+		return nil
+	}
+	set := &nameSet{slots: make(map[string]int, len(sels)), order: make([]int, len(sels))}
+	for i, sel := range sels {
+		name, ok := sel.(Name)
+		if !ok {
+			return nil
+		}
+		slot, ok := set.slots[string(name)]
+		if !ok {
+			slot = len(set.slots)
+			set.slots[string(name)] = slot
+		}
+		set.order[i] = slot
+	}
+	return set
+}
+
+// scan returns the values of the members of node named in set, indexed by
+// slot, and whether node has such a member. Retains the first member for any
+// name that appears more than once. Returns nil unless node implements
+// [Object]; maps and structs already look up each name by hash.
+func (set *nameSet) scan(node any) []scanned {
+	obj, ok := node.(Object)
+	if !ok {
+		return nil
+	}
+
+	vals := make([]scanned, len(set.slots))
+	found := 0
+	for name, val := range obj.Iterate() {
+		if slot, ok := set.slots[name]; ok && !vals[slot].ok {
+			vals[slot] = scanned{val, true}
+			if found++; found == len(vals) {
+				break
+			}
+		}
+	}
+	return vals
+}
+
+// appendFrom appends the values of the members of node named in set to dst,
+// in selector order, and returns the result and true. Returns dst and false
+// if node does not implement [Object].
+func (set *nameSet) appendFrom(dst []any, node any) ([]any, bool) {
+	vals := set.scan(node)
+	if vals == nil {
+		return dst, false
+	}
+	for _, slot := range set.order {
+		if vals[slot].ok {
+			dst = append(dst, vals[slot].val)
+		}
+	}
+	return dst, true
+}
+
+// scanned records a value found by [nameSet.scan].
+type scanned struct {
+	val any
+	ok  bool
 }
 
 // Selectors returns s's [Selector] values.
@@ -59,136 +145,501 @@ func (s *Segment) String() string {
 // Select selects and returns values from current or root, for each of s's
 // selectors. Defined by the [Selector] interface.
 func (s *Segment) Select(current, root any) []any {
-	ret := make([]any, 0, len(s.selectors))
-	for _, sel := range s.selectors {
-		ret = append(ret, sel.Select(current, root)...)
-	}
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	return s.selectFrom(current, ev)
+}
+
+// selectFrom selects and returns values from current or ev.root, for each of
+// s's selectors.
+func (s *Segment) selectFrom(current any, ev *evaluation) []any {
+	return slices.Clip(s.appendFrom(make([]any, 0, len(s.selectors)), current, ev))
+}
+
+// appendFrom appends the values selected from current or ev.root by each of
+// s's selectors to dst and returns the result. Descendant segments append
+// the values selected from current's descendants to the same slice, rather
+// than allocating a slice for each node.
+func (s *Segment) appendFrom(dst []any, current any, ev *evaluation) []any {
 	if s.descendant {
-		ret = append(ret, s.descend(current, root)...)
+		return descend(dst, current, ev, s)
 	}
-	return slices.Clip(ret)
+	ev.visit(0)
+	return s.appendSelected(ev, current, dst)
+}
+
+// step appends the values selected from node by each of s's selectors to
+// dst and returns the result. Defined by [stepper].
+func (s *Segment) step(ev *evaluation, node any, dst []any) []any {
+	return s.appendSelected(ev, node, dst)
+}
+
+// appendSelected appends the values selected from node by each of s's
+// selectors to dst and returns the result.
+func (s *Segment) appendSelected(ev *evaluation, node any, dst []any) []any {
+	if s.names != nil {
+		if dst, ok := s.names.appendFrom(dst, node); ok {
+			return dst
+		}
+	}
+	for _, sel := range s.selectors {
+		dst = appendWith(dst, sel, node, ev)
+	}
+	return dst
 }
 
 // SelectLocated selects and returns values as [LocatedNode] values from
 // current or root for each of seg's selectors. Defined by the [Selector]
 // interface.
 func (s *Segment) SelectLocated(current, root any, parent NormalizedPath) []*LocatedNode {
-	ret := make([]*LocatedNode, 0, len(s.selectors))
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	return s.selectLocatedFrom(current, ev, parent)
+}
+
+// selectLocatedFrom selects and returns values as [LocatedNode] values from
+// current or ev.root for each of seg's selectors.
+func (s *Segment) selectLocatedFrom(current any, ev *evaluation, parent NormalizedPath) []*LocatedNode {
+	return slices.Clip(s.appendLocatedFrom(
+		make([]*LocatedNode, 0, len(s.selectors)), current, ev, parent,
+	))
+}
+
+// appendLocatedFrom appends the [LocatedNode] values selected from current
+// or ev.root by each of s's selectors to dst and returns the result.
+// Descendant segments append the values selected from current's descendants
+// to the same slice, rather than allocating a slice for each node.
+func (s *Segment) appendLocatedFrom(dst []*LocatedNode, current any, ev *evaluation, parent NormalizedPath) []*LocatedNode {
+	if s.descendant {
+		return s.descendLocated(dst, current, ev, parent)
+	}
+	ev.visit(0)
+	return s.appendLocatedSelected(dst, current, ev, parent)
+}
+
+// appendLocatedSelected appends the [LocatedNode] values selected from node
+// by each of s's selectors to dst and returns the result.
+func (s *Segment) appendLocatedSelected(dst []*LocatedNode, node any, ev *evaluation, parent NormalizedPath) []*LocatedNode {
+	if s.names != nil {
+		if vals := s.names.scan(node); vals != nil {
+			for i, slot := range s.names.order {
+				if vals[slot].ok {
+					dst = append(dst, newLocatedNode(
+						append(parent, s.selectors[i].(Name)), vals[slot].val,
+					))
+				}
+			}
+			return dst
+		}
+	}
 	for _, sel := range s.selectors {
-		ret = append(ret, sel.SelectLocated(current, root, parent)...)
+		dst = append(dst, selectLocatedWith(sel, node, ev, parent)...)
 	}
-	if s.descendant {
-		ret = append(ret, s.descendLocated(current, root, parent)...)
+	return dst
+}
+
+// appendWith appends the values that sel selects from current to dst and
+// returns the result. Selects directly for [Name], [Index], and
+// [WildcardSelector] values, and passes ev to [FilterSelector] values, the
+// only selectors that evaluate expressions.
+func appendWith(dst []any, sel Selector, current any, ev *evaluation) []any {
+	switch sel := sel.(type) {
+	case Name:
+		if v, ok := lookupName(current, string(sel)); ok {
+			dst = append(dst, v)
+		}
+		return dst
+	case Index:
+		if v, ok := lookupIndex(current, int(sel)); ok {
+			dst = append(dst, v)
+		}
+		return dst
+	case WildcardSelector:
+		return selectAll(ev, current, dst)
+	case SliceSelector:
+		return sel.appendFrom(dst, current)
+	case *FilterSelector:
+		return append(dst, sel.selectFrom(current, ev)...)
+	default:
+		return append(dst, sel.Select(current, ev.root)...)
+	}
+}
+
+// selectLocatedWith selects [LocatedNode] values from current with sel.
+// Passes ev to [FilterSelector] values, the only selectors that evaluate
+// expressions, and sorts the members of objects for [WildcardSelector]
+// values if ev's options enable SortMembers.
+func selectLocatedWith(sel Selector, current any, ev *evaluation, parent NormalizedPath) []*LocatedNode {
+	switch sel := sel.(type) {
+	case *FilterSelector:
+		return sel.selectLocatedFrom(current, ev, parent)
+	case WildcardSelector:
+		return sel.SelectLocated(ev.sorted(current), ev.root, parent)
+	default:
+		return sel.SelectLocated(current, ev.root, parent)
+	}
+}
+
+// descent is a value awaiting a visit by [descend], along with its depth
+// below the value at which the descent started.
+type descent struct {
+	node  any
+	depth int
+}
+
+// cycleCheckDepth is the depth below which descendant segments track the
+// values on the path to each value they visit, to detect values that
+// contain themselves. Checking only deep paths, as [encoding/json] does,
+// keeps cycle detection off the common path.
+const cycleCheckDepth = 1000
+
+// cycleKey identifies a pointer, map, or slice by its type, its address,
+// and, for slices, its length.
+type cycleKey struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// cycles tracks the pointers, maps, and slices on the path to the value a
+// descendant segment visits, once the path is deeper than cycleCheckDepth.
+// The zero value is ready to use.
+type cycles struct {
+	path []cycleKey
+	seen map[cycleKey]struct{}
+}
+
+// check records node as the value at depth on the current path, forgetting
+// the values at depth or deeper recorded for previous paths. Panics with an
+// [evalAbort] if node is already on the path, as a value with a pointer to
+// its parent would be. A descent that never reaches cycleCheckDepth never
+// records anything.
+func (c *cycles) check(node any, depth int) {
+	if depth < cycleCheckDepth {
+		return
+	}
+	i := depth - cycleCheckDepth
+	for len(c.path) > i {
+		delete(c.seen, c.path[len(c.path)-1])
+		c.path = c.path[:len(c.path)-1]
+	}
+	for len(c.path) < i {
+		// A descent that starts below cycleCheckDepth, as a parallel one
+		// may, knows nothing of the path above it.
+		c.path = append(c.path, cycleKey{})
+	}
+	key, ok := cycleKeyOf(node)
+	if !ok {
+		// Record a placeholder to keep the path aligned with depth.
+		c.path = append(c.path, cycleKey{})
+		return
+	}
+	if _, dup := c.seen[key]; dup {
+		panic(evalAbort{fmt.Errorf(
+			"%w: %T contains itself", ErrCycle, node,
+		)})
+	}
+	if c.seen == nil {
+		c.seen = map[cycleKey]struct{}{}
 	}
-	return slices.Clip(ret)
+	c.seen[key] = struct{}{}
+	c.path = append(c.path, key)
+}
+
+// cycleKeyOf returns the key that identifies node and true if node is a
+// non-nil pointer, map, or slice, and false otherwise.
+func cycleKeyOf(node any) (cycleKey, bool) {
+	v := reflect.ValueOf(node)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map:
+		if !v.IsNil() {
+			return cycleKey{typ: v.Type(), ptr: uintptr(v.UnsafePointer())}, true
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			return cycleKey{typ: v.Type(), ptr: uintptr(v.UnsafePointer()), len: v.Len()}, true
+		}
+	default:
+	}
+	return cycleKey{}, false
+}
+
+// descend applies step to current and each of its descendants in document
+// order, appending the results to dst, and returns the result. Uses an
+// explicit stack rather than recursion, so that deeply nested values cannot
+// exhaust the goroutine stack, and visits no values more than
+// ev.opts.MaxDepth levels below current if MaxDepth is greater than zero.
+func descend(dst []any, current any, ev *evaluation, step stepper) []any {
+	return descendFrom(dst, current, 0, ev, step)
 }
 
-// descend recursively executes [Segment.Select] for each value in current
-// and/or root and its descendants and returns the results.
-func (s *Segment) descend(current, root any) []any {
-	switch val := current.(type) {
+// descendUntil applies s's selectors to current and each of its
+// descendants, and passes each selected value to found until found returns
+// true. Returns true if found returns true and false if it never does. Like
+// [descend], it honors ev.opts.MaxDepth, but never descends in parallel.
+func (s *Segment) descendUntil(current any, ev *evaluation, found func(any) bool) bool {
+	// Borrow ev's stack, if any; found may descend again.
+	stack := append(ev.stack, descent{node: current})
+	ev.stack = nil
+	var buf []any
+	var seen cycles
+	for len(stack) > 0 {
+		d := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		ev.visit(d.depth)
+		seen.check(d.node, d.depth)
+		buf = s.appendSelected(ev, d.node, buf[:0])
+		for _, v := range buf {
+			if found(v) {
+				ev.stack = stack[:0]
+				return true
+			}
+		}
+		if ev.opts.MaxDepth <= 0 || d.depth < ev.opts.MaxDepth {
+			stack = pushChildren(stack, ev.sorted(d.node), d.depth+1)
+		} else {
+			ev.truncate(d.node, d.depth)
+		}
+	}
+	ev.stack = stack
+	return false
+}
+
+// descendFrom implements [descend] for current at depth.
+func descendFrom(dst []any, current any, depth int, ev *evaluation, step stepper) []any {
+	// Borrow ev's stack, if any; step may descend again from a filter.
+	stack := append(ev.stack, descent{current, depth})
+	ev.stack = nil
+	var seen cycles
+	for len(stack) > 0 {
+		d := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		ev.visit(d.depth)
+		seen.check(d.node, d.depth)
+		dst = step.step(ev, d.node, dst)
+		if ev.opts.MaxDepth > 0 && d.depth >= ev.opts.MaxDepth {
+			ev.truncate(d.node, d.depth)
+			continue
+		}
+		if elems, ok := ev.parallelElements(d.node); ok {
+			// The elements would pop off the stack next, so append their
+			// results now.
+			for _, res := range parallelize(ev, len(elems), func(wev *evaluation, lo, hi int) []any {
+				var res []any
+				for _, v := range elems[lo:hi] {
+					res = descendFrom(res, v, d.depth+1, wev, step)
+				}
+				return res
+			}) {
+				dst = append(dst, res...)
+			}
+			continue
+		}
+		stack = pushChildren(stack, ev.sorted(d.node), d.depth+1)
+	}
+	ev.stack = stack
+	return dst
+}
+
+// pushChildren pushes the children of node at depth onto stack in reverse
+// order, so that they pop off in document order, and returns the result.
+// Skips scalars and containers that cannot contain arrays or objects.
+func pushChildren(stack []descent, node any, depth int) []descent {
+	switch val := node.(type) {
 	case []any:
-		ret := make([]any, 0, len(val))
-		for _, v := range val {
-			ret = append(ret, s.Select(v, root)...)
+		for i := len(val) - 1; i >= 0; i-- {
+			if !isScalar(val[i]) {
+				stack = append(stack, descent{val[i], depth})
+			}
 		}
-		return slices.Clip(ret)
 	case map[string]any:
-		ret := make([]any, 0, len(val))
+		// Map order is undefined, so no need to reverse.
 		for _, v := range val {
-			ret = append(ret, s.Select(v, root)...)
+			if !isScalar(v) {
+				stack = append(stack, descent{v, depth})
+			}
 		}
-		return slices.Clip(ret)
 	default:
-		value := reflect.ValueOf(current)
-		switch value.Kind() {
-		case reflect.Slice:
-			// Descend into any other slice that contains slices or maps.
-			switch value.Type().Elem().Kind() {
-			case reflect.Slice, reflect.Map:
-				ret := make([]any, 0, value.Len())
-				for i := range value.Len() {
-					ret = append(ret, s.Select(value.Index(i).Interface(), root)...)
-				}
-				return slices.Clip(ret)
-			default:
-				return make([]any, 0)
-			}
-		case reflect.Map:
-			// Descend into any map[string]* that contains slices or maps.
-			if value.Type().Key().Kind() != reflect.String {
-				return make([]any, 0)
+		// Descend into any other array or object that may contain arrays or
+		// objects.
+		if arr, ok := AsArray(node); ok {
+			if !leavesOnly(arr) {
+				stack = pushSeq(stack, arr.Iterate(), depth)
 			}
-			switch value.Type().Elem().Kind() {
-			case reflect.Slice, reflect.Map:
-				ret := make([]any, 0, value.Len())
-				for _, k := range value.MapKeys() {
-					ret = append(ret, s.Select(value.MapIndex(k).Interface(), root)...)
+		} else if obj, ok := AsObject(node); ok && !leavesOnly(obj) {
+			stack = pushSeq(stack, obj.Iterate(), depth)
+		}
+	}
+	return stack
+}
+
+// isScalar returns true if val is a common JSON scalar type. Segments select
+// nothing from scalars, so [descend] need not visit them.
+func isScalar(val any) bool {
+	switch val.(type) {
+	case nil, string, bool, float64, int, int64, json.Number:
+		return true
+	default:
+		return false
+	}
+}
+
+// pushSeq pushes the values in seq at depth onto stack in reverse order and
+// returns the result. Keeps the iterator's closure, which captures stack,
+// out of [pushChildren], so that stack escapes to the heap only for custom
+// containers.
+func pushSeq[K any](stack []descent, seq iter.Seq2[K, any], depth int) []descent {
+	mark := len(stack)
+	for _, v := range seq {
+		if !isScalar(v) {
+			stack = append(stack, descent{v, depth})
+		}
+	}
+	slices.Reverse(stack[mark:])
+	return stack
+}
+
+// locatedDescent is a value awaiting a visit by [descendLocated], along
+// with the key that identifies it in its parent and its depth below the
+// value at which the descent started.
+type locatedDescent struct {
+	node  any
+	key   NormalSelector
+	depth int
+}
+
+// locatedStepFunc appends the [LocatedNode] values selected from node, whose
+// path is parent, to dst and returns the result.
+type locatedStepFunc func(dst []*LocatedNode, node any, ev *evaluation, parent NormalizedPath) []*LocatedNode
+
+// descendLocated applies s to current and each of its descendants in
+// document order, appending the resulting [LocatedNode] values to dst, and
+// returns the result.
+func (s *Segment) descendLocated(dst []*LocatedNode, current any, ev *evaluation, parent NormalizedPath) []*LocatedNode {
+	return descendLocated(dst, current, 0, ev, parent, s.appendLocatedSelected)
+}
+
+// descendLocated applies step to current, at depth, and each of its
+// descendants in document order, and returns the result. Like [descend], it
+// uses an explicit stack and honors ev.opts.MaxDepth. Because it visits
+// values depth-first, it tracks the path to each value in a single slice,
+// truncating it to the depth of each value it visits and appending the
+// value's key.
+func descendLocated(dst []*LocatedNode, current any, depth int, ev *evaluation, parent NormalizedPath, step locatedStepFunc) []*LocatedNode {
+	base := len(parent) - depth
+	path := slices.Clip(parent)
+	stack := []locatedDescent{{node: current, depth: depth}}
+	var seen cycles
+	for len(stack) > 0 {
+		d := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if d.depth > depth {
+			path = append(path[:base+d.depth-1], d.key)
+		}
+		ev.visit(d.depth)
+		seen.check(d.node, d.depth)
+		dst = step(dst, d.node, ev, path)
+		if ev.opts.MaxDepth > 0 && d.depth >= ev.opts.MaxDepth {
+			ev.truncate(d.node, d.depth)
+			continue
+		}
+		if elems, ok := ev.parallelElements(d.node); ok {
+			// The elements would pop off the stack next, so append their
+			// results now.
+			prefix := slices.Clone(path)
+			for _, res := range parallelize(ev, len(elems), func(wev *evaluation, lo, hi int) []*LocatedNode {
+				var res []*LocatedNode
+				for i := lo; i < hi; i++ {
+					res = descendLocated(
+						res, elems[i], d.depth+1, wev,
+						append(slices.Clip(prefix), Index(i)), step,
+					)
 				}
-				return slices.Clip(ret)
-			default:
-				return make([]any, 0)
+				return res
+			}) {
+				dst = append(dst, res...)
+			}
+			continue
+		}
+		stack = pushLocatedChildren(stack, ev.sorted(d.node), d.depth+1)
+	}
+	return dst
+}
+
+// descendLocatedUntil applies s's selectors to current and each of its
+// descendants in document order, and passes each selected [LocatedNode] to
+// found until found returns true. Returns true if found returns true and
+// false if it never does. Like [Segment.descendUntil], it honors
+// ev.opts.MaxDepth, but never descends in parallel.
+func (s *Segment) descendLocatedUntil(current any, ev *evaluation, parent NormalizedPath, found func(*LocatedNode) bool) bool {
+	base := len(parent)
+	path := slices.Clip(parent)
+	stack := []locatedDescent{{node: current}}
+	var buf []*LocatedNode
+	var seen cycles
+	for len(stack) > 0 {
+		d := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if d.depth > 0 {
+			path = append(path[:base+d.depth-1], d.key)
+		}
+		ev.visit(d.depth)
+		seen.check(d.node, d.depth)
+		buf = s.appendLocatedSelected(buf[:0], d.node, ev, path)
+		for _, node := range buf {
+			if found(node) {
+				return true
 			}
-		default:
-			return make([]any, 0)
+		}
+		if ev.opts.MaxDepth <= 0 || d.depth < ev.opts.MaxDepth {
+			stack = pushLocatedChildren(stack, ev.sorted(d.node), d.depth+1)
+		} else {
+			ev.truncate(d.node, d.depth)
 		}
 	}
+	return false
 }
 
-// descend recursively executes [q] for each value in current and/or root and
-// its descendants and returns the results.
-func (s *Segment) descendLocated(current, root any, parent NormalizedPath) []*LocatedNode {
-	switch val := current.(type) {
+// pushLocatedChildren pushes the children of node at depth onto stack in
+// reverse order, so that they pop off in document order, and returns the
+// result. Skips scalars and containers that cannot contain arrays or
+// objects.
+func pushLocatedChildren(stack []locatedDescent, node any, depth int) []locatedDescent {
+	mark := len(stack)
+	switch val := node.(type) {
 	case []any:
-		ret := make([]*LocatedNode, 0, len(val))
 		for i, v := range val {
-			ret = append(ret, s.SelectLocated(v, root, append(parent, Index(i)))...)
+			if !isScalar(v) {
+				stack = append(stack, locatedDescent{v, Index(i), depth})
+			}
 		}
-		return slices.Clip(ret)
 	case map[string]any:
-		ret := make([]*LocatedNode, 0, len(val))
 		for k, v := range val {
-			ret = append(ret, s.SelectLocated(v, root, append(parent, Name(k)))...)
+			if !isScalar(v) {
+				stack = append(stack, locatedDescent{v, Name(k), depth})
+			}
 		}
-		return slices.Clip(ret)
 	default:
-		value := reflect.ValueOf(current)
-		switch value.Kind() {
-		case reflect.Slice:
-			// Descend into any other slice that contains slices or maps.
-			switch value.Type().Elem().Kind() {
-			case reflect.Slice, reflect.Map:
-				ret := make([]*LocatedNode, 0, value.Len())
-				for i := range value.Len() {
-					ret = append(ret, s.SelectLocated(
-						value.Index(i).Interface(), root, append(parent, Index(i)),
-					)...)
+		// Descend into any other array or object that may contain arrays or
+		// objects.
+		if arr, ok := AsArray(node); ok {
+			if !leavesOnly(arr) {
+				for i, v := range arr.Iterate() {
+					if !isScalar(v) {
+						stack = append(stack, locatedDescent{v, Index(i), depth})
+					}
 				}
-				return slices.Clip(ret)
-			default:
-				return make([]*LocatedNode, 0)
-			}
-		case reflect.Map:
-			// Descend into any map[string]* that contains slices or maps.
-			if value.Type().Key().Kind() != reflect.String {
-				return make([]*LocatedNode, 0)
 			}
-			switch value.Type().Elem().Kind() {
-			case reflect.Slice, reflect.Map:
-				ret := make([]*LocatedNode, 0, value.Len())
-				for _, k := range value.MapKeys() {
-					ret = append(ret, s.SelectLocated(
-						value.MapIndex(k).Interface(), root, append(parent, Name(k.String())),
-					)...)
+		} else if obj, ok := AsObject(node); ok && !leavesOnly(obj) {
+			for k, v := range obj.Iterate() {
+				if !isScalar(v) {
+					stack = append(stack, locatedDescent{v, Name(k), depth})
 				}
-				return slices.Clip(ret)
-			default:
-				return make([]*LocatedNode, 0)
 			}
-		default:
-			return make([]*LocatedNode, 0)
 		}
 	}
+	slices.Reverse(stack[mark:])
+	return stack
 }
 
 // isSingular returns true if the segment selects at most one node. Defined by
@@ -749,3 +749,265 @@ func TestDescendantSegmentSelect(t *testing.T) {
 		})
 	}
 }
+
+func TestDescendDeep(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Nest far deeper than recursion could comfortably handle.
+	const depth = 200_000
+	var input any = map[string]any{"x": "bottom"}
+	for range depth {
+		input = []any{input}
+	}
+
+	seg := Descendant(Name("x"))
+	a.Equal([]any{"bottom"}, seg.Select(input, nil))
+	res := seg.SelectLocated(input, nil, Normalized())
+	a.Len(res, 1)
+	a.Len(res[0].Path, depth+1)
+	a.Equal([]any{"bottom"}, Query(true, seg).Compile().Select(nil, input))
+}
+
+func TestDescendMaxDepth(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"x": 0,
+		"a": []any{map[string]any{"x": 2}, squares(2)},
+		"b": map[string]any{"x": 1, "c": pairs{{"d", pairs{{"x", 3}}}}},
+	}
+
+	for _, tc := range []struct {
+		test  string
+		depth int
+		exp   []any
+		paths []string
+	}{
+		{"unlimited", 0, []any{0, 1, 2, 3}, []string{"$['x']", "$['b']['x']", "$['a'][0]['x']", "$['b']['c']['d']['x']"}},
+		{"negative", -1, []any{0, 1, 2, 3}, []string{"$['x']", "$['b']['x']", "$['a'][0]['x']", "$['b']['c']['d']['x']"}},
+		{"one_level", 1, []any{0, 1}, []string{"$['x']", "$['b']['x']"}},
+		{"two_levels", 2, []any{0, 1, 2}, []string{"$['x']", "$['b']['x']", "$['a'][0]['x']"}},
+		{"three_levels", 3, []any{0, 1, 2, 3}, []string{"$['x']", "$['b']['x']", "$['a'][0]['x']", "$['b']['c']['d']['x']"}},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			opts := Options{MaxDepth: tc.depth}
+
+			q := Query(true, Descendant(Name("x")))
+			a.ElementsMatch(tc.exp, q.SelectWith(nil, input, opts))
+			a.ElementsMatch(tc.exp, q.Compile().SelectWith(nil, input, opts))
+
+			paths := make([]string, 0, len(tc.paths))
+			for _, n := range q.SelectLocatedWith(nil, input, Normalized(), opts) {
+				paths = append(paths, n.Path.String())
+			}
+			a.ElementsMatch(tc.paths, paths)
+		})
+	}
+}
+
+func TestDescendOrder(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := []any{
+		[]any{1, []any{2, 3}},
+		pairs{{"a", 4}, {"b", squares(3)}},
+		[]any{[]any{[]any{5}}, 6},
+	}
+	exp := []any{
+		[]any{1, []any{2, 3}}, pairs{{"a", 4}, {"b", squares(3)}}, []any{[]any{[]any{5}}, 6},
+		1, []any{2, 3}, 2, 3,
+		4, squares(3), 0, 1, 4,
+		[]any{[]any{5}}, 6, []any{5}, 5,
+	}
+	q := Query(true, Descendant(Wildcard()))
+	a.Equal(exp, q.Select(nil, input))
+	a.Equal(exp, q.Compile().Select(nil, input))
+
+	nodes := q.SelectLocated(nil, input, Normalized())
+	vals := make([]any, len(nodes))
+	for i, n := range nodes {
+		vals[i] = n.Node
+	}
+	a.Equal(exp, vals)
+	a.Equal("$[1]['b'][2]", nodes[11].Path.String())
+}
+
+func TestDescendParallel(t *testing.T) {
+	t.Parallel()
+
+	// Use ordered objects, so that the order of results is deterministic.
+	items := make([]any, 3*parallelThreshold)
+	for i := range items {
+		items[i] = pairs{{"id", i}, {"kids", []any{pairs{{"id", -i}}}}}
+	}
+	typed := make([]pairs, parallelThreshold)
+	for i := range typed {
+		typed[i] = pairs{{"id", i}, {"nested", []any{items[:2]}}}
+	}
+	input := pairs{
+		{"limit", 3000},
+		{"items", items},
+		{"typed", typed},
+		{"ints", squares(parallelThreshold)},
+	}
+
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		depth int
+	}{
+		{
+			test:  "ids",
+			query: Query(true, Descendant(Name("id"))),
+		},
+		{
+			test:  "wildcard",
+			query: Query(true, Descendant(Wildcard())),
+		},
+		{
+			test:  "max_depth",
+			query: Query(true, Descendant(Name("id"))),
+			depth: 3,
+		},
+		{
+			test: "root_filter",
+			query: Query(true, Descendant(Filter(And(Comparison(
+				SingularQuery(false, Name("id")),
+				GreaterThan,
+				SingularQuery(true, Name("limit")),
+			))))),
+		},
+		{
+			test: "nested_descent",
+			query: Query(true, Child(Name("typed")), Descendant(Filter(And(Existence(
+				Query(false, Descendant(Index(1))),
+			))))),
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			serial := Options{MaxDepth: tc.depth}
+			parallel := Options{MaxDepth: tc.depth, Parallelism: 4}
+
+			exp := tc.query.SelectWith(nil, input, serial)
+			a.NotEmpty(exp)
+			a.Equal(exp, tc.query.SelectWith(nil, input, parallel))
+			a.Equal(exp, tc.query.Compile().SelectWith(nil, input, parallel))
+			a.Equal(
+				tc.query.SelectLocatedWith(nil, input, Normalized(), serial),
+				tc.query.SelectLocatedWith(nil, input, Normalized(), parallel),
+			)
+		})
+	}
+}
+
+func TestDescendParallelPanic(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	boom := Extension(
+		"__boom",
+		FuncLogical,
+		func([]FuncExprArg) error { return nil },
+		func([]PathValue) PathValue { panic("boom") },
+	)
+	input := make([]any, parallelThreshold)
+	for i := range input {
+		input[i] = []any{i}
+	}
+	q := Query(true, Descendant(Filter(And(Function(boom)))))
+	a.PanicsWithValue("boom", func() {
+		q.SelectWith(nil, []any{input}, Options{Parallelism: 2})
+	})
+}
+
+func TestNameSet(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test  string
+		sels  []Selector
+		exp   *nameSet
+		input any
+		vals  []any
+		paths []string
+	}{
+		{
+			test: "too_few",
+			sels: []Selector{Name("a"), Name("b"), Name("c")},
+		},
+		{
+			test: "not_all_names",
+			sels: []Selector{Name("a"), Name("b"), Name("c"), Index(0)},
+		},
+		{
+			test: "names",
+			sels: []Selector{Name("d"), Name("b"), Name("x"), Name("a")},
+			exp: &nameSet{
+				slots: map[string]int{"d": 0, "b": 1, "x": 2, "a": 3},
+				order: []int{0, 1, 2, 3},
+			},
+			input: pairs{{"a", 1}, {"b", 2}, {"c", 3}, {"d", 4}},
+			vals:  []any{4, 2, 1},
+			paths: []string{"$['d']", "$['b']", "$['a']"},
+		},
+		{
+			test: "dupe_names",
+			sels: []Selector{Name("a"), Name("b"), Name("a"), Name("c")},
+			exp: &nameSet{
+				slots: map[string]int{"a": 0, "b": 1, "c": 2},
+				order: []int{0, 1, 0, 2},
+			},
+			input: pairs{{"c", 3}, {"a", 1}, {"a", 5}, {"b", 2}},
+			vals:  []any{1, 2, 1, 3},
+			paths: []string{"$['a']", "$['b']", "$['a']", "$['c']"},
+		},
+		{
+			test: "map",
+			sels: []Selector{Name("a"), Name("b"), Name("c"), Name("d")},
+			exp: &nameSet{
+				slots: map[string]int{"a": 0, "b": 1, "c": 2, "d": 3},
+				order: []int{0, 1, 2, 3},
+			},
+			input: map[string]any{"d": 4, "b": 2, "a": 1},
+			vals:  []any{1, 2, 4},
+			paths: []string{"$['a']", "$['b']", "$['d']"},
+		},
+		{
+			test: "array",
+			sels: []Selector{Name("a"), Name("b"), Name("c"), Name("d")},
+			exp: &nameSet{
+				slots: map[string]int{"a": 0, "b": 1, "c": 2, "d": 3},
+				order: []int{0, 1, 2, 3},
+			},
+			input: squares(4),
+			vals:  []any{},
+			paths: []string{},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			seg := Child(tc.sels...)
+			a.Equal(tc.exp, seg.names)
+			if tc.exp == nil {
+				return
+			}
+
+			a.Equal(tc.vals, seg.Select(tc.input, nil))
+			a.Equal(tc.vals, Query(true, seg).Compile().Select(nil, tc.input))
+
+			paths := []string{}
+			for _, n := range seg.SelectLocated(tc.input, nil, Normalized()) {
+				paths = append(paths, n.Path.String())
+			}
+			a.Equal(tc.paths, paths)
+		})
+	}
+}
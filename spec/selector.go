@@ -2,9 +2,9 @@ package spec
 
 import (
 	"fmt"
+	"iter"
 	"maps"
 	"math"
-	"reflect"
 	"slices"
 	"strconv"
 	"strings"
@@ -47,6 +47,9 @@ type Name string
 // Defined by the [Selector] interface.
 func (Name) isSingular() bool { return true }
 
+// Value returns the name that n selects, unquoted.
+func (n Name) Value() string { return string(n) }
+
 // String returns the quoted string representation of n.
 func (n Name) String() string {
 	return strconv.Quote(string(n))
@@ -59,31 +62,34 @@ func (n Name) writeTo(buf *strings.Builder) {
 }
 
 // Select selects n from input and returns it as a single value in a slice.
-// Returns an empty slice if input is not a string-keyed map or if it does not
-// contain n. Defined by the [Selector] interface.
+// Returns an empty slice if input is not a string-keyed map or struct or if
+// it does not contain n. Defined by the [Selector] interface.
 func (n Name) Select(input, _ any) []any {
-	if obj, ok := input.(map[string]any); ok {
-		if val, ok := obj[string(n)]; ok {
-			return []any{val}
-		}
-		return make([]any, 0)
+	if val, ok := lookupName(input, string(n)); ok {
+		return []any{val}
 	}
+	return make([]any, 0)
+}
 
-	// Select from any map[string]*.
-	obj := reflect.ValueOf(input)
-	if obj.Kind() == reflect.Map && obj.Type().Key().Kind() == reflect.String {
-		if v := obj.MapIndex(reflect.ValueOf(string(n))); v.Kind() != reflect.Invalid {
-			return []any{v.Interface()}
-		}
+// lookupName returns the value of the member name in input. Returns false
+// if input is not an object or has no member name.
+func lookupName(input any, name string) (any, bool) {
+	if obj, ok := input.(map[string]any); ok {
+		val, ok := obj[name]
+		return val, ok
 	}
 
-	return make([]any, 0)
+	// Select from any other string-keyed map or struct.
+	if obj, ok := AsObject(input); ok {
+		return obj.Get(name)
+	}
+	return nil, false
 }
 
 // SelectLocated selects n from input and returns it with its normalized path
 // as a single [LocatedNode] in a slice. Returns an empty slice if input is
-// not a string-keyed map or if it does not contain n. Defined by the
-// [Selector] interface.
+// not a string-keyed map or struct or if it does not contain n. Defined by
+// the [Selector] interface.
 func (n Name) SelectLocated(input, _ any, parent NormalizedPath) []*LocatedNode {
 	if obj, ok := input.(map[string]any); ok {
 		if val, ok := obj[string(n)]; ok {
@@ -92,11 +98,10 @@ func (n Name) SelectLocated(input, _ any, parent NormalizedPath) []*LocatedNode
 		return make([]*LocatedNode, 0)
 	}
 
-	// Select from any map[string]*.
-	obj := reflect.ValueOf(input)
-	if obj.Kind() == reflect.Map && obj.Type().Key().Kind() == reflect.String {
-		if v := obj.MapIndex(reflect.ValueOf(string(n))); v.Kind() != reflect.Invalid {
-			return []*LocatedNode{newLocatedNode(append(parent, n), v.Interface())}
+	// Select from any other string-keyed map or struct.
+	if obj, ok := AsObject(input); ok {
+		if val, ok := obj.Get(string(n)); ok {
+			return []*LocatedNode{newLocatedNode(append(parent, n), val)}
 		}
 	}
 	return make([]*LocatedNode, 0)
@@ -125,10 +130,12 @@ func (n Name) writeNormalizedTo(buf *strings.Builder) {
 			buf.WriteString(`\'`)
 		case '\\': // \ backslash (reverse solidus) U+005C
 			buf.WriteString(`\\`)
-		case '\x00', '\x01', '\x02', '\x03', '\x04', '\x05', '\x06', '\x07', '\x0b', '\x0e', '\x0f':
-			// "00"-"07", "0b", "0e"-"0f"
-			fmt.Fprintf(buf, `\u000%x`, r)
 		default:
+			if r < ' ' {
+				// "00"-"07", "0b", "0e"-"0f", "10"-"1f"
+				fmt.Fprintf(buf, `\u%04x`, r)
+				continue
+			}
 			buf.WriteRune(r)
 		}
 	}
@@ -171,8 +178,8 @@ func (WildcardSelector) String() string { return "*" }
 func (WildcardSelector) isSingular() bool { return false }
 
 // Select selects the values from input and returns them in a slice. Returns
-// an empty slice if input is not a slice or string-keyed map. Defined by the
-// [Selector] interface.
+// an empty slice if input is not a slice, string-keyed map, or struct.
+// Defined by the [Selector] interface.
 func (WildcardSelector) Select(input, _ any) []any {
 	switch val := input.(type) {
 	case []any:
@@ -180,36 +187,29 @@ func (WildcardSelector) Select(input, _ any) []any {
 	case map[string]any:
 		return slices.Collect(maps.Values(val))
 	default:
-		// Look for other slice and map types.
-		value := reflect.ValueOf(val)
-		switch value.Kind() {
-		case reflect.Slice:
-			// Copy the slice values.
-			ret := make([]any, value.Len())
-			for i := range value.Len() {
-				ret[i] = value.Index(i).Interface()
+		// Look for other array and object types.
+		if arr, ok := AsArray(val); ok {
+			ret := make([]any, 0, arr.Len())
+			for _, v := range arr.Iterate() {
+				ret = append(ret, v)
 			}
 			return ret
-		case reflect.Map:
-			// Copy from any map[string]*.
-			if value.Type().Key().Kind() == reflect.String {
-				ret := make([]any, value.Len())
-				for i, k := range value.MapKeys() {
-					ret[i] = value.MapIndex(k).Interface()
-				}
-				return ret
+		}
+		if obj, ok := AsObject(val); ok {
+			ret := make([]any, 0, obj.Len())
+			for _, v := range obj.Iterate() {
+				ret = append(ret, v)
 			}
-			return make([]any, 0)
-		default:
-			return make([]any, 0)
+			return ret
 		}
+		return make([]any, 0)
 	}
 }
 
 // SelectLocated selects the values from input and returns them with their
 // normalized paths in a slice of [LocatedNode] values. Returns an empty slice
-// if input is not a slice or string-keyed map. Defined by the [Selector]
-// interface.
+// if input is not a slice, string-keyed map, or struct. Defined by the
+// [Selector] interface.
 func (WildcardSelector) SelectLocated(input, _ any, parent NormalizedPath) []*LocatedNode {
 	switch val := input.(type) {
 	case []any:
@@ -225,30 +225,55 @@ func (WildcardSelector) SelectLocated(input, _ any, parent NormalizedPath) []*Lo
 		}
 		return slices.Clip(ret)
 	default:
-		// Look for other slice and map types.
-		value := reflect.ValueOf(val)
-		switch value.Kind() {
-		case reflect.Slice:
-			// Copy the slice values.
-			ret := make([]*LocatedNode, value.Len())
-			for i := range value.Len() {
-				ret[i] = newLocatedNode(append(parent, Index(i)), value.Index(i).Interface())
+		// Look for other array and object types.
+		if arr, ok := AsArray(val); ok {
+			ret := make([]*LocatedNode, 0, arr.Len())
+			for i, v := range arr.Iterate() {
+				ret = append(ret, newLocatedNode(append(parent, Index(i)), v))
 			}
 			return ret
-		case reflect.Map:
-			// Copy from any map[string]*.
-			if value.Type().Key().Kind() == reflect.String {
-				ret := make([]*LocatedNode, value.Len())
-				for i, k := range value.MapKeys() {
-					ret[i] = newLocatedNode(append(parent, Name(k.String())), value.MapIndex(k).Interface())
-				}
-				return ret
+		}
+		if obj, ok := AsObject(val); ok {
+			ret := make([]*LocatedNode, 0, obj.Len())
+			for k, v := range obj.Iterate() {
+				ret = append(ret, newLocatedNode(append(parent, Name(k)), v))
 			}
-			return make([]*LocatedNode, 0)
-		default:
-			return make([]*LocatedNode, 0)
+			return ret
+		}
+		return make([]*LocatedNode, 0)
+	}
+}
+
+// selectAll appends all of the values in node to dst and returns the
+// result. Implements [WildcardSelector] for [Segment] and as a [stepFunc]
+// for [CompiledQuery].
+func selectAll(ev *evaluation, node any, dst []any) []any {
+	node = ev.sorted(node)
+	switch val := node.(type) {
+	case []any:
+		return append(dst, val...)
+	case map[string]any:
+		for _, v := range val {
+			dst = append(dst, v)
 		}
+		return dst
+	}
+
+	if arr, ok := AsArray(node); ok {
+		return appendAll(dst, arr.Iterate())
 	}
+	if obj, ok := AsObject(node); ok {
+		return appendAll(dst, obj.Iterate())
+	}
+	return dst
+}
+
+// appendAll appends the values in seq to dst and returns the result.
+func appendAll[K any](dst []any, seq iter.Seq2[K, any]) []any {
+	for _, v := range seq {
+		dst = append(dst, v)
+	}
+	return dst
 }
 
 // Index is an array index selector, e.g., [3], as defined by [RFC
@@ -271,6 +296,10 @@ func (i Index) writeTo(buf *strings.Builder) {
 	buf.WriteString(i.String())
 }
 
+// Value returns the index that i selects, which counts from the end of an
+// array if negative.
+func (i Index) Value() int { return int(i) }
+
 // String returns a string representation of i.
 func (i Index) String() string { return strconv.FormatInt(int64(i), 10) }
 
@@ -278,32 +307,31 @@ func (i Index) String() string { return strconv.FormatInt(int64(i), 10) }
 // Returns an empty slice if input is not a slice or if i it outside the
 // bounds of input. Defined by the [Selector] interface.
 func (i Index) Select(input, _ any) []any {
-	if val, ok := input.([]any); ok {
-		idx := int(i)
-		if idx < 0 {
-			if idx = len(val) + idx; idx >= 0 {
-				return []any{val[idx]}
-			}
-		} else if idx < len(val) {
-			return []any{val[idx]}
-		}
-		return make([]any, 0)
+	if val, ok := lookupIndex(input, int(i)); ok {
+		return []any{val}
 	}
+	return make([]any, 0)
+}
 
-	// Select from any kind of slice.
-	val := reflect.ValueOf(input)
-	if val.Kind() == reflect.Slice {
-		idx := int(i)
+// lookupIndex returns the value at idx in input, counting from the end of
+// input for a negative idx. Returns false if input is not an array or idx
+// is out of its bounds.
+func lookupIndex(input any, idx int) (any, bool) {
+	if val, ok := input.([]any); ok {
 		if idx < 0 {
-			if idx = val.Len() + idx; idx >= 0 {
-				return []any{val.Index(idx).Interface()}
-			}
-		} else if idx < val.Len() {
-			return []any{val.Index(idx).Interface()}
+			idx += len(val)
+		}
+		if idx >= 0 && idx < len(val) {
+			return val[idx], true
 		}
+		return nil, false
 	}
 
-	return make([]any, 0)
+	// Select from any other array.
+	if arr, ok := AsArray(input); ok {
+		return arr.Get(normalize(idx, arr.Len()))
+	}
+	return nil, false
 }
 
 // SelectLocated selects i from input and returns it with its normalized path
@@ -323,20 +351,11 @@ func (i Index) SelectLocated(input, _ any, parent NormalizedPath) []*LocatedNode
 		return make([]*LocatedNode, 0)
 	}
 
-	// Select from any kind of slice.
-	val := reflect.ValueOf(input)
-	if val.Kind() == reflect.Slice {
-		idx := int(i)
-		if idx < 0 {
-			if idx = val.Len() + idx; idx >= 0 {
-				return []*LocatedNode{newLocatedNode(
-					append(parent, Index(idx)), val.Index(idx).Interface(),
-				)}
-			}
-		} else if idx < val.Len() {
-			return []*LocatedNode{newLocatedNode(
-				append(parent, Index(idx)), val.Index(idx).Interface(),
-			)}
+	// Select from any other array.
+	if arr, ok := AsArray(input); ok {
+		idx := normalize(int(i), arr.Len())
+		if v, ok := arr.Get(idx); ok {
+			return []*LocatedNode{newLocatedNode(append(parent, Index(idx)), v)}
 		}
 	}
 
@@ -435,11 +454,11 @@ func Slice(args ...any) SliceSelector {
 // writeTo writes a string representation of s to buf. Defined by
 // [stringWriter].
 func (s SliceSelector) writeTo(buf *strings.Builder) {
-	if s.start != 0 && (s.step >= 0 || s.start != math.MaxInt) {
+	if s.HasStart() {
 		buf.WriteString(strconv.FormatInt(int64(s.start), 10))
 	}
 	buf.WriteByte(':')
-	if s.end != math.MaxInt && (s.step >= 0 || s.end != math.MinInt) {
+	if s.HasEnd() {
 		buf.WriteString(strconv.FormatInt(int64(s.end), 10))
 	}
 	if s.step != 1 {
@@ -460,41 +479,45 @@ func (s SliceSelector) String() string {
 // bounds of input will not be included in the return value. Defined by the
 // [Selector] interface.
 func (s SliceSelector) Select(input, _ any) []any {
+	return slices.Clip(s.appendFrom(make([]any, 0), input))
+}
+
+// appendFrom appends the values from input for the indexes specified by s
+// to dst and returns the result. Appends nothing if input is not an array.
+func (s SliceSelector) appendFrom(dst []any, input any) []any {
 	if val, ok := input.([]any); ok {
 		lower, upper := s.Bounds(len(val))
-		res := make([]any, 0, len(val))
 		switch {
 		case s.step > 0:
 			for i := lower; i < upper; i += s.step {
-				res = append(res, val[i])
+				dst = append(dst, val[i])
 			}
 		case s.step < 0:
 			for i := upper; lower < i; i += s.step {
-				res = append(res, val[i])
+				dst = append(dst, val[i])
 			}
 		}
-		return slices.Clip(res)
+		return dst
 	}
 
-	// Select from any kind of slice.
-	val := reflect.ValueOf(input)
-	if val.Kind() == reflect.Slice {
-		lower, upper := s.Bounds(val.Len())
-		res := make([]any, 0, val.Len())
+	// Select from any other array.
+	if arr, ok := AsArray(input); ok {
+		lower, upper := s.Bounds(arr.Len())
 		switch {
 		case s.step > 0:
 			for i := lower; i < upper; i += s.step {
-				res = append(res, val.Index(i).Interface())
+				v, _ := arr.Get(i)
+				dst = append(dst, v)
 			}
 		case s.step < 0:
 			for i := upper; lower < i; i += s.step {
-				res = append(res, val.Index(i).Interface())
+				v, _ := arr.Get(i)
+				dst = append(dst, v)
 			}
 		}
-		return slices.Clip(res)
 	}
 
-	return make([]any, 0)
+	return dst
 }
 
 // SelectLocated selects values from input for the indexes specified by s and
@@ -519,23 +542,20 @@ func (s SliceSelector) SelectLocated(input, _ any, parent NormalizedPath) []*Loc
 		return slices.Clip(res)
 	}
 
-	// Select from any kind of slice.
-	val := reflect.ValueOf(input)
-	if val.Kind() == reflect.Slice {
-		lower, upper := s.Bounds(val.Len())
-		res := make([]*LocatedNode, 0, val.Len())
+	// Select from any other array.
+	if arr, ok := AsArray(input); ok {
+		lower, upper := s.Bounds(arr.Len())
+		res := make([]*LocatedNode, 0, arr.Len())
 		switch {
 		case s.step > 0:
 			for i := lower; i < upper; i += s.step {
-				res = append(res, newLocatedNode(
-					append(parent, Index(i)), val.Index(i).Interface(),
-				))
+				v, _ := arr.Get(i)
+				res = append(res, newLocatedNode(append(parent, Index(i)), v))
 			}
 		case s.step < 0:
 			for i := upper; lower < i; i += s.step {
-				res = append(res, newLocatedNode(
-					append(parent, Index(i)), val.Index(i).Interface(),
-				))
+				v, _ := arr.Get(i)
+				res = append(res, newLocatedNode(append(parent, Index(i)), v))
 			}
 		}
 		return slices.Clip(res)
@@ -558,6 +578,32 @@ func (s SliceSelector) Step() int {
 	return s.step
 }
 
+// HasStart returns false if the start position of s is the default for its
+// step, as when omitted from the slice, so that [SliceSelector.Start]
+// returns 0 for a non-negative step and [math.MaxInt] for a negative step.
+func (s SliceSelector) HasStart() bool {
+	if s.step < 0 {
+		return s.start != math.MaxInt
+	}
+	return s.start != 0
+}
+
+// HasEnd returns false if the end position of s is the default for its
+// step, as when omitted from the slice, so that [SliceSelector.End]
+// returns [math.MaxInt] for a non-negative step and [math.MinInt] for a
+// negative step.
+func (s SliceSelector) HasEnd() bool {
+	if s.step < 0 {
+		return s.end != math.MinInt
+	}
+	return s.end != math.MaxInt
+}
+
+// HasStep returns false if the step of s is the default, 1.
+func (s SliceSelector) HasStep() bool {
+	return s.step != 1
+}
+
 // Bounds returns the lower and upper bounds for selecting from a slice of
 // length.
 func (s SliceSelector) Bounds(length int) (int, int) {
@@ -615,11 +661,20 @@ func (f *FilterSelector) writeTo(buf *strings.Builder) {
 // expressions may evaluate the current value (@), the root value ($), or any
 // path expression. Defined by the [Selector] interface.
 func (f *FilterSelector) Select(current, root any) []any {
-	switch current := current.(type) {
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	return f.selectFrom(current, ev)
+}
+
+// selectFrom selects and returns values that f filters from current,
+// evaluating its expressions with ev.
+func (f *FilterSelector) selectFrom(current any, ev *evaluation) []any {
+	switch current := ev.sorted(current).(type) {
 	case []any:
 		ret := make([]any, 0, len(current))
 		for _, v := range current {
-			if f.Eval(v, root) {
+			ev.filter()
+			if f.testFilter(v, ev) {
 				ret = append(ret, v)
 			}
 		}
@@ -627,40 +682,35 @@ func (f *FilterSelector) Select(current, root any) []any {
 	case map[string]any:
 		ret := make([]any, 0, len(current))
 		for _, v := range current {
-			if f.Eval(v, root) {
+			ev.filter()
+			if f.testFilter(v, ev) {
 				ret = append(ret, v)
 			}
 		}
 		return slices.Clip(ret)
 	default:
-		val := reflect.ValueOf(current)
-		switch val.Kind() {
-		case reflect.Slice:
-			// Select from any type of slice.
-			ret := make([]any, 0, val.Len())
-			for i := range val.Len() {
-				v := val.Index(i).Interface()
-				if f.Eval(v, root) {
+		// Select from any other array or object.
+		if arr, ok := AsArray(current); ok {
+			ret := make([]any, 0, arr.Len())
+			for _, v := range arr.Iterate() {
+				ev.filter()
+				if f.testFilter(v, ev) {
 					ret = append(ret, v)
 				}
 			}
 			return slices.Clip(ret)
-		case reflect.Map:
-			// Select from any map[string]*.
-			if val.Type().Key().Kind() != reflect.String {
-				return make([]any, 0)
-			}
-			ret := make([]any, 0, val.Len())
-			for _, k := range val.MapKeys() {
-				v := val.MapIndex(k).Interface()
-				if f.Eval(v, root) {
+		}
+		if obj, ok := AsObject(current); ok {
+			ret := make([]any, 0, obj.Len())
+			for _, v := range obj.Iterate() {
+				ev.filter()
+				if f.testFilter(v, ev) {
 					ret = append(ret, v)
 				}
 			}
 			return slices.Clip(ret)
-		default:
-			return make([]any, 0)
 		}
+		return make([]any, 0)
 	}
 }
 
@@ -669,11 +719,20 @@ func (f *FilterSelector) Select(current, root any) []any {
 // (@), the root value ($), or any path expression. Defined by the [Selector]
 // interface.
 func (f *FilterSelector) SelectLocated(current, root any, parent NormalizedPath) []*LocatedNode {
-	switch current := current.(type) {
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	return f.selectLocatedFrom(current, ev, parent)
+}
+
+// selectLocatedFrom selects and returns [LocatedNode] values with values
+// that f filters from current, evaluating its expressions with ev.
+func (f *FilterSelector) selectLocatedFrom(current any, ev *evaluation, parent NormalizedPath) []*LocatedNode {
+	switch current := ev.sorted(current).(type) {
 	case []any:
 		ret := make([]*LocatedNode, 0, len(current))
 		for i, v := range current {
-			if f.Eval(v, root) {
+			ev.filter()
+			if f.testFilter(v, ev) {
 				ret = append(ret, newLocatedNode(append(parent, Index(i)), v))
 			}
 		}
@@ -681,40 +740,35 @@ func (f *FilterSelector) SelectLocated(current, root any, parent NormalizedPath)
 	case map[string]any:
 		ret := make([]*LocatedNode, 0, len(current))
 		for k, v := range current {
-			if f.Eval(v, root) {
+			ev.filter()
+			if f.testFilter(v, ev) {
 				ret = append(ret, newLocatedNode(append(parent, Name(k)), v))
 			}
 		}
 		return slices.Clip(ret)
 	default:
-		val := reflect.ValueOf(current)
-		switch val.Kind() {
-		case reflect.Slice:
-			// Select from any type of slice.
-			ret := make([]*LocatedNode, 0, val.Len())
-			for i := range val.Len() {
-				v := val.Index(i).Interface()
-				if f.Eval(v, root) {
+		// Select from any other array or object.
+		if arr, ok := AsArray(current); ok {
+			ret := make([]*LocatedNode, 0, arr.Len())
+			for i, v := range arr.Iterate() {
+				ev.filter()
+				if f.testFilter(v, ev) {
 					ret = append(ret, newLocatedNode(append(parent, Index(i)), v))
 				}
 			}
 			return slices.Clip(ret)
-		case reflect.Map:
-			// Select from any map[string]*.
-			if val.Type().Key().Kind() != reflect.String {
-				return make([]*LocatedNode, 0)
-			}
-			ret := make([]*LocatedNode, 0, val.Len())
-			for _, k := range val.MapKeys() {
-				v := val.MapIndex(k).Interface()
-				if f.Eval(v, root) {
-					ret = append(ret, newLocatedNode(append(parent, Name(k.String())), v))
+		}
+		if obj, ok := AsObject(current); ok {
+			ret := make([]*LocatedNode, 0, obj.Len())
+			for k, v := range obj.Iterate() {
+				ev.filter()
+				if f.testFilter(v, ev) {
+					ret = append(ret, newLocatedNode(append(parent, Name(k)), v))
 				}
 			}
 			return slices.Clip(ret)
-		default:
-			return make([]*LocatedNode, 0)
 		}
+		return make([]*LocatedNode, 0)
 	}
 }
 
@@ -722,7 +776,9 @@ func (f *FilterSelector) SelectLocated(current, root any, parent NormalizedPath)
 // [FilterSelector.Select] as it iterates over nodes, and always passes the
 // root value($) for filter expressions that reference it.
 func (f *FilterSelector) Eval(node, root any) bool {
-	return f.testFilter(node, root)
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	return f.testFilter(node, ev)
 }
 
 // isSingular returns false because Filters can return more than one value.
@@ -160,7 +160,7 @@ func TestSelectorString(t *testing.T) {
 		{
 			test: "slice_max_end_neg_step",
 			tok:  Slice(0, math.MaxInt, -1),
-			str:  "::-1",
+			str:  "0:9223372036854775807:-1",
 		},
 		{
 			test: "slice_min_end",
@@ -170,8 +170,23 @@ func TestSelectorString(t *testing.T) {
 		{
 			test: "slice_min_end_neg_step",
 			tok:  Slice(0, math.MinInt, -1),
+			str:  "0::-1",
+		},
+		{
+			test: "slice_defaults_neg_step",
+			tok:  Slice(nil, nil, -1),
 			str:  "::-1",
 		},
+		{
+			test: "slice_zero_start_neg_step",
+			tok:  Slice(0, -3, -2),
+			str:  "0:-3:-2",
+		},
+		{
+			test: "slice_max_start_neg_step",
+			tok:  Slice(math.MaxInt, 1, -1),
+			str:  ":1:-1",
+		},
 		{
 			test: "wildcard",
 			tok:  Wildcard(),
@@ -333,6 +348,40 @@ func TestSliceBounds(t *testing.T) {
 	}
 }
 
+func TestSliceHas(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test  string
+		slice SliceSelector
+		start bool
+		end   bool
+		step  bool
+	}{
+		{"defaults", Slice(), false, false, false},
+		{"nil_nil_nil", Slice(nil, nil, nil), false, false, false},
+		{"start", Slice(2), true, false, false},
+		{"zero_start", Slice(0, 3), false, true, false},
+		{"end", Slice(nil, 3), false, true, false},
+		{"step", Slice(nil, nil, 2), false, false, true},
+		{"explicit_step_1", Slice(1, 4, 1), true, true, false},
+		{"all", Slice(1, 5, 2), true, true, true},
+		{"neg_step_defaults", Slice(nil, nil, -1), false, false, true},
+		{"neg_step_zero_start", Slice(0, nil, -1), true, false, true},
+		{"neg_step_bounds", Slice(5, 1, -2), true, true, true},
+		{"neg_step_max_end", Slice(nil, math.MaxInt, -1), false, true, true},
+		{"zero_step", Slice(nil, nil, 0), false, false, true},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			a.Equal(tc.start, tc.slice.HasStart())
+			a.Equal(tc.end, tc.slice.HasEnd())
+			a.Equal(tc.step, tc.slice.HasStep())
+		})
+	}
+}
+
 func TestSlicePanic(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
@@ -351,6 +400,17 @@ func TestSlicePanic(t *testing.T) {
 	)
 }
 
+func TestSelectorValue(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal("hi", Name("hi").Value())
+	a.Equal(`"a\"b"`, Name(`a"b`).String())
+	a.Equal(`a"b`, Name(`a"b`).Value())
+	a.Equal(3, Index(3).Value())
+	a.Equal(-1, Index(-1).Value())
+}
+
 func TestNameSelect(t *testing.T) {
 	t.Parallel()
 
@@ -165,6 +165,23 @@ func ExampleCompExpr() {
 	// Output: 42 == @["age"]
 }
 
+// Compare values as filter expressions compare them.
+func ExampleEqual() {
+	fmt.Println(spec.Equal(1, 1.0))
+	fmt.Println(spec.Equal(json.Number("2"), uint8(2)))
+	fmt.Println(spec.Equal([]any{1}, []any{1}))
+	fmt.Println(spec.Less(1, 1.5))
+	fmt.Println(spec.Less("a", "b"))
+	fmt.Println(spec.Less(1, "2"))
+	// Output:
+	// true
+	// true
+	// true
+	// true
+	// true
+	// false
+}
+
 // Create an existence expression as a filter expression.
 func ExampleExistExpr() {
 	filter := spec.Filter(spec.And(
@@ -341,6 +358,85 @@ func ExampleSingularQueryExpr() {
 	// Output: $["profile"]["contacts"][0]["email"]
 }
 
+// Build a filter that selects books cheaper than a limit in the input,
+// comparing a relative singular query to an absolute singular query.
+func ExampleSingularRel() {
+	filter := spec.Filter(spec.And(spec.Comparison(
+		spec.SingularRel("price"),
+		spec.LessThan,
+		spec.SingularAbs("limits", "price"),
+	)))
+	q := spec.Query(true, spec.Child(spec.Name("books")), spec.Child(filter))
+	fmt.Printf("%v\n", q)
+
+	input := map[string]any{
+		"limits": map[string]any{"price": 10},
+		"books": []any{
+			map[string]any{"title": "Moby Dick", "price": 8.99},
+			map[string]any{"title": "Sword of Honour", "price": 12.99},
+		},
+	}
+	fmt.Printf("%v\n", q.Select(nil, input))
+	// Output:
+	// $["books"][?@["price"] < $["limits"]["price"]]
+	// [map[price:8.99 title:Moby Dick]]
+}
+
+// descendantFinder is a [spec.Visitor] that finds descendant segments.
+type descendantFinder struct{ found []string }
+
+func (f *descendantFinder) Enter(node any) bool {
+	if seg, ok := node.(*spec.Segment); ok && seg.IsDescendant() {
+		f.found = append(f.found, seg.String())
+	}
+	return true
+}
+
+func (f *descendantFinder) Exit(any) {}
+
+// Use Walk to find descendant segments anywhere in a query, including in
+// filter expressions, for example to reject potentially expensive queries.
+func ExampleWalk() {
+	p, err := jsonpath.Parse(`$.store[?count(@..price) > 1]..author`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	finder := &descendantFinder{}
+	spec.Walk(p.Query(), finder)
+	fmt.Printf("%v\n", finder.found)
+	// Output: [..["price"] ..["author"]]
+}
+
+// Inspect the selectors of a query with their accessors, rather than by
+// parsing their string representations.
+func ExampleSegment_Selectors() {
+	p, err := jsonpath.Parse(`$..books[1:][?@.price < 10, -1]["title"]`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, seg := range p.Query().Segments() {
+		for _, sel := range seg.Selectors() {
+			switch sel := sel.(type) {
+			case spec.Name:
+				fmt.Printf("name %q, descendant: %v\n", sel.Value(), seg.IsDescendant())
+			case spec.Index:
+				fmt.Printf("index %v\n", sel.Value())
+			case spec.SliceSelector:
+				fmt.Printf("slice start %v, has end: %v\n", sel.Start(), sel.HasEnd())
+			case *spec.FilterSelector:
+				cmp := sel.LogicalOr[0][0].(*spec.CompExpr)
+				fmt.Printf("filter %v %v %v\n", cmp.Left(), cmp.Op(), cmp.Right())
+			}
+		}
+	}
+	// Output:
+	// name "books", descendant: true
+	// slice start 1, has end: false
+	// filter @["price"] < 10
+	// index -1
+	// name "title", descendant: false
+}
+
 // Create A [spec.ValueType] for each supported JSON type.
 func ExampleValueType() {
 	for _, val := range []any{
@@ -495,3 +591,33 @@ func bookstore() any {
 	}
 	return value
 }
+
+// Use a RelativePointer to find values near the nodes a query selects, such
+// as the title of each book with an author, and NormalizedPath.RelativePointer
+// to create one from the locations of two nodes.
+func ExampleRelativePointer() {
+	store := bookstore()
+	title, err := spec.ParseRelativePointer("1/title")
+	if err != nil {
+		log.Fatal(err)
+	}
+	index, err := spec.ParseRelativePointer("1#")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p := jsonpath.MustParse(`$.store.book[?@.price > 10].author`)
+	for _, node := range p.SelectLocated(store) {
+		t, _ := title.Resolve(store, node.Path)
+		i, _ := index.Resolve(store, node.Path)
+		fmt.Printf("%v: %v by %v\n", i, t, node.Node)
+	}
+
+	from := spec.Normalized(spec.Name("store"), spec.Name("book"), spec.Index(0))
+	to := spec.Normalized(spec.Name("store"), spec.Name("bicycle"), spec.Name("color"))
+	fmt.Println(to.RelativePointer(from))
+	// Output:
+	// 1: Sword of Honour by Evelyn Waugh
+	// 3: The Lord of the Rings by J. R. R. Tolkien
+	// 2/bicycle/color
+}
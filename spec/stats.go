@@ -0,0 +1,93 @@
+package spec
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Hook receives notifications of the work done to evaluate a [PathQuery],
+// for instrumentation such as [Collector]. Set one with [Options].Hook.
+// Queries that descend in parallel, as configured by [Options].Parallelism,
+// call a Hook from multiple goroutines, and queries may be evaluated
+// concurrently, so implementations must be safe for concurrent use.
+type Hook interface {
+	// Visit is called for each value to which a segment applies its
+	// selectors, with the depth of the value below the value to which the
+	// segment applies: always zero for child segments, and zero or more for
+	// descendant segments.
+	Visit(depth int)
+
+	// Filter is called for each evaluation of a [FilterSelector]'s
+	// expression for a value.
+	Filter()
+
+	// Function is called for each call to the [FuncExtension] named name.
+	Function(name string)
+}
+
+// Stats summarizes the work done to evaluate a [PathQuery], as collected by
+// a [Collector].
+type Stats struct {
+	// Nodes is the number of values to which segments applied their
+	// selectors, including the segments of queries in filter expressions.
+	Nodes int
+	// MaxDepth is the greatest depth below the value to which a descendant
+	// segment applied that the segment visited.
+	MaxDepth int
+	// Filters is the number of evaluations of filter expressions.
+	Filters int
+	// Functions is the number of calls to function extensions.
+	Functions int
+	// Regexes is the number of calls to the match() and search() functions,
+	// each of which evaluates a regular expression.
+	Regexes int
+	// Duration is the time taken to evaluate the query. [Collector] does
+	// not measure it, so it's zero unless set by the caller.
+	Duration time.Duration
+}
+
+// Collector is a [Hook] that collects [Stats]. It's safe for concurrent
+// use. The zero value is ready to use.
+type Collector struct {
+	nodes     atomic.Int64
+	maxDepth  atomic.Int64
+	filters   atomic.Int64
+	functions atomic.Int64
+	regexes   atomic.Int64
+}
+
+// Visit counts a visit to a value at depth. Defined by [Hook].
+func (c *Collector) Visit(depth int) {
+	c.nodes.Add(1)
+	for d := int64(depth); ; {
+		cur := c.maxDepth.Load()
+		if d <= cur || c.maxDepth.CompareAndSwap(cur, d) {
+			return
+		}
+	}
+}
+
+// Filter counts an evaluation of a filter expression. Defined by [Hook].
+func (c *Collector) Filter() {
+	c.filters.Add(1)
+}
+
+// Function counts a call to the function extension named name, and to a
+// regular expression if name is "match" or "search". Defined by [Hook].
+func (c *Collector) Function(name string) {
+	c.functions.Add(1)
+	if name == "match" || name == "search" {
+		c.regexes.Add(1)
+	}
+}
+
+// Stats returns the Stats collected by c so far.
+func (c *Collector) Stats() Stats {
+	return Stats{
+		Nodes:     int(c.nodes.Load()),
+		MaxDepth:  int(c.maxDepth.Load()),
+		Filters:   int(c.filters.Load()),
+		Functions: int(c.functions.Load()),
+		Regexes:   int(c.regexes.Load()),
+	}
+}
@@ -0,0 +1,147 @@
+package spec
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var c Collector
+	a.Equal(Stats{}, c.Stats())
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range 100 {
+				c.Visit(d + i)
+			}
+			c.Filter()
+			c.Function("length")
+			c.Function("match")
+			c.Function("search")
+		}()
+	}
+	wg.Wait()
+
+	a.Equal(Stats{
+		Nodes:     800,
+		MaxDepth:  106,
+		Filters:   8,
+		Functions: 24,
+		Regexes:   16,
+	}, c.Stats())
+}
+
+func TestHook(t *testing.T) {
+	t.Parallel()
+
+	root := pairs{
+		{"a", []any{pairs{{"x", 1}}, pairs{{"x", "hi"}}}},
+		{"b", pairs{{"c", pairs{{"d", 1}}}}},
+	}
+	match := Extension(
+		"match",
+		FuncLogical,
+		func([]FuncExprArg) error { return nil },
+		func([]PathValue) PathValue { return LogicalTrue },
+	)
+	for _, tc := range []struct {
+		test  string
+		query *PathQuery
+		exp   Stats
+	}{
+		{
+			test:  "no_segments",
+			query: Query(true),
+			exp:   Stats{},
+		},
+		{
+			test:  "singular",
+			query: Query(true, Child(Name("a")), Child(Index(0)), Child(Name("x"))),
+			exp:   Stats{Nodes: 3},
+		},
+		{
+			test:  "wildcard",
+			query: Query(true, Child(Name("a")), Child(Wildcard()), Child(Name("x"))),
+			exp:   Stats{Nodes: 4},
+		},
+		{
+			test:  "descendant",
+			query: Query(true, Descendant(Name("d"))),
+			exp:   Stats{Nodes: 6, MaxDepth: 2},
+		},
+		{
+			test:  "max_depth_after_child",
+			query: Query(true, Child(Name("b")), Descendant(Name("d"))),
+			exp:   Stats{Nodes: 3, MaxDepth: 1},
+		},
+		{
+			test: "comparison",
+			query: Query(true, Child(Name("a")), Child(Filter(And(Comparison(
+				SingularQuery(false, Name("x")), GreaterThan, Literal(0),
+			))))),
+			exp: Stats{Nodes: 4, Filters: 2},
+		},
+		{
+			test: "regex",
+			query: Query(true, Child(Name("a")), Child(Filter(And(Function(
+				match, SingularQuery(false, Name("x")), Literal("h.*"),
+			))))),
+			exp: Stats{Nodes: 4, Filters: 2, Functions: 2, Regexes: 2},
+		},
+		{
+			test: "existence",
+			query: Query(true, Descendant(Filter(And(Existence(
+				Query(false, Child(Name("d"))),
+			))))),
+			exp: Stats{Nodes: 14, MaxDepth: 2, Filters: 8},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			// All forms of selection should do the same work.
+			for name, sel := range map[string]func(opts Options){
+				"select": func(opts Options) { tc.query.SelectWith(nil, root, opts) },
+				"located": func(opts Options) {
+					tc.query.SelectLocatedWith(nil, root, Normalized(), opts)
+				},
+				"compiled": func(opts Options) { tc.query.Compile().SelectWith(nil, root, opts) },
+				"query_set": func(opts Options) {
+					NewQuerySet(tc.query).SelectWith(nil, root, opts)
+				},
+				"located_set": func(opts Options) {
+					NewQuerySet(tc.query).SelectLocatedWith(nil, root, Normalized(), opts)
+				},
+			} {
+				c := new(Collector)
+				sel(Options{Hook: c})
+				a.Equal(tc.exp, c.Stats(), name)
+			}
+		})
+	}
+
+	t.Run("parallel", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+
+		items := make([]any, parallelThreshold*2)
+		for i := range items {
+			items[i] = []any{i}
+		}
+		q := Query(true, Descendant(Index(0)))
+		for _, p := range []int{0, 4} {
+			c := new(Collector)
+			q.SelectWith(nil, items, Options{Hook: c, Parallelism: p})
+			a.Equal(Stats{Nodes: len(items) + 1, MaxDepth: 1}, c.Stats())
+		}
+	})
+}
@@ -0,0 +1,304 @@
+package spec
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// errStop indicates that a yield function passed to [PathQuery.Stream]
+// returned false.
+var errStop = errors.New("stop")
+
+// Stream reads a single JSON value from dec and passes the nodes that q
+// selects from it to yield, along with their normalized paths, as they are
+// encountered. Stops reading and returns nil as soon as yield returns false.
+// Returns any error returned by dec.
+//
+// Stream never decodes values that cannot contribute to the result. Segments
+// consisting solely of [Name], [WildcardSelector], non-negative [Index], and
+// [SliceSelector] values with non-negative bounds and a positive step select
+// children as they're read from dec. All other selectors, including
+// [FilterSelector], require the decoding of the value they select from, after
+// which Stream applies them and the remaining segments to that value in
+// memory. Queries with filter expressions that reference the root node ($)
+// require decoding the entire value.
+//
+// Because it emits nodes in the order they appear in the input, Stream may
+// emit the nodes selected by segments with multiple selectors in a different
+// order than [PathQuery.SelectLocated] does. Otherwise the nodes are the
+// same.
+func (q *PathQuery) Stream(dec *json.Decoder, yield func(NormalizedPath, any) bool) error {
+	if usesRoot(q.segments) {
+		var val any
+		if err := dec.Decode(&val); err != nil {
+			//nolint:wrapcheck
+			return err
+		}
+		for _, node := range q.SelectLocated(val, val, Normalized()) {
+			if !yield(node.Path, node.Node) {
+				break
+			}
+		}
+		return nil
+	}
+
+	s := &streamer{
+		dec:    dec,
+		segs:   q.segments,
+		direct: make([]bool, len(q.segments)),
+		yield:  yield,
+	}
+	for i, seg := range q.segments {
+		s.direct[i] = seg.selectsByKey()
+	}
+
+	if err := s.value(Normalized(), []int{0}); err != nil && !errors.Is(err, errStop) {
+		return err
+	}
+	return nil
+}
+
+// streamer selects nodes from a JSON token stream. It tracks the progress
+// through the query for each value as a list of states, each of which is the
+// index of the next segment to apply to the value. A value with a state equal
+// to the number of segments has been selected. States may repeat, as a value
+// may be reachable through more than one selector or descendant segment.
+type streamer struct {
+	dec    *json.Decoder
+	segs   []*Segment
+	direct []bool
+	yield  func(NormalizedPath, any) bool
+}
+
+// value reads the next value from the stream and selects from it according
+// to states.
+func (s *streamer) value(path NormalizedPath, states []int) error {
+	if len(states) == 0 {
+		return s.skip()
+	}
+
+	if s.mustDecode(states) {
+		var val any
+		if err := s.dec.Decode(&val); err != nil {
+			//nolint:wrapcheck
+			return err
+		}
+		return s.emit(path, states, val)
+	}
+
+	tok, err := s.dec.Token()
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for s.dec.More() {
+			tok, err := s.dec.Token()
+			if err != nil {
+				//nolint:wrapcheck
+				return err
+			}
+			key := Name(tok.(string))
+			if err := s.value(append(path, key), s.next(states, key)); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for i := 0; s.dec.More(); i++ {
+			if err := s.value(append(path, Index(i)), s.next(states, Index(i))); err != nil {
+				return err
+			}
+		}
+	default:
+		// Scalars have no children to select.
+		return nil
+	}
+
+	// Consume the closing delimiter.
+	_, err = s.dec.Token()
+	//nolint:wrapcheck
+	return err
+}
+
+// mustDecode returns true if any of states selects the current value or
+// requires it to be decoded in order to select from it.
+func (s *streamer) mustDecode(states []int) bool {
+	for _, st := range states {
+		if st == len(s.segs) || !s.direct[st] {
+			return true
+		}
+	}
+	return false
+}
+
+// emit yields val if it has been selected and selects from it in memory for
+// all other states.
+func (s *streamer) emit(path NormalizedPath, states []int, val any) error {
+	for _, st := range states {
+		if st == len(s.segs) {
+			if !s.yield(append(make(NormalizedPath, 0, len(path)), path...), val) {
+				return errStop
+			}
+			continue
+		}
+		q := &PathQuery{segments: s.segs[st:]}
+		for _, node := range q.SelectLocated(val, nil, path) {
+			if !s.yield(node.Path, node.Node) {
+				return errStop
+			}
+		}
+	}
+	return nil
+}
+
+// next returns the states for the child of a value identified by key, given
+// the value's states.
+func (s *streamer) next(states []int, key NormalSelector) []int {
+	next := make([]int, 0, len(states))
+	for _, st := range states {
+		seg := s.segs[st]
+		for _, sel := range seg.selectors {
+			if selectsKey(sel, key) {
+				next = append(next, st+1)
+			}
+		}
+		if seg.descendant {
+			next = append(next, st)
+		}
+	}
+	return next
+}
+
+// skip reads and discards the next value from the stream.
+func (s *streamer) skip() error {
+	depth := 0
+	for {
+		tok, err := s.dec.Token()
+		if err != nil {
+			//nolint:wrapcheck
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// selectsByKey returns true if all of s's selectors select children solely
+// by their names or indexes, without needing to know the values of the
+// children or the length of an array.
+func (s *Segment) selectsByKey() bool {
+	for _, sel := range s.selectors {
+		switch sel := sel.(type) {
+		case Name, WildcardSelector:
+		case Index:
+			if sel < 0 {
+				return false
+			}
+		case SliceSelector:
+			if sel.step <= 0 || sel.start < 0 || sel.end < 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// selectsKey returns true if sel selects the child identified by key. sel
+// must be a selector for which [Segment.selectsByKey] returns true.
+func selectsKey(sel Selector, key NormalSelector) bool {
+	switch sel := sel.(type) {
+	case Name:
+		k, ok := key.(Name)
+		return ok && k == sel
+	case Index:
+		k, ok := key.(Index)
+		return ok && k == sel
+	case SliceSelector:
+		k, ok := key.(Index)
+		if !ok {
+			return false
+		}
+		i := int(k)
+		return i >= sel.start && i < sel.end && (i-sel.start)%sel.step == 0
+	case WildcardSelector:
+		return true
+	default:
+		return false
+	}
+}
+
+// usesRoot returns true if any of the filter expressions in segs contains a
+// query against the root node ($).
+func usesRoot(segs []*Segment) bool {
+	for _, seg := range segs {
+		for _, sel := range seg.selectors {
+			if f, ok := sel.(*FilterSelector); ok && exprUsesRoot(f.LogicalOr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exprUsesRoot returns true if expr is or contains a query against the root
+// node ($). Returns true for unknown expression types.
+//
+//nolint:gocyclo
+func exprUsesRoot(expr any) bool {
+	switch expr := expr.(type) {
+	case LogicalOr:
+		for _, and := range expr {
+			if exprUsesRoot(and) {
+				return true
+			}
+		}
+		return false
+	case LogicalAnd:
+		for _, e := range expr {
+			if exprUsesRoot(e) {
+				return true
+			}
+		}
+		return false
+	case *ParenExpr:
+		return exprUsesRoot(expr.LogicalOr)
+	case *NotParenExpr:
+		return exprUsesRoot(expr.LogicalOr)
+	case *CompExpr:
+		return exprUsesRoot(expr.left) || exprUsesRoot(expr.right)
+	case *ExistExpr:
+		return exprUsesRoot(expr.PathQuery)
+	case *NonExistExpr:
+		return exprUsesRoot(expr.PathQuery)
+	case NonExistExpr:
+		return exprUsesRoot(expr.PathQuery)
+	case *FuncExpr:
+		for _, arg := range expr.args {
+			if exprUsesRoot(arg) {
+				return true
+			}
+		}
+		return false
+	case NotFuncExpr:
+		return exprUsesRoot(expr.FuncExpr)
+	case *PathQuery:
+		return expr.root || usesRoot(expr.segments)
+	case *SingularQueryExpr:
+		return !expr.relative
+	case *LiteralArg, *ValueType:
+		return false
+	default:
+		return true
+	}
+}
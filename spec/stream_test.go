@@ -0,0 +1,240 @@
+package spec
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	doc := `{
+		"store": {
+			"book": [
+				{"title": "Sayings", "price": 8.95, "tags": ["a", "b"]},
+				{"title": "Sword", "price": 12.99, "isbn": "0-553"},
+				{"title": "Moby Dick", "price": 8.99, "tags": ["c"]}
+			],
+			"bicycle": {"color": "red", "price": 399}
+		},
+		"threshold": 9
+	}`
+
+	gt := func(left CompVal, right any) LogicalAnd {
+		return And(Comparison(left, GreaterThan, Literal(right)))
+	}
+
+	for _, tc := range []struct {
+		test string
+		segs []*Segment
+		exp  []*LocatedNode
+	}{
+		{
+			test: "root",
+			exp:  nil,
+		},
+		{
+			test: "name",
+			segs: []*Segment{Child(Name("threshold"))},
+			exp:  []*LocatedNode{{Path: Normalized(Name("threshold")), Node: float64(9)}},
+		},
+		{
+			test: "missing",
+			segs: []*Segment{Child(Name("nope")), Child(Wildcard())},
+			exp:  []*LocatedNode{},
+		},
+		{
+			test: "index",
+			segs: []*Segment{Child(Name("store")), Child(Name("book")), Child(Index(1)), Child(Name("title"))},
+			exp: []*LocatedNode{
+				{Path: Normalized(Name("store"), Name("book"), Index(1), Name("title")), Node: "Sword"},
+			},
+		},
+		{
+			test: "negative_index",
+			segs: []*Segment{Child(Name("store")), Child(Name("book")), Child(Index(-1)), Child(Name("title"))},
+			exp: []*LocatedNode{
+				{Path: Normalized(Name("store"), Name("book"), Index(2), Name("title")), Node: "Moby Dick"},
+			},
+		},
+		{
+			test: "slice",
+			segs: []*Segment{Child(Name("store")), Child(Name("book")), Child(Slice(0, nil, 2)), Child(Name("price"))},
+			exp: []*LocatedNode{
+				{Path: Normalized(Name("store"), Name("book"), Index(0), Name("price")), Node: 8.95},
+				{Path: Normalized(Name("store"), Name("book"), Index(2), Name("price")), Node: 8.99},
+			},
+		},
+		{
+			test: "descendant",
+			segs: []*Segment{Descendant(Name("tags")), Child(Wildcard())},
+			exp: []*LocatedNode{
+				{Path: Normalized(Name("store"), Name("book"), Index(0), Name("tags"), Index(0)), Node: "a"},
+				{Path: Normalized(Name("store"), Name("book"), Index(0), Name("tags"), Index(1)), Node: "b"},
+				{Path: Normalized(Name("store"), Name("book"), Index(2), Name("tags"), Index(0)), Node: "c"},
+			},
+		},
+		{
+			test: "duplicates",
+			segs: []*Segment{Child(Name("threshold"), Name("threshold"))},
+			exp: []*LocatedNode{
+				{Path: Normalized(Name("threshold")), Node: float64(9)},
+				{Path: Normalized(Name("threshold")), Node: float64(9)},
+			},
+		},
+		{
+			test: "filter",
+			segs: []*Segment{
+				Child(Name("store")), Child(Name("book")),
+				Child(Filter(gt(SingularQuery(false, Name("price")), 9))),
+				Child(Name("title")),
+			},
+			exp: []*LocatedNode{
+				{Path: Normalized(Name("store"), Name("book"), Index(1), Name("title")), Node: "Sword"},
+			},
+		},
+		{
+			test: "filter_root",
+			segs: []*Segment{
+				Child(Name("store")), Child(Name("book")),
+				Child(Filter(gt(SingularQuery(false, Name("price")), 0))),
+				Child(Filter(And(Comparison(
+					SingularQuery(false), EqualTo, SingularQuery(true, Name("store"), Name("bicycle"), Name("color")),
+				)))),
+			},
+			exp: []*LocatedNode{},
+		},
+		{
+			test: "filter_root_match",
+			segs: []*Segment{
+				Child(Name("store")), Child(Name("book")),
+				Child(Filter(And(Comparison(
+					SingularQuery(false, Name("price")), GreaterThan, SingularQuery(true, Name("threshold")),
+				)))),
+				Child(Name("price")),
+			},
+			exp: []*LocatedNode{
+				{Path: Normalized(Name("store"), Name("book"), Index(1), Name("price")), Node: 12.99},
+			},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			q := Query(true, tc.segs...)
+			var root any
+			r.NoError(json.Unmarshal([]byte(doc), &root))
+			exp := q.SelectLocated(nil, root, Normalized())
+			if tc.exp != nil {
+				a.Equal(tc.exp, exp)
+			}
+
+			res := []*LocatedNode{}
+			err := q.Stream(json.NewDecoder(strings.NewReader(doc)), func(p NormalizedPath, v any) bool {
+				res = append(res, &LocatedNode{Path: p, Node: v})
+				return true
+			})
+			r.NoError(err)
+			a.ElementsMatch(exp, res)
+		})
+	}
+}
+
+func TestStreamRootReference(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	q := Query(true, Child(Wildcard()), Child(Filter(And(Comparison(
+		SingularQuery(false), EqualTo, SingularQuery(true, Name("x")),
+	)))))
+	a.True(usesRoot(q.segments))
+
+	res := []*LocatedNode{}
+	doc := `{"x": 2, "y": [1, 2, 3]}`
+	err := q.Stream(json.NewDecoder(strings.NewReader(doc)), func(p NormalizedPath, v any) bool {
+		res = append(res, &LocatedNode{Path: p, Node: v})
+		return true
+	})
+	a.NoError(err)
+	a.Equal([]*LocatedNode{{Path: Normalized(Name("y"), Index(1)), Node: float64(2)}}, res)
+}
+
+func TestStreamStop(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	q := Query(true, Child(Wildcard()))
+	doc := `[1, 2, 3, {"x": [4, 5]}]`
+	res := []any{}
+	err := q.Stream(json.NewDecoder(strings.NewReader(doc)), func(_ NormalizedPath, v any) bool {
+		res = append(res, v)
+		return len(res) < 2
+	})
+	a.NoError(err)
+	a.Equal([]any{float64(1), float64(2)}, res)
+}
+
+func TestStreamError(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		segs []*Segment
+		doc  string
+	}{
+		{"skipped", []*Segment{Child(Name("y"))}, `{"x": [1, 2`},
+		{"selected", []*Segment{Child(Name("x"))}, `{"x": [1, 2`},
+		{"key", []*Segment{Child(Name("y"))}, `{"x": 1, 2}`},
+		{"root_reference", []*Segment{Child(Filter(And(Existence(Query(true)))))}, `[`},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			q := Query(true, tc.segs...)
+			err := q.Stream(json.NewDecoder(strings.NewReader(tc.doc)), func(NormalizedPath, any) bool {
+				return true
+			})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExprUsesRoot(t *testing.T) {
+	t.Parallel()
+	fn := Extension("f", FuncValue, nil, nil)
+
+	for _, tc := range []struct {
+		test string
+		expr any
+		exp  bool
+	}{
+		{"literal", Literal(1), false},
+		{"value", Value(1), false},
+		{"relative_singular", SingularQuery(false, Name("x")), false},
+		{"root_singular", SingularQuery(true, Name("x")), true},
+		{"relative_query", Query(false, Child(Wildcard())), false},
+		{"root_query", Query(true, Child(Wildcard())), true},
+		{"nested_filter", Query(false, Child(Filter(And(Existence(Query(true)))))), true},
+		{"exist", Existence(Query(false)), false},
+		{"non_exist", Nonexistence(Query(true)), true},
+		{"non_exist_value", *Nonexistence(Query(true)), true},
+		{"paren", Paren(And(Existence(Query(true)))), true},
+		{"not_paren", NotParen(And(Existence(Query(false)))), false},
+		{"comparison", Comparison(Literal(1), EqualTo, SingularQuery(true)), true},
+		{"function", Function(fn, Literal(1), Query(false)), false},
+		{"function_root", Function(fn, Literal(1), Query(true)), true},
+		{"not_function", NotFunction(Function(fn, Query(true))), true},
+		{"or", Or(And(Existence(Query(false))), And(Existence(Query(true)))), true},
+		{"unknown", "hi", true},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, exprUsesRoot(tc.expr))
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package spec
+
+import (
+	"maps"
+	"math"
+	"slices"
+)
+
+const (
+	// maxOpaque is the maximum number of distinct selectors whose results
+	// depend on the input, such as filters and negative indexes, that
+	// [PathQuery.Subsumes] and [PathQuery.Overlaps] compare to each other.
+	// They assume that any others may select any value.
+	maxOpaque = 6
+
+	// maxSliceIndexes is the maximum number of array indexes that
+	// [PathQuery.Subsumes] and [PathQuery.Overlaps] enumerate to compare
+	// slices. They compare slices that require more, such as those with
+	// large steps, as they do filters.
+	maxSliceIndexes = 256
+)
+
+// Subsumes returns true if q selects every node that other selects from
+// the same input, for any input. Returns false if it doesn't or if the
+// selectors of the queries do not establish that it does.
+//
+// Subsumes compares the segments and selectors of the queries rather than
+// any particular input. It treats selectors whose results depend on the
+// input, such as filters, negative indexes, and slices with negative
+// bounds, as selecting unknown values, so that q subsumes other only if
+// each such selector in other corresponds to the same selector or a
+// broader one, such as a wildcard, in q. It therefore finds that
+// $.a[*] subsumes $.a[?@.b] and that $..a subsumes $.x[?@.b].a, but not that
+// $[?@.b > 0] subsumes $[?@.b > 1].
+//
+// Useful for access control, for example to determine whether a
+// JSONPath query granting access to fields covers a query requesting them.
+func (q *PathQuery) Subsumes(other *PathQuery) bool {
+	c := newComparer(q, other)
+	return !c.search(
+		true,
+		func(a, b states) bool { return b.accepts() && !a.accepts() },
+		func(_, b states) bool { return b.any() },
+	)
+}
+
+// Overlaps returns true if q and other can select the same node from some
+// input. Returns false only if they never do. Like [PathQuery.Subsumes],
+// Overlaps compares the segments and selectors of the queries, assuming
+// that selectors whose results depend on the input, such as filters, may
+// select any value they could select from some input.
+func (q *PathQuery) Overlaps(other *PathQuery) bool {
+	c := newComparer(q, other)
+	return c.search(
+		false,
+		func(a, b states) bool { return a.accepts() && b.accepts() },
+		func(a, b states) bool { return a.any() && b.any() },
+	)
+}
+
+// pathLabel is a step in the path from the value a query selects from to a
+// node: an object member name or an array index. It models all the names
+// that no [Name] selector selects with other, and the results of selectors
+// that depend on the input with opaque, a bit set of the selectors that
+// select the node.
+type pathLabel struct {
+	name    string
+	index   int
+	isIndex bool
+	other   bool
+	opaque  uint
+}
+
+// states is the set of states of the automaton for a query after matching
+// the labels of a path: one byte for each number of segments matched,
+// set to 1 if the segments may have matched the path.
+type states string
+
+// accepts returns true if all segments in s have matched, so that the
+// query selects the node at the end of the path.
+func (s states) accepts() bool { return s[len(s)-1] == 1 }
+
+// any returns true if any state in s is set.
+func (s states) any() bool {
+	for i := range len(s) {
+		if s[i] == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// comparer compares the nodes that two queries select for
+// [PathQuery.Subsumes] and [PathQuery.Overlaps]. It models each query as a
+// nondeterministic automaton that accepts the labels of the paths to the
+// nodes it selects, and searches the product of the two automata for the
+// labels of a representative set of paths.
+type comparer struct {
+	a, b        *PathQuery
+	opaque      map[string]uint
+	exactSlices bool
+	labels      []pathLabel
+}
+
+// newComparer creates a comparer for a and b.
+func newComparer(a, b *PathQuery) *comparer {
+	c := &comparer{a: a, b: b, opaque: map[string]uint{}, exactSlices: true}
+	names := map[string]struct{}{}
+	indexes := map[int]struct{}{}
+	var opaque []Selector
+	var ranges []SliceSelector
+	for _, q := range []*PathQuery{a, b} {
+		for _, seg := range q.segments {
+			for _, sel := range seg.selectors {
+				switch sel := sel.(type) {
+				case Name:
+					names[string(sel)] = struct{}{}
+				case Index:
+					if sel >= 0 {
+						indexes[int(sel)] = struct{}{}
+					} else {
+						opaque = append(opaque, sel)
+					}
+				case SliceSelector:
+					if exactSlice(sel) {
+						ranges = append(ranges, sel)
+					} else if sel.step != 0 {
+						opaque = append(opaque, sel)
+					}
+				case *FilterSelector:
+					opaque = append(opaque, sel)
+				}
+			}
+		}
+	}
+
+	idxs, ok := indexLabels(indexes, ranges)
+	if !ok {
+		c.exactSlices = false
+		for _, s := range ranges {
+			opaque = append(opaque, s)
+		}
+		idxs, _ = indexLabels(indexes, nil)
+	}
+
+	for _, sel := range opaque {
+		key := sel.String()
+		if _, ok := c.opaque[key]; !ok && len(c.opaque) < maxOpaque {
+			c.opaque[key] = uint(len(c.opaque))
+		}
+	}
+
+	// Collect a label for each name, for all other names, and for each
+	// group of indexes that the selectors cannot distinguish.
+	base := make([]pathLabel, 0, len(names)+1+len(idxs))
+	for name := range names {
+		base = append(base, pathLabel{name: name})
+	}
+	base = append(base, pathLabel{other: true})
+	for _, idx := range idxs {
+		base = append(base, pathLabel{index: idx, isIndex: true})
+	}
+
+	for bits := range uint(1) << len(c.opaque) {
+		for _, l := range base {
+			l.opaque = bits
+			c.labels = append(c.labels, l)
+		}
+	}
+	return c
+}
+
+// indexLabels returns an array index for each group of indexes that
+// indexes and ranges cannot distinguish. The bounds of the selectors divide
+// indexes into intervals, in which indexes with the same remainder modulo
+// the steps of the slices select the same way. Returns false if ranges
+// require more than maxSliceIndexes labels.
+func indexLabels(indexes map[int]struct{}, ranges []SliceSelector) ([]int, bool) {
+	points := map[int]struct{}{0: {}}
+	for idx := range indexes {
+		points[idx] = struct{}{}
+		if idx < math.MaxInt {
+			points[idx+1] = struct{}{}
+		}
+	}
+	period := 1
+	for _, s := range ranges {
+		if s.step > maxSliceIndexes {
+			return nil, false
+		}
+		if period = lcm(period, s.step); period > maxSliceIndexes {
+			return nil, false
+		}
+		points[s.start] = struct{}{}
+		points[s.end] = struct{}{}
+	}
+
+	bounds := slices.Sorted(maps.Keys(points))
+	var res []int
+	for i, lo := range bounds {
+		hi := math.MaxInt
+		if i+1 < len(bounds) {
+			hi = bounds[i+1]
+		}
+		for idx := lo; idx < hi && idx-lo < period; idx++ {
+			res = append(res, idx)
+		}
+		if len(ranges) > 0 && len(res) > maxSliceIndexes {
+			return nil, false
+		}
+	}
+	return res, true
+}
+
+// exactSlice returns true if the indexes that s selects do not depend on
+// the length of the array.
+func exactSlice(s SliceSelector) bool {
+	return s.step > 0 && s.start >= 0 && s.end >= 0
+}
+
+// lcm returns the least common multiple of positive integers a and b.
+func lcm(a, b int) int {
+	x, y := a, b
+	for y != 0 {
+		x, y = y, x%y
+	}
+	return a / x * b
+}
+
+// search searches the product of the automata for a and b for a pair of
+// states for which found returns true, following only pairs for which
+// alive returns true. If must is true, the automaton for a follows a label
+// only for selectors that certainly select it; otherwise both automata
+// follow labels for selectors that might select them.
+func (c *comparer) search(must bool, found, alive func(a, b states) bool) bool {
+	type pair struct{ a, b states }
+	start := pair{initial(c.a), initial(c.b)}
+	seen := map[pair]struct{}{start: {}}
+	queue := []pair{start}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if found(p.a, p.b) {
+			return true
+		}
+		for _, l := range c.labels {
+			next := pair{c.step(c.a, p.a, l, must), c.step(c.b, p.b, l, false)}
+			if _, ok := seen[next]; ok || !alive(next.a, next.b) {
+				continue
+			}
+			seen[next] = struct{}{}
+			queue = append(queue, next)
+		}
+	}
+	return false
+}
+
+// initial returns the initial states of the automaton for q, in which no
+// segments have matched.
+func initial(q *PathQuery) states {
+	s := make([]byte, len(q.segments)+1)
+	s[0] = 1
+	return states(s)
+}
+
+// step returns the states of the automaton for q after following label l
+// from states s. A child segment matches a label that one of its selectors
+// selects, while a descendant segment also skips any label.
+func (c *comparer) step(q *PathQuery, s states, l pathLabel, must bool) states {
+	next := make([]byte, len(s))
+	for i, seg := range q.segments {
+		if s[i] == 0 {
+			continue
+		}
+		if seg.descendant {
+			next[i] = 1
+		}
+		if slices.ContainsFunc(seg.selectors, func(sel Selector) bool {
+			return c.selects(sel, l, must)
+		}) {
+			next[i+1] = 1
+		}
+	}
+	return states(next)
+}
+
+// selects returns true if sel selects the node at label l. For selectors
+// whose results depend on the input and that c tracks, returns true if l
+// models nodes that sel selects. For those c does not track, returns true
+// if sel might select l, unless must is true.
+func (c *comparer) selects(sel Selector, l pathLabel, must bool) bool {
+	switch sel := sel.(type) {
+	case Name:
+		return !l.isIndex && !l.other && l.name == string(sel)
+	case Index:
+		if sel >= 0 {
+			return l.isIndex && l.index == int(sel)
+		}
+	case WildcardSelector:
+		return true
+	case SliceSelector:
+		if sel.step == 0 {
+			return false
+		}
+		if c.exactSlices && exactSlice(sel) {
+			return l.isIndex && l.index >= sel.start && l.index < sel.end &&
+				(l.index-sel.start)%sel.step == 0
+		}
+	}
+
+	// Only filters select object members.
+	if _, ok := sel.(*FilterSelector); !ok && !l.isIndex {
+		return false
+	}
+	if bit, ok := c.opaque[sel.String()]; ok {
+		return l.opaque&(1<<bit) != 0
+	}
+	return !must
+}
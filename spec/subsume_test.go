@@ -0,0 +1,111 @@
+package spec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubsumes(t *testing.T) {
+	t.Parallel()
+
+	filter := func(name string) *FilterSelector {
+		return Filter(And(Existence(Query(false, Child(Name(name))))))
+	}
+	filters := make([]Selector, maxOpaque+2)
+	for i := range filters {
+		filters[i] = filter(string(rune('a' + i)))
+	}
+
+	for _, tc := range []struct {
+		test     string
+		query    *PathQuery
+		other    *PathQuery
+		subsumes bool
+		overlaps bool
+	}{
+		{
+			test:     "relative",
+			query:    Query(false, Child(Wildcard())),
+			other:    Query(true, Child(Name("a"))),
+			subsumes: true,
+			overlaps: true,
+		},
+		{
+			test:     "empty_segment",
+			query:    Query(true, Child()),
+			other:    Query(true, Child(Name("a"))),
+			subsumes: false,
+			overlaps: false,
+		},
+		{
+			test:     "tracked_filters",
+			query:    Query(true, Child(filters[:maxOpaque]...)),
+			other:    Query(true, Child(filters[maxOpaque-1])),
+			subsumes: true,
+			overlaps: true,
+		},
+		{
+			test:     "untracked_filter",
+			query:    Query(true, Child(filters...)),
+			other:    Query(true, Child(filters[maxOpaque+1])),
+			subsumes: false,
+			overlaps: true,
+		},
+		{
+			test:     "untracked_filter_name",
+			query:    Query(true, Child(filters...)),
+			other:    Query(true, Child(Name("x"))),
+			subsumes: false,
+			overlaps: true,
+		},
+		{
+			test:     "max_index",
+			query:    Query(true, Child(Slice(0))),
+			other:    Query(true, Child(Index(math.MaxInt))),
+			subsumes: true,
+			overlaps: false,
+		},
+		{
+			test:     "many_slice_indexes",
+			query:    Query(true, Child(Slice(0, nil, 7), Slice(3, nil, 11), Slice(5, nil, 13))),
+			other:    Query(true, Child(Index(1))),
+			subsumes: false,
+			overlaps: true,
+		},
+		{
+			test:     "large_slice_step",
+			query:    Query(true, Child(Slice(0, nil, maxSliceIndexes+1))),
+			other:    Query(true, Child(Index(maxSliceIndexes+1))),
+			subsumes: false,
+			overlaps: true,
+		},
+		{
+			test:     "same_large_slice_step",
+			query:    Query(true, Child(Slice(0, nil, maxSliceIndexes+1))),
+			other:    Query(true, Child(Slice(0, nil, maxSliceIndexes+1))),
+			subsumes: true,
+			overlaps: true,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			a.Equal(tc.subsumes, tc.query.Subsumes(tc.other), "subsumes")
+			a.Equal(tc.overlaps, tc.query.Overlaps(tc.other), "overlaps")
+			a.Equal(tc.overlaps, tc.other.Overlaps(tc.query), "overlaps reversed")
+		})
+	}
+}
+
+func TestLCM(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal(1, lcm(1, 1))
+	a.Equal(6, lcm(2, 3))
+	a.Equal(12, lcm(4, 6))
+	a.Equal(7, lcm(7, 7))
+}
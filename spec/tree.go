@@ -0,0 +1,188 @@
+package spec
+
+import "slices"
+
+// Branch returns a copy of s with next appended to its branches, the
+// segments that select from the values s selects. Segments with branches
+// form a tree in which segments that share a prefix select from it only
+// once. Use [Segment.SelectTree] or [Segment.SelectLocatedTree] to evaluate
+// such a tree. [PathQuery] ignores branches; [QuerySet] merges its queries
+// into trees of segments.
+func (s *Segment) Branch(next ...*Segment) *Segment {
+	seg := *s
+	seg.branches = append(slices.Clip(s.branches), next...)
+	return &seg
+}
+
+// Branches returns the segments that select from the values s selects.
+func (s *Segment) Branches() []*Segment {
+	return s.branches
+}
+
+// SelectTree selects values from current or root with s and passes them to
+// visit, then selects from those values with each of s's branches,
+// recursively, passing the results of each segment in the tree to visit.
+// Each branch selects the same values as it would following s in a
+// [PathQuery]. visit must not modify the values it receives.
+func (s *Segment) SelectTree(current, root any, visit func(seg *Segment, vals []any)) {
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	selectBranches([]*Segment{s}, []any{current}, ev, visit)
+}
+
+// SelectLocatedTree selects values from current or root with s as
+// [LocatedNode] values and passes them to visit, then selects from those
+// values with each of s's branches, recursively, passing the results of
+// each segment in the tree to visit. Each branch selects the same values as
+// it would following s in a [PathQuery]. visit must not modify the values
+// it receives.
+func (s *Segment) SelectLocatedTree(current, root any, parent NormalizedPath, visit func(seg *Segment, nodes []*LocatedNode)) {
+	ev := &evaluation{root: root}
+	defer ev.dropAbort()
+	selectLocatedBranches(
+		[]*Segment{s}, []*LocatedNode{newLocatedNode(parent, current)},
+		ev, visit,
+	)
+}
+
+// mergeBranch merges segs into branches, reusing existing segments equal to
+// each of segs rather than adding them. Returns the updated branches and the
+// segment in the tree for the last of segs, which must not be empty. Copies
+// segs rather than adding branches to them.
+func mergeBranch(branches, segs []*Segment) ([]*Segment, *Segment) {
+	var seg *Segment
+	str := segs[0].String()
+	for _, b := range branches {
+		if b.String() == str {
+			seg = b
+			break
+		}
+	}
+	if seg == nil {
+		seg = &Segment{
+			selectors:  segs[0].selectors,
+			descendant: segs[0].descendant,
+			names:      segs[0].names,
+		}
+		branches = append(branches, seg)
+	}
+
+	if len(segs) == 1 {
+		return branches, seg
+	}
+	var leaf *Segment
+	seg.branches, leaf = mergeBranch(seg.branches, segs[1:])
+	return branches, leaf
+}
+
+// selectBranches selects from vals with each segment in branches, passes
+// the results to visit, and then selects from them with the segment's own
+// branches. Descendant segments that select from the same values visit each
+// descendant only once.
+func selectBranches(branches []*Segment, vals []any, ev *evaluation, visit func(*Segment, []any)) {
+	var descendants []*Segment
+	for _, seg := range branches {
+		if seg.descendant {
+			descendants = append(descendants, seg)
+			continue
+		}
+		next := make([]any, 0, len(vals))
+		for _, v := range vals {
+			next = seg.appendFrom(next, v, ev)
+		}
+		visit(seg, next)
+		selectBranches(seg.branches, next, ev, visit)
+	}
+
+	switch len(descendants) {
+	case 0:
+		return
+	case 1:
+		seg := descendants[0]
+		next := make([]any, 0, len(vals))
+		for _, v := range vals {
+			next = descend(next, v, ev, seg)
+		}
+		visit(seg, next)
+		selectBranches(seg.branches, next, ev, visit)
+		return
+	}
+
+	// Visit each descendant once, appending the values selected by each
+	// segment to its own slice. The slices are not safe to append to
+	// concurrently, so disable parallelism.
+	sev := ev.serial()
+	nexts := make([][]any, len(descendants))
+	for i := range nexts {
+		nexts[i] = make([]any, 0, len(vals))
+	}
+	step := stepFunc(func(ev *evaluation, node any, dst []any) []any {
+		for i, seg := range descendants {
+			nexts[i] = seg.appendSelected(ev, node, nexts[i])
+		}
+		return dst
+	})
+	for _, v := range vals {
+		descend(nil, v, sev, step)
+	}
+	for i, seg := range descendants {
+		visit(seg, nexts[i])
+		selectBranches(seg.branches, nexts[i], ev, visit)
+	}
+}
+
+// selectLocatedBranches selects [LocatedNode] values from vals with each
+// segment in branches, passes the results to visit, and then selects from
+// them with the segment's own branches. Descendant segments that select
+// from the same values visit each descendant only once.
+func selectLocatedBranches(branches []*Segment, vals []*LocatedNode, ev *evaluation, visit func(*Segment, []*LocatedNode)) {
+	var descendants []*Segment
+	for _, seg := range branches {
+		if seg.descendant {
+			descendants = append(descendants, seg)
+			continue
+		}
+		next := make([]*LocatedNode, 0, len(vals))
+		for _, v := range vals {
+			next = seg.appendLocatedFrom(next, v.Node, ev, v.Path)
+		}
+		visit(seg, next)
+		selectLocatedBranches(seg.branches, next, ev, visit)
+	}
+
+	switch len(descendants) {
+	case 0:
+		return
+	case 1:
+		seg := descendants[0]
+		next := make([]*LocatedNode, 0, len(vals))
+		for _, v := range vals {
+			next = seg.descendLocated(next, v.Node, ev, v.Path)
+		}
+		visit(seg, next)
+		selectLocatedBranches(seg.branches, next, ev, visit)
+		return
+	}
+
+	// Visit each descendant once, appending the values selected by each
+	// segment to its own slice. The slices are not safe to append to
+	// concurrently, so disable parallelism.
+	sev := ev.serial()
+	nexts := make([][]*LocatedNode, len(descendants))
+	for i := range nexts {
+		nexts[i] = make([]*LocatedNode, 0, len(vals))
+	}
+	step := func(dst []*LocatedNode, node any, ev *evaluation, path NormalizedPath) []*LocatedNode {
+		for i, seg := range descendants {
+			nexts[i] = seg.appendLocatedSelected(nexts[i], node, ev, path)
+		}
+		return dst
+	}
+	for _, v := range vals {
+		descendLocated(nil, v.Node, 0, sev, v.Path, step)
+	}
+	for i, seg := range descendants {
+		visit(seg, nexts[i])
+		selectLocatedBranches(seg.branches, nexts[i], ev, visit)
+	}
+}
@@ -0,0 +1,113 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentBranch(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	x, y := Child(Name("x")), Descendant(Name("y"))
+	seg := Child(Name("a"))
+	a.Nil(seg.Branches())
+
+	tree := seg.Branch(x)
+	a.Nil(seg.Branches())
+	a.Equal([]*Segment{x}, tree.Branches())
+	a.Equal(seg.Selectors(), tree.Selectors())
+	a.Equal(seg.String(), tree.String())
+
+	tree2 := tree.Branch(y)
+	a.Equal([]*Segment{x}, tree.Branches())
+	a.Equal([]*Segment{x, y}, tree2.Branches())
+}
+
+func TestSegmentSelectTree(t *testing.T) {
+	t.Parallel()
+
+	input := pairs{
+		{"a", pairs{
+			{"b", []any{pairs{{"x", 1}, {"y", 2}}, pairs{{"x", 3}}}},
+			{"c", pairs{{"x", 4}, {"y", []any{5, 6}}}},
+		}},
+		{"x", 7},
+	}
+
+	for _, tc := range []struct {
+		test string
+		tree *Segment
+		// exp lists the query from each segment in the tree, in the order
+		// the tree visits them.
+		exp []*PathQuery
+	}{
+		{
+			test: "leaf",
+			tree: Child(Name("a")),
+			exp:  []*PathQuery{Query(false, Child(Name("a")))},
+		},
+		{
+			test: "shared_prefix",
+			tree: Child(Name("a")).Branch(
+				Child(Name("b")).Branch(Child(Index(0)), Child(Index(1))),
+				Child(Name("c")).Branch(Child(Name("y"))),
+			),
+			exp: []*PathQuery{
+				Query(false, Child(Name("a"))),
+				Query(false, Child(Name("a")), Child(Name("b"))),
+				Query(false, Child(Name("a")), Child(Name("b")), Child(Index(0))),
+				Query(false, Child(Name("a")), Child(Name("b")), Child(Index(1))),
+				Query(false, Child(Name("a")), Child(Name("c"))),
+				Query(false, Child(Name("a")), Child(Name("c")), Child(Name("y"))),
+			},
+		},
+		{
+			test: "descendants",
+			tree: Child(Name("a")).Branch(
+				Descendant(Name("x")),
+				Child(Wildcard()),
+				Descendant(Name("y")).Branch(Child(Index(-1))),
+			),
+			exp: []*PathQuery{
+				Query(false, Child(Name("a"))),
+				Query(false, Child(Name("a")), Child(Wildcard())),
+				Query(false, Child(Name("a")), Descendant(Name("x"))),
+				Query(false, Child(Name("a")), Descendant(Name("y"))),
+				Query(false, Child(Name("a")), Descendant(Name("y")), Child(Index(-1))),
+			},
+		},
+		{
+			test: "single_descendant",
+			tree: Descendant(Name("x")).Branch(Descendant(Name("y"))),
+			exp: []*PathQuery{
+				Query(false, Descendant(Name("x"))),
+				Query(false, Descendant(Name("x")), Descendant(Name("y"))),
+			},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			i := 0
+			tc.tree.SelectTree(input, nil, func(seg *Segment, vals []any) {
+				q := tc.exp[i]
+				a.Equal(q.segments[len(q.segments)-1].String(), seg.String())
+				a.Equal(q.Select(input, nil), vals, q.String())
+				i++
+			})
+			a.Equal(len(tc.exp), i)
+
+			i = 0
+			tc.tree.SelectLocatedTree(input, nil, Normalized(), func(seg *Segment, nodes []*LocatedNode) {
+				q := tc.exp[i]
+				a.Equal(q.segments[len(q.segments)-1].String(), seg.String())
+				a.Equal(q.SelectLocated(input, nil, Normalized()), nodes, q.String())
+				i++
+			})
+			a.Equal(len(tc.exp), i)
+		})
+	}
+}
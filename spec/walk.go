@@ -0,0 +1,104 @@
+package spec
+
+// Visitor defines the interface for visiting the nodes of the abstract
+// syntax tree of a [PathQuery] with [Walk]. The nodes are values of the
+// following types:
+//
+//   - [*PathQuery]: a query, including the queries of [ExistExpr] and
+//     [NonExistExpr] expressions and of function arguments
+//   - [*Segment]: a child or descendant segment
+//   - [Selector]: a [Name], [Index], [SliceSelector], [WildcardSelector],
+//     or [*FilterSelector]
+//   - [LogicalOr] and [LogicalAnd]: the logical expressions of filter
+//     selectors and parenthesized expressions
+//   - [*ParenExpr], [*NotParenExpr]: parenthesized expressions
+//   - [*ExistExpr], [*NonExistExpr]: existence tests
+//   - [*CompExpr]: comparisons
+//   - [*FuncExpr], [NotFuncExpr]: function expressions
+//   - [*SingularQueryExpr]: singular queries in comparisons and function
+//     arguments
+//   - [*LiteralArg]: literal values in comparisons and function arguments
+type Visitor interface {
+	// Enter is called for node before its children. Return false to skip
+	// the children of node.
+	Enter(node any) bool
+
+	// Exit is called for node after its children, or after Enter if
+	// Enter returns false.
+	Exit(node any)
+}
+
+// Walk traverses the abstract syntax tree of q in depth-first order,
+// calling visitor.Enter for each node before its children and
+// visitor.Exit after them. The children of a [*PathQuery] are its
+// segments; of a [*Segment], its selectors; of a [*FilterSelector], its
+// [LogicalOr]; of a LogicalOr, its [LogicalAnd] expressions; of a
+// LogicalAnd, its [BasicExpr] expressions; of a [*ParenExpr] or
+// [*NotParenExpr], its LogicalOr; of an [*ExistExpr] or [*NonExistExpr],
+// its PathQuery; of a [*CompExpr], its left and right operands; of a
+// [*FuncExpr] or [NotFuncExpr], its arguments; and of a
+// [*SingularQueryExpr], its selectors. Use to analyze queries, for example
+// to reject descendant segments or to estimate their cost.
+func Walk(q *PathQuery, visitor Visitor) {
+	walk(q, visitor)
+}
+
+// walk visits node and its children with visitor.
+func walk(node any, visitor Visitor) {
+	if visitor.Enter(node) {
+		walkChildren(node, visitor)
+	}
+	visitor.Exit(node)
+}
+
+// walkChildren visits the children of node with visitor.
+func walkChildren(node any, visitor Visitor) {
+	switch n := node.(type) {
+	case *PathQuery:
+		for _, seg := range n.segments {
+			walk(seg, visitor)
+		}
+	case *Segment:
+		for _, sel := range n.selectors {
+			walk(sel, visitor)
+		}
+	case *FilterSelector:
+		walk(n.LogicalOr, visitor)
+	case LogicalOr:
+		for _, and := range n {
+			walk(and, visitor)
+		}
+	case LogicalAnd:
+		for _, expr := range n {
+			walk(expr, visitor)
+		}
+	case *ParenExpr:
+		walk(n.LogicalOr, visitor)
+	case *NotParenExpr:
+		walk(n.LogicalOr, visitor)
+	case *ExistExpr:
+		walk(n.PathQuery, visitor)
+	case *NonExistExpr:
+		walk(n.PathQuery, visitor)
+	case NonExistExpr:
+		walk(n.PathQuery, visitor)
+	case *CompExpr:
+		walk(n.left, visitor)
+		walk(n.right, visitor)
+	case *FuncExpr:
+		walkArgs(n.args, visitor)
+	case NotFuncExpr:
+		walkArgs(n.args, visitor)
+	case *SingularQueryExpr:
+		for _, sel := range n.selectors {
+			walk(sel, visitor)
+		}
+	}
+}
+
+// walkArgs visits the function arguments in args with visitor.
+func walkArgs(args []FuncExprArg, visitor Visitor) {
+	for _, arg := range args {
+		walk(arg, visitor)
+	}
+}
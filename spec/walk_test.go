@@ -0,0 +1,179 @@
+package spec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is a [Visitor] that records the nodes it visits and skips the
+// children of nodes for which skip returns true.
+type recorder struct {
+	events []string
+	skip   func(node any) bool
+}
+
+func (r *recorder) Enter(node any) bool {
+	r.events = append(r.events, fmt.Sprintf("enter %T %v", node, nodeString(node)))
+	return r.skip == nil || !r.skip(node)
+}
+
+func (r *recorder) Exit(node any) {
+	r.events = append(r.events, fmt.Sprintf("exit %T", node))
+}
+
+// nodeString returns the string representation of node.
+func nodeString(node any) string {
+	if sw, ok := node.(stringWriter); ok {
+		return bufString(sw)
+	}
+	return fmt.Sprint(node)
+}
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+
+	fn := newValueFunc(1)
+	// $.a[0,?@.x > 1 || (!exists(@.y) && __val(@.z) == "a")]..*
+	q := Query(
+		true,
+		Child(Name("a")),
+		Child(
+			Index(0),
+			Filter(
+				And(Comparison(SingularQuery(false, Name("x")), GreaterThan, Literal(1))),
+				And(NotParen(
+					And(
+						Nonexistence(Query(false, Child(Name("y")))),
+						Comparison(Function(fn, Query(false, Child(Name("z")))), EqualTo, Literal("a")),
+					),
+				)),
+			),
+		),
+		Descendant(Wildcard()),
+	)
+
+	r := &recorder{}
+	Walk(q, r)
+	assert.Equal(t, []string{
+		`enter *spec.PathQuery $["a"][0,?@["x"] > 1 || !(!@["y"] && __val(@["z"]) == "a")]..[*]`,
+		`enter *spec.Segment ["a"]`,
+		`enter spec.Name "a"`,
+		`exit spec.Name`,
+		`exit *spec.Segment`,
+		`enter *spec.Segment [0,?@["x"] > 1 || !(!@["y"] && __val(@["z"]) == "a")]`,
+		`enter spec.Index 0`,
+		`exit spec.Index`,
+		`enter *spec.FilterSelector ?@["x"] > 1 || !(!@["y"] && __val(@["z"]) == "a")`,
+		`enter spec.LogicalOr @["x"] > 1 || !(!@["y"] && __val(@["z"]) == "a")`,
+		`enter spec.LogicalAnd @["x"] > 1`,
+		`enter *spec.CompExpr @["x"] > 1`,
+		`enter *spec.SingularQueryExpr @["x"]`,
+		`enter spec.Name "x"`,
+		`exit spec.Name`,
+		`exit *spec.SingularQueryExpr`,
+		`enter *spec.LiteralArg 1`,
+		`exit *spec.LiteralArg`,
+		`exit *spec.CompExpr`,
+		`exit spec.LogicalAnd`,
+		`enter spec.LogicalAnd !(!@["y"] && __val(@["z"]) == "a")`,
+		`enter *spec.NotParenExpr !(!@["y"] && __val(@["z"]) == "a")`,
+		`enter spec.LogicalOr !@["y"] && __val(@["z"]) == "a"`,
+		`enter spec.LogicalAnd !@["y"] && __val(@["z"]) == "a"`,
+		`enter *spec.NonExistExpr !@["y"]`,
+		`enter *spec.PathQuery @["y"]`,
+		`enter *spec.Segment ["y"]`,
+		`enter spec.Name "y"`,
+		`exit spec.Name`,
+		`exit *spec.Segment`,
+		`exit *spec.PathQuery`,
+		`exit *spec.NonExistExpr`,
+		`enter *spec.CompExpr __val(@["z"]) == "a"`,
+		`enter *spec.FuncExpr __val(@["z"])`,
+		`enter *spec.PathQuery @["z"]`,
+		`enter *spec.Segment ["z"]`,
+		`enter spec.Name "z"`,
+		`exit spec.Name`,
+		`exit *spec.Segment`,
+		`exit *spec.PathQuery`,
+		`exit *spec.FuncExpr`,
+		`enter *spec.LiteralArg "a"`,
+		`exit *spec.LiteralArg`,
+		`exit *spec.CompExpr`,
+		`exit spec.LogicalAnd`,
+		`exit spec.LogicalOr`,
+		`exit *spec.NotParenExpr`,
+		`exit spec.LogicalAnd`,
+		`exit spec.LogicalOr`,
+		`exit *spec.FilterSelector`,
+		`exit *spec.Segment`,
+		`enter *spec.Segment ..[*]`,
+		`enter spec.WildcardSelector *`,
+		`exit spec.WildcardSelector`,
+		`exit *spec.Segment`,
+		`exit *spec.PathQuery`,
+	}, r.events)
+
+	// Should skip children.
+	r = &recorder{skip: func(node any) bool {
+		_, ok := node.(*FilterSelector)
+		return ok
+	}}
+	Walk(q, r)
+	assert.Len(t, r.events, 16)
+	assert.Equal(t, `enter *spec.FilterSelector ?@["x"] > 1 || !(!@["y"] && __val(@["z"]) == "a")`, r.events[8])
+	assert.Equal(t, `exit *spec.FilterSelector`, r.events[9])
+}
+
+func TestWalkExpressions(t *testing.T) {
+	t.Parallel()
+
+	fn := newTrueFunc()
+	for _, tc := range []struct {
+		test string
+		expr BasicExpr
+		exp  []string
+	}{
+		{
+			test: "paren",
+			expr: Paren(And(Existence(Query(false)))),
+			exp: []string{
+				"*spec.ParenExpr", "spec.LogicalOr", "spec.LogicalAnd",
+				"*spec.ExistExpr", "*spec.PathQuery",
+			},
+		},
+		{
+			test: "non_exist_value",
+			expr: NonExistExpr{Query(false)},
+			exp:  []string{"spec.NonExistExpr", "*spec.PathQuery"},
+		},
+		{
+			test: "not_func",
+			expr: NotFunction(Function(fn, SingularQuery(true, Index(0)), Literal(nil))),
+			exp: []string{
+				"spec.NotFuncExpr", "*spec.SingularQueryExpr", "spec.Index", "*spec.LiteralArg",
+			},
+		},
+		{
+			test: "func_logical_arg",
+			expr: Function(fn, LogicalOr{And(Existence(Query(false)))}),
+			exp: []string{
+				"*spec.FuncExpr", "spec.LogicalOr", "spec.LogicalAnd",
+				"*spec.ExistExpr", "*spec.PathQuery",
+			},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+
+			var types []string
+			v := &recorder{skip: func(node any) bool {
+				types = append(types, fmt.Sprintf("%T", node))
+				return false
+			}}
+			Walk(Query(true, Child(Filter(And(tc.expr)))), v)
+			assert.Equal(t, tc.exp, types[5:])
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// Package sqljsonpath translates RFC 9535 JSONPath queries into the JSON
+// path expressions used by the JSON_EXTRACT() and related functions of
+// [MySQL] and [SQLite], such as $.a[0].b, so that applications can push
+// simple queries down to the database. Use [ToMySQL] or [ToSQLite] to
+// translate a path.
+//
+// The translation is best-effort: these dialects lack filter expressions
+// and RFC 9535 descendant and wildcard semantics, so the translators return
+// an [ErrUnsupported] error for paths that use them. For queries that
+// require them, use [jsonpath.Path.SingularPrefix] to push the longest
+// translatable prefix down to the database and apply the remainder to its
+// result.
+//
+// [MySQL]: https://dev.mysql.com/doc/refman/8.4/en/json.html#json-path-syntax
+// [SQLite]: https://sqlite.org/json1.html#path_arguments
+package sqljsonpath
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+)
+
+// ErrUnsupported errors are returned by [ToMySQL] and [ToSQLite] for paths
+// that cannot be translated.
+var ErrUnsupported = errors.New("sqljsonpath")
+
+// dialect identifies the SQL JSON path dialect to translate to.
+type dialect uint8
+
+const (
+	mysql dialect = iota
+	sqlite
+)
+
+// ToMySQL translates path into a MySQL JSON path expression. It supports
+// name and index selectors, including negative indexes, translated to
+// [last] and [last-n], and slice selectors with a step of 1, translated to
+// ranges such as [1 to 3]. Returns an [ErrUnsupported] error listing each
+// construct of path that it cannot translate, including descendant
+// segments, wildcard and filter selectors, other slices, and segments with
+// multiple selectors.
+//
+// MySQL evaluates queries that use ranges, like other queries that select
+// multiple values, to an array of the selected values.
+func ToMySQL(path *jsonpath.Path) (string, error) {
+	return translate(path, mysql)
+}
+
+// ToSQLite translates path into a SQLite JSON path expression. It supports
+// name and index selectors, including negative indexes, translated to [#-n].
+// Returns an [ErrUnsupported] error listing each construct of path that it
+// cannot translate, including descendant segments, wildcard, slice, and
+// filter selectors, segments with multiple selectors, and names containing
+// double quotation marks, which SQLite paths cannot represent.
+func ToSQLite(path *jsonpath.Path) (string, error) {
+	return translate(path, sqlite)
+}
+
+// translate translates path into a JSON path expression in dialect d.
+func translate(path *jsonpath.Path, d dialect) (string, error) {
+	q := path.Query()
+	buf := new(strings.Builder)
+	buf.WriteByte('$')
+
+	var errs []error
+	unsupported := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(
+			"%w: cannot translate %v in %v",
+			ErrUnsupported, fmt.Sprintf(format, args...), path,
+		))
+	}
+
+	for _, seg := range q.Segments() {
+		if seg.IsDescendant() {
+			unsupported("descendant segment %v", seg)
+			continue
+		}
+		sels := seg.Selectors()
+		if len(sels) != 1 {
+			unsupported("segment %v with multiple selectors", seg)
+			continue
+		}
+
+		switch sel := sels[0].(type) {
+		case spec.Name:
+			if d == sqlite && strings.ContainsRune(string(sel), '"') {
+				unsupported("name %v containing a double quotation mark", sel)
+				continue
+			}
+			writeName(buf, string(sel), d)
+		case spec.Index:
+			buf.WriteByte('[')
+			buf.WriteString(subscript(int(sel), d))
+			buf.WriteByte(']')
+		case spec.SliceSelector:
+			if d == sqlite || sel.Step() != 1 {
+				unsupported("slice selector %v", sel)
+				continue
+			}
+			buf.WriteByte('[')
+			buf.WriteString(subscript(sel.Start(), d))
+			buf.WriteString(" to ")
+			switch end := sel.End(); {
+			case end == math.MaxInt:
+				buf.WriteString("last")
+			case end > 0:
+				buf.WriteString(strconv.Itoa(end - 1))
+			default:
+				buf.WriteString(subscript(end-1, d))
+			}
+			buf.WriteByte(']')
+		case spec.WildcardSelector:
+			unsupported("wildcard selector %v", sel)
+		default:
+			unsupported("filter selector %v", sel)
+		}
+	}
+
+	if len(errs) > 0 {
+		return "", errors.Join(errs...)
+	}
+	return buf.String(), nil
+}
+
+// subscript returns the array subscript for idx in dialect d, counting
+// from the end of the array with last in MySQL or # in SQLite for a
+// negative idx.
+func subscript(idx int, d dialect) string {
+	switch {
+	case idx >= 0:
+		return strconv.Itoa(idx)
+	case d == sqlite:
+		return "#" + strconv.Itoa(idx)
+	case idx == -1:
+		return "last"
+	default:
+		return "last-" + strconv.Itoa(-idx-1)
+	}
+}
+
+// writeName writes a member accessor for name to buf, quoting name unless
+// it is an identifier. MySQL quoted names use JSON string escapes, while
+// SQLite reads quoted names verbatim up to the closing quotation mark.
+func writeName(buf *strings.Builder, name string, d dialect) {
+	buf.WriteByte('.')
+	if isIdentifier(name) {
+		buf.WriteString(name)
+		return
+	}
+
+	buf.WriteByte('"')
+	if d == sqlite {
+		buf.WriteString(name)
+	} else {
+		writeEscaped(buf, name)
+	}
+	buf.WriteByte('"')
+}
+
+// writeEscaped writes s to buf, escaping quotation marks, backslashes, and
+// control characters as in JSON strings.
+func writeEscaped(buf *strings.Builder, s string) {
+	const hex = "0123456789abcdef"
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r < 0x20:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hex[r>>4])
+			buf.WriteByte(hex[r&0xf])
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// isIdentifier returns true if s can be written as an unquoted member
+// accessor in both dialects: an ASCII letter, underscore, or dollar sign
+// followed by ASCII letters, digits, underscores, or dollar signs.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := range len(s) {
+		c := s[i]
+		switch {
+		case c == '_', c == '$', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package sqljsonpath_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/sqljsonpath"
+)
+
+// Translate a JSONPath query into a MySQL JSON path expression to pass to
+// JSON_EXTRACT().
+func ExampleToMySQL() {
+	path := jsonpath.MustParse(`$.orders[-1]["ship to"].city`)
+	expr, err := sqljsonpath.ToMySQL(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(expr)
+	// Output: $.orders[last]."ship to".city
+}
+
+// Translate a JSONPath query into a SQLite JSON path expression to pass to
+// json_extract().
+func ExampleToSQLite() {
+	path := jsonpath.MustParse(`$.orders[-1]["ship to"].city`)
+	expr, err := sqljsonpath.ToSQLite(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(expr)
+	// Output: $.orders[#-1]."ship to".city
+}
+
+// Push the translatable prefix of a query down to the database and apply
+// the rest of the query to the value it returns.
+func ExampleToSQLite_prefix() {
+	path := jsonpath.MustParse(`$.store.book[?@.price < 10].title`)
+	prefix, rest := path.SingularPrefix()
+	expr, err := sqljsonpath.ToSQLite(jsonpath.MustParse(prefix.String()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(expr)
+	fmt.Println(rest)
+	// Output:
+	// $.store.book
+	// $[?@["price"] < 10]["title"]
+}
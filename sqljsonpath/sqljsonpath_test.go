@@ -0,0 +1,115 @@
+package sqljsonpath
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+)
+
+func TestTranslate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test   string
+		path   string
+		mysql  string
+		sqlite string
+		err    string
+	}{
+		{test: "root", path: `$`, mysql: `$`, sqlite: `$`},
+		{test: "names", path: `$.a.b_1['$c']`, mysql: `$.a.b_1.$c`, sqlite: `$.a.b_1.$c`},
+		{test: "index", path: `$.a[0].b`, mysql: `$.a[0].b`, sqlite: `$.a[0].b`},
+		{test: "last", path: `$.a[-1]`, mysql: `$.a[last]`, sqlite: `$.a[#-1]`},
+		{test: "from_last", path: `$.a[-3]`, mysql: `$.a[last-2]`, sqlite: `$.a[#-3]`},
+		{test: "quoted", path: `$['a.b']['c d']`, mysql: `$."a.b"."c d"`, sqlite: `$."a.b"."c d"`},
+		{test: "empty_name", path: `$['']`, mysql: `$.""`, sqlite: `$.""`},
+		{test: "digit_name", path: `$['0']`, mysql: `$."0"`, sqlite: `$."0"`},
+		{test: "backslash", path: `$['a\\b\n']`, mysql: `$."a\\b\n"`, sqlite: "$.\"a\\b\n\""},
+		{
+			test:   "quote",
+			path:   `$['a"b']`,
+			mysql:  `$."a\"b"`,
+			sqlite: `sqljsonpath: cannot translate name "a\"b" containing a double quotation mark in $["a\"b"]`,
+		},
+		{
+			test:   "slice",
+			path:   `$.a[1:3]`,
+			mysql:  `$.a[1 to 2]`,
+			sqlite: `sqljsonpath: cannot translate slice selector 1:3 in $["a"][1:3]`,
+		},
+		{
+			test:   "open_slice",
+			path:   `$.a[-2:]`,
+			mysql:  `$.a[last-1 to last]`,
+			sqlite: `sqljsonpath: cannot translate slice selector -2: in $["a"][-2:]`,
+		},
+		{
+			test:   "negative_end",
+			path:   `$.a[:-1]`,
+			mysql:  `$.a[0 to last-1]`,
+			sqlite: `sqljsonpath: cannot translate slice selector :-1 in $["a"][:-1]`,
+		},
+		{
+			test: "step",
+			path: `$.a[::2]`,
+			err:  `sqljsonpath: cannot translate slice selector ::2 in $["a"][::2]`,
+		},
+		{
+			test: "wildcard",
+			path: `$.a[*]`,
+			err:  `sqljsonpath: cannot translate wildcard selector * in $["a"][*]`,
+		},
+		{
+			test: "filter",
+			path: `$.a[?@.b]`,
+			err:  `sqljsonpath: cannot translate filter selector ?@["b"] in $["a"][?@["b"]]`,
+		},
+		{
+			test: "descendant",
+			path: `$..a`,
+			err:  `sqljsonpath: cannot translate descendant segment ..["a"] in $..["a"]`,
+		},
+		{
+			test: "union",
+			path: `$[0,1]`,
+			err:  `sqljsonpath: cannot translate segment [0,1] with multiple selectors in $[0,1]`,
+		},
+		{
+			test: "multiple",
+			path: `$..a[*].b[?@]`,
+			err: `sqljsonpath: cannot translate descendant segment ..["a"] in $..["a"][*]["b"][?@]` + "\n" +
+				`sqljsonpath: cannot translate wildcard selector * in $..["a"][*]["b"][?@]` + "\n" +
+				`sqljsonpath: cannot translate filter selector ?@ in $..["a"][*]["b"][?@]`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			path := jsonpath.MustParse(tc.path)
+			for _, d := range []struct {
+				name string
+				fn   func(*jsonpath.Path) (string, error)
+				exp  string
+			}{
+				{"mysql", ToMySQL, tc.mysql},
+				{"sqlite", ToSQLite, tc.sqlite},
+			} {
+				res, err := d.fn(path)
+				switch {
+				case tc.err != "":
+					require.EqualError(t, err, tc.err, d.name)
+					require.ErrorIs(t, err, ErrUnsupported, d.name)
+					assert.Empty(t, res, d.name)
+				case strings.HasPrefix(d.exp, "sqljsonpath:"):
+					require.EqualError(t, err, d.exp, d.name)
+					require.ErrorIs(t, err, ErrUnsupported, d.name)
+				default:
+					require.NoError(t, err, d.name)
+					assert.Equal(t, d.exp, res, d.name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"io"
+	"iter"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Stream reads a single JSON value from r and returns an iterator over the
+// nodes that path selects from it, paired with their [spec.NormalizedPath]
+// locations. Nodes are yielded as they are encountered in r, and subtrees
+// that cannot contribute to the result are never decoded, so that queries
+// consisting of name, wildcard, non-negative index, and forward slice
+// selectors run in constant memory over inputs of any size. See
+// [spec.PathQuery.Stream] for details.
+//
+// If reading or decoding r fails, the iterator yields a nil
+// [spec.NormalizedPath] and the error as its final pair. Stream may read
+// past the end of the JSON value in r.
+func Stream(r io.Reader, path *Path) iter.Seq2[spec.NormalizedPath, any] {
+	return func(yield func(spec.NormalizedPath, any) bool) {
+		if err := path.q.Stream(json.NewDecoder(r), yield); err != nil {
+			yield(nil, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package jsonpath
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		test string
+		path string
+		doc  string
+		exp  LocatedNodeList
+		err  string
+	}{
+		{
+			test: "root",
+			path: "$",
+			doc:  `{"x": 1}`,
+			exp:  LocatedNodeList{{Path: spec.NormalizedPath{}, Node: map[string]any{"x": float64(1)}}},
+		},
+		{
+			test: "descendants",
+			path: "$..x",
+			doc:  `{"x": {"x": 1}, "y": [{"x": true}]}`,
+			exp: LocatedNodeList{
+				{Path: norm("x"), Node: map[string]any{"x": float64(1)}},
+				{Path: norm("x", "x"), Node: float64(1)},
+				{Path: spec.Normalized(spec.Name("y"), spec.Index(0), spec.Name("x")), Node: true},
+			},
+		},
+		{
+			test: "filter",
+			path: "$.a[?@ > 1]",
+			doc:  `{"a": [1, 2, 3], "b": [4, 5]}`,
+			exp: LocatedNodeList{
+				{Path: spec.Normalized(spec.Name("a"), spec.Index(1)), Node: float64(2)},
+				{Path: spec.Normalized(spec.Name("a"), spec.Index(2)), Node: float64(3)},
+			},
+		},
+		{
+			test: "syntax_error",
+			path: "$.a",
+			doc:  `{"a": }`,
+			exp:  LocatedNodeList{},
+			err:  "invalid character '}' looking for beginning of value",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			res := LocatedNodeList{}
+			var err error
+			for p, v := range Stream(strings.NewReader(tc.doc), MustParse(tc.path)) {
+				if p == nil {
+					err, _ = v.(error)
+					continue
+				}
+				res = append(res, &spec.LocatedNode{Path: p, Node: v})
+			}
+			a.Equal(tc.exp, res)
+			if tc.err == "" {
+				a.NoError(err)
+			} else {
+				a.EqualError(err, tc.err)
+			}
+		})
+	}
+}
+
+func TestStreamBreak(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	for range Stream(strings.NewReader(`[1, 2, 3]`), MustParse("$[*]")) {
+		count++
+		break
+	}
+	assert.Equal(t, 1, count)
+}
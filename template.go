@@ -0,0 +1,82 @@
+package jsonpath
+
+import "sync"
+
+// maxCachedPaths is the maximum number of paths that the functions
+// returned by [Parser.FuncMap] cache. They parse other paths on every call.
+const maxCachedPaths = 1024
+
+// FuncMap returns functions for use with [text/template] and
+// [html/template] that select from values with JSONPath queries, parsed by
+// a [Parser] with no options. See [Parser.FuncMap] for details.
+func FuncMap() map[string]any {
+	return NewParser().FuncMap()
+}
+
+// FuncMap returns functions for use with [text/template] and
+// [html/template] that select from values with JSONPath queries parsed by
+// c. Pass it to the Funcs method of a template to define these functions:
+//
+//   - jsonpath: Selects the nodes that a query selects from a value, as in
+//     {{ jsonpath . "$.items[?@.active == true].name" }}
+//   - jsonpathFirst: Selects the first node that a query selects from a
+//     value, or nil if it selects none, as in
+//     {{ jsonpathFirst . "$.owner.email" }}
+//
+// The functions parse each query once and cache the resulting [Path] for
+// subsequent calls, up to 1,024 distinct queries. They return an
+// [ErrPathParse] error for invalid queries, which stops template
+// execution, as do the errors returned by [Path.TrySelect].
+func (c *Parser) FuncMap() map[string]any {
+	cache := &pathCache{parser: c, paths: map[string]*Path{}}
+	return map[string]any{
+		"jsonpath": func(input any, query string) (NodeList, error) {
+			path, err := cache.get(query)
+			if err != nil {
+				return nil, err
+			}
+			return path.TrySelect(input)
+		},
+		"jsonpathFirst": func(input any, query string) (any, error) {
+			path, err := cache.get(query)
+			if err != nil {
+				return nil, err
+			}
+			nodes, err := path.TrySelect(input)
+			if err != nil || len(nodes) == 0 {
+				return nil, err
+			}
+			return nodes[0], nil
+		},
+	}
+}
+
+// pathCache caches the paths parsed by a [Parser]. It's safe for
+// concurrent use.
+type pathCache struct {
+	parser *Parser
+	mu     sync.RWMutex
+	paths  map[string]*Path
+}
+
+// get returns the [Path] for query, parsing and caching it if it's not
+// already cached.
+func (pc *pathCache) get(query string) (*Path, error) {
+	pc.mu.RLock()
+	path, ok := pc.paths[query]
+	pc.mu.RUnlock()
+	if ok {
+		return path, nil
+	}
+
+	path, err := pc.parser.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	pc.mu.Lock()
+	if len(pc.paths) < maxCachedPaths {
+		pc.paths[query] = path
+	}
+	pc.mu.Unlock()
+	return path, nil
+}
@@ -0,0 +1,137 @@
+package jsonpath
+
+import (
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncMap(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]any{
+		"items": []any{
+			map[string]any{"name": "a", "active": true},
+			map[string]any{"name": "b", "active": false},
+			map[string]any{"name": "<c>", "active": true},
+		},
+		"owner": map[string]any{"email": "x@example.com"},
+	}
+
+	for _, tc := range []struct {
+		test string
+		tmpl string
+		exp  string
+		err  string
+	}{
+		{
+			test: "select",
+			tmpl: `{{ jsonpath . "$.items[?@.active == true].name" }}`,
+			exp:  "[a <c>]",
+		},
+		{
+			test: "range",
+			tmpl: `{{ range jsonpath . "$.items[*].name" }}{{ . }};{{ end }}`,
+			exp:  "a;b;<c>;",
+		},
+		{
+			test: "none",
+			tmpl: `{{ jsonpath . "$.nope" }}`,
+			exp:  "[]",
+		},
+		{
+			test: "first",
+			tmpl: `{{ jsonpathFirst . "$.owner.email" }}`,
+			exp:  "x@example.com",
+		},
+		{
+			test: "first_of_many",
+			tmpl: `{{ jsonpathFirst . "$.items[*].name" }}`,
+			exp:  "a",
+		},
+		{
+			test: "first_none",
+			tmpl: `{{ with jsonpathFirst . "$.nope" }}found{{ else }}none{{ end }}`,
+			exp:  "none",
+		},
+		{
+			test: "parse_error",
+			tmpl: `{{ jsonpath . "$.items[" }}`,
+			err:  `template: test:1:3: executing "test" at <jsonpath . "$.items[">: error calling jsonpath: jsonpath: unexpected eof at position 9`,
+		},
+		{
+			test: "first_parse_error",
+			tmpl: `{{ jsonpathFirst . "$[" }}`,
+			err:  `template: test:1:3: executing "test" at <jsonpathFirst . "$[">: error calling jsonpathFirst: jsonpath: unexpected eof at position 3`,
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			tmpl := template.Must(template.New("test").Funcs(FuncMap()).Parse(tc.tmpl))
+			buf := new(strings.Builder)
+			err := tmpl.Execute(buf, data)
+			if tc.err != "" {
+				a.ErrorIs(err, ErrPathParse)
+				a.EqualError(err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			a.Equal(tc.exp, buf.String())
+		})
+	}
+
+	t.Run("html", func(t *testing.T) {
+		t.Parallel()
+		tmpl := htmltemplate.Must(htmltemplate.New("test").Funcs(FuncMap()).Parse(
+			`{{ range jsonpath . "$.items[?@.active == true].name" }}<li>{{ . }}</li>{{ end }}`,
+		))
+		buf := new(strings.Builder)
+		require.NoError(t, tmpl.Execute(buf, data))
+		assert.Equal(t, "<li>a</li><li>&lt;c&gt;</li>", buf.String())
+	})
+
+	t.Run("budget", func(t *testing.T) {
+		t.Parallel()
+		funcs := NewParser(WithMaxNodes(1)).FuncMap()
+		tmpl := template.Must(template.New("test").Funcs(funcs).Parse(`{{ jsonpathFirst . "$..name" }}`))
+		err := tmpl.Execute(new(strings.Builder), data)
+		assert.ErrorIs(t, err, ErrBudgetExceeded)
+	})
+}
+
+func TestPathCache(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	cache := &pathCache{parser: NewParser(), paths: map[string]*Path{}}
+	path, err := cache.get(`$.a`)
+	a.NoError(err)
+	again, err := cache.get(`$.a`)
+	a.NoError(err)
+	a.Same(path, again)
+
+	_, err = cache.get(`$[`)
+	a.ErrorIs(err, ErrPathParse)
+	a.Len(cache.paths, 1)
+
+	var wg sync.WaitGroup
+	for i := range maxCachedPaths + 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := cache.get(`$[` + strconv.Itoa(i) + `]`)
+			a.NoError(err)
+			a.Equal(`$[`+strconv.Itoa(i)+`]`, p.String())
+		}()
+	}
+	wg.Wait()
+	a.Len(cache.paths, maxCachedPaths)
+}
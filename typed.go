@@ -0,0 +1,221 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+var (
+	// ErrConversion errors are returned by [SelectAs] for selected values
+	// that it cannot convert to the requested type.
+	ErrConversion = errors.New("jsonpath: cannot convert value")
+
+	// ErrDecode errors are returned by [Path.DecodeInto] for selections that
+	// it cannot decode into the requested value.
+	ErrDecode = errors.New("jsonpath: cannot decode")
+)
+
+// SelectAs selects the nodes that p selects from doc and converts them to
+// values of type T, so that callers need not assert the type of each node.
+// It converts each node by:
+//
+//   - Returning it unchanged if it's a T, including for interface types
+//     that it implements
+//   - Converting it to a numeric T if it's a number, including a
+//     [json.Number], in the range of T and, for integer types, with an
+//     integral value, such as 42.0 to an int, but not 42.5 to an int or
+//     300 to an int8
+//   - Converting it to a struct, map, slice, array, or pointer T, or a T
+//     that implements [json.Unmarshaler], by marshaling it to JSON and
+//     unmarshaling the JSON into a T, as when decoding a generic
+//     map[string]any into a struct
+//   - Converting a JSON null to the zero value of an interface, pointer,
+//     map, or slice T
+//
+// Returns an [ErrConversion] error that identifies the normalized path of
+// the first node it cannot convert, and the errors returned by
+// [Path.TrySelect].
+func SelectAs[T any](p *Path, doc any) ([]T, error) {
+	nodes, err := p.TrySelectLocated(doc)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]T, len(nodes))
+	for i, node := range nodes {
+		if res[i], err = convertTo[T](node.Node); err != nil {
+			return nil, fmt.Errorf("%w at %v: %w", ErrConversion, node.Path, err)
+		}
+	}
+	return res, nil
+}
+
+// DecodeInto selects the nodes that p selects from doc and decodes them into
+// out, which must be a non-nil pointer, following the semantics of
+// [json.Unmarshal]. If out points to a slice and p is not a singular query,
+// it decodes all of the selected nodes into the slice, as if they were the
+// elements of a JSON array. Otherwise p must select exactly one node, which
+// it decodes into the value out points to. Use it in place of selecting
+// nodes, marshaling them to JSON, and unmarshaling the JSON into a struct:
+//
+//	var owner struct {
+//		Name  string `json:"name"`
+//		Email string `json:"email"`
+//	}
+//	err := jsonpath.MustParse("$.owner").DecodeInto(doc, &owner)
+//
+// Returns an [ErrDecode] error if out is not a non-nil pointer, if p selects
+// no nodes or more than one node to decode into a single value, or if the
+// nodes cannot be decoded into out, and the errors returned by
+// [Path.TrySelect].
+func (p *Path) DecodeInto(doc, out any) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%w into %T: requires a non-nil pointer", ErrDecode, out)
+	}
+
+	nodes, err := p.TrySelect(doc)
+	if err != nil {
+		return err
+	}
+
+	var src any
+	switch {
+	case rv.Elem().Kind() == reflect.Slice && p.q.Singular() == nil:
+		src = []any(nodes)
+	case len(nodes) == 1:
+		src = nodes[0]
+	default:
+		return fmt.Errorf("%w into %T: %v selected %d nodes, not 1", ErrDecode, out, p, len(nodes))
+	}
+
+	data, err := json.Marshal(src)
+	if err == nil {
+		err = json.Unmarshal(data, out)
+	}
+	if err != nil {
+		return fmt.Errorf("%w into %T: %w", ErrDecode, out, err)
+	}
+	return nil
+}
+
+// errConvert indicates that a value has no conversion to a type.
+var errConvert = errors.New("no conversion")
+
+// unmarshalerType is the reflect.Type of [json.Unmarshaler].
+var unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// convertTo converts val to a T as described by [SelectAs].
+func convertTo[T any](val any) (T, error) {
+	if v, ok := val.(T); ok {
+		return v, nil
+	}
+
+	var zero T
+	rt := reflect.TypeFor[T]()
+	out := reflect.New(rt)
+	switch {
+	case val == nil:
+		switch rt.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+			return zero, nil
+		}
+	case setNumber(out.Elem(), val):
+		return out.Elem().Interface().(T), nil //nolint:forcetypeassert
+	case remarshals(rt):
+		data, err := json.Marshal(val)
+		if err == nil {
+			err = json.Unmarshal(data, out.Interface())
+		}
+		if err != nil {
+			return zero, err
+		}
+		return out.Elem().Interface().(T), nil //nolint:forcetypeassert
+	}
+	return zero, fmt.Errorf("%w from %T to %v", errConvert, val, rt)
+}
+
+// remarshals returns true if [convertTo] converts values to rt by
+// marshaling them to JSON and unmarshaling the result.
+func remarshals(rt reflect.Type) bool {
+	if reflect.PointerTo(rt).Implements(unmarshalerType) {
+		return true
+	}
+	switch rt.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Pointer:
+		return true
+	default:
+		return false
+	}
+}
+
+// setNumber sets dst, a settable numeric value, to val if val is a number
+// that dst's type represents exactly, and returns true. Otherwise it
+// returns false.
+func setNumber(dst reflect.Value, val any) bool {
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := toInt64(val); ok && !dst.OverflowInt(n) {
+			dst.SetInt(n)
+			return true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := toInt64(val); ok && n >= 0 && !dst.OverflowUint(uint64(n)) {
+			dst.SetUint(uint64(n))
+			return true
+		}
+		if v := reflect.ValueOf(val); v.CanUint() && !dst.OverflowUint(v.Uint()) {
+			dst.SetUint(v.Uint())
+			return true
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := toFloat64(val); ok && !dst.OverflowFloat(f) {
+			dst.SetFloat(f)
+			return true
+		}
+	}
+	return false
+}
+
+// toInt64 returns val as an int64 if it's a number with an integral value
+// in the range of int64.
+func toInt64(val any) (int64, bool) {
+	if n, ok := val.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, true
+		}
+	}
+	v := reflect.ValueOf(val)
+	switch {
+	case v.CanInt():
+		return v.Int(), true
+	case v.CanUint():
+		u := v.Uint()
+		return int64(u), u <= math.MaxInt64 //nolint:gosec
+	}
+	if f, ok := toFloat64(val); ok && f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+		return int64(f), true
+	}
+	return 0, false
+}
+
+// toFloat64 returns val as a float64 if it's a number.
+func toFloat64(val any) (float64, bool) {
+	if n, ok := val.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	v := reflect.ValueOf(val)
+	switch {
+	case v.CanFloat():
+		return v.Float(), true
+	case v.CanInt():
+		return float64(v.Int()), true
+	case v.CanUint():
+		return float64(v.Uint()), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,245 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedBook struct {
+	Title string  `json:"title"`
+	Price float64 `json:"price"`
+}
+
+type typedID int
+
+func TestSelectAs(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"books": [
+			{"title": "A", "price": 8.95, "tags": ["x"]},
+			{"title": "B", "price": 12, "tags": []}
+		],
+		"ids": [1, 2, 3],
+		"when": "2024-05-01T12:00:00Z",
+		"nil": null
+	}`), &doc))
+
+	titles, err := SelectAs[string](MustParse(`$.books[*].title`), doc)
+	a.NoError(err)
+	a.Equal([]string{"A", "B"}, titles)
+
+	ids, err := SelectAs[int](MustParse(`$.ids[*]`), doc)
+	a.NoError(err)
+	a.Equal([]int{1, 2, 3}, ids)
+
+	named, err := SelectAs[typedID](MustParse(`$.ids[0]`), doc)
+	a.NoError(err)
+	a.Equal([]typedID{1}, named)
+
+	books, err := SelectAs[typedBook](MustParse(`$.books[*]`), doc)
+	a.NoError(err)
+	a.Equal([]typedBook{{"A", 8.95}, {"B", 12}}, books)
+
+	ptrs, err := SelectAs[*typedBook](MustParse(`$.books[1]`), doc)
+	a.NoError(err)
+	a.Equal([]*typedBook{{"B", 12}}, ptrs)
+
+	tags, err := SelectAs[[]string](MustParse(`$.books[*].tags`), doc)
+	a.NoError(err)
+	a.Equal([][]string{{"x"}, {}}, tags)
+
+	objs, err := SelectAs[map[string]any](MustParse(`$.books[0]`), doc)
+	a.NoError(err)
+	a.Equal([]map[string]any{{"title": "A", "price": 8.95, "tags": []any{"x"}}}, objs)
+
+	times, err := SelectAs[time.Time](MustParse(`$.when`), doc)
+	a.NoError(err)
+	a.Equal([]time.Time{time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}, times)
+
+	anys, err := SelectAs[any](MustParse(`$["nil","when"]`), doc)
+	a.NoError(err)
+	a.Equal([]any{nil, "2024-05-01T12:00:00Z"}, anys)
+
+	none, err := SelectAs[int](MustParse(`$.nope`), doc)
+	a.NoError(err)
+	a.Empty(none)
+
+	_, err = SelectAs[int](MustParse(`$.books[*].price`), doc)
+	a.ErrorIs(err, ErrConversion)
+	a.EqualError(err, "jsonpath: cannot convert value at $['books'][0]['price']: no conversion from float64 to int")
+
+	_, err = SelectAs[typedBook](MustParse(`$.ids[0]`), doc)
+	a.ErrorIs(err, ErrConversion)
+	a.EqualError(err, "jsonpath: cannot convert value at $['ids'][0]: json: cannot unmarshal number into Go value of type jsonpath.typedBook")
+
+	_, err = SelectAs[string](MustParse(`$.nil`), doc)
+	a.ErrorIs(err, ErrConversion)
+	a.EqualError(err, "jsonpath: cannot convert value at $['nil']: no conversion from <nil> to string")
+
+	_, err = SelectAs[int](NewParser(WithMaxNodes(1)).MustParse(`$..*`), doc)
+	a.ErrorIs(err, ErrBudgetExceeded)
+}
+
+func TestConvertTo(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	check := func(exp any, got any, err error) {
+		t.Helper()
+		a.NoError(err)
+		a.Equal(exp, got)
+	}
+	fails := func(_ any, err error) {
+		t.Helper()
+		a.ErrorIs(err, errConvert)
+	}
+
+	v1, err := convertTo[int](json.Number("42"))
+	check(42, v1, err)
+	v2, err := convertTo[int8](42.0)
+	check(int8(42), v2, err)
+	v3, err := convertTo[uint16](int64(65535))
+	check(uint16(65535), v3, err)
+	v4, err := convertTo[uint64](uint64(math.MaxUint64))
+	check(uint64(math.MaxUint64), v4, err)
+	v5, err := convertTo[float32](3)
+	check(float32(3), v5, err)
+	v6, err := convertTo[float64](json.Number("1.5"))
+	check(1.5, v6, err)
+	v7, err := convertTo[int64](uint8(7))
+	check(int64(7), v7, err)
+	v8, err := convertTo[*int](nil)
+	check((*int)(nil), v8, err)
+	v9, err := convertTo[[]int](nil)
+	check([]int(nil), v9, err)
+	v10, err := convertTo[int](json.Number("1e3"))
+	check(1000, v10, err)
+
+	fails(convertTo[int8](300))
+	fails(convertTo[int](42.5))
+	fails(convertTo[uint](-1))
+	fails(convertTo[int](math.Inf(1)))
+	fails(convertTo[int](math.NaN()))
+	fails(convertTo[int](uint64(math.MaxUint64)))
+	fails(convertTo[float32](math.MaxFloat64))
+	fails(convertTo[int]("42"))
+	fails(convertTo[int](json.Number("x")))
+	fails(convertTo[string](42))
+	fails(convertTo[bool](nil))
+	fails(convertTo[int](true))
+}
+
+func TestDecodeInto(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"owner": {"name": "Ann", "email": "ann@example.com"},
+		"books": [
+			{"title": "A", "price": 8.95},
+			{"title": "B", "price": 12}
+		],
+		"tags": ["x", "y"]
+	}`), &doc))
+
+	var owner struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	a.NoError(MustParse(`$.owner`).DecodeInto(doc, &owner))
+	a.Equal("Ann", owner.Name)
+	a.Equal("ann@example.com", owner.Email)
+
+	var books []typedBook
+	a.NoError(MustParse(`$.books[*]`).DecodeInto(doc, &books))
+	a.Equal([]typedBook{{"A", 8.95}, {"B", 12}}, books)
+
+	var titles []string
+	a.NoError(MustParse(`$..title`).DecodeInto(doc, &titles))
+	a.Equal([]string{"A", "B"}, titles)
+
+	// Singular queries decode the selected array into a slice.
+	var tags []string
+	a.NoError(MustParse(`$.tags`).DecodeInto(doc, &tags))
+	a.Equal([]string{"x", "y"}, tags)
+
+	// Non-singular queries that select nothing decode an empty slice.
+	titles = []string{"z"}
+	a.NoError(MustParse(`$.nope[*]`).DecodeInto(doc, &titles))
+	a.Empty(titles)
+
+	var book typedBook
+	a.NoError(MustParse(`$.books[?@.price > 10]`).DecodeInto(doc, &book))
+	a.Equal(typedBook{"B", 12}, book)
+
+	var price float64
+	a.NoError(MustParse(`$.books[0].price`).DecodeInto(doc, &price))
+	a.InEpsilon(8.95, price, 0)
+
+	var val any
+	a.NoError(MustParse(`$.tags[1]`).DecodeInto(doc, &val))
+	a.Equal("y", val)
+
+	for _, tc := range []struct {
+		name string
+		path string
+		out  any
+		err  string
+	}{
+		{
+			name: "not_pointer",
+			path: `$.owner`,
+			out:  book,
+			err:  "jsonpath: cannot decode into jsonpath.typedBook: requires a non-nil pointer",
+		},
+		{
+			name: "nil_pointer",
+			path: `$.owner`,
+			out:  (*typedBook)(nil),
+			err:  "jsonpath: cannot decode into *jsonpath.typedBook: requires a non-nil pointer",
+		},
+		{
+			name: "nil",
+			path: `$.owner`,
+			out:  nil,
+			err:  "jsonpath: cannot decode into <nil>: requires a non-nil pointer",
+		},
+		{
+			name: "no_nodes",
+			path: `$.nope`,
+			out:  &book,
+			err:  "jsonpath: cannot decode into *jsonpath.typedBook: $[\"nope\"] selected 0 nodes, not 1",
+		},
+		{
+			name: "many_nodes",
+			path: `$.books[*]`,
+			out:  &book,
+			err:  "jsonpath: cannot decode into *jsonpath.typedBook: $[\"books\"][*] selected 2 nodes, not 1",
+		},
+		{
+			name: "wrong_type",
+			path: `$.tags`,
+			out:  &book,
+			err:  "jsonpath: cannot decode into *jsonpath.typedBook: json: cannot unmarshal array into Go value of type jsonpath.typedBook",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := MustParse(tc.path).DecodeInto(doc, tc.out)
+			require.ErrorIs(t, err, ErrDecode)
+			require.EqualError(t, err, tc.err)
+		})
+	}
+
+	err := NewParser(WithMaxNodes(1)).MustParse(`$..*`).DecodeInto(doc, &titles)
+	a.ErrorIs(err, ErrBudgetExceeded)
+}
@@ -0,0 +1,263 @@
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// ErrUpdate errors are returned by [Watcher.Update] for changes it cannot
+// apply to its document.
+var ErrUpdate = errors.New("jsonpath: cannot apply update")
+
+// Watcher maintains the nodes that a [Path] selects from a document as the
+// document changes, for reactive systems that would otherwise re-evaluate
+// their paths after every small update. Create one with [NewWatcher] and
+// notify it of changes with [Watcher.Update]. It's safe for concurrent
+// use.
+//
+// For paths whose results depend only on the locations of nodes, Update
+// revises the results by examining only the changed value and its
+// descendants. These paths contain no filter selectors, negative indexes,
+// or slices with negative bounds or steps, and are not configured by
+// [WithMaxDepth]. For other paths, and for changes that remove array
+// elements and therefore shift the indexes of the elements that follow,
+// Update evaluates the path against the entire document.
+type Watcher struct {
+	mu          sync.Mutex
+	path        *Path
+	doc         any
+	nodes       LocatedNodeList
+	incremental bool
+}
+
+// NewWatcher creates a [Watcher] that maintains the nodes that path selects
+// from doc, which must consist of map[string]any and []any values for
+// Update to change it. Selects the initial results from doc.
+func NewWatcher(path *Path, doc any) *Watcher {
+	w := &Watcher{path: path, doc: doc, incremental: incremental(path)}
+	w.nodes = w.selectAll()
+	return w
+}
+
+// incremental returns true if the nodes p selects depend only on their
+// locations, so that [spec.PathQuery.MatchesPath] is exact for them.
+func incremental(p *Path) bool {
+	if p.opts.MaxDepth > 0 {
+		return false
+	}
+	for _, seg := range p.q.Segments() {
+		for _, sel := range seg.Selectors() {
+			switch sel := sel.(type) {
+			case spec.Name, spec.WildcardSelector:
+			case spec.Index:
+				if sel < 0 {
+					return false
+				}
+			case spec.SliceSelector:
+				if sel.Step() <= 0 || sel.Start() < 0 || sel.End() < 0 {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Path returns the path w evaluates.
+func (w *Watcher) Path() *Path {
+	return w.path
+}
+
+// Document returns the current document.
+func (w *Watcher) Document() any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.doc
+}
+
+// Results returns the nodes that w's path selects from its current
+// document, sorted and deduplicated by their normalized paths as by
+// [LocatedNodeList.Sort] and [LocatedNodeList.Deduplicate]. Returns a new
+// list for each call.
+func (w *Watcher) Results() LocatedNodeList {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.nodes.Clone()
+}
+
+// Update applies a change to w's document that sets the value at path to
+// value, or removes it if value is [Nothing], and updates the results.
+// Setting a value adds or replaces an object member or array element, or
+// appends an element to an array if path identifies the index after its
+// last element. Removing an array element shifts the elements that follow
+// it. Update modifies the document in place, and replaces it entirely if
+// path is the root path.
+//
+// Returns true if the change may have changed the results: if it added or
+// removed a node, or changed a value at, above, or below one of them.
+// Returns an [ErrUpdate] error if path does not identify a value that
+// exists or, for the last selector, could exist in the document, or if the
+// objects and arrays along it are not map[string]any and []any values, in
+// which case neither the document nor the results change.
+func (w *Watcher) Update(path spec.NormalizedPath, value any) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	doc, shifted, err := applyUpdate(w.doc, path, 0, value)
+	if err != nil {
+		return false, err
+	}
+	w.doc = doc
+
+	prev := w.nodes
+	if w.incremental && !shifted && len(path) > 0 {
+		w.nodes = w.revise(path, value)
+	} else {
+		w.nodes = w.selectAll()
+	}
+
+	if !slices.EqualFunc(prev, w.nodes, func(a, b *spec.LocatedNode) bool {
+		return a.Path.Compare(b.Path) == 0
+	}) {
+		return true, nil
+	}
+	return slices.ContainsFunc(w.nodes, func(n *spec.LocatedNode) bool {
+		return hasPrefix(path, n.Path) || hasPrefix(n.Path, path)
+	}), nil
+}
+
+// selectAll selects the nodes from w's entire document, sorted and
+// deduplicated.
+func (w *Watcher) selectAll() LocatedNodeList {
+	nodes := w.path.SelectLocated(w.doc)
+	nodes.Sort()
+	return nodes.Deduplicate()
+}
+
+// revise returns w's results revised for a change to the value at path,
+// replacing the nodes at and below path with those that w's path selects
+// from value, and refreshing the values of the nodes above path, which may
+// have been reallocated.
+func (w *Watcher) revise(path spec.NormalizedPath, value any) LocatedNodeList {
+	nodes := make(LocatedNodeList, 0, len(w.nodes))
+	for _, n := range w.nodes {
+		switch {
+		case hasPrefix(n.Path, path):
+			// Replaced or removed.
+		case hasPrefix(path, n.Path):
+			nodes = append(nodes, &spec.LocatedNode{Path: n.Path, Node: lookup(w.doc, n.Path)})
+		default:
+			nodes = append(nodes, n)
+		}
+	}
+
+	if value != Nothing {
+		q := w.path.q
+		if q.MatchesPath(path) {
+			nodes = append(nodes, &spec.LocatedNode{Path: slices.Clone(path), Node: value})
+		}
+		descendants := spec.Query(false, spec.Descendant(spec.Wildcard()))
+		for _, n := range descendants.SelectLocated(value, w.doc, path) {
+			if q.MatchesPath(n.Path) {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+
+	nodes.Sort()
+	return nodes.Deduplicate()
+}
+
+// hasPrefix returns true if path starts with the selectors in prefix.
+func hasPrefix(path, prefix spec.NormalizedPath) bool {
+	return len(path) >= len(prefix) && slices.Equal(path[:len(prefix)], prefix)
+}
+
+// lookup returns the value at path in doc, which must exist.
+func lookup(doc any, path spec.NormalizedPath) any {
+	for _, sel := range path {
+		switch sel := sel.(type) {
+		case spec.Name:
+			obj, _ := doc.(map[string]any)
+			doc = obj[string(sel)]
+		case spec.Index:
+			arr, _ := doc.([]any)
+			doc = arr[sel]
+		}
+	}
+	return doc
+}
+
+// updateError returns an [ErrUpdate] error for an update at path.
+func updateError(path spec.NormalizedPath, format string, args ...any) error {
+	return fmt.Errorf("%w to %v: %v", ErrUpdate, path, fmt.Sprintf(format, args...))
+}
+
+// applyUpdate sets the value at path[i:] in node, the value at path[:i], to
+// value, or removes it if value is [Nothing]. Returns the updated node,
+// which may be a new slice, and true if it removed an array element.
+func applyUpdate(node any, path spec.NormalizedPath, i int, value any) (any, bool, error) {
+	if i == len(path) {
+		if value == Nothing {
+			return nil, false, updateError(path, "cannot remove the root value")
+		}
+		return value, false, nil
+	}
+	last := i == len(path)-1
+
+	switch sel := path[i].(type) {
+	case spec.Name:
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return nil, false, updateError(path, "%v is not a map[string]any", path[:i])
+		}
+		child, exists := obj[string(sel)]
+		switch {
+		case last && value == Nothing:
+			delete(obj, string(sel))
+			return obj, false, nil
+		case last:
+			obj[string(sel)] = value
+			return obj, false, nil
+		case !exists:
+			return nil, false, updateError(path, "%v does not exist", path[:i+1])
+		}
+		child, shifted, err := applyUpdate(child, path, i+1, value)
+		if err != nil {
+			return nil, false, err
+		}
+		obj[string(sel)] = child
+		return obj, shifted, nil
+	case spec.Index:
+		arr, ok := node.([]any)
+		if !ok {
+			return nil, false, updateError(path, "%v is not a []any", path[:i])
+		}
+		idx := int(sel)
+		switch {
+		case last && idx == len(arr) && value != Nothing:
+			return append(arr, value), false, nil
+		case idx < 0 || idx >= len(arr):
+			return nil, false, updateError(path, "%v does not exist", path[:i+1])
+		case last && value == Nothing:
+			return slices.Delete(arr, idx, idx+1), true, nil
+		case last:
+			arr[idx] = value
+			return arr, false, nil
+		}
+		child, shifted, err := applyUpdate(arr[idx], path, i+1, value)
+		if err != nil {
+			return nil, false, err
+		}
+		arr[idx] = child
+		return arr, shifted, nil
+	default:
+		return nil, false, updateError(path, "unsupported selector %v", sel)
+	}
+}
@@ -0,0 +1,228 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestWatcherIncremental(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		path string
+		exp  bool
+	}{
+		{`$`, true},
+		{`$.a[0].b`, true},
+		{`$..a[*]`, true},
+		{`$.a[1:5:2]`, true},
+		{`$.a[-1]`, false},
+		{`$.a[-3:]`, false},
+		{`$.a[:-1]`, false},
+		{`$.a[::-1]`, false},
+		{`$.a[?@.b]`, false},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, incremental(MustParse(tc.path)))
+		})
+	}
+	assert.False(t, incremental(NewParser(WithMaxDepth(3)).MustParse(`$..a`)))
+}
+
+func TestWatcher(t *testing.T) {
+	t.Parallel()
+
+	np := func(sel ...any) spec.NormalizedPath {
+		path := spec.Normalized()
+		for _, s := range sel {
+			switch s := s.(type) {
+			case string:
+				path = append(path, spec.Name(s))
+			case int:
+				path = append(path, spec.Index(s))
+			}
+		}
+		return path
+	}
+
+	type update struct {
+		path    spec.NormalizedPath
+		value   any
+		changed bool
+	}
+
+	updates := []update{
+		{np("config", "debug"), true, true},
+		{np("config", "name"), "x", true},
+		{np("services", 0, "port"), 8081, true},
+		{np("services", 2), map[string]any{"name": "c", "port": 9000}, true},
+		{np("services", 0), Nothing, true},
+		{np("services", 1, "tags"), []any{"x", map[string]any{"port": 1}}, true},
+		{np("config", "nested"), map[string]any{"port": 2, "name": "n"}, true},
+		{np("config"), Nothing, true},
+		{np(), map[string]any{"services": []any{map[string]any{"name": "z", "port": 1}}}, true},
+	}
+
+	for _, path := range []string{
+		`$`,
+		`$.services[*].port`,
+		`$.services[0]`,
+		`$..port`,
+		`$..name`,
+		`$.services[1:3].name`,
+		`$.services[-1].name`,
+		`$.services[?@.port > 8000].name`,
+		`$.config.*`,
+	} {
+		t.Run(path, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			var doc any
+			require.NoError(t, json.Unmarshal([]byte(`{
+				"config": {"debug": false, "name": "app"},
+				"services": [
+					{"name": "a", "port": 8080},
+					{"name": "b", "port": 80, "tags": ["web"]}
+				]
+			}`), &doc))
+
+			p := MustParse(path)
+			w := NewWatcher(p, doc)
+			a.Same(p, w.Path())
+			a.Equal(want(p, doc), w.Results())
+
+			for _, u := range updates {
+				before := w.Results()
+				changed, err := w.Update(u.path, u.value)
+				require.NoError(t, err, u.path.String())
+				after := w.Results()
+				a.Equal(want(p, w.Document()), after, u.path.String())
+				if !changed {
+					a.Equal(before, after, u.path.String())
+				}
+			}
+		})
+	}
+}
+
+// want returns the results a [Watcher] for p should have for doc.
+func want(p *Path, doc any) LocatedNodeList {
+	nodes := p.SelectLocated(doc)
+	nodes.Sort()
+	return nodes.Deduplicate()
+}
+
+func TestWatcherChanged(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	doc := map[string]any{"a": map[string]any{"b": 1}, "c": []any{1, 2}}
+	w := NewWatcher(MustParse(`$.a`), doc)
+
+	// Change below a node.
+	changed, err := w.Update(spec.Normalized(spec.Name("a"), spec.Name("b")), 2)
+	a.NoError(err)
+	a.True(changed)
+	a.Equal(LocatedNodeList{{Path: spec.Normalized(spec.Name("a")), Node: map[string]any{"b": 2}}}, w.Results())
+
+	// Unrelated changes.
+	changed, err = w.Update(spec.Normalized(spec.Name("c"), spec.Index(2)), 3)
+	a.NoError(err)
+	a.False(changed)
+	changed, err = w.Update(spec.Normalized(spec.Name("c"), spec.Index(0)), Nothing)
+	a.NoError(err)
+	a.False(changed)
+	a.Equal(map[string]any{"a": map[string]any{"b": 2}, "c": []any{2, 3}}, w.Document())
+
+	// Remove the node.
+	changed, err = w.Update(spec.Normalized(spec.Name("a")), Nothing)
+	a.NoError(err)
+	a.True(changed)
+	a.Empty(w.Results())
+
+	// Results are copies.
+	w = NewWatcher(MustParse(`$.c[*]`), w.Document())
+	res := w.Results()
+	res[0] = nil
+	a.NotNil(w.Results()[0])
+}
+
+func TestWatcherErrors(t *testing.T) {
+	t.Parallel()
+
+	doc := map[string]any{"a": []any{1, map[string]any{}}, "s": "x", "m": map[string]int{"x": 1}}
+	w := NewWatcher(MustParse(`$..*`), doc)
+	results := w.Results()
+
+	for _, tc := range []struct {
+		test string
+		path spec.NormalizedPath
+		val  any
+		err  string
+	}{
+		{
+			test: "remove_root",
+			path: spec.Normalized(),
+			val:  Nothing,
+			err:  "jsonpath: cannot apply update to $: cannot remove the root value",
+		},
+		{
+			test: "missing_member",
+			path: spec.Normalized(spec.Name("x"), spec.Name("y")),
+			val:  1,
+			err:  "jsonpath: cannot apply update to $['x']['y']: $['x'] does not exist",
+		},
+		{
+			test: "missing_element",
+			path: spec.Normalized(spec.Name("a"), spec.Index(3)),
+			val:  1,
+			err:  "jsonpath: cannot apply update to $['a'][3]: $['a'][3] does not exist",
+		},
+		{
+			test: "remove_missing_element",
+			path: spec.Normalized(spec.Name("a"), spec.Index(2)),
+			val:  Nothing,
+			err:  "jsonpath: cannot apply update to $['a'][2]: $['a'][2] does not exist",
+		},
+		{
+			test: "negative_index",
+			path: spec.Normalized(spec.Name("a"), spec.Index(-1)),
+			val:  1,
+			err:  "jsonpath: cannot apply update to $['a'][-1]: $['a'][-1] does not exist",
+		},
+		{
+			test: "not_object",
+			path: spec.Normalized(spec.Name("a"), spec.Name("b")),
+			val:  1,
+			err:  "jsonpath: cannot apply update to $['a']['b']: $['a'] is not a map[string]any",
+		},
+		{
+			test: "not_array",
+			path: spec.Normalized(spec.Name("s"), spec.Index(0)),
+			val:  1,
+			err:  "jsonpath: cannot apply update to $['s'][0]: $['s'] is not a []any",
+		},
+		{
+			test: "typed_map",
+			path: spec.Normalized(spec.Name("m"), spec.Name("x")),
+			val:  2,
+			err:  "jsonpath: cannot apply update to $['m']['x']: $['m'] is not a map[string]any",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			changed, err := w.Update(tc.path, tc.val)
+			a.False(changed)
+			a.ErrorIs(err, ErrUpdate)
+			a.EqualError(err, tc.err)
+			a.Equal(results, w.Results())
+		})
+	}
+}
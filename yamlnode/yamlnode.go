@@ -0,0 +1,183 @@
+// Package yamlnode executes RFC 9535 JSONPath queries against [yaml.Node]
+// trees and returns the selected nodes, so that callers can report the line
+// and column of each selected value in the YAML source.
+package yamlnode
+
+import (
+	"iter"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// LocatedNode pairs a selected [yaml.Node] with the [spec.NormalizedPath]
+// that identifies it.
+type LocatedNode struct {
+	// Path is the normalized path that identifies Node.
+	Path spec.NormalizedPath `json:"path"`
+	// Node is the selected YAML node.
+	Node *yaml.Node `json:"node"`
+}
+
+// Line returns the line number of n in the YAML source, starting at 1.
+func (n *LocatedNode) Line() int { return n.Node.Line }
+
+// Column returns the column number of n in the YAML source, starting at 1.
+func (n *LocatedNode) Column() int { return n.Node.Column }
+
+// Select selects the nodes that path selects from root and returns them with
+// their normalized paths. Document nodes and aliases are resolved to the
+// nodes they contain or reference, and filter expressions compare scalar
+// nodes by their decoded values. Merge keys are not expanded.
+func Select(path *jsonpath.Path, root *yaml.Node) []*LocatedNode {
+	list := path.SelectLocated(Value(root))
+	ret := make([]*LocatedNode, 0, len(list))
+	for _, loc := range list {
+		if node := lookup(root, loc.Path); node != nil {
+			ret = append(ret, &LocatedNode{Path: loc.Path, Node: node})
+		}
+	}
+	return ret
+}
+
+// Value returns the value of node for use in a JSONPath query. Mapping
+// nodes become [jsonpath.Object] values, sequence nodes become
+// [jsonpath.Array] values, and scalar nodes are decoded into Go values.
+// Returns nil for an empty document node or a scalar that cannot be decoded.
+func Value(node *yaml.Node) any {
+	node = resolve(node)
+	if node == nil {
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		return mapping{node}
+	case yaml.SequenceNode:
+		return sequence{node}
+	default:
+		var val any
+		if err := node.Decode(&val); err != nil {
+			return nil
+		}
+		return val
+	}
+}
+
+// resolve returns the node contained by a document node or referenced by an
+// alias node. Returns any other node unchanged.
+func resolve(node *yaml.Node) *yaml.Node {
+	for node != nil {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			if len(node.Content) == 0 {
+				return nil
+			}
+			node = node.Content[0]
+		case yaml.AliasNode:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+	return nil
+}
+
+// lookup returns the node identified by path in root. Returns nil if path
+// does not identify a node.
+func lookup(root *yaml.Node, path spec.NormalizedPath) *yaml.Node {
+	node := resolve(root)
+	for _, sel := range path {
+		var ok bool
+		switch sel := sel.(type) {
+		case spec.Name:
+			node, ok = mapping{node}.member(string(sel))
+		case spec.Index:
+			node, ok = sequence{node}.element(int(sel))
+		}
+		if !ok {
+			return nil
+		}
+	}
+	return node
+}
+
+// mapping implements [jsonpath.Object] for YAML mapping nodes.
+type mapping struct {
+	node *yaml.Node
+}
+
+// member returns the resolved value node for the key name.
+func (m mapping) member(name string) (*yaml.Node, bool) {
+	if m.node == nil || m.node.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	for i := 0; i+1 < len(m.node.Content); i += 2 {
+		if key := resolve(m.node.Content[i]); key != nil && key.Value == name {
+			return resolve(m.node.Content[i+1]), true
+		}
+	}
+	return nil, false
+}
+
+// Get returns the value for the key name. Defined by [jsonpath.Object].
+func (m mapping) Get(name string) (any, bool) {
+	if node, ok := m.member(name); ok {
+		return Value(node), true
+	}
+	return nil, false
+}
+
+// Len returns the number of key/value pairs in m. Defined by
+// [jsonpath.Object].
+func (m mapping) Len() int { return len(m.node.Content) / 2 }
+
+// Iterate returns an iterator over the keys and values of m in the order in
+// which they appear in the YAML source. Defined by [jsonpath.Object].
+func (m mapping) Iterate() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for i := 0; i+1 < len(m.node.Content); i += 2 {
+			if !yield(resolve(m.node.Content[i]).Value, Value(m.node.Content[i+1])) {
+				return
+			}
+		}
+	}
+}
+
+// sequence implements [jsonpath.Array] for YAML sequence nodes.
+type sequence struct {
+	node *yaml.Node
+}
+
+// element returns the resolved node at index.
+func (s sequence) element(index int) (*yaml.Node, bool) {
+	if s.node == nil || s.node.Kind != yaml.SequenceNode ||
+		index < 0 || index >= len(s.node.Content) {
+		return nil, false
+	}
+	return resolve(s.node.Content[index]), true
+}
+
+// Get returns the value at index. Defined by [jsonpath.Array].
+func (s sequence) Get(index int) (any, bool) {
+	if node, ok := s.element(index); ok {
+		return Value(node), true
+	}
+	return nil, false
+}
+
+// Len returns the number of elements in s. Defined by [jsonpath.Array].
+func (s sequence) Len() int { return len(s.node.Content) }
+
+// Iterate returns an iterator over the indexes and values of s. Defined by
+// [jsonpath.Array].
+func (s sequence) Iterate() iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for i, node := range s.node.Content {
+			if !yield(i, Value(node)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package yamlnode_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/yamlnode"
+	"gopkg.in/yaml.v3"
+)
+
+// Report the source position of each value selected from a YAML document.
+func ExampleSelect() {
+	src := []byte(`spec:
+  containers:
+    - name: app
+      image: nginx:latest
+    - name: sidecar
+      image: envoy:1.29
+`)
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(src, &root); err != nil {
+		log.Fatal(err)
+	}
+
+	path := jsonpath.MustParse(`$.spec.containers[?@.image == 'nginx:latest'].image`)
+	for _, node := range yamlnode.Select(path, &root) {
+		fmt.Printf("%v is %q at line %d, column %d\n", node.Path, node.Node.Value, node.Line(), node.Column())
+	}
+	// Output:
+	// $['spec']['containers'][0]['image'] is "nginx:latest" at line 4, column 14
+}
@@ -0,0 +1,167 @@
+package yamlnode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"gopkg.in/yaml.v3"
+)
+
+const manifest = `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  labels: &labels
+    app: web
+    tier: frontend
+spec:
+  containers:
+    - name: app
+      image: nginx:latest
+      ports:
+        - containerPort: 80
+    - name: sidecar
+      image: envoy:1.29
+      ports:
+        - containerPort: 9901
+  selector: *labels
+  replicas: 3
+  enabled: true
+`
+
+type position struct {
+	Path   string
+	Line   int
+	Column int
+	Value  string
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(manifest), &root))
+
+	for _, tc := range []struct {
+		test string
+		path string
+		exp  []position
+	}{
+		{
+			test: "root",
+			path: `$`,
+			exp:  []position{{"$", 1, 1, ""}},
+		},
+		{
+			test: "name",
+			path: `$.metadata.name`,
+			exp:  []position{{"$['metadata']['name']", 4, 9, "web"}},
+		},
+		{
+			test: "index",
+			path: `$.spec.containers[1].image`,
+			exp:  []position{{"$['spec']['containers'][1]['image']", 15, 14, "envoy:1.29"}},
+		},
+		{
+			test: "wildcard_order",
+			path: `$.metadata.labels.*`,
+			exp: []position{
+				{"$['metadata']['labels']['app']", 6, 10, "web"},
+				{"$['metadata']['labels']['tier']", 7, 11, "frontend"},
+			},
+		},
+		{
+			test: "alias",
+			path: `$.spec.selector.tier`,
+			exp:  []position{{"$['spec']['selector']['tier']", 7, 11, "frontend"}},
+		},
+		{
+			test: "descendant",
+			path: `$..containerPort`,
+			exp: []position{
+				{"$['spec']['containers'][0]['ports'][0]['containerPort']", 13, 26, "80"},
+				{"$['spec']['containers'][1]['ports'][0]['containerPort']", 17, 26, "9901"},
+			},
+		},
+		{
+			test: "filter_string",
+			path: `$.spec.containers[?@.image == 'nginx:latest'].name`,
+			exp:  []position{{"$['spec']['containers'][0]['name']", 10, 13, "app"}},
+		},
+		{
+			test: "filter_number",
+			path: `$.spec.containers[?@.ports[0].containerPort > 1000].name`,
+			exp:  []position{{"$['spec']['containers'][1]['name']", 14, 13, "sidecar"}},
+		},
+		{
+			test: "filter_bool",
+			path: `$.spec[?@ == true]`,
+			exp:  []position{{"$['spec']['enabled']", 20, 12, "true"}},
+		},
+		{
+			test: "function",
+			path: `$.spec[?length(@) == 2]`,
+			exp: []position{
+				{"$['spec']['containers']", 10, 5, ""},
+				{"$['spec']['selector']", 5, 11, ""},
+			},
+		},
+		{
+			test: "no_match",
+			path: `$.spec.volumes[*]`,
+			exp:  []position{},
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			t.Parallel()
+			res := Select(jsonpath.MustParse(tc.path), &root)
+			got := make([]position, len(res))
+			for i, n := range res {
+				got[i] = position{n.Path.String(), n.Line(), n.Column(), n.Node.Value}
+			}
+			assert.Equal(t, tc.exp, got)
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Nil(Value(nil))
+	a.Nil(Value(&yaml.Node{Kind: yaml.DocumentNode}))
+	a.Nil(Value(&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "nope"}))
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("[1, 2.5, x, null, {a: b}]"), &root))
+	arr, ok := Value(&root).(jsonpath.Array)
+	a.True(ok)
+	a.Equal(5, arr.Len())
+	for i, exp := range []any{1, 2.5, "x", nil} {
+		v, ok := arr.Get(i)
+		a.True(ok)
+		a.Equal(exp, v)
+	}
+	_, ok = arr.Get(5)
+	a.False(ok)
+
+	v, _ := arr.Get(4)
+	obj, ok := v.(jsonpath.Object)
+	a.True(ok)
+	a.Equal(1, obj.Len())
+	v, ok = obj.Get("a")
+	a.True(ok)
+	a.Equal("b", v)
+	_, ok = obj.Get("b")
+	a.False(ok)
+
+	// Stop iteration early.
+	for range obj.Iterate() {
+		break
+	}
+	for range arr.Iterate() {
+		break
+	}
+}